@@ -0,0 +1,56 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	applyLockKey            = "apply_changes"
+	defaultApplyLockTimeout = 10 * time.Minute
+)
+
+// applyLock is held cluster-wide while pending changes are applied
+type applyLock interface {
+	Acquire() error
+	Release() error
+}
+
+// newApplyLock creates the Redis lock guarding the applies of pending changes, replaced in tests
+var newApplyLock = func(expiration time.Duration) applyLock {
+	return common.NewDistributedLock(applyLockKey, expiration)
+}
+
+// applyLockTimeout returns how long an apply may hold the lock before it expires on its own
+func applyLockTimeout() time.Duration {
+	if d := common.ReadConfig(func(c *common.HubConfig) time.Duration { return c.ApplyLockTimeout }); d > 0 {
+		return d
+	}
+	return defaultApplyLockTimeout
+}
+
+// lockApply takes the apply lock so only one apply runs in the cluster at a time. When the lock is
+// unavailable it writes the response, 409 while another apply is in progress, and returns a nil lock.
+func lockApply(c echo.Context) (applyLock, error) {
+	lock := newApplyLock(applyLockTimeout())
+	if err := lock.Acquire(); err != nil {
+		if errors.Is(err, common.ErrLockHeld) {
+			return nil, c.JSON(http.StatusConflict, map[string]string{"error": "apply in progress: another apply of pending changes is running, retry when it completes"})
+		}
+		logger.Error("Failed to acquire apply lock", "error", err)
+		return nil, c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "failed to acquire apply lock: " + err.Error()})
+	}
+	return lock, nil
+}
+
+// unlockApply releases the apply lock, which otherwise expires after applyLockTimeout
+func unlockApply(lock applyLock) {
+	if err := lock.Release(); err != nil {
+		logger.Warn("Failed to release apply lock, it expires on its own", "error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// memoryLocks mimics Redis SETNX for the apply lock shared by every node of a test cluster
+type memoryLocks struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+type memoryLock struct {
+	store    *memoryLocks
+	acquired bool
+}
+
+func (l *memoryLock) Acquire() error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	if l.store.held[applyLockKey] {
+		return common.ErrLockHeld
+	}
+	l.store.held[applyLockKey] = true
+	l.acquired = true
+	return nil
+}
+
+func (l *memoryLock) Release() error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	if l.acquired {
+		delete(l.store.held, applyLockKey)
+		l.acquired = false
+	}
+	return nil
+}
+
+func applyChanges(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/apply-changes", nil)
+	rec := httptest.NewRecorder()
+	if err := ApplyAllChanges(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatalf("ApplyAllChanges: %v", err)
+	}
+	return rec
+}
+
+func TestConcurrentApplyRejected(t *testing.T) {
+	store := &memoryLocks{held: make(map[string]bool)}
+	prev := newApplyLock
+	newApplyLock = func(time.Duration) applyLock { return &memoryLock{store: store} }
+	defer func() { newApplyLock = prev }()
+
+	// An apply running on another node holds the lock
+	running := newApplyLock(applyLockTimeout())
+	if err := running.Acquire(); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	rec := applyChanges(t)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "apply in progress") {
+		t.Fatalf("expected the second apply to be rejected, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if err := running.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if rec := applyChanges(t); rec.Code != http.StatusOK {
+		t.Fatalf("expected the apply to run once the lock is released, got %d %s", rec.Code, rec.Body.String())
+	}
+	if store.held[applyLockKey] {
+		t.Fatal("expected the lock to be released when the apply completes")
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -142,6 +144,16 @@ func leaderConfig(c echo.Context) error {
 	})
 }
 
+// reloadHubConfig re-reads config.yaml on this node like SIGHUP, returning which changes were applied
+// and which need a restart
+func reloadHubConfig(c echo.Context) error {
+	result, err := common.ReloadHubConfig()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
 func downloadConfig(c echo.Context) error {
 	configRoot := common.Config.ConfigRoot
 	if configRoot == "" {
@@ -488,6 +500,103 @@ func getClusterSystemMetrics(c echo.Context) error {
 	}
 }
 
+// ClusterNodeView is the resource usage and limits of one cluster node
+type ClusterNodeView struct {
+	NodeID          string    `json:"node_id"`
+	IsLeader        bool      `json:"is_leader"`
+	CPUPercent      float64   `json:"cpu_percent"`
+	MemoryUsedMB    float64   `json:"memory_used_mb"`
+	MemoryPercent   float64   `json:"memory_percent"`
+	GoroutineCount  int       `json:"goroutine_count"`
+	RunningProjects int       `json:"running_projects"`
+	CPUCores        int       `json:"cpu_cores"`
+	PoolMinSize     int       `json:"pool_min_size"`
+	PoolMaxSize     int       `json:"pool_max_size"`
+	LastSeen        time.Time `json:"last_seen"`
+}
+
+// buildClusterNodesView turns the metrics synced to the leader into a node list sorted by node id
+func buildClusterNodesView(metrics map[string]*common.SystemMetrics, leaderID string) []ClusterNodeView {
+	nodes := make([]ClusterNodeView, 0, len(metrics))
+	for nodeID, m := range metrics {
+		if m == nil {
+			continue
+		}
+		nodes = append(nodes, ClusterNodeView{
+			NodeID:          nodeID,
+			IsLeader:        nodeID == leaderID,
+			CPUPercent:      m.CPUPercent,
+			MemoryUsedMB:    m.MemoryUsedMB,
+			MemoryPercent:   m.MemoryPercent,
+			GoroutineCount:  m.GoroutineCount,
+			RunningProjects: m.RunningProjects,
+			CPUCores:        m.CPUCores,
+			PoolMinSize:     m.PoolMinSize,
+			PoolMaxSize:     m.PoolMaxSize,
+			LastSeen:        m.Timestamp,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].NodeID < nodes[j].NodeID
+	})
+	return nodes
+}
+
+// simulateClusterFailure injects a failure on this node to test cluster recovery: heartbeat_loss on
+// a follower, leader_relinquish on the leader. It requires allow_failure_simulation in config.yaml.
+func simulateClusterFailure(c echo.Context) error {
+	var req struct {
+		Mode     string `json:"mode"`
+		Duration string `json:"duration"` // e.g. 30s, the default
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	duration := 30 * time.Second
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid duration: " + err.Error()})
+		}
+		duration = d
+	}
+
+	sim, err := cluster.SimulateFailure(req.Mode, duration)
+	if errors.Is(err, cluster.ErrFailureSimulationDisabled) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	logger.Warn("Cluster failure simulation started", "mode", sim.Mode, "until", sim.Until)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"simulation": sim,
+	})
+}
+
+// getClusterNodes returns per-node resource usage, running projects and worker pool limits
+func getClusterNodes(c echo.Context) error {
+	if !common.IsCurrentNodeLeader() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Cluster node view is only available from leader nodes",
+		})
+	}
+
+	if common.GlobalClusterSystemManager == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Cluster system manager not initialized",
+		})
+	}
+
+	nodes := buildClusterNodesView(common.GlobalClusterSystemManager.GetAllMetrics(), common.GetNodeID())
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"nodes":     nodes,
+		"total":     len(nodes),
+		"timestamp": time.Now(),
+	})
+}
+
 // getClusterSystemStats returns cluster system manager statistics
 func getClusterSystemStats(c echo.Context) error {
 	// Only provide cluster system stats from leader nodes
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"testing"
+	"time"
+)
+
+func TestBuildClusterNodesView(t *testing.T) {
+	now := time.Now()
+	metrics := map[string]*common.SystemMetrics{
+		"10.0.0.2": {NodeID: "10.0.0.2", CPUPercent: 80, MemoryUsedMB: 2048, RunningProjects: 5, CPUCores: 8, PoolMinSize: 4, PoolMaxSize: 32, Timestamp: now},
+		"10.0.0.1": {NodeID: "10.0.0.1", CPUPercent: 10, MemoryUsedMB: 512, RunningProjects: 1, CPUCores: 4, PoolMinSize: 4, PoolMaxSize: 16, Timestamp: now},
+		"10.0.0.3": nil,
+	}
+
+	nodes := buildClusterNodesView(metrics, "10.0.0.1")
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(nodes))
+	}
+	if nodes[0].NodeID != "10.0.0.1" || !nodes[0].IsLeader {
+		t.Fatalf("expected leader 10.0.0.1 first, got %+v", nodes[0])
+	}
+	follower := nodes[1]
+	if follower.IsLeader || follower.RunningProjects != 5 || follower.CPUCores != 8 || follower.PoolMaxSize != 32 || follower.CPUPercent != 80 {
+		t.Fatalf("unexpected follower view: %+v", follower)
+	}
+	if !follower.LastSeen.Equal(now) {
+		t.Fatalf("expected last_seen %v, got %v", now, follower.LastSeen)
+	}
+}
+
+func TestClusterSystemManagerKeepsNodeLimits(t *testing.T) {
+	csm := common.NewClusterSystemManager()
+	defer csm.Stop()
+
+	csm.AddSystemMetrics(&common.SystemMetrics{NodeID: "n1", RunningProjects: 3, PoolMinSize: 4, PoolMaxSize: 16})
+	got := csm.GetNodeMetrics("n1")
+	if got == nil || got.RunningProjects != 3 || got.PoolMaxSize != 16 {
+		t.Fatalf("node limits lost in metrics copy: %+v", got)
+	}
+	if all := csm.GetAllMetrics(); all["n1"].PoolMinSize != 4 {
+		t.Fatalf("node limits lost in GetAllMetrics: %+v", all["n1"])
+	}
+}
@@ -260,6 +260,9 @@ func getProjects(c echo.Context) error {
 				"total":    len(inputList) + len(outputList) + len(rulesetList),
 			},
 		}
+		if usage, ok := proj.BudgetStats(); ok {
+			projectData["usage"] = usage
+		}
 
 		// Include path information
 		if proj.Config != nil && proj.Config.Path != "" {
@@ -301,6 +304,10 @@ func getProjects(c echo.Context) error {
 			result = append(result, projectData)
 		}
 	}
+	result, err := applyComponentLabels(c, "project", result)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, result)
 }
 
@@ -340,6 +347,12 @@ func getProject(c echo.Context) error {
 		"path":              formalPath,
 		"status_changed_at": p.StatusChangedAt,
 	}
+	if usage, ok := p.BudgetStats(); ok {
+		response["usage"] = usage
+	}
+	if p.Status == common.StatusRunning {
+		response["unrouted_events"] = p.UnroutedEvents()
+	}
 	if err == nil && len(sampleData) > 0 {
 		response["sample_data"] = sampleData
 		response["data_source"] = dataSource
@@ -347,12 +360,29 @@ func getProject(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// componentSampleCounts returns the number of retained samples per sampler name, read once for a
+// whole component list. ok is false when sampling is not available on this node.
+func componentSampleCounts() (map[string]int64, bool) {
+	rsm := common.GetRedisSampleManager()
+	if rsm == nil {
+		return nil, false
+	}
+	counts, err := rsm.CountAllSamples()
+	if err != nil {
+		return nil, false
+	}
+	return counts, true
+}
+
 func getRulesets(c echo.Context) error {
 	rulesets := make([]map[string]interface{}, 0)
 
 	// Create a map to track processed IDs
 	processedIDs := make(map[string]bool)
 
+	// Retained sample counts of every component, read in one scan
+	sampleCounts, sampleCountsOK := componentSampleCounts()
+
 	// Helper function to find which projects use a ruleset
 	findProjectsUsingRuleset := func(rulesetId string) []string {
 		projects := make([]string, 0)
@@ -429,10 +459,25 @@ func getRulesets(c echo.Context) error {
 			"status":           string(r.Status),
 		}
 
+		// Include current retained sample count (leader only)
+		if sampleCountsOK {
+			rulesetData["sample_count"] = sampleCounts[strings.ToLower("ruleset."+r.RulesetID)]
+		}
+
 		// Include error information if component has errors
 		if r.Status == common.StatusError && r.Err != nil {
 			rulesetData["errorMessage"] = r.Err.Error()
 		}
+		if len(r.RuleErrors) > 0 {
+			rulesetData["rule_errors"] = r.RuleErrors
+		}
+		if groups := r.RuleGroups(); len(groups) > 0 {
+			groupCounts := make(map[string]int, len(groups))
+			for g, ids := range groups {
+				groupCounts[g] = len(ids)
+			}
+			rulesetData["rule_groups"] = groupCounts
+		}
 
 		// Include path information if available
 		if r.Path != "" {
@@ -464,6 +509,10 @@ func getRulesets(c echo.Context) error {
 			rulesets = append(rulesets, rulesetData)
 		}
 	}
+	rulesets, err := applyComponentLabels(c, "ruleset", rulesets)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, rulesets)
 }
 
@@ -480,6 +529,11 @@ func getRuleset(c echo.Context) error {
 			"raw":  r_raw,
 			"path": tempPath,
 		}
+		if parsed, err := rules_engine.ParseRuleset([]byte(r_raw)); err == nil {
+			if groups := parsed.RuleGroups(); len(groups) > 0 {
+				response["rule_groups"] = groups
+			}
+		}
 		if err == nil && len(sampleData) > 0 {
 			response["sample_data"] = sampleData
 			response["data_source"] = dataSource
@@ -498,6 +552,16 @@ func getRuleset(c echo.Context) error {
 			"raw":  r.RawConfig,
 			"path": formalPath,
 		}
+		if len(r.RuleErrors) > 0 {
+			response["rule_errors"] = r.RuleErrors
+		}
+		if groups := r.RuleGroups(); len(groups) > 0 {
+			response["rule_groups"] = groups
+			response["group_hits"] = r.GetGroupHits()
+		}
+		if r.HasDecodeAppends() {
+			response["decode_failures"] = project.RulesetDecodeFailures(r.RulesetID)
+		}
 		if err == nil && len(sampleData) > 0 {
 			response["sample_data"] = sampleData
 			response["data_source"] = dataSource
@@ -507,12 +571,72 @@ func getRuleset(c echo.Context) error {
 	return c.JSON(http.StatusNotFound, map[string]string{"error": "ruleset not found"})
 }
 
+// getCompiledRuleset returns how the engine interpreted a ruleset. The running version is used
+// unless temp=true asks for the pending temporary version, which is built on the fly.
+func getCompiledRuleset(c echo.Context) error {
+	r, release, status, msg := introspectedRuleset(c)
+	if r == nil {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	defer release()
+	return c.JSON(http.StatusOK, r.Compiled())
+}
+
+// getRulesetFields returns the event fields a ruleset reads, writes and deletes
+func getRulesetFields(c echo.Context) error {
+	r, release, status, msg := introspectedRuleset(c)
+	if r == nil {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	defer release()
+	return c.JSON(http.StatusOK, r.Fields())
+}
+
+// getRulesetCost returns the estimated per-event cost of a ruleset and of each of its rules
+func getRulesetCost(c echo.Context) error {
+	r, release, status, msg := introspectedRuleset(c)
+	if r == nil {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	defer release()
+	return c.JSON(http.StatusOK, r.Cost())
+}
+
+// introspectedRuleset resolves the ruleset of an introspection endpoint: the loaded one, or with
+// ?temp=true the pending version built on the fly. Callers run release once done with the ruleset,
+// it closes the caches of a ruleset built on the fly. On failure it returns the HTTP status and message.
+func introspectedRuleset(c echo.Context) (*rules_engine.Ruleset, func(), int, string) {
+	id := c.Param("id")
+
+	if c.QueryParam("temp") == "true" {
+		raw, ok := project.GetRulesetNew(id)
+		if !ok {
+			return nil, nil, http.StatusNotFound, "temporary ruleset not found"
+		}
+		r, err := rules_engine.NewRuleset("", raw, id)
+		if err != nil {
+			return nil, nil, http.StatusUnprocessableEntity, err.Error()
+		}
+		r.RulesetID = id
+		return r, r.CloseCaches, 0, ""
+	}
+
+	r, exists := project.GetRuleset(id)
+	if !exists {
+		return nil, nil, http.StatusNotFound, "ruleset not found"
+	}
+	return r, func() {}, 0, ""
+}
+
 func getInputs(c echo.Context) error {
 	inputs := make([]map[string]interface{}, 0)
 
 	// Create a map to track processed IDs
 	processedIDs := make(map[string]bool)
 
+	// Retained sample counts of every component, read in one scan
+	sampleCounts, sampleCountsOK := componentSampleCounts()
+
 	// Helper function to find which projects use an input
 	findProjectsUsingInput := func(inputId string) []string {
 		projects := make([]string, 0)
@@ -570,8 +694,13 @@ func getInputs(c echo.Context) error {
 			"status":           string(in.Status),
 		}
 
+		// Include current retained sample count (leader only)
+		if sampleCountsOK {
+			inputData["sample_count"] = sampleCounts[strings.ToLower("input."+in.Id)]
+		}
+
 		// Include error information if component has errors
-		if in.Status == common.StatusError && in.Err != nil {
+		if (in.Status == common.StatusError || in.Status == common.StatusDegraded) && in.Err != nil {
 			inputData["errorMessage"] = in.Err.Error()
 		}
 
@@ -603,6 +732,10 @@ func getInputs(c echo.Context) error {
 			inputs = append(inputs, inputData)
 		}
 	}
+	inputs, err := applyComponentLabels(c, "input", inputs)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, inputs)
 }
 
@@ -645,9 +778,21 @@ func getInput(c echo.Context) error {
 		// Get sample data for this input (for MCP interface optimization)
 		sampleData, dataSource, err := getSampleDataForInput(id)
 		response := map[string]interface{}{
-			"id":   in.Id,
-			"raw":  in.Config.RawConfig,
-			"path": formalPath,
+			"id":           in.Id,
+			"raw":          in.Config.RawConfig,
+			"path":         formalPath,
+			"sample_rate":  in.GetSampleRateStats(),
+			"buffer":       in.GetBufferStats(),
+			"field_limits": in.GetFieldLimitStats(),
+		}
+		if parseErrors := in.GetParseErrorStats(); parseErrors != nil {
+			response["parse_errors"] = parseErrors
+		}
+		if dropIf := in.GetDropIfStats(); dropIf != nil {
+			response["drop_if"] = dropIf
+		}
+		if delivery := in.GetDeliveryStats(); delivery != nil {
+			response["delivery"] = delivery
 		}
 		if err == nil && len(sampleData) > 0 {
 			response["sample_data"] = sampleData
@@ -748,7 +893,7 @@ func getPlugins(c echo.Context) error {
 		return "Plugin function"
 	}
 
-	for _, p := range plugin.Plugins {
+	for _, p := range plugin.GetAllPlugins() {
 		// Filter by plugin type if specified
 		var currentPluginType string
 		if p.Type == plugin.LOCAL_PLUGIN {
@@ -765,7 +910,7 @@ func getPlugins(c echo.Context) error {
 		}
 
 		// Check if there is a temporary file
-		tempRaw, hasTemp := plugin.PluginsNew[p.Name]
+		tempRaw, hasTemp := plugin.GetPluginNew(p.Name)
 
 		// Skip temporary plugins if not requested
 		if !includeTemp && hasTemp {
@@ -821,7 +966,7 @@ func getPlugins(c echo.Context) error {
 
 	// Add plugins that only exist in temporary files (only if including temp and detailed)
 	if includeTemp && detailed {
-		for name, content := range plugin.PluginsNew {
+		for name, content := range plugin.GetAllPluginsNew() {
 			if !processedNames[name] {
 				// Try to determine return type for temporary plugins
 				returnType := "unknown"
@@ -857,6 +1002,10 @@ func getPlugins(c echo.Context) error {
 		}
 	}
 
+	plugins, err := applyComponentLabels(c, "plugin", plugins)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, plugins)
 }
 
@@ -869,7 +1018,7 @@ func getPlugin(c echo.Context) error {
 	}
 
 	// First check if there is a temporary file
-	p_raw, ok := plugin.PluginsNew[id]
+	p_raw, ok := plugin.GetPluginNew(id)
 	if ok {
 		tempPath, _ := GetComponentPath("plugin", id, true)
 		return c.JSON(http.StatusOK, map[string]interface{}{
@@ -882,7 +1031,7 @@ func getPlugin(c echo.Context) error {
 	}
 
 	// If no temporary file, check formal file
-	if p, ok := plugin.Plugins[id]; ok {
+	if p, ok := plugin.GetPlugin(id); ok {
 		var pluginType string
 		var rawContent string
 
@@ -951,6 +1100,9 @@ func getOutputs(c echo.Context) error {
 	// Create a map to track processed IDs
 	processedIDs := make(map[string]bool)
 
+	// Retained sample counts of every component, read in one scan
+	sampleCounts, sampleCountsOK := componentSampleCounts()
+
 	// Helper function to find which projects use an output
 	findProjectsUsingOutput := func(outputId string) []string {
 		projects := make([]string, 0)
@@ -996,6 +1148,11 @@ func getOutputs(c echo.Context) error {
 			"status":           string(out.Status),
 		}
 
+		// Include current retained sample count (leader only)
+		if sampleCountsOK {
+			outputData["sample_count"] = sampleCounts[strings.ToLower("output."+out.Id)]
+		}
+
 		// Include error information if component has errors
 		if out.Status == common.StatusError && out.Err != nil {
 			outputData["errorMessage"] = out.Err.Error()
@@ -1034,6 +1191,10 @@ func getOutputs(c echo.Context) error {
 			outputs = append(outputs, outputData)
 		}
 	}
+	outputs, err := applyComponentLabels(c, "output", outputs)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, outputs)
 }
 
@@ -1075,6 +1236,17 @@ func getOutput(c echo.Context) error {
 			response["sample_data"] = sampleData
 			response["data_source"] = dataSource
 		}
+		if out.Config != nil && out.Config.Dedup != nil {
+			// Suppressed duplicates across the running instances of this output
+			var suppressed uint64
+			project.ForEachProject(func(_ string, proj *project.Project) bool {
+				if o, ok := proj.Outputs[id]; ok {
+					suppressed += o.GetDedupSuppressed()
+				}
+				return true
+			})
+			response["dedup_suppressed"] = suppressed
+		}
 		return c.JSON(http.StatusOK, response)
 	}
 	return c.JSON(http.StatusNotFound, map[string]string{"error": "output not found"})
@@ -1283,6 +1455,11 @@ func deleteComponent(componentType string, c echo.Context) error {
 			}
 		}
 
+		// A deleted component has nothing to roll back
+		if err := os.Remove(componentPath + backupSuffix); err != nil && !os.IsNotExist(err) {
+			logger.Warn("failed to delete component backup", "path", componentPath+backupSuffix, "error", err)
+		}
+
 		// Publish deletion instruction regardless of which files existed
 		// This ensures followers are notified of the deletion
 		if componentType == "project" {
@@ -1309,6 +1486,10 @@ func deleteComponent(componentType string, c echo.Context) error {
 			}
 		}
 
+		if err := DeleteComponentLabels(componentType, id); err != nil {
+			logger.Warn("Failed to delete component labels", "type", componentType, "id", id, "error", err)
+		}
+
 		// Record successful deletion operation
 		RecordComponentDelete(componentType, id, "success", "", affectedProjects)
 	}
@@ -1387,9 +1568,9 @@ func updateComponent(componentType string, c echo.Context) error {
 	switch componentType {
 	case "plugin":
 		// Check if plugin exists in memory
-		if p, ok := plugin.Plugins[id]; ok {
+		if p, ok := plugin.GetPlugin(id); ok {
 			originalContent = string(p.Payload)
-		} else if p_raw, ok := plugin.PluginsNew[id]; ok {
+		} else if p_raw, ok := plugin.GetPluginNew(id); ok {
 			originalContent = p_raw
 		} else {
 			// Fall back to file system check
@@ -1669,6 +1850,9 @@ func verifyComponent(c echo.Context) error {
 	case "input":
 		err := input.Verify("", req.Raw)
 		result := createSimpleResult(err)
+		if result.IsValid && common.StrictYAMLMode() == common.StrictYAMLWarn {
+			result.Warnings = append(result.Warnings, unknownFieldWarnings(input.UnknownFields(req.Raw))...)
+		}
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"valid":    result.IsValid,
 			"errors":   result.Errors,
@@ -1677,6 +1861,9 @@ func verifyComponent(c echo.Context) error {
 	case "output":
 		err := output.Verify("", req.Raw)
 		result := createSimpleResult(err)
+		if result.IsValid && common.StrictYAMLMode() == common.StrictYAMLWarn {
+			result.Warnings = append(result.Warnings, unknownFieldWarnings(output.UnknownFields(req.Raw))...)
+		}
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"valid":    result.IsValid,
 			"errors":   result.Errors,
@@ -1715,6 +1902,19 @@ func verifyComponent(c echo.Context) error {
 	}
 }
 
+// unknownFieldWarnings turns the unknown keys of a config into validation warnings
+func unknownFieldWarnings(fields []common.UnknownYAMLField) []rules_engine.ValidationWarning {
+	warnings := make([]rules_engine.ValidationWarning, 0, len(fields))
+	for _, f := range fields {
+		warnings = append(warnings, rules_engine.ValidationWarning{
+			Line:    f.Line,
+			Message: fmt.Sprintf("Unknown field '%s'", f.Field),
+			Detail:  "The key is ignored, check it for typos",
+		})
+	}
+	return warnings
+}
+
 // Cancel upgrade functions - delete both memory and temp files
 func cancelProjectUpgrade(c echo.Context) error {
 	id := c.Param("id")
@@ -1801,6 +2001,33 @@ func GetSamplerData(c echo.Context) error {
 
 	logger.Info("GetSamplerData request", "componentName", componentName, "nodeSequence", nodeSequence)
 
+	// Optional paging: limit/offset over the matched samples and a since time filter (RFC3339)
+	paged := false
+	limit, offset := 0, 0
+	var since time.Time
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+			paged = true
+		}
+	}
+	if offsetStr := c.QueryParam("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
+			offset = parsed
+			paged = true
+		}
+	}
+	if sinceStr := c.QueryParam("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Invalid since parameter format: %v", err),
+			})
+		}
+		since = parsed
+		paged = true
+	}
+
 	if componentName == "" || nodeSequence == "" {
 		logger.Error("Missing required parameters for GetSamplerData", "componentName", componentName, "nodeSequence", nodeSequence)
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -1891,7 +2118,7 @@ func GetSamplerData(c echo.Context) error {
 	}
 
 	// Collect samples from all samplers that contain this component in their flow path
-	result := make(map[string][]interface{})
+	matchedSamples := make(map[string][]common.SampleData)
 
 	// Get potential sampler names based on component types and IDs
 	samplerNames := []string{}
@@ -1951,16 +2178,7 @@ func GetSamplerData(c echo.Context) error {
 						"nodeSequence", nodeSequence,
 						"sampleCount", len(sampleData))
 
-					// Convert SampleData to interface{} for JSON response
-					convertedSamples := make([]interface{}, len(sampleData))
-					for i, sample := range sampleData {
-						convertedSamples[i] = map[string]interface{}{
-							"data":                  sample.Data,
-							"timestamp":             sample.Timestamp.Format(time.RFC3339),
-							"project_node_sequence": sample.ProjectNodeSequence,
-						}
-					}
-					result[projectNodeSequence] = convertedSamples
+					matchedSamples[projectNodeSequence] = sampleData
 					totalSamples += len(sampleData)
 				}
 			}
@@ -1976,10 +2194,34 @@ func GetSamplerData(c echo.Context) error {
 			"message", "This is normal if the component hasn't processed any data yet")
 	}
 
+	// Page through the matched samples when asked, newest first
+	var pagination map[string]interface{}
+	if paged {
+		matchedSamples, totalSamples = pageSamples(matchedSamples, since, offset, limit)
+		pagination = map[string]interface{}{"total": totalSamples, "offset": offset, "limit": limit}
+	}
+
+	// Convert SampleData to interface{} for JSON response
+	result := make(map[string][]interface{}, len(matchedSamples))
+	for projectNodeSequence, sampleData := range matchedSamples {
+		convertedSamples := make([]interface{}, len(sampleData))
+		for i, sample := range sampleData {
+			convertedSamples[i] = map[string]interface{}{
+				"data":                  sample.Data,
+				"timestamp":             sample.Timestamp.Format(time.RFC3339),
+				"project_node_sequence": sample.ProjectNodeSequence,
+			}
+		}
+		result[projectNodeSequence] = convertedSamples
+	}
+
 	// Initialize response structure
 	response := map[string]interface{}{
 		componentName: result,
 	}
+	if pagination != nil {
+		response["pagination"] = pagination
+	}
 
 	logger.Info("GetSamplerData response ready",
 		"componentName", componentName,
@@ -1990,6 +2232,45 @@ func GetSamplerData(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// pageSamples keeps the samples taken after since (all when zero), orders them newest first and
+// returns the page of at most limit samples (all when 0) starting at offset, grouped by flow path
+// again, along with the number of samples before paging
+func pageSamples(samples map[string][]common.SampleData, since time.Time, offset, limit int) (map[string][]common.SampleData, int) {
+	type flowSample struct {
+		flow   string
+		sample common.SampleData
+	}
+	all := make([]flowSample, 0)
+	for flow, list := range samples {
+		for _, sample := range list {
+			if since.IsZero() || sample.Timestamp.After(since) {
+				all = append(all, flowSample{flow: flow, sample: sample})
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if !a.sample.Timestamp.Equal(b.sample.Timestamp) {
+			return a.sample.Timestamp.After(b.sample.Timestamp)
+		}
+		return a.flow < b.flow
+	})
+
+	total := len(all)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	page := make(map[string][]common.SampleData)
+	for _, fs := range all[offset:end] {
+		page[fs.flow] = append(page[fs.flow], fs.sample)
+	}
+	return page, total
+}
+
 // GetRulesetFields extracts field keys from sample data for intelligent completion in ruleset editing
 func GetRulesetFields(c echo.Context) error {
 	componentId := c.Param("id")
@@ -2126,7 +2407,7 @@ func GetPluginParameters(c echo.Context) error {
 	}
 
 	// Check if plugin exists in memory
-	if p, exists := plugin.Plugins[id]; exists {
+	if p, exists := plugin.GetPlugin(id); exists {
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"success":    true,
 			"plugin":     id,
@@ -2136,7 +2417,7 @@ func GetPluginParameters(c echo.Context) error {
 	}
 
 	// Check if plugin exists in temporary files
-	if tempContent, exists := plugin.PluginsNew[id]; exists {
+	if tempContent, exists := plugin.GetPluginNew(id); exists {
 		// Create a temporary plugin instance to parse parameters
 		tempPlugin := &plugin.Plugin{
 			Name:       id,
@@ -2308,14 +2589,15 @@ func searchComponentsConfig(c echo.Context) error {
 	// Component types to search
 	componentTypes := []string{"input", "output", "ruleset", "project", "plugin"}
 	var allResults []SearchResult
+	maskers := searchMaskRegexps()
 
 	for _, componentType := range componentTypes {
 		// Search formal files
-		results := searchInComponentType(componentType, query, false)
+		results := searchInComponentType(componentType, query, false, maskers)
 		allResults = append(allResults, results...)
 
 		// Search temporary files
-		tempResults := searchInComponentType(componentType, query, true)
+		tempResults := searchInComponentType(componentType, query, true, maskers)
 		allResults = append(allResults, tempResults...)
 	}
 
@@ -2340,7 +2622,7 @@ func searchComponentsConfig(c echo.Context) error {
 }
 
 // searchInComponentType searches within a specific component type
-func searchInComponentType(componentType, query string, isTemporary bool) []SearchResult {
+func searchInComponentType(componentType, query string, isTemporary bool, maskers []*regexp.Regexp) []SearchResult {
 	var results []SearchResult
 	var componentMap map[string]string
 
@@ -2356,7 +2638,7 @@ func searchInComponentType(componentType, query string, isTemporary bool) []Sear
 		case "project":
 			componentMap = project.GetAllProjectsNew()
 		case "plugin":
-			componentMap = plugin.PluginsNew
+			componentMap = plugin.GetAllPluginsNew()
 		}
 	} else {
 		// For formal files, we need to read from the actual component instances
@@ -2383,7 +2665,7 @@ func searchInComponentType(componentType, query string, isTemporary bool) []Sear
 				return true
 			})
 		case "plugin":
-			for _, comp := range plugin.Plugins {
+			for _, comp := range plugin.GetAllPlugins() {
 				if comp.Type == plugin.YAEGI_PLUGIN {
 					componentMap[comp.Name] = string(comp.Payload)
 				} else if comp.Type == plugin.LOCAL_PLUGIN {
@@ -2398,7 +2680,7 @@ func searchInComponentType(componentType, query string, isTemporary bool) []Sear
 
 	// Search within each component's content
 	for componentID, content := range componentMap {
-		matches := searchInContent(content, query)
+		matches := searchInContent(content, query, maskers)
 		for _, match := range matches {
 			filePath, _ := GetComponentPath(componentType, componentID, isTemporary)
 			fileName := filepath.Base(filePath)
@@ -2425,8 +2707,9 @@ type ContentMatch struct {
 	LineContent string
 }
 
-// searchInContent searches for query within content and returns matches
-func searchInContent(content, query string) []ContentMatch {
+// searchInContent searches for query within content and returns matches with secret values masked.
+// Lines that only match inside a masked value are left out so a search cannot confirm a secret.
+func searchInContent(content, query string, maskers []*regexp.Regexp) []ContentMatch {
 	var matches []ContentMatch
 
 	if content == "" || query == "" {
@@ -2438,12 +2721,17 @@ func searchInContent(content, query string) []ContentMatch {
 
 	for lineNum, line := range lines {
 		lineLower := strings.ToLower(line)
-		if strings.Contains(lineLower, queryLower) {
-			matches = append(matches, ContentMatch{
-				LineNumber:  lineNum + 1, // 1-based line numbers
-				LineContent: strings.TrimSpace(line),
-			})
+		if !strings.Contains(lineLower, queryLower) {
+			continue
 		}
+		masked := maskSecrets(strings.TrimSpace(line), maskers)
+		if !strings.Contains(strings.ToLower(masked), queryLower) {
+			continue
+		}
+		matches = append(matches, ContentMatch{
+			LineNumber:  lineNum + 1, // 1-based line numbers
+			LineContent: masked,
+		})
 	}
 
 	return matches
@@ -2826,7 +3114,7 @@ func GetBatchPluginParameters(c echo.Context) error {
 		result := make(map[string]interface{})
 
 		// Get parameters from loaded plugins only (no temporary plugins)
-		for id, p := range plugin.Plugins {
+		for id, p := range plugin.GetAllPlugins() {
 			result[id] = map[string]interface{}{
 				"parameters": p.Parameters,
 				"returnType": p.ReturnType,
@@ -2847,7 +3135,7 @@ func GetBatchPluginParameters(c echo.Context) error {
 		}
 
 		// Check if plugin exists in memory (loaded plugins only)
-		if p, exists := plugin.Plugins[id]; exists {
+		if p, exists := plugin.GetPlugin(id); exists {
 			result[id] = map[string]interface{}{
 				"parameters": p.Parameters,
 				"returnType": p.ReturnType,
@@ -2961,6 +3249,52 @@ func getPluginUsage(c echo.Context) error {
 	})
 }
 
+// getPluginDependencies returns the packages a yaegi plugin imports and the other plugins it references.
+// The pending version is analyzed when one exists.
+func getPluginDependencies(c echo.Context) error {
+	pluginID := c.Param("id")
+	if pluginID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "plugin ID is required"})
+	}
+
+	source, isTemp := plugin.GetPluginNew(pluginID)
+	if !isTemp {
+		p, exists := plugin.GetPlugin(pluginID)
+		if !exists {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "plugin not found"})
+		}
+		if p.Type == plugin.LOCAL_PLUGIN {
+			// Built-in plugins are compiled into the hub and have no source to analyze
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"plugin_id": pluginID,
+				"type":      "local",
+				"is_temp":   false,
+			})
+		}
+		source = string(p.Payload)
+	}
+
+	knownPlugins := make([]string, 0)
+	for name := range plugin.GetAllPlugins() {
+		knownPlugins = append(knownPlugins, name)
+	}
+	for name := range plugin.GetAllPluginsNew() {
+		knownPlugins = append(knownPlugins, name)
+	}
+
+	deps, err := plugin.ExtractDependencies(source, pluginID, knownPlugins)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"plugin_id":    pluginID,
+		"type":         "yaegi",
+		"is_temp":      isTemp,
+		"dependencies": deps,
+	})
+}
+
 // PluginUsageInfo represents plugin usage information
 type PluginUsageInfo struct {
 	UsedByRulesets []RulesetUsageInfo `json:"used_by_rulesets"`
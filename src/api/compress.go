@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bytes"
+	"compress/zlib"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// compressMinLength keeps small responses uncompressed, the compression overhead is not worth it for them
+const compressMinLength = 1024
+
+const deflateScheme = "deflate"
+
+// deflateWriters reuses zlib writers, each one holds several hundred KB of state. HTTP deflate is
+// the zlib format (RFC 1950), not a raw deflate stream.
+var deflateWriters = sync.Pool{
+	New: func() interface{} {
+		return zlib.NewWriter(nil)
+	},
+}
+
+// gzipMiddleware compresses responses honoring Accept-Encoding; streaming endpoints are skipped
+func gzipMiddleware() echo.MiddlewareFunc {
+	return middleware.GzipWithConfig(middleware.GzipConfig{
+		Skipper:   skipCompression,
+		MinLength: compressMinLength,
+	})
+}
+
+// deflateMiddleware compresses responses with deflate for clients accepting it but not gzip, which
+// gzipMiddleware serves; streaming endpoints are skipped
+func deflateMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			accept := c.Request().Header.Get(echo.HeaderAcceptEncoding)
+			if skipCompression(c) || strings.Contains(accept, "gzip") || !strings.Contains(accept, deflateScheme) {
+				return next(c)
+			}
+			res := c.Response()
+			dw := &deflateResponseWriter{ResponseWriter: res.Writer}
+			res.Writer = dw
+			defer func() {
+				dw.finish()
+				res.Writer = dw.ResponseWriter
+			}()
+			return next(c)
+		}
+	}
+}
+
+// deflateResponseWriter buffers the response until it reaches compressMinLength, then writes it
+// deflated; shorter responses are written as they are
+type deflateResponseWriter struct {
+	http.ResponseWriter
+	fw     *zlib.Writer // set once compressing
+	buffer bytes.Buffer
+	code   int
+}
+
+func (w *deflateResponseWriter) WriteHeader(code int) {
+	w.Header().Del(echo.HeaderContentLength)
+	// Delay writing the header until we know whether the response is compressed
+	w.code = code
+}
+
+func (w *deflateResponseWriter) Write(b []byte) (int, error) {
+	if w.Header().Get(echo.HeaderContentType) == "" {
+		w.Header().Set(echo.HeaderContentType, http.DetectContentType(b))
+	}
+	if w.fw != nil {
+		return w.fw.Write(b)
+	}
+	n, err := w.buffer.Write(b)
+	if w.buffer.Len() >= compressMinLength {
+		if err := w.startCompressing(); err != nil {
+			return 0, err
+		}
+	}
+	return n, err
+}
+
+// startCompressing writes the header with Content-Encoding and the buffered body to the zlib writer
+func (w *deflateResponseWriter) startCompressing() error {
+	w.Header().Set(echo.HeaderContentEncoding, deflateScheme)
+	if !strings.Contains(w.Header().Get(echo.HeaderVary), echo.HeaderAcceptEncoding) {
+		w.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	}
+	w.Header().Del(echo.HeaderContentLength)
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	w.fw = deflateWriters.Get().(*zlib.Writer)
+	w.fw.Reset(w.ResponseWriter)
+	_, err := w.fw.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+	return err
+}
+
+func (w *deflateResponseWriter) Flush() {
+	// Compress from the first flush on, as more data may follow
+	if w.fw == nil {
+		_ = w.startCompressing()
+	}
+	_ = w.fw.Flush()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *deflateResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish completes the response once the handler returned: it closes the deflate stream, or writes
+// a short response uncompressed
+func (w *deflateResponseWriter) finish() {
+	if w.fw != nil {
+		_ = w.fw.Close()
+		deflateWriters.Put(w.fw)
+		return
+	}
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	if w.buffer.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buffer.Bytes())
+	}
+}
+
+// skipCompression returns true for SSE and WebSocket requests, which must not be buffered
+func skipCompression(c echo.Context) bool {
+	req := c.Request()
+	if strings.Contains(req.Header.Get(echo.HeaderAccept), "text/event-stream") {
+		return true
+	}
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return true
+	}
+	// GET /mcp opens an MCP SSE session and /error-logs/stream tails the error logs regardless of the Accept header
+	return req.Method == http.MethodGet && (req.URL.Path == "/mcp" || req.URL.Path == "/mcp/ws" || req.URL.Path == "/error-logs/stream")
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newCompressTestServer() *echo.Echo {
+	e := echo.New()
+	e.Use(gzipMiddleware())
+	e.Use(deflateMiddleware())
+	e.GET("/rulesets", func(c echo.Context) error {
+		items := make([]map[string]string, 0, 500)
+		for i := 0; i < 500; i++ {
+			items = append(items, map[string]string{"id": "ruleset", "raw": strings.Repeat("x", 32)})
+		}
+		return c.JSON(http.StatusOK, items)
+	})
+	e.GET("/mcp", func(c echo.Context) error {
+		return c.String(http.StatusOK, strings.Repeat("data: x\n\n", 500))
+	})
+	return e
+}
+
+func TestGzipCompressesLargeJSON(t *testing.T) {
+	e := newCompressTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/rulesets", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(echo.HeaderContentEncoding); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	var items []map[string]string
+	if err := json.Unmarshal(body, &items); err != nil || len(items) != 500 {
+		t.Fatalf("decoded %d items, err %v", len(items), err)
+	}
+}
+
+func TestDeflateCompressesWithoutGzip(t *testing.T) {
+	e := newCompressTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/rulesets", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "deflate")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(echo.HeaderContentEncoding); got != "deflate" {
+		t.Fatalf("Content-Encoding = %q, want deflate", got)
+	}
+	zr, err := zlib.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("open zlib body: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read deflate body: %v", err)
+	}
+	var items []map[string]string
+	if err := json.Unmarshal(body, &items); err != nil || len(items) != 500 {
+		t.Fatalf("decoded %d items, err %v", len(items), err)
+	}
+
+	// gzip is preferred when both are accepted
+	req = httptest.NewRequest(http.MethodGet, "/rulesets", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "deflate, gzip")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if got := rec.Header().Get(echo.HeaderContentEncoding); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+}
+
+func TestGzipSkippedWithoutHeaderAndForSSE(t *testing.T) {
+	e := newCompressTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/rulesets", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if got := rec.Header().Get(echo.HeaderContentEncoding); got != "" {
+		t.Fatalf("Content-Encoding = %q without Accept-Encoding", got)
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		req = httptest.NewRequest(http.MethodGet, "/mcp", nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, encoding)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if got := rec.Header().Get(echo.HeaderContentEncoding); got != "" {
+			t.Fatalf("Content-Encoding = %q for SSE endpoint", got)
+		}
+	}
+}
+
+func TestDeflateKeepsSmallResponsesUncompressed(t *testing.T) {
+	e := echo.New()
+	e.Use(deflateMiddleware())
+	e.GET("/ping", func(c echo.Context) error {
+		return c.JSON(http.StatusAccepted, map[string]string{"status": "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "deflate")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if got := rec.Header().Get(echo.HeaderContentEncoding); got != "" || rec.Code != http.StatusAccepted {
+		t.Fatalf("expected an uncompressed 202, got %d with Content-Encoding %q", rec.Code, got)
+	}
+	if !strings.Contains(rec.Body.String(), `"status":"ok"`) {
+		t.Fatalf("unexpected body %q", rec.Body.String())
+	}
+}
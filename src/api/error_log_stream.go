@@ -0,0 +1,117 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxErrorLogStreamBatch caps the entries of one streamed event, the rest of a burst is counted as dropped
+	maxErrorLogStreamBatch = 100
+	errorLogStreamBuffer   = 1024
+)
+
+// Intervals of the error log live tail, replaced in tests
+var (
+	errorLogStreamFlush     = 500 * time.Millisecond
+	errorLogStreamHeartbeat = 15 * time.Second
+)
+
+// errorLogStreamFilter selects the entries of a live tail, empty fields match everything
+type errorLogStreamFilter struct {
+	levels map[string]bool
+	source string
+	nodeID string
+}
+
+func newErrorLogStreamFilter(c echo.Context) errorLogStreamFilter {
+	f := errorLogStreamFilter{source: c.QueryParam("source"), nodeID: c.QueryParam("node_id")}
+	if f.source == "all" {
+		f.source = ""
+	}
+	if f.nodeID == "all" {
+		f.nodeID = ""
+	}
+	for _, level := range strings.Split(c.QueryParam("level"), ",") {
+		if level = strings.ToUpper(strings.TrimSpace(level)); level != "" {
+			if f.levels == nil {
+				f.levels = make(map[string]bool)
+			}
+			f.levels[level] = true
+		}
+	}
+	return f
+}
+
+func (f errorLogStreamFilter) match(entry common.ErrorLogEntry) bool {
+	if f.levels != nil && !f.levels[strings.ToUpper(entry.Level)] {
+		return false
+	}
+	if f.source != "" && entry.Source != f.source {
+		return false
+	}
+	return f.nodeID == "" || entry.NodeID == f.nodeID
+}
+
+// streamErrorLogs tails the error logs of the cluster as server-sent events. Entries arriving
+// together are coalesced into one "logs" event every errorLogStreamFlush, and a comment line is sent
+// every errorLogStreamHeartbeat so proxies keep the connection open. Filters: level (comma separated),
+// source (hub or plugin) and node_id.
+func streamErrorLogs(c echo.Context) error {
+	filter := newErrorLogStreamFilter(c)
+	entries, unsubscribe := common.GlobalErrorLogTail.Subscribe(errorLogStreamBuffer)
+	defer unsubscribe()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	w.Flush()
+
+	flush := time.NewTicker(errorLogStreamFlush)
+	defer flush.Stop()
+	heartbeat := time.NewTicker(errorLogStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	batch := make([]common.ErrorLogEntry, 0, maxErrorLogStreamBatch)
+	dropped := 0
+	done := c.Request().Context().Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		case entry := <-entries:
+			if !filter.match(entry) {
+				continue
+			}
+			if len(batch) >= maxErrorLogStreamBatch {
+				dropped++
+				continue
+			}
+			batch = append(batch, entry)
+		case <-flush.C:
+			if len(batch) == 0 && dropped == 0 {
+				continue
+			}
+			data, err := json.Marshal(map[string]interface{}{"logs": batch, "dropped": dropped})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "event: logs\ndata: %s\n\n", data)
+			w.Flush()
+			batch = batch[:0]
+			dropped = 0
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			w.Flush()
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestStreamErrorLogsDeliversWrittenErrors(t *testing.T) {
+	prevFlush := errorLogStreamFlush
+	errorLogStreamFlush = 10 * time.Millisecond
+	defer func() { errorLogStreamFlush = prevFlush }()
+
+	e := echo.New()
+	e.GET("/error-logs/stream", streamErrorLogs)
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/error-logs/stream?level=error&source=hub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get(echo.HeaderContentType); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for common.GlobalErrorLogTail.Subscribers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stream client did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	common.GlobalErrorLogTail.Publish(common.ErrorLogEntry{Level: "INFO", Source: "hub", Message: "filtered by level"})
+	common.GlobalErrorLogTail.Publish(common.ErrorLogEntry{Level: "ERROR", Source: "plugin", Message: "filtered by source"})
+	common.GlobalErrorLogTail.Publish(common.ErrorLogEntry{Level: "ERROR", Source: "hub", Message: "kafka produce failed"})
+
+	lines := make(chan string, 64)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream closed before the error was delivered")
+			}
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var event struct {
+				Logs []common.ErrorLogEntry `json:"logs"`
+			}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				t.Fatalf("invalid event %q: %v", line, err)
+			}
+			if len(event.Logs) != 1 || event.Logs[0].Message != "kafka produce failed" {
+				t.Fatalf("expected only the matching error, got %+v", event.Logs)
+			}
+			return
+		case <-time.After(5 * time.Second):
+			t.Fatal("the written error was not delivered")
+		}
+	}
+}
@@ -14,6 +14,8 @@ import (
 
 // ErrorLogEntry represents a single error log entry
 type ErrorLogEntry struct {
+	ID          string    `json:"id,omitempty"`
+	HasContext  bool      `json:"has_context,omitempty"` // event snapshot available via /error-logs/:id/context
 	Timestamp   time.Time `json:"timestamp"`
 	Level       string    `json:"level"`
 	Message     string    `json:"message"`
@@ -78,6 +80,8 @@ func getUnifiedErrorLogs(filter ErrorLogFilter) ([]ErrorLogEntry, int, error) {
 	var apiLogs []ErrorLogEntry
 	for _, log := range logs {
 		apiLog := ErrorLogEntry{
+			ID:          log.ID,
+			HasContext:  log.HasContext,
 			Timestamp:   log.Timestamp,
 			Level:       log.Level,
 			Message:     log.Message,
@@ -176,6 +180,25 @@ func getErrorLogNodes(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// getErrorLogContext handles GET /error-logs/:id/context - returns the redacted event snapshot
+// captured with an error log (e.g. the message an output failed to write)
+func getErrorLogContext(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "error log id is required"})
+	}
+
+	snapshot, err := common.GetErrorLogContext(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"id":      id,
+		"context": snapshot,
+	})
+}
+
 // getClusterErrorLogs - DEPRECATED: Use getErrorLogs instead
 // This endpoint is kept for backward compatibility but redirects to the unified endpoint
 func getClusterErrorLogs(c echo.Context) error {
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"AgentSmith-HUB/rules_engine"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultFieldPreflightSamples = 200
+	maxFieldPreflightSamples     = 2000
+)
+
+// FieldPreflightWarning is a field a rule reads that never appears in the sampled input data.
+// Required is set when the rule declares the field in <requires>, so it is skipped for these events.
+type FieldPreflightWarning struct {
+	RulesetID string `json:"ruleset_id"`
+	RuleID    string `json:"rule_id"`
+	Field     string `json:"field"`
+	Required  bool   `json:"required,omitempty"`
+}
+
+// observedFields collects the dotted path of every key present in the events, prefixes included.
+// Array elements are merged under "*" like RulesetFields reports iterator reads.
+func observedFields(events []map[string]interface{}) map[string]bool {
+	seen := make(map[string]bool)
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				path := strings.ReplaceAll(k, ".", `\.`)
+				if prefix != "" {
+					path = prefix + "." + path
+				}
+				seen[path] = true
+				walk(path, child)
+			}
+		case []interface{}:
+			for _, item := range val {
+				walk(prefix+".*", item)
+			}
+		}
+	}
+	for _, event := range events {
+		walk("", event)
+	}
+	return seen
+}
+
+// computeFieldPreflight flags the fields each rule reads that are absent from every event and not
+// written by any of the rulesets themselves
+func computeFieldPreflight(rulesets []*rules_engine.Ruleset, events []map[string]interface{}) []FieldPreflightWarning {
+	seen := observedFields(events)
+	for _, rs := range rulesets {
+		for _, f := range rs.Fields().Writes {
+			seen[f] = true
+		}
+	}
+
+	warnings := make([]FieldPreflightWarning, 0)
+	for _, rs := range rulesets {
+		for i := range rs.Rules {
+			rule := &rs.Rules[i]
+			fields := rule.Fields()
+			required := make(map[string]bool, len(fields.Requires))
+			for _, f := range fields.Requires {
+				required[f] = true
+			}
+			for _, f := range fields.Reads {
+				if seen[f] || strings.HasPrefix(f, "_hub") {
+					continue
+				}
+				warnings = append(warnings, FieldPreflightWarning{RulesetID: rs.RulesetID, RuleID: rule.ID, Field: f, Required: required[f]})
+			}
+		}
+	}
+	return warnings
+}
+
+// recentInputSamples returns up to limit sampled events of the given inputs. Inputs sample events
+// before grok, rename and static_fields, so each sample gets the transforms of its input first.
+func recentInputSamples(inputIDs []string, limit int) []map[string]interface{} {
+	events := make([]map[string]interface{}, 0)
+	for _, id := range inputIDs {
+		sampler := common.GetSampler("input." + id)
+		if sampler == nil {
+			continue
+		}
+		in, _ := project.GetInput(id)
+		for _, samples := range sampler.GetSamples() {
+			for _, sample := range samples {
+				if data, ok := sample.Data.(map[string]interface{}); ok {
+					if in != nil {
+						data = in.TransformSample(data)
+					}
+					events = append(events, data)
+					if len(events) >= limit {
+						return events
+					}
+				}
+			}
+		}
+	}
+	return events
+}
+
+// validateProjectFields checks the fields read by the rulesets of a project against the fields
+// observed in the samples of its inputs, catching typos such as src_ip vs source_ip
+func validateProjectFields(c echo.Context) error {
+	id := c.Param("id")
+
+	var req struct {
+		Limit int                      `json:"limit,omitempty"`
+		Data  []map[string]interface{} `json:"data,omitempty"` // Optional events used instead of samples
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultFieldPreflightSamples
+	}
+	if req.Limit > maxFieldPreflightSamples {
+		req.Limit = maxFieldPreflightSamples
+	}
+
+	proj, exists := project.GetProject(id)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "project not found"})
+	}
+
+	inputSet := make(map[string]bool)
+	rulesetSet := make(map[string]bool)
+	for _, node := range proj.FlowNodes {
+		if node.FromType == "INPUT" {
+			inputSet[node.FromID] = true
+		}
+		if node.FromType == "RULESET" {
+			rulesetSet[node.FromID] = true
+		}
+		if node.ToType == "RULESET" {
+			rulesetSet[node.ToID] = true
+		}
+	}
+	inputIDs := make([]string, 0, len(inputSet))
+	for inputID := range inputSet {
+		inputIDs = append(inputIDs, inputID)
+	}
+	sort.Strings(inputIDs)
+
+	rulesets := make([]*rules_engine.Ruleset, 0, len(rulesetSet))
+	missing := make([]string, 0)
+	for rulesetID := range rulesetSet {
+		rs, ok := project.GetRuleset(rulesetID)
+		if !ok || rs.Err != nil {
+			missing = append(missing, rulesetID)
+			continue
+		}
+		rulesets = append(rulesets, rs)
+	}
+	sort.Slice(rulesets, func(i, j int) bool { return rulesets[i].RulesetID < rulesets[j].RulesetID })
+	sort.Strings(missing)
+
+	events := req.Data
+	source := "request"
+	if len(events) == 0 {
+		events = recentInputSamples(inputIDs, req.Limit)
+		source = "samples"
+	}
+
+	resp := map[string]interface{}{
+		"project_id":       id,
+		"inputs":           inputIDs,
+		"source":           source,
+		"evaluated":        len(events),
+		"skipped_rulesets": missing,
+		"warnings":         []FieldPreflightWarning{},
+	}
+	if len(events) == 0 {
+		resp["message"] = "no input samples available, start the project or pass events in data"
+		return c.JSON(http.StatusOK, resp)
+	}
+	resp["warnings"] = computeFieldPreflight(rulesets, events)
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"AgentSmith-HUB/rules_engine"
+	"reflect"
+	"testing"
+)
+
+func TestComputeFieldPreflight(t *testing.T) {
+	enrich, err := rules_engine.NewRuleset("", `<root type="DETECTION">
+  <rule id="r_tag">
+    <check type="NOTNULL" field="source_ip"></check>
+    <append field="geo.country">CN</append>
+  </rule>
+</root>`, "enrich")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	detect, err := rules_engine.NewRuleset("", `<root type="DETECTION">
+  <rule id="r_typo">
+    <check type="EQU" field="src_ip">10.0.0.1</check>
+  </rule>
+  <rule id="r_ok">
+    <checklist condition="a and b">
+      <check id="a" type="EQU" field="geo.country">CN</check>
+      <check id="b" type="EQU" field="http.method">GET</check>
+    </checklist>
+  </rule>
+</root>`, "detect")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+
+	events := []map[string]interface{}{
+		{"source_ip": "10.0.0.1", "http": map[string]interface{}{"method": "GET"}},
+		{"source_ip": "10.0.0.2"},
+	}
+
+	got := computeFieldPreflight([]*rules_engine.Ruleset{enrich, detect}, events)
+	want := []FieldPreflightWarning{{RulesetID: "detect", RuleID: "r_typo", Field: "src_ip"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("warnings = %+v, want %+v", got, want)
+	}
+}
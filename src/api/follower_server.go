@@ -76,6 +76,10 @@ func ServerStartFollower(listenAddr string) error {
 	// Expose auth config
 	e.GET("/auth/config", getAuthConfig)
 
+	// Each node exposes the write latency of its own outputs
+	e.GET("/metrics", getPrometheusMetrics)
+	e.GET("/output-latency", getOutputLatency)
+
 	e.GET("/follower-status", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"role":    "follower",
@@ -90,6 +94,7 @@ func ServerStartFollower(listenAddr string) error {
 	// Read-only project endpoints
 	auth.GET("/projects", getProjects)
 	auth.GET("/projects/:id", getProject)
+	auth.GET("/projects/:id/operations", getProjectOperations)
 	auth.GET("/project-error/:id", getProjectError)
 	auth.GET("/project-inputs/:id", getProjectInputs)
 	auth.GET("/project-components/:id", getProjectComponents)
@@ -98,10 +103,13 @@ func ServerStartFollower(listenAddr string) error {
 	// Read-only component endpoints
 	auth.GET("/rulesets", getRulesets)
 	auth.GET("/rulesets/:id", getRuleset)
+	auth.GET("/rulesets/:id/dead-letters", getRulesetDeadLetters)
 	auth.GET("/inputs", getInputs)
 	auth.GET("/inputs/:id", getInput)
+	auth.GET("/inputs/:id/dead-letters", getInputDeadLetters)
 	auth.GET("/outputs", getOutputs)
 	auth.GET("/outputs/:id", getOutput)
+	auth.GET("/outputs/:id/dead-letters", getOutputDeadLetters)
 	auth.GET("/plugins", getPlugins)
 	auth.GET("/plugins/:id", getPlugin)
 	auth.GET("/available-plugins", getPlugins) // Use same handler with different default params
@@ -111,6 +119,7 @@ func ServerStartFollower(listenAddr string) error {
 	auth.GET("/plugin-parameters/:id", GetPluginParameters)
 	auth.GET("/plugin-parameters", GetBatchPluginParameters)
 	auth.GET("/plugins/:id/usage", getPluginUsage)
+	auth.GET("/plugins/:id/dependencies", getPluginDependencies)
 
 	// Read-only configuration endpoints
 	auth.GET("/samplers/data", GetSamplerData)
@@ -121,6 +130,9 @@ func ServerStartFollower(listenAddr string) error {
 	auth.GET("/component-usage/:type/:id", GetComponentUsage)
 	auth.GET("/search-components", searchComponentsConfig)
 
+	// Failure injection for resilience tests, affects only this node
+	auth.POST("/cluster/simulate-failure", simulateClusterFailure)
+
 	// Block all write operations with helpful error messages
 	blockWriteOperation := func(c echo.Context) error {
 		return c.JSON(http.StatusForbidden, map[string]interface{}{
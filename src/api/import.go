@@ -0,0 +1,520 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/logger"
+	"AgentSmith-HUB/output"
+	"AgentSmith-HUB/plugin"
+	"AgentSmith-HUB/project"
+	"AgentSmith-HUB/rules_engine"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	maxImportFileSize = 5 << 20
+	importTimeout     = 60 * time.Second
+)
+
+// importRequest selects what to import: a file or bundle manifest from an HTTP(S) URL, or a file in
+// a Git repository
+type importRequest struct {
+	URL    string `json:"url"`
+	Repo   string `json:"repo"`
+	Ref    string `json:"ref"`  // Branch or tag, the default branch when empty
+	Path   string `json:"path"` // File in the repository
+	Type   string `json:"type"` // Component type, inferred from the file extension when empty
+	ID     string `json:"id"`   // Component ID, the file name without extension when empty
+	Bundle bool   `json:"bundle"`
+}
+
+// importManifest is a bundle of components. Paths are relative to the manifest, depends_on lists
+// "type/id" of components the item needs, either in the bundle or already on the hub.
+type importManifest struct {
+	Components []importItem `yaml:"components"`
+}
+
+type importItem struct {
+	Type      string   `yaml:"type"`
+	ID        string   `yaml:"id"`
+	Path      string   `yaml:"path"`
+	DependsOn []string `yaml:"depends_on"`
+
+	content string
+}
+
+// ImportItemResult is the outcome of one imported component
+type ImportItemResult struct {
+	Type     string   `json:"type"`
+	ID       string   `json:"id"`
+	Source   string   `json:"source"`
+	Valid    bool     `json:"valid"`
+	Staged   bool     `json:"staged"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// importSource reads files relative to where the import points
+type importSource interface {
+	read(ref string) ([]byte, string, error)
+}
+
+type httpImportSource struct {
+	ctx    context.Context
+	base   *url.URL
+	client *http.Client
+}
+
+func (s *httpImportSource) read(ref string) ([]byte, string, error) {
+	rel, err := url.Parse(ref)
+	if err != nil {
+		return nil, ref, fmt.Errorf("invalid path: %w", err)
+	}
+	target := s.base.ResolveReference(rel)
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, target.String(), fmt.Errorf("only http and https URLs can be imported")
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, target.String(), err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, target.String(), err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, target.String(), fmt.Errorf("fetch failed: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportFileSize+1))
+	if err != nil {
+		return nil, target.String(), err
+	}
+	if len(data) > maxImportFileSize {
+		return nil, target.String(), fmt.Errorf("file is larger than %d bytes", maxImportFileSize)
+	}
+	return data, target.String(), nil
+}
+
+// dirImportSource reads from a Git checkout, refs are relative to dir and may not leave the checkout,
+// neither directly nor through a symlink committed to the repository
+type dirImportSource struct {
+	root string
+	dir  string
+}
+
+// insideDir reports whether p is dir or below it
+func insideDir(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (s *dirImportSource) read(ref string) ([]byte, string, error) {
+	p := filepath.Join(s.root, s.dir, filepath.FromSlash(ref))
+	if !insideDir(s.root, p) {
+		return nil, ref, fmt.Errorf("path leaves the repository")
+	}
+	rel, _ := filepath.Rel(s.root, p)
+	source := filepath.ToSlash(rel)
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return nil, source, err
+	}
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return nil, source, fmt.Errorf("file not found in repository")
+	}
+	if !insideDir(root, resolved) {
+		return nil, source, fmt.Errorf("path leaves the repository through a symlink")
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, source, fmt.Errorf("file not found in repository")
+	}
+	if !info.Mode().IsRegular() {
+		return nil, source, fmt.Errorf("not a regular file")
+	}
+	if info.Size() > maxImportFileSize {
+		return nil, source, fmt.Errorf("file is larger than %d bytes", maxImportFileSize)
+	}
+	data, err := os.ReadFile(resolved)
+	return data, source, err
+}
+
+// importRepoSchemes are the protocols a repository can be cloned over, local paths are not allowed
+var importRepoSchemes = []string{"https", "http", "ssh", "git"}
+
+// verifyImportRepo checks that repo is a URL with one of importRepoSchemes and a host
+func verifyImportRepo(repo string) error {
+	if strings.HasPrefix(repo, "-") {
+		return fmt.Errorf("invalid repository")
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return fmt.Errorf("invalid repository URL: %w", err)
+	}
+	if !slices.Contains(importRepoSchemes, u.Scheme) {
+		return fmt.Errorf("repository must be a URL with one of the schemes %s", strings.Join(importRepoSchemes, ", "))
+	}
+	if u.Host == "" {
+		return fmt.Errorf("repository URL has no host")
+	}
+	return nil
+}
+
+// cloneImportRepo makes a shallow clone of repo into a temporary directory
+func cloneImportRepo(ctx context.Context, repo, ref string) (string, error) {
+	if err := verifyImportRepo(repo); err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref")
+	}
+	dir, err := os.MkdirTemp("", "hub-import-")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"clone", "--depth", "1", "--quiet"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", repo, dir)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	// Redirects and submodules are held to the same protocols
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL="+strings.Join(importRepoSchemes, ":"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("git clone failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return dir, nil
+}
+
+// importExtTypes maps file extensions to component types; YAML components need an explicit type
+var importExtTypes = map[string]string{".xml": "ruleset", ".go": "plugin"}
+
+func inferImportItem(ref, componentType, id string) importItem {
+	base := path.Base(ref)
+	ext := path.Ext(base)
+	if componentType == "" {
+		componentType = importExtTypes[ext]
+	}
+	if id == "" {
+		id = strings.TrimSuffix(base, ext)
+	}
+	return importItem{Type: strings.TrimSuffix(componentType, "s"), ID: strings.TrimSpace(id), Path: ref}
+}
+
+// importComponents fetches, validates and stages components as temporary files. Nothing is staged
+// unless every item is valid.
+func importComponents(c echo.Context) error {
+	var req importRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if (req.URL == "") == (req.Repo == "") {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "either url or repo is required"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), importTimeout)
+	defer cancel()
+
+	var source importSource
+	var entry, entryRef string
+	if req.URL != "" {
+		base, err := url.Parse(req.URL)
+		if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "url must be an http or https URL"})
+		}
+		source = &httpImportSource{ctx: ctx, base: base, client: &http.Client{}}
+		entry = base.Path
+	} else {
+		if req.Path == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "path is required when importing from a repository"})
+		}
+		if err := verifyImportRepo(req.Repo); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		dir, err := cloneImportRepo(ctx, req.Repo, req.Ref)
+		if err != nil {
+			return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+		}
+		defer os.RemoveAll(dir)
+		entry = path.Clean("/" + req.Path)[1:]
+		entryRef = entry
+		source = &dirImportSource{root: dir}
+	}
+
+	data, entrySource, err := source.read(entryRef)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": fmt.Sprintf("failed to fetch %s: %v", entrySource, err)})
+	}
+
+	var items []importItem
+	var itemSource importSource = source
+	if req.Bundle {
+		var manifest importManifest
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid bundle manifest: " + err.Error()})
+		}
+		if len(manifest.Components) == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "bundle manifest lists no components"})
+		}
+		if ds, ok := source.(*dirImportSource); ok {
+			// Manifest paths are relative to the manifest's directory
+			itemSource = &dirImportSource{root: ds.root, dir: path.Dir(entry)}
+		}
+		for _, m := range manifest.Components {
+			item := inferImportItem(m.Path, m.Type, m.ID)
+			item.DependsOn = m.DependsOn
+			items = append(items, item)
+		}
+	} else {
+		item := inferImportItem(entry, req.Type, req.ID)
+		item.content = string(data)
+		item.Path = ""
+		items = append(items, item)
+	}
+
+	results, ok := validateImport(items, itemSource, entrySource)
+	if !ok {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":   "import validation failed, nothing was staged",
+			"results": results,
+		})
+	}
+
+	staged := 0
+	for i := range items {
+		if err := stageImportItem(&items[i]); err != nil {
+			results[i].Errors = append(results[i].Errors, err.Error())
+			continue
+		}
+		results[i].Staged = true
+		staged++
+	}
+
+	logger.Info("Components imported", "source", entrySource, "staged", staged, "total", len(items))
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("Staged %d/%d components as temporary files", staged, len(items)),
+		"results": results,
+	})
+}
+
+// validateImport reads and validates every item and checks the dependencies between them. It
+// reports whether all items are valid; results are indexed like items.
+func validateImport(items []importItem, source importSource, entrySource string) ([]ImportItemResult, bool) {
+	results := make([]ImportItemResult, len(items))
+	inBundle := make(map[string]int, len(items))
+	allValid := true
+	fail := func(i int, format string, args ...interface{}) {
+		results[i].Errors = append(results[i].Errors, fmt.Sprintf(format, args...))
+		allValid = false
+	}
+
+	for i := range items {
+		item := &items[i]
+		results[i] = ImportItemResult{Type: item.Type, ID: item.ID, Source: entrySource}
+		if !isImportType(item.Type) {
+			fail(i, "unknown component type '%s', set type explicitly", item.Type)
+			continue
+		}
+		if item.ID == "" || strings.ContainsAny(item.ID, `/\`) || strings.Contains(item.ID, "..") {
+			fail(i, "invalid component id '%s'", item.ID)
+			continue
+		}
+		key := item.Type + "/" + item.ID
+		if _, dup := inBundle[key]; dup {
+			fail(i, "component %s is listed more than once", key)
+			continue
+		}
+		inBundle[key] = i
+
+		if item.Path != "" {
+			data, src, err := source.read(item.Path)
+			results[i].Source = src
+			if err != nil {
+				fail(i, "failed to fetch %s: %v", item.Path, err)
+				continue
+			}
+			item.content = string(data)
+		}
+		if strings.TrimSpace(item.content) == "" {
+			fail(i, "component content is empty")
+		}
+	}
+
+	for i := range items {
+		for _, dep := range items[i].DependsOn {
+			depType, depID, found := strings.Cut(strings.TrimSpace(dep), "/")
+			if !found || !isImportType(depType) {
+				fail(i, "invalid dependency '%s', expected type/id", dep)
+				continue
+			}
+			if j, ok := inBundle[depType+"/"+depID]; ok {
+				if j == i {
+					fail(i, "component depends on itself")
+				}
+				continue
+			}
+			if _, exists := GetComponentPath(depType, depID, false); !exists {
+				fail(i, "dependency %s is neither in the bundle nor on the hub", dep)
+			}
+		}
+	}
+	if allValid {
+		if cycle := importDependencyCycle(items, inBundle); cycle != "" {
+			for i := range items {
+				fail(i, "dependency cycle: %s", cycle)
+			}
+		}
+	}
+
+	for i := range items {
+		if len(results[i].Errors) > 0 {
+			continue
+		}
+		errs, warnings := verifyImportItem(&items[i], inBundle)
+		results[i].Warnings = warnings
+		if len(errs) > 0 {
+			for _, e := range errs {
+				fail(i, "%s", e)
+			}
+			continue
+		}
+		results[i].Valid = true
+	}
+	return results, allValid
+}
+
+func isImportType(t string) bool {
+	switch t {
+	case "input", "output", "ruleset", "project", "plugin":
+		return true
+	}
+	return false
+}
+
+// importDependencyCycle returns a description of a depends_on cycle inside the bundle, if any
+func importDependencyCycle(items []importItem, inBundle map[string]int) string {
+	state := make([]int, len(items)) // 0 unvisited, 1 in progress, 2 done
+	var stack []string
+	var visit func(i int) string
+	visit = func(i int) string {
+		state[i] = 1
+		stack = append(stack, items[i].Type+"/"+items[i].ID)
+		for _, dep := range items[i].DependsOn {
+			j, ok := inBundle[strings.TrimSpace(dep)]
+			if !ok {
+				continue
+			}
+			if state[j] == 1 {
+				return strings.Join(append(stack, items[j].Type+"/"+items[j].ID), " -> ")
+			}
+			if state[j] == 0 {
+				if cycle := visit(j); cycle != "" {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = 2
+		return ""
+	}
+	for i := range items {
+		if state[i] == 0 {
+			if cycle := visit(i); cycle != "" {
+				return cycle
+			}
+		}
+	}
+	return ""
+}
+
+// verifyImportItem validates the content like verifyComponent does. A ruleset may use plugins from
+// the same bundle, which are not loaded yet, so those lookups are not counted as errors.
+func verifyImportItem(item *importItem, inBundle map[string]int) (errs []string, warnings []string) {
+	var err error
+	switch item.Type {
+	case "input":
+		err = input.Verify("", item.content)
+	case "output":
+		err = output.Verify("", item.content)
+	case "project":
+		err = project.Verify("", item.content)
+	case "plugin":
+		err = plugin.Verify("", item.content, item.ID)
+	case "ruleset":
+		result, verr := rules_engine.ValidateWithDetails("", item.content)
+		if verr != nil {
+			return []string{verr.Error()}, nil
+		}
+		for _, e := range result.Errors {
+			if isBundlePluginError(e, inBundle) {
+				continue
+			}
+			errs = append(errs, formatImportIssue(e.Line, e.Message, e.Detail))
+		}
+		for _, w := range result.Warnings {
+			warnings = append(warnings, formatImportIssue(w.Line, w.Message, w.Detail))
+		}
+		return errs, warnings
+	}
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	return nil, nil
+}
+
+func isBundlePluginError(e rules_engine.ValidationError, inBundle map[string]int) bool {
+	if e.Message != "Plugin not found" && e.Message != "Cannot reference temporary plugin, please save it first" {
+		return false
+	}
+	_, name, ok := strings.Cut(e.Detail, "Plugin: ")
+	if !ok {
+		return false
+	}
+	_, found := inBundle["plugin/"+strings.TrimSpace(name)]
+	return found
+}
+
+func formatImportIssue(line int, message, detail string) string {
+	s := message
+	if detail != "" {
+		s += ": " + detail
+	}
+	if line > 0 {
+		s = fmt.Sprintf("line %d: %s", line, s)
+	}
+	return s
+}
+
+// stageImportItem writes the item as a temporary component, the same pending state as an edit
+func stageImportItem(item *importItem) error {
+	_, formalExists := GetComponentPath(item.Type, item.ID, false)
+	tempPath, _ := GetComponentPath(item.Type, item.ID, true)
+	if err := WriteComponentFile(tempPath, item.content); err != nil {
+		return err
+	}
+	if common.IsCurrentNodeLeader() {
+		if formalExists {
+			common.RecordComponentUpdate(item.Type, item.ID, item.content, "success", "")
+		} else {
+			common.RecordComponentAdd(item.Type, item.ID, item.content, "success", "")
+		}
+	}
+	return nil
+}
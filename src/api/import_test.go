@@ -0,0 +1,128 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+const importedRuleset = `<root type="DETECTION" name="shared">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="action">login_failed</check>
+  </rule>
+</root>`
+
+func postImport(t *testing.T, body string) (int, map[string]interface{}) {
+	t.Helper()
+	e := echo.New()
+	e.POST("/import", importComponents)
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func TestImportRulesetFromURL(t *testing.T) {
+	prev := common.Config
+	root := t.TempDir()
+	common.Config = &common.HubConfig{ConfigRoot: root}
+	t.Cleanup(func() {
+		common.Config = prev
+		project.DeleteRulesetNew("shared_rules")
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rules/shared_rules.xml":
+			_, _ = w.Write([]byte(importedRuleset))
+		case "/rules/broken.xml":
+			_, _ = w.Write([]byte(`<root type="DETECTION"><rule id="r1"><check type="EQU">x</check>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	code, resp := postImport(t, `{"url":"`+srv.URL+`/rules/shared_rules.xml"}`)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body %v", code, resp)
+	}
+	results := resp["results"].([]interface{})
+	item := results[0].(map[string]interface{})
+	if len(results) != 1 || item["type"] != "ruleset" || item["id"] != "shared_rules" || item["staged"] != true {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	staged, err := os.ReadFile(path.Join(root, "ruleset", "shared_rules.xml.new"))
+	if err != nil || string(staged) != importedRuleset {
+		t.Fatalf("expected ruleset staged as temporary file, got %q (%v)", staged, err)
+	}
+	if _, ok := project.GetRulesetNew("shared_rules"); !ok {
+		t.Fatal("expected ruleset staged in memory")
+	}
+
+	code, resp = postImport(t, `{"url":"`+srv.URL+`/rules/broken.xml"}`)
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid ruleset: status = %d, body %v", code, resp)
+	}
+	if _, err := os.Stat(path.Join(root, "ruleset", "broken.xml.new")); !os.IsNotExist(err) {
+		t.Fatal("invalid ruleset must not be staged")
+	}
+
+	code, _ = postImport(t, `{"url":"`+srv.URL+`/rules/missing.xml"}`)
+	if code != http.StatusBadGateway {
+		t.Fatalf("missing file: status = %d", code)
+	}
+}
+
+func TestImportRejectsLocalRepositories(t *testing.T) {
+	for _, repo := range []string{"/srv/rules.git", "../rules", "file:///srv/rules.git", "ext::sh -c id", "git@github.com:org/rules.git", "https:///rules.git"} {
+		code, resp := postImport(t, `{"repo":"`+repo+`","path":"rules/shared_rules.xml"}`)
+		if code != http.StatusBadRequest {
+			t.Errorf("repo %q: status = %d, body %v", repo, code, resp)
+		}
+	}
+}
+
+func TestDirImportSourceRejectsEscapingSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.yaml"), []byte("token: x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "rules.xml"), []byte(importedRuleset), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.yaml"), filepath.Join(root, "leak.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "outside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("rules.xml", filepath.Join(root, "alias.xml")); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &dirImportSource{root: root}
+	for _, ref := range []string{"leak.yaml", "outside/secret.yaml", "../secret.yaml", "."} {
+		if _, _, err := src.read(ref); err == nil {
+			t.Errorf("%s: expected the read to be rejected", ref)
+		}
+	}
+	if data, _, err := src.read("alias.xml"); err != nil || string(data) != importedRuleset {
+		t.Fatalf("a symlink inside the checkout must be readable, got %q (%v)", data, err)
+	}
+}
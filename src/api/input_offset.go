@@ -0,0 +1,137 @@
+package api
+
+import (
+	"AgentSmith-HUB/cluster"
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/project"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// projectRealStates reads the runtime states a node reports for its projects, replaced in tests
+var projectRealStates = common.GetAllProjectRealStates
+
+// runningInputProjects returns the projects using the input that are not stopped on some node of the
+// cluster, read from the runtime states every node reports to Redis and from the local instances
+func runningInputProjects(id string) []string {
+	running := make(map[string]struct{})
+	using := make(map[string]struct{})
+	project.ForEachProject(func(projId string, proj *project.Project) bool {
+		if proj.CheckExist("INPUT", id) {
+			using[projId] = struct{}{}
+		}
+		// Project components are keyed by their sequence, e.g. INPUT.<id> or PROJECT.<p>.INPUT.<id>
+		for _, in := range proj.Inputs {
+			if in.Id == id && in.Status != common.StatusStopped && in.Status != common.StatusError {
+				running[projId] = struct{}{}
+				break
+			}
+		}
+		return true
+	})
+
+	nodes := []string{common.GetNodeID()}
+	if cluster.GlobalHeartbeatManager != nil {
+		for nodeID := range cluster.GlobalHeartbeatManager.GetNodes() {
+			nodes = append(nodes, nodeID)
+		}
+	}
+	for _, nodeID := range nodes {
+		states, _ := projectRealStates(nodeID)
+		for projId := range using {
+			state := common.Status(states[projId])
+			if state != "" && state != common.StatusStopped && state != common.StatusError {
+				running[projId] = struct{}{}
+			}
+		}
+	}
+
+	projects := make([]string, 0, len(running))
+	for projId := range running {
+		projects = append(projects, projId)
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// offsetResetTargetError checks that an offset reset can be scheduled for the input: it must be a
+// Kafka input and no project may be consuming from it
+func offsetResetTargetError(in *input.Input, runningProjects []string) error {
+	if !in.IsKafka() {
+		return fmt.Errorf("input %s is of type %s, offset reset only applies to Kafka inputs", in.Id, in.Type)
+	}
+	if len(runningProjects) > 0 {
+		return fmt.Errorf("input %s is running in projects %v, stop them before resetting offsets", in.Id, runningProjects)
+	}
+	return nil
+}
+
+// setInputOffsetReset schedules a consumer group offset reset for a Kafka input, applied when the
+// input next starts
+func setInputOffsetReset(c echo.Context) error {
+	id := c.Param("id")
+	in, exists := project.GetInput(id)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "input not found"})
+	}
+
+	var reset common.KafkaOffsetReset
+	if err := c.Bind(&reset); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	if err := reset.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if in.IsKafka() {
+		if _, err := in.SingleKafkaTopic(); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "offset reset is " + err.Error()})
+		}
+	}
+	if err := offsetResetTargetError(in, runningInputProjects(id)); err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+
+	reset.RequestedAt = time.Now()
+	if err := input.SetPendingOffsetReset(id, &reset); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store offset reset: " + err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"input_id": id,
+		"pending":  reset,
+		"message":  "offset reset will be applied on the next start of the input",
+	})
+}
+
+// getInputOffsetReset returns the offset reset pending for an input
+func getInputOffsetReset(c echo.Context) error {
+	id := c.Param("id")
+	reset, err := input.PendingOffsetReset(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if reset == nil {
+		return c.JSON(http.StatusOK, map[string]interface{}{"input_id": id, "pending": nil})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"input_id": id, "pending": reset})
+}
+
+// cancelInputOffsetReset drops the offset reset pending for an input
+func cancelInputOffsetReset(c echo.Context) error {
+	id := c.Param("id")
+	reset, err := input.PendingOffsetReset(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if reset == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no pending offset reset"})
+	}
+	if err := input.ClearPendingOffsetReset(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "pending offset reset cancelled"})
+}
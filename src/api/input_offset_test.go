@@ -0,0 +1,42 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/project"
+	"reflect"
+	"testing"
+)
+
+func TestRunningInputProjectsMatchesInstancesBySequence(t *testing.T) {
+	running := &input.Input{Id: "offset_in", Type: input.InputTypeKafka, Status: common.StatusRunning}
+	stopped := &input.Input{Id: "offset_in", Type: input.InputTypeKafka, Status: common.StatusStopped}
+	other := &input.Input{Id: "other_in", Type: input.InputTypeKafka, Status: common.StatusRunning}
+
+	// Project components are keyed by sequence, not by component ID
+	project.SetProject("offset_shared", &project.Project{Id: "offset_shared", Inputs: map[string]*input.Input{"INPUT.offset_in": running}})
+	project.SetProject("offset_scoped", &project.Project{Id: "offset_scoped", Inputs: map[string]*input.Input{"PROJECT.offset_scoped.INPUT.offset_in": running}})
+	project.SetProject("offset_idle", &project.Project{Id: "offset_idle", Inputs: map[string]*input.Input{"INPUT.offset_in": stopped}})
+	project.SetProject("offset_other", &project.Project{Id: "offset_other", Inputs: map[string]*input.Input{"INPUT.other_in": other}})
+	defer func() {
+		for _, id := range []string{"offset_shared", "offset_scoped", "offset_idle", "offset_other", "offset_remote"} {
+			project.DeleteProject(id)
+		}
+	}()
+
+	// Only the runtime states reported to Redis show the projects running on other nodes
+	origStates := projectRealStates
+	defer func() { projectRealStates = origStates }()
+	project.SetProject("offset_remote", &project.Project{Id: "offset_remote", BackUpFlowNodes: []project.FlowNode{{FromType: "INPUT", FromID: "offset_in"}}})
+	projectRealStates = func(nodeID string) (map[string]string, error) {
+		return map[string]string{"offset_remote": string(common.StatusRunning), "offset_other": string(common.StatusRunning)}, nil
+	}
+
+	got := runningInputProjects("offset_in")
+	if want := []string{"offset_remote", "offset_scoped", "offset_shared"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("runningInputProjects = %v, want %v", got, want)
+	}
+	if err := offsetResetTargetError(running, got); err == nil {
+		t.Fatal("expected a reset of a running input to be rejected")
+	}
+}
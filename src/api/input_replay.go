@@ -0,0 +1,264 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"AgentSmith-HUB/rules_engine"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultReplayLimit     = 1000
+	maxReplayOutputSamples = 10
+)
+
+// ReplayRulesetResult counts the replayed events a ruleset evaluated and matched: hit for
+// DETECTION, filtered for EXCLUDE
+type ReplayRulesetResult struct {
+	Evaluated int `json:"evaluated"`
+	Matched   int `json:"matched"`
+}
+
+// ReplayOutputResult is what an output would have received, nothing is written to it
+type ReplayOutputResult struct {
+	Count   int                      `json:"count"`
+	Samples []map[string]interface{} `json:"samples"`
+}
+
+// ReplayProjectResult is the outcome of replaying events through the flow of one project
+type ReplayProjectResult struct {
+	ProjectID       string                          `json:"project_id"`
+	Rulesets        map[string]*ReplayRulesetResult `json:"rulesets"`
+	Outputs         map[string]*ReplayOutputResult  `json:"outputs"`
+	SkippedRulesets []string                        `json:"skipped_rulesets"`
+}
+
+// shadowPipeline runs events through the flow of a project in-process. Outputs only collect what
+// they would have received.
+type shadowPipeline struct {
+	edges    map[string][]project.FlowNode // Keyed by TYPE.id of the edge source
+	lookup   func(id string) (*rules_engine.Ruleset, bool)
+	rulesets map[string]*rules_engine.Ruleset
+	skipped  map[string]bool
+	res      *ReplayProjectResult
+}
+
+// shadowReplay runs the events of an input through a project flow. lookup returns the ruleset
+// instance to evaluate, which must not be a running instance so replays leave no trace in its samples;
+// their caches are closed once the replay is done.
+func shadowReplay(projectID string, flowNodes []project.FlowNode, inputID string, lookup func(id string) (*rules_engine.Ruleset, bool), events []map[string]interface{}) *ReplayProjectResult {
+	p := &shadowPipeline{
+		edges:    make(map[string][]project.FlowNode),
+		lookup:   lookup,
+		rulesets: make(map[string]*rules_engine.Ruleset),
+		skipped:  make(map[string]bool),
+		res: &ReplayProjectResult{
+			ProjectID:       projectID,
+			Rulesets:        make(map[string]*ReplayRulesetResult),
+			Outputs:         make(map[string]*ReplayOutputResult),
+			SkippedRulesets: make([]string, 0),
+		},
+	}
+	for _, node := range flowNodes {
+		from := node.FromType + "." + node.FromID
+		p.edges[from] = append(p.edges[from], node)
+		if node.ToType == "OUTPUT" && p.res.Outputs[node.ToID] == nil {
+			p.res.Outputs[node.ToID] = &ReplayOutputResult{Samples: make([]map[string]interface{}, 0)}
+		}
+	}
+
+	// The copies are discarded with the replay
+	defer func() {
+		for _, rs := range p.rulesets {
+			rs.CloseCaches()
+		}
+	}()
+
+	for _, event := range events {
+		p.forward("INPUT."+inputID, event)
+	}
+
+	for id := range p.skipped {
+		p.res.SkippedRulesets = append(p.res.SkippedRulesets, id)
+	}
+	sort.Strings(p.res.SkippedRulesets)
+	return p.res
+}
+
+// forward hands an event to the regular downstreams of a component
+func (p *shadowPipeline) forward(from string, event map[string]interface{}) {
+	for _, node := range p.edges[from] {
+		if !node.NoMatch {
+			p.deliver(node, event)
+		}
+	}
+}
+
+// deliver hands an event to the target of a flow edge
+func (p *shadowPipeline) deliver(node project.FlowNode, event map[string]interface{}) {
+	switch node.ToType {
+	case "OUTPUT":
+		out := p.res.Outputs[node.ToID]
+		out.Count++
+		if len(out.Samples) < maxReplayOutputSamples {
+			out.Samples = append(out.Samples, event)
+		}
+	case "RULESET":
+		rs := p.ruleset(node.ToID)
+		if rs == nil {
+			return
+		}
+		stats := p.res.Rulesets[node.ToID]
+		if stats == nil {
+			stats = &ReplayRulesetResult{}
+			p.res.Rulesets[node.ToID] = stats
+		}
+		stats.Evaluated++
+		matched, results := rulesetMatches(rs, event)
+		if matched {
+			stats.Matched++
+		}
+		from := "RULESET." + node.ToID
+		for _, result := range results {
+			p.forward(from, result)
+		}
+		if rs.IsDetection && len(results) == 0 {
+			// Mirror emit_no_match: only its edges receive the events no rule matched
+			for _, next := range p.edges[from] {
+				if next.NoMatch {
+					flagged := common.MapDeepCopy(event)
+					flagged[rules_engine.NoMatchFieldName] = rs.RulesetID
+					p.deliver(next, flagged)
+				}
+			}
+		}
+	}
+}
+
+// ruleset returns the instance evaluating a ruleset of the flow, nil when it is unavailable
+func (p *shadowPipeline) ruleset(id string) *rules_engine.Ruleset {
+	if rs, ok := p.rulesets[id]; ok {
+		return rs
+	}
+	if p.skipped[id] {
+		return nil
+	}
+	rs, ok := p.lookup(id)
+	if !ok {
+		p.skipped[id] = true
+		return nil
+	}
+	p.rulesets[id] = rs
+	return rs
+}
+
+// replayRulesetLookup returns isolated, never started copies of the deployed rulesets. Their
+// threshold counters and cooldowns are namespaced, so a replay neither advances nor reads those of
+// the running instances.
+func replayRulesetLookup() func(id string) (*rules_engine.Ruleset, bool) {
+	return func(id string) (*rules_engine.Ruleset, bool) {
+		existing, ok := project.GetRuleset(id)
+		if !ok || existing.Err != nil {
+			return nil, false
+		}
+		seq := fmt.Sprintf("replay_%d", time.Now().UnixNano())
+		rs, err := rules_engine.NewFromExisting(existing, seq)
+		if err != nil {
+			return nil, false
+		}
+		rs.SetTestMode()
+		rs.SetStateNamespace(seq + ":")
+		return rs, true
+	}
+}
+
+// replayInputFrom reads a time window of a Kafka input's topic with a temporary consumer and runs it
+// through a shadow of the flows consuming the input, reporting matches. It is read-only: the live
+// input, its consumer group offsets and the real outputs are untouched.
+func replayInputFrom(c echo.Context) error {
+	id := c.Param("id")
+	in, exists := project.GetInput(id)
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "input not found"})
+	}
+	if !in.IsKafka() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("input %s is of type %s, replay only applies to Kafka inputs", id, in.Type)})
+	}
+	if _, err := in.SingleKafkaTopic(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "replay is " + err.Error()})
+	}
+
+	var req struct {
+		Start     int64  `json:"start"`           // Unix milliseconds, inclusive
+		End       int64  `json:"end,omitempty"`   // Unix milliseconds, exclusive, default now
+		Limit     int    `json:"limit,omitempty"` // Records read at most
+		ProjectID string `json:"project_id,omitempty"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	if req.End == 0 {
+		req.End = time.Now().UnixMilli()
+	}
+	if req.Start <= 0 || req.End <= req.Start {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "start and end must be unix milliseconds with start before end"})
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultReplayLimit
+	}
+	if req.Limit > common.MaxKafkaReplayRecords {
+		req.Limit = common.MaxKafkaReplayRecords
+	}
+
+	flows := make(map[string][]project.FlowNode)
+	project.ForEachProject(func(projId string, proj *project.Project) bool {
+		if req.ProjectID != "" && projId != req.ProjectID {
+			return true
+		}
+		for _, node := range proj.FlowNodes {
+			if node.FromType == "INPUT" && node.FromID == id {
+				flows[projId] = proj.FlowNodes
+				break
+			}
+		}
+		return true
+	})
+	if len(flows) == 0 {
+		if req.ProjectID != "" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("project %s not found or does not use input %s", req.ProjectID, id)})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "input is not used by any project"})
+	}
+
+	start, end := time.UnixMilli(req.Start), time.UnixMilli(req.End)
+	batch, err := in.ReplayWindow(start, end, req.Limit)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to read the window: " + err.Error()})
+	}
+
+	projectIDs := make([]string, 0, len(flows))
+	for projId := range flows {
+		projectIDs = append(projectIDs, projId)
+	}
+	sort.Strings(projectIDs)
+	lookup := replayRulesetLookup()
+	results := make([]*ReplayProjectResult, 0, len(projectIDs))
+	for _, projId := range projectIDs {
+		results = append(results, shadowReplay(projId, flows[projId], id, lookup, batch.Events))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"input_id":     id,
+		"start":        start,
+		"end":          end,
+		"read":         batch.Read,
+		"parse_errors": batch.ParseErrors,
+		"truncated":    batch.Truncated,
+		"projects":     results,
+	})
+}
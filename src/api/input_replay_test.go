@@ -0,0 +1,68 @@
+package api
+
+import (
+	"AgentSmith-HUB/project"
+	"AgentSmith-HUB/rules_engine"
+	"testing"
+)
+
+const replayExcludeRuleset = `<root type="EXCLUDE" name="replay_allow">
+  <rule id="allow_scanner" name="scanner">
+    <check type="EQU" field="src">10.0.0.1</check>
+  </rule>
+</root>`
+
+func TestShadowReplayRoutesThroughProjectFlow(t *testing.T) {
+	rulesets := map[string]string{"allow": replayExcludeRuleset, "detect": impactRuleset}
+	lookup := func(id string) (*rules_engine.Ruleset, bool) {
+		raw, ok := rulesets[id]
+		if !ok {
+			return nil, false
+		}
+		rs, err := rules_engine.NewRuleset("", raw, id)
+		if err != nil {
+			t.Fatalf("NewRuleset %s: %v", id, err)
+		}
+		rs.SetTestMode()
+		return rs, true
+	}
+	flow := []project.FlowNode{
+		{FromType: "INPUT", FromID: "kafka_in", ToType: "RULESET", ToID: "allow"},
+		{FromType: "RULESET", FromID: "allow", ToType: "RULESET", ToID: "detect"},
+		{FromType: "RULESET", FromID: "detect", ToType: "OUTPUT", ToID: "alerts"},
+		{FromType: "RULESET", FromID: "detect", ToType: "OUTPUT", ToID: "audit", NoMatch: true},
+		{FromType: "INPUT", FromID: "kafka_in", ToType: "RULESET", ToID: "missing"},
+		{FromType: "INPUT", FromID: "other_in", ToType: "OUTPUT", ToID: "raw"},
+	}
+	events := []map[string]interface{}{
+		{"src": "10.0.0.1", "action": "login", "user": "bob"},    // Excluded before detection
+		{"src": "10.0.0.2", "action": "logout", "user": "admin"}, // Hits detection
+		{"src": "10.0.0.3", "action": "logout", "user": "eve"},   // No detection rule matches
+	}
+
+	res := shadowReplay("proj", flow, "kafka_in", lookup, events)
+
+	if got := res.Rulesets["allow"]; got == nil || got.Evaluated != 3 || got.Matched != 1 {
+		t.Fatalf("unexpected exclude ruleset result: %+v", got)
+	}
+	if got := res.Rulesets["detect"]; got == nil || got.Evaluated != 2 || got.Matched != 1 {
+		t.Fatalf("unexpected detection ruleset result: %+v", got)
+	}
+	alerts := res.Outputs["alerts"]
+	if alerts.Count != 1 || alerts.Samples[0]["user"] != "admin" {
+		t.Fatalf("unexpected alerts output: %+v", alerts)
+	}
+	audit := res.Outputs["audit"]
+	if audit.Count != 1 || audit.Samples[0][rules_engine.NoMatchFieldName] != "detect" {
+		t.Fatalf("unexpected no-match output: %+v", audit)
+	}
+	if res.Outputs["raw"].Count != 0 {
+		t.Fatalf("events of another input must not reach its outputs: %+v", res.Outputs["raw"])
+	}
+	if len(res.SkippedRulesets) != 1 || res.SkippedRulesets[0] != "missing" {
+		t.Fatalf("unexpected skipped rulesets: %v", res.SkippedRulesets)
+	}
+	if _, ok := events[2][rules_engine.NoMatchFieldName]; ok {
+		t.Fatal("replay modified the source event")
+	}
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LabelsFileName is the sidecar file under the config root that stores component labels
+const LabelsFileName = "labels.json"
+
+const (
+	maxLabelsPerComponent = 32
+	maxLabelKeyLength     = 64
+	maxLabelValueLength   = 256
+)
+
+// componentLabels holds labels keyed by component type, then component id
+type componentLabels map[string]map[string]map[string]string
+
+var (
+	labelsMu    sync.Mutex
+	labelsCache componentLabels
+)
+
+func labelsFilePath() string {
+	return path.Join(common.Config.ConfigRoot, LabelsFileName)
+}
+
+// loadLabelsUnsafe reads the sidecar file once; the caller must hold labelsMu
+func loadLabelsUnsafe() componentLabels {
+	if labelsCache != nil {
+		return labelsCache
+	}
+	labelsCache = make(componentLabels)
+	data, err := os.ReadFile(labelsFilePath())
+	if err != nil {
+		return labelsCache
+	}
+	if err := json.Unmarshal(data, &labelsCache); err != nil || labelsCache == nil {
+		labelsCache = make(componentLabels)
+	}
+	return labelsCache
+}
+
+// saveLabelsUnsafe persists the labels; the caller must hold labelsMu
+func saveLabelsUnsafe() error {
+	data, err := json.MarshalIndent(labelsCache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	if err := os.WriteFile(labelsFilePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write labels file: %w", err)
+	}
+	return nil
+}
+
+// GetComponentLabels returns a copy of the labels attached to a component
+func GetComponentLabels(componentType, id string) map[string]string {
+	labelsMu.Lock()
+	defer labelsMu.Unlock()
+	res := make(map[string]string)
+	for k, v := range loadLabelsUnsafe()[componentType][id] {
+		res[k] = v
+	}
+	return res
+}
+
+// SetComponentLabels replaces the labels of a component; empty labels remove the entry
+func SetComponentLabels(componentType, id string, labels map[string]string) error {
+	if err := validateLabels(labels); err != nil {
+		return err
+	}
+
+	labelsMu.Lock()
+	defer labelsMu.Unlock()
+	all := loadLabelsUnsafe()
+	if len(labels) == 0 {
+		delete(all[componentType], id)
+	} else {
+		if all[componentType] == nil {
+			all[componentType] = make(map[string]map[string]string)
+		}
+		copied := make(map[string]string, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		all[componentType][id] = copied
+	}
+	return saveLabelsUnsafe()
+}
+
+// DeleteComponentLabels drops all labels of a deleted component
+func DeleteComponentLabels(componentType, id string) error {
+	labelsMu.Lock()
+	defer labelsMu.Unlock()
+	all := loadLabelsUnsafe()
+	if _, ok := all[componentType][id]; !ok {
+		return nil
+	}
+	delete(all[componentType], id)
+	return saveLabelsUnsafe()
+}
+
+func validateLabels(labels map[string]string) error {
+	if len(labels) > maxLabelsPerComponent {
+		return fmt.Errorf("too many labels: %d, maximum is %d", len(labels), maxLabelsPerComponent)
+	}
+	for k, v := range labels {
+		if k == "" {
+			return fmt.Errorf("label key cannot be empty")
+		}
+		if len(k) > maxLabelKeyLength {
+			return fmt.Errorf("label key '%s' exceeds %d characters", k, maxLabelKeyLength)
+		}
+		if strings.ContainsAny(k, "=,") {
+			return fmt.Errorf("label key '%s' cannot contain '=' or ','", k)
+		}
+		if len(v) > maxLabelValueLength {
+			return fmt.Errorf("label value for '%s' exceeds %d characters", k, maxLabelValueLength)
+		}
+	}
+	return nil
+}
+
+// parseLabelSelectors parses ?label=key=value query params; a bare key matches any value
+func parseLabelSelectors(c echo.Context) (map[string]*string, error) {
+	return parseLabelSelectorList(c.QueryParams()["label"])
+}
+
+// parseLabelSelectorList parses comma-separated key=value selectors; a bare key matches any value
+func parseLabelSelectorList(raws []string) (map[string]*string, error) {
+	selectors := make(map[string]*string)
+	for _, raw := range raws {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			key, value, hasValue := strings.Cut(part, "=")
+			key = strings.TrimSpace(key)
+			if key == "" {
+				return nil, fmt.Errorf("invalid label selector '%s'", part)
+			}
+			if hasValue {
+				v := strings.TrimSpace(value)
+				selectors[key] = &v
+			} else {
+				selectors[key] = nil
+			}
+		}
+	}
+	return selectors, nil
+}
+
+func labelsMatch(labels map[string]string, selectors map[string]*string) bool {
+	for key, want := range selectors {
+		got, ok := labels[key]
+		if !ok || (want != nil && got != *want) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyComponentLabels attaches labels to list entries and keeps only those matching ?label= selectors
+func applyComponentLabels(c echo.Context, componentType string, items []map[string]interface{}) ([]map[string]interface{}, error) {
+	selectors, err := parseLabelSelectors(c)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		id, _ := item["id"].(string)
+		if id == "" {
+			id, _ = item["name"].(string)
+		}
+		labels := GetComponentLabels(componentType, id)
+		if !labelsMatch(labels, selectors) {
+			continue
+		}
+		item["labels"] = labels
+		filtered = append(filtered, item)
+	}
+	return filtered, nil
+}
+
+// labelComponentType maps the plural route segment to the component type
+func labelComponentType(c echo.Context) string {
+	segment := strings.Split(strings.TrimPrefix(c.Path(), "/"), "/")[0]
+	return strings.TrimSuffix(segment, "s")
+}
+
+func componentExists(componentType, id string) bool {
+	if _, exists := GetComponentPath(componentType, id, false); exists {
+		return true
+	}
+	_, exists := GetComponentPath(componentType, id, true)
+	return exists
+}
+
+func getComponentLabels(c echo.Context) error {
+	componentType := labelComponentType(c)
+	id := c.Param("id")
+	if !componentExists(componentType, id) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": componentType + " not found"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"type":   componentType,
+		"id":     id,
+		"labels": GetComponentLabels(componentType, id),
+	})
+}
+
+func setComponentLabels(c echo.Context) error {
+	componentType := labelComponentType(c)
+	id := c.Param("id")
+	if !componentExists(componentType, id) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": componentType + " not found"})
+	}
+
+	var req struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	if err := SetComponentLabels(componentType, id, req.Labels); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("labels updated for %s %s", componentType, id),
+		"type":    componentType,
+		"id":      id,
+		"labels":  GetComponentLabels(componentType, id),
+	})
+}
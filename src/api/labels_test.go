@@ -0,0 +1,116 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func withLabelsConfigRoot(t *testing.T) string {
+	t.Helper()
+	prev := common.Config
+	root := t.TempDir()
+	common.Config = &common.HubConfig{ConfigRoot: root}
+	labelsMu.Lock()
+	labelsCache = nil
+	labelsMu.Unlock()
+	t.Cleanup(func() {
+		common.Config = prev
+		labelsMu.Lock()
+		labelsCache = nil
+		labelsMu.Unlock()
+	})
+	return root
+}
+
+func TestSetComponentLabelsHandler(t *testing.T) {
+	root := withLabelsConfigRoot(t)
+	if err := os.MkdirAll(path.Join(root, "ruleset"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(root, "ruleset", "rs1.xml"), []byte("<root/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.PUT("/rulesets/:id/labels", setComponentLabels)
+
+	req := httptest.NewRequest(http.MethodPut, "/rulesets/rs1/labels", strings.NewReader(`{"labels":{"team":"soc","env":"prod"}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if got := GetComponentLabels("ruleset", "rs1"); got["team"] != "soc" || got["env"] != "prod" {
+		t.Fatalf("labels = %v", got)
+	}
+
+	// Labels survive a reload from the sidecar file
+	labelsMu.Lock()
+	labelsCache = nil
+	labelsMu.Unlock()
+	if got := GetComponentLabels("ruleset", "rs1"); got["team"] != "soc" {
+		t.Fatalf("labels after reload = %v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/rulesets/missing/labels", strings.NewReader(`{"labels":{"team":"soc"}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status for missing component = %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/rulesets/rs1/labels", strings.NewReader(`{"labels":{"a=b":"x"}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status for invalid key = %d", rec.Code)
+	}
+}
+
+func TestApplyComponentLabelsFilter(t *testing.T) {
+	withLabelsConfigRoot(t)
+	if err := SetComponentLabels("input", "a", map[string]string{"team": "soc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetComponentLabels("input", "b", map[string]string{"team": "ops", "env": "prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := func(query string) []string {
+		e := echo.New()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/inputs?"+query, nil), httptest.NewRecorder())
+		items := []map[string]interface{}{{"id": "a"}, {"id": "b"}, {"id": "c"}}
+		res, err := applyComponentLabels(c, "input", items)
+		if err != nil {
+			t.Fatalf("applyComponentLabels(%s): %v", query, err)
+		}
+		ids := make([]string, 0, len(res))
+		for _, item := range res {
+			ids = append(ids, item["id"].(string))
+		}
+		return ids
+	}
+
+	cases := map[string]string{
+		"":                              "a,b,c",
+		"label=team=soc":                "a",
+		"label=env":                     "b",
+		"label=team=ops&label=env=prod": "b",
+		"label=team=ops,env=dev":        "",
+	}
+	for query, want := range cases {
+		if got := strings.Join(filter(query), ","); got != want {
+			t.Errorf("filter %q = %q, want %q", query, got, want)
+		}
+	}
+}
@@ -193,7 +193,7 @@ func getLocalChangesCount(c echo.Context) error {
 			filename := d.Name()
 			id := strings.TrimSuffix(filename, ".go")
 
-			memoryPlugin, exists := plugin.Plugins[id]
+			memoryPlugin, exists := plugin.GetPlugin(id)
 			if !exists {
 				count++
 				return nil
@@ -210,7 +210,7 @@ func getLocalChangesCount(c echo.Context) error {
 			}
 
 			// Also check if there's content in temporary memory (PluginsNew)
-			if tempContent, existsInTemp := plugin.PluginsNew[id]; existsInTemp {
+			if tempContent, existsInTemp := plugin.GetPluginNew(id); existsInTemp {
 				memoryContent = tempContent
 			}
 
@@ -462,7 +462,7 @@ func getLocalChanges(c echo.Context) error {
 				return nil
 			}
 
-			memoryPlugin, exists := plugin.Plugins[id]
+			memoryPlugin, exists := plugin.GetPlugin(id)
 			var memoryContent string
 			if exists && memoryPlugin.Type == plugin.YAEGI_PLUGIN {
 				memoryContent = string(memoryPlugin.Payload)
@@ -470,7 +470,7 @@ func getLocalChanges(c echo.Context) error {
 
 			// Also check if there's content in temporary memory (PluginsNew)
 			// If plugin was loaded but not yet applied, use temporary content for comparison
-			if tempContent, existsInTemp := plugin.PluginsNew[id]; existsInTemp {
+			if tempContent, existsInTemp := plugin.GetPluginNew(id); existsInTemp {
 				memoryContent = tempContent
 				exists = true // Treat as existing if it's in temporary memory
 			}
@@ -585,7 +585,7 @@ func getLocalChanges(c echo.Context) error {
 	})
 
 	// Check for deleted plugins
-	for id, pluginInstance := range plugin.Plugins {
+	for id, pluginInstance := range plugin.GetAllPlugins() {
 		// Only check yaegi plugins (skip local/built-in plugins)
 		if pluginInstance.Type != plugin.YAEGI_PLUGIN {
 			continue
@@ -760,7 +760,7 @@ func loadLocalChanges(c echo.Context) error {
 			return nil
 		}
 
-		memoryPlugin, exists := plugin.Plugins[id]
+		memoryPlugin, exists := plugin.GetPlugin(id)
 		var memoryContent string
 		if exists && memoryPlugin.Type == plugin.YAEGI_PLUGIN {
 			memoryContent = string(memoryPlugin.Payload)
@@ -768,7 +768,7 @@ func loadLocalChanges(c echo.Context) error {
 
 		// Also check if there's content in temporary memory (PluginsNew)
 		// If plugin was loaded but not yet applied, use temporary content for comparison
-		if tempContent, existsInTemp := plugin.PluginsNew[id]; existsInTemp {
+		if tempContent, existsInTemp := plugin.GetPluginNew(id); existsInTemp {
 			memoryContent = tempContent
 			exists = true // Treat as existing if it's in temporary memory
 		}
@@ -825,7 +825,7 @@ func getMCPStats(c echo.Context) error {
 			"total_projects": project.GetProjectsCount(),
 			"total_inputs":   project.GetInputsCount(),
 			"total_outputs":  project.GetOutputsCount(),
-			"total_plugins":  len(plugin.Plugins),
+			"total_plugins":  plugin.PluginCount(),
 			"total_rulesets": project.GetRulesetsCount(),
 		},
 		"capabilities": map[string]interface{}{
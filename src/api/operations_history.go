@@ -244,6 +244,36 @@ func GetOperationsHistory(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// getProjectOperations handles GET /projects/:id/operations - the operations of one project in the
+// cluster history, newest first, paged with limit (default 100, max 1000) and offset
+func getProjectOperations(c echo.Context) error {
+	id := c.Param("id")
+	limit := 100
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 {
+			limit = min(n, 1000)
+		}
+	}
+	offset := 0
+	if offsetStr := c.QueryParam("offset"); offsetStr != "" {
+		if n, err := strconv.Atoi(offsetStr); err == nil && n > 0 {
+			offset = n
+		}
+	}
+
+	operations, totalCount, err := common.GetOperationsFromRedisWithFilter(common.OperationHistoryFilter{ProjectID: id, Limit: limit, Offset: offset})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": fmt.Sprintf("Failed to retrieve project operations: %v", err),
+		})
+	}
+	return c.JSON(http.StatusOK, OperationHistoryResponse{
+		Operations: operations,
+		TotalCount: totalCount,
+		HasMore:    offset+len(operations) < totalCount,
+	})
+}
+
 // GetClusterOperationsHistory handles GET /cluster-operations-history - DEPRECATED but kept for backward compatibility
 func GetClusterOperationsHistory(c echo.Context) error {
 	// Redirect to unified endpoint - no longer restricted to leader only
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"AgentSmith-HUB/output"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getOutputDeadLetters returns the newest dead letters of an output
+// Query parameters:
+//   - limit: number of entries, newest first (default 100, max 1000)
+func getOutputDeadLetters(c echo.Context) error {
+	id := c.Param("id")
+	limit := defaultDeadLetterLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+		}
+		limit = min(n, maxDeadLetterLimit)
+	}
+	letters, err := output.DeadLetters(id, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read dead letters: " + err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"output_id": id, "dead_letters": letters})
+}
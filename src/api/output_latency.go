@@ -0,0 +1,83 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const outputLatencyMetric = "agentsmith_output_write_duration_seconds"
+
+// getOutputLatency returns the write latency summary of each output on this node
+func getOutputLatency(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"node_id": common.Config.LocalIP,
+		"outputs": common.OutputLatencySnapshots(),
+		"pools":   common.OutputConnPoolSnapshots(),
+	})
+}
+
+// getPrometheusMetrics exposes the output write latency histograms and connection pool usage in the
+// Prometheus text format
+func getPrometheusMetrics(c echo.Context) error {
+	body := formatOutputLatencyMetrics(common.OutputLatencySnapshots()) + formatOutputPoolMetrics(common.OutputConnPoolSnapshots())
+	return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(body))
+}
+
+func formatOutputLatencyMetrics(snapshots map[string]common.HistogramSnapshot) string {
+	ids := make([]string, 0, len(snapshots))
+	for id := range snapshots {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# HELP %s Time spent writing events to an output.\n", outputLatencyMetric)
+	fmt.Fprintf(&sb, "# TYPE %s histogram\n", outputLatencyMetric)
+	for _, id := range ids {
+		s := snapshots[id]
+		label := strconv.Quote(id)
+		for _, b := range s.Buckets {
+			fmt.Fprintf(&sb, "%s_bucket{output=%s,le=\"%s\"} %d\n", outputLatencyMetric, label, strconv.FormatFloat(b.Le, 'g', -1, 64), b.Count)
+		}
+		fmt.Fprintf(&sb, "%s_bucket{output=%s,le=\"+Inf\"} %d\n", outputLatencyMetric, label, s.Count)
+		fmt.Fprintf(&sb, "%s_sum{output=%s} %s\n", outputLatencyMetric, label, strconv.FormatFloat(s.SumSeconds, 'g', -1, 64))
+		fmt.Fprintf(&sb, "%s_count{output=%s} %d\n", outputLatencyMetric, label, s.Count)
+	}
+	return sb.String()
+}
+
+func formatOutputPoolMetrics(pools map[string]common.ConnPoolSnapshot) string {
+	ids := make([]string, 0, len(pools))
+	for id := range pools {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	metrics := []struct {
+		name, kind, help string
+		value            func(common.ConnPoolSnapshot) int64
+	}{
+		{"agentsmith_output_pool_open_connections", "gauge", "Connections an output currently holds open.", func(s common.ConnPoolSnapshot) int64 { return s.Open }},
+		{"agentsmith_output_pool_in_use_connections", "gauge", "Connections of an output currently serving a write.", func(s common.ConnPoolSnapshot) int64 { return s.InUse }},
+		{"agentsmith_output_pool_opened_total", "counter", "Connections opened by an output.", func(s common.ConnPoolSnapshot) int64 { return int64(s.Opened) }},
+		{"agentsmith_output_pool_reused_total", "counter", "HTTP requests of an output that reused an open connection.", func(s common.ConnPoolSnapshot) int64 { return int64(s.Reused) }},
+		{"agentsmith_output_pool_waits_total", "counter", "SQL writes of an output that waited for a free connection.", func(s common.ConnPoolSnapshot) int64 { return s.Waits }},
+	}
+
+	var sb strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", m.name, m.kind)
+		for _, id := range ids {
+			s := pools[id]
+			fmt.Fprintf(&sb, "%s{output=%s,type=\"%s\"} %d\n", m.name, strconv.Quote(id), s.Type, m.value(s))
+		}
+	}
+	return sb.String()
+}
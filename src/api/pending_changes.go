@@ -412,16 +412,12 @@ func syncProjectsToEnhancedManager() {
 
 // Helper functions for safe access to plugin data
 func getPendingPluginChange(id string) (string, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	content, exists := plugin.PluginsNew[id]
+	content, exists := plugin.GetPluginNew(id)
 	return content, exists
 }
 
 func getExistingPluginContent(id string) string {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	if pluginInstance, exists := plugin.Plugins[id]; exists {
+	if pluginInstance, exists := plugin.GetPlugin(id); exists {
 		return string(pluginInstance.Payload)
 	}
 	return ""
@@ -698,6 +694,7 @@ const (
 	SourceChangePush  ComponentReloadSource = "change_push"
 	SourceLocalFile   ComponentReloadSource = "local_file"
 	SourceClusterSync ComponentReloadSource = "cluster_sync"
+	SourceRollback    ComponentReloadSource = "rollback"
 )
 
 // ComponentReloadRequest represents a request to reload a component
@@ -711,6 +708,12 @@ type ComponentReloadRequest struct {
 	WriteToFile bool                  `json:"write_to_file,omitempty"`
 }
 
+// getAffectedProjects and recordChangePush read and write Redis, replaced in tests
+var (
+	getAffectedProjects = project.GetAffectedProjects
+	recordChangePush    = RecordChangePush
+)
+
 // reloadComponentUnified provides unified component reload logic for all sources
 func reloadComponentUnified(req *ComponentReloadRequest) ([]string, error) {
 	logger.Info("Starting unified component reload", "type", req.Type, "id", req.ID, "source", req.Source)
@@ -763,6 +766,9 @@ func reloadComponentUnified(req *ComponentReloadRequest) ([]string, error) {
 			return nil, fmt.Errorf("unsupported component type for file write: %s", req.Type)
 		}
 
+		// Keep the version being replaced so the component can be rolled back
+		backupFormalFile(filePath, req.NewContent)
+
 		err := os.WriteFile(filePath, []byte(req.NewContent), 0644)
 		if err != nil {
 			logger.Error("Failed to write component file", "type", req.Type, "id", req.ID, "error", err)
@@ -816,7 +822,7 @@ func reloadComponentUnified(req *ComponentReloadRequest) ([]string, error) {
 		project.SetInput(req.ID, newInput)
 		project.DeleteInputNew(req.ID)
 
-		affectedProjects = project.GetAffectedProjects("input", req.ID)
+		affectedProjects = getAffectedProjects("input", req.ID)
 
 	case "output":
 		// Create new component instance
@@ -835,7 +841,7 @@ func reloadComponentUnified(req *ComponentReloadRequest) ([]string, error) {
 		project.SetOutput(req.ID, newOutput)
 		project.DeleteOutputNew(req.ID)
 
-		affectedProjects = project.GetAffectedProjects("output", req.ID)
+		affectedProjects = getAffectedProjects("output", req.ID)
 
 	case "ruleset":
 		// Create new component instance
@@ -854,7 +860,7 @@ func reloadComponentUnified(req *ComponentReloadRequest) ([]string, error) {
 		project.SetRuleset(req.ID, newRuleset)
 		project.DeleteRulesetNew(req.ID)
 
-		affectedProjects = project.GetAffectedProjects("ruleset", req.ID)
+		affectedProjects = getAffectedProjects("ruleset", req.ID)
 
 	case "project":
 		// Create new component instance
@@ -891,7 +897,7 @@ func reloadComponentUnified(req *ComponentReloadRequest) ([]string, error) {
 		// Clear temporary version using safe accessor
 		plugin.DeletePluginNew(req.ID)
 
-		affectedProjects = project.GetAffectedProjects("plugin", req.ID)
+		affectedProjects = getAffectedProjects("plugin", req.ID)
 
 	default:
 		return nil, fmt.Errorf("unsupported component type: %s", req.Type)
@@ -909,8 +915,8 @@ func reloadComponentUnified(req *ComponentReloadRequest) ([]string, error) {
 
 	// Phase 6: Record operation history
 	switch req.Source {
-	case SourceChangePush:
-		RecordChangePush(req.Type, req.ID, req.OldContent, req.NewContent, "", "success", "")
+	case SourceChangePush, SourceRollback:
+		recordChangePush(req.Type, req.ID, req.OldContent, req.NewContent, "", "success", "")
 	case SourceLocalFile:
 		RecordLocalPush(req.Type, req.ID, req.NewContent, "success", "")
 	case SourceClusterSync:
@@ -944,6 +950,12 @@ func ApplySingleChange(c echo.Context) error {
 
 	logger.Info("ApplySingleChange request", "type", req.Type, "id", req.ID)
 
+	lock, resp := lockApply(c)
+	if lock == nil {
+		return resp
+	}
+	defer unlockApply(lock)
+
 	// Get pending change using safe accessors
 	var content string
 	var oldContent string
@@ -1043,6 +1055,12 @@ func ApplyAllChanges(c echo.Context) error {
 
 	logger.Info("ApplyAllChanges request")
 
+	lock, resp := lockApply(c)
+	if lock == nil {
+		return resp
+	}
+	defer unlockApply(lock)
+
 	// Sync from legacy storage first
 	syncLegacyToEnhancedManager()
 
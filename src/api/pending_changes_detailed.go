@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DiffSummary counts the lines a pending change adds to and removes from the current content
+type DiffSummary struct {
+	LinesAdded   int `json:"lines_added"`
+	LinesRemoved int `json:"lines_removed"`
+}
+
+// PendingChangeDetail describes one pending change without its full content
+type PendingChangeDetail struct {
+	Type         string      `json:"type"`
+	ID           string      `json:"id"`
+	IsNew        bool        `json:"is_new"`
+	Status       string      `json:"status"`
+	ErrorMessage string      `json:"error_message,omitempty"`
+	LastUpdated  time.Time   `json:"last_updated"`
+	Diff         DiffSummary `json:"diff"`
+}
+
+// summarizeDiff counts added and removed lines, ignoring their order: a line moved elsewhere is
+// neither added nor removed
+func summarizeDiff(oldContent, newContent string) DiffSummary {
+	counts := make(map[string]int)
+	if oldContent != "" {
+		for _, line := range strings.Split(oldContent, "\n") {
+			counts[line]++
+		}
+	}
+	var summary DiffSummary
+	if newContent != "" {
+		for _, line := range strings.Split(newContent, "\n") {
+			if counts[line] > 0 {
+				counts[line]--
+			} else {
+				summary.LinesAdded++
+			}
+		}
+	}
+	for _, n := range counts {
+		summary.LinesRemoved += n
+	}
+	return summary
+}
+
+// GetDetailedPendingChanges lists every pending change with its status and a diff summary, sorted by
+// type and ID. DELETE /pending-changes/:type/:id cancels one of them.
+func GetDetailedPendingChanges(c echo.Context) error {
+	syncLegacyToEnhancedManager()
+
+	changes := globalPendingChangeManager.GetAllChanges()
+	details := make([]PendingChangeDetail, 0, len(changes))
+	for _, change := range changes {
+		details = append(details, PendingChangeDetail{
+			Type:         change.Type,
+			ID:           change.ID,
+			IsNew:        change.IsNew,
+			Status:       change.Status.String(),
+			ErrorMessage: change.ErrorMessage,
+			LastUpdated:  change.LastUpdated,
+			Diff:         summarizeDiff(change.OldContent, change.NewContent),
+		})
+	}
+	sort.Slice(details, func(i, j int) bool {
+		if details[i].Type != details[j].Type {
+			return details[i].Type < details[j].Type
+		}
+		return details[i].ID < details[j].ID
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"total":   len(details),
+		"changes": details,
+	})
+}
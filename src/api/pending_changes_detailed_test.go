@@ -0,0 +1,80 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDetailedPendingChangesCancelOne(t *testing.T) {
+	prevConfig := common.Config
+	common.Config = &common.HubConfig{ConfigRoot: t.TempDir()}
+	defer func() { common.Config = prevConfig }()
+
+	project.SetRulesetNew("pending_rs_a", "<root>\n</root>")
+	project.SetRulesetNew("pending_rs_b", "<root type=\"DETECTION\">\n</root>")
+	defer project.DeleteRulesetNew("pending_rs_a")
+	defer project.DeleteRulesetNew("pending_rs_b")
+
+	e := echo.New()
+	e.GET("/pending-changes/detailed", GetDetailedPendingChanges)
+	e.DELETE("/pending-changes/:type/:id", CancelPendingChange)
+
+	list := func() map[string]PendingChangeDetail {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pending-changes/detailed", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list: %d %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Changes []PendingChangeDetail `json:"changes"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		byID := make(map[string]PendingChangeDetail)
+		for _, change := range resp.Changes {
+			byID[change.Type+":"+change.ID] = change
+		}
+		return byID
+	}
+
+	changes := list()
+	a, okA := changes["ruleset:pending_rs_a"]
+	_, okB := changes["ruleset:pending_rs_b"]
+	if !okA || !okB {
+		t.Fatalf("expected both pending rulesets listed, got %v", changes)
+	}
+	if !a.IsNew || a.Status != "draft" || a.Diff.LinesAdded != 2 || a.Diff.LinesRemoved != 0 {
+		t.Fatalf("unexpected change %+v", a)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/pending-changes/ruleset/pending_rs_a", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cancel: %d %s", rec.Code, rec.Body.String())
+	}
+
+	changes = list()
+	if _, ok := changes["ruleset:pending_rs_a"]; ok {
+		t.Fatal("expected the cancelled change gone")
+	}
+	if _, ok := changes["ruleset:pending_rs_b"]; !ok {
+		t.Fatal("expected the other change kept")
+	}
+	if _, ok := project.GetRulesetNew("pending_rs_b"); !ok {
+		t.Fatal("expected the other pending content kept")
+	}
+}
+
+func TestSummarizeDiff(t *testing.T) {
+	got := summarizeDiff("a\nb\nc", "a\nc\nd\ne")
+	if got.LinesAdded != 2 || got.LinesRemoved != 1 {
+		t.Fatalf("unexpected summary %+v", got)
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/plugin"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPluginSampleLimit = 20
+	maxPluginSampleLimit     = 100
+)
+
+// PluginSampleResult is the outcome of a plugin run on one sampled event
+type PluginSampleResult struct {
+	Index               int         `json:"index"`
+	ProjectNodeSequence string      `json:"project_node_sequence,omitempty"`
+	Argument            interface{} `json:"argument"`
+	Result              interface{} `json:"result"`
+	Success             bool        `json:"success"`
+	Error               string      `json:"error,omitempty"`
+}
+
+// pluginSample is an event to run a plugin on, with where it was sampled
+type pluginSample struct {
+	Data                map[string]interface{}
+	ProjectNodeSequence string
+}
+
+// testPluginSamples runs a plugin on the sampled events of a component, passing a field of each event
+// as the argument like a rule passing _$field would (the whole event without a field), so authors can
+// check a plugin against real data before using it in rules.
+func testPluginSamples(c echo.Context) error {
+	id := c.Param("id")
+
+	var req struct {
+		Component string                   `json:"component"`         // Sampled component, e.g. input.kafka_in or ruleset.web
+		Field     string                   `json:"field,omitempty"`   // Event field passed as the argument
+		Limit     int                      `json:"limit,omitempty"`   // Samples to run on
+		Content   string                   `json:"content,omitempty"` // Optional plugin code tested instead of id
+		Data      []map[string]interface{} `json:"data,omitempty"`    // Optional events used instead of samples
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultPluginSampleLimit
+	}
+	if req.Limit > maxPluginSampleLimit {
+		req.Limit = maxPluginSampleLimit
+	}
+
+	var samples []pluginSample
+	source := "request"
+	if len(req.Data) > 0 {
+		for i := 0; i < len(req.Data) && i < req.Limit; i++ {
+			samples = append(samples, pluginSample{Data: req.Data[i]})
+		}
+	} else {
+		componentType, componentID, _ := strings.Cut(strings.ToLower(req.Component), ".")
+		if componentID == "" || (componentType != "input" && componentType != "ruleset" && componentType != "output") {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "component must be input.<id>, ruleset.<id> or output.<id>"})
+		}
+		samples = recentPluginSamples(componentType+"."+componentID, req.Limit)
+		source = "samples"
+	}
+
+	p, errMsg := loadTestPlugin(id, req.Content)
+	if errMsg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": errMsg})
+	}
+
+	results := runPluginOnSamples(p, id, req.Field, samples)
+	succeeded := 0
+	for _, res := range results {
+		if res.Success {
+			succeeded++
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"plugin":      id,
+		"component":   req.Component,
+		"field":       req.Field,
+		"data_source": source,
+		"results":     results,
+		"total":       len(results),
+		"succeeded":   succeeded,
+		"failed":      len(results) - succeeded,
+	})
+}
+
+// runPluginOnSamples runs p once per sample. A sample without the field is reported, not run.
+func runPluginOnSamples(p *plugin.Plugin, id string, field string, samples []pluginSample) []PluginSampleResult {
+	var fieldList []string
+	if field != "" {
+		fieldList = common.StringToList(field)
+	}
+	results := make([]PluginSampleResult, 0, len(samples))
+	for i, sample := range samples {
+		res := PluginSampleResult{Index: i, ProjectNodeSequence: sample.ProjectNodeSequence}
+		if fieldList == nil {
+			res.Argument = sample.Data
+		} else if value, ok := common.GetCheckData(sample.Data, fieldList); ok {
+			res.Argument = value
+		} else {
+			res.Error = "field not found: " + field
+			results = append(results, res)
+			continue
+		}
+		res.Result, res.Success, res.Error, _ = evalTestPlugin(p, id, []interface{}{res.Argument})
+		results = append(results, res)
+	}
+	return results
+}
+
+// recentPluginSamples returns up to limit sampled events of a component sampler
+func recentPluginSamples(samplerName string, limit int) []pluginSample {
+	samples := make([]pluginSample, 0)
+	sampler := common.GetSampler(samplerName)
+	if sampler == nil {
+		return samples
+	}
+	for _, list := range sampler.GetSamples() {
+		for _, sample := range list {
+			if data, ok := sample.Data.(map[string]interface{}); ok {
+				samples = append(samples, pluginSample{Data: data, ProjectNodeSequence: sample.ProjectNodeSequence})
+				if len(samples) >= limit {
+					return samples
+				}
+			}
+		}
+	}
+	return samples
+}
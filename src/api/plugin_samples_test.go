@@ -0,0 +1,57 @@
+package api
+
+import (
+	"AgentSmith-HUB/plugin"
+	"testing"
+)
+
+const sampleTestPluginCode = `package plugin
+
+import (
+	"errors"
+	"strings"
+)
+
+func Eval(args ...interface{}) (bool, error) {
+	domain, ok := args[0].(string)
+	if !ok {
+		return false, errors.New("domain must be a string")
+	}
+	return strings.HasSuffix(domain, ".evil.example"), nil
+}
+`
+
+func TestRunPluginOnSamples(t *testing.T) {
+	p, err := plugin.NewTestPlugin("", sampleTestPluginCode, "sample_test_plugin", plugin.YAEGI_PLUGIN)
+	if err != nil {
+		t.Fatalf("NewTestPlugin: %v", err)
+	}
+	if err := p.YaegiLoad(); err != nil {
+		t.Fatalf("YaegiLoad: %v", err)
+	}
+
+	samples := []pluginSample{
+		{Data: map[string]interface{}{"dns": map[string]interface{}{"query": "cdn.evil.example"}}, ProjectNodeSequence: "INPUT.dns"},
+		{Data: map[string]interface{}{"dns": map[string]interface{}{"query": "example.com"}}, ProjectNodeSequence: "INPUT.dns"},
+		{Data: map[string]interface{}{"action": "login"}},
+	}
+	results := runPluginOnSamples(p, "sample_test_plugin", "dns.query", samples)
+	if len(results) != 3 {
+		t.Fatalf("expected one result per sample, got %d", len(results))
+	}
+	if results[0].Result != true || !results[0].Success || results[0].Argument != "cdn.evil.example" {
+		t.Fatalf("unexpected result for the suspicious domain: %+v", results[0])
+	}
+	if results[1].Result != false || !results[1].Success || results[1].ProjectNodeSequence != "INPUT.dns" {
+		t.Fatalf("unexpected result for the benign domain: %+v", results[1])
+	}
+	if results[2].Success || results[2].Error != "field not found: dns.query" {
+		t.Fatalf("expected the sample without the field to be reported, got %+v", results[2])
+	}
+
+	// Without a field the plugin receives the whole event, which this plugin rejects
+	results = runPluginOnSamples(p, "sample_test_plugin", "", samples[:1])
+	if results[0].Success || results[0].Error == "" {
+		t.Fatalf("expected the plugin error to be reported per sample, got %+v", results[0])
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"AgentSmith-HUB/project"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BatchControlProjectsRequest selects projects by ids, label selector and/or id glob.
+// When several selectors are given a project must match all of them.
+type BatchControlProjectsRequest struct {
+	Action  string   `json:"action"` // "start", "stop", "restart"
+	IDs     []string `json:"ids,omitempty"`
+	Label   string   `json:"label,omitempty"`   // e.g. "env=prod,team"
+	Pattern string   `json:"pattern,omitempty"` // id glob, e.g. "edr_*"
+}
+
+// BatchControlResult is the outcome of the action for one project
+type BatchControlResult struct {
+	ProjectID     string `json:"project_id"`
+	Success       bool   `json:"success"`
+	Error         string `json:"error,omitempty"`
+	ProjectStatus string `json:"project_status,omitempty"`
+}
+
+// selectProjects returns the sorted ids of existing projects that match every given selector
+func selectProjects(req BatchControlProjectsRequest) ([]string, error) {
+	if len(req.IDs) == 0 && req.Label == "" && req.Pattern == "" {
+		return nil, fmt.Errorf("at least one selector (ids, label or pattern) is required")
+	}
+	if req.Pattern != "" {
+		if _, err := path.Match(req.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", req.Pattern, err)
+		}
+	}
+	selectors, err := parseLabelSelectorList([]string{req.Label})
+	if err != nil {
+		return nil, err
+	}
+
+	var wantIDs map[string]bool
+	if len(req.IDs) > 0 {
+		wantIDs = make(map[string]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			wantIDs[id] = true
+		}
+	}
+
+	ids := make([]string, 0)
+	project.ForEachProject(func(id string, _ *project.Project) bool {
+		if wantIDs != nil && !wantIDs[id] {
+			return true
+		}
+		if req.Pattern != "" {
+			if matched, _ := path.Match(req.Pattern, id); !matched {
+				return true
+			}
+		}
+		if len(selectors) > 0 && !labelsMatch(GetComponentLabels("project", id), selectors) {
+			return true
+		}
+		ids = append(ids, id)
+		return true
+	})
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// missingProjects returns the sorted explicit ids of the request that name no project, each reported
+// as not found like in the other batch endpoints
+func missingProjects(ids []string) []string {
+	missing := make([]string, 0)
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, exists := project.GetProject(id); !exists {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// batchControlProjects applies start/stop/restart to every selected project. Projects are handled
+// one at a time so operations on the same project never interleave.
+func batchControlProjects(c echo.Context) error {
+	var req BatchControlProjectsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+
+	var control func(*project.Project) error
+	switch req.Action {
+	case "start":
+		control = startProject
+	case "stop":
+		control = stopProject
+	case "restart":
+		control = restartProject
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("invalid action '%s', expected start, stop or restart", req.Action),
+		})
+	}
+
+	ids, err := selectProjects(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	missing := missingProjects(req.IDs)
+	results := make([]BatchControlResult, 0, len(ids)+len(missing))
+	for _, id := range missing {
+		results = append(results, BatchControlResult{ProjectID: id, Error: "project not found"})
+	}
+	succeeded := 0
+	for _, id := range ids {
+		p, exists := project.GetProject(id)
+		if !exists {
+			// Deleted while the batch was running
+			results = append(results, BatchControlResult{ProjectID: id, Error: "project not found"})
+			continue
+		}
+
+		res := BatchControlResult{ProjectID: id}
+		if err := control(p); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Success = true
+			succeeded++
+		}
+		res.ProjectStatus = string(p.Status)
+		results = append(results, res)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"action":    req.Action,
+		"matched":   len(ids),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"AgentSmith-HUB/project"
+	"reflect"
+	"testing"
+)
+
+func withBatchProjects(t *testing.T, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		project.SetProject(id, &project.Project{Id: id})
+	}
+	t.Cleanup(func() {
+		for _, id := range ids {
+			project.DeleteProject(id)
+		}
+	})
+}
+
+func TestSelectProjectsByPattern(t *testing.T) {
+	withLabelsConfigRoot(t)
+	withBatchProjects(t, "batch_edr_1", "batch_edr_2", "batch_waf_1")
+
+	ids, err := selectProjects(BatchControlProjectsRequest{Pattern: "batch_edr_*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"batch_edr_1", "batch_edr_2"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+
+	if _, err := selectProjects(BatchControlProjectsRequest{Pattern: "batch_[edr"}); err == nil {
+		t.Fatal("expected error for malformed pattern")
+	}
+}
+
+func TestSelectProjectsByLabel(t *testing.T) {
+	withLabelsConfigRoot(t)
+	withBatchProjects(t, "batch_edr_1", "batch_edr_2", "batch_waf_1")
+	if err := SetComponentLabels("project", "batch_edr_1", map[string]string{"env": "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetComponentLabels("project", "batch_waf_1", map[string]string{"env": "prod", "team": "web"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := selectProjects(BatchControlProjectsRequest{Label: "env=prod"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"batch_edr_1", "batch_waf_1"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+
+	// Selectors are combined: label and pattern must both match
+	ids, err = selectProjects(BatchControlProjectsRequest{Label: "env=prod", Pattern: "batch_edr_*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"batch_edr_1"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+}
+
+func TestSelectProjectsRequiresSelector(t *testing.T) {
+	if _, err := selectProjects(BatchControlProjectsRequest{Action: "stop"}); err == nil {
+		t.Fatal("expected error when no selector is given")
+	}
+}
+
+func TestMissingProjectsReportsUnknownIDs(t *testing.T) {
+	withBatchProjects(t, "batch_edr_1")
+
+	got := missingProjects([]string{"batch_typo", "batch_edr_1", "batch_gone", "batch_typo"})
+	if want := []string{"batch_gone", "batch_typo"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"AgentSmith-HUB/project"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const defaultProjectStartConcurrency = 4
+
+// bulkRestartProject restarts one project of a bulk restart, replaced in tests
+var bulkRestartProject = restartProject
+
+// BulkRestartRequest optionally overrides the hub's project_start_concurrency and project_start_stagger
+type BulkRestartRequest struct {
+	Concurrency int    `json:"concurrency,omitempty"`
+	Stagger     string `json:"stagger,omitempty"` // e.g. "2s"
+}
+
+// BulkRestartProgress is the state of the running or last bulk restart
+type BulkRestartProgress struct {
+	Running     bool       `json:"running"`
+	Total       int        `json:"total"`
+	Done        int        `json:"done"`
+	Failed      int        `json:"failed"`
+	Wave        int        `json:"wave"`
+	Waves       int        `json:"waves"`
+	Concurrency int        `json:"concurrency"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+var (
+	bulkRestartMu       sync.Mutex
+	bulkRestartProgress BulkRestartProgress
+)
+
+// projectStartSettings returns the wave size and the pause between waves of bulk restarts
+func projectStartSettings(req BulkRestartRequest) (int, time.Duration, error) {
+	concurrency := defaultProjectStartConcurrency
+	if n := common.ReadConfig(func(c *common.HubConfig) int { return c.ProjectStartConcurrency }); n > 0 {
+		concurrency = n
+	}
+	var stagger time.Duration
+	if d := common.ReadConfig(func(c *common.HubConfig) time.Duration { return c.ProjectStartStagger }); d > 0 {
+		stagger = d
+	}
+	if req.Concurrency < 0 {
+		return 0, 0, fmt.Errorf("concurrency must be a positive integer")
+	}
+	if req.Concurrency > 0 {
+		concurrency = req.Concurrency
+	}
+	if req.Stagger != "" {
+		d, err := time.ParseDuration(req.Stagger)
+		if err != nil || d < 0 {
+			return 0, 0, fmt.Errorf("invalid stagger '%s', expected a duration like 2s", req.Stagger)
+		}
+		stagger = d
+	}
+	return concurrency, stagger, nil
+}
+
+// restartInWaves restarts the projects in waves of at most concurrency projects, waiting stagger
+// between waves so the connections to shared backends ramp up gradually. Results keep the order of ids.
+func restartInWaves(ids []string, concurrency int, stagger time.Duration) []BatchControlResult {
+	results := make([]BatchControlResult, len(ids))
+	waves := (len(ids) + concurrency - 1) / concurrency
+	updateBulkRestart(func(p *BulkRestartProgress) { p.Waves = waves })
+
+	for wave := 0; wave < waves; wave++ {
+		if wave > 0 && stagger > 0 {
+			time.Sleep(stagger)
+		}
+		updateBulkRestart(func(p *BulkRestartProgress) { p.Wave = wave + 1 })
+
+		var wg sync.WaitGroup
+		end := (wave + 1) * concurrency
+		if end > len(ids) {
+			end = len(ids)
+		}
+		for i := wave * concurrency; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = restartOne(ids[i])
+				updateBulkRestart(func(p *BulkRestartProgress) {
+					p.Done++
+					if !results[i].Success {
+						p.Failed++
+					}
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		progress := getBulkRestartProgress()
+		logger.Info("Bulk project restart progress", "wave", wave+1, "waves", waves, "done", progress.Done, "total", len(ids), "failed", progress.Failed)
+	}
+	return results
+}
+
+func restartOne(id string) BatchControlResult {
+	res := BatchControlResult{ProjectID: id}
+	p, exists := project.GetProject(id)
+	if !exists {
+		// Deleted while the restart was running
+		res.Error = "project not found"
+		return res
+	}
+	if err := bulkRestartProject(p); err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Success = true
+	}
+	res.ProjectStatus = string(p.Status)
+	return res
+}
+
+func updateBulkRestart(update func(*BulkRestartProgress)) {
+	bulkRestartMu.Lock()
+	defer bulkRestartMu.Unlock()
+	update(&bulkRestartProgress)
+}
+
+func getBulkRestartProgress() BulkRestartProgress {
+	bulkRestartMu.Lock()
+	defer bulkRestartMu.Unlock()
+	return bulkRestartProgress
+}
+
+// restartableProjects returns the sorted ids of the projects a bulk restart applies to: those running
+// or in error. Stopped projects stay stopped.
+func restartableProjects() []string {
+	ids := make([]string, 0)
+	project.ForEachProject(func(id string, p *project.Project) bool {
+		if p.Status == common.StatusRunning || p.Status == common.StatusError {
+			ids = append(ids, id)
+		}
+		return true
+	})
+	sort.Strings(ids)
+	return ids
+}
+
+// restartAllProjects restarts every running or failed project in waves, see restartInWaves.
+// Only one bulk restart runs at a time; GET /restart-all-projects/progress follows it.
+func restartAllProjects(c echo.Context) error {
+	var req BulkRestartRequest
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+		}
+	}
+	concurrency, stagger, err := projectStartSettings(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	ids := restartableProjects()
+	bulkRestartMu.Lock()
+	if bulkRestartProgress.Running {
+		bulkRestartMu.Unlock()
+		return c.JSON(http.StatusConflict, map[string]string{"error": "a bulk restart is already running"})
+	}
+	now := time.Now()
+	bulkRestartProgress = BulkRestartProgress{Running: true, Total: len(ids), Concurrency: concurrency, StartedAt: &now}
+	bulkRestartMu.Unlock()
+
+	results := restartInWaves(ids, concurrency, stagger)
+	updateBulkRestart(func(p *BulkRestartProgress) {
+		finished := time.Now()
+		p.Running = false
+		p.FinishedAt = &finished
+	})
+
+	progress := getBulkRestartProgress()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"matched":     len(ids),
+		"succeeded":   progress.Done - progress.Failed,
+		"failed":      progress.Failed,
+		"waves":       progress.Waves,
+		"concurrency": concurrency,
+		"results":     results,
+	})
+}
+
+// getRestartAllProgress returns the progress of the running or last bulk restart
+func getRestartAllProgress(c echo.Context) error {
+	return c.JSON(http.StatusOK, getBulkRestartProgress())
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRestartInWavesLimitsConcurrentStarts(t *testing.T) {
+	ids := []string{"bulk_1", "bulk_2", "bulk_3", "bulk_4", "bulk_5"}
+	withBatchProjects(t, ids...)
+
+	var mu sync.Mutex
+	starting, maxStarting := 0, 0
+	prev := bulkRestartProject
+	defer func() { bulkRestartProject = prev }()
+	bulkRestartProject = func(p *project.Project) error {
+		mu.Lock()
+		p.Status = common.StatusStarting
+		starting++
+		if starting > maxStarting {
+			maxStarting = starting
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		p.Status = common.StatusRunning
+		starting--
+		mu.Unlock()
+		return nil
+	}
+
+	// restartInWaves only advances the progress, which a bulk restart resets before calling it
+	updateBulkRestart(func(p *BulkRestartProgress) { *p = BulkRestartProgress{} })
+	results := restartInWaves(ids, 2, time.Millisecond)
+	if maxStarting != 2 {
+		t.Fatalf("expected at most 2 projects starting at once, saw %d", maxStarting)
+	}
+	for i, res := range results {
+		if res.ProjectID != ids[i] || !res.Success || res.ProjectStatus != string(common.StatusRunning) {
+			t.Fatalf("unexpected result %d: %+v", i, res)
+		}
+	}
+	if progress := getBulkRestartProgress(); progress.Done != 5 || progress.Waves != 3 || progress.Wave != 3 {
+		t.Fatalf("unexpected progress %+v", progress)
+	}
+}
+
+func TestProjectStartSettings(t *testing.T) {
+	concurrency, stagger, err := projectStartSettings(BulkRestartRequest{Concurrency: 3, Stagger: "2s"})
+	if err != nil || concurrency != 3 || stagger != 2*time.Second {
+		t.Fatalf("got %d %v %v", concurrency, stagger, err)
+	}
+	if _, _, err := projectStartSettings(BulkRestartRequest{Stagger: "later"}); err == nil {
+		t.Fatal("expected an invalid stagger rejected")
+	}
+}
@@ -43,6 +43,64 @@ func syncProjectOperationToFollowers(projectID, action string) {
 	}
 }
 
+// startProject persists the user intention, syncs followers and starts the project locally
+func startProject(p *project.Project) error {
+	// API-side persistence: Save project states in Redis
+	// proj_states: User intention (what user wants the project to be)
+	if err := common.SetProjectUserIntention(p.Id, true); err != nil {
+		logger.Warn("Failed to persist project user intention to Redis (proj_states)", "project", p.Id, "error", err)
+	}
+
+	// Sync operation to follower nodes FIRST - ensure cluster consistency regardless of local result
+	syncProjectOperationToFollowers(p.Id, "start")
+
+	// Start the project
+	if err := p.Start(true); err != nil {
+		// Record failed operation
+		RecordProjectOperation(OpTypeProjectStart, p.Id, "failed", err.Error(), nil)
+		return err
+	}
+
+	// Record successful operation
+	RecordProjectOperation(OpTypeProjectStart, p.Id, "success", "", nil)
+	return nil
+}
+
+// stopProject syncs followers, stops the project locally and persists the user intention
+func stopProject(p *project.Project) error {
+	// Sync operation to follower nodes FIRST - ensure cluster consistency regardless of local result
+	syncProjectOperationToFollowers(p.Id, "stop")
+
+	// Stop the project
+	if err := p.Stop(true); err != nil {
+		// Record failed operation
+		RecordProjectOperation(OpTypeProjectStop, p.Id, "failed", err.Error(), nil)
+		return err
+	}
+
+	// API-side persistence: Update project states in Redis
+	// proj_states: User intention (user wants project to be stopped)
+	if err := common.SetProjectUserIntention(p.Id, false); err != nil {
+		logger.Warn("Failed to update project user intention to Redis (proj_states)", "project", p.Id, "error", err)
+	}
+
+	// Record successful operation
+	RecordProjectOperation(OpTypeProjectStop, p.Id, "success", "", nil)
+	return nil
+}
+
+// restartProject syncs followers and restarts the project locally
+func restartProject(p *project.Project) error {
+	// Sync operation to follower nodes FIRST - ensure cluster consistency regardless of local result
+	syncProjectOperationToFollowers(p.Id, "restart")
+
+	if err := p.Restart(true, "api"); err != nil {
+		logger.Error("Failed to restart project after component change", "project_id", p.Id, "error", err)
+		return err
+	}
+	return nil
+}
+
 func StartProject(c echo.Context) error {
 	var req CtrlProjectRequest
 	if err := c.Bind(&req); err != nil {
@@ -59,27 +117,12 @@ func StartProject(c echo.Context) error {
 		})
 	}
 
-	// API-side persistence: Save project states in Redis
-	// proj_states: User intention (what user wants the project to be)
-	if err := common.SetProjectUserIntention(req.ProjectID, true); err != nil {
-		logger.Warn("Failed to persist project user intention to Redis (proj_states)", "project", req.ProjectID, "error", err)
-	}
-
-	// Sync operation to follower nodes FIRST - ensure cluster consistency regardless of local result
-	syncProjectOperationToFollowers(req.ProjectID, "start")
-
-	// Start the project
-	if err := p.Start(true); err != nil {
-		// Record failed operation
-		RecordProjectOperation(OpTypeProjectStart, req.ProjectID, "failed", err.Error(), nil)
+	if err := startProject(p); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": fmt.Sprintf("Failed to start project: %v", err),
 		})
 	}
 
-	// Record successful operation
-	RecordProjectOperation(OpTypeProjectStart, req.ProjectID, "success", "", nil)
-
 	return c.JSON(http.StatusOK, map[string]string{"message": "Project started successfully"})
 }
 
@@ -99,27 +142,12 @@ func StopProject(c echo.Context) error {
 		})
 	}
 
-	// Sync operation to follower nodes FIRST - ensure cluster consistency regardless of local result
-	syncProjectOperationToFollowers(req.ProjectID, "stop")
-
-	// Stop the project
-	if err := p.Stop(true); err != nil {
-		// Record failed operation
-		RecordProjectOperation(OpTypeProjectStop, req.ProjectID, "failed", err.Error(), nil)
+	if err := stopProject(p); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": fmt.Sprintf("Failed to stop project: %v", err),
 		})
 	}
 
-	// API-side persistence: Update project states in Redis
-	// proj_states: User intention (user wants project to be stopped)
-	if err := common.SetProjectUserIntention(req.ProjectID, false); err != nil {
-		logger.Warn("Failed to update project user intention to Redis (proj_states)", "project", req.ProjectID, "error", err)
-	}
-
-	// Record successful operation
-	RecordProjectOperation(OpTypeProjectStop, req.ProjectID, "success", "", nil)
-
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"status":  "success",
 		"message": "Project stopped successfully",
@@ -146,12 +174,7 @@ func RestartProject(c echo.Context) error {
 		})
 	}
 
-	// Sync operation to follower nodes FIRST - ensure cluster consistency regardless of local result
-	syncProjectOperationToFollowers(req.ProjectID, "restart")
-
-	err := p.Restart(true, "api")
-	if err != nil {
-		logger.Error("Failed to restart project after component change", "project_id", req.ProjectID, "error", err)
+	if err := restartProject(p); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": fmt.Sprintf("Failed to restart project: %v", err),
 		})
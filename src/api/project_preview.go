@@ -0,0 +1,24 @@
+package api
+
+import (
+	"AgentSmith-HUB/project"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// previewProject validates a project DSL without saving it and returns its resolved topology, the
+// components it references that do not exist and the validation errors with their lines
+func previewProject(c echo.Context) error {
+	var req struct {
+		Raw string `json:"raw"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	if strings.TrimSpace(req.Raw) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "raw cannot be empty"})
+	}
+	return c.JSON(http.StatusOK, project.Preview(req.Raw))
+}
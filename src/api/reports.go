@@ -0,0 +1,30 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getReports returns the stored rule activity reports, newest first
+func getReports(c echo.Context) error {
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+		}
+		limit = n
+	}
+
+	reports, err := common.GetRuleActivityReports(limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read reports: " + err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"reports": reports,
+		"enabled": common.Config != nil && common.Config.RuleReport.Enabled,
+	})
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"AgentSmith-HUB/project"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/labstack/echo/v4"
+)
+
+// backupSuffix marks the previous formal version of a component, kept on each apply
+const backupSuffix = ".bak"
+
+// restartProjects restarts the projects depending on a reloaded component in the background,
+// replaced in tests
+var restartProjects = func(projectIDs []string, triggeredBy string) {
+	go func() {
+		for _, id := range projectIDs {
+			if p, ok := project.GetProject(id); ok {
+				if err := p.Restart(true, triggeredBy); err != nil {
+					logger.Error("Failed to restart project after reload", "project_id", id, "trigger", triggeredBy, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// formalComponentPath returns the file of the formal version of a component
+func formalComponentPath(componentType, id string) (string, error) {
+	configRoot := common.Config.ConfigRoot
+	switch componentType {
+	case "input":
+		return path.Join(configRoot, "input", id+".yaml"), nil
+	case "output":
+		return path.Join(configRoot, "output", id+".yaml"), nil
+	case "ruleset":
+		return path.Join(configRoot, "ruleset", id+".xml"), nil
+	case "project":
+		return path.Join(configRoot, "project", id+".yaml"), nil
+	case "plugin":
+		return path.Join(configRoot, "plugin", id+".go"), nil
+	default:
+		return "", fmt.Errorf("unsupported component type: %s", componentType)
+	}
+}
+
+// backupFormalFile keeps the formal version a write is about to replace as <file>.bak. Nothing is
+// kept for new components or when the content does not change, so the backup stays the last
+// different version.
+func backupFormalFile(filePath, newContent string) {
+	current, err := os.ReadFile(filePath)
+	if err != nil || string(current) == newContent {
+		return
+	}
+	if err := os.WriteFile(filePath+backupSuffix, current, 0644); err != nil {
+		logger.Warn("Failed to keep the previous component version", "path", filePath, "error", err)
+	}
+}
+
+// rollbackComponent restores the previous formal version of a component from its backup, e.g.
+// POST /inputs/:id/rollback. The restored version is verified, reloaded and synced like an apply, and
+// the projects using the component restart. The version rolled back from becomes the new backup.
+func rollbackComponent(componentType string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Param("id")
+		filePath, err := formalComponentPath(componentType, id)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		lock, resp := lockApply(c)
+		if lock == nil {
+			return resp
+		}
+		defer unlockApply(lock)
+
+		previous, err := os.ReadFile(filePath + backupSuffix)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("no previous version of %s %s to roll back to", componentType, id)})
+		}
+		current, err := os.ReadFile(filePath)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("%s %s not found", componentType, id)})
+		}
+
+		affectedProjects, err := reloadComponentUnified(&ComponentReloadRequest{
+			Type:        componentType,
+			ID:          id,
+			NewContent:  string(previous),
+			OldContent:  string(current),
+			Source:      SourceRollback,
+			WriteToFile: true,
+		})
+		if err != nil {
+			logger.Error("Failed to roll back component", "type", componentType, "id", id, "error", err)
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to roll back: " + err.Error()})
+		}
+
+		if len(affectedProjects) > 0 {
+			logger.Info("Restarting projects after rollback", "type", componentType, "id", id, "count", len(affectedProjects))
+			restartProjects(affectedProjects, "rollback")
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message":             fmt.Sprintf("%s %s rolled back to its previous version", componentType, id),
+			"type":                componentType,
+			"id":                  id,
+			"projects_to_restart": affectedProjects,
+		})
+	}
+}
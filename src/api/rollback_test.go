@@ -0,0 +1,107 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/project"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const rollbackInputV1 = `type: kafka
+kafka:
+  brokers:
+    - 127.0.0.1:9092
+  group: hub
+  topic: events_v1
+`
+
+const rollbackInputV2 = `type: kafka
+kafka:
+  brokers:
+    - 127.0.0.1:9092
+  group: hub
+  topic: events_v2
+`
+
+func TestRollbackRestoresPreviousVersion(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "input"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	prevConfig := common.Config
+	common.Config = &common.HubConfig{ConfigRoot: root}
+	defer func() { common.Config = prevConfig }()
+
+	store := &memoryLocks{held: make(map[string]bool)}
+	prevLock, prevAffected, prevRecord, prevRestart := newApplyLock, getAffectedProjects, recordChangePush, restartProjects
+	var restarted []string
+	newApplyLock = func(time.Duration) applyLock { return &memoryLock{store: store} }
+	getAffectedProjects = func(componentType, id string) []string {
+		if componentType == "input" && id == "rollback_in" {
+			return []string{"rollback_proj"}
+		}
+		return nil
+	}
+	recordChangePush = func(componentType, componentID, oldContent, newContent, diff, status, errorMsg string) {}
+	restartProjects = func(projectIDs []string, triggeredBy string) { restarted = append(restarted, projectIDs...) }
+	defer func() {
+		newApplyLock, getAffectedProjects, recordChangePush, restartProjects = prevLock, prevAffected, prevRecord, prevRestart
+	}()
+
+	filePath := filepath.Join(root, "input", "rollback_in.yaml")
+	if err := os.WriteFile(filePath, []byte(rollbackInputV1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	in, err := input.NewInput(filePath, "", "rollback_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	project.SetInput("rollback_in", in)
+	defer project.DeleteInput("rollback_in")
+
+	// Applying a new version keeps the previous one
+	if _, err := reloadComponentUnified(&ComponentReloadRequest{
+		Type: "input", ID: "rollback_in", NewContent: rollbackInputV2, OldContent: rollbackInputV1,
+		Source: SourceChangePush, WriteToFile: true,
+	}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if backup, _ := os.ReadFile(filePath + backupSuffix); string(backup) != rollbackInputV1 {
+		t.Fatalf("expected the previous version kept, got %q", backup)
+	}
+	restarted = nil
+
+	req := httptest.NewRequest(http.MethodPost, "/inputs/rollback_in/rollback", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("rollback_in")
+	if err := rollbackComponent("input")(c); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the rollback to succeed, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if content, _ := os.ReadFile(filePath); string(content) != rollbackInputV1 {
+		t.Fatalf("expected the exact prior content restored, got %q", content)
+	}
+	reloaded, ok := project.GetInput("rollback_in")
+	if !ok || reloaded.Config.RawConfig != rollbackInputV1 || reloaded.Config.Kafka.Topic != "events_v1" {
+		t.Fatalf("expected the running definition reloaded from the prior version, got %+v", reloaded.Config)
+	}
+	if !reflect.DeepEqual(restarted, []string{"rollback_proj"}) {
+		t.Fatalf("expected the projects using the input to restart, got %v", restarted)
+	}
+	if backup, _ := os.ReadFile(filePath + backupSuffix); string(backup) != rollbackInputV2 {
+		t.Fatalf("expected the rolled back version kept as the new backup, got %q", backup)
+	}
+}
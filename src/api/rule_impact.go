@@ -0,0 +1,189 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"AgentSmith-HUB/rules_engine"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultRuleImpactSamples = 100
+	maxRuleImpactSamples     = 1000
+)
+
+// RuleImpact summarizes how deleting a rule changes the outcome for a set of events
+type RuleImpact struct {
+	RulesetID string `json:"ruleset_id"`
+	RuleID    string `json:"rule_id"`
+	Evaluated int    `json:"evaluated"`
+	// RuleHits counts events on which the deleted rule itself matched
+	RuleHits int `json:"rule_hits"`
+	// MatchedBefore/MatchedAfter count events that hit (DETECTION) or were filtered (EXCLUDE)
+	MatchedBefore int `json:"matched_before"`
+	MatchedAfter  int `json:"matched_after"`
+	// Lost events match now but would no longer match without the rule; Kept still match
+	Lost int `json:"lost"`
+	Kept int `json:"kept"`
+}
+
+// rulesetMatches reports whether the ruleset "matches" an event: any hit for DETECTION,
+// the event being filtered for EXCLUDE
+func rulesetMatches(rs *rules_engine.Ruleset, event map[string]interface{}) (bool, []map[string]interface{}) {
+	results := rs.EngineCheck(common.MapDeepCopy(event))
+	if rs.IsDetection {
+		return len(results) > 0, results
+	}
+	return len(results) == 0, results
+}
+
+// computeRuleImpact runs events through the ruleset with and without the rule and counts the delta
+func computeRuleImpact(with, without *rules_engine.Ruleset, ruleID string, events []map[string]interface{}) RuleImpact {
+	impact := RuleImpact{
+		RulesetID: with.RulesetID,
+		RuleID:    ruleID,
+	}
+	hitID := with.RulesetID + "." + ruleID
+
+	for _, event := range events {
+		impact.Evaluated++
+
+		before, results := rulesetMatches(with, event)
+		after, _ := rulesetMatches(without, event)
+
+		if before {
+			impact.MatchedBefore++
+			if after {
+				impact.Kept++
+			} else {
+				impact.Lost++
+			}
+		}
+		if after {
+			impact.MatchedAfter++
+		}
+
+		if with.IsDetection {
+			for _, res := range results {
+				if hits, ok := res[rules_engine.HitRuleIdFieldName].(string); ok && containsHitRule(hits, hitID) {
+					impact.RuleHits++
+					break
+				}
+			}
+		} else if before && !after {
+			// For EXCLUDE the deleted rule is the one that filtered the event
+			impact.RuleHits++
+		}
+	}
+	return impact
+}
+
+func containsHitRule(hits, hitID string) bool {
+	for _, h := range strings.Split(hits, ",") {
+		if h == hitID {
+			return true
+		}
+	}
+	return false
+}
+
+// recentRulesetSamples returns up to limit sampled input events of a ruleset
+func recentRulesetSamples(rulesetID string, limit int) []map[string]interface{} {
+	events := make([]map[string]interface{}, 0)
+	sampler := common.GetSampler("ruleset." + rulesetID)
+	if sampler == nil {
+		return events
+	}
+	for _, samples := range sampler.GetSamples() {
+		for _, sample := range samples {
+			if data, ok := sample.Data.(map[string]interface{}); ok {
+				events = append(events, data)
+				if len(events) >= limit {
+					return events
+				}
+			}
+		}
+	}
+	return events
+}
+
+// previewRuleDeletionImpact reports which recent events would lose their match if a rule were deleted.
+// Nothing is changed; use DELETE /rulesets/:id/rules/:ruleId to actually remove the rule.
+func previewRuleDeletionImpact(c echo.Context) error {
+	rulesetID := c.Param("id")
+	ruleID := c.Param("ruleId")
+
+	var req struct {
+		Limit int                      `json:"limit,omitempty"`
+		Data  []map[string]interface{} `json:"data,omitempty"` // Optional events used instead of samples
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultRuleImpactSamples
+	}
+	if req.Limit > maxRuleImpactSamples {
+		req.Limit = maxRuleImpactSamples
+	}
+
+	// Prefer the pending version so authors see the impact on what they are editing
+	var currentRaw string
+	var isTemp bool
+	existing, exists := project.GetRuleset(rulesetID)
+	if tempRaw, ok := project.GetRulesetNew(rulesetID); ok {
+		currentRaw = tempRaw
+		isTemp = true
+	} else if exists {
+		currentRaw = existing.RawConfig
+	} else {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "ruleset not found"})
+	}
+
+	updatedXML, err := removeRuleFromXML(currentRaw, ruleID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var with *rules_engine.Ruleset
+	if isTemp {
+		with, err = rules_engine.NewRuleset("", currentRaw, rulesetID)
+	} else {
+		with, err = rules_engine.NewFromExisting(existing, fmt.Sprintf("impact_%d", time.Now().UnixNano()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to build current ruleset: " + err.Error()})
+	}
+	defer with.CloseCaches()
+	without, err := rules_engine.NewRuleset("", updatedXML, rulesetID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "ruleset is invalid after rule deletion",
+			"details": err.Error(),
+		})
+	}
+	defer without.CloseCaches()
+	// The rulesets are never started; disable sampling so the preview does not feed back into samples
+	with.SetTestMode()
+	without.SetTestMode()
+
+	events := req.Data
+	source := "request"
+	if len(events) == 0 {
+		events = recentRulesetSamples(rulesetID, req.Limit)
+		source = "samples"
+	} else if len(events) > req.Limit {
+		events = events[:req.Limit]
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"impact":      computeRuleImpact(with, without, ruleID, events),
+		"data_source": source,
+		"is_temp":     isTemp,
+	})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"AgentSmith-HUB/rules_engine"
+	"testing"
+)
+
+const impactRuleset = `<root type="DETECTION" name="impact">
+  <rule id="r_login" name="login">
+    <check type="EQU" field="action">login</check>
+  </rule>
+  <rule id="r_admin" name="admin">
+    <check type="EQU" field="user">admin</check>
+  </rule>
+</root>`
+
+func TestComputeRuleImpact(t *testing.T) {
+	with, err := rules_engine.NewRuleset("", impactRuleset, "impact_rs")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	withoutXML, err := removeRuleFromXML(impactRuleset, "r_login")
+	if err != nil {
+		t.Fatalf("removeRuleFromXML: %v", err)
+	}
+	without, err := rules_engine.NewRuleset("", withoutXML, "impact_rs")
+	if err != nil {
+		t.Fatalf("NewRuleset without rule: %v", err)
+	}
+
+	// Sampled events: two only hit r_login, one hits both rules, one hits only r_admin, one hits nothing
+	events := []map[string]interface{}{
+		{"action": "login", "user": "bob"},
+		{"action": "login", "user": "eve"},
+		{"action": "login", "user": "admin"},
+		{"action": "logout", "user": "admin"},
+		{"action": "logout", "user": "bob"},
+	}
+
+	impact := computeRuleImpact(with, without, "r_login", events)
+	want := RuleImpact{
+		RulesetID:     "impact_rs",
+		RuleID:        "r_login",
+		Evaluated:     5,
+		RuleHits:      3,
+		MatchedBefore: 4,
+		MatchedAfter:  2,
+		Lost:          2,
+		Kept:          2,
+	}
+	if impact != want {
+		t.Fatalf("impact = %+v, want %+v", impact, want)
+	}
+
+	// Events must not be modified by the preview
+	if _, ok := events[0][rules_engine.HitRuleIdFieldName]; ok {
+		t.Fatal("preview modified the sampled event")
+	}
+}
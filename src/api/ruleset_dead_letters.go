@@ -0,0 +1,29 @@
+package api
+
+import (
+	"AgentSmith-HUB/rules_engine"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getRulesetDeadLetters returns the newest dead letters of a ruleset
+// Query parameters:
+//   - limit: number of entries, newest first (default 100, max 1000)
+func getRulesetDeadLetters(c echo.Context) error {
+	id := c.Param("id")
+	limit := defaultDeadLetterLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+		}
+		limit = min(n, maxDeadLetterLimit)
+	}
+	letters, err := rules_engine.DeadLetters(id, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read dead letters: " + err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"ruleset_id": id, "dead_letters": letters})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getRulesetPool returns the worker pool utilization of every instance of a ruleset on the node
+// serving the request, one per project using it
+func getRulesetPool(c echo.Context) error {
+	id := c.Param("id")
+	if _, exists := project.GetRuleset(id); !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "ruleset not found"})
+	}
+
+	instances := make([]map[string]interface{}, 0)
+	project.ForEachProject(func(projId string, proj *project.Project) bool {
+		// Project components are keyed by their sequence, e.g. RULESET.<id> or PROJECT.<p>.RULESET.<id>
+		for _, rs := range proj.Rulesets {
+			if rs.RulesetID != id {
+				continue
+			}
+			instances = append(instances, map[string]interface{}{
+				"project":               projId,
+				"project_node_sequence": rs.ProjectNodeSequence,
+				"status":                rs.Status,
+				"pool":                  rs.PoolStats(),
+			})
+		}
+		return true
+	})
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i]["project_node_sequence"].(string) < instances[j]["project_node_sequence"].(string)
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"node_id":    common.Config.LocalIP,
+		"ruleset_id": id,
+		"instances":  instances,
+	})
+}
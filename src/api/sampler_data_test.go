@@ -0,0 +1,65 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"testing"
+	"time"
+)
+
+func sampleFlows(base time.Time) map[string][]common.SampleData {
+	flows := map[string][]common.SampleData{}
+	for i := 0; i < 5; i++ {
+		flow := "INPUT.in.RULESET.a"
+		if i%2 == 1 {
+			flow = "INPUT.in.RULESET.b"
+		}
+		flows[flow] = append(flows[flow], common.SampleData{
+			Data:                map[string]interface{}{"n": i},
+			Timestamp:           base.Add(time.Duration(i) * time.Minute),
+			ProjectNodeSequence: flow,
+		})
+	}
+	return flows
+}
+
+func pagedNumbers(page map[string][]common.SampleData) map[int]bool {
+	got := map[int]bool{}
+	for _, list := range page {
+		for _, sample := range list {
+			got[sample.Data.(map[string]interface{})["n"].(int)] = true
+		}
+	}
+	return got
+}
+
+func TestPageSamples_LimitOffset(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	page, total := pageSamples(sampleFlows(base), time.Time{}, 1, 2)
+	if total != 5 {
+		t.Fatalf("expected 5 samples before paging, got %d", total)
+	}
+	// Newest first: 4 3 2 1 0, so offset 1 limit 2 is 3 and 2, one from each flow
+	if got := pagedNumbers(page); len(got) != 2 || !got[3] || !got[2] {
+		t.Fatalf("expected samples 3 and 2, got %v", got)
+	}
+	if len(page["INPUT.in.RULESET.a"]) != 1 || len(page["INPUT.in.RULESET.b"]) != 1 {
+		t.Fatalf("expected the page grouped by flow path, got %v", page)
+	}
+
+	if page, _ := pageSamples(sampleFlows(base), time.Time{}, 10, 2); len(page) != 0 {
+		t.Fatalf("expected an empty page past the end, got %v", page)
+	}
+}
+
+func TestPageSamples_Since(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	page, total := pageSamples(sampleFlows(base), base.Add(2*time.Minute), 0, 0)
+	if total != 2 {
+		t.Fatalf("expected 2 samples after the since time, got %d", total)
+	}
+	if got := pagedNumbers(page); !got[3] || !got[4] {
+		t.Fatalf("expected samples 3 and 4, got %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"regexp"
+)
+
+// defaultSearchMaskPatterns match key names whose values are masked in search results
+var defaultSearchMaskPatterns = []string{"password", "passwd", "secret", "token", `api[_-]?key`, `access[_-]?key`, "authorization", "credential"}
+
+// searchMaskRegexps compiles the default and configured search_mask_patterns into matchers for
+// "key: value", "key = value", "key": "value" and key="value" assignments
+func searchMaskRegexps() []*regexp.Regexp {
+	patterns := append([]string{}, defaultSearchMaskPatterns...)
+	patterns = append(patterns, common.ReadConfig(func(c *common.HubConfig) []string { return c.SearchMaskPatterns })...)
+
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(fmt.Sprintf(`(?i)(["']?[\w.-]*(?:%s)[\w.-]*["']?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,;}<>]+)`, p))
+		if err != nil {
+			logger.Warn("Ignoring invalid search mask pattern", "pattern", p, "error", err)
+			continue
+		}
+		res = append(res, re)
+	}
+	return res
+}
+
+// maskSecrets replaces the values assigned to secret-looking keys in line, keeping quotes so the
+// structure of the line stays readable
+func maskSecrets(line string, matchers []*regexp.Regexp) string {
+	for _, re := range matchers {
+		line = re.ReplaceAllStringFunc(line, func(m string) string {
+			sub := re.FindStringSubmatch(m)
+			value := sub[2]
+			masked := "***"
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+				masked = string(value[0]) + masked + string(value[0])
+			}
+			return sub[1] + masked
+		})
+	}
+	return line
+}
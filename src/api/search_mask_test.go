@@ -0,0 +1,58 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"strings"
+	"testing"
+)
+
+func TestSearchInContent_MasksSecrets(t *testing.T) {
+	content := strings.Join([]string{
+		"type: kafka",
+		"  password: abc123",
+		`  sasl: {"username": "hub", "api_key": "k-999"}`,
+		`<check type="EQU" field="token">t0k3n</check>`,
+		"  access_key_secret = s3cr3t",
+		"  topic: passwords-audit",
+	}, "\n")
+	maskers := searchMaskRegexps()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"password", "password: ***"},
+		{"api_key", `{"username": "hub", "api_key": "***"}`},
+		{"access_key", "access_key_secret = ***"},
+		{"topic", "topic: passwords-audit"},
+	}
+	for _, tt := range tests {
+		matches := searchInContent(content, tt.query, maskers)
+		if len(matches) == 0 {
+			t.Fatalf("query %q: expected a match", tt.query)
+		}
+		if !strings.Contains(matches[0].LineContent, tt.want) {
+			t.Fatalf("query %q: expected %q in %q", tt.query, tt.want, matches[0].LineContent)
+		}
+	}
+
+	// Searching for the secret itself must not return the line that holds it
+	if matches := searchInContent(content, "abc123", maskers); len(matches) != 0 {
+		t.Fatalf("secret value confirmed by search: %+v", matches)
+	}
+	// Check values are not key/value assignments and stay searchable
+	if matches := searchInContent(content, "t0k3n", maskers); len(matches) != 1 {
+		t.Fatalf("expected the check line to match, got %+v", matches)
+	}
+}
+
+func TestSearchMaskPatterns_Configurable(t *testing.T) {
+	saved := common.Config
+	common.Config = &common.HubConfig{SearchMaskPatterns: []string{`private[_-]?key`, "("}}
+	defer func() { common.Config = saved }()
+
+	matches := searchInContent("tls_private_key: /etc/hub/key.pem", "tls", searchMaskRegexps())
+	if len(matches) != 1 || matches[0].LineContent != "tls_private_key: ***" {
+		t.Fatalf("configured pattern not applied: %+v", matches)
+	}
+}
@@ -44,6 +44,9 @@ func ServerStart(listener string) error {
 		Format: `{"time":"${time_rfc3339}","id":"${id}","remote_ip":"${remote_ip}","host":"${host}","method":"${method}","uri":"${uri}","user_agent":"${user_agent}","status":${status},"error":"${error}","latency":${latency},"latency_human":"${latency_human}","bytes_in":${bytes_in},"bytes_out":${bytes_out}}` + "\n",
 	}))
 	e.Use(middleware.Recover())
+	// Compress responses for clients that send Accept-Encoding: gzip, or deflate without gzip
+	e.Use(gzipMiddleware())
+	e.Use(deflateMiddleware())
 
 	// Authentication middleware will be applied selectively via AuthenticateRequest
 
@@ -60,6 +63,8 @@ func ServerStart(listener string) error {
 	e.GET("/system-stats", getSystemStats)
 	e.GET("/cluster-system-metrics", getClusterSystemMetrics)
 	e.GET("/cluster-system-stats", getClusterSystemStats)
+	e.GET("/metrics", getPrometheusMetrics)
+	e.GET("/output-latency", getOutputLatency)
 	e.GET("/cluster-status", getClusterStatus)
 	e.GET("/cluster", getCluster)
 
@@ -81,27 +86,44 @@ func ServerStart(listener string) error {
 	auth.GET("/projects", getProjects)
 	auth.GET("/projects/:id", getProject)
 	auth.POST("/projects", createProject)
+	auth.POST("/projects/preview", previewProject)
 	auth.DELETE("/projects/:id", deleteProject)
+	auth.POST("/projects/:id/rollback", rollbackComponent("project"))
 	auth.PUT("/projects/:id", updateProject)
 	auth.POST("/start-project", StartProject)
 	auth.POST("/stop-project", StopProject)
 	auth.POST("/restart-project", RestartProject)
+	auth.POST("/projects/batch-control", batchControlProjects)
+	auth.POST("/restart-all-projects", restartAllProjects)
+	auth.GET("/restart-all-projects/progress", getRestartAllProgress)
 	auth.GET("/project-error/:id", getProjectError)
 	auth.GET("/project-inputs/:id", getProjectInputs)
 	auth.GET("/project-components/:id", getProjectComponents)
+	auth.POST("/projects/:id/validate-fields", validateProjectFields)
+	auth.GET("/projects/:id/operations", getProjectOperations)
 	auth.GET("/project-component-sequences/:id", getProjectComponentSequences)
 	auth.GET("/cluster-project-states", getClusterProjectStates)
 
 	// Ruleset endpoints (use plural form for consistency) - REQUIRE AUTH
 	auth.GET("/rulesets", getRulesets)
 	auth.GET("/rulesets/:id", getRuleset)
+	auth.GET("/rulesets/:id/dead-letters", getRulesetDeadLetters)
+	auth.GET("/rulesets/:id/compiled", getCompiledRuleset)
+	auth.GET("/rulesets/:id/fields", getRulesetFields)
+	auth.GET("/rulesets/:id/cost", getRulesetCost)
+	auth.GET("/rulesets/:id/pool", getRulesetPool)
+	auth.GET("/rulesets/:id/throughput", getComponentThroughput("ruleset"))
+	auth.GET("/rulesets/:id/status-history", getComponentStatusHistory("ruleset"))
 	auth.POST("/rulesets", createRuleset)
 	auth.PUT("/rulesets/:id", updateRuleset)
 	auth.DELETE("/rulesets/:id", deleteRuleset)
+	auth.POST("/rulesets/:id/rollback", rollbackComponent("ruleset"))
 
 	// Ruleset rule management endpoints - REQUIRE AUTH
 	auth.DELETE("/rulesets/:id/rules/:ruleId", deleteRulesetRule)
 	auth.POST("/rulesets/:id/rules", addRulesetRule)
+	auth.POST("/rulesets/:id/rules/:ruleId/impact", previewRuleDeletionImpact)
+	auth.GET("/rulesets/:id/tuning-bundle", getTuningBundle)
 
 	// Ruleset templates and documentation - REQUIRE AUTH (Updated to use MCP module)
 	auth.GET("/ruleset-templates", mcp.GetRulesetTemplates)
@@ -114,13 +136,25 @@ func ServerStart(listener string) error {
 	auth.POST("/inputs", createInput)
 	auth.PUT("/inputs/:id", updateInput)
 	auth.DELETE("/inputs/:id", deleteInput)
+	auth.POST("/inputs/:id/rollback", rollbackComponent("input"))
+	auth.GET("/inputs/:id/offset", getInputOffsetReset)
+	auth.POST("/inputs/:id/offset", setInputOffsetReset)
+	auth.DELETE("/inputs/:id/offset", cancelInputOffsetReset)
+	auth.POST("/inputs/:id/replay-from", replayInputFrom)
+	auth.GET("/inputs/:id/throughput", getComponentThroughput("input"))
+	auth.GET("/inputs/:id/status-history", getComponentStatusHistory("input"))
+	auth.GET("/inputs/:id/dead-letters", getInputDeadLetters)
 
 	// Output endpoints (use plural form for consistency) - REQUIRE AUTH
 	auth.GET("/outputs", getOutputs)
 	auth.GET("/outputs/:id", getOutput)
+	auth.GET("/outputs/:id/dead-letters", getOutputDeadLetters)
 	auth.POST("/outputs", createOutput)
 	auth.PUT("/outputs/:id", updateOutput)
 	auth.DELETE("/outputs/:id", deleteOutput)
+	auth.POST("/outputs/:id/rollback", rollbackComponent("output"))
+	auth.GET("/outputs/:id/throughput", getComponentThroughput("output"))
+	auth.GET("/outputs/:id/status-history", getComponentStatusHistory("output"))
 
 	// Plugin endpoints (use plural form and :id for consistency) - REQUIRE AUTH
 	auth.GET("/plugins", getPlugins)
@@ -128,10 +162,12 @@ func ServerStart(listener string) error {
 	auth.POST("/plugins", createPlugin)
 	auth.PUT("/plugins/:id", updatePlugin)
 	auth.DELETE("/plugins/:id", deletePlugin)
+	auth.POST("/plugins/:id/rollback", rollbackComponent("plugin"))
 	auth.GET("/available-plugins", getPlugins) // Use same handler with different default params
 	auth.GET("/plugin-parameters/:id", GetPluginParameters)
 	auth.GET("/plugin-parameters", GetBatchPluginParameters)
 	auth.GET("/plugins/:id/usage", getPluginUsage)
+	auth.GET("/plugins/:id/dependencies", getPluginDependencies)
 
 	// Component verification and testing - REQUIRE AUTH
 	auth.POST("/verify/:type/:id", verifyComponent)
@@ -139,26 +175,33 @@ func ServerStart(listener string) error {
 	auth.POST("/connect-check/:type/:id", connectCheck)
 	auth.POST("/test-plugin/:id", testPlugin)
 	auth.POST("/test-plugin-content", testPlugin)
+	auth.POST("/test-plugin-samples/:id", testPluginSamples)
 	auth.POST("/test-ruleset/:id", testRuleset)
 	auth.POST("/test-ruleset-content", testRuleset)
 	auth.POST("/test-output/:id", testOutput)
+	auth.POST("/test-input/:id", testInput)
 	auth.POST("/test-project/:id", testProject)
 	auth.POST("/test-project-content/:inputNode", testProject)
 
 	// Cluster management endpoints - REQUIRE AUTH
 	auth.GET("/config_root", leaderConfig)
 	auth.GET("/config/download", downloadConfig)
+	auth.POST("/reload-config", reloadHubConfig)
+	auth.GET("/cluster/nodes", getClusterNodes)
 	auth.GET("/cluster/instruction-stats", getInstructionStats)
 	auth.GET("/cluster/follower-execution-status", getFollowerExecutionStatus)
+	auth.POST("/cluster/simulate-failure", simulateClusterFailure)
 
 	// Pending changes management (enhanced) - REQUIRE AUTH
 	auth.GET("/pending-changes", GetPendingChanges)                  // Legacy endpoint
 	auth.GET("/pending-changes/enhanced", GetEnhancedPendingChanges) // Enhanced endpoint with status info
+	auth.GET("/pending-changes/detailed", GetDetailedPendingChanges) // Status and diff summary per change
 	auth.POST("/apply-single-change", ApplySingleChange)             // Legacy endpoint
 	auth.POST("/apply-changes", ApplyAllChanges)                     // Apply all pending changes
 	auth.POST("/verify-changes", VerifyPendingChanges)               // Verify all changes
 	auth.POST("/verify-change/:type/:id", VerifySinglePendingChange) // Verify single change
 	auth.DELETE("/cancel-change/:type/:id", CancelPendingChange)     // Cancel single change
+	auth.DELETE("/pending-changes/:type/:id", CancelPendingChange)   // Cancel single change
 	auth.DELETE("/cancel-all-changes", CancelAllPendingChanges)      // Cancel all changes
 
 	// Temporary file management - REQUIRE AUTH
@@ -179,12 +222,21 @@ func ServerStart(listener string) error {
 	auth.POST("/cancel-upgrade/projects/:id", cancelProjectUpgrade)
 	auth.POST("/cancel-upgrade/plugins/:id", cancelPluginUpgrade)
 
+	// Component labels - REQUIRE AUTH
+	for _, plural := range []string{"projects", "rulesets", "inputs", "outputs", "plugins"} {
+		auth.GET("/"+plural+"/:id/labels", getComponentLabels)
+		auth.PUT("/"+plural+"/:id/labels", setComponentLabels)
+	}
+
 	// Component usage analysis - REQUIRE AUTH
 	auth.GET("/component-usage/:type/:id", GetComponentUsage)
 
 	// Component configuration search - REQUIRE AUTH
 	auth.GET("/search-components", searchComponentsConfig)
 
+	// Component import from HTTP(S) or Git, staged as temporary files - REQUIRE AUTH
+	auth.POST("/import", importComponents)
+
 	// Load local components routes - REQUIRE AUTH
 	auth.GET("/local-changes", getLocalChanges)
 	auth.GET("/local-changes/count", getLocalChangesCount) // Lightweight count endpoint
@@ -194,6 +246,8 @@ func ServerStart(listener string) error {
 	// Error log endpoints - REQUIRE AUTH
 	auth.GET("/error-logs", getErrorLogs)
 	auth.GET("/error-logs/nodes", getErrorLogNodes)
+	auth.GET("/error-logs/stream", streamErrorLogs)
+	auth.GET("/error-logs/:id/context", getErrorLogContext)
 	auth.GET("/cluster-error-logs", getClusterErrorLogs)
 
 	// Operations history endpoints - REQUIRE AUTH
@@ -202,6 +256,9 @@ func ServerStart(listener string) error {
 	auth.GET("/cluster-operations-history", GetClusterOperationsHistory)
 	auth.GET("/operations-stats", GetOperationsStats)
 
+	// Rule activity reports - REQUIRE AUTH
+	auth.GET("/reports", getReports)
+
 	// MCP (Model Context Protocol) endpoints - REQUIRE AUTH
 	auth.POST("/mcp", handleMCP)              // Main MCP JSON-RPC endpoint
 	auth.GET("/mcp", handleMCP)               // MCP SSE endpoint (for Cline and similar clients)
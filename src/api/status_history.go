@@ -0,0 +1,117 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/project"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+)
+
+// statusTracked is a component instance whose status changes go through a common.StatusMachine
+type statusTracked interface {
+	StatusHistory() []common.StatusTransition
+}
+
+// ComponentStatusInstance is the status history of one running instance of a component. Projects
+// with vars or limits run their own instance, the others share the global one.
+type ComponentStatusInstance struct {
+	Sequence string                    `json:"sequence,omitempty"` // Empty for the global instance
+	Projects []string                  `json:"projects"`
+	Status   common.Status             `json:"status"`
+	History  []common.StatusTransition `json:"history"`
+}
+
+// statusInstanceRef is an instance of a component, either the global one (empty project) or the one
+// a project holds under a sequence
+type statusInstanceRef struct {
+	project  string
+	sequence string
+	instance statusTracked
+	status   common.Status
+}
+
+// componentInstances lists the global instance of a component, then the instances projects hold
+func componentInstances(componentType, id string) []statusInstanceRef {
+	refs := make([]statusInstanceRef, 0)
+	switch componentType {
+	case "input":
+		if in, ok := project.GetInput(id); ok {
+			refs = append(refs, statusInstanceRef{instance: in, status: in.Status})
+		}
+		project.ForEachProject(func(projId string, proj *project.Project) bool {
+			for pns, in := range proj.Inputs {
+				if in.Id == id {
+					refs = append(refs, statusInstanceRef{project: projId, sequence: pns, instance: in, status: in.Status})
+				}
+			}
+			return true
+		})
+	case "output":
+		if out, ok := project.GetOutput(id); ok {
+			refs = append(refs, statusInstanceRef{instance: out, status: out.Status})
+		}
+		project.ForEachProject(func(projId string, proj *project.Project) bool {
+			for pns, out := range proj.Outputs {
+				if out.Id == id {
+					refs = append(refs, statusInstanceRef{project: projId, sequence: pns, instance: out, status: out.Status})
+				}
+			}
+			return true
+		})
+	case "ruleset":
+		if rs, ok := project.GetRuleset(id); ok {
+			refs = append(refs, statusInstanceRef{instance: rs, status: rs.Status})
+		}
+		project.ForEachProject(func(projId string, proj *project.Project) bool {
+			for pns, rs := range proj.Rulesets {
+				if rs.RulesetID == id {
+					refs = append(refs, statusInstanceRef{project: projId, sequence: pns, instance: rs, status: rs.Status})
+				}
+			}
+			return true
+		})
+	}
+	return refs
+}
+
+// getComponentStatusHistory returns the recent status transitions of every instance of a component,
+// with their timestamps and reasons, e.g. GET /inputs/:id/status-history
+func getComponentStatusHistory(componentType string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Param("id")
+		refs := componentInstances(componentType, id)
+		if len(refs) == 0 {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": componentType + " not found"})
+		}
+		// The global instance comes first, then the project instances in a stable order
+		sort.SliceStable(refs, func(i, j int) bool {
+			if refs[i].project != refs[j].project {
+				return refs[i].project < refs[j].project
+			}
+			return refs[i].sequence < refs[j].sequence
+		})
+
+		// Projects sharing an instance are grouped under it
+		byInstance := make(map[statusTracked]*ComponentStatusInstance)
+		instances := make([]*ComponentStatusInstance, 0, len(refs))
+		for _, ref := range refs {
+			entry, ok := byInstance[ref.instance]
+			if !ok {
+				entry = &ComponentStatusInstance{Sequence: ref.sequence, Projects: []string{}, Status: ref.status, History: ref.instance.StatusHistory()}
+				byInstance[ref.instance] = entry
+				instances = append(instances, entry)
+			}
+			if ref.project != "" {
+				entry.Projects = append(entry.Projects, ref.project)
+			}
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"type":      componentType,
+			"id":        id,
+			"instances": instances,
+		})
+	}
+}
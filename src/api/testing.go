@@ -9,6 +9,7 @@ import (
 	"AgentSmith-HUB/project"
 	"AgentSmith-HUB/rules_engine"
 	"fmt"
+	"io"
 	"net/http"
 	"sort"
 	"strings"
@@ -18,6 +19,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// maxTestInputMessageSize bounds the raw message accepted by /test-input
+const maxTestInputMessageSize = 1 << 20
+
 // Helper function to return appropriate error format for ruleset APIs
 func rulesetErrorResponse(isContentMode bool, success bool, errorMsg string) map[string]interface{} {
 	if isContentMode {
@@ -242,21 +246,65 @@ func testPlugin(c echo.Context) error {
 		})
 	}
 
+	pluginToTest, errMsg := loadTestPlugin(id, req.Content)
+	if errMsg != "" {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": false,
+			"error":   errMsg,
+			"result":  nil,
+		})
+	}
+
+	// Process parameter values, try to convert to appropriate types
+	args := make([]interface{}, 0)
+	for _, value := range req.Data {
+		// Convert to appropriate types
+		if str, ok := value.(string); ok {
+			args = append(args, str)
+		} else {
+			// Convert other types to string
+			args = append(args, fmt.Sprintf("%v", value))
+		}
+	}
+
+	result, success, errMsg, fatal := evalTestPlugin(pluginToTest, id, args)
+	if fatal {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": false,
+			"error":   errMsg,
+			"result":  nil,
+		})
+	}
+
+	// Return the result
+	if errMsg != "" {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": false,
+			"error":   errMsg,
+			"result":  result,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": success,
+		"result":  result,
+	})
+}
+
+// loadTestPlugin returns the plugin to test: one built from content when given, otherwise the
+// pending version of plugin id, or the loaded one. Plugins not in the global registry are loaded
+// here. errMsg is set when no plugin can be tested.
+func loadTestPlugin(id string, content string) (*plugin.Plugin, string) {
 	var pluginToTest *plugin.Plugin
 	var isTemporary bool
-	var tempPluginId string
 
 	// If content is provided directly, use it (for /test-plugin-content endpoint)
-	if req.Content != "" {
+	if content != "" {
 		// Create a temporary plugin for testing (isolated from global registry)
-		tempPluginId = fmt.Sprintf("temp_test_content_%d", time.Now().UnixNano())
-		tempPlugin, err := plugin.NewTestPlugin("", req.Content, tempPluginId, plugin.YAEGI_PLUGIN)
+		tempPluginId := fmt.Sprintf("temp_test_content_%d", time.Now().UnixNano())
+		tempPlugin, err := plugin.NewTestPlugin("", content, tempPluginId, plugin.YAEGI_PLUGIN)
 		if err != nil {
-			return c.JSON(http.StatusOK, map[string]interface{}{
-				"success": false,
-				"error":   "Failed to create plugin: " + err.Error(),
-				"result":  nil,
-			})
+			return nil, "Failed to create plugin: " + err.Error()
 		}
 
 		pluginToTest = tempPlugin
@@ -266,17 +314,13 @@ func testPlugin(c echo.Context) error {
 	} else if id != "" {
 		// Original logic to find plugin by ID (for /test-plugin/:id endpoint)
 		// Check if plugin exists in memory
-		p, existsInMemory := plugin.Plugins[id]
+		p, existsInMemory := plugin.GetPlugin(id)
 
 		// Check if plugin exists in temporary files
-		tempContent, existsInTemp := plugin.PluginsNew[id]
+		tempContent, existsInTemp := plugin.GetPluginNew(id)
 
 		if !existsInMemory && !existsInTemp {
-			return c.JSON(http.StatusOK, map[string]interface{}{
-				"success": false,
-				"error":   "Plugin not found: " + id,
-				"result":  nil,
-			})
+			return nil, "Plugin not found: " + id
 		}
 
 		// Prioritize temporary version for testing if it exists
@@ -285,11 +329,7 @@ func testPlugin(c echo.Context) error {
 			tempPluginName := id + "_test_temp"
 			tempPlugin, err := plugin.NewTestPlugin("", tempContent, tempPluginName, plugin.YAEGI_PLUGIN)
 			if err != nil {
-				return c.JSON(http.StatusOK, map[string]interface{}{
-					"success": false,
-					"error":   fmt.Sprintf("Plugin compilation failed: %v", err),
-					"result":  nil,
-				})
+				return nil, fmt.Sprintf("Plugin compilation failed: %v", err)
 			}
 
 			pluginToTest = tempPlugin
@@ -301,30 +341,21 @@ func testPlugin(c echo.Context) error {
 		}
 	} else {
 		// Neither content nor id provided
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"success": false,
-			"error":   "Either plugin ID or content must be provided",
-			"result":  nil,
-		})
+		return nil, "Either plugin ID or content must be provided"
 	}
 
-	// Process parameter values, try to convert to appropriate types
-	args := make([]interface{}, 0)
-	for _, value := range req.Data {
-		// Convert to appropriate types
-		if str, ok := value.(string); ok {
-			args = append(args, str)
-		} else {
-			// Convert other types to string
-			args = append(args, fmt.Sprintf("%v", value))
+	// For temporary plugins, we need to load them first since they're not in the global registry
+	if isTemporary && pluginToTest.Type == plugin.YAEGI_PLUGIN {
+		if err := pluginToTest.YaegiLoad(); err != nil {
+			return nil, fmt.Sprintf("Failed to load temporary plugin: %v", err)
 		}
 	}
+	return pluginToTest, ""
+}
 
-	// Determine plugin type and execute
-	var result interface{}
-	var success bool
-	var errMsg string
-
+// evalTestPlugin runs a plugin loaded by loadTestPlugin once. errMsg reports a failed run; fatal is
+// set when the plugin cannot run at all, and result is then meaningless.
+func evalTestPlugin(pluginToTest *plugin.Plugin, id string, args []interface{}) (result interface{}, success bool, errMsg string, fatal bool) {
 	switch pluginToTest.Type {
 	case plugin.LOCAL_PLUGIN:
 		// Check if it's a boolean result plugin
@@ -347,26 +378,9 @@ func testPlugin(c echo.Context) error {
 				errMsg = fmt.Sprintf("Plugin execution failed: %v", err)
 			}
 		} else {
-			return c.JSON(http.StatusOK, map[string]interface{}{
-				"success": false,
-				"error":   "Plugin exists but function not found",
-				"result":  nil,
-			})
+			return nil, false, "Plugin exists but function not found", true
 		}
 	case plugin.YAEGI_PLUGIN:
-		// For Yaegi plugins, we need to determine the return type
-		if isTemporary {
-			// For temporary plugins, we need to load them first since they're not in the global registry
-			err := pluginToTest.YaegiLoad()
-			if err != nil {
-				return c.JSON(http.StatusOK, map[string]interface{}{
-					"success": false,
-					"error":   fmt.Sprintf("Failed to load temporary plugin: %v", err),
-					"result":  nil,
-				})
-			}
-		}
-
 		// Execute plugin based on return type
 		if pluginToTest.ReturnType == "bool" {
 			// For check-type plugins (bool return type), use FuncEvalCheckNode
@@ -393,26 +407,9 @@ func testPlugin(c echo.Context) error {
 			}
 		}
 	default:
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"success": false,
-			"error":   "Unknown plugin type",
-			"result":  nil,
-		})
+		return nil, false, "Unknown plugin type", true
 	}
-
-	// Return the result
-	if errMsg != "" {
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"success": false,
-			"error":   errMsg,
-			"result":  result,
-		})
-	}
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": success,
-		"result":  result,
-	})
+	return result, success, errMsg, false
 }
 
 func testOutput(c echo.Context) error {
@@ -1924,3 +1921,92 @@ func buildPNSDirect(flowNodes []project.FlowNode) {
 		flowNodes[i].ToPNS = toSequence
 	}
 }
+
+// testInput runs a raw message through an input's parsing (JSON decoding and grok) without
+// connecting to the source. The message is taken from the "raw" field of a JSON body, or from the
+// request body itself when it is not JSON.
+func testInput(c echo.Context) error {
+	id := c.Param("id")
+
+	var raw []byte
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		var req struct {
+			Raw string `json:"raw"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"success": false,
+				"error":   "Invalid request body: " + err.Error(),
+				"result":  nil,
+			})
+		}
+		raw = []byte(req.Raw)
+	} else {
+		body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxTestInputMessageSize+1))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"success": false,
+				"error":   "Failed to read request body: " + err.Error(),
+				"result":  nil,
+			})
+		}
+		raw = body
+	}
+	if len(raw) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   "Raw message is required",
+			"result":  nil,
+		})
+	}
+	if len(raw) > maxTestInputMessageSize {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("Raw message exceeds %d bytes", maxTestInputMessageSize),
+			"result":  nil,
+		})
+	}
+
+	// Prefer the pending version so authors test what they are editing
+	var inputContent string
+	if tempPath, ok := GetComponentPath("input", id, true); ok {
+		if content, err := ReadComponent(tempPath); err == nil {
+			inputContent = content
+		}
+	}
+	if inputContent == "" {
+		if content, ok := project.GetInputNew(id); ok {
+			inputContent = content
+		} else if in, ok := project.GetInput(id); ok {
+			inputContent = in.Config.RawConfig
+		} else {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{
+				"success": false,
+				"error":   "Input not found: " + id,
+				"result":  nil,
+			})
+		}
+	}
+
+	tempInput, err := input.NewInput("", inputContent, "temp_test_"+id)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   "Failed to parse input: " + err.Error(),
+			"result":  nil,
+		})
+	}
+
+	result, err := tempInput.ParseMessage(raw)
+	if err != nil {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+			"result":  nil,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"result":  result,
+	})
+}
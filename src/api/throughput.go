@@ -0,0 +1,52 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultThroughputWindow = 5 * time.Minute
+	defaultThroughputStep   = 10 * time.Second
+)
+
+// parseThroughputDuration reads a duration query parameter, def when it is absent
+func parseThroughputDuration(c echo.Context, name string, def time.Duration) (time.Duration, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return def, nil
+	}
+	return time.ParseDuration(v)
+}
+
+// getComponentThroughput returns the events/sec series of a component on this node over a short
+// window, e.g. GET /inputs/:id/throughput?window=5m&resolution=10s. componentType is the type the
+// stats collection reports: input, output or ruleset.
+func getComponentThroughput(componentType string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Param("id")
+		window, err := parseThroughputDuration(c, "window", defaultThroughputWindow)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid window: " + err.Error()})
+		}
+		step, err := parseThroughputDuration(c, "resolution", defaultThroughputStep)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid resolution: " + err.Error()})
+		}
+		points, err := common.GlobalThroughput.Series(componentType, id, window, step, time.Now())
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"node_id":    common.Config.LocalIP,
+			"type":       componentType,
+			"id":         id,
+			"window":     window.String(),
+			"resolution": step.String(),
+			"points":     points,
+		})
+	}
+}
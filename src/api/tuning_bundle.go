@@ -0,0 +1,157 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/rules_engine"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultTuningDays           = 1
+	maxTuningDays               = 30
+	defaultTuningSamplesPerRule = 5
+	maxTuningSamplesPerRule     = 20
+	tuningEvaluatedSamples      = 1000
+)
+
+// getRuleHits returns the recorded hits per rule of a day, replaced in tests since they live in Redis
+var getRuleHits = common.GetRuleHits
+
+// TuningRule is the activity of one rule: its recorded hits and the sampled events it matches
+type TuningRule struct {
+	RuleID string `json:"rule_id"`
+	Name   string `json:"name"`
+	// Hits are the recorded matches of the rule over the period, summed over the projects
+	Hits uint64 `json:"hits"`
+	// SampleMatches counts the sampled events the rule matches, Samples holds the first of them
+	SampleMatches int                      `json:"sample_matches"`
+	Samples       []map[string]interface{} `json:"samples"`
+}
+
+// TuningBundle gathers what is needed to tune a noisy ruleset. Rules are ordered by hits, the most
+// active first.
+type TuningBundle struct {
+	RulesetID        string       `json:"ruleset_id"`
+	Type             string       `json:"type"`
+	Days             int          `json:"days"`
+	SamplesEvaluated int          `json:"samples_evaluated"`
+	Rules            []TuningRule `json:"rules"`
+}
+
+// buildTuningBundle matches the sampled events against the ruleset and joins them to the rule hits.
+// At most perRule matching events are kept per rule.
+func buildTuningBundle(rs *rules_engine.Ruleset, hits map[string]uint64, events []map[string]interface{}, perRule int) *TuningBundle {
+	bundle := &TuningBundle{
+		RulesetID:        rs.RulesetID,
+		Type:             "DETECTION",
+		SamplesEvaluated: len(events),
+		Rules:            make([]TuningRule, 0, len(rs.Rules)),
+	}
+	if !rs.IsDetection {
+		bundle.Type = "EXCLUDE"
+	}
+
+	index := make(map[string]int, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		index[rule.ID] = len(bundle.Rules)
+		bundle.Rules = append(bundle.Rules, TuningRule{
+			RuleID:  rule.ID,
+			Name:    rule.Name,
+			Hits:    hits[rule.ID],
+			Samples: make([]map[string]interface{}, 0),
+		})
+	}
+
+	for _, event := range events {
+		for _, ruleID := range rs.MatchedRules(event) {
+			i, ok := index[ruleID]
+			if !ok {
+				continue
+			}
+			rule := &bundle.Rules[i]
+			rule.SampleMatches++
+			if len(rule.Samples) < perRule {
+				rule.Samples = append(rule.Samples, event)
+			}
+		}
+	}
+
+	sort.SliceStable(bundle.Rules, func(i, j int) bool {
+		if bundle.Rules[i].Hits != bundle.Rules[j].Hits {
+			return bundle.Rules[i].Hits > bundle.Rules[j].Hits
+		}
+		return bundle.Rules[i].SampleMatches > bundle.Rules[j].SampleMatches
+	})
+	return bundle
+}
+
+// rulesetHits sums the recorded hits per rule of a ruleset over the last days, all projects included
+func rulesetHits(rulesetID string, days int, now time.Time) (map[string]uint64, error) {
+	hits := make(map[string]uint64)
+	for d := 0; d < days; d++ {
+		dayHits, err := getRuleHits(now.AddDate(0, 0, -d).Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		for k, n := range dayHits {
+			if k.RulesetID == rulesetID {
+				hits[k.RuleID] += n
+			}
+		}
+	}
+	return hits, nil
+}
+
+// getTuningBundle returns the hit count of every rule of a deployed ruleset with a few sampled
+// events each firing rule matches, e.g. GET /rulesets/:id/tuning-bundle?days=7&samples=5
+func getTuningBundle(c echo.Context) error {
+	id := c.Param("id")
+	days, err := boundedQueryInt(c, "days", defaultTuningDays, maxTuningDays)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	perRule, err := boundedQueryInt(c, "samples", defaultTuningSamplesPerRule, maxTuningSamplesPerRule)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// The samples are matched against an isolated copy so they leave no trace in the live ruleset
+	rs, ok := replayRulesetLookup()(id)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "ruleset not found or not valid"})
+	}
+	defer rs.CloseCaches()
+	if common.GetRedisClient() == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "rule hit statistics require Redis"})
+	}
+	hits, err := rulesetHits(id, days, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read rule hits: " + err.Error()})
+	}
+
+	bundle := buildTuningBundle(rs, hits, recentRulesetSamples(id, tuningEvaluatedSamples), perRule)
+	bundle.Days = days
+	return c.JSON(http.StatusOK, bundle)
+}
+
+// boundedQueryInt reads a positive integer query parameter, capped at max
+func boundedQueryInt(c echo.Context, name string, def, max int) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	if n > max {
+		n = max
+	}
+	return n, nil
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/rules_engine"
+	"testing"
+	"time"
+)
+
+func TestTuningBundle_CountsAndSamplesPerFiringRule(t *testing.T) {
+	rs, err := rules_engine.NewRuleset("", impactRuleset, "tuning_rs")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	rs.SetTestMode()
+
+	prev := getRuleHits
+	defer func() { getRuleHits = prev }()
+	getRuleHits = func(date string) (map[common.RuleHitKey]uint64, error) {
+		if date != "2024-05-02" && date != "2024-05-01" {
+			t.Fatalf("unexpected date %s", date)
+		}
+		return map[common.RuleHitKey]uint64{
+			{ProjectID: "p1", RulesetID: "tuning_rs", RuleID: "r_login"}: 40,
+			{ProjectID: "p2", RulesetID: "tuning_rs", RuleID: "r_login"}: 2,
+			{ProjectID: "p1", RulesetID: "tuning_rs", RuleID: "r_admin"}: 1,
+			{ProjectID: "p1", RulesetID: "other_rs", RuleID: "r_admin"}:  100,
+		}, nil
+	}
+	hits, err := rulesetHits("tuning_rs", 2, time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("rulesetHits: %v", err)
+	}
+
+	events := []map[string]interface{}{
+		{"action": "login", "user": "bob"},
+		{"action": "login", "user": "eve"},
+		{"action": "login", "user": "admin"},
+		{"action": "logout", "user": "admin"},
+		{"action": "logout", "user": "bob"},
+	}
+	bundle := buildTuningBundle(rs, hits, events, 2)
+
+	if bundle.RulesetID != "tuning_rs" || bundle.Type != "DETECTION" || bundle.SamplesEvaluated != 5 || len(bundle.Rules) != 2 {
+		t.Fatalf("unexpected bundle %+v", bundle)
+	}
+	login, admin := bundle.Rules[0], bundle.Rules[1]
+	if login.RuleID != "r_login" || login.Hits != 84 || login.SampleMatches != 3 || len(login.Samples) != 2 {
+		t.Fatalf("unexpected r_login entry %+v", login)
+	}
+	if login.Samples[0]["user"] != "bob" || login.Samples[1]["user"] != "eve" {
+		t.Fatalf("expected the first matching samples, got %v", login.Samples)
+	}
+	if admin.RuleID != "r_admin" || admin.Hits != 2 || admin.SampleMatches != 2 || len(admin.Samples) != 2 {
+		t.Fatalf("unexpected r_admin entry %+v", admin)
+	}
+	if _, ok := events[0][rules_engine.HitRuleIdFieldName]; ok {
+		t.Fatal("building the bundle modified the sampled event")
+	}
+}
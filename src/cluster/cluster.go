@@ -3,6 +3,7 @@ package cluster
 import (
 	"AgentSmith-HUB/common"
 	"AgentSmith-HUB/logger"
+	"sync"
 	"time"
 )
 
@@ -14,7 +15,9 @@ type ClusterManager struct {
 	instructionManager *InstructionManager
 	heartbeatManager   *HeartbeatManager
 	syncListener       *SyncListener
-	leaderLocker       *LeaderLocker
+
+	mu           sync.Mutex // guards leaderLocker and leader role changes
+	leaderLocker *LeaderLocker
 }
 
 var GlobalClusterManager *ClusterManager
@@ -40,7 +43,45 @@ func (cm *ClusterManager) ObtainLeaderLocker() error {
 	if err != nil {
 		return err
 	}
+	cm.mu.Lock()
+	cm.leaderLocker = locker
+	cm.mu.Unlock()
+	return nil
+}
+
+// relinquishLeadership releases the leader lock and demotes this node: the leader services stop and
+// the node reports itself as a follower until it wins the election again
+func (cm *ClusterManager) relinquishLeadership() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.leaderLocker != nil {
+		cm.leaderLocker.Release()
+		cm.leaderLocker = nil
+	}
+	if cm.heartbeatManager != nil {
+		cm.heartbeatManager.stopLeaderLoops()
+	}
+	common.SetClusterState(false, common.GetNodeID())
+	common.SetLeaderState(false, "")
+	logger.Warn("Relinquished cluster leadership", "node_id", common.GetNodeID())
+}
+
+// runLeaderElection competes for the leader lock like a starting leader does; the winner takes the
+// leader role back and starts the leader services
+func (cm *ClusterManager) runLeaderElection() error {
+	locker, err := ObtainLeaderLocker()
+	if err != nil {
+		return err
+	}
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
 	cm.leaderLocker = locker
+	nodeID := common.GetNodeID()
+	common.SetClusterState(true, nodeID)
+	common.SetLeaderState(true, nodeID)
+	if cm.heartbeatManager != nil {
+		cm.heartbeatManager.startLeaderLoops()
+	}
 	return nil
 }
 
@@ -81,9 +122,11 @@ func (cm *ClusterManager) Stop() {
 		cm.instructionManager.Stop()
 	}
 
+	cm.mu.Lock()
 	if cm.leaderLocker != nil {
 		cm.leaderLocker.Release()
 	}
+	cm.mu.Unlock()
 
 	logger.Info("Cluster stopped")
 }
@@ -135,21 +178,22 @@ func GetClusterStatus() map[string]interface{} {
 	// Add other follower nodes (only for leader)
 	if common.IsCurrentNodeLeader() && GlobalHeartbeatManager != nil {
 		nodes := GlobalHeartbeatManager.GetNodes()
-		now := time.Now().Unix()
+		health := GlobalHeartbeatManager.GetNodeHealth(time.Now())
 		for nodeID, heartbeat := range nodes {
-			// Calculate health status based on heartbeat timing
-			// Healthy: last heartbeat within 10 seconds (missed < 2 heartbeats)
-			// Unhealthy: last heartbeat between 10-120 seconds (missed 2-24 heartbeats)
-			// Offline: > 120 seconds (will be removed by cleanup, won't appear here)
-			timeSinceLastHeartbeat := now - heartbeat.Timestamp
-			isHealthy := timeSinceLastHeartbeat <= 10
+			// Health is based on missed heartbeats at the configured interval:
+			// Healthy: missed < 2 heartbeats
+			// Unhealthy: missed 2 or more, down once missed_threshold is reached (online=false)
+			// Removed by cleanup after 24 missed heartbeats (or twice the threshold)
+			h := health[nodeID]
 
 			nodeList[nodeID] = map[string]interface{}{
-				"version":   heartbeat.Version,
-				"timestamp": heartbeat.Timestamp,
-				"online":    true,
-				"role":      "follower",
-				"healthy":   isHealthy, // Add health status
+				"version":           heartbeat.Version,
+				"timestamp":         heartbeat.Timestamp,
+				"online":            !h.Down,
+				"role":              "follower",
+				"healthy":           h.Healthy, // Add health status
+				"down":              h.Down,
+				"missed_heartbeats": h.MissedHeartbeats,
 			}
 		}
 	}
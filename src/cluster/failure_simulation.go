@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Failure modes accepted by SimulateFailure
+const (
+	// SimulateHeartbeatLoss stops the heartbeats of a follower, so the leader marks it down
+	SimulateHeartbeatLoss = "heartbeat_loss"
+	// SimulateLeaderRelinquish demotes the leader, then lets it compete for the leader lock again
+	SimulateLeaderRelinquish = "leader_relinquish"
+
+	minSimulatedFailure = time.Second
+	maxSimulatedFailure = 10 * time.Minute
+)
+
+// ErrFailureSimulationDisabled is returned by SimulateFailure unless allow_failure_simulation is set
+var ErrFailureSimulationDisabled = errors.New("failure simulation is disabled, set allow_failure_simulation in config.yaml")
+
+// SimulatedFailure is a failure injected on this node to test how the cluster recovers
+type SimulatedFailure struct {
+	Mode      string    `json:"mode"`
+	NodeID    string    `json:"node_id"`
+	StartedAt time.Time `json:"started_at"`
+	Until     time.Time `json:"until"`
+}
+
+var (
+	simulationMu     sync.Mutex
+	activeSimulation *SimulatedFailure
+)
+
+// Overridden in tests, no Redis lock is available there
+var (
+	demoteLeader = func() {
+		if GlobalClusterManager != nil {
+			GlobalClusterManager.relinquishLeadership()
+		}
+	}
+	electLeader = func() error {
+		if GlobalClusterManager == nil {
+			return fmt.Errorf("cluster manager not initialized")
+		}
+		return GlobalClusterManager.runLeaderElection()
+	}
+	simulationTimer = time.After
+)
+
+// SimulateFailure injects a failure on this node for duration, then lets it recover: a follower
+// resumes its heartbeats, a demoted leader goes through the leader lock election again. Only one
+// failure runs at a time.
+func SimulateFailure(mode string, duration time.Duration) (*SimulatedFailure, error) {
+	if common.Config == nil || !common.Config.AllowFailureSimulation {
+		return nil, ErrFailureSimulationDisabled
+	}
+	if duration < minSimulatedFailure || duration > maxSimulatedFailure {
+		return nil, fmt.Errorf("duration must be between %s and %s", minSimulatedFailure, maxSimulatedFailure)
+	}
+	switch mode {
+	case SimulateHeartbeatLoss:
+		if common.IsCurrentNodeLeader() {
+			return nil, fmt.Errorf("%s can only be simulated on a follower", mode)
+		}
+	case SimulateLeaderRelinquish:
+		if !common.IsCurrentNodeLeader() {
+			return nil, fmt.Errorf("%s can only be simulated on the leader", mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown failure mode '%s', expected %s or %s", mode, SimulateHeartbeatLoss, SimulateLeaderRelinquish)
+	}
+
+	simulationMu.Lock()
+	defer simulationMu.Unlock()
+	if activeSimulation != nil {
+		return nil, fmt.Errorf("a %s simulation is already running until %s", activeSimulation.Mode, activeSimulation.Until.Format(time.RFC3339))
+	}
+	now := time.Now()
+	sim := &SimulatedFailure{Mode: mode, NodeID: common.GetNodeID(), StartedAt: now, Until: now.Add(duration)}
+	activeSimulation = sim
+	logger.Warn("Simulating cluster failure", "mode", mode, "node_id", sim.NodeID, "duration", duration)
+
+	if mode == SimulateLeaderRelinquish {
+		demoteLeader()
+	}
+	go func() {
+		<-simulationTimer(duration)
+		if mode == SimulateLeaderRelinquish {
+			// A standby leader waiting on the lock may have taken over meanwhile
+			if err := electLeader(); err != nil {
+				logger.Error("Failed to obtain the leader lock again after simulated failover", "node_id", sim.NodeID, "error", err)
+			} else {
+				logger.Info("Obtained the leader lock again after simulated failover", "node_id", sim.NodeID)
+			}
+		}
+		simulationMu.Lock()
+		activeSimulation = nil
+		simulationMu.Unlock()
+		logger.Info("Simulated cluster failure ended", "mode", mode, "node_id", sim.NodeID)
+	}()
+	return sim, nil
+}
+
+// ActiveSimulatedFailure returns the failure currently simulated on this node, or nil
+func ActiveSimulatedFailure() *SimulatedFailure {
+	simulationMu.Lock()
+	defer simulationMu.Unlock()
+	if activeSimulation == nil {
+		return nil
+	}
+	sim := *activeSimulation
+	return &sim
+}
+
+func heartbeatLossSimulated() bool {
+	sim := ActiveSimulatedFailure()
+	return sim != nil && sim.Mode == SimulateHeartbeatLoss
+}
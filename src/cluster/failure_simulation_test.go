@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"AgentSmith-HUB/common"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSimulateFailure_LeaderRelinquishRunsElection(t *testing.T) {
+	prevConfig, prevDemote, prevElect, prevTimer := common.Config, demoteLeader, electLeader, simulationTimer
+	t.Cleanup(func() {
+		common.Config, demoteLeader, electLeader, simulationTimer = prevConfig, prevDemote, prevElect, prevTimer
+		common.SetClusterState(false, "")
+	})
+	common.SetClusterState(true, "leader-1")
+
+	common.Config = &common.HubConfig{}
+	if _, err := SimulateFailure(SimulateLeaderRelinquish, time.Second); !errors.Is(err, ErrFailureSimulationDisabled) {
+		t.Fatalf("expected the simulation to be disabled by default, got %v", err)
+	}
+	common.Config = &common.HubConfig{AllowFailureSimulation: true}
+
+	demoted := false
+	elected := make(chan struct{})
+	expire := make(chan time.Time)
+	demoteLeader = func() { demoted = true }
+	electLeader = func() error {
+		close(elected)
+		return nil
+	}
+	simulationTimer = func(time.Duration) <-chan time.Time { return expire }
+
+	if _, err := SimulateFailure(SimulateLeaderRelinquish, 500*time.Millisecond); err == nil {
+		t.Fatal("durations under 1s must be rejected")
+	}
+	if _, err := SimulateFailure(SimulateHeartbeatLoss, time.Second); err == nil {
+		t.Fatal("heartbeat loss must be rejected on the leader")
+	}
+	sim, err := SimulateFailure(SimulateLeaderRelinquish, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !demoted || sim.NodeID != "leader-1" {
+		t.Fatalf("the leader must be demoted, got demoted=%v sim=%+v", demoted, sim)
+	}
+	if _, err := SimulateFailure(SimulateLeaderRelinquish, time.Second); err == nil {
+		t.Fatal("a second simulation must be rejected while one is running")
+	}
+
+	close(expire)
+	select {
+	case <-elected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the election did not run after the simulated failure")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for ActiveSimulatedFailure() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the simulation did not end")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRelinquishLeadershipDemotesNode(t *testing.T) {
+	t.Cleanup(func() { common.SetClusterState(false, "") })
+	common.SetClusterState(true, "leader-1")
+
+	hm := &HeartbeatManager{nodeID: "leader-1", isLeader: true, stopChan: make(chan struct{})}
+	stop := hm.stopChan
+	cm := &ClusterManager{heartbeatManager: hm}
+	cm.relinquishLeadership()
+
+	if common.IsCurrentNodeLeader() || common.GetNodeID() != "leader-1" {
+		t.Fatalf("the node must be a follower keeping its ID, got leader=%v id=%s", common.IsCurrentNodeLeader(), common.GetNodeID())
+	}
+	select {
+	case <-stop:
+	default:
+		t.Fatal("the leader services must be stopped")
+	}
+	if hm.isLeader {
+		t.Fatal("the heartbeat manager must leave the leader role")
+	}
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"time"
+)
+
+const (
+	defaultHeartbeatInterval        = 5 * time.Second
+	defaultHeartbeatMissedThreshold = 6
+	// heartbeatUnhealthyMissed is the number of missed heartbeats after which a node is reported
+	// unhealthy, before it is considered down
+	heartbeatUnhealthyMissed = 2
+	// heartbeatRemoveMissed is the number of missed heartbeats after which a node is forgotten; it is
+	// at least twice the down threshold so down nodes stay visible for a while
+	heartbeatRemoveMissed = 24
+)
+
+// heartbeatSettings returns the configured heartbeat interval and missed heartbeat threshold
+func heartbeatSettings() (time.Duration, int) {
+	interval, threshold := defaultHeartbeatInterval, defaultHeartbeatMissedThreshold
+	if common.Config != nil {
+		if common.Config.Heartbeat.Interval > 0 {
+			interval = common.Config.Heartbeat.Interval
+		}
+		if common.Config.Heartbeat.MissedThreshold > 0 {
+			threshold = common.Config.Heartbeat.MissedThreshold
+		}
+	}
+	return interval, threshold
+}
+
+// NodeHealth is the leader's view of a follower derived from its last heartbeat
+type NodeHealth struct {
+	MissedHeartbeats int  `json:"missed_heartbeats"`
+	Healthy          bool `json:"healthy"`
+	Down             bool `json:"down"`
+}
+
+// nodeHealth counts the heartbeats missed since lastSeen (Unix seconds)
+func nodeHealth(now time.Time, lastSeen int64, interval time.Duration, threshold int) NodeHealth {
+	missed := int(now.Sub(time.Unix(lastSeen, 0)) / interval)
+	if missed < 0 {
+		missed = 0
+	}
+	return NodeHealth{
+		MissedHeartbeats: missed,
+		Healthy:          missed < heartbeatUnhealthyMissed && missed < threshold,
+		Down:             missed >= threshold,
+	}
+}
+
+func heartbeatRemoveAfter(threshold int) int {
+	if threshold*2 > heartbeatRemoveMissed {
+		return threshold * 2
+	}
+	return heartbeatRemoveMissed
+}
+
+// checkNodes marks nodes down once they pass the missed heartbeat threshold and forgets nodes that
+// have been silent for much longer. It returns the health of the remaining nodes.
+func (hm *HeartbeatManager) checkNodes(now time.Time) map[string]NodeHealth {
+	interval, threshold := heartbeatSettings()
+	removeAfter := heartbeatRemoveAfter(threshold)
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	health := make(map[string]NodeHealth, len(hm.nodes))
+	for nodeID, heartbeat := range hm.nodes {
+		h := nodeHealth(now, heartbeat.Timestamp, interval, threshold)
+		if h.MissedHeartbeats >= removeAfter {
+			delete(hm.nodes, nodeID)
+			delete(hm.downNodes, nodeID)
+			logger.Debug("Removed offline node", "node_id", nodeID, "missed_heartbeats", h.MissedHeartbeats)
+			continue
+		}
+		if h.Down && !hm.downNodes[nodeID] {
+			hm.downNodes[nodeID] = true
+			logger.Warn("Cluster node marked down", "node_id", nodeID,
+				"missed_heartbeats", h.MissedHeartbeats, "threshold", threshold, "last_seen", heartbeat.Timestamp)
+		} else if !h.Down && hm.downNodes[nodeID] {
+			delete(hm.downNodes, nodeID)
+			logger.Info("Cluster node is back", "node_id", nodeID)
+		}
+		health[nodeID] = h
+	}
+	return health
+}
+
+// GetNodeHealth returns the health of the known follower nodes at now
+func (hm *HeartbeatManager) GetNodeHealth(now time.Time) map[string]NodeHealth {
+	interval, threshold := heartbeatSettings()
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+	health := make(map[string]NodeHealth, len(hm.nodes))
+	for nodeID, heartbeat := range hm.nodes {
+		health[nodeID] = nodeHealth(now, heartbeat.Timestamp, interval, threshold)
+	}
+	return health
+}
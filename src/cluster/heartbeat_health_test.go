@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"AgentSmith-HUB/common"
+	"testing"
+	"time"
+)
+
+func TestCheckNodes_MarksNodeDownAfterMissedHeartbeats(t *testing.T) {
+	prev := common.Config
+	common.Config = &common.HubConfig{Heartbeat: common.HeartbeatConfig{Interval: 2 * time.Second, MissedThreshold: 3}}
+	t.Cleanup(func() { common.Config = prev })
+
+	now := time.Unix(1_700_000_000, 0)
+	hm := &HeartbeatManager{
+		nodes: map[string]HeartbeatData{
+			"fresh":   {NodeID: "fresh", Timestamp: now.Add(-1 * time.Second).Unix()},
+			"lagging": {NodeID: "lagging", Timestamp: now.Add(-5 * time.Second).Unix()},
+			"down":    {NodeID: "down", Timestamp: now.Add(-6 * time.Second).Unix()},
+			"gone":    {NodeID: "gone", Timestamp: now.Add(-48 * time.Second).Unix()},
+		},
+		downNodes: make(map[string]bool),
+	}
+
+	health := hm.checkNodes(now)
+	if h := health["fresh"]; !h.Healthy || h.Down {
+		t.Fatalf("fresh: %+v", h)
+	}
+	if h := health["lagging"]; h.Healthy || h.Down || h.MissedHeartbeats != 2 {
+		t.Fatalf("lagging: expected unhealthy but not down after 2 missed heartbeats, got %+v", h)
+	}
+	if h := health["down"]; !h.Down || h.MissedHeartbeats != 3 {
+		t.Fatalf("down: expected down after 3 missed heartbeats, got %+v", h)
+	}
+	if !hm.downNodes["down"] {
+		t.Fatal("expected the leader to remember the down node")
+	}
+	if _, ok := hm.GetNodes()["gone"]; ok {
+		t.Fatal("expected a node silent for 24 heartbeats to be removed")
+	}
+
+	// A heartbeat brings the node back
+	hm.nodes["down"] = HeartbeatData{NodeID: "down", Timestamp: now.Unix()}
+	if h := hm.checkNodes(now)["down"]; h.Down || hm.downNodes["down"] {
+		t.Fatalf("expected node to recover, got %+v", h)
+	}
+}
+
+func TestGetClusterStatus_SurfacesDownNodes(t *testing.T) {
+	prev, prevHM := common.Config, GlobalHeartbeatManager
+	common.Config = &common.HubConfig{Heartbeat: common.HeartbeatConfig{Interval: time.Second, MissedThreshold: 4}}
+	common.SetClusterState(true, "leader")
+	t.Cleanup(func() {
+		common.Config = prev
+		GlobalHeartbeatManager = prevHM
+		common.SetClusterState(false, "")
+	})
+
+	GlobalHeartbeatManager = &HeartbeatManager{
+		nodes: map[string]HeartbeatData{
+			"follower-1": {NodeID: "follower-1", Timestamp: time.Now().Add(-10 * time.Second).Unix()},
+		},
+		downNodes: make(map[string]bool),
+	}
+
+	nodes := GetClusterStatus()["nodes"].([]map[string]interface{})
+	for _, node := range nodes {
+		if node["id"] != "follower-1" {
+			continue
+		}
+		if node["down"] != true || node["online"] != false || node["is_healthy"] != false {
+			t.Fatalf("expected follower-1 reported down, got %v", node)
+		}
+		return
+	}
+	t.Fatalf("follower-1 missing from cluster status: %v", nodes)
+}
@@ -3,8 +3,11 @@ package cluster
 import (
 	"AgentSmith-HUB/common"
 	"AgentSmith-HUB/logger"
+	"AgentSmith-HUB/project"
+	"AgentSmith-HUB/rules_engine"
 	"context"
 	"encoding/json"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,16 +23,26 @@ type HeartbeatData struct {
 	MemoryUsedMB   float64 `json:"memory_used_mb"`
 	MemoryPercent  float64 `json:"memory_percent"`
 	GoroutineCount int     `json:"goroutine_count"`
+
+	RunningProjects int `json:"running_projects"`
+	CPUCores        int `json:"cpu_cores"`
+	PoolMinSize     int `json:"pool_min_size"`
+	PoolMaxSize     int `json:"pool_max_size"`
+
+	EngineTaskLimit    int    `json:"engine_task_limit"`
+	EngineTasksRunning int64  `json:"engine_tasks_running"`
+	EngineTaskWaits    uint64 `json:"engine_task_waits"`
 }
 
 // HeartbeatManager manages heartbeat and version sync
 type HeartbeatManager struct {
-	nodeID           string
-	isLeader         bool
-	nodes            map[string]HeartbeatData
-	mu               sync.RWMutex
-	stopChan         chan struct{}
-	heartbeatInterval time.Duration // Randomized heartbeat interval for followers
+	nodeID            string
+	isLeader          bool
+	nodes             map[string]HeartbeatData
+	mu                sync.RWMutex
+	stopChan          chan struct{}
+	heartbeatInterval time.Duration   // Randomized heartbeat interval for followers
+	downNodes         map[string]bool // Nodes past the missed heartbeat threshold (leader only)
 }
 
 var GlobalHeartbeatManager *HeartbeatManager
@@ -37,48 +50,70 @@ var GlobalHeartbeatManager *HeartbeatManager
 // InitHeartbeatManager initializes the heartbeat manager
 func InitHeartbeatManager(nodeID string, isLeader bool) {
 	// Calculate randomized heartbeat interval for followers
-	// Base interval: 5 seconds, random jitter: ±2 seconds (3-7 seconds total)
-	baseInterval := 5 * time.Second
-	jitter := time.Duration((time.Now().UnixNano() % 4000)) * time.Millisecond // 0-4 seconds
+	// Base interval from hub config (default 5 seconds) plus up to 80% random jitter
+	baseInterval, _ := heartbeatSettings()
+	jitter := time.Duration(time.Now().UnixNano() % int64(baseInterval*4/5+1))
 	heartbeatInterval := baseInterval + jitter
-	
+
 	GlobalHeartbeatManager = &HeartbeatManager{
 		nodeID:            nodeID,
 		isLeader:          isLeader,
 		nodes:             make(map[string]HeartbeatData),
 		stopChan:          make(chan struct{}),
 		heartbeatInterval: heartbeatInterval,
+		downNodes:         make(map[string]bool),
 	}
-	
+
 	if !isLeader {
-		logger.Info("Follower heartbeat initialized with randomized interval", 
+		logger.Info("Follower heartbeat initialized with randomized interval",
 			"node_id", nodeID, "interval", heartbeatInterval)
 	}
 }
 
 // Start starts the heartbeat manager
 func (hm *HeartbeatManager) Start() {
-	if hm.isLeader {
-		go hm.startLeaderHeartbeat()
+	hm.mu.RLock()
+	isLeader, stop := hm.isLeader, hm.stopChan
+	hm.mu.RUnlock()
+	if isLeader {
+		go hm.startLeaderHeartbeat(stop)
 	} else {
-		go hm.startFollowerHeartbeat()
+		go hm.startFollowerHeartbeat(stop)
 	}
 }
 
+// stopLeaderLoops stops the leader services when the node gives up the leader lock
+func (hm *HeartbeatManager) stopLeaderLoops() {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	close(hm.stopChan)
+	hm.stopChan = make(chan struct{})
+	hm.isLeader = false
+}
+
+// startLeaderLoops starts the leader services again once the node holds the leader lock
+func (hm *HeartbeatManager) startLeaderLoops() {
+	hm.mu.Lock()
+	hm.isLeader = true
+	stop := hm.stopChan
+	hm.mu.Unlock()
+	go hm.startLeaderHeartbeat(stop)
+}
+
 // startLeaderHeartbeat starts leader heartbeat services
-func (hm *HeartbeatManager) startLeaderHeartbeat() {
+func (hm *HeartbeatManager) startLeaderHeartbeat(stop <-chan struct{}) {
 	// Listen for follower heartbeats
-	go hm.listenHeartbeats()
+	go hm.listenHeartbeats(stop)
 
 	// Clean up offline nodes
-	go hm.cleanupOfflineNodes()
+	go hm.cleanupOfflineNodes(stop)
 
 	// Update leader's own system metrics
-	go hm.updateLeaderSystemMetrics()
+	go hm.updateLeaderSystemMetrics(stop)
 }
 
 // updateLeaderSystemMetrics periodically updates leader's own system metrics
-func (hm *HeartbeatManager) updateLeaderSystemMetrics() {
+func (hm *HeartbeatManager) updateLeaderSystemMetrics(stop <-chan struct{}) {
 	if !common.IsCurrentNodeLeader() {
 		return
 	}
@@ -87,7 +122,7 @@ func (hm *HeartbeatManager) updateLeaderSystemMetrics() {
 	ticker := time.NewTicker(hm.heartbeatInterval)
 	defer ticker.Stop()
 
-	logger.Info("Starting leader system metrics update with randomized interval", 
+	logger.Info("Starting leader system metrics update with randomized interval",
 		"node_id", hm.nodeID, "interval", hm.heartbeatInterval)
 
 	for {
@@ -96,29 +131,30 @@ func (hm *HeartbeatManager) updateLeaderSystemMetrics() {
 			// Get current system metrics for leader
 			if common.GlobalSystemMonitor != nil && common.GlobalClusterSystemManager != nil {
 				if metrics := common.GlobalSystemMonitor.GetCurrentMetrics(); metrics != nil {
+					addNodeResources(metrics)
 					common.GlobalClusterSystemManager.AddSystemMetrics(metrics)
 				}
 			}
-		case <-hm.stopChan:
+		case <-stop:
 			return
 		}
 	}
 }
 
 // startFollowerHeartbeat starts follower heartbeat services
-func (hm *HeartbeatManager) startFollowerHeartbeat() {
+func (hm *HeartbeatManager) startFollowerHeartbeat(stop <-chan struct{}) {
 	// Use randomized heartbeat interval to avoid heartbeat storms
 	ticker := time.NewTicker(hm.heartbeatInterval)
 	defer ticker.Stop()
 
-	logger.Info("Starting follower heartbeat with randomized interval", 
+	logger.Info("Starting follower heartbeat with randomized interval",
 		"node_id", hm.nodeID, "interval", hm.heartbeatInterval)
 
 	for {
 		select {
 		case <-ticker.C:
 			hm.sendHeartbeat()
-		case <-hm.stopChan:
+		case <-stop:
 			return
 		}
 	}
@@ -126,7 +162,7 @@ func (hm *HeartbeatManager) startFollowerHeartbeat() {
 
 // sendHeartbeat sends heartbeat with current version and system metrics (follower only)
 func (hm *HeartbeatManager) sendHeartbeat() {
-	if common.IsCurrentNodeLeader() {
+	if common.IsCurrentNodeLeader() || heartbeatLossSimulated() {
 		return
 	}
 
@@ -147,14 +183,25 @@ func (hm *HeartbeatManager) sendHeartbeat() {
 		}
 	}
 
+	resources := &common.SystemMetrics{}
+	addNodeResources(resources)
+
 	heartbeat := HeartbeatData{
-		NodeID:         hm.nodeID,
-		Version:        currentVersion,
-		Timestamp:      time.Now().Unix(),
-		CPUPercent:     cpuPercent,
-		MemoryUsedMB:   memoryUsedMB,
-		MemoryPercent:  memoryPercent,
-		GoroutineCount: goroutineCount,
+		NodeID:          hm.nodeID,
+		Version:         currentVersion,
+		Timestamp:       time.Now().Unix(),
+		CPUPercent:      cpuPercent,
+		MemoryUsedMB:    memoryUsedMB,
+		MemoryPercent:   memoryPercent,
+		GoroutineCount:  goroutineCount,
+		RunningProjects: resources.RunningProjects,
+		CPUCores:        resources.CPUCores,
+		PoolMinSize:     resources.PoolMinSize,
+		PoolMaxSize:     resources.PoolMaxSize,
+
+		EngineTaskLimit:    resources.EngineTaskLimit,
+		EngineTasksRunning: resources.EngineTasksRunning,
+		EngineTaskWaits:    resources.EngineTaskWaits,
 	}
 
 	data, err := json.Marshal(heartbeat)
@@ -170,7 +217,7 @@ func (hm *HeartbeatManager) sendHeartbeat() {
 }
 
 // listenHeartbeats listens for heartbeats and handles version sync (leader only)
-func (hm *HeartbeatManager) listenHeartbeats() {
+func (hm *HeartbeatManager) listenHeartbeats(stop <-chan struct{}) {
 	if !common.IsCurrentNodeLeader() {
 		return
 	}
@@ -218,12 +265,20 @@ func (hm *HeartbeatManager) listenHeartbeats() {
 			// Store system metrics in cluster system manager
 			if common.GlobalClusterSystemManager != nil {
 				systemMetrics := &common.SystemMetrics{
-					NodeID:         heartbeat.NodeID,
-					CPUPercent:     heartbeat.CPUPercent,
-					MemoryUsedMB:   heartbeat.MemoryUsedMB,
-					MemoryPercent:  heartbeat.MemoryPercent,
-					GoroutineCount: heartbeat.GoroutineCount,
-					Timestamp:      time.Unix(heartbeat.Timestamp, 0),
+					NodeID:          heartbeat.NodeID,
+					CPUPercent:      heartbeat.CPUPercent,
+					MemoryUsedMB:    heartbeat.MemoryUsedMB,
+					MemoryPercent:   heartbeat.MemoryPercent,
+					GoroutineCount:  heartbeat.GoroutineCount,
+					Timestamp:       time.Unix(heartbeat.Timestamp, 0),
+					RunningProjects: heartbeat.RunningProjects,
+					CPUCores:        heartbeat.CPUCores,
+					PoolMinSize:     heartbeat.PoolMinSize,
+					PoolMaxSize:     heartbeat.PoolMaxSize,
+
+					EngineTaskLimit:    heartbeat.EngineTaskLimit,
+					EngineTasksRunning: heartbeat.EngineTasksRunning,
+					EngineTaskWaits:    heartbeat.EngineTaskWaits,
 				}
 				common.GlobalClusterSystemManager.AddSystemMetrics(systemMetrics)
 			}
@@ -231,12 +286,30 @@ func (hm *HeartbeatManager) listenHeartbeats() {
 			// Check version and send sync command if needed
 			hm.checkVersionSync(heartbeat)
 
-		case <-hm.stopChan:
+		case <-stop:
 			return
 		}
 	}
 }
 
+// addNodeResources fills in this node's running project count, worker pool limits and engine task saturation
+func addNodeResources(metrics *common.SystemMetrics) {
+	running := 0
+	project.ForEachProject(func(_ string, p *project.Project) bool {
+		if p.Status == common.StatusRunning {
+			running++
+		}
+		return true
+	})
+	metrics.RunningProjects = running
+	metrics.CPUCores = runtime.NumCPU()
+	metrics.PoolMinSize, metrics.PoolMaxSize = rules_engine.PoolSizes()
+	tasks := rules_engine.GetEngineTaskStats()
+	metrics.EngineTaskLimit = tasks.Limit
+	metrics.EngineTasksRunning = tasks.InFlight
+	metrics.EngineTaskWaits = tasks.Waits
+}
+
 // trackNodeInRedis tracks a node in Redis for node enumeration (48 hours TTL)
 func (hm *HeartbeatManager) trackNodeInRedis(nodeID string) {
 	if nodeID == "" {
@@ -301,31 +374,21 @@ func (hm *HeartbeatManager) checkVersionSync(heartbeat HeartbeatData) {
 	}
 }
 
-// cleanupOfflineNodes removes offline nodes
-func (hm *HeartbeatManager) cleanupOfflineNodes() {
+// cleanupOfflineNodes marks nodes down after the missed heartbeat threshold and removes long offline nodes
+func (hm *HeartbeatManager) cleanupOfflineNodes(stop <-chan struct{}) {
 	if !common.IsCurrentNodeLeader() {
 		return
 	}
 
-	ticker := time.NewTicker(10 * time.Second)
+	interval, _ := heartbeatSettings()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			hm.mu.Lock()
-			now := time.Now().Unix()
-			for nodeID, heartbeat := range hm.nodes {
-				// Remove nodes that haven't sent heartbeat for more than 2 minutes (120 seconds)
-				// With heartbeat every 5 seconds, missing 2 heartbeats means unhealthy (10s),
-				// and missing 24 heartbeats means offline and should be removed (120s)
-				if now-heartbeat.Timestamp > 120 {
-					delete(hm.nodes, nodeID)
-					logger.Debug("Removed offline node", "node_id", nodeID)
-				}
-			}
-			hm.mu.Unlock()
-		case <-hm.stopChan:
+		case now := <-ticker.C:
+			hm.checkNodes(now)
+		case <-stop:
 			return
 		}
 	}
@@ -345,5 +408,7 @@ func (hm *HeartbeatManager) GetNodes() map[string]HeartbeatData {
 
 // Stop stops the heartbeat manager
 func (hm *HeartbeatManager) Stop() {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
 	close(hm.stopChan)
 }
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyConfigOverrides sets dotted keys (e.g. "kafka.topic") in a YAML component config and
+// returns the resulting YAML. Every key must already exist in the config, so a typo fails
+// instead of being silently ignored.
+func ApplyConfigOverrides(raw string, overrides map[string]interface{}) (string, error) {
+	if len(overrides) == 0 {
+		return raw, nil
+	}
+
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal([]byte(raw), &cfg); err != nil {
+		return "", fmt.Errorf("failed to parse component config: %w", err)
+	}
+	if cfg == nil {
+		return "", fmt.Errorf("component config is empty")
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+		node := cfg
+		for i, part := range parts {
+			value, exists := node[part]
+			if !exists {
+				return "", fmt.Errorf("config key '%s' does not exist", key)
+			}
+			if i == len(parts)-1 {
+				node[part] = overrides[key]
+				break
+			}
+			child, ok := value.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("config key '%s' does not exist: '%s' is not a mapping", key, strings.Join(parts[:i+1], "."))
+			}
+			node = child
+		}
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal component config: %w", err)
+	}
+	return string(data), nil
+}
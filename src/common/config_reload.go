@@ -0,0 +1,187 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ConfigReloadResult lists the config.yaml keys a reload changed: the applied ones take effect
+// immediately, the others keep their running value until the hub restarts
+type ConfigReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+var (
+	hubConfigLoader   func() (*HubConfig, error)
+	hubConfigReloadMu sync.Mutex
+)
+
+// SetHubConfigLoader registers how the hub config is read again from config.yaml on reload
+func SetHubConfigLoader(loader func() (*HubConfig, error)) {
+	hubConfigReloadMu.Lock()
+	defer hubConfigReloadMu.Unlock()
+	hubConfigLoader = loader
+}
+
+// ReloadHubConfig re-reads config.yaml and applies the settings that are safe to change while running.
+// Nothing is applied when the new config cannot be read or is invalid.
+func ReloadHubConfig() (*ConfigReloadResult, error) {
+	hubConfigReloadMu.Lock()
+	defer hubConfigReloadMu.Unlock()
+	if hubConfigLoader == nil || Config == nil {
+		return nil, fmt.Errorf("hub config is not loaded")
+	}
+	next, err := hubConfigLoader()
+	if err != nil {
+		return nil, err
+	}
+	result, err := applyHubConfig(Config, next)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Hub config reloaded", "applied", result.Applied, "restart_required", result.RestartRequired)
+	return result, nil
+}
+
+// hubConfigSetting is one config.yaml key: changed reports whether next differs from cur, apply,
+// nil for settings that need a restart, copies the new value into the running config under configMu,
+// and notify, when set, passes the new value on to the components using it once the copy is done
+type hubConfigSetting struct {
+	key     string
+	changed func(cur, next *HubConfig) bool
+	apply   func(cur, next *HubConfig)
+	notify  func(next *HubConfig)
+}
+
+func differs[T any](get func(*HubConfig) T) func(cur, next *HubConfig) bool {
+	return func(cur, next *HubConfig) bool { return !reflect.DeepEqual(get(cur), get(next)) }
+}
+
+// hubConfigSettings lists the keys compared on reload, those without apply need a restart. Connections (Redis, pprof, OIDC) and the values read
+// once at startup (the engine task and plugin compilation limits, heartbeats, the rule report) are restart-only.
+var hubConfigSettings = []hubConfigSetting{
+	{key: "redis", changed: differs(func(c *HubConfig) string { return c.Redis })},
+	{key: "redis_password", changed: differs(func(c *HubConfig) string { return c.RedisPassword })},
+	{key: "pprof_enable", changed: differs(func(c *HubConfig) bool { return c.PprofEnable })},
+	{key: "pprof_port", changed: differs(func(c *HubConfig) string { return c.PprofPort })},
+	{key: "simd_enabled", changed: differs(func(c *HubConfig) bool { return c.SIMDEnabled })},
+	{key: "oidc", changed: func(cur, next *HubConfig) bool {
+		return cur.OIDCEnabled != next.OIDCEnabled || cur.OIDCIssuer != next.OIDCIssuer || cur.OIDCClientID != next.OIDCClientID ||
+			cur.OIDCUsernameClaim != next.OIDCUsernameClaim || cur.OIDCRedirectURI != next.OIDCRedirectURI ||
+			cur.OIDCScope != next.OIDCScope || !reflect.DeepEqual(cur.OIDCAllowedUsers, next.OIDCAllowedUsers)
+	}},
+	{key: "correlation_id_field", changed: differs(func(c *HubConfig) string { return c.CorrelationIDField })},
+	{key: "max_engine_tasks", changed: differs(func(c *HubConfig) int { return c.MaxEngineTasks })},
+	{key: "max_plugin_compilations", changed: differs(func(c *HubConfig) int { return c.MaxPluginCompilations })},
+	{key: "rule_report", changed: differs(func(c *HubConfig) RuleReportConfig { return c.RuleReport })},
+	{key: "heartbeat", changed: differs(func(c *HubConfig) HeartbeatConfig { return c.Heartbeat })},
+	{key: "strict_startup", changed: differs(func(c *HubConfig) bool { return c.StrictStartup })},
+	{key: "allow_failure_simulation", changed: differs(func(c *HubConfig) bool { return c.AllowFailureSimulation })},
+
+	{key: "log_level", changed: differs(func(c *HubConfig) string { return c.LogLevel }), apply: func(cur, next *HubConfig) {
+		cur.LogLevel = next.LogLevel
+	}, notify: func(next *HubConfig) {
+		lvl, _ := logger.ParseLevel(next.LogLevel)
+		logger.SetLevel(lvl)
+	}},
+	{key: "sample_retention", changed: differs(func(c *HubConfig) SampleRetentionConfig { return c.SampleRetention }), apply: func(cur, next *HubConfig) {
+		cur.SampleRetention = next.SampleRetention
+	}, notify: func(next *HubConfig) {
+		if rsm := GetRedisSampleManager(); rsm != nil {
+			rsm.ApplyRetentionConfig(next.SampleRetention)
+		}
+	}},
+	{key: "operation_retention", changed: differs(func(c *HubConfig) OperationRetentionConfig { return c.OperationRetention }), apply: func(cur, next *HubConfig) {
+		cur.OperationRetention = next.OperationRetention
+	}},
+	{key: "sample_compression", changed: differs(func(c *HubConfig) string { return c.SampleCompression }), apply: func(cur, next *HubConfig) {
+		cur.SampleCompression = next.SampleCompression
+	}, notify: func(next *HubConfig) {
+		if rsm := GetRedisSampleManager(); rsm != nil {
+			compression, _ := ParseSampleCompression(next.SampleCompression)
+			rsm.SetCompression(compression)
+		}
+	}},
+	{key: "sample_encryption", changed: differs(func(c *HubConfig) SampleEncryptionConfig { return c.SampleEncryption }), apply: func(cur, next *HubConfig) {
+		cur.SampleEncryption = next.SampleEncryption
+	}, notify: func(next *HubConfig) {
+		if rsm := GetRedisSampleManager(); rsm != nil {
+			rsm.ApplyEncryptionConfig(next.SampleEncryption)
+		}
+	}},
+	{key: "error_log_redact_fields", changed: differs(func(c *HubConfig) []string { return c.ErrorLogRedactFields }), apply: func(cur, next *HubConfig) {
+		cur.ErrorLogRedactFields = next.ErrorLogRedactFields
+	}},
+	{key: "json_placeholder", changed: differs(func(c *HubConfig) string { return c.JSONPlaceholder }), apply: func(cur, next *HubConfig) {
+		cur.JSONPlaceholder = next.JSONPlaceholder
+	}},
+	{key: "search_mask_patterns", changed: differs(func(c *HubConfig) []string { return c.SearchMaskPatterns }), apply: func(cur, next *HubConfig) {
+		cur.SearchMaskPatterns = next.SearchMaskPatterns
+	}},
+	{key: "strict_yaml", changed: differs(func(c *HubConfig) string { return c.StrictYAML }), apply: func(cur, next *HubConfig) {
+		cur.StrictYAML = next.StrictYAML
+	}},
+	{key: "ruleset_warn_rules", changed: differs(func(c *HubConfig) int { return c.RulesetWarnRules }), apply: func(cur, next *HubConfig) {
+		cur.RulesetWarnRules = next.RulesetWarnRules
+	}},
+	{key: "ruleset_max_rules", changed: differs(func(c *HubConfig) int { return c.RulesetMaxRules }), apply: func(cur, next *HubConfig) {
+		cur.RulesetMaxRules = next.RulesetMaxRules
+	}},
+	{key: "apply_lock_timeout", changed: differs(func(c *HubConfig) time.Duration { return c.ApplyLockTimeout }), apply: func(cur, next *HubConfig) {
+		cur.ApplyLockTimeout = next.ApplyLockTimeout
+	}},
+	{key: "project_start_concurrency", changed: differs(func(c *HubConfig) int { return c.ProjectStartConcurrency }), apply: func(cur, next *HubConfig) {
+		cur.ProjectStartConcurrency = next.ProjectStartConcurrency
+	}},
+	{key: "project_start_stagger", changed: differs(func(c *HubConfig) time.Duration { return c.ProjectStartStagger }), apply: func(cur, next *HubConfig) {
+		cur.ProjectStartStagger = next.ProjectStartStagger
+	}},
+}
+
+// applyHubConfig copies the safe changes of next into cur and reports the changes needing a restart
+func applyHubConfig(cur, next *HubConfig) (*ConfigReloadResult, error) {
+	if _, err := logger.ParseLevel(next.LogLevel); err != nil {
+		return nil, fmt.Errorf("invalid log_level: %w", err)
+	}
+	if _, err := ParseSampleCompression(next.SampleCompression); err != nil {
+		return nil, fmt.Errorf("invalid sample_compression: %w", err)
+	}
+	if err := VerifySampleEncryptionConfig(next.SampleEncryption); err != nil {
+		return nil, fmt.Errorf("invalid sample_encryption: %w", err)
+	}
+	if _, err := ParseStrictYAML(next.StrictYAML); err != nil {
+		return nil, fmt.Errorf("invalid strict_yaml: %w", err)
+	}
+	if err := VerifyOperationRetention(next.OperationRetention); err != nil {
+		return nil, fmt.Errorf("invalid operation_retention: %w", err)
+	}
+
+	result := &ConfigReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	var applied []hubConfigSetting
+	configMu.Lock()
+	for _, setting := range hubConfigSettings {
+		if !setting.changed(cur, next) {
+			continue
+		}
+		if setting.apply == nil {
+			result.RestartRequired = append(result.RestartRequired, setting.key)
+			continue
+		}
+		setting.apply(cur, next)
+		applied = append(applied, setting)
+		result.Applied = append(result.Applied, setting.key)
+	}
+	configMu.Unlock()
+
+	// Components are notified outside configMu, they may read the config themselves
+	for _, setting := range applied {
+		if setting.notify != nil {
+			setting.notify(next)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,238 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultHTTPPoolMaxIdle     = 10
+	defaultHTTPPoolIdleTimeout = 90 * time.Second
+	defaultSQLPoolMaxIdle      = 2
+	defaultSQLPoolIdleTimeout  = 5 * time.Minute
+)
+
+// ConnPoolConfig sizes the connection pool of an HTTP or SQL output, zero values use the defaults
+type ConnPoolConfig struct {
+	MaxIdle     int    `yaml:"max_idle,omitempty"`     // Idle connections kept for reuse, default 10 for HTTP and 2 for SQL
+	MaxOpen     int    `yaml:"max_open,omitempty"`     // Connections open at once, 0 is unlimited
+	IdleTimeout string `yaml:"idle_timeout,omitempty"` // How long an idle connection is kept, default 90s for HTTP and 5m for SQL
+}
+
+// VerifyConnPoolConfig validates the pool settings of an output, prefix names the config key
+func VerifyConnPoolConfig(prefix string, cfg *ConnPoolConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.MaxIdle < 0 {
+		return fmt.Errorf("'%s.max_idle' cannot be negative", prefix)
+	}
+	if cfg.MaxOpen < 0 {
+		return fmt.Errorf("'%s.max_open' cannot be negative", prefix)
+	}
+	if cfg.MaxOpen > 0 && cfg.MaxIdle > cfg.MaxOpen {
+		return fmt.Errorf("'%s.max_idle' (%d) cannot exceed '%s.max_open' (%d)", prefix, cfg.MaxIdle, prefix, cfg.MaxOpen)
+	}
+	if cfg.IdleTimeout != "" {
+		if d, err := time.ParseDuration(cfg.IdleTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid '%s.idle_timeout' %q: must be a positive duration like 90s", prefix, cfg.IdleTimeout)
+		}
+	}
+	return nil
+}
+
+// ConnPool applies the pool settings of an output to its HTTP transport or SQL handle and counts
+// how its connections are used
+type ConnPool struct {
+	mu  sync.Mutex
+	cfg ConnPoolConfig
+
+	requests uint64 // HTTP requests sent
+	reused   uint64 // HTTP requests served by an already open connection
+	opened   uint64 // HTTP connections dialed
+	closed   uint64 // HTTP connections closed
+	inFlight int64  // HTTP requests waiting for their response
+
+	db atomic.Pointer[sql.DB]
+}
+
+// ConnPoolSnapshot is the size and usage of an output connection pool. Reused, Requests and Opened
+// are counted for HTTP outputs; SQL outputs report the database/sql statistics instead.
+type ConnPoolSnapshot struct {
+	Type     string `json:"type"` // http or sql
+	MaxIdle  int    `json:"max_idle"`
+	MaxOpen  int    `json:"max_open"`
+	Open     int64  `json:"open"`   // Connections currently open
+	InUse    int64  `json:"in_use"` // Connections currently serving a write
+	Opened   uint64 `json:"opened"` // Connections opened since the hub started
+	Requests uint64 `json:"requests"`
+	Reused   uint64 `json:"reused"` // Requests that reused an open connection
+	Waits    int64  `json:"waits"`  // Writes that waited for a free connection (SQL)
+}
+
+// outputConnPools holds the connection pool of each output ID
+var outputConnPools sync.Map
+
+// OutputConnPool returns the connection pool of an output with its current settings, creating it
+// on first use. Counters survive restarts of the output, like its latency histogram.
+func OutputConnPool(outputID string, cfg *ConnPoolConfig) *ConnPool {
+	v, _ := outputConnPools.LoadOrStore(outputID, &ConnPool{})
+	p := v.(*ConnPool)
+	p.mu.Lock()
+	p.cfg = ConnPoolConfig{}
+	if cfg != nil {
+		p.cfg = *cfg
+	}
+	p.mu.Unlock()
+	return p
+}
+
+// OutputConnPoolSnapshots returns the pool usage of every output that has one
+func OutputConnPoolSnapshots() map[string]ConnPoolSnapshot {
+	res := make(map[string]ConnPoolSnapshot)
+	outputConnPools.Range(func(key, value interface{}) bool {
+		res[key.(string)] = value.(*ConnPool).Snapshot()
+		return true
+	})
+	return res
+}
+
+func (p *ConnPool) config() ConnPoolConfig {
+	if p == nil {
+		return ConnPoolConfig{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg
+}
+
+func poolIdleTimeout(cfg ConnPoolConfig, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(cfg.IdleTimeout); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+// Transport returns an HTTP transport sized by the pool settings, counting connections and reuse.
+// A nil pool returns a transport with the defaults.
+func (p *ConnPool) Transport(tlsConfig *tls.Config) http.RoundTripper {
+	cfg := p.config()
+	maxIdle := cfg.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = defaultHTTPPoolMaxIdle
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        maxIdle,
+		MaxIdleConnsPerHost: maxIdle,
+		MaxConnsPerHost:     cfg.MaxOpen,
+		IdleConnTimeout:     poolIdleTimeout(cfg, defaultHTTPPoolIdleTimeout),
+		TLSHandshakeTimeout: 10 * time.Second,
+		DialContext:         dialer.DialContext,
+	}
+	if p == nil {
+		return transport
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		atomic.AddUint64(&p.opened, 1)
+		return &countedConn{Conn: conn, pool: p}, nil
+	}
+	return &pooledRoundTripper{base: transport, pool: p}
+}
+
+// countedConn counts its close in the pool
+type countedConn struct {
+	net.Conn
+	pool *ConnPool
+	once sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { atomic.AddUint64(&c.pool.closed, 1) })
+	return c.Conn.Close()
+}
+
+// pooledRoundTripper counts the requests of a pool and whether they reused a connection
+type pooledRoundTripper struct {
+	base *http.Transport
+	pool *ConnPool
+}
+
+func (rt *pooledRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddUint64(&rt.pool.requests, 1)
+	atomic.AddInt64(&rt.pool.inFlight, 1)
+	defer atomic.AddInt64(&rt.pool.inFlight, -1)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddUint64(&rt.pool.reused, 1)
+			}
+		},
+	}
+	return rt.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// ConfigureDB applies the pool settings to a SQL handle and reports its statistics from then on
+func (p *ConnPool) ConfigureDB(db *sql.DB) {
+	cfg := p.config()
+	maxIdle := cfg.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = defaultSQLPoolMaxIdle
+	}
+	db.SetMaxIdleConns(maxIdle)
+	db.SetMaxOpenConns(cfg.MaxOpen)
+	db.SetConnMaxIdleTime(poolIdleTimeout(cfg, defaultSQLPoolIdleTimeout))
+	if p != nil {
+		p.db.Store(db)
+	}
+}
+
+// Snapshot returns the current size and usage of the pool
+func (p *ConnPool) Snapshot() ConnPoolSnapshot {
+	cfg := p.config()
+	if db := p.db.Load(); db != nil {
+		stats := db.Stats()
+		maxIdle := cfg.MaxIdle
+		if maxIdle == 0 {
+			maxIdle = defaultSQLPoolMaxIdle
+		}
+		return ConnPoolSnapshot{
+			Type:    "sql",
+			MaxIdle: maxIdle,
+			MaxOpen: stats.MaxOpenConnections,
+			Open:    int64(stats.OpenConnections),
+			InUse:   int64(stats.InUse),
+			Opened:  uint64(stats.OpenConnections) + uint64(stats.MaxIdleClosed+stats.MaxIdleTimeClosed+stats.MaxLifetimeClosed),
+			Waits:   stats.WaitCount,
+		}
+	}
+	maxIdle := cfg.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = defaultHTTPPoolMaxIdle
+	}
+	opened := atomic.LoadUint64(&p.opened)
+	return ConnPoolSnapshot{
+		Type:     "http",
+		MaxIdle:  maxIdle,
+		MaxOpen:  cfg.MaxOpen,
+		Open:     int64(opened - atomic.LoadUint64(&p.closed)),
+		InUse:    atomic.LoadInt64(&p.inFlight),
+		Opened:   opened,
+		Requests: atomic.LoadUint64(&p.requests),
+		Reused:   atomic.LoadUint64(&p.reused),
+	}
+}
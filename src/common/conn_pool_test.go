@@ -0,0 +1,66 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnPool_ReusesConnectionsUpToLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	pool := &ConnPool{cfg: ConnPoolConfig{MaxIdle: 2, MaxOpen: 2}}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: pool.Transport(nil)}
+	write := func() {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Errorf("write failed: %v", err)
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	for i := 0; i < 5; i++ {
+		write()
+	}
+	s := pool.Snapshot()
+	if s.Type != "http" || s.Requests != 5 || s.Opened != 1 || s.Reused != 4 {
+		t.Fatalf("sequential writes must share one connection, got %+v", s)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			write()
+		}()
+	}
+	wg.Wait()
+	s = pool.Snapshot()
+	if s.Requests != 13 || s.Opened > 2 || s.Reused != s.Requests-s.Opened {
+		t.Fatalf("concurrent writes must stay within max_open connections, got %+v", s)
+	}
+	if s.InUse != 0 || s.Open != int64(s.Opened) {
+		t.Fatalf("idle connections must stay open for reuse, got %+v", s)
+	}
+}
+
+func TestVerifyConnPoolConfig(t *testing.T) {
+	if err := VerifyConnPoolConfig("loki.pool", &ConnPoolConfig{MaxIdle: 4, MaxOpen: 8, IdleTimeout: "30s"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, cfg := range []*ConnPoolConfig{{MaxIdle: -1}, {MaxIdle: 4, MaxOpen: 2}, {IdleTimeout: "soon"}} {
+		if err := VerifyConnPoolConfig("loki.pool", cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
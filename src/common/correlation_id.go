@@ -0,0 +1,31 @@
+package common
+
+// DefaultCorrelationIDField is the reserved field that carries the per-event correlation id
+const DefaultCorrelationIDField = "_hub_correlation_id"
+
+// CorrelationIDDisabled turns off correlation id injection when used as correlation_id_field
+const CorrelationIDDisabled = "-"
+
+// CorrelationIDField returns the configured correlation id field, or "" when disabled
+func CorrelationIDField() string {
+	if Config == nil || Config.CorrelationIDField == "" {
+		return DefaultCorrelationIDField
+	}
+	if Config.CorrelationIDField == CorrelationIDDisabled {
+		return ""
+	}
+	return Config.CorrelationIDField
+}
+
+// EnsureCorrelationID sets a new correlation id on the event unless it already carries one,
+// so an id assigned upstream (e.g. by another hub) stays stable
+func EnsureCorrelationID(msg map[string]interface{}) {
+	field := CorrelationIDField()
+	if field == "" || msg == nil {
+		return
+	}
+	if id, ok := msg[field].(string); ok && id != "" {
+		return
+	}
+	msg[field] = NewUUID()
+}
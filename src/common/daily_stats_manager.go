@@ -280,9 +280,11 @@ func (dsm *DailyStatsManager) persistenceLoop() {
 }
 
 func (dsm *DailyStatsManager) CollectAllComponentsData() {
+	dsm.collectRuleHits()
 	if statsCollector != nil {
 		// 检查是否有运行中的项目，如果没有则跳过收集
 		stats := GetStatsCollector()()
+		GlobalThroughput.Record(time.Now(), stats)
 		if len(stats) == 0 {
 			logger.Debug("No running components found, skipping stats collection")
 			return
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"AgentSmith-HUB/logger"
 	"bytes"
 	"context"
 	"crypto/tls"
@@ -32,7 +33,8 @@ type ElasticsearchProducer struct {
 	flushDur      time.Duration
 	maxRetries    int
 	retryDelay    time.Duration
-	stopChan      chan struct{} // Add stop channel for graceful shutdown
+	stopChan      chan struct{}     // Add stop channel for graceful shutdown
+	latency       *LatencyHistogram // Bulk write duration per batch, including retries
 }
 
 // replaceTimePatterns replaces time patterns in index name with actual values
@@ -67,16 +69,14 @@ func replaceTimePatterns(indexTemplate string) string {
 }
 
 // NewElasticsearchProducer creates a new Elasticsearch producer
-func NewElasticsearchProducer(hosts []string, index string, msgChan chan map[string]interface{}, batchSize int, flushDur time.Duration, auth *ElasticsearchAuthConfig) (*ElasticsearchProducer, error) {
+func NewElasticsearchProducer(hosts []string, index string, msgChan chan map[string]interface{}, batchSize int, flushDur time.Duration, auth *ElasticsearchAuthConfig, pool *ConnPool, latency *LatencyHistogram) (*ElasticsearchProducer, error) {
 	cfg := elasticsearch.Config{
 		Addresses:     hosts,
 		MaxRetries:    3,
 		RetryOnStatus: []int{502, 503, 504, 429},
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // Skip TLS certificate verification
-			},
-		},
+		Transport: pool.Transport(&tls.Config{
+			InsecureSkipVerify: true, // Skip TLS certificate verification
+		}),
 	}
 
 	// Configure authentication if provided
@@ -118,6 +118,7 @@ func NewElasticsearchProducer(hosts []string, index string, msgChan chan map[str
 		maxRetries:    3,
 		retryDelay:    1 * time.Second,
 		stopChan:      make(chan struct{}),
+		latency:       latency,
 	}
 
 	go prod.run()
@@ -183,17 +184,24 @@ func (p *ElasticsearchProducer) sendBatch(batch []map[string]interface{}) {
 				"_index": p.Index,
 			},
 		}
+		// Encode the document first so a failure does not leave a dangling action line
+		line, err := SafeMarshal(doc)
+		if err != nil {
+			fmt.Printf("Failed to encode document: %v\n", err)
+			continue
+		}
 		if err := json.NewEncoder(&buf).Encode(meta); err != nil {
 			fmt.Printf("Failed to encode meta: %v\n", err)
 			continue
 		}
 		// Add document
-		if err := json.NewEncoder(&buf).Encode(doc); err != nil {
-			fmt.Printf("Failed to encode document: %v\n", err)
-			continue
-		}
+		buf.Write(line)
+		buf.WriteByte('\n')
 	}
 
+	start := time.Now()
+	defer func() { p.latency.Observe(time.Since(start)) }()
+
 	// Try to send with retries and timeout control
 	for i := 0; i <= p.maxRetries; i++ {
 		// Create context with timeout for each retry
@@ -206,7 +214,7 @@ func (p *ElasticsearchProducer) sendBatch(batch []map[string]interface{}) {
 
 		if err != nil {
 			if i == p.maxRetries {
-				fmt.Printf("Failed to send batch to ES after %d retries: %v\n", p.maxRetries, err)
+				logger.Error("[ElasticsearchProducer] failed to send batch", "index", p.Index, "retries", p.maxRetries, "batch_size", len(batch), "error", err, ErrorLogContextKey, NewErrorLogContext(batch[0]))
 				return
 			}
 			time.Sleep(p.retryDelay)
@@ -216,7 +224,7 @@ func (p *ElasticsearchProducer) sendBatch(batch []map[string]interface{}) {
 
 		if res.IsError() {
 			if i == p.maxRetries {
-				fmt.Printf("ES returned error after %d retries: %s\n", p.maxRetries, res.String())
+				logger.Error("[ElasticsearchProducer] elasticsearch returned error for batch", "index", p.Index, "retries", p.maxRetries, "batch_size", len(batch), "error", res.String(), ErrorLogContextKey, NewErrorLogContext(batch[0]))
 				return
 			}
 			time.Sleep(p.retryDelay)
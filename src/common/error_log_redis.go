@@ -3,23 +3,59 @@ package common
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"sort"
 	"strings"
 	"time"
 )
 
+const (
+	// ErrorLogContextKey is the log attribute key carrying the offending event, e.g.
+	// logger.Error("failed to produce message", "error", err, common.ErrorLogContextKey, common.NewErrorLogContext(msg))
+	ErrorLogContextKey = "event_context"
+
+	// RedisErrorLogContextPrefix stores event snapshots keyed by error log ID
+	RedisErrorLogContextPrefix = "cluster:error_log_context:"
+
+	// MaxErrorLogContextSize bounds the serialized event snapshot stored with an error log
+	MaxErrorLogContextSize = 16 * 1024
+
+	errorLogTTL = 14 * 24 * 60 * 60
+)
+
+// DefaultErrorLogRedactFields are always redacted from error log event snapshots
+var DefaultErrorLogRedactFields = []string{"password", "passwd", "secret", "token", "authorization", "access_key_secret", "api_key"}
+
+// ErrorLogContext wraps an event attached to an error log. It renders as a placeholder in file
+// logs so that full events only end up in the bounded, redacted Redis snapshot.
+type ErrorLogContext struct {
+	Event map[string]interface{}
+}
+
+// NewErrorLogContext creates an error log context for an event
+func NewErrorLogContext(event map[string]interface{}) ErrorLogContext {
+	return ErrorLogContext{Event: event}
+}
+
+// LogValue implements slog.LogValuer
+func (c ErrorLogContext) LogValue() slog.Value {
+	return slog.StringValue(fmt.Sprintf("<event with %d fields>", len(c.Event)))
+}
+
 // ErrorLogEntry represents an error log entry for Redis storage
 type ErrorLogEntry struct {
-	Timestamp time.Time              `json:"timestamp"`
-	Level     string                 `json:"level"`
-	Message   string                 `json:"message"`
-	Source    string                 `json:"source"` // "hub" or "plugin"
-	NodeID    string                 `json:"node_id"`
-	Function  string                 `json:"function,omitempty"`
-	File      string                 `json:"file,omitempty"`
-	Line      int                    `json:"line,omitempty"`
-	Error     string                 `json:"error,omitempty"`
-	Details   map[string]interface{} `json:"details,omitempty"`
+	ID         string                 `json:"id,omitempty"`
+	HasContext bool                   `json:"has_context,omitempty"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Level      string                 `json:"level"`
+	Message    string                 `json:"message"`
+	Source     string                 `json:"source"` // "hub" or "plugin"
+	NodeID     string                 `json:"node_id"`
+	Function   string                 `json:"function,omitempty"`
+	File       string                 `json:"file,omitempty"`
+	Line       int                    `json:"line,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
 }
 
 // WriteErrorLogToRedis writes an error log entry to Redis
@@ -28,6 +64,21 @@ func WriteErrorLogToRedis(entry ErrorLogEntry) error {
 		return fmt.Errorf("Redis client not initialized")
 	}
 
+	if entry.ID == "" {
+		entry.ID = NewUUID()
+	}
+
+	// Store the offending event snapshot separately so list queries stay small
+	if raw, ok := entry.Details[ErrorLogContextKey]; ok {
+		delete(entry.Details, ErrorLogContextKey)
+		if logCtx, ok := raw.(ErrorLogContext); ok && logCtx.Event != nil {
+			snapshot := BuildErrorLogContextSnapshot(logCtx.Event, errorLogRedactFields())
+			if _, err := RedisSet(RedisErrorLogContextPrefix+entry.ID, snapshot, errorLogTTL); err == nil {
+				entry.HasContext = true
+			}
+		}
+	}
+
 	// Convert entry to JSON
 	jsonData, err := json.Marshal(entry)
 	if err != nil {
@@ -43,8 +94,11 @@ func WriteErrorLogToRedis(entry ErrorLogEntry) error {
 		return fmt.Errorf("failed to push error log to Redis: %w", err)
 	}
 
+	// Live tails (GET /error-logs/stream) on every node follow the stream channel
+	_ = RedisPublish(RedisErrorLogStreamChannel, string(jsonData))
+
 	// Set TTL for the key to 7 days (7 * 24 * 60 * 60 = 604800 seconds)
-	if err := RedisExpire(key, 14*24*60*60); err != nil {
+	if err := RedisExpire(key, errorLogTTL); err != nil {
 		// Don't fail if TTL setting fails, just log it
 		return nil
 	}
@@ -52,6 +106,77 @@ func WriteErrorLogToRedis(entry ErrorLogEntry) error {
 	return nil
 }
 
+// GetErrorLogContext returns the event snapshot stored with an error log
+func GetErrorLogContext(id string) (map[string]interface{}, error) {
+	if rdb == nil {
+		return nil, fmt.Errorf("Redis client not initialized")
+	}
+
+	raw, err := RedisGet(RedisErrorLogContextPrefix + id)
+	if err != nil {
+		return nil, fmt.Errorf("no context stored for error log %s", id)
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse error log context: %w", err)
+	}
+	return snapshot, nil
+}
+
+// errorLogRedactFields returns the default redact fields plus those configured in hub config
+func errorLogRedactFields() []string {
+	fields := append([]string{}, DefaultErrorLogRedactFields...)
+	return append(fields, ReadConfig(func(c *HubConfig) []string { return c.ErrorLogRedactFields })...)
+}
+
+// BuildErrorLogContextSnapshot redacts sensitive fields and serializes the event,
+// truncating it if it exceeds MaxErrorLogContextSize
+func BuildErrorLogContextSnapshot(event map[string]interface{}, redactFields []string) string {
+	redact := make(map[string]bool, len(redactFields))
+	for _, f := range redactFields {
+		redact[strings.ToLower(f)] = true
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"event": redactValue(event, redact)})
+	if err != nil {
+		data, _ = json.Marshal(map[string]interface{}{"error": "failed to serialize event: " + err.Error()})
+		return string(data)
+	}
+
+	if len(data) > MaxErrorLogContextSize {
+		data, _ = json.Marshal(map[string]interface{}{
+			"truncated": true,
+			"raw":       strings.ToValidUTF8(string(data[:MaxErrorLogContextSize]), ""),
+		})
+	}
+	return string(data)
+}
+
+// redactValue returns a copy of v with values of sensitive keys replaced, recursing into maps and slices
+func redactValue(v interface{}, redact map[string]bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			if redact[strings.ToLower(k)] {
+				res[k] = "***"
+			} else {
+				res[k] = redactValue(item, redact)
+			}
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(value))
+		for i, item := range value {
+			res[i] = redactValue(item, redact)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
 // GetErrorLogsFromRedis retrieves error logs from Redis for all nodes or a specific node
 func GetErrorLogsFromRedis(nodeID string, limit int, offset int) ([]ErrorLogEntry, error) {
 	if rdb == nil {
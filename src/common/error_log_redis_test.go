@@ -0,0 +1,100 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestErrorLogContext_CapturedFromLogAttrs(t *testing.T) {
+	captured := make(chan logger.RedisErrorLogEntry, 1)
+	handler := logger.NewRedisErrorLogHandler(slog.NewTextHandler(io.Discard, nil), "hub", "node-1", func(entry logger.RedisErrorLogEntry) error {
+		captured <- entry
+		return nil
+	})
+
+	failing := map[string]interface{}{"user": "alice", "password": "hunter2"}
+	slog.New(handler).Error("[KafkaProducer] failed to produce message to topic", "topic", "t", ErrorLogContextKey, NewErrorLogContext(failing))
+
+	var entry logger.RedisErrorLogEntry
+	select {
+	case entry = <-captured:
+	case <-time.After(time.Second):
+		t.Fatalf("error log entry was not written")
+	}
+
+	logCtx, ok := entry.Details[ErrorLogContextKey].(ErrorLogContext)
+	if !ok {
+		t.Fatalf("expected event context in details, got %T", entry.Details[ErrorLogContextKey])
+	}
+
+	var snapshot map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(BuildErrorLogContextSnapshot(logCtx.Event, DefaultErrorLogRedactFields)), &snapshot); err != nil {
+		t.Fatalf("invalid snapshot: %v", err)
+	}
+	if snapshot["event"]["user"] != "alice" {
+		t.Fatalf("expected failing event in snapshot, got %v", snapshot)
+	}
+	if snapshot["event"]["password"] != "***" {
+		t.Fatalf("expected password to be redacted, got %v", snapshot["event"]["password"])
+	}
+}
+
+func TestBuildErrorLogContextSnapshot_Truncates(t *testing.T) {
+	event := map[string]interface{}{"big": strings.Repeat("x", MaxErrorLogContextSize*2)}
+	snapshot := BuildErrorLogContextSnapshot(event, nil)
+
+	var res map[string]interface{}
+	if err := json.Unmarshal([]byte(snapshot), &res); err != nil {
+		t.Fatalf("invalid snapshot: %v", err)
+	}
+	if res["truncated"] != true {
+		t.Fatalf("expected truncated snapshot")
+	}
+}
+
+func TestErrorLogContext_KafkaProduceFailure(t *testing.T) {
+	captured := make(chan logger.RedisErrorLogEntry, 8)
+	logger.InitLoggerWithRedisAndNodeID("node-1", func(entry logger.RedisErrorLogEntry) error {
+		captured <- entry
+		return nil
+	})
+	defer logger.InitLogger()
+
+	// Nothing listens on the broker address, so every record fails once its delivery times out
+	client, err := kgo.NewClient(kgo.SeedBrokers("127.0.0.1:1"), kgo.DefaultProduceTopic("alerts"), kgo.RecordDeliveryTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgChan := make(chan map[string]interface{}, 2)
+	producer := &KafkaProducer{Client: client, MsgChan: msgChan, Topic: "alerts", stopChan: make(chan struct{})}
+	go producer.run()
+	defer producer.Close()
+
+	msgChan <- map[string]interface{}{"user": "alice"}
+	msgChan <- map[string]interface{}{"user": "bob"}
+
+	// Each failure is logged with its own event
+	users := make(map[interface{}]bool)
+	for len(users) < 2 {
+		select {
+		case entry := <-captured:
+			logCtx, ok := entry.Details[ErrorLogContextKey].(ErrorLogContext)
+			if !ok || entry.Message != "[KafkaProducer] failed to produce message to topic" {
+				continue
+			}
+			users[logCtx.Event["user"]] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("produce failures were not logged, got %v", users)
+		}
+	}
+	if !users["alice"] || !users["bob"] {
+		t.Fatalf("expected the events of both failed records, got %v", users)
+	}
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// RedisErrorLogStreamChannel carries every error log entry as it is written, for live tailing
+const RedisErrorLogStreamChannel = "cluster:error_logs:stream"
+
+// ErrorLogTail fans the error log entries written by any node out to the live tail subscribers of
+// this node. Entries are dropped for subscribers that do not keep up, a tail is best effort.
+type ErrorLogTail struct {
+	mu          sync.Mutex
+	subscribers map[chan ErrorLogEntry]struct{}
+	listenOnce  sync.Once
+}
+
+// GlobalErrorLogTail is the error log tail of this node
+var GlobalErrorLogTail = &ErrorLogTail{subscribers: make(map[chan ErrorLogEntry]struct{})}
+
+// Subscribe returns a channel receiving new error log entries and the function ending the
+// subscription. The first subscription starts listening to the Redis stream channel.
+func (t *ErrorLogTail) Subscribe(buffer int) (<-chan ErrorLogEntry, func()) {
+	t.listenOnce.Do(func() {
+		if rdb != nil {
+			go t.listen()
+		}
+	})
+
+	ch := make(chan ErrorLogEntry, buffer)
+	t.mu.Lock()
+	t.subscribers[ch] = struct{}{}
+	t.mu.Unlock()
+	return ch, func() {
+		t.mu.Lock()
+		delete(t.subscribers, ch)
+		t.mu.Unlock()
+	}
+}
+
+// Subscribers returns the number of live tail subscribers
+func (t *ErrorLogTail) Subscribers() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.subscribers)
+}
+
+// Publish delivers an entry to every subscriber with room for it
+func (t *ErrorLogTail) Publish(entry ErrorLogEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.subscribers {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+func (t *ErrorLogTail) listen() {
+	pubsub := rdb.Subscribe(context.Background(), RedisErrorLogStreamChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var entry ErrorLogEntry
+		if err := json.Unmarshal([]byte(msg.Payload), &entry); err != nil {
+			logger.Warn("Failed to decode streamed error log entry", "error", err)
+			continue
+		}
+		t.Publish(entry)
+	}
+}
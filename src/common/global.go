@@ -4,6 +4,21 @@ import "sync"
 
 var Config *HubConfig
 
+// configMu guards the Config fields a reload changes while the hub runs
+var configMu sync.RWMutex
+
+// ReadConfig reads a value of the running config under configMu, the zero value when none is loaded.
+// Settings that can be reloaded must be read through it.
+func ReadConfig[T any](get func(*HubConfig) T) T {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	if Config == nil {
+		var zero T
+		return zero
+	}
+	return get(Config)
+}
+
 // for follower node
 // id:raw
 var AllInputsRawConfig map[string]string
@@ -10,7 +10,6 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 
-	"github.com/bytedance/sonic"
 	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/sasl"
@@ -74,7 +73,8 @@ type KafkaProducer struct {
 	KeyFieldList []string // List of fields to use as keys
 	BatchSize    int
 	BatchTimeout time.Duration
-	stopChan     chan struct{} // Add stop channel for graceful shutdown
+	stopChan     chan struct{}     // Add stop channel for graceful shutdown
+	latency      *LatencyHistogram // Time from produce to broker acknowledgement
 }
 
 func EnsureTopicExists(cl *kgo.Client, topic string) (bool, error) {
@@ -102,6 +102,7 @@ func NewKafkaProducer(
 	msgChan chan map[string]interface{},
 	keyField string,
 	tlsCfg *KafkaTLSConfig,
+	latency *LatencyHistogram,
 ) (*KafkaProducer, error) {
 	opts := []kgo.Opt{
 		kgo.SeedBrokers(brokers...),
@@ -150,6 +151,7 @@ func NewKafkaProducer(
 		BatchSize:    1000,
 		BatchTimeout: 100 * time.Millisecond,
 		stopChan:     make(chan struct{}),
+		latency:      latency,
 	}
 
 	_, err = EnsureTopicExists(cl, topic)
@@ -161,6 +163,25 @@ func NewKafkaProducer(
 	return prod, nil
 }
 
+// NewKafkaRecord serializes msg into a record for topic, keyed by the value at keyFieldList when present
+func NewKafkaRecord(topic string, keyFieldList []string, msg map[string]interface{}) (*kgo.Record, error) {
+	value, err := SafeMarshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &kgo.Record{
+		Topic: topic,
+		Value: value,
+	}
+	if len(keyFieldList) > 0 {
+		if tmp, ok := GetCheckData(msg, keyFieldList); ok {
+			rec.Key = []byte(tmp)
+		}
+	}
+	return rec, nil
+}
+
 // run processes messages from the input channel and sends them to Kafka
 // It handles message serialization and error reporting
 func (p *KafkaProducer) run() {
@@ -178,28 +199,26 @@ func (p *KafkaProducer) run() {
 				return
 			}
 
-			value, err := sonic.Marshal(msg)
+			rec, err := NewKafkaRecord(p.Topic, p.KeyFieldList, msg)
 			if err != nil {
-				logger.Error("[KafkaProducer] failed to serialize message", "error", err.Error())
+				logger.Error("[KafkaProducer] failed to serialize message", "error", err.Error(), ErrorLogContextKey, NewErrorLogContext(msg))
 				continue // skip invalid message
 			}
 
-			rec := &kgo.Record{
-				Topic: p.Topic,
-				Value: value,
-			}
-
-			if p.KeyField != "" {
-				if tmp, ok := GetCheckData(msg, p.KeyFieldList); ok {
-					rec.Key = []byte(tmp)
-				}
-			}
+			p.Client.Produce(context.Background(), rec, p.produced(msg, time.Now(), "[KafkaProducer] failed to produce message to topic"))
+		}
+	}
+}
 
-			p.Client.Produce(context.Background(), rec, func(r *kgo.Record, err error) {
-				if err != nil {
-					logger.Error("[KafkaProducer] failed to produce message to topic", "topic", p.Topic, "error", err)
-				}
-			})
+// produced returns the acknowledgement callback of msg: it observes the latency from start, when set,
+// and writes a failed produce to the error log with the event
+func (p *KafkaProducer) produced(msg map[string]interface{}, start time.Time, failure string) func(*kgo.Record, error) {
+	return func(r *kgo.Record, err error) {
+		if !start.IsZero() {
+			p.latency.Observe(time.Since(start))
+		}
+		if err != nil {
+			logger.Error(failure, "topic", p.Topic, "error", err, ErrorLogContextKey, NewErrorLogContext(msg))
 		}
 	}
 }
@@ -226,28 +245,13 @@ func (p *KafkaProducer) drainRemainingMessages() {
 				return
 			}
 
-			value, err := sonic.Marshal(msg)
+			rec, err := NewKafkaRecord(p.Topic, p.KeyFieldList, msg)
 			if err != nil {
-				logger.Error("[KafkaProducer] failed to serialize message during drain", "error", err.Error())
+				logger.Error("[KafkaProducer] failed to serialize message during drain", "error", err.Error(), ErrorLogContextKey, NewErrorLogContext(msg))
 				continue
 			}
 
-			rec := &kgo.Record{
-				Topic: p.Topic,
-				Value: value,
-			}
-
-			if p.KeyField != "" {
-				if tmp, ok := GetCheckData(msg, p.KeyFieldList); ok {
-					rec.Key = []byte(tmp)
-				}
-			}
-
-			p.Client.Produce(context.Background(), rec, func(r *kgo.Record, err error) {
-				if err != nil {
-					logger.Error("[KafkaProducer] failed to produce message to topic during drain", "topic", p.Topic, "error", err)
-				}
-			})
+			p.Client.Produce(context.Background(), rec, p.produced(msg, time.Time{}, "[KafkaProducer] failed to produce message to topic during drain"))
 			drainCount++
 		}
 	}
@@ -264,6 +268,8 @@ type KafkaConsumer struct {
 	Client   *kgo.Client
 	MsgChan  chan map[string]interface{}
 	stopChan chan struct{}
+	decode   MessageDecoder
+	tagTopic bool
 }
 
 // getCompression returns the appropriate compression option based on the compression type
@@ -319,14 +325,45 @@ func getSASLMechanism(cfg *KafkaSASLConfig) (sasl.Mechanism, error) {
 	}
 }
 
+// KafkaTopicField holds the topic of the record an event was consumed from, set on inputs that
+// consume several topics or a topic pattern
+const KafkaTopicField = "_hub_topic"
+
+// consumeTopicsOpt subscribes to the listed topics, or with a pattern to every topic whose whole
+// name matches it, including topics created later
+func consumeTopicsOpt(topics []string, topicPattern string) []kgo.Opt {
+	if topicPattern != "" {
+		return []kgo.Opt{kgo.ConsumeTopics("^(?:" + topicPattern + ")$"), kgo.ConsumeRegex()}
+	}
+	return []kgo.Opt{kgo.ConsumeTopics(topics...)}
+}
+
+// decodeRecord decodes the value of rec, tagging the event with its topic when tagTopic is set
+func decodeRecord(decode MessageDecoder, rec *kgo.Record, tagTopic bool) (map[string]interface{}, error) {
+	m, err := decode(rec.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !tagTopic {
+		return m, nil
+	}
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	m[KafkaTopicField] = rec.Topic
+	return m, nil
+}
+
 // NewKafkaConsumer creates a new high-performance Kafka consumer with compression and SASL support.
-func NewKafkaConsumer(brokers []string, group, topic string, compression KafkaCompressionType, saslCfg *KafkaSASLConfig, tlsCfg *KafkaTLSConfig, offsetReset string, msgChan chan map[string]interface{}) (*KafkaConsumer, error) {
+// It consumes topics, or the topics matching topicPattern when set, and tagTopic records the topic of
+// each event in KafkaTopicField. decode turns record values into events, nil decodes JSON.
+func NewKafkaConsumer(brokers []string, group string, topics []string, topicPattern string, tagTopic bool, compression KafkaCompressionType, saslCfg *KafkaSASLConfig, tlsCfg *KafkaTLSConfig, offsetReset string, decode MessageDecoder, msgChan chan map[string]interface{}) (*KafkaConsumer, error) {
 	opts := []kgo.Opt{
 		kgo.SeedBrokers(brokers...),
 		kgo.ConsumerGroup(group),
-		kgo.ConsumeTopics(topic),
 		kgo.DisableAutoCommit(), // manual commit for perf
 	}
+	opts = append(opts, consumeTopicsOpt(topics, topicPattern)...)
 
 	// Set offset reset strategy based on configuration
 	switch offsetReset {
@@ -386,10 +423,15 @@ func NewKafkaConsumer(brokers []string, group, topic string, compression KafkaCo
 		return nil, err
 	}
 
+	if decode == nil {
+		decode = decodeJSONMessage
+	}
 	cons := &KafkaConsumer{
 		Client:   cl,
 		MsgChan:  msgChan,
 		stopChan: make(chan struct{}),
+		decode:   decode,
+		tagTopic: tagTopic,
 	}
 	go cons.run()
 	return cons, nil
@@ -428,8 +470,8 @@ func (c *KafkaConsumer) run() {
 
 			// Process messages immediately when available
 			fetches.EachRecord(func(rec *kgo.Record) {
-				var m map[string]interface{}
-				if err := sonic.Unmarshal(rec.Value, &m); err != nil {
+				m, err := decodeRecord(c.decode, rec, c.tagTopic)
+				if err != nil {
 					logger.Error("[KafkaConsumer] failed to deserialize message", "error", err.Error())
 					return
 				}
@@ -472,8 +514,8 @@ func (c *KafkaConsumer) drainRemainingMessages() {
 			}
 
 			fetches.EachRecord(func(rec *kgo.Record) {
-				var m map[string]interface{}
-				if err := sonic.Unmarshal(rec.Value, &m); err != nil {
+				m, err := decodeRecord(c.decode, rec, c.tagTopic)
+				if err != nil {
 					logger.Error("[KafkaConsumer] failed to deserialize message during drain", "error", err.Error())
 					return
 				}
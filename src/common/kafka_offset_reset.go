@@ -0,0 +1,209 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Offset reset modes of a Kafka input
+const (
+	OffsetResetEarliest  = "earliest"
+	OffsetResetLatest    = "latest"
+	OffsetResetOffset    = "offset"
+	OffsetResetTimestamp = "timestamp"
+)
+
+// KafkaOffsetReset repositions the consumer group of a Kafka input, applied once on its next start
+type KafkaOffsetReset struct {
+	Mode string `json:"mode"` // earliest, latest, offset or timestamp
+	// Partitions holds, per partition, the offset (mode offset) or the unix milliseconds (mode timestamp).
+	// Unlisted partitions keep their committed offset, except in timestamp mode with Timestamp set.
+	Partitions map[int32]int64 `json:"partitions,omitempty"`
+	// Timestamp is the unix milliseconds used in timestamp mode for partitions not listed
+	Timestamp   int64     `json:"timestamp,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// Validate checks the mode and its partition values
+func (r *KafkaOffsetReset) Validate() error {
+	switch r.Mode {
+	case OffsetResetEarliest, OffsetResetLatest:
+		return nil
+	case OffsetResetOffset:
+		if len(r.Partitions) == 0 {
+			return fmt.Errorf("offset mode requires partitions with the target offset of each")
+		}
+	case OffsetResetTimestamp:
+		if len(r.Partitions) == 0 && r.Timestamp <= 0 {
+			return fmt.Errorf("timestamp mode requires a timestamp or per-partition timestamps (unix milliseconds)")
+		}
+		if r.Timestamp < 0 {
+			return fmt.Errorf("timestamp cannot be negative")
+		}
+	default:
+		return fmt.Errorf("invalid offset reset mode '%s' (valid: earliest, latest, offset, timestamp)", r.Mode)
+	}
+	for p, v := range r.Partitions {
+		if p < 0 {
+			return fmt.Errorf("invalid partition %d", p)
+		}
+		if v < 0 {
+			return fmt.Errorf("partition %d: value cannot be negative", p)
+		}
+	}
+	return nil
+}
+
+// resolve computes the offset to commit per partition from the start and end offsets of the topic.
+// afterMilli returns, per partition, the first offset at or after a timestamp (-1 when there is none).
+func (r *KafkaOffsetReset) resolve(start, end map[int32]int64, afterMilli func(int64) (map[int32]int64, error)) (map[int32]int64, error) {
+	partitions := make([]int32, 0, len(start))
+	for p := range start {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+	for p := range r.Partitions {
+		if _, ok := start[p]; !ok {
+			return nil, fmt.Errorf("partition %d does not exist", p)
+		}
+	}
+
+	res := make(map[int32]int64)
+	switch r.Mode {
+	case OffsetResetEarliest:
+		for _, p := range partitions {
+			res[p] = start[p]
+		}
+	case OffsetResetLatest:
+		for _, p := range partitions {
+			res[p] = end[p]
+		}
+	case OffsetResetOffset:
+		for p, off := range r.Partitions {
+			// Clamp so the consumer never starts out of range
+			res[p] = min(max(off, start[p]), end[p])
+		}
+	case OffsetResetTimestamp:
+		byMilli := make(map[int64]map[int32]int64)
+		for _, p := range partitions {
+			ms, ok := r.Partitions[p]
+			if !ok {
+				if r.Timestamp <= 0 {
+					continue
+				}
+				ms = r.Timestamp
+			}
+			offsets, ok := byMilli[ms]
+			if !ok {
+				var err error
+				if offsets, err = afterMilli(ms); err != nil {
+					return nil, err
+				}
+				byMilli[ms] = offsets
+			}
+			off, ok := offsets[p]
+			if !ok || off < 0 {
+				off = end[p] // No record at or after the timestamp
+			}
+			res[p] = off
+		}
+	}
+	return res, nil
+}
+
+// kafkaClientOpts returns the options of a short-lived client used for admin calls or one-off reads
+func kafkaClientOpts(brokers []string, saslCfg *KafkaSASLConfig, tlsCfg *KafkaTLSConfig) ([]kgo.Opt, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.RequestTimeoutOverhead(5 * time.Second),
+	}
+	if saslCfg != nil && saslCfg.Enable {
+		mechanism, err := getSASLMechanism(saslCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure SASL: %w", err)
+		}
+		if mechanism != nil {
+			opts = append(opts, kgo.SASL(mechanism))
+		}
+	}
+	if tlsCfg != nil {
+		tlsOpt, err := getTLSDialOpt(tlsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		opts = append(opts, tlsOpt)
+	}
+	return opts, nil
+}
+
+// listedPartitionOffsets flattens the offsets listed for the topic into a per-partition map
+func listedPartitionOffsets(topic string, l kadm.ListedOffsets, err error) (map[int32]int64, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := l.Error(); err != nil {
+		return nil, err
+	}
+	res := make(map[int32]int64)
+	for _, o := range l[topic] {
+		res[o.Partition] = o.Offset
+	}
+	return res, nil
+}
+
+// ResetKafkaGroupOffsets commits the offsets the reset resolves to for the group on the topic and
+// returns them. Kafka only accepts the commit while the group has no active member.
+func ResetKafkaGroupOffsets(brokers []string, group, topic string, saslCfg *KafkaSASLConfig, tlsCfg *KafkaTLSConfig, reset *KafkaOffsetReset) (map[int32]int64, error) {
+	if err := reset.Validate(); err != nil {
+		return nil, err
+	}
+	opts, err := kafkaClientOpts(brokers, saslCfg, tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	admin := kadm.NewClient(cl)
+
+	listed := func(l kadm.ListedOffsets, err error) (map[int32]int64, error) {
+		return listedPartitionOffsets(topic, l, err)
+	}
+	start, err := listed(admin.ListStartOffsets(ctx, topic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list start offsets of %s: %w", topic, err)
+	}
+	if len(start) == 0 {
+		return nil, fmt.Errorf("topic %s has no partitions", topic)
+	}
+	end, err := listed(admin.ListEndOffsets(ctx, topic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets of %s: %w", topic, err)
+	}
+	targets, err := reset.resolve(start, end, func(ms int64) (map[int32]int64, error) {
+		return listed(admin.ListOffsetsAfterMilli(ctx, ms, topic))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(kadm.Offsets)
+	for p, off := range targets {
+		offsets.Add(kadm.Offset{Topic: topic, Partition: p, At: off, LeaderEpoch: -1})
+	}
+	if err := admin.CommitAllOffsets(ctx, group, offsets); err != nil {
+		return nil, fmt.Errorf("failed to commit offsets for group %s (stop every consumer of the group first): %w", group, err)
+	}
+	return targets, nil
+}
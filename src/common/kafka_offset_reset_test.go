@@ -0,0 +1,54 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKafkaOffsetResetResolve(t *testing.T) {
+	start := map[int32]int64{0: 10, 1: 0}
+	end := map[int32]int64{0: 100, 1: 50}
+	afterMilli := func(ms int64) (map[int32]int64, error) {
+		if ms == 1000 {
+			return map[int32]int64{0: 20, 1: -1}, nil
+		}
+		return map[int32]int64{0: 30, 1: 5}, nil
+	}
+
+	tests := []struct {
+		name  string
+		reset KafkaOffsetReset
+		want  map[int32]int64
+	}{
+		{"earliest", KafkaOffsetReset{Mode: OffsetResetEarliest}, map[int32]int64{0: 10, 1: 0}},
+		{"latest", KafkaOffsetReset{Mode: OffsetResetLatest}, map[int32]int64{0: 100, 1: 50}},
+		{"offset clamped", KafkaOffsetReset{Mode: OffsetResetOffset, Partitions: map[int32]int64{0: 5, 1: 500}}, map[int32]int64{0: 10, 1: 50}},
+		{"offset partial", KafkaOffsetReset{Mode: OffsetResetOffset, Partitions: map[int32]int64{1: 7}}, map[int32]int64{1: 7}},
+		{"timestamp", KafkaOffsetReset{Mode: OffsetResetTimestamp, Timestamp: 1000}, map[int32]int64{0: 20, 1: 50}},
+		{"timestamp per partition", KafkaOffsetReset{Mode: OffsetResetTimestamp, Partitions: map[int32]int64{1: 2000}}, map[int32]int64{1: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.reset.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			got, err := tt.reset.resolve(start, end, afterMilli)
+			if err != nil {
+				t.Fatalf("resolve: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	unknown := KafkaOffsetReset{Mode: OffsetResetOffset, Partitions: map[int32]int64{7: 1}}
+	if _, err := unknown.resolve(start, end, afterMilli); err == nil {
+		t.Fatal("expected an error for an unknown partition")
+	}
+	for _, invalid := range []KafkaOffsetReset{{Mode: "middle"}, {Mode: OffsetResetOffset}, {Mode: OffsetResetTimestamp}} {
+		if err := invalid.Validate(); err == nil {
+			t.Fatalf("expected %+v to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Bounds of a Kafka window read
+const (
+	MaxKafkaReplayRecords = 10000
+	KafkaReplayTimeout    = 30 * time.Second
+)
+
+// KafkaRecord is a message read from a topic outside any consumer group
+type KafkaRecord struct {
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Value     []byte
+}
+
+// KafkaWindow is the result of a window read. Truncated is set when the limit or the timeout
+// stopped the read before every partition reached the end of the window.
+type KafkaWindow struct {
+	Records   []KafkaRecord
+	Truncated bool
+}
+
+// kafkaOffsetRange is the [From, To) offsets of a partition holding the records of a window
+type kafkaOffsetRange struct {
+	From, To int64
+}
+
+// windowRanges computes the offsets to read per partition from the first offsets at or after the
+// start and the end of the window. A partition without a record after the start is skipped, and one
+// without a record after the end is read up to its end offset.
+func windowRanges(afterStart, afterEnd, end map[int32]int64) map[int32]kafkaOffsetRange {
+	ranges := make(map[int32]kafkaOffsetRange)
+	for p, from := range afterStart {
+		if from < 0 || from >= end[p] {
+			continue
+		}
+		to, ok := afterEnd[p]
+		if !ok || to < 0 || to > end[p] {
+			to = end[p]
+		}
+		if from < to {
+			ranges[p] = kafkaOffsetRange{From: from, To: to}
+		}
+	}
+	return ranges
+}
+
+// ReadKafkaWindow reads the records of the topic produced in [start, end), at most limit of them.
+// Partitions are assigned directly instead of joining a group, so no offset is ever committed and
+// the consumer groups of the topic are untouched.
+func ReadKafkaWindow(brokers []string, topic string, saslCfg *KafkaSASLConfig, tlsCfg *KafkaTLSConfig, start, end time.Time, limit int) (*KafkaWindow, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("window start must be before its end")
+	}
+	if limit <= 0 || limit > MaxKafkaReplayRecords {
+		limit = MaxKafkaReplayRecords
+	}
+	opts, err := kafkaClientOpts(brokers, saslCfg, tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), KafkaReplayTimeout)
+	defer cancel()
+
+	ranges, err := listWindowRanges(ctx, opts, topic, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return readKafkaRanges(ctx, opts, topic, ranges, start, end, limit)
+}
+
+// listWindowRanges looks up the offsets bounding the window in each partition of the topic
+func listWindowRanges(ctx context.Context, opts []kgo.Opt, topic string, start, end time.Time) (map[int32]kafkaOffsetRange, error) {
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	defer cl.Close()
+	admin := kadm.NewClient(cl)
+	listed := func(l kadm.ListedOffsets, err error) (map[int32]int64, error) {
+		return listedPartitionOffsets(topic, l, err)
+	}
+
+	endOffsets, err := listed(admin.ListEndOffsets(ctx, topic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets of %s: %w", topic, err)
+	}
+	if len(endOffsets) == 0 {
+		return nil, fmt.Errorf("topic %s has no partitions", topic)
+	}
+	afterStart, err := listed(admin.ListOffsetsAfterMilli(ctx, start.UnixMilli(), topic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of %s at the window start: %w", topic, err)
+	}
+	afterEnd, err := listed(admin.ListOffsetsAfterMilli(ctx, end.UnixMilli(), topic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of %s at the window end: %w", topic, err)
+	}
+	return windowRanges(afterStart, afterEnd, endOffsets), nil
+}
+
+// readKafkaRanges consumes the partition ranges of a window until each is exhausted or limit is reached
+func readKafkaRanges(ctx context.Context, opts []kgo.Opt, topic string, ranges map[int32]kafkaOffsetRange, start, end time.Time, limit int) (*KafkaWindow, error) {
+	window := &KafkaWindow{Records: make([]KafkaRecord, 0)}
+	if len(ranges) == 0 {
+		return window, nil
+	}
+	assign := make(map[int32]kgo.Offset, len(ranges))
+	for p, r := range ranges {
+		assign[p] = kgo.NewOffset().At(r.From)
+	}
+	cl, err := kgo.NewClient(append(opts, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: assign}))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+	defer cl.Close()
+
+	pending := len(ranges)
+	done := make(map[int32]bool, len(ranges))
+	for pending > 0 {
+		fetches := cl.PollFetches(ctx)
+		if ctx.Err() != nil {
+			window.Truncated = true
+			return window, nil
+		}
+		for _, fe := range fetches.Errors() {
+			if !errors.Is(fe.Err, context.DeadlineExceeded) && !errors.Is(fe.Err, context.Canceled) {
+				return nil, fmt.Errorf("failed to fetch %s partition %d: %w", fe.Topic, fe.Partition, fe.Err)
+			}
+		}
+		stop := false
+		fetches.EachRecord(func(rec *kgo.Record) {
+			if stop || done[rec.Partition] {
+				return
+			}
+			if rec.Offset >= ranges[rec.Partition].To {
+				done[rec.Partition] = true
+				pending--
+				return
+			}
+			if !rec.Timestamp.Before(start) && rec.Timestamp.Before(end) {
+				window.Records = append(window.Records, KafkaRecord{
+					Partition: rec.Partition,
+					Offset:    rec.Offset,
+					Timestamp: rec.Timestamp,
+					Value:     rec.Value,
+				})
+				if len(window.Records) >= limit {
+					stop = true
+				}
+			}
+			if rec.Offset+1 >= ranges[rec.Partition].To {
+				done[rec.Partition] = true
+				pending--
+			}
+		})
+		if stop {
+			window.Truncated = pending > 0
+			return window, nil
+		}
+	}
+	return window, nil
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// multiTopicSession returns one poll holding records of several topics, then blocks until stopped
+type multiTopicSession struct {
+	fetches kgo.Fetches
+	polled  bool
+}
+
+func (s *multiTopicSession) PollFetches(ctx context.Context) kgo.Fetches {
+	if !s.polled {
+		s.polled = true
+		return s.fetches
+	}
+	<-ctx.Done()
+	return kgo.Fetches{}
+}
+
+func (s *multiTopicSession) Begin() error { return nil }
+
+func (s *multiTopicSession) Produce(_ context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	promise(r, nil)
+}
+
+func (s *multiTopicSession) End(context.Context, kgo.TransactionEndTry) (bool, error) {
+	return true, nil
+}
+
+func (s *multiTopicSession) Close() {}
+
+func TestKafkaConsume_TagsEventsWithTopic(t *testing.T) {
+	fetchTopic := func(topic string, values ...string) kgo.FetchTopic {
+		recs := make([]*kgo.Record, 0, len(values))
+		for _, v := range values {
+			recs = append(recs, &kgo.Record{Topic: topic, Value: []byte(v)})
+		}
+		return kgo.FetchTopic{Topic: topic, Partitions: []kgo.FetchPartition{{Records: recs}}}
+	}
+	session := &multiTopicSession{fetches: kgo.Fetches{{Topics: []kgo.FetchTopic{
+		fetchTopic("auth-logs", `{"id":"a"}`, `{"id":"b"}`),
+		fetchTopic("dns-logs", `{"id":"c"}`),
+	}}}}
+
+	txn := NewKafkaTxn("test")
+	msgChan := make(chan map[string]interface{}, 4)
+	stop := make(chan struct{})
+	consumed := make(chan struct{})
+	go func() {
+		txn.Consume(session, nil, true, msgChan, stop)
+		close(consumed)
+	}()
+
+	got := map[string]interface{}{}
+	for len(got) < 3 {
+		select {
+		case msg := <-msgChan:
+			got[msg["id"].(string)] = msg[KafkaTopicField]
+			txn.Done()
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for events, got %v", got)
+		}
+	}
+	close(stop)
+	<-consumed
+
+	want := map[string]string{"a": "auth-logs", "b": "auth-logs", "c": "dns-logs"}
+	for id, topic := range want {
+		if got[id] != topic {
+			t.Errorf("event %s tagged with topic %v, want %s", id, got[id], topic)
+		}
+	}
+}
+
+func TestDecodeRecord_TagsTopicOnlyWhenAsked(t *testing.T) {
+	rec := &kgo.Record{Topic: "events", Value: []byte(`{"id":"a"}`)}
+	m, err := decodeRecord(decodeJSONMessage, rec, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m[KafkaTopicField]; ok {
+		t.Fatalf("single topic inputs must not tag events, got %v", m)
+	}
+	if m, _ = decodeRecord(decodeJSONMessage, rec, true); m[KafkaTopicField] != "events" {
+		t.Fatalf("expected the event tagged with its topic, got %v", m)
+	}
+}
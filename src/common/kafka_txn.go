@@ -0,0 +1,338 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// KafkaTxnStopTimeout bounds how long a stopping input waits for in-flight events before the open
+// transaction is aborted; aborted batches are consumed again after restart
+const KafkaTxnStopTimeout = 10 * time.Second
+
+// kafkaTxnIdleTimeout bounds how long a running transaction waits for its in-flight events. It stays
+// below the producer transaction timeout, so a slow or lost event aborts the batch before the
+// broker does.
+var kafkaTxnIdleTimeout = 30 * time.Second
+
+// KafkaTxnSession is the part of kgo.GroupTransactSession used for exactly-once forwarding
+type KafkaTxnSession interface {
+	PollFetches(ctx context.Context) kgo.Fetches
+	Begin() error
+	Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error))
+	End(ctx context.Context, commit kgo.TransactionEndTry) (bool, error)
+	Close()
+}
+
+// KafkaTxn ties a project's Kafka input and its transactional Kafka outputs together. Every poll is
+// one transaction: each consumed event is tracked until all records derived from it have been
+// acknowledged by the producer, then the produced records and the consumer offsets are committed
+// together. A failed produce aborts the transaction and the batch is consumed again.
+type KafkaTxn struct {
+	TransactionalID string
+
+	mu      sync.Mutex
+	session KafkaTxnSession
+	failed  error // first produce error of the open transaction
+
+	pending   int64 // events and derived records still inside the project
+	committed uint64
+	aborted   uint64
+	leaked    uint64 // tracked events or records still in flight when their transaction timed out
+	draining  int32  // 1 while consumption waits for the in-flight events of an aborted transaction
+}
+
+// NewKafkaTxn creates an unbound transaction coordinator, the input binds its session on start
+func NewKafkaTxn(transactionalID string) *KafkaTxn {
+	return &KafkaTxn{TransactionalID: transactionalID}
+}
+
+// NewKafkaTxnSession creates a group consumer that can also produce inside consumer transactions.
+// It consumes topics, or the topics matching topicPattern when set.
+func NewKafkaTxnSession(brokers []string, group string, topics []string, topicPattern, transactionalID string, compression KafkaCompressionType, saslCfg *KafkaSASLConfig, tlsCfg *KafkaTLSConfig, offsetReset string) (*kgo.GroupTransactSession, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumerGroup(group),
+		kgo.TransactionalID(transactionalID),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.RequireStableFetchOffsets(),
+		kgo.RecordPartitioner(kgo.RoundRobinPartitioner()),
+	}
+	opts = append(opts, consumeTopicsOpt(topics, topicPattern)...)
+
+	switch offsetReset {
+	case "latest":
+		opts = append(opts, kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()))
+	case "none":
+	case "earliest", "":
+		opts = append(opts, kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	default:
+		return nil, fmt.Errorf("invalid offset_reset value: %s (valid values: earliest, latest, none)", offsetReset)
+	}
+
+	if compression != KafkaCompressionNone && compression != "" {
+		opts = append(opts, kgo.ProducerBatchCompression(getCompression(compression)))
+	}
+
+	if saslCfg != nil && saslCfg.Enable {
+		mechanism, err := getSASLMechanism(saslCfg)
+		if err != nil {
+			return nil, err
+		}
+		if mechanism != nil {
+			opts = append(opts, kgo.SASL(mechanism))
+		}
+	}
+
+	if tlsCfg != nil {
+		tlsOpt, err := getTLSDialOpt(tlsCfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, tlsOpt)
+	}
+
+	return kgo.NewGroupTransactSession(opts...)
+}
+
+// Track registers n events or records entering the project
+func (t *KafkaTxn) Track(n int) {
+	if n > 0 {
+		atomic.AddInt64(&t.pending, int64(n))
+	}
+}
+
+// Done marks one tracked event or record as fully handled (forwarded, dropped or produced)
+func (t *KafkaTxn) Done() {
+	atomic.AddInt64(&t.pending, -1)
+}
+
+// Pending returns how many tracked events or records are still in flight
+func (t *KafkaTxn) Pending() int64 {
+	return atomic.LoadInt64(&t.pending)
+}
+
+// Produce sends rec inside the open transaction. The tracked record is released once the broker
+// acknowledged it; an error marks the transaction for abort.
+func (t *KafkaTxn) Produce(rec *kgo.Record, msg map[string]interface{}) {
+	t.mu.Lock()
+	session := t.session
+	t.mu.Unlock()
+	if session == nil {
+		t.fail(fmt.Errorf("no open transaction"), rec.Topic, msg)
+		t.Done()
+		return
+	}
+
+	session.Produce(context.Background(), rec, func(r *kgo.Record, err error) {
+		if err != nil {
+			t.fail(err, r.Topic, msg)
+		}
+		t.Done()
+	})
+}
+
+func (t *KafkaTxn) fail(err error, topic string, msg map[string]interface{}) {
+	t.mu.Lock()
+	if t.failed == nil {
+		t.failed = err
+	}
+	t.mu.Unlock()
+	logger.Error("[KafkaTxn] failed to produce message, transaction will be aborted", "topic", topic, "error", err, ErrorLogContextKey, NewErrorLogContext(msg))
+}
+
+// Consume polls the session and feeds msgChan the records decoded by decode (nil decodes JSON),
+// tagged with their topic when tagTopic is set, committing one transaction per poll. It returns when
+// stopChan is closed or the session fails, and closes msgChan.
+func (t *KafkaTxn) Consume(session KafkaTxnSession, decode MessageDecoder, tagTopic bool, msgChan chan map[string]interface{}, stopChan <-chan struct{}) {
+	defer close(msgChan)
+	if decode == nil {
+		decode = decodeJSONMessage
+	}
+
+	t.mu.Lock()
+	t.session = session
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.session = nil
+		t.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		fetches := session.PollFetches(ctx)
+		if ctx.Err() != nil {
+			// Records fetched but not begun are not committed and will be consumed again
+			return
+		}
+		if errs := fetches.Errors(); len(errs) > 0 {
+			for _, err := range errs {
+				if err.Err.Error() == "client closed" {
+					return
+				}
+				logger.Warn("[KafkaTxn] fetch error", "error", err.Err)
+			}
+			continue
+		}
+		if fetches.Empty() {
+			continue
+		}
+
+		if err := session.Begin(); err != nil {
+			logger.Error("[KafkaTxn] failed to begin transaction", "transactional_id", t.TransactionalID, "error", err)
+			return
+		}
+
+		stopped := false
+		fetches.EachRecord(func(rec *kgo.Record) {
+			if stopped {
+				return
+			}
+			m, err := decodeRecord(decode, rec, tagTopic)
+			if err != nil {
+				// Undecodable records are skipped and committed with the batch, like the plain consumer
+				logger.Error("[KafkaTxn] failed to deserialize message", "error", err.Error())
+				return
+			}
+			t.Track(1)
+			select {
+			case msgChan <- m:
+			case <-stopChan:
+				t.Done()
+				stopped = true
+			}
+		})
+
+		idle := !stopped && t.waitIdle(stopChan)
+		if !t.end(session, idle) {
+			return
+		}
+		if stopped {
+			return
+		}
+		if !idle && !t.drain(stopChan) {
+			return
+		}
+	}
+}
+
+// waitIdle waits until every tracked event was handled, for up to kafkaTxnIdleTimeout, or
+// KafkaTxnStopTimeout once stopChan closes. It reports whether the project became idle in time;
+// otherwise the leak is reported and the transaction is aborted.
+func (t *KafkaTxn) waitIdle(stopChan <-chan struct{}) bool {
+	ticker := time.NewTicker(2 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(kafkaTxnIdleTimeout)
+	for t.Pending() > 0 {
+		select {
+		case <-ticker.C:
+		case <-stopChan:
+			deadline = time.After(KafkaTxnStopTimeout)
+			stopChan = nil
+		case <-deadline:
+			t.reportLeak()
+			return false
+		}
+	}
+	return true
+}
+
+// reportLeak logs the events or records still tracked after the wait. The pending count is kept:
+// the events are still in flight and their completions must not be counted against the next batch.
+func (t *KafkaTxn) reportLeak() {
+	leaked := t.Pending()
+	if leaked <= 0 {
+		return
+	}
+	atomic.AddUint64(&t.leaked, uint64(leaked))
+	logger.Error("[KafkaTxn] in-flight events were not handled in time, transaction will be aborted",
+		"transactional_id", t.TransactionalID, "pending", leaked)
+}
+
+// drain pauses consumption after a transaction was aborted with events in flight, until they are
+// all handled. Only then can the next transaction tell its own events apart, so it never commits
+// offsets while records of its batch are still on their way. It returns false when stopChan closes
+// first.
+func (t *KafkaTxn) drain(stopChan <-chan struct{}) bool {
+	atomic.StoreInt32(&t.draining, 1)
+	defer atomic.StoreInt32(&t.draining, 0)
+
+	ticker := time.NewTicker(2 * time.Millisecond)
+	defer ticker.Stop()
+	report := time.NewTicker(kafkaTxnIdleTimeout)
+	defer report.Stop()
+	for t.Pending() > 0 {
+		select {
+		case <-ticker.C:
+		case <-report.C:
+			logger.Warn("[KafkaTxn] consumption paused, in-flight events of an aborted transaction are not handled yet",
+				"transactional_id", t.TransactionalID, "pending", t.Pending())
+		case <-stopChan:
+			return false
+		}
+	}
+
+	// Produce errors of the late records belong to the aborted transaction
+	t.mu.Lock()
+	t.failed = nil
+	t.mu.Unlock()
+	return true
+}
+
+// end commits the open transaction, or aborts it when the project is not idle or a produce failed.
+// It returns false when the session can no longer be used.
+func (t *KafkaTxn) end(session KafkaTxnSession, idle bool) bool {
+	t.mu.Lock()
+	failed := t.failed
+	t.failed = nil
+	t.mu.Unlock()
+
+	try := kgo.TryCommit
+	if !idle || failed != nil {
+		try = kgo.TryAbort
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	committed, err := session.End(ctx, try)
+	if err != nil {
+		logger.Error("[KafkaTxn] failed to end transaction", "transactional_id", t.TransactionalID, "error", err)
+		atomic.AddUint64(&t.aborted, 1)
+		return false
+	}
+	if committed {
+		atomic.AddUint64(&t.committed, 1)
+	} else {
+		atomic.AddUint64(&t.aborted, 1)
+		logger.Warn("[KafkaTxn] transaction aborted, batch will be consumed again",
+			"transactional_id", t.TransactionalID, "idle", idle, "produce_error", failed)
+	}
+	return true
+}
+
+// Stats returns transaction counters for monitoring
+func (t *KafkaTxn) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"transactional_id": t.TransactionalID,
+		"committed":        atomic.LoadUint64(&t.committed),
+		"aborted":          atomic.LoadUint64(&t.aborted),
+		"leaked":           atomic.LoadUint64(&t.leaked),
+		"draining":         atomic.LoadInt32(&t.draining) == 1,
+		"pending":          t.Pending(),
+	}
+}
@@ -0,0 +1,227 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// mockTxnSession models a consumer group transaction: produced records and consumed offsets only
+// become visible together when End commits, and an abort discards both.
+type mockTxnSession struct {
+	t     *testing.T
+	mu    sync.Mutex
+	polls [][]string
+
+	inTxn     bool
+	unacked   int
+	nextPoll  int64 // offset after the last polled record
+	buffered  []string
+	failTopic string
+
+	committed       []string
+	committedOffset int64
+	ends            []kgo.TransactionEndTry
+	exhausted       chan struct{}
+}
+
+func newMockTxnSession(t *testing.T, polls ...[]string) *mockTxnSession {
+	return &mockTxnSession{t: t, polls: polls, exhausted: make(chan struct{})}
+}
+
+func (m *mockTxnSession) PollFetches(ctx context.Context) kgo.Fetches {
+	m.mu.Lock()
+	if len(m.polls) == 0 {
+		m.mu.Unlock()
+		select {
+		case <-m.exhausted:
+		default:
+			close(m.exhausted)
+		}
+		<-ctx.Done()
+		return kgo.Fetches{}
+	}
+	batch := m.polls[0]
+	m.polls = m.polls[1:]
+	// The consumer position continues after whatever was polled, committed or not
+	recs := make([]*kgo.Record, 0, len(batch))
+	for _, v := range batch {
+		recs = append(recs, &kgo.Record{Topic: "in", Offset: m.nextPoll, Value: []byte(v)})
+		m.nextPoll++
+	}
+	m.mu.Unlock()
+	return kgo.Fetches{{Topics: []kgo.FetchTopic{{Topic: "in", Partitions: []kgo.FetchPartition{{Records: recs}}}}}}
+}
+
+func (m *mockTxnSession) Begin() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inTxn = true
+	return nil
+}
+
+func (m *mockTxnSession) Produce(_ context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	m.mu.Lock()
+	if !m.inTxn {
+		m.mu.Unlock()
+		promise(r, errors.New("not in transaction"))
+		return
+	}
+	m.unacked++
+	var err error
+	if r.Topic == m.failTopic {
+		err = errors.New("produce failed")
+	} else {
+		m.buffered = append(m.buffered, string(r.Value))
+	}
+	m.mu.Unlock()
+
+	// Acknowledge asynchronously like the real client
+	go func() {
+		time.Sleep(time.Millisecond)
+		m.mu.Lock()
+		m.unacked--
+		m.mu.Unlock()
+		promise(r, err)
+	}()
+}
+
+func (m *mockTxnSession) End(_ context.Context, commit kgo.TransactionEndTry) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unacked > 0 {
+		m.t.Errorf("End called with %d unacknowledged records", m.unacked)
+	}
+	m.ends = append(m.ends, commit)
+	if commit == kgo.TryCommit {
+		m.committed = append(m.committed, m.buffered...)
+		m.committedOffset = m.nextPoll
+	} else {
+		// Abort rewinds to the committed offset so the batch is consumed again
+		m.nextPoll = m.committedOffset
+	}
+	m.buffered = nil
+	m.inTxn = false
+	return commit == kgo.TryCommit, nil
+}
+
+func (m *mockTxnSession) Close() {}
+
+// runTxnPipeline consumes through txn and fans every event out to two records, dropping events
+// that have "drop" set, the way rulesets and outputs use the tracker. Events with "slow" set are
+// released 150ms later without output.
+func runTxnPipeline(t *testing.T, session *mockTxnSession, topicFor func(map[string]interface{}) string) *KafkaTxn {
+	t.Helper()
+	txn := NewKafkaTxn("test")
+	msgChan := make(chan map[string]interface{}, 4)
+	stop := make(chan struct{})
+	consumed := make(chan struct{})
+	go func() {
+		txn.Consume(session, nil, false, msgChan, stop)
+		close(consumed)
+	}()
+
+	go func() {
+		for msg := range msgChan {
+			if msg["slow"] == true {
+				go func() {
+					time.Sleep(150 * time.Millisecond)
+					txn.Done()
+				}()
+				continue
+			}
+			if msg["drop"] == true {
+				txn.Done()
+				continue
+			}
+			txn.Track(2)
+			for i := 0; i < 2; i++ {
+				rec, err := NewKafkaRecord(topicFor(msg), nil, map[string]interface{}{"id": msg["id"], "copy": i})
+				if err != nil {
+					t.Error(err)
+					txn.Done()
+					continue
+				}
+				txn.Produce(rec, msg)
+			}
+			txn.Done()
+		}
+	}()
+
+	select {
+	case <-session.exhausted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for batches to be consumed")
+	}
+	close(stop)
+	<-consumed
+	return txn
+}
+
+func TestKafkaTxn_CommitsRecordsWithOffsets(t *testing.T) {
+	session := newMockTxnSession(t,
+		[]string{`{"id":"a"}`, `{"id":"b"}`, `{"id":"c"}`},
+		[]string{`{"id":"d","drop":true}`, `{"id":"e"}`},
+	)
+	txn := runTxnPipeline(t, session, func(map[string]interface{}) string { return "out" })
+
+	if want := []kgo.TransactionEndTry{kgo.TryCommit, kgo.TryCommit}; fmt.Sprint(session.ends) != fmt.Sprint(want) {
+		t.Fatalf("expected one commit per poll %v, got %v", want, session.ends)
+	}
+	if len(session.committed) != 8 {
+		t.Fatalf("expected 8 committed records, got %d: %v", len(session.committed), session.committed)
+	}
+	if session.committedOffset != 5 {
+		t.Fatalf("expected offsets committed up to 5, got %d", session.committedOffset)
+	}
+	if txn.Pending() != 0 {
+		t.Fatalf("expected nothing pending, got %d", txn.Pending())
+	}
+}
+
+func TestKafkaTxn_ProduceFailureAbortsBatch(t *testing.T) {
+	session := newMockTxnSession(t, []string{`{"id":"a"}`, `{"id":"b"}`})
+	session.failTopic = "bad"
+	txn := runTxnPipeline(t, session, func(msg map[string]interface{}) string {
+		if msg["id"] == "b" {
+			return "bad"
+		}
+		return "out"
+	})
+
+	if len(session.ends) != 1 || session.ends[0] != kgo.TryAbort {
+		t.Fatalf("expected the transaction to be aborted, got %v", session.ends)
+	}
+	// Neither the records of "a" nor the offsets may be committed on their own
+	if len(session.committed) != 0 || session.committedOffset != 0 {
+		t.Fatalf("aborted batch leaked: records=%v offset=%d", session.committed, session.committedOffset)
+	}
+	if stats := txn.Stats(); stats["aborted"].(uint64) != 1 || stats["committed"].(uint64) != 0 {
+		t.Fatalf("unexpected stats: %v", stats)
+	}
+}
+
+func TestKafkaTxn_SlowEventAbortsBatchAndDrains(t *testing.T) {
+	kafkaTxnIdleTimeout = 50 * time.Millisecond
+	defer func() { kafkaTxnIdleTimeout = 30 * time.Second }()
+
+	session := newMockTxnSession(t, []string{`{"id":"a"}`, `{"id":"b","slow":true}`}, []string{`{"id":"c"}`})
+	txn := runTxnPipeline(t, session, func(map[string]interface{}) string { return "out" })
+
+	// The slow event aborts its batch; the next batch only begins once it was released, so its late
+	// completion is not counted against the records of c
+	if want := []kgo.TransactionEndTry{kgo.TryAbort, kgo.TryCommit}; fmt.Sprint(session.ends) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, session.ends)
+	}
+	if len(session.committed) != 2 {
+		t.Fatalf("expected only the records of c committed, got %v", session.committed)
+	}
+	if stats := txn.Stats(); stats["leaked"].(uint64) != 1 || stats["pending"].(int64) != 0 || stats["draining"] != false {
+		t.Fatalf("unexpected stats: %v", stats)
+	}
+}
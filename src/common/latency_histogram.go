@@ -0,0 +1,109 @@
+package common
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the write latency histogram buckets
+var LatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// LatencyHistogram counts durations into LatencyBuckets. It is safe for concurrent use and a nil
+// histogram ignores observations, so producers can record unconditionally.
+type LatencyHistogram struct {
+	buckets  []uint64 // per bucket, the last one counts durations above every bound
+	count    uint64
+	sumNanos uint64
+}
+
+// BucketCount is a cumulative bucket of a histogram snapshot
+type BucketCount struct {
+	Le    float64 `json:"le"` // Upper bound in seconds
+	Count uint64  `json:"count"`
+}
+
+// HistogramSnapshot is a point-in-time copy of a LatencyHistogram
+type HistogramSnapshot struct {
+	Count      uint64        `json:"count"`
+	SumSeconds float64       `json:"sum_seconds"`
+	AvgMs      float64       `json:"avg_ms"`
+	P50Ms      float64       `json:"p50_ms"` // Quantiles are upper bounds of the bucket they fall in
+	P95Ms      float64       `json:"p95_ms"`
+	P99Ms      float64       `json:"p99_ms"`
+	Buckets    []BucketCount `json:"buckets"` // Cumulative, without the +Inf bucket (that is Count)
+}
+
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{buckets: make([]uint64, len(LatencyBuckets)+1)}
+}
+
+// Observe records one write duration
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	if h == nil {
+		return
+	}
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(LatencyBuckets, seconds)
+	atomic.AddUint64(&h.buckets[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sumNanos, uint64(d.Nanoseconds()))
+}
+
+// Snapshot returns the cumulative bucket counts and summary of the histogram
+func (h *LatencyHistogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{Buckets: make([]BucketCount, len(LatencyBuckets))}
+	var cumulative uint64
+	for i, le := range LatencyBuckets {
+		cumulative += atomic.LoadUint64(&h.buckets[i])
+		s.Buckets[i] = BucketCount{Le: le, Count: cumulative}
+	}
+	s.Count = cumulative + atomic.LoadUint64(&h.buckets[len(LatencyBuckets)])
+	s.SumSeconds = time.Duration(atomic.LoadUint64(&h.sumNanos)).Seconds()
+	if s.Count > 0 {
+		s.AvgMs = s.SumSeconds * 1000 / float64(s.Count)
+		s.P50Ms = s.quantileMs(0.50)
+		s.P95Ms = s.quantileMs(0.95)
+		s.P99Ms = s.quantileMs(0.99)
+	}
+	return s
+}
+
+// quantileMs returns the upper bound of the bucket holding quantile q, the largest bound when it
+// falls above every bucket
+func (s HistogramSnapshot) quantileMs(q float64) float64 {
+	rank := uint64(q*float64(s.Count) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	for _, b := range s.Buckets {
+		if b.Count >= rank {
+			return b.Le * 1000
+		}
+	}
+	return LatencyBuckets[len(LatencyBuckets)-1] * 1000
+}
+
+// outputLatency holds the write latency histogram of each output ID
+var outputLatency sync.Map
+
+// OutputLatency returns the write latency histogram of an output, creating it on first use.
+// Instances of the same output in different projects share it.
+func OutputLatency(outputID string) *LatencyHistogram {
+	if h, ok := outputLatency.Load(outputID); ok {
+		return h.(*LatencyHistogram)
+	}
+	h, _ := outputLatency.LoadOrStore(outputID, NewLatencyHistogram())
+	return h.(*LatencyHistogram)
+}
+
+// OutputLatencySnapshots returns the write latency of every output that has a histogram
+func OutputLatencySnapshots() map[string]HistogramSnapshot {
+	res := make(map[string]HistogramSnapshot)
+	outputLatency.Range(func(key, value interface{}) bool {
+		res[key.(string)] = value.(*LatencyHistogram).Snapshot()
+		return true
+	})
+	return res
+}
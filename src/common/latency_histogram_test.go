@@ -0,0 +1,31 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram_BucketsPopulated(t *testing.T) {
+	t.Cleanup(func() { outputLatency.Delete("test-latency-output") })
+	h := OutputLatency("test-latency-output")
+	for _, d := range []time.Duration{500 * time.Microsecond, 3 * time.Millisecond, 3 * time.Millisecond, 200 * time.Millisecond, 20 * time.Second} {
+		h.Observe(d)
+	}
+
+	s := OutputLatencySnapshots()["test-latency-output"]
+	if s.Count != 5 {
+		t.Fatalf("expected 5 observations, got %d", s.Count)
+	}
+	want := map[float64]uint64{0.001: 1, 0.005: 3, 0.1: 3, 0.25: 4, 10: 4}
+	for _, b := range s.Buckets {
+		if n, ok := want[b.Le]; ok && b.Count != n {
+			t.Fatalf("bucket le=%v: expected cumulative count %d, got %d", b.Le, n, b.Count)
+		}
+	}
+	if s.P50Ms != 5 || s.P99Ms != 10000 {
+		t.Fatalf("unexpected quantiles p50=%v p99=%v", s.P50Ms, s.P99Ms)
+	}
+
+	var nilHistogram *LatencyHistogram
+	nilHistogram.Observe(time.Second) // producers without a histogram must not panic
+}
@@ -0,0 +1,363 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// LokiPushPath is the path of the Loki push API, appended to the configured URL
+	LokiPushPath = "/loki/api/v1/push"
+	// LokiLabelOverflow replaces the new values of a label that reached its cardinality limit
+	LokiLabelOverflow = "__overflow__"
+	// DefaultLokiMaxLabelValues is the number of distinct values a label keeps by default
+	DefaultLokiMaxLabelValues = 100
+)
+
+// LokiAuthConfig represents authentication configuration for Loki
+type LokiAuthConfig struct {
+	Type     string `yaml:"type"`               // auth type: basic, bearer
+	Username string `yaml:"username,omitempty"` // for basic auth
+	Password string `yaml:"password,omitempty"` // for basic auth
+	Token    string `yaml:"token,omitempty"`    // for bearer token auth
+}
+
+// LokiEntry is one log line of a Loki stream
+type LokiEntry struct {
+	Labels    map[string]string
+	Timestamp time.Time
+	Line      string
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// BuildLokiPushPayload groups entries by label set into the streams of a Loki push request. Streams
+// are ordered by their first entry and keep the order of their entries.
+func BuildLokiPushPayload(entries []LokiEntry) ([]byte, error) {
+	streams := make([]*lokiStream, 0)
+	byLabels := make(map[string]*lokiStream)
+	for _, entry := range entries {
+		key := lokiLabelsKey(entry.Labels)
+		stream, ok := byLabels[key]
+		if !ok {
+			stream = &lokiStream{Stream: entry.Labels, Values: make([][2]string, 0, 1)}
+			byLabels[key] = stream
+			streams = append(streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.Timestamp.UnixNano(), 10), entry.Line})
+	}
+	return json.Marshal(map[string]interface{}{"streams": streams})
+}
+
+// lokiLabelsKey identifies a label set regardless of the map order
+func lokiLabelsKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[name]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// LokiLabeler derives the labels of events. Every label taken from an event field keeps at most
+// maxValues distinct values, later values are replaced with LokiLabelOverflow so a high cardinality
+// field cannot create unbounded streams.
+type LokiLabeler struct {
+	static    map[string]string
+	fields    map[string][]string // label name -> parsed field path
+	maxValues int
+
+	mu     sync.Mutex
+	seen   map[string]map[string]struct{}
+	warned map[string]bool
+	source string // Logged with the cardinality warning
+}
+
+// NewLokiLabeler creates a labeler from static labels and labels keyed by event field
+func NewLokiLabeler(static map[string]string, fields map[string]string, maxValues int, source string) *LokiLabeler {
+	if maxValues <= 0 {
+		maxValues = DefaultLokiMaxLabelValues
+	}
+	l := &LokiLabeler{
+		static:    static,
+		fields:    make(map[string][]string, len(fields)),
+		maxValues: maxValues,
+		seen:      make(map[string]map[string]struct{}, len(fields)),
+		warned:    make(map[string]bool),
+		source:    source,
+	}
+	for name, field := range fields {
+		l.fields[name] = StringToList(strings.TrimSpace(field))
+		l.seen[name] = make(map[string]struct{})
+	}
+	return l
+}
+
+// Labels returns the labels of an event. Fields missing from the event leave their label out.
+func (l *LokiLabeler) Labels(event map[string]interface{}) map[string]string {
+	labels := make(map[string]string, len(l.static)+len(l.fields))
+	for name, value := range l.static {
+		labels[name] = value
+	}
+	if len(l.fields) == 0 {
+		return labels
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for name, path := range l.fields {
+		value, ok := GetCheckData(event, path)
+		if !ok || value == "" {
+			continue
+		}
+		seen := l.seen[name]
+		if _, known := seen[value]; !known {
+			if len(seen) >= l.maxValues {
+				if !l.warned[name] {
+					l.warned[name] = true
+					logger.Warn("Loki label cardinality too high, new values are replaced", "output", l.source, "label", name, "max_label_values", l.maxValues, "replacement", LokiLabelOverflow)
+				}
+				value = LokiLabelOverflow
+			} else {
+				seen[value] = struct{}{}
+			}
+		}
+		labels[name] = value
+	}
+	return labels
+}
+
+// lokiTimestamp reads the timestamp of an event from an RFC 3339 string or a unix time in seconds
+// or milliseconds, falling back to now
+func lokiTimestamp(event map[string]interface{}, path []string, now time.Time) time.Time {
+	if len(path) == 0 {
+		return now
+	}
+	value, ok := GetCheckDataWithType(event, path)
+	if !ok {
+		return now
+	}
+
+	t, err := ParseEventTime(value)
+	if err != nil || t.UnixNano() <= 0 {
+		return now
+	}
+	return t
+}
+
+// LokiProducer batches events into Loki push requests with a channel-based interface
+type LokiProducer struct {
+	MsgChan        chan map[string]interface{}
+	pushURL        string
+	tenantID       string
+	auth           *LokiAuthConfig
+	labeler        *LokiLabeler
+	timestampField []string
+	client         *http.Client
+	batchSize      int
+	flushDur       time.Duration
+	maxRetries     int
+	retryDelay     time.Duration
+	stopChan       chan struct{}
+	latency        *LatencyHistogram // Push duration per batch, including retries
+}
+
+// LokiPushURL returns the push API URL of a Loki base URL, which may already be the push URL
+func LokiPushURL(url string) string {
+	url = strings.TrimRight(url, "/")
+	if strings.HasSuffix(url, LokiPushPath) {
+		return url
+	}
+	return url + LokiPushPath
+}
+
+// NewLokiProducer creates a new Loki producer pushing what it receives on msgChan
+func NewLokiProducer(url, tenantID string, auth *LokiAuthConfig, labeler *LokiLabeler, timestampField string, msgChan chan map[string]interface{}, batchSize int, flushDur time.Duration, pool *ConnPool, latency *LatencyHistogram) *LokiProducer {
+	prod := &LokiProducer{
+		MsgChan:        msgChan,
+		pushURL:        LokiPushURL(url),
+		tenantID:       tenantID,
+		auth:           auth,
+		labeler:        labeler,
+		timestampField: StringToList(strings.TrimSpace(timestampField)),
+		client:         &http.Client{Timeout: 10 * time.Second, Transport: pool.Transport(nil)},
+		batchSize:      batchSize,
+		flushDur:       flushDur,
+		maxRetries:     3,
+		retryDelay:     1 * time.Second,
+		stopChan:       make(chan struct{}),
+		latency:        latency,
+	}
+	go prod.run()
+	return prod
+}
+
+func (p *LokiProducer) run() {
+	batch := make([]map[string]interface{}, 0, p.batchSize)
+	timer := time.NewTimer(p.flushDur)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.stopChan:
+			// Don't flush the remaining batch during shutdown, like the Elasticsearch producer
+			return
+		case msg, ok := <-p.MsgChan:
+			if !ok {
+				if len(batch) > 0 {
+					p.sendBatch(batch)
+				}
+				return
+			}
+			batch = append(batch, msg)
+			if len(batch) >= p.batchSize {
+				p.sendBatch(batch)
+				batch = batch[:0]
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(p.flushDur)
+			}
+		case <-timer.C:
+			if len(batch) > 0 {
+				p.sendBatch(batch)
+				batch = batch[:0]
+			}
+			timer.Reset(p.flushDur)
+		}
+	}
+}
+
+// Entries turns events into log lines: the JSON of the event, labeled by the labeler
+func (p *LokiProducer) Entries(batch []map[string]interface{}) []LokiEntry {
+	now := time.Now()
+	entries := make([]LokiEntry, 0, len(batch))
+	for _, event := range batch {
+		line, err := SafeMarshal(event)
+		if err != nil {
+			logger.Error("[LokiProducer] failed to encode event", "url", p.pushURL, "error", err, ErrorLogContextKey, NewErrorLogContext(event))
+			continue
+		}
+		entries = append(entries, LokiEntry{
+			Labels:    p.labeler.Labels(event),
+			Timestamp: lokiTimestamp(event, p.timestampField, now),
+			Line:      string(line),
+		})
+	}
+	return entries
+}
+
+// sendBatch pushes a batch of events to Loki with retry logic
+func (p *LokiProducer) sendBatch(batch []map[string]interface{}) {
+	payload, err := BuildLokiPushPayload(p.Entries(batch))
+	if err != nil {
+		logger.Error("[LokiProducer] failed to build push payload", "url", p.pushURL, "batch_size", len(batch), "error", err)
+		return
+	}
+
+	start := time.Now()
+	defer func() { p.latency.Observe(time.Since(start)) }()
+
+	for i := 0; i <= p.maxRetries; i++ {
+		err = p.push(payload)
+		if err == nil {
+			return
+		}
+		if i == p.maxRetries {
+			logger.Error("[LokiProducer] failed to push batch", "url", p.pushURL, "retries", p.maxRetries, "batch_size", len(batch), "error", err, ErrorLogContextKey, NewErrorLogContext(batch[0]))
+			return
+		}
+		time.Sleep(p.retryDelay)
+	}
+}
+
+func (p *LokiProducer) push(payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.pushURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setLokiHeaders(req, p.tenantID, p.auth)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("loki returned %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Close closes the producer
+// Note: We don't close MsgChan here because it's owned by the caller
+func (p *LokiProducer) Close() {
+	if p.stopChan != nil {
+		close(p.stopChan)
+	}
+}
+
+func setLokiHeaders(req *http.Request, tenantID string, auth *LokiAuthConfig) {
+	if tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", tenantID)
+	}
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case "basic":
+		if auth.Username != "" && auth.Password != "" {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+	case "bearer":
+		if auth.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+auth.Token)
+		}
+	}
+}
+
+// TestLokiConnection checks that Loki is reachable and ready through its /ready endpoint
+func TestLokiConnection(url, tenantID string, auth *LokiAuthConfig) error {
+	base := strings.TrimSuffix(strings.TrimRight(url, "/"), LokiPushPath)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/ready", nil)
+	if err != nil {
+		return fmt.Errorf("invalid Loki URL: %w", err)
+	}
+	setLokiHeaders(req, tenantID, auth)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Loki: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Loki is not ready: %s", res.Status)
+	}
+	return nil
+}
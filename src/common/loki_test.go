@@ -0,0 +1,68 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLokiProducer_PushesBatchedStreams(t *testing.T) {
+	pushed := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != LokiPushPath || r.Header.Get("X-Scope-OrgID") != "tenant1" {
+			t.Errorf("unexpected request %s with tenant %q", r.URL.Path, r.Header.Get("X-Scope-OrgID"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		pushed <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	labeler := NewLokiLabeler(map[string]string{"job": "hub"}, map[string]string{"host": "host.name"}, 1, "loki_test")
+	msgChan := make(chan map[string]interface{}, 3)
+	producer := NewLokiProducer(server.URL, "tenant1", nil, labeler, "ts", msgChan, 3, time.Minute, nil, nil)
+	defer producer.Close()
+
+	msgChan <- map[string]interface{}{"host": map[string]interface{}{"name": "a"}, "ts": "2024-05-01T10:00:00Z"}
+	msgChan <- map[string]interface{}{"host": map[string]interface{}{"name": "a"}, "ts": float64(1714557601)}
+	// A second host is over max_label_values
+	msgChan <- map[string]interface{}{"host": map[string]interface{}{"name": "b"}, "ts": float64(1714557602000)}
+
+	var body []byte
+	select {
+	case body = <-pushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the batch was not pushed")
+	}
+
+	var payload struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", body, err)
+	}
+	if len(payload.Streams) != 2 {
+		t.Fatalf("expected 2 streams, got %s", body)
+	}
+
+	first, second := payload.Streams[0], payload.Streams[1]
+	if first.Stream["job"] != "hub" || first.Stream["host"] != "a" || len(first.Values) != 2 {
+		t.Fatalf("unexpected first stream %+v", first)
+	}
+	if first.Values[0][0] != "1714557600000000000" || first.Values[1][0] != "1714557601000000000" {
+		t.Fatalf("unexpected timestamps %v", first.Values)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(first.Values[0][1]), &line); err != nil || line["ts"] != "2024-05-01T10:00:00Z" {
+		t.Fatalf("the line must be the JSON event, got %q", first.Values[0][1])
+	}
+	if second.Stream["host"] != LokiLabelOverflow || len(second.Values) != 1 || second.Values[0][0] != "1714557602000000000" {
+		t.Fatalf("unexpected overflow stream %+v", second)
+	}
+}
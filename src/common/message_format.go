@@ -0,0 +1,165 @@
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// Source message formats of an input
+const (
+	MessageFormatJSON = "json"
+	MessageFormatXML  = "xml"
+)
+
+const (
+	// RawMessageField holds the original payload of a message that failed to parse
+	RawMessageField = "_hub_raw"
+	// ParseErrorField holds why the message in RawMessageField failed to parse
+	ParseErrorField = "_hub_parse_error"
+
+	// XMLAttrPrefix prefixes the keys of XML attributes, XMLTextKey holds the text of an element that
+	// also has attributes or child elements
+	XMLAttrPrefix = "@"
+	XMLTextKey    = "#text"
+)
+
+// MessageDecoder turns the payload of a source message into an event. An error drops the message.
+type MessageDecoder func(raw []byte) (map[string]interface{}, error)
+
+// NewMessageDecoder returns the decoder of a message format, JSON when format is empty
+func NewMessageDecoder(format string) (MessageDecoder, error) {
+	switch format {
+	case "", MessageFormatJSON:
+		return decodeJSONMessage, nil
+	case MessageFormatXML:
+		return decodeXMLMessage, nil
+	default:
+		return nil, fmt.Errorf("unsupported message format '%s' (valid: json, xml)", format)
+	}
+}
+
+func decodeJSONMessage(raw []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := sonic.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("message must be a JSON object")
+	}
+	return m, nil
+}
+
+// decodeXMLMessage parses an XML document. A malformed document is kept as an event holding the
+// payload and the error, so it can still be routed and inspected instead of silently dropped.
+func decodeXMLMessage(raw []byte) (map[string]interface{}, error) {
+	event, err := ParseXMLEvent(raw)
+	if err != nil {
+		return map[string]interface{}{
+			RawMessageField: string(raw),
+			ParseErrorField: err.Error(),
+		}, nil
+	}
+	return event, nil
+}
+
+// ParseXMLEvent converts an XML document to a nested map keyed by the root element. Attributes are
+// stored under "@name", repeated child elements become arrays and the text of an element is its
+// value, or "#text" when the element also has attributes or children. Namespaces are dropped.
+//
+//	<Event><Data Name="User">alice</Data><Data Name="Ip">10.0.0.1</Data></Event>
+//	=> {"Event": {"Data": [{"@Name": "User", "#text": "alice"}, {"@Name": "Ip", "#text": "10.0.0.1"}]}}
+func ParseXMLEvent(raw []byte) (map[string]interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no root element found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		value, err := parseXMLElement(dec, start)
+		if err != nil {
+			return nil, err
+		}
+		// Only whitespace, comments and processing instructions may follow the root element
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				return nil, fmt.Errorf("unexpected element <%s> after the root element", t.Name.Local)
+			case xml.CharData:
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, fmt.Errorf("unexpected text after the root element")
+				}
+			}
+		}
+		return map[string]interface{}{start.Name.Local: value}, nil
+	}
+}
+
+// parseXMLElement reads the content of start up to its end element
+func parseXMLElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	node := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		node[XMLAttrPrefix+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	hasChildren := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("element <%s> is not closed", start.Name.Local)
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := parseXMLElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			hasChildren = true
+			name := t.Name.Local
+			switch existing := node[name].(type) {
+			case nil:
+				node[name] = child
+			case []interface{}:
+				node[name] = append(existing, child)
+			default:
+				node[name] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if !hasChildren && len(node) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				node[XMLTextKey] = content
+			}
+			return node, nil
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"AgentSmith-HUB/logger"
+)
+
+// Defaults and bounds of operation_retention
+const (
+	DefaultOperationMaxEntries      = 1000
+	DefaultOperationMaxAge          = 31 * 24 * time.Hour
+	DefaultOperationCompactInterval = 10 * time.Minute
+	MaxOperationMaxEntries          = 10000 // the whole history list holds at most 10000 operations
+	minOperationCompactInterval     = time.Minute
+)
+
+// operationHistoryKey is the Redis list of the cluster operation history, newest first
+const operationHistoryKey = "cluster:ops_history"
+
+// Redis list access of the operation history, replaced in tests
+var (
+	opsLRange = RedisLRange
+	opsLRem   = RedisLRem
+)
+
+var startCompactionOnce sync.Once
+
+// VerifyOperationRetention validates an operation_retention config
+func VerifyOperationRetention(cfg OperationRetentionConfig) error {
+	if cfg.MaxEntries < 0 || cfg.MaxEntries > MaxOperationMaxEntries {
+		return fmt.Errorf("max_entries must be between 0 and %d, got %d", MaxOperationMaxEntries, cfg.MaxEntries)
+	}
+	if cfg.MaxAge < 0 {
+		return fmt.Errorf("max_age cannot be negative, got %s", cfg.MaxAge)
+	}
+	if cfg.CompactInterval != 0 && cfg.CompactInterval < minOperationCompactInterval {
+		return fmt.Errorf("compact_interval must be at least %s, got %s", minOperationCompactInterval, cfg.CompactInterval)
+	}
+	return nil
+}
+
+// OperationRetention returns the effective retention, with defaults for unset or invalid values
+func OperationRetention() OperationRetentionConfig {
+	cfg := ReadConfig(func(c *HubConfig) OperationRetentionConfig { return c.OperationRetention })
+	if cfg.MaxEntries <= 0 || cfg.MaxEntries > MaxOperationMaxEntries {
+		cfg.MaxEntries = DefaultOperationMaxEntries
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultOperationMaxAge
+	}
+	if cfg.CompactInterval < minOperationCompactInterval {
+		cfg.CompactInterval = DefaultOperationCompactInterval
+	}
+	return cfg
+}
+
+// retainedOperations returns which of the records, newest first, the retention keeps: at most
+// maxEntries per project, and none older than maxAge. Unparseable records are kept.
+func retainedOperations(records []string, retention OperationRetentionConfig, now time.Time) []bool {
+	keep := make([]bool, len(records))
+	perProject := make(map[string]int)
+	cutoff := now.Add(-retention.MaxAge)
+	for i, record := range records {
+		var op OperationRecord
+		if err := json.Unmarshal([]byte(record), &op); err != nil {
+			keep[i] = true
+			continue
+		}
+		if op.Timestamp.Before(cutoff) {
+			continue
+		}
+		if op.ProjectID != "" {
+			perProject[op.ProjectID]++
+			if perProject[op.ProjectID] > retention.MaxEntries {
+				continue
+			}
+		}
+		keep[i] = true
+	}
+	return keep
+}
+
+// CompactOperationHistory removes the operations the retention no longer keeps from the history and
+// returns how many it removed. Records are removed one by one from the tail, so operations recorded
+// meanwhile are kept.
+func CompactOperationHistory(now time.Time) (int, error) {
+	records, err := opsLRange(operationHistoryKey, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+	keep := retainedOperations(records, OperationRetention(), now)
+	removed := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		if keep[i] {
+			continue
+		}
+		n, err := opsLRem(operationHistoryKey, -1, records[i])
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+	return removed, nil
+}
+
+// StartOperationCompaction runs the compaction every compact_interval, read again after each run so
+// reloads apply. Only the leader calls it.
+func StartOperationCompaction() {
+	startCompactionOnce.Do(func() {
+		go func() {
+			for {
+				time.Sleep(OperationRetention().CompactInterval)
+				removed, err := CompactOperationHistory(time.Now())
+				if err != nil {
+					logger.Warn("Failed to compact operation history", "error", err)
+				}
+				if removed > 0 {
+					logger.Info("Compacted operation history", "removed", removed)
+				}
+			}
+		}()
+	})
+}
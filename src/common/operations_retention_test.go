@@ -0,0 +1,101 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// memoryHistory stands in for the Redis list of the operation history
+type memoryHistory []string
+
+func (h *memoryHistory) install(t *testing.T) {
+	origRange, origRem := opsLRange, opsLRem
+	t.Cleanup(func() { opsLRange, opsLRem = origRange, origRem })
+	opsLRange = func(string, int64, int64) ([]string, error) {
+		return append([]string{}, *h...), nil
+	}
+	opsLRem = func(_ string, count int64, value interface{}) (int64, error) {
+		// Only the tail-first removal of one element is used
+		for i := len(*h) - 1; i >= 0; i-- {
+			if (*h)[i] == value.(string) {
+				*h = append((*h)[:i], (*h)[i+1:]...)
+				return 1, nil
+			}
+		}
+		return 0, nil
+	}
+}
+
+func operationJSON(t *testing.T, projectID, status string, ts time.Time) string {
+	t.Helper()
+	data, err := json.Marshal(OperationRecord{Type: OpTypeProjectStart, ProjectID: projectID, Status: status, Timestamp: ts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestOperationRetention_TrimsOldestOperations(t *testing.T) {
+	orig := Config
+	Config = &HubConfig{OperationRetention: OperationRetentionConfig{MaxEntries: 3, MaxAge: time.Hour}}
+	t.Cleanup(func() { Config = orig })
+
+	now := time.Now()
+	history := memoryHistory{}
+	// Newest first, as LPUSH leaves them
+	for i, status := range []string{"op5", "op4", "op3", "op2", "op1"} {
+		history = append(history, operationJSON(t, "p1", status, now.Add(-time.Duration(i+1)*time.Minute)))
+	}
+	history = append(history,
+		operationJSON(t, "p2", "new", now.Add(-time.Minute)),
+		operationJSON(t, "", "component", now.Add(-30*time.Minute)),
+		operationJSON(t, "p2", "old", now.Add(-2*time.Hour)),
+		operationJSON(t, "", "expired", now.Add(-3*time.Hour)),
+	)
+	history.install(t)
+
+	removed, err := CompactOperationHistory(now)
+	if err != nil || removed != 4 {
+		t.Fatalf("expected 4 operations removed, got %d (%v)", removed, err)
+	}
+	var statuses []string
+	for _, record := range history {
+		var op OperationRecord
+		if err := json.Unmarshal([]byte(record), &op); err != nil {
+			t.Fatal(err)
+		}
+		statuses = append(statuses, op.Status)
+	}
+	want := []string{"op5", "op4", "op3", "new", "component"}
+	if len(statuses) != len(want) {
+		t.Fatalf("expected %v kept, got %v", want, statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("expected %v kept, got %v", want, statuses)
+		}
+	}
+
+	// Lowering max_entries applies to the stored history
+	Config.OperationRetention.MaxEntries = 1
+	if removed, _ := CompactOperationHistory(now); removed != 2 || len(history) != 3 {
+		t.Fatalf("expected p1 compacted to the new max_entries, removed %d, left %d", removed, len(history))
+	}
+}
+
+func TestVerifyOperationRetention(t *testing.T) {
+	for _, cfg := range []OperationRetentionConfig{
+		{MaxEntries: -1},
+		{MaxEntries: MaxOperationMaxEntries + 1},
+		{MaxAge: -time.Hour},
+		{CompactInterval: time.Second},
+	} {
+		if err := VerifyOperationRetention(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	if err := VerifyOperationRetention(OperationRetentionConfig{MaxEntries: 500, MaxAge: 7 * 24 * time.Hour, CompactInterval: time.Hour}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -305,6 +306,69 @@ func RedisIncrby(key string, value int64) (int64, error) {
 	return rdb.IncrBy(ctx, key, value).Result()
 }
 
+// thresholdSumScript adds ARGV[1] to the counter KEYS[1] in one step, so nodes sharing a counter cannot
+// interleave: a new counter starts at the increment and expires after ARGV[2] seconds, and a counter
+// exceeding ARGV[3] is deleted, so only the node whose increment crossed the threshold sees it fire.
+// It returns whether the counter fired and its value.
+var thresholdSumScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2])
+	return {0, tonumber(ARGV[1])}
+end
+local count = redis.call('INCRBY', KEYS[1], ARGV[1])
+if count > tonumber(ARGV[3]) then
+	redis.call('DEL', KEYS[1])
+	return {1, count}
+end
+return {0, count}
+`)
+
+// RedisThresholdSum adds value to the cluster-wide threshold counter key and reports whether the
+// counter exceeded threshold, with the counter value. The counter lives for expiration seconds from
+// its first increment.
+func RedisThresholdSum(key string, value int64, expiration int, threshold int) (bool, int64, error) {
+	res, err := thresholdSumScript.Run(ctx, rdb, []string{key}, value, expiration, threshold).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected threshold counter reply %v", res)
+	}
+	return res[0] == 1, res[1], nil
+}
+
+// thresholdClassifyScript adds the value ARGV[1] to the set KEYS[1] in one step, so nodes sharing a
+// set cannot interleave: a new set expires after ARGV[2] seconds, and a set holding more than ARGV[3]
+// distinct values is deleted, so only the node whose value crossed the threshold sees it fire. It
+// returns whether the set fired, followed by its values when it did.
+var thresholdClassifyScript = redis.NewScript(`
+redis.call('SADD', KEYS[1], ARGV[1])
+if redis.call('TTL', KEYS[1]) < 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[2])
+end
+if redis.call('SCARD', KEYS[1]) > tonumber(ARGV[3]) then
+	local values = redis.call('SMEMBERS', KEYS[1])
+	redis.call('DEL', KEYS[1])
+	table.insert(values, 1, '1')
+	return values
+end
+return {'0'}
+`)
+
+// RedisThresholdClassify adds value to the cluster-wide set of distinct values of a CLASSIFY
+// threshold and reports whether the set exceeded threshold, with its values when it did. The set
+// lives for expiration seconds from its first value.
+func RedisThresholdClassify(key string, value string, expiration int, threshold int) (bool, []string, error) {
+	res, err := thresholdClassifyScript.Run(ctx, rdb, []string{key}, value, expiration, threshold).StringSlice()
+	if err != nil {
+		return false, nil, err
+	}
+	if len(res) == 0 {
+		return false, nil, fmt.Errorf("unexpected threshold set reply %v", res)
+	}
+	return res[0] == "1", res[1:], nil
+}
+
 func RedisDel(key string) error {
 	return rdb.Del(ctx, key).Err()
 }
@@ -345,6 +409,30 @@ func RedisHDel(key string, field string) error {
 	return rdb.HDel(ctx, key, field).Err()
 }
 
+// RedisHSetNX sets a field in a Redis hash unless it already exists, reporting whether it was set
+func RedisHSetNX(hash string, field string, value interface{}) (bool, error) {
+	return rdb.HSetNX(ctx, hash, field, value).Result()
+}
+
+// hashTakeScript returns the field ARGV[1] of the hash KEYS[1] and deletes it in one step
+var hashTakeScript = redis.NewScript(`
+local value = redis.call('HGET', KEYS[1], ARGV[1])
+if value then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return value
+`)
+
+// RedisHTake removes a field from a Redis hash and returns its value, "" when it does not exist.
+// Only one of the callers racing for the same field gets its value.
+func RedisHTake(hash string, field string) (string, error) {
+	res, err := hashTakeScript.Run(ctx, rdb, []string{hash}, field).Text()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return res, err
+}
+
 // GetRedisClient returns underlying redis client for advanced operations
 func GetRedisClient() *redis.Client {
 	return rdb
@@ -368,6 +456,11 @@ func RedisLRange(key string, start, stop int64) ([]string, error) {
 	return rdb.LRange(ctx, key, start, stop).Result()
 }
 
+// RedisLRem removes count elements equal to value from a list, from the tail when count is negative
+func RedisLRem(key string, count int64, value interface{}) (int64, error) {
+	return rdb.LRem(ctx, key, count, value).Result()
+}
+
 // RedisExpire sets the expiration time for a key
 func RedisExpire(key string, expiration int) error {
 	return rdb.Expire(ctx, key, time.Duration(expiration)*time.Second).Err()
@@ -402,6 +495,11 @@ func RedisZRevRange(key string, start, stop int64) ([]string, error) {
 	return rdb.ZRevRange(ctx, key, start, stop).Result()
 }
 
+// RedisZCard returns the number of members in a sorted set
+func RedisZCard(key string) (int64, error) {
+	return rdb.ZCard(ctx, key).Result()
+}
+
 // RedisZRemRangeByRank removes members by rank from a sorted set
 func RedisZRemRangeByRank(key string, start, stop int64) (int64, error) {
 	return rdb.ZRemRangeByRank(ctx, key, start, stop).Result()
@@ -470,6 +568,9 @@ func RedisPublishWithRetry(channel string, message string) error {
 	}, 3, 100*time.Millisecond)
 }
 
+// ErrLockHeld is returned by DistributedLock.Acquire when another holder has the lock
+var ErrLockHeld = errors.New("lock already acquired")
+
 // DistributedLock represents a Redis-based distributed lock
 type DistributedLock struct {
 	key        string
@@ -489,17 +590,21 @@ func NewDistributedLock(key string, expiration time.Duration) *DistributedLock {
 
 // Acquire attempts to acquire the distributed lock
 func (dl *DistributedLock) Acquire() error {
-	return redisFailureHandler.circuitBreaker.Call(func() error {
-		acquired, err := rdb.SetNX(ctx, dl.key, dl.value, dl.expiration).Result()
-		if err != nil {
-			return err
-		}
-		if !acquired {
-			return fmt.Errorf("lock already acquired")
-		}
-		dl.acquired = true
-		return nil
+	var acquired bool
+	err := redisFailureHandler.circuitBreaker.Call(func() error {
+		var err error
+		acquired, err = rdb.SetNX(ctx, dl.key, dl.value, dl.expiration).Result()
+		return err
 	})
+	if err != nil {
+		return err
+	}
+	// A held lock is not a Redis failure, so it must not count towards opening the circuit breaker
+	if !acquired {
+		return ErrLockHeld
+	}
+	dl.acquired = true
+	return nil
 }
 
 // Release releases the distributed lock
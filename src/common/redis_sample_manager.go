@@ -1,10 +1,15 @@
 package common
 
 import (
+	"AgentSmith-HUB/logger"
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cespare/xxhash/v2"
@@ -26,20 +31,55 @@ const (
 // RedisSampleData represents sample data stored in Redis
 type RedisSampleData struct {
 	Data                interface{} `json:"data"`
+	Trace               interface{} `json:"trace,omitempty"`
 	Timestamp           time.Time   `json:"timestamp"`
 	ProjectNodeSequence string      `json:"project_node_sequence"`
 	SamplerName         string      `json:"sampler_name"`
 	Score               float64     `json:"score"` // Used for sorting by timestamp
 }
 
+// SampleRetentionPolicy bounds how many samples are kept per key and how long they live.
+// Zero values fall back to the manager defaults.
+type SampleRetentionPolicy struct {
+	MaxSamples int           `yaml:"max_samples" json:"max_samples"`
+	MaxAge     time.Duration `yaml:"max_age" json:"max_age"`
+}
+
+// Apply trims samples (latest first) to the policy: samples older than MaxAge are dropped,
+// then only the MaxSamples most recent are kept
+func (p SampleRetentionPolicy) Apply(samples []SampleData, now time.Time) []SampleData {
+	result := make([]SampleData, 0, len(samples))
+	for _, sample := range samples {
+		if p.MaxAge > 0 && now.Sub(sample.Timestamp) > p.MaxAge {
+			continue
+		}
+		result = append(result, sample)
+	}
+
+	// Sort latest first so the cap always drops the oldest samples
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.After(result[j].Timestamp)
+	})
+
+	if p.MaxSamples > 0 && len(result) > p.MaxSamples {
+		result = result[:p.MaxSamples]
+	}
+	return result
+}
+
 // RedisSampleManager manages sample data in Redis
 type RedisSampleManager struct {
 	ttl              time.Duration
 	maxSamplesPerKey int
+	policies         map[string]SampleRetentionPolicy // Per-sampler overrides, keyed by sampler name
+	policiesMu       sync.RWMutex
 	cleanupTicker    *time.Ticker
 	stopChan         chan struct{}
-	batchChannel     chan SampleData // Channel for batch processing
-	batchTicker      *time.Ticker    // Ticker for batch processing
+	batchChannel     chan SampleData                   // Channel for batch processing
+	batchTicker      *time.Ticker                      // Ticker for batch processing
+	compression      atomic.Pointer[SampleCompression] // Nil stores samples uncompressed
+	compressionStats sampleCompressionStats
+	encryption       atomic.Pointer[SampleEncryption] // Nil when no field is encrypted
 }
 
 // NewRedisSampleManager creates a new Redis Sample Manager
@@ -47,6 +87,7 @@ func NewRedisSampleManager() *RedisSampleManager {
 	rsm := &RedisSampleManager{
 		ttl:              DefaultSampleTTL,
 		maxSamplesPerKey: DefaultMaxSamplesPerKey,
+		policies:         make(map[string]SampleRetentionPolicy),
 		cleanupTicker:    time.NewTicker(DefaultCleanupInterval),
 		stopChan:         make(chan struct{}),
 		batchChannel:     make(chan SampleData, 5000),            // Large buffer for batch processing
@@ -62,6 +103,56 @@ func NewRedisSampleManager() *RedisSampleManager {
 	return rsm
 }
 
+// marshalSample serializes a sample, replacing the values of its data and trace that cannot be
+// encoded as JSON so one bad value does not lose the whole sample
+func marshalSample(s *RedisSampleData) ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err == nil {
+		return data, nil
+	}
+	s.Data, s.Trace = SafeJSONValue(s.Data), SafeJSONValue(s.Trace)
+	return json.Marshal(s)
+}
+
+// serializeSample encodes a sample for Redis with its sensitive fields encrypted. It also returns the
+// plain data, which the deduplication hash is computed from since encrypted values differ each time.
+func (rsm *RedisSampleManager) serializeSample(s *RedisSampleData) ([]byte, interface{}, error) {
+	enc := rsm.encryption.Load()
+	if enc == nil {
+		data, err := marshalSample(s)
+		return data, s.Data, err
+	}
+	plain := SafeJSONValue(s.Data)
+	encrypted, err := enc.EncryptFields(plain)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Data = encrypted
+	data, err := marshalSample(s)
+	return data, plain, err
+}
+
+// decodeMember reads a stored sample back, decrypting its encrypted fields
+func (rsm *RedisSampleManager) decodeMember(member []byte) (SampleData, error) {
+	data, err := decodeSample(member)
+	if err != nil {
+		return SampleData{}, err
+	}
+	var redisSample RedisSampleData
+	if err := json.Unmarshal(data, &redisSample); err != nil {
+		return SampleData{}, err
+	}
+	if enc := rsm.encryption.Load(); enc != nil {
+		redisSample.Data = enc.DecryptFields(redisSample.Data)
+	}
+	return SampleData{
+		Data:                redisSample.Data,
+		Trace:               redisSample.Trace,
+		Timestamp:           redisSample.Timestamp,
+		ProjectNodeSequence: redisSample.ProjectNodeSequence,
+	}, nil
+}
+
 // StoreSample stores a sample in Redis with TTL and size limits
 func (rsm *RedisSampleManager) StoreSample(samplerName string, sample SampleData) error {
 	if rdb == nil {
@@ -71,10 +162,12 @@ func (rsm *RedisSampleManager) StoreSample(samplerName string, sample SampleData
 	ctx := context.Background()
 	// Simplified key structure: sample_data:samplerName:projectNodeSequence
 	key := fmt.Sprintf("%s%s:%s", RedisSampleKeyPrefix, samplerName, sample.ProjectNodeSequence)
+	policy := rsm.RetentionPolicy(samplerName)
 
 	// Create Redis sample data
 	redisSample := RedisSampleData{
 		Data:                sample.Data,
+		Trace:               sample.Trace,
 		Timestamp:           sample.Timestamp,
 		ProjectNodeSequence: sample.ProjectNodeSequence,
 		SamplerName:         samplerName,
@@ -82,16 +175,20 @@ func (rsm *RedisSampleManager) StoreSample(samplerName string, sample SampleData
 	}
 
 	// Serialize to JSON
-	jsonData, err := json.Marshal(redisSample)
+	jsonData, plainData, err := rsm.serializeSample(&redisSample)
 	if err != nil {
 		return fmt.Errorf("failed to serialize sample data: %w", err)
 	}
+	member, err := rsm.encodeMember(jsonData)
+	if err != nil {
+		return err
+	}
 
 	// Deduplication: compute hash only from business content (sequence + data), exclude timestamp
 	hashInputBytes, _ := json.Marshal(struct {
 		Seq  string      `json:"seq"`
 		Data interface{} `json:"data"`
-	}{Seq: sample.ProjectNodeSequence, Data: sample.Data})
+	}{Seq: sample.ProjectNodeSequence, Data: plainData})
 	hashVal := xxhash.Sum64(hashInputBytes)
 	// Simplified hash key: sample_hash:samplerName:projectNodeSequence
 	hashKey := fmt.Sprintf("%s%s:%s", RedisSampleHashKey, samplerName, sample.ProjectNodeSequence)
@@ -102,7 +199,7 @@ func (rsm *RedisSampleManager) StoreSample(samplerName string, sample SampleData
 		return fmt.Errorf("failed to add hash set: %w", err)
 	}
 	// set TTL for hash set key
-	RedisExpire(hashKey, int(rsm.ttl.Seconds()))
+	RedisExpire(hashKey, int(policy.MaxAge.Seconds()))
 	if added == 0 {
 		// duplicate, do not store
 		return nil
@@ -114,19 +211,19 @@ func (rsm *RedisSampleManager) StoreSample(samplerName string, sample SampleData
 	// Add to sorted set (sorted by timestamp)
 	pipe.ZAdd(ctx, key, redis.Z{
 		Score:  redisSample.Score,
-		Member: jsonData,
+		Member: member,
 	})
 
 	// Set TTL on the key
-	pipe.Expire(ctx, key, rsm.ttl)
+	pipe.Expire(ctx, key, policy.MaxAge)
 
 	// Keep only the most recent N samples
-	pipe.ZRemRangeByRank(ctx, key, 0, -int64(rsm.maxSamplesPerKey+1))
+	pipe.ZRemRangeByRank(ctx, key, 0, -int64(policy.MaxSamples+1))
 
 	// Update sample count with simplified key
 	countKey := fmt.Sprintf("%s%s:%s", RedisSampleCountKey, samplerName, sample.ProjectNodeSequence)
 	pipe.Incr(ctx, countKey)
-	pipe.Expire(ctx, countKey, rsm.ttl)
+	pipe.Expire(ctx, countKey, policy.MaxAge)
 
 	// Execute transaction
 	_, err = pipe.Exec(ctx)
@@ -152,6 +249,8 @@ func (rsm *RedisSampleManager) GetSamples(samplerName string) (map[string][]Samp
 	}
 
 	result := make(map[string][]SampleData)
+	policy := rsm.RetentionPolicy(samplerName)
+	now := time.Now()
 
 	for _, key := range keys {
 		// Extract project node sequence from key (fixed extraction logic)
@@ -165,6 +264,9 @@ func (rsm *RedisSampleManager) GetSamples(samplerName string) (map[string][]Samp
 			continue // Skip this key if error
 		}
 
+		// Enforce retention on read as well, so callers never see more than the cap
+		// even if periodic trimming has not run yet
+		samples = policy.Apply(samples, now)
 		if len(samples) > 0 {
 			result[projectNodeSequence] = samples
 		}
@@ -184,17 +286,10 @@ func (rsm *RedisSampleManager) getSamplesFromKey(key string) ([]SampleData, erro
 	samples := make([]SampleData, 0, len(members))
 
 	for _, member := range members {
-		var redisSample RedisSampleData
-		err := json.Unmarshal([]byte(member), &redisSample)
+		sample, err := rsm.decodeMember([]byte(member))
 		if err != nil {
 			continue // Skip invalid data
 		}
-
-		sample := SampleData{
-			Data:                redisSample.Data,
-			Timestamp:           redisSample.Timestamp,
-			ProjectNodeSequence: redisSample.ProjectNodeSequence,
-		}
 		samples = append(samples, sample)
 	}
 
@@ -233,6 +328,52 @@ func (rsm *RedisSampleManager) GetStats(samplerName string) (map[string]int64, e
 	return result, nil
 }
 
+// CountAllSamples returns the number of samples currently retained per sampler name, reading every
+// sample key in one scan so component lists do not scan once per component
+func (rsm *RedisSampleManager) CountAllSamples() (map[string]int64, error) {
+	if rdb == nil {
+		return nil, fmt.Errorf("Redis client not available")
+	}
+
+	keys, err := RedisKeys(RedisSampleKeyPrefix + "*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sample keys: %w", err)
+	}
+
+	ctx := context.Background()
+	pipe := GetRedisClient().Pipeline()
+	cards := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cards[i] = pipe.ZCard(ctx, key)
+	}
+	if len(keys) > 0 {
+		// Errors are per key and checked below, like the per-key reads skip a key that fails
+		_, _ = pipe.Exec(ctx)
+	}
+
+	counts := make(map[string]int64)
+	for i, key := range keys {
+		count, err := cards[i].Result()
+		if err != nil {
+			continue // Skip this key if error
+		}
+		if name, ok := sampleKeySampler(key); ok {
+			counts[name] += count
+		}
+	}
+	return counts, nil
+}
+
+// sampleKeySampler returns the sampler name of a sample_data:samplerName:projectNodeSequence key
+func sampleKeySampler(key string) (string, bool) {
+	rest := strings.TrimPrefix(key, RedisSampleKeyPrefix)
+	name, _, ok := strings.Cut(rest, ":")
+	if !ok || name == "" || rest == key {
+		return "", false
+	}
+	return name, true
+}
+
 // Reset clears all samples for a sampler
 func (rsm *RedisSampleManager) Reset(samplerName string) error {
 	if rdb == nil {
@@ -280,19 +421,114 @@ func (rsm *RedisSampleManager) SetMaxSamplesPerKey(max int) {
 	rsm.maxSamplesPerKey = max
 }
 
+// SetRetentionPolicy overrides the retention policy for a single sampler (e.g. "ruleset.test")
+func (rsm *RedisSampleManager) SetRetentionPolicy(samplerName string, policy SampleRetentionPolicy) {
+	rsm.policiesMu.Lock()
+	defer rsm.policiesMu.Unlock()
+	rsm.policies[strings.ToLower(samplerName)] = policy
+}
+
+// RetentionPolicy returns the effective retention policy for a sampler,
+// falling back to the manager defaults for unset fields
+func (rsm *RedisSampleManager) RetentionPolicy(samplerName string) SampleRetentionPolicy {
+	rsm.policiesMu.RLock()
+	policy := rsm.policies[strings.ToLower(samplerName)]
+	rsm.policiesMu.RUnlock()
+
+	if policy.MaxSamples <= 0 {
+		policy.MaxSamples = rsm.maxSamplesPerKey
+	}
+	if policy.MaxAge <= 0 {
+		policy.MaxAge = rsm.ttl
+	}
+	return policy
+}
+
+// ApplyRetentionConfig applies global and per-component retention settings from hub config
+func (rsm *RedisSampleManager) ApplyRetentionConfig(cfg SampleRetentionConfig) {
+	if cfg.MaxSamples > 0 {
+		rsm.SetMaxSamplesPerKey(cfg.MaxSamples)
+	}
+	if cfg.MaxAge > 0 {
+		rsm.SetTTL(cfg.MaxAge)
+	}
+	if cfg.CleanupInterval > 0 && rsm.cleanupTicker != nil {
+		rsm.cleanupTicker.Reset(cfg.CleanupInterval)
+	}
+	for name, policy := range cfg.Components {
+		rsm.SetRetentionPolicy(name, policy)
+	}
+}
+
+// SetCompression sets the encoding used for newly stored samples; existing samples stay readable
+func (rsm *RedisSampleManager) SetCompression(c SampleCompression) {
+	rsm.compression.Store(&c)
+}
+
+// Compression returns the encoding used for newly stored samples
+func (rsm *RedisSampleManager) Compression() SampleCompression {
+	if c := rsm.compression.Load(); c != nil {
+		return *c
+	}
+	return SampleCompressionNone
+}
+
+// SetEncryption sets the field encryption applied to newly stored samples and to samples read back,
+// nil stores every field in clear
+func (rsm *RedisSampleManager) SetEncryption(enc *SampleEncryption) {
+	rsm.encryption.Store(enc)
+}
+
+// ApplyEncryptionConfig builds and sets the field encryption of a config. With an invalid key the
+// configured fields are stored as a placeholder instead of in clear.
+func (rsm *RedisSampleManager) ApplyEncryptionConfig(cfg SampleEncryptionConfig) {
+	enc, err := NewSampleEncryption(cfg)
+	if err != nil {
+		logger.Error("Invalid sample_encryption, encrypted fields are not stored in samples", "error", err)
+	}
+	rsm.SetEncryption(enc)
+}
+
+// CompressionStats returns serialized and stored sample bytes since startup and their ratio
+func (rsm *RedisSampleManager) CompressionStats() map[string]interface{} {
+	return map[string]interface{}{
+		"compression":  string(rsm.Compression()),
+		"raw_bytes":    rsm.compressionStats.rawBytes.Load(),
+		"stored_bytes": rsm.compressionStats.storedBytes.Load(),
+		"ratio":        rsm.compressionStats.ratio(),
+	}
+}
+
+// encodeMember applies the configured compression to a serialized sample and records its size
+func (rsm *RedisSampleManager) encodeMember(jsonData []byte) ([]byte, error) {
+	member, err := encodeSample(jsonData, rsm.Compression())
+	if err != nil {
+		return nil, err
+	}
+	rsm.compressionStats.record(len(jsonData), len(member))
+	return member, nil
+}
+
 // startCleanup starts the cleanup routine
 func (rsm *RedisSampleManager) startCleanup() {
 	for {
 		select {
 		case <-rsm.cleanupTicker.C:
 			rsm.cleanupExpiredData()
+			if compression := rsm.Compression(); compression != SampleCompressionNone && rsm.compressionStats.rawBytes.Load() > 0 {
+				logger.Info("Sample compression ratio",
+					"compression", compression,
+					"raw_bytes", rsm.compressionStats.rawBytes.Load(),
+					"stored_bytes", rsm.compressionStats.storedBytes.Load(),
+					"ratio", fmt.Sprintf("%.3f", rsm.compressionStats.ratio()))
+			}
 		case <-rsm.stopChan:
 			return
 		}
 	}
 }
 
-// cleanupExpiredData removes expired data
+// cleanupExpiredData trims every sample key to its retention policy (max age and max samples)
 func (rsm *RedisSampleManager) cleanupExpiredData() {
 	if rdb == nil {
 		return
@@ -305,13 +541,18 @@ func (rsm *RedisSampleManager) cleanupExpiredData() {
 		return
 	}
 
-	// Remove expired samples based on timestamp
-	cutoffTime := time.Now().Add(-rsm.ttl)
-	cutoffScore := float64(cutoffTime.Unix())
-
+	now := time.Now()
 	for _, key := range keys {
-		// Remove samples older than TTL
+		// Key format: sample_data:samplerName:projectNodeSequence
+		samplerName, _, _ := strings.Cut(strings.TrimPrefix(key, RedisSampleKeyPrefix), ":")
+		policy := rsm.RetentionPolicy(samplerName)
+
+		// Remove samples older than max age
+		cutoffScore := float64(now.Add(-policy.MaxAge).Unix())
 		RedisZRemRangeByScore(key, "0", strconv.FormatFloat(cutoffScore, 'f', -1, 64))
+
+		// Keep only the most recent N samples
+		RedisZRemRangeByRank(key, 0, -int64(policy.MaxSamples+1))
 	}
 }
 
@@ -384,6 +625,7 @@ func (rsm *RedisSampleManager) processBatch(batch []SampleData) {
 			// Create Redis sample data
 			redisSample := RedisSampleData{
 				Data:                sample.Data,
+				Trace:               sample.Trace,
 				Timestamp:           sample.Timestamp,
 				ProjectNodeSequence: sample.ProjectNodeSequence,
 				SamplerName:         "unknown", // We need to pass sampler name
@@ -391,7 +633,11 @@ func (rsm *RedisSampleManager) processBatch(batch []SampleData) {
 			}
 
 			// Serialize to JSON
-			jsonData, err := json.Marshal(redisSample)
+			jsonData, _, err := rsm.serializeSample(&redisSample)
+			if err != nil {
+				continue
+			}
+			member, err := rsm.encodeMember(jsonData)
 			if err != nil {
 				continue
 			}
@@ -399,7 +645,7 @@ func (rsm *RedisSampleManager) processBatch(batch []SampleData) {
 			// Add to sorted set (sorted by timestamp)
 			pipe.ZAdd(ctx, key, redis.Z{
 				Score:  redisSample.Score,
-				Member: jsonData,
+				Member: member,
 			})
 
 			// Set TTL on the key
@@ -444,6 +690,15 @@ var globalRedisSampleManager *RedisSampleManager
 // InitRedisSampleManager initializes the global Redis sample manager
 func InitRedisSampleManager() {
 	globalRedisSampleManager = NewRedisSampleManager()
+	if Config != nil {
+		globalRedisSampleManager.ApplyRetentionConfig(Config.SampleRetention)
+		compression, err := ParseSampleCompression(Config.SampleCompression)
+		if err != nil {
+			logger.Warn("Invalid sample_compression, storing samples uncompressed", "error", err)
+		}
+		globalRedisSampleManager.SetCompression(compression)
+		globalRedisSampleManager.ApplyEncryptionConfig(Config.SampleEncryption)
+	}
 }
 
 // GetRedisSampleManager returns the global Redis sample manager
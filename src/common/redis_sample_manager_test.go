@@ -0,0 +1,77 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleRetentionPolicy_CapTrimsOldest(t *testing.T) {
+	now := time.Now()
+	samples := make([]SampleData, 0, 5)
+	// Oldest first on purpose; Apply must still keep the newest
+	for i := 0; i < 5; i++ {
+		samples = append(samples, SampleData{
+			Data:      i,
+			Timestamp: now.Add(time.Duration(i-5) * time.Minute),
+		})
+	}
+
+	res := SampleRetentionPolicy{MaxSamples: 3}.Apply(samples, now)
+	if len(res) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(res))
+	}
+	for i, want := range []int{4, 3, 2} {
+		if res[i].Data.(int) != want {
+			t.Fatalf("sample %d: expected %d, got %v", i, want, res[i].Data)
+		}
+	}
+}
+
+func TestSampleRetentionPolicy_MaxAge(t *testing.T) {
+	now := time.Now()
+	samples := []SampleData{
+		{Data: "new", Timestamp: now.Add(-time.Minute)},
+		{Data: "old", Timestamp: now.Add(-2 * time.Hour)},
+	}
+
+	res := SampleRetentionPolicy{MaxSamples: 10, MaxAge: time.Hour}.Apply(samples, now)
+	if len(res) != 1 || res[0].Data != "new" {
+		t.Fatalf("expected only the recent sample to be kept, got %v", res)
+	}
+}
+
+func TestRedisSampleManager_RetentionPolicyFallback(t *testing.T) {
+	rsm := &RedisSampleManager{
+		ttl:              DefaultSampleTTL,
+		maxSamplesPerKey: DefaultMaxSamplesPerKey,
+		policies:         make(map[string]SampleRetentionPolicy),
+	}
+	rsm.SetRetentionPolicy("Ruleset.Test", SampleRetentionPolicy{MaxSamples: 10})
+
+	p := rsm.RetentionPolicy("ruleset.test")
+	if p.MaxSamples != 10 || p.MaxAge != DefaultSampleTTL {
+		t.Fatalf("unexpected override policy: %+v", p)
+	}
+
+	p = rsm.RetentionPolicy("input.other")
+	if p.MaxSamples != DefaultMaxSamplesPerKey || p.MaxAge != DefaultSampleTTL {
+		t.Fatalf("unexpected default policy: %+v", p)
+	}
+}
+
+func TestSampleKeySampler(t *testing.T) {
+	cases := map[string]string{
+		"sample_data:ruleset.brute_force:proj1.input.k.ruleset.brute_force": "ruleset.brute_force",
+		"sample_data:input.kafka:a:b":                                       "input.kafka",
+	}
+	for key, want := range cases {
+		if name, ok := sampleKeySampler(key); !ok || name != want {
+			t.Fatalf("%s: expected %s, got %q %v", key, want, name, ok)
+		}
+	}
+	for _, key := range []string{"sample_count:input.kafka:a", "sample_data:input.kafka", "sample_data::a"} {
+		if name, ok := sampleKeySampler(key); ok {
+			t.Fatalf("%s: expected no sampler, got %s", key, name)
+		}
+	}
+}
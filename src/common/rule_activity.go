@@ -0,0 +1,388 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	ruleHitsKeyPrefix = "hub:rule_hits:" // + date, hash of "project#ruleset#rule" -> hits
+	ruleReportsKey    = "hub:rule_reports"
+
+	defaultRuleReportInterval  = 24 * time.Hour
+	defaultRuleReportRetention = 30
+	defaultRuleReportTopN      = 20
+)
+
+// RuleReportConfig schedules the rule activity report compiled by the leader
+type RuleReportConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval"`  // Time between reports and the period each one covers, default 24h
+	Retention int           `yaml:"retention"` // Reports kept for GET /reports, default 30
+	TopN      int           `yaml:"top_n"`     // Number of top firing rules listed, default 20
+	Webhook   string        `yaml:"webhook"`   // Optional URL every report is POSTed to as JSON
+}
+
+// RuleHitKey identifies a rule inside a project's ruleset
+type RuleHitKey struct {
+	ProjectID string
+	RulesetID string
+	RuleID    string
+}
+
+func (k RuleHitKey) field() string {
+	return k.ProjectID + "#" + k.RulesetID + "#" + k.RuleID
+}
+
+func parseRuleHitField(field string) (RuleHitKey, bool) {
+	parts := strings.SplitN(field, "#", 3)
+	if len(parts) != 3 {
+		return RuleHitKey{}, false
+	}
+	return RuleHitKey{ProjectID: parts[0], RulesetID: parts[1], RuleID: parts[2]}, true
+}
+
+// RuleHitCollectorFunc returns the rule hits since its last call, set by the project package
+type RuleHitCollectorFunc func() map[RuleHitKey]uint64
+
+// DeployedRulesFunc returns every rule of the running projects, set by the project package
+type DeployedRulesFunc func() []RuleHitKey
+
+var (
+	ruleHitCollector      RuleHitCollectorFunc
+	deployedRulesProvider DeployedRulesFunc
+)
+
+// SetRuleHitCollector sets the callback collecting per-rule hit increments
+func SetRuleHitCollector(collector RuleHitCollectorFunc) {
+	ruleHitCollector = collector
+}
+
+// SetDeployedRulesProvider sets the callback listing the rules of running projects
+func SetDeployedRulesProvider(provider DeployedRulesFunc) {
+	deployedRulesProvider = provider
+}
+
+// collectRuleHits persists the rule hit increments next to the daily component statistics
+func (dsm *DailyStatsManager) collectRuleHits() {
+	if ruleHitCollector == nil {
+		return
+	}
+	hits := ruleHitCollector()
+	if len(hits) == 0 {
+		return
+	}
+
+	key := ruleHitsKeyPrefix + time.Now().Format("2006-01-02")
+	ctx := context.Background()
+	pipe := GetRedisClient().Pipeline()
+	for k, n := range hits {
+		pipe.HIncrBy(ctx, key, k.field(), int64(n))
+	}
+	pipe.Expire(ctx, key, time.Duration(dsm.retentionDays)*24*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Error("Failed to write rule hit statistics", "rules", len(hits), "error", err)
+	}
+}
+
+// GetRuleHits returns the cluster wide hits per rule recorded for date (2006-01-02)
+func GetRuleHits(date string) (map[RuleHitKey]uint64, error) {
+	raw, err := GetRedisClient().HGetAll(context.Background(), ruleHitsKeyPrefix+date).Result()
+	if err != nil {
+		return nil, err
+	}
+	hits := make(map[RuleHitKey]uint64, len(raw))
+	for field, v := range raw {
+		k, ok := parseRuleHitField(field)
+		if !ok {
+			continue
+		}
+		var n uint64
+		if _, err := fmt.Sscan(v, &n); err == nil {
+			hits[k] += n
+		}
+	}
+	return hits, nil
+}
+
+// RuleActivity is the number of hits of one rule during the report period
+type RuleActivity struct {
+	ProjectID string `json:"project_id"`
+	RulesetID string `json:"ruleset_id"`
+	RuleID    string `json:"rule_id"`
+	Hits      uint64 `json:"hits"`
+}
+
+// ProjectVolume is the number of messages a project handled during the report period
+type ProjectVolume struct {
+	ProjectID string `json:"project_id"`
+	Input     uint64 `json:"input"`
+	Ruleset   uint64 `json:"ruleset"`
+	Output    uint64 `json:"output"`
+}
+
+// RuleActivityReport summarizes rule activity over a period: the rules firing most, the deployed
+// rules that did not fire at all and the message volume of every project
+type RuleActivityReport struct {
+	ID            string          `json:"id"`
+	GeneratedAt   time.Time       `json:"generated_at"`
+	From          string          `json:"from"` // First date covered, 2006-01-02
+	To            string          `json:"to"`   // Last date covered
+	TotalHits     uint64          `json:"total_hits"`
+	TopRules      []RuleActivity  `json:"top_rules"`
+	DeadRules     []RuleActivity  `json:"dead_rules"`
+	ProjectVolume []ProjectVolume `json:"project_volume"`
+}
+
+// BuildRuleActivityReport compiles a report from rule hit counters, the currently deployed rules and
+// per-project message volume. Dead rules are deployed rules without any hit.
+func BuildRuleActivityReport(hits map[RuleHitKey]uint64, deployed []RuleHitKey, volume map[string]*ProjectVolume, topN int) *RuleActivityReport {
+	report := &RuleActivityReport{
+		TopRules:      []RuleActivity{},
+		DeadRules:     []RuleActivity{},
+		ProjectVolume: []ProjectVolume{},
+	}
+
+	for k, n := range hits {
+		if n == 0 {
+			continue
+		}
+		report.TotalHits += n
+		report.TopRules = append(report.TopRules, RuleActivity{ProjectID: k.ProjectID, RulesetID: k.RulesetID, RuleID: k.RuleID, Hits: n})
+	}
+	sort.Slice(report.TopRules, func(i, j int) bool {
+		a, b := report.TopRules[i], report.TopRules[j]
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		return RuleHitKey{a.ProjectID, a.RulesetID, a.RuleID}.field() < RuleHitKey{b.ProjectID, b.RulesetID, b.RuleID}.field()
+	})
+	if topN > 0 && len(report.TopRules) > topN {
+		report.TopRules = report.TopRules[:topN]
+	}
+
+	seen := make(map[RuleHitKey]struct{}, len(deployed))
+	for _, k := range deployed {
+		if _, dup := seen[k]; dup || hits[k] > 0 {
+			continue
+		}
+		seen[k] = struct{}{}
+		report.DeadRules = append(report.DeadRules, RuleActivity{ProjectID: k.ProjectID, RulesetID: k.RulesetID, RuleID: k.RuleID})
+	}
+	sort.Slice(report.DeadRules, func(i, j int) bool {
+		a, b := report.DeadRules[i], report.DeadRules[j]
+		return RuleHitKey{a.ProjectID, a.RulesetID, a.RuleID}.field() < RuleHitKey{b.ProjectID, b.RulesetID, b.RuleID}.field()
+	})
+
+	for _, v := range volume {
+		report.ProjectVolume = append(report.ProjectVolume, *v)
+	}
+	sort.Slice(report.ProjectVolume, func(i, j int) bool {
+		a, b := report.ProjectVolume[i], report.ProjectVolume[j]
+		if a.Input != b.Input {
+			return a.Input > b.Input
+		}
+		return a.ProjectID < b.ProjectID
+	})
+	return report
+}
+
+// reportDates lists the dates of the period ending at now
+func reportDates(now time.Time, interval time.Duration) []string {
+	var dates []string
+	last := now.Format("2006-01-02")
+	for d := now.Add(-interval); ; d = d.Add(24 * time.Hour) {
+		date := d.Format("2006-01-02")
+		dates = append(dates, date)
+		if date >= last {
+			return dates
+		}
+	}
+}
+
+// RuleActivityReporter compiles rule activity reports on the leader on a schedule
+type RuleActivityReporter struct {
+	cfg      RuleReportConfig
+	client   *http.Client
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRuleActivityReporter applies the defaults to cfg
+func NewRuleActivityReporter(cfg RuleReportConfig) *RuleActivityReporter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultRuleReportInterval
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultRuleReportRetention
+	}
+	if cfg.TopN <= 0 {
+		cfg.TopN = defaultRuleReportTopN
+	}
+	return &RuleActivityReporter{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Generate compiles the report for the period ending at now, stores it and delivers it to the webhook
+func (r *RuleActivityReporter) Generate(now time.Time) (*RuleActivityReport, error) {
+	dates := reportDates(now, r.cfg.Interval)
+
+	hits := make(map[RuleHitKey]uint64)
+	volume := make(map[string]*ProjectVolume)
+	for _, date := range dates {
+		dayHits, err := GetRuleHits(date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule hits for %s: %w", date, err)
+		}
+		for k, n := range dayHits {
+			hits[k] += n
+		}
+		if GlobalDailyStatsManager == nil {
+			continue
+		}
+		for _, data := range GlobalDailyStatsManager.GetDailyStats(date, "", "") {
+			if data.ProjectID == "" || data.ProjectID == "global" {
+				continue
+			}
+			v, ok := volume[data.ProjectID]
+			if !ok {
+				v = &ProjectVolume{ProjectID: data.ProjectID}
+				volume[data.ProjectID] = v
+			}
+			switch GetComponentTypeFromSequence(data.ProjectNodeSequence, data.ComponentType) {
+			case "input":
+				v.Input += data.TotalMessages
+			case "ruleset":
+				v.Ruleset += data.TotalMessages
+			case "output":
+				v.Output += data.TotalMessages
+			}
+		}
+	}
+
+	var deployed []RuleHitKey
+	if deployedRulesProvider != nil {
+		deployed = deployedRulesProvider()
+	}
+
+	report := BuildRuleActivityReport(hits, deployed, volume, r.cfg.TopN)
+	report.ID = fmt.Sprintf("%d", now.UnixNano())
+	report.GeneratedAt = now
+	report.From, report.To = dates[0], dates[len(dates)-1]
+
+	if err := r.store(report); err != nil {
+		return report, err
+	}
+	if r.cfg.Webhook != "" {
+		if err := r.deliver(report); err != nil {
+			logger.Error("Failed to deliver rule activity report", "webhook", r.cfg.Webhook, "error", err)
+		}
+	}
+	return report, nil
+}
+
+func (r *RuleActivityReporter) store(report *RuleActivityReport) error {
+	data, err := sonic.Marshal(report)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	pipe := GetRedisClient().Pipeline()
+	pipe.LPush(ctx, ruleReportsKey, data)
+	pipe.LTrim(ctx, ruleReportsKey, 0, int64(r.cfg.Retention-1))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store rule activity report: %w", err)
+	}
+	return nil
+}
+
+func (r *RuleActivityReporter) deliver(report *RuleActivityReport) error {
+	data, err := sonic.Marshal(report)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Post(r.cfg.Webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (r *RuleActivityReporter) run() {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case now := <-ticker.C:
+			if report, err := r.Generate(now); err != nil {
+				logger.Error("Failed to generate rule activity report", "error", err)
+			} else {
+				logger.Info("Rule activity report generated", "id", report.ID,
+					"top_rules", len(report.TopRules), "dead_rules", len(report.DeadRules))
+			}
+		}
+	}
+}
+
+// Stop ends the schedule
+func (r *RuleActivityReporter) Stop() {
+	r.stopOnce.Do(func() { close(r.stopChan) })
+}
+
+// GetRuleActivityReports returns up to limit stored reports, newest first
+func GetRuleActivityReports(limit int) ([]RuleActivityReport, error) {
+	if limit <= 0 {
+		limit = defaultRuleReportRetention
+	}
+	raw, err := GetRedisClient().LRange(context.Background(), ruleReportsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]RuleActivityReport, 0, len(raw))
+	for _, item := range raw {
+		var report RuleActivityReport
+		if err := sonic.UnmarshalString(item, &report); err != nil {
+			logger.Warn("Skipping undecodable rule activity report", "error", err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+var GlobalRuleActivityReporter *RuleActivityReporter
+
+// StartRuleActivityReporter starts the report schedule when rule_report is enabled (leader only)
+func StartRuleActivityReporter() {
+	if Config == nil || !Config.RuleReport.Enabled || GlobalRuleActivityReporter != nil {
+		return
+	}
+	GlobalRuleActivityReporter = NewRuleActivityReporter(Config.RuleReport)
+	go GlobalRuleActivityReporter.run()
+	logger.Info("Rule activity reporter started", "interval", GlobalRuleActivityReporter.cfg.Interval)
+}
+
+// StopRuleActivityReporter stops the report schedule
+func StopRuleActivityReporter() {
+	if GlobalRuleActivityReporter != nil {
+		GlobalRuleActivityReporter.Stop()
+	}
+}
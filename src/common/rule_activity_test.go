@@ -0,0 +1,84 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildRuleActivityReport_Sections(t *testing.T) {
+	hits := map[RuleHitKey]uint64{
+		{"p1", "rs1", "brute_force"}: 120,
+		{"p1", "rs1", "sudo"}:        5,
+		{"p2", "rs2", "dns_tunnel"}:  40,
+		{"p2", "rs2", "zero"}:        0,
+	}
+	deployed := []RuleHitKey{
+		{"p1", "rs1", "brute_force"},
+		{"p1", "rs1", "sudo"},
+		{"p1", "rs1", "never"},
+		{"p2", "rs2", "dns_tunnel"},
+		{"p2", "rs2", "zero"},
+		{"p2", "rs2", "zero"}, // the same ruleset can be listed twice
+	}
+	volume := map[string]*ProjectVolume{
+		"p1": {ProjectID: "p1", Input: 1000, Ruleset: 1000, Output: 125},
+		"p2": {ProjectID: "p2", Input: 5000, Ruleset: 5000, Output: 40},
+	}
+
+	report := BuildRuleActivityReport(hits, deployed, volume, 2)
+
+	if report.TotalHits != 165 {
+		t.Fatalf("expected 165 total hits, got %d", report.TotalHits)
+	}
+	wantTop := []RuleActivity{
+		{ProjectID: "p1", RulesetID: "rs1", RuleID: "brute_force", Hits: 120},
+		{ProjectID: "p2", RulesetID: "rs2", RuleID: "dns_tunnel", Hits: 40},
+	}
+	if !reflect.DeepEqual(report.TopRules, wantTop) {
+		t.Fatalf("unexpected top rules: %+v", report.TopRules)
+	}
+	wantDead := []RuleActivity{
+		{ProjectID: "p1", RulesetID: "rs1", RuleID: "never"},
+		{ProjectID: "p2", RulesetID: "rs2", RuleID: "zero"},
+	}
+	if !reflect.DeepEqual(report.DeadRules, wantDead) {
+		t.Fatalf("unexpected dead rules: %+v", report.DeadRules)
+	}
+	if len(report.ProjectVolume) != 2 || report.ProjectVolume[0].ProjectID != "p2" || report.ProjectVolume[1].Output != 125 {
+		t.Fatalf("unexpected project volume: %+v", report.ProjectVolume)
+	}
+}
+
+func TestBuildRuleActivityReport_EmptySectionsEncodeAsArrays(t *testing.T) {
+	out, err := json.Marshal(BuildRuleActivityReport(nil, nil, nil, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(out, &decoded)
+	for _, section := range []string{"top_rules", "dead_rules", "project_volume"} {
+		if _, ok := decoded[section].([]interface{}); !ok {
+			t.Fatalf("section %s should be an empty array: %s", section, out)
+		}
+	}
+}
+
+func TestRuleHitField_RoundTrip(t *testing.T) {
+	k := RuleHitKey{ProjectID: "p", RulesetID: "rs", RuleID: "rule#with#hashes"}
+	got, ok := parseRuleHitField(k.field())
+	if !ok || got != k {
+		t.Fatalf("round trip failed: %+v", got)
+	}
+}
+
+func TestReportDates(t *testing.T) {
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.Local)
+	if got := reportDates(now, 48*time.Hour); !reflect.DeepEqual(got, []string{"2026-03-08", "2026-03-09", "2026-03-10"}) {
+		t.Fatalf("unexpected dates: %v", got)
+	}
+	if got := reportDates(now, time.Hour); !reflect.DeepEqual(got, []string{"2026-03-10"}) {
+		t.Fatalf("unexpected dates: %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/bytedance/sonic"
+)
+
+// unencodableTypes records the types already logged by SafeJSONValue, so each is reported once
+var unencodableTypes sync.Map
+
+// jsonPlaceholder returns what replaces a value of type t that cannot be encoded as JSON
+func jsonPlaceholder(t reflect.Type) string {
+	if placeholder := ReadConfig(func(c *HubConfig) string { return c.JSONPlaceholder }); placeholder != "" {
+		return placeholder
+	}
+	return fmt.Sprintf("<unserializable %s>", t)
+}
+
+// SafeMarshal encodes v as JSON. Values that cannot be encoded, such as channels or functions
+// returned by plugins, are replaced with a placeholder instead of failing the whole event.
+func SafeMarshal(v interface{}) ([]byte, error) {
+	data, err := sonic.Marshal(v)
+	if err == nil {
+		return data, nil
+	}
+	return sonic.Marshal(SafeJSONValue(v))
+}
+
+// SafeJSONValue returns v with every value that cannot be encoded as JSON replaced with a
+// placeholder: channels, functions, complex numbers, NaN and infinite floats, and structs that fail
+// to encode. Maps and slices are copied where needed, v itself is never modified.
+func SafeJSONValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	out, _ := safeJSONValue(reflect.ValueOf(v))
+	return out
+}
+
+// safeJSONValue returns the encodable form of rv and whether it differs from the original
+func safeJSONValue(rv reflect.Value) (interface{}, bool) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, false
+	case reflect.Interface, reflect.Ptr:
+		if rv.IsNil() {
+			return rv.Interface(), false
+		}
+		if rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Struct {
+			return safeJSONLeaf(rv)
+		}
+		return safeJSONValue(rv.Elem())
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return unencodable(rv.Type()), true
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return unencodable(rv.Type()), true
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return safeJSONLeaf(rv)
+		}
+		changed := false
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			value, c := safeJSONValue(iter.Value())
+			changed = changed || c
+			m[iter.Key().String()] = value
+		}
+		if changed {
+			return m, true
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && (rv.IsNil() || rv.Type().Elem().Kind() == reflect.Uint8) {
+			return rv.Interface(), false
+		}
+		changed := false
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			value, c := safeJSONValue(rv.Index(i))
+			changed = changed || c
+			list[i] = value
+		}
+		if changed {
+			return list, true
+		}
+	case reflect.Struct:
+		return safeJSONLeaf(rv)
+	}
+	return rv.Interface(), false
+}
+
+// safeJSONLeaf keeps a value whose encoding is up to its type, or replaces it when it fails
+func safeJSONLeaf(rv reflect.Value) (interface{}, bool) {
+	v := rv.Interface()
+	if _, err := sonic.Marshal(v); err != nil {
+		return unencodable(rv.Type()), true
+	}
+	return v, false
+}
+
+// unencodable returns the placeholder of a type and logs the first time the type is seen
+func unencodable(t reflect.Type) string {
+	placeholder := jsonPlaceholder(t)
+	if _, seen := unencodableTypes.LoadOrStore(t, struct{}{}); !seen {
+		logger.Warn("Replaced a value that cannot be encoded as JSON", "type", t.String(), "placeholder", placeholder)
+	}
+	return placeholder
+}
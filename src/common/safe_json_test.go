@@ -0,0 +1,56 @@
+package common
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestSafeMarshal_ReplacesNonSerializableValues(t *testing.T) {
+	event := map[string]interface{}{
+		"user":   "admin",
+		"events": make(chan int),
+		"nested": map[string]interface{}{"callback": func() {}, "ok": 1},
+		"list":   []interface{}{"a", math.NaN()},
+	}
+	if _, err := sonic.Marshal(event); err == nil {
+		t.Fatal("expected the raw event to be rejected by the encoder")
+	}
+
+	data, err := SafeMarshal(event)
+	if err != nil {
+		t.Fatalf("expected the event to be encoded, got %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := sonic.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %s: %v", data, err)
+	}
+	if decoded["user"] != "admin" || decoded["nested"].(map[string]interface{})["ok"] != float64(1) {
+		t.Fatalf("expected encodable values to be kept, got %s", data)
+	}
+	if decoded["events"] != "<unserializable chan int>" {
+		t.Fatalf("expected the channel to be replaced, got %v", decoded["events"])
+	}
+	if !strings.HasPrefix(decoded["nested"].(map[string]interface{})["callback"].(string), "<unserializable func") {
+		t.Fatalf("expected the function to be replaced, got %s", data)
+	}
+	if decoded["list"].([]interface{})[1] != "<unserializable float64>" {
+		t.Fatalf("expected NaN to be replaced, got %s", data)
+	}
+	if _, ok := event["events"].(chan int); !ok {
+		t.Fatal("expected the original event to be left unchanged")
+	}
+}
+
+func TestSafeMarshal_ConfiguredPlaceholder(t *testing.T) {
+	orig := Config
+	Config = &HubConfig{JSONPlaceholder: "[removed]"}
+	t.Cleanup(func() { Config = orig })
+
+	data, err := SafeMarshal(map[string]interface{}{"f": func() {}})
+	if err != nil || string(data) != `{"f":"[removed]"}` {
+		t.Fatalf("expected the configured placeholder, got %s (%v)", data, err)
+	}
+}
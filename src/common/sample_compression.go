@@ -0,0 +1,107 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+// SampleCompression selects how sample members are encoded before being stored in Redis
+type SampleCompression string
+
+const (
+	SampleCompressionNone   SampleCompression = "none"
+	SampleCompressionGzip   SampleCompression = "gzip"
+	SampleCompressionSnappy SampleCompression = "snappy"
+)
+
+// Compressed members carry a marker prefix; plain JSON members (always starting with '{')
+// are still read as-is, so switching compression on or off keeps existing samples readable
+const (
+	sampleGzipMarker   = "gz1:"
+	sampleSnappyMarker = "sn1:"
+)
+
+// ParseSampleCompression validates a sample_compression config value ("" means none)
+func ParseSampleCompression(value string) (SampleCompression, error) {
+	switch c := SampleCompression(strings.ToLower(strings.TrimSpace(value))); c {
+	case "", SampleCompressionNone:
+		return SampleCompressionNone, nil
+	case SampleCompressionGzip, SampleCompressionSnappy:
+		return c, nil
+	default:
+		return SampleCompressionNone, fmt.Errorf("unsupported sample compression '%s', expected none, gzip or snappy", value)
+	}
+}
+
+// encodeSample compresses a serialized sample according to c
+func encodeSample(data []byte, c SampleCompression) ([]byte, error) {
+	switch c {
+	case SampleCompressionGzip:
+		var buf bytes.Buffer
+		buf.WriteString(sampleGzipMarker)
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to gzip sample: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to gzip sample: %w", err)
+		}
+		return buf.Bytes(), nil
+	case SampleCompressionSnappy:
+		encoded := snappy.Encode(nil, data)
+		return append([]byte(sampleSnappyMarker), encoded...), nil
+	default:
+		return data, nil
+	}
+}
+
+// decodeSample returns the serialized sample of a Redis member, whatever encoding it was stored with
+func decodeSample(member []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(member, []byte(sampleGzipMarker)):
+		r, err := gzip.NewReader(bytes.NewReader(member[len(sampleGzipMarker):]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to gunzip sample: %w", err)
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to gunzip sample: %w", err)
+		}
+		return data, nil
+	case bytes.HasPrefix(member, []byte(sampleSnappyMarker)):
+		data, err := snappy.Decode(nil, member[len(sampleSnappyMarker):])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode snappy sample: %w", err)
+		}
+		return data, nil
+	default:
+		return member, nil
+	}
+}
+
+// sampleCompressionStats tracks serialized vs stored sample bytes
+type sampleCompressionStats struct {
+	rawBytes    atomic.Int64
+	storedBytes atomic.Int64
+}
+
+func (s *sampleCompressionStats) record(raw, stored int) {
+	s.rawBytes.Add(int64(raw))
+	s.storedBytes.Add(int64(stored))
+}
+
+// ratio returns stored/raw bytes (1 when nothing was stored yet)
+func (s *sampleCompressionStats) ratio() float64 {
+	raw := s.rawBytes.Load()
+	if raw == 0 {
+		return 1
+	}
+	return float64(s.storedBytes.Load()) / float64(raw)
+}
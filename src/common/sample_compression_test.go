@@ -0,0 +1,108 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSampleJSON(t *testing.T) []byte {
+	data, err := json.Marshal(RedisSampleData{
+		Data:                map[string]interface{}{"msg": strings.Repeat("login failed for admin ", 20)},
+		Timestamp:           time.Now(),
+		ProjectNodeSequence: "INPUT.kafka.RULESET.test",
+		SamplerName:         "ruleset.test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSampleCompression_RoundTrip(t *testing.T) {
+	raw := testSampleJSON(t)
+	for _, c := range []SampleCompression{SampleCompressionNone, SampleCompressionGzip, SampleCompressionSnappy} {
+		encoded, err := encodeSample(raw, c)
+		if err != nil {
+			t.Fatalf("%s: encode failed: %v", c, err)
+		}
+		if c != SampleCompressionNone && len(encoded) >= len(raw) {
+			t.Fatalf("%s: expected repetitive sample to shrink, %d >= %d bytes", c, len(encoded), len(raw))
+		}
+		decoded, err := decodeSample(encoded)
+		if err != nil {
+			t.Fatalf("%s: decode failed: %v", c, err)
+		}
+		if !bytes.Equal(decoded, raw) {
+			t.Fatalf("%s: round trip mismatch", c)
+		}
+	}
+}
+
+func TestSampleCompression_CorruptPayload(t *testing.T) {
+	if _, err := decodeSample([]byte(sampleGzipMarker + "not gzip")); err == nil {
+		t.Fatal("expected error for corrupt gzip payload")
+	}
+}
+
+func TestParseSampleCompression(t *testing.T) {
+	for value, want := range map[string]SampleCompression{
+		"":        SampleCompressionNone,
+		"none":    SampleCompressionNone,
+		"GZIP":    SampleCompressionGzip,
+		" snappy": SampleCompressionSnappy,
+	} {
+		got, err := ParseSampleCompression(value)
+		if err != nil || got != want {
+			t.Fatalf("ParseSampleCompression(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseSampleCompression("zstd"); err == nil {
+		t.Fatal("expected error for unsupported compression")
+	}
+}
+
+func TestRedisSampleManager_CompressionStats(t *testing.T) {
+	rsm := &RedisSampleManager{}
+	rsm.SetCompression(SampleCompressionGzip)
+	raw := testSampleJSON(t)
+	member, err := rsm.encodeMember(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := rsm.CompressionStats()
+	if stats["raw_bytes"].(int64) != int64(len(raw)) || stats["stored_bytes"].(int64) != int64(len(member)) {
+		t.Fatalf("unexpected stats: %v", stats)
+	}
+	if ratio := stats["ratio"].(float64); ratio <= 0 || ratio >= 1 {
+		t.Fatalf("expected ratio in (0,1), got %v", ratio)
+	}
+}
+
+func TestRedisSampleManager_SetCompressionWhileStoring(t *testing.T) {
+	rsm := &RedisSampleManager{}
+	raw := testSampleJSON(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if i%2 == 0 {
+				rsm.SetCompression(SampleCompressionGzip)
+			} else {
+				rsm.SetCompression(SampleCompressionNone)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		member, err := rsm.encodeMember(raw)
+		if err != nil {
+			t.Fatalf("encodeMember: %v", err)
+		}
+		if _, err := decodeSample(member); err != nil {
+			t.Fatalf("a sample stored during a compression change must stay readable: %v", err)
+		}
+	}
+	<-done
+}
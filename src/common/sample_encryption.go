@@ -0,0 +1,197 @@
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// sampleEncryptedMarker prefixes an encrypted field value: base64 of the nonce followed by the
+// AES-GCM sealed JSON of the original value, so its type survives decryption
+const sampleEncryptedMarker = "enc1:"
+
+// sampleEncryptionUnavailable replaces the configured fields when the key cannot be loaded, so a
+// broken key never leaves the sensitive values stored in clear
+const sampleEncryptionUnavailable = "<encryption unavailable>"
+
+// SampleEncryptionConfig encrypts named event fields of samples before they are stored in Redis
+type SampleEncryptionConfig struct {
+	Fields  []string `yaml:"fields"`   // Dotted field paths, e.g. user.password
+	Key     string   `yaml:"key"`      // Base64 AES key of 16, 24 or 32 bytes
+	KeyFile string   `yaml:"key_file"` // File holding the base64 key, used instead of key
+}
+
+// SampleEncryption encrypts and decrypts the configured fields of sample data
+type SampleEncryption struct {
+	fields [][]string
+	aead   cipher.AEAD // nil when the key could not be loaded
+}
+
+// loadSampleEncryptionKey reads the base64 key from the config or its key file
+func loadSampleEncryptionKey(cfg SampleEncryptionConfig) ([]byte, error) {
+	encoded := cfg.Key
+	if cfg.KeyFile != "" {
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sample_encryption.key_file: %w", err)
+		}
+		encoded = string(data)
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, fmt.Errorf("sample_encryption requires key or key_file")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("sample_encryption key must be base64: %w", err)
+	}
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, fmt.Errorf("sample_encryption key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// NewSampleEncryption builds the field encryption of a config, nil when no field is configured.
+// With an invalid key the returned encryption still hides the fields, with a placeholder, and the
+// error is returned alongside.
+func NewSampleEncryption(cfg SampleEncryptionConfig) (*SampleEncryption, error) {
+	var fields [][]string
+	for _, field := range cfg.Fields {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, StringToList(field))
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	enc := &SampleEncryption{fields: fields}
+	key, err := loadSampleEncryptionKey(cfg)
+	if err != nil {
+		return enc, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return enc, fmt.Errorf("invalid sample_encryption key: %w", err)
+	}
+	enc.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return enc, fmt.Errorf("invalid sample_encryption key: %w", err)
+	}
+	return enc, nil
+}
+
+// VerifySampleEncryptionConfig checks that the key of a config with fields can be loaded
+func VerifySampleEncryptionConfig(cfg SampleEncryptionConfig) error {
+	_, err := NewSampleEncryption(cfg)
+	return err
+}
+
+// EncryptFields returns data with each configured field encrypted. Maps on the way to a field are
+// copied, data itself is never modified.
+func (e *SampleEncryption) EncryptFields(data interface{}) (interface{}, error) {
+	event, ok := data.(map[string]interface{})
+	if e == nil || !ok {
+		return data, nil
+	}
+	var err error
+	for _, path := range e.fields {
+		event, err = e.encryptPath(event, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
+
+func (e *SampleEncryption) encryptPath(m map[string]interface{}, path []string) (map[string]interface{}, error) {
+	value, exists := m[path[0]]
+	if !exists {
+		return m, nil
+	}
+	if len(path) > 1 {
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			return m, nil
+		}
+		encrypted, err := e.encryptPath(child, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		value = encrypted
+	} else {
+		sealed, err := e.encryptValue(value)
+		if err != nil {
+			return nil, err
+		}
+		value = sealed
+	}
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	copied[path[0]] = value
+	return copied, nil
+}
+
+func (e *SampleEncryption) encryptValue(value interface{}) (interface{}, error) {
+	if e.aead == nil {
+		return sampleEncryptionUnavailable, nil
+	}
+	plain, err := SafeMarshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sample field: %w", err)
+	}
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := e.aead.Seal(nonce, nonce, plain, nil)
+	return sampleEncryptedMarker + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptFields decrypts every encrypted value of data in place. Values it cannot decrypt, because
+// the key is missing or has changed, are left encrypted.
+func (e *SampleEncryption) DecryptFields(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = e.DecryptFields(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = e.DecryptFields(child)
+		}
+	case string:
+		if plain, ok := e.decryptValue(v); ok {
+			return plain
+		}
+	}
+	return data
+}
+
+func (e *SampleEncryption) decryptValue(s string) (interface{}, bool) {
+	if e == nil || e.aead == nil || !strings.HasPrefix(s, sampleEncryptedMarker) {
+		return nil, false
+	}
+	sealed, err := base64.StdEncoding.DecodeString(s[len(sampleEncryptedMarker):])
+	if err != nil || len(sealed) < e.aead.NonceSize() {
+		return nil, false
+	}
+	nonce, ciphertext := sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, false
+	}
+	var value interface{}
+	if err := sonic.Unmarshal(plain, &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
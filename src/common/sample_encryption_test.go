@@ -0,0 +1,84 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampleEncryption_EncryptsStoredFieldsAndDecryptsOnRead(t *testing.T) {
+	cfg := SampleEncryptionConfig{
+		Fields: []string{"user.password", "card"},
+		Key:    base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)),
+	}
+	enc, err := NewSampleEncryption(cfg)
+	if err != nil {
+		t.Fatalf("NewSampleEncryption: %v", err)
+	}
+	rsm := &RedisSampleManager{}
+	rsm.SetEncryption(enc)
+
+	event := map[string]interface{}{
+		"user": map[string]interface{}{"name": "alice", "password": "hunter2"},
+		"card": float64(4111111111111111),
+		"src":  "10.0.0.1",
+	}
+	redisSample := RedisSampleData{Data: event, Timestamp: time.Now(), ProjectNodeSequence: "INPUT.in"}
+	jsonData, plain, err := rsm.serializeSample(&redisSample)
+	if err != nil {
+		t.Fatalf("serializeSample: %v", err)
+	}
+	member, err := rsm.encodeMember(jsonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// What is written to Redis
+	stored := string(member)
+	if strings.Contains(stored, "hunter2") || strings.Contains(stored, "4111111111111111") {
+		t.Fatalf("configured fields must not be stored in clear: %s", stored)
+	}
+	if !strings.Contains(stored, sampleEncryptedMarker) || !strings.Contains(stored, "alice") || !strings.Contains(stored, "10.0.0.1") {
+		t.Fatalf("only the configured fields must be encrypted: %s", stored)
+	}
+	if event["user"].(map[string]interface{})["password"] != "hunter2" {
+		t.Fatal("the sampled event must not be modified")
+	}
+	if plain.(map[string]interface{})["card"] != float64(4111111111111111) {
+		t.Fatalf("the deduplication data must stay plain, got %v", plain)
+	}
+
+	sample, err := rsm.decodeMember(member)
+	if err != nil {
+		t.Fatalf("decodeMember: %v", err)
+	}
+	data := sample.Data.(map[string]interface{})
+	if data["user"].(map[string]interface{})["password"] != "hunter2" || data["card"] != float64(4111111111111111) {
+		t.Fatalf("fields were not decrypted: %v", data)
+	}
+
+	// A node without the key reads the values still encrypted
+	sample, err = (&RedisSampleManager{}).decodeMember(member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, _ := sample.Data.(map[string]interface{})["user"].(map[string]interface{})["password"].(string); !strings.HasPrefix(password, sampleEncryptedMarker) {
+		t.Fatalf("expected the password to stay encrypted without the key, got %q", password)
+	}
+}
+
+func TestSampleEncryption_InvalidKeyHidesFields(t *testing.T) {
+	enc, err := NewSampleEncryption(SampleEncryptionConfig{Fields: []string{"password"}, Key: "short"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid key")
+	}
+	data, err := enc.EncryptFields(map[string]interface{}{"password": "hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.(map[string]interface{})["password"] != sampleEncryptionUnavailable {
+		t.Fatalf("the field must be hidden when the key is invalid, got %v", data)
+	}
+}
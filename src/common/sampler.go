@@ -18,6 +18,7 @@ const (
 // SampleData represents a single sample with its metadata
 type SampleData struct {
 	Data                interface{} `json:"data"`
+	Trace               interface{} `json:"trace,omitempty"` // Check node trace, only for rulesets with sample_trace
 	Timestamp           time.Time   `json:"timestamp"`
 	ProjectNodeSequence string      `json:"project_node_sequence"`
 }
@@ -89,8 +90,18 @@ func (s *Sampler) samplingController() {
 
 // Sample attempts to sample the data based on timer (performance optimized version)
 func (s *Sampler) Sample(data interface{}, projectNodeSequence string) bool {
+	if data == nil || !s.Claim(projectNodeSequence) {
+		return false
+	}
+	s.StoreClaimed(data, nil, projectNodeSequence)
+	return true
+}
+
+// Claim reports whether the next event of projectNodeSequence should be sampled and, if so, takes
+// the sampling slot. The caller must then pass the event to StoreClaimed.
+func (s *Sampler) Claim(projectNodeSequence string) bool {
 	// Quick checks first to avoid expensive operations
-	if atomic.LoadInt32(&s.closed) == 1 || data == nil || projectNodeSequence == "" {
+	if atomic.LoadInt32(&s.closed) == 1 || projectNodeSequence == "" {
 		return false
 	}
 
@@ -121,11 +132,32 @@ func (s *Sampler) Sample(data interface{}, projectNodeSequence string) bool {
 
 	// Increment sampling count
 	atomic.AddUint64(&s.sampledCount, 1)
+	return true
+}
+
+// Pending reports whether a sampling slot is open for projectNodeSequence without taking it, so a
+// caller that only keeps some events can look at the outcome before calling Claim
+func (s *Sampler) Pending(projectNodeSequence string) bool {
+	if atomic.LoadInt32(&s.closed) == 1 || projectNodeSequence == "" {
+		return false
+	}
+	flag, exists := s.samplingFlags.Load(strings.ToLower(projectNodeSequence))
+	if !exists {
+		return true
+	}
+	enabled, ok := flag.(bool)
+	return ok && enabled
+}
+
+// StoreClaimed stores an event picked by Claim, with an optional evaluation trace
+func (s *Sampler) StoreClaimed(data interface{}, trace interface{}, projectNodeSequence string) {
+	normalizedKey := strings.ToLower(projectNodeSequence)
 
 	// Create sample data
 	now := time.Now()
 	sample := SampleData{
 		Data:                data,
+		Trace:               trace,
 		Timestamp:           now,
 		ProjectNodeSequence: projectNodeSequence, // Keep original case for downstream
 	}
@@ -142,8 +174,6 @@ func (s *Sampler) Sample(data interface{}, projectNodeSequence string) bool {
 	} else {
 		s.storeSample(sample, normalizedKey)
 	}
-
-	return true
 }
 
 // storeSample stores sample data to Redis only
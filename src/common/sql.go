@@ -0,0 +1,402 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+const (
+	SQLDriverPostgres = "postgres"
+	SQLDriverMySQL    = "mysql"
+)
+
+// Column types of a SQL output, each event value is coerced to the column type before insertion
+const (
+	SQLColumnText      = "text"
+	SQLColumnInt       = "int"
+	SQLColumnFloat     = "float"
+	SQLColumnBool      = "bool"
+	SQLColumnTimestamp = "timestamp"
+	SQLColumnJSON      = "json"
+)
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLColumn maps a table column to the event field holding its value
+type SQLColumn struct {
+	Name  string
+	Field []string
+	Type  string
+}
+
+// VerifySQLDSN checks that a DSN parses for the driver, without connecting
+func VerifySQLDSN(driver, dsn string) error {
+	switch driver {
+	case SQLDriverPostgres:
+		_, err := pq.NewConnector(dsn)
+		return err
+	case SQLDriverMySQL:
+		_, err := mysql.ParseDSN(dsn)
+		return err
+	default:
+		return fmt.Errorf("unsupported driver %q, expected %s or %s", driver, SQLDriverPostgres, SQLDriverMySQL)
+	}
+}
+
+// VerifySQLIdentifier checks a table or column name, optionally qualified by a schema (schema.table)
+func VerifySQLIdentifier(name string, qualified bool) error {
+	parts := []string{name}
+	if qualified {
+		parts = strings.Split(name, ".")
+	}
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid name %q: at most one schema qualifier is allowed", name)
+	}
+	for _, part := range parts {
+		if !sqlIdentifier.MatchString(part) {
+			return fmt.Errorf("invalid name %q: must match %s", name, sqlIdentifier.String())
+		}
+	}
+	return nil
+}
+
+// VerifySQLColumnType checks the type of a SQL output column, "" meaning text
+func VerifySQLColumnType(columnType string) error {
+	switch columnType {
+	case "", SQLColumnText, SQLColumnInt, SQLColumnFloat, SQLColumnBool, SQLColumnTimestamp, SQLColumnJSON:
+		return nil
+	}
+	return fmt.Errorf("unsupported column type %q, expected text, int, float, bool, timestamp or json", columnType)
+}
+
+// BuildSQLInsert returns the single-row insert statement of a table in the placeholder style of the driver
+func BuildSQLInsert(driver, table string, columns []SQLColumn) string {
+	quote := func(name string) string {
+		if driver == SQLDriverMySQL {
+			return "`" + name + "`"
+		}
+		return `"` + name + `"`
+	}
+	var tableName []string
+	for _, part := range strings.Split(table, ".") {
+		tableName = append(tableName, quote(part))
+	}
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quote(col.Name)
+		if driver == SQLDriverMySQL {
+			placeholders[i] = "?"
+		} else {
+			placeholders[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", strings.Join(tableName, "."), strings.Join(names, ", "), strings.Join(placeholders, ", "))
+}
+
+// SQLRowValues coerces the fields of an event to the column types. Missing fields are NULL.
+func SQLRowValues(event map[string]interface{}, columns []SQLColumn) ([]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	for i, col := range columns {
+		value, ok := GetCheckDataWithType(event, col.Field)
+		if !ok || value == nil {
+			continue
+		}
+		v, err := coerceSQLValue(value, col.Type)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func coerceSQLValue(value interface{}, columnType string) (interface{}, error) {
+	switch columnType {
+	case SQLColumnInt:
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("%v is not an integer", v)
+			}
+			return int64(v), nil
+		case json.Number:
+			return v.Int64()
+		case string:
+			return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		}
+	case SQLColumnFloat:
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case json.Number:
+			return v.Float64()
+		case string:
+			return strconv.ParseFloat(strings.TrimSpace(v), 64)
+		}
+	case SQLColumnBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(strings.TrimSpace(v))
+		}
+	case SQLColumnTimestamp:
+		return ParseEventTime(value)
+	case SQLColumnJSON:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			return string(b), nil
+		}
+		return AnyToString(value), nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", value, columnType)
+}
+
+// SQLDeadLetter receives the events of the rows a SQL output could not insert, with the reason
+type SQLDeadLetter func(event map[string]interface{}, err error)
+
+// SQLProducer inserts batches of events into a table with a channel-based interface. Each batch is
+// inserted in one transaction through a prepared statement; when a row fails the batch is rolled
+// back and retried row by row, and the rows failing again go to the dead letter queue with their
+// event.
+type SQLProducer struct {
+	MsgChan    chan map[string]interface{}
+	db         *sql.DB
+	table      string
+	columns    []SQLColumn
+	insertSQL  string
+	batchSize  int
+	flushDur   time.Duration
+	stopChan   chan struct{}
+	done       chan struct{} // closed once run has flushed and returned
+	deadLetter SQLDeadLetter
+	latency    *LatencyHistogram // Insert duration per batch
+	insertedOK uint64
+	failedRows uint64
+}
+
+// OpenSQL opens the connection pool of a SQL output
+func OpenSQL(driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	return db, nil
+}
+
+// NewSQLProducer creates a producer inserting what it receives on msgChan into table
+func NewSQLProducer(db *sql.DB, driver, table string, columns []SQLColumn, msgChan chan map[string]interface{}, batchSize int, flushDur time.Duration, deadLetter SQLDeadLetter, latency *LatencyHistogram) *SQLProducer {
+	prod := &SQLProducer{
+		MsgChan:    msgChan,
+		db:         db,
+		table:      table,
+		columns:    columns,
+		insertSQL:  BuildSQLInsert(driver, table, columns),
+		batchSize:  batchSize,
+		flushDur:   flushDur,
+		stopChan:   make(chan struct{}),
+		done:       make(chan struct{}),
+		deadLetter: deadLetter,
+		latency:    latency,
+	}
+	go prod.run()
+	return prod
+}
+
+func (p *SQLProducer) run() {
+	defer close(p.done)
+	batch := make([]map[string]interface{}, 0, p.batchSize)
+	timer := time.NewTimer(p.flushDur)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.stopChan:
+			// Flush the pending batch and what is already queued, the events were counted as produced
+		drain:
+			for {
+				select {
+				case msg, ok := <-p.MsgChan:
+					if !ok {
+						break drain
+					}
+					batch = append(batch, msg)
+					if len(batch) >= p.batchSize {
+						p.sendBatch(batch)
+						batch = batch[:0]
+					}
+				default:
+					break drain
+				}
+			}
+			if len(batch) > 0 {
+				p.sendBatch(batch)
+			}
+			return
+		case msg, ok := <-p.MsgChan:
+			if !ok {
+				if len(batch) > 0 {
+					p.sendBatch(batch)
+				}
+				return
+			}
+			batch = append(batch, msg)
+			if len(batch) >= p.batchSize {
+				p.sendBatch(batch)
+				batch = batch[:0]
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(p.flushDur)
+			}
+		case <-timer.C:
+			if len(batch) > 0 {
+				p.sendBatch(batch)
+				batch = batch[:0]
+			}
+			timer.Reset(p.flushDur)
+		}
+	}
+}
+
+// sendBatch inserts a batch of events, rows that cannot be converted or inserted are dead-lettered
+func (p *SQLProducer) sendBatch(batch []map[string]interface{}) {
+	start := time.Now()
+	defer func() { p.latency.Observe(time.Since(start)) }()
+
+	rows := make([][]interface{}, 0, len(batch))
+	events := make([]map[string]interface{}, 0, len(batch))
+	for _, event := range batch {
+		values, err := SQLRowValues(event, p.columns)
+		if err != nil {
+			p.failRow(event, err)
+			continue
+		}
+		rows = append(rows, values)
+		events = append(events, event)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	err := p.insertTx(rows)
+	if err == nil {
+		atomic.AddUint64(&p.insertedOK, uint64(len(rows)))
+		return
+	}
+	logger.Warn("[SQLProducer] batch insert failed, retrying row by row", "table", p.table, "batch_size", len(rows), "error", err)
+	p.insertRows(rows, events)
+}
+
+// insertTx inserts the rows in one transaction, all or none
+func (p *SQLProducer) insertTx(rows [][]interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, p.insertSQL)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, values := range rows {
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// insertRows inserts the rows one at a time so a failing row does not hold back the others
+func (p *SQLProducer) insertRows(rows [][]interface{}, events []map[string]interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stmt, err := p.db.PrepareContext(ctx, p.insertSQL)
+	if err != nil {
+		for _, event := range events {
+			p.failRow(event, err)
+		}
+		return
+	}
+	defer stmt.Close()
+	for i, values := range rows {
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			p.failRow(events[i], err)
+			continue
+		}
+		atomic.AddUint64(&p.insertedOK, 1)
+	}
+}
+
+// failRow hands the event of a failed row to the dead letter queue, or to the error log without one
+func (p *SQLProducer) failRow(event map[string]interface{}, err error) {
+	atomic.AddUint64(&p.failedRows, 1)
+	if p.deadLetter != nil {
+		p.deadLetter(event, err)
+		return
+	}
+	logger.Error("[SQLProducer] failed to insert row", "table", p.table, "error", err, ErrorLogContextKey, NewErrorLogContext(event))
+}
+
+// Stats returns the rows inserted and the rows dead-lettered
+func (p *SQLProducer) Stats() (inserted, failed uint64) {
+	return atomic.LoadUint64(&p.insertedOK), atomic.LoadUint64(&p.failedRows)
+}
+
+// Close stops the producer once the pending batch is flushed and closes its connection pool
+// Note: We don't close MsgChan here because it's owned by the caller
+func (p *SQLProducer) Close() {
+	if p.stopChan != nil {
+		close(p.stopChan)
+		<-p.done
+	}
+	_ = p.db.Close()
+}
+
+// TestSQLConnection opens a connection and pings the database
+func TestSQLConnection(driver, dsn string) error {
+	db, err := OpenSQL(driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return db.PingContext(ctx)
+}
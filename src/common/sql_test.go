@@ -0,0 +1,178 @@
+package common
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingDriver is a database/sql driver recording statements and executions,
+// failing the rows whose first value is "reject"
+type recordingDriver struct {
+	mu        sync.Mutex
+	prepared  []string
+	execs     [][]driver.Value
+	commits   int
+	rollbacks int
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.prepared = append(c.d.prepared, query)
+	return &recordingStmt{d: c.d}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return &recordingTx{d: c.d}, nil }
+
+type recordingTx struct{ d *recordingDriver }
+
+func (tx *recordingTx) Commit() error {
+	tx.d.mu.Lock()
+	defer tx.d.mu.Unlock()
+	tx.d.commits++
+	return nil
+}
+func (tx *recordingTx) Rollback() error {
+	tx.d.mu.Lock()
+	defer tx.d.mu.Unlock()
+	tx.d.rollbacks++
+	return nil
+}
+
+type recordingStmt struct{ d *recordingDriver }
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if args[0] == "reject" {
+		return nil, fmt.Errorf("constraint violation")
+	}
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.execs = append(s.d.execs, args)
+	return driver.RowsAffected(1), nil
+}
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+var sqlTestDriverSeq int
+
+func newRecordingDB(t *testing.T) (*sql.DB, *recordingDriver) {
+	t.Helper()
+	d := &recordingDriver{}
+	sqlTestDriverSeq++
+	name := fmt.Sprintf("hub_sql_test_%d", sqlTestDriverSeq)
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, d
+}
+
+var sqlTestColumns = []SQLColumn{
+	{Name: "user_name", Field: []string{"user"}, Type: SQLColumnText},
+	{Name: "port", Field: []string{"net", "port"}, Type: SQLColumnInt},
+	{Name: "seen_at", Field: []string{"ts"}, Type: SQLColumnTimestamp},
+}
+
+func waitForRows(t *testing.T, producer *SQLProducer, rows uint64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if inserted, failed := producer.Stats(); inserted+failed >= rows {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("rows were not inserted in time")
+}
+
+func TestSQLProducer_BatchedInsert(t *testing.T) {
+	db, d := newRecordingDB(t)
+	msgChan := make(chan map[string]interface{}, 3)
+	producer := NewSQLProducer(db, SQLDriverPostgres, "hub.alerts", sqlTestColumns, msgChan, 3, time.Minute, nil, nil)
+	defer producer.Close()
+
+	msgChan <- map[string]interface{}{"user": "alice", "net": map[string]interface{}{"port": float64(22)}, "ts": "2024-05-01T10:00:00Z"}
+	msgChan <- map[string]interface{}{"user": "bob", "net": map[string]interface{}{"port": "443"}}
+	msgChan <- map[string]interface{}{"user": "carol", "ts": float64(1714557602000)}
+	waitForRows(t, producer, 3)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.prepared) != 1 || d.prepared[0] != `INSERT INTO "hub"."alerts" ("user_name", "port", "seen_at") VALUES ($1, $2, $3)` {
+		t.Fatalf("expected one prepared insert, got %q", d.prepared)
+	}
+	if len(d.execs) != 3 || d.commits != 1 {
+		t.Fatalf("expected 3 rows in one transaction, got %d rows and %d commits", len(d.execs), d.commits)
+	}
+	if d.execs[0][1] != int64(22) || d.execs[1][1] != int64(443) || d.execs[2][1] != nil {
+		t.Fatalf("unexpected port values %v %v %v", d.execs[0][1], d.execs[1][1], d.execs[2][1])
+	}
+	if ts, ok := d.execs[2][2].(time.Time); !ok || ts.Unix() != 1714557602 {
+		t.Fatalf("unexpected timestamp %v", d.execs[2][2])
+	}
+}
+
+func TestSQLProducer_FailedRowsRouted(t *testing.T) {
+	db, d := newRecordingDB(t)
+	msgChan := make(chan map[string]interface{}, 3)
+	var mu sync.Mutex
+	var dead []string
+	deadLetter := func(event map[string]interface{}, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		dead = append(dead, event["user"].(string))
+	}
+	producer := NewSQLProducer(db, SQLDriverMySQL, "alerts", sqlTestColumns, msgChan, 3, time.Minute, deadLetter, nil)
+	defer producer.Close()
+
+	msgChan <- map[string]interface{}{"user": "alice"}
+	msgChan <- map[string]interface{}{"user": "reject"}
+	// Not an integer, fails before reaching the database
+	msgChan <- map[string]interface{}{"user": "bob", "net": map[string]interface{}{"port": "ssh"}}
+	waitForRows(t, producer, 3)
+
+	if inserted, failed := producer.Stats(); inserted != 1 || failed != 2 {
+		t.Fatalf("expected 1 inserted and 2 failed rows, got %d and %d", inserted, failed)
+	}
+	mu.Lock()
+	if len(dead) != 2 || dead[0] != "bob" || dead[1] != "reject" {
+		t.Fatalf("expected bob and reject dead-lettered, got %v", dead)
+	}
+	mu.Unlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.rollbacks != 1 || len(d.execs) != 2 || d.execs[1][0] != "alice" {
+		t.Fatalf("expected a rolled back batch and alice inserted on retry, got %d rollbacks and %v", d.rollbacks, d.execs)
+	}
+}
+
+func TestSQLProducer_CloseFlushesPendingBatch(t *testing.T) {
+	db, d := newRecordingDB(t)
+	msgChan := make(chan map[string]interface{}, 4)
+	producer := NewSQLProducer(db, SQLDriverPostgres, "alerts", sqlTestColumns, msgChan, 10, time.Minute, nil, nil)
+
+	msgChan <- map[string]interface{}{"user": "alice"}
+	msgChan <- map[string]interface{}{"user": "bob"}
+	producer.Close()
+
+	if inserted, _ := producer.Stats(); inserted != 2 {
+		t.Fatalf("expected the pending batch inserted on close, got %d rows", inserted)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.commits != 1 {
+		t.Fatalf("expected one committed batch, got %d", d.commits)
+	}
+}
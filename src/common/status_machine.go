@@ -0,0 +1,91 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaxStatusHistory bounds the transitions a component remembers, the oldest are dropped first
+const MaxStatusHistory = 50
+
+// validStatusTransitions lists the statuses each status may move to. Only error may repeat itself,
+// so a later failure can replace the reason of an earlier one.
+var validStatusTransitions = map[Status][]Status{
+	StatusStopped:  {StatusStarting, StatusError},
+	StatusStarting: {StatusRunning, StatusStopping, StatusStopped, StatusError},
+	StatusRunning:  {StatusStopping, StatusStopped, StatusError, StatusDegraded},
+	StatusStopping: {StatusStopped, StatusError},
+	StatusError:    {StatusStarting, StatusStopping, StatusStopped, StatusError},
+	StatusDegraded: {StatusRunning, StatusStopping, StatusStopped, StatusError},
+}
+
+// ValidStatusTransition reports whether a component may move from one status to another.
+// An empty status is a component that was never started and counts as stopped.
+func ValidStatusTransition(from, to Status) bool {
+	if from == "" {
+		from = StatusStopped
+	}
+	for _, s := range validStatusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusTransition is one status change of a component
+type StatusTransition struct {
+	From   Status    `json:"from"`
+	To     Status    `json:"to"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// StatusMachine serializes the status changes of a component. It guards the component's own status
+// field rather than holding a copy, so code reading the field keeps working, and records the
+// accepted transitions in a bounded history. The zero value is ready to use.
+type StatusMachine struct {
+	mu      sync.Mutex
+	history []StatusTransition // Ring of at most MaxStatusHistory entries
+	next    int
+}
+
+// Transition moves *status to the target status if the move is valid, under the machine lock so two
+// goroutines cannot both make the same move (e.g. starting to running). Invalid moves leave the
+// status unchanged and return an error.
+func (m *StatusMachine) Transition(status *Status, to Status, reason string) (StatusTransition, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := *status
+	if !ValidStatusTransition(from, to) {
+		return StatusTransition{}, fmt.Errorf("invalid status transition from %s to %s", statusOrStopped(from), to)
+	}
+	t := StatusTransition{From: statusOrStopped(from), To: to, At: time.Now(), Reason: reason}
+	*status = to
+
+	if len(m.history) < MaxStatusHistory {
+		m.history = append(m.history, t)
+	} else {
+		m.history[m.next] = t
+		m.next = (m.next + 1) % MaxStatusHistory
+	}
+	return t, nil
+}
+
+// History returns the recorded transitions, oldest first
+func (m *StatusMachine) History() []StatusTransition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make([]StatusTransition, 0, len(m.history))
+	res = append(res, m.history[m.next:]...)
+	return append(res, m.history[:m.next]...)
+}
+
+func statusOrStopped(s Status) Status {
+	if s == "" {
+		return StatusStopped
+	}
+	return s
+}
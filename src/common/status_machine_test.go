@@ -0,0 +1,85 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStatusMachineConcurrentTransitions(t *testing.T) {
+	var m StatusMachine
+	status := StatusStopped
+
+	// Racing goroutines attempt each step of a start; exactly one may make each move
+	for _, to := range []Status{StatusStarting, StatusRunning, StatusStopping, StatusStopped} {
+		var wins int32
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if _, err := m.Transition(&status, to, fmt.Sprintf("worker %d", i)); err == nil {
+					atomic.AddInt32(&wins, 1)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if wins != 1 {
+			t.Fatalf("%d goroutines moved to %s, want exactly 1", wins, to)
+		}
+		if status != to {
+			t.Fatalf("status = %s, want %s", status, to)
+		}
+	}
+
+	history := m.History()
+	if len(history) != 4 {
+		t.Fatalf("history has %d transitions, want 4: %+v", len(history), history)
+	}
+	prev := StatusStopped
+	for i, tr := range history {
+		if tr.From != prev {
+			t.Fatalf("transition %d starts from %s, want %s: %+v", i, tr.From, prev, history)
+		}
+		if i > 0 && tr.At.Before(history[i-1].At) {
+			t.Fatalf("transition %d is older than the previous one", i)
+		}
+		if tr.Reason == "" {
+			t.Fatalf("transition %d lost its reason", i)
+		}
+		prev = tr.To
+	}
+}
+
+func TestStatusMachineRejectsInvalidTransitions(t *testing.T) {
+	var m StatusMachine
+	var status Status // Never started: counts as stopped
+
+	if _, err := m.Transition(&status, StatusRunning, ""); err == nil {
+		t.Fatal("expected stopped -> running to be rejected")
+	}
+	if status != "" || len(m.History()) != 0 {
+		t.Fatalf("a rejected transition must not change anything: status=%q history=%+v", status, m.History())
+	}
+	if _, err := m.Transition(&status, StatusError, "connectivity check failed"); err != nil {
+		t.Fatalf("stopped -> error: %v", err)
+	}
+	if _, err := m.Transition(&status, StatusError, "panic during stop"); err != nil {
+		t.Fatalf("a later failure must be able to replace the reason: %v", err)
+	}
+	if h := m.History(); h[0].From != StatusStopped || h[1].Reason != "panic during stop" {
+		t.Fatalf("unexpected history: %+v", h)
+	}
+
+	// The history keeps the most recent transitions, oldest first
+	for i := 0; i < MaxStatusHistory; i++ {
+		if _, err := m.Transition(&status, StatusError, fmt.Sprintf("failure %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := m.History()
+	if len(h) != MaxStatusHistory || h[0].Reason != "failure 0" || h[len(h)-1].Reason != fmt.Sprintf("failure %d", MaxStatusHistory-1) {
+		t.Fatalf("unexpected bounded history: first=%+v last=%+v len=%d", h[0], h[len(h)-1], len(h))
+	}
+}
@@ -20,6 +20,17 @@ type SystemMetrics struct {
 	MemoryPercent  float64   `json:"memory_percent"`  // Memory usage percentage
 	GoroutineCount int       `json:"goroutine_count"` // Number of goroutines
 	Timestamp      time.Time `json:"timestamp"`
+
+	// Node load and limits, reported with heartbeats
+	RunningProjects int `json:"running_projects"` // Projects running on the node
+	CPUCores        int `json:"cpu_cores"`        // Logical CPUs available to the process
+	PoolMinSize     int `json:"pool_min_size"`    // Ruleset worker pool lower bound
+	PoolMaxSize     int `json:"pool_max_size"`    // Ruleset worker pool upper bound
+
+	// Global engine task guard shared by all rulesets
+	EngineTaskLimit    int    `json:"engine_task_limit"`
+	EngineTasksRunning int64  `json:"engine_tasks_running"`
+	EngineTaskWaits    uint64 `json:"engine_task_waits"` // Times a ruleset waited because the guard was saturated
 }
 
 // SystemDataPoint represents a single system metrics measurement
@@ -443,14 +454,8 @@ func (csm *ClusterSystemManager) GetNodeMetrics(nodeID string) *SystemMetrics {
 
 	if metrics, exists := csm.data[nodeID]; exists {
 		// Return a copy to prevent external modification
-		return &SystemMetrics{
-			NodeID:         metrics.NodeID,
-			CPUPercent:     metrics.CPUPercent,
-			MemoryUsedMB:   metrics.MemoryUsedMB,
-			MemoryPercent:  metrics.MemoryPercent,
-			GoroutineCount: metrics.GoroutineCount,
-			Timestamp:      metrics.Timestamp,
-		}
+		copied := *metrics
+		return &copied
 	}
 
 	return nil
@@ -464,14 +469,8 @@ func (csm *ClusterSystemManager) GetAllMetrics() map[string]*SystemMetrics {
 	result := make(map[string]*SystemMetrics)
 	for nodeID, metrics := range csm.data {
 		// Return copies to prevent external modification
-		result[nodeID] = &SystemMetrics{
-			NodeID:         metrics.NodeID,
-			CPUPercent:     metrics.CPUPercent,
-			MemoryUsedMB:   metrics.MemoryUsedMB,
-			MemoryPercent:  metrics.MemoryPercent,
-			GoroutineCount: metrics.GoroutineCount,
-			Timestamp:      metrics.Timestamp,
-		}
+		copied := *metrics
+		result[nodeID] = &copied
 	}
 
 	return result
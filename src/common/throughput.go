@@ -0,0 +1,162 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// throughputHistory is the number of collections kept per component, over an hour at the 30s
+	// collection interval
+	throughputHistory   = 512
+	MaxThroughputWindow = time.Hour
+	MinThroughputStep   = time.Second
+)
+
+// throughputEntry is the number of messages a component handled between start and end
+type throughputEntry struct {
+	start, end time.Time
+	count      uint64
+}
+
+// throughputRing is a fixed size ring of the latest entries of one component, oldest first
+type throughputRing struct {
+	entries []throughputEntry
+	next    int
+	full    bool
+}
+
+func (r *throughputRing) add(e throughputEntry) {
+	if r.entries == nil {
+		r.entries = make([]throughputEntry, throughputHistory)
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *throughputRing) each(fn func(throughputEntry)) {
+	if r.full {
+		for _, e := range r.entries[r.next:] {
+			fn(e)
+		}
+	}
+	for _, e := range r.entries[:r.next] {
+		fn(e)
+	}
+}
+
+// ThroughputRecorder keeps, in memory, the increments the periodic stats collection reports per
+// component so dashboards can plot a short window without going through the daily stats
+type ThroughputRecorder struct {
+	mu          sync.Mutex
+	rings       map[string]*throughputRing // keyed by component type and id
+	lastCollect time.Time
+	interval    time.Duration // span assumed for the first collection
+}
+
+// ThroughputPoint is the average rate of one step of a series
+type ThroughputPoint struct {
+	Timestamp    time.Time `json:"timestamp"` // start of the step
+	EventsPerSec float64   `json:"events_per_sec"`
+}
+
+func NewThroughputRecorder(interval time.Duration) *ThroughputRecorder {
+	return &ThroughputRecorder{rings: make(map[string]*throughputRing), interval: interval}
+}
+
+func throughputKey(componentType, id string) string {
+	return componentType + "/" + id
+}
+
+// Record stores the increments of one collection made at now. Increments of the same component in
+// several projects or sequences are summed.
+func (t *ThroughputRecorder) Record(now time.Time, stats []DailyStatsData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	start := t.lastCollect
+	if start.IsZero() || !start.Before(now) {
+		start = now.Add(-t.interval)
+	}
+	t.lastCollect = now
+
+	totals := make(map[string]uint64)
+	for _, s := range stats {
+		if s.TotalMessages > 0 {
+			totals[throughputKey(s.ComponentType, s.ComponentID)] += s.TotalMessages
+		}
+	}
+	for key, count := range totals {
+		ring, ok := t.rings[key]
+		if !ok {
+			ring = &throughputRing{}
+			t.rings[key] = ring
+		}
+		ring.add(throughputEntry{start: start, end: now, count: count})
+	}
+}
+
+// Series returns the events per second of a component over the window ending at now, one point per
+// step. The count of each collection is spread evenly over the time it covers, so steps finer than
+// the collection interval show its average rate.
+func (t *ThroughputRecorder) Series(componentType, id string, window, step time.Duration, now time.Time) ([]ThroughputPoint, error) {
+	if window <= 0 || window > MaxThroughputWindow {
+		return nil, fmt.Errorf("window must be between %s and %s", MinThroughputStep, MaxThroughputWindow)
+	}
+	if step < MinThroughputStep || step > window {
+		return nil, fmt.Errorf("resolution must be between %s and the window", MinThroughputStep)
+	}
+	from := now.Add(-window).Truncate(step)
+	steps := int(now.Sub(from)/step) + 1
+	counts := make([]float64, steps)
+
+	t.mu.Lock()
+	if ring, ok := t.rings[throughputKey(componentType, id)]; ok {
+		ring.each(func(e throughputEntry) {
+			span := e.end.Sub(e.start)
+			if span <= 0 || !e.end.After(from) {
+				return
+			}
+			for i := max(0, int(e.start.Sub(from)/step)); i < steps; i++ {
+				stepStart := from.Add(time.Duration(i) * step)
+				if !stepStart.Before(e.end) {
+					break
+				}
+				overlap := minTime(e.end, stepStart.Add(step)).Sub(maxTime(e.start, stepStart))
+				if overlap > 0 {
+					counts[i] += float64(e.count) * float64(overlap) / float64(span)
+				}
+			}
+		})
+	}
+	t.mu.Unlock()
+
+	points := make([]ThroughputPoint, steps)
+	for i := range points {
+		points[i] = ThroughputPoint{
+			Timestamp:    from.Add(time.Duration(i) * step),
+			EventsPerSec: counts[i] / step.Seconds(),
+		}
+	}
+	return points, nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// GlobalThroughput is fed by the daily stats collection
+var GlobalThroughput = NewThroughputRecorder(30 * time.Second)
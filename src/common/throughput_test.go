@@ -0,0 +1,60 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThroughputSeriesReflectsCounterIncrements(t *testing.T) {
+	rec := NewThroughputRecorder(30 * time.Second)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Three 30s collections: 300, 600 and 0 messages for the input, the ruleset only in the second
+	rec.Record(base, nil)
+	rec.Record(base.Add(30*time.Second), []DailyStatsData{
+		{ComponentType: "input", ComponentID: "in", ProjectNodeSequence: "INPUT.in", TotalMessages: 200},
+		{ComponentType: "input", ComponentID: "in", ProjectNodeSequence: "PROJECT.p.INPUT.in", TotalMessages: 100},
+	})
+	rec.Record(base.Add(60*time.Second), []DailyStatsData{
+		{ComponentType: "input", ComponentID: "in", TotalMessages: 600},
+		{ComponentType: "ruleset", ComponentID: "in", TotalMessages: 30},
+	})
+	rec.Record(base.Add(90*time.Second), nil)
+
+	now := base.Add(90 * time.Second)
+	points, err := rec.Series("input", "in", 90*time.Second, 30*time.Second, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{10, 20, 0, 0} // steps starting at 0s, 30s, 60s and 90s
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d: %+v", len(points), len(want), points)
+	}
+	for i, w := range want {
+		if points[i].EventsPerSec != w || !points[i].Timestamp.Equal(base.Add(time.Duration(i)*30*time.Second)) {
+			t.Fatalf("point %d = %+v, want %v events/sec", i, points[i], w)
+		}
+	}
+
+	// Finer steps spread each collection evenly over the time it covers
+	fine, err := rec.Series("input", "in", 90*time.Second, 10*time.Second, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range []float64{10, 10, 10, 20, 20, 20, 0} {
+		if got := fine[i].EventsPerSec; got < w-1e-9 || got > w+1e-9 {
+			t.Fatalf("fine point %d = %v, want %v", i, got, w)
+		}
+	}
+
+	rs, _ := rec.Series("ruleset", "in", 90*time.Second, 30*time.Second, now)
+	if rs[1].EventsPerSec < 1-1e-9 || rs[1].EventsPerSec > 1+1e-9 || rs[0].EventsPerSec != 0 {
+		t.Fatalf("ruleset series mixed with the input of the same id: %+v", rs)
+	}
+	if _, err := rec.Series("input", "in", 2*time.Hour, 10*time.Second, now); err == nil {
+		t.Fatal("expected a window above the retained history to be rejected")
+	}
+	if _, err := rec.Series("input", "in", time.Minute, 2*time.Minute, now); err == nil {
+		t.Fatal("expected a resolution above the window to be rejected")
+	}
+}
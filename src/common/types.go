@@ -13,6 +13,9 @@ const (
 	StatusRunning  Status = "running"
 	StatusStopping Status = "stopping"
 	StatusError    Status = "error"
+	// StatusDegraded is a running component whose output is suspect, e.g. an input failing to parse
+	// much of what it consumes. It keeps processing and returns to running once healthy.
+	StatusDegraded Status = "degraded"
 )
 
 // CheckCoreCache for rule engine
@@ -28,6 +31,7 @@ type HubConfig struct {
 	PprofEnable   bool   `yaml:"pprof_enable"`
 	PprofPort     string `yaml:"pprof_port"`
 	SIMDEnabled   bool   `yaml:"simd_enabled"`
+	LogLevel      string `yaml:"log_level"` // debug, info (default), warn or error
 	ConfigRoot    string
 	Leader        string
 	LocalIP       string
@@ -40,6 +44,66 @@ type HubConfig struct {
 	OIDCAllowedUsers  []string `yaml:"oidc_allowed_users"`
 	OIDCRedirectURI   string   `yaml:"oidc_redirect_uri"`
 	OIDCScope         string   `yaml:"oidc_scope"`
+	// Sample retention configuration
+	SampleRetention SampleRetentionConfig `yaml:"sample_retention"`
+	// OperationRetention bounds the operation history kept per project
+	OperationRetention OperationRetentionConfig `yaml:"operation_retention"`
+	// Encoding of sample data stored in Redis: none (default), gzip or snappy
+	SampleCompression string `yaml:"sample_compression"`
+	// Event fields encrypted with AES-GCM in samples stored in Redis, decrypted when read back
+	SampleEncryption SampleEncryptionConfig `yaml:"sample_encryption"`
+	// Extra field names redacted from event snapshots stored with error logs
+	ErrorLogRedactFields []string `yaml:"error_log_redact_fields"`
+	// Replaces event values that cannot be encoded as JSON in samples and outputs (default "<unserializable TYPE>")
+	JSONPlaceholder string `yaml:"json_placeholder"`
+	// Extra key name regexes whose values are masked in component search results
+	SearchMaskPatterns []string `yaml:"search_mask_patterns"`
+	// Reserved event field carrying the correlation id injected at input ingestion ("-" disables it)
+	CorrelationIDField string `yaml:"correlation_id_field"`
+	// Ruleset size limits: warn above RulesetWarnRules, reject above RulesetMaxRules (0 uses the default)
+	RulesetWarnRules int `yaml:"ruleset_warn_rules"`
+	RulesetMaxRules  int `yaml:"ruleset_max_rules"`
+	// Cap on engine tasks in flight across all rulesets of the node (0 uses the default)
+	MaxEngineTasks int `yaml:"max_engine_tasks"`
+	// Cap on yaegi plugin compilations running at once (0 uses half the cores)
+	MaxPluginCompilations int `yaml:"max_plugin_compilations"`
+	// Scheduled rule activity report compiled by the leader
+	RuleReport RuleReportConfig `yaml:"rule_report"`
+	// Follower heartbeat interval and the missed heartbeats after which the leader marks a node down
+	Heartbeat HeartbeatConfig `yaml:"heartbeat"`
+	// How input and output configs with unknown keys are handled: off (default, ignored), warn or error
+	StrictYAML string `yaml:"strict_yaml"`
+	// Abort startup with a non-zero exit when any local component or project fails to load
+	StrictStartup bool `yaml:"strict_startup"`
+	// Allow POST /cluster/simulate-failure to inject heartbeat loss or a leader failover, for staging only
+	AllowFailureSimulation bool `yaml:"allow_failure_simulation"`
+	// Longest an apply of pending changes holds the cluster-wide apply lock before it expires (0 uses the default)
+	ApplyLockTimeout time.Duration `yaml:"apply_lock_timeout"`
+	// Bulk project restarts start at most ProjectStartConcurrency projects per wave (0 uses the default)
+	// and wait ProjectStartStagger between waves
+	ProjectStartConcurrency int           `yaml:"project_start_concurrency"`
+	ProjectStartStagger     time.Duration `yaml:"project_start_stagger"`
+}
+
+// HeartbeatConfig tunes cluster failure detection, zero values use the defaults
+type HeartbeatConfig struct {
+	Interval        time.Duration `yaml:"interval"`         // Base follower heartbeat interval, default 5s
+	MissedThreshold int           `yaml:"missed_threshold"` // Missed heartbeats before a node is down, default 6
+}
+
+// SampleRetentionConfig bounds sample storage in Redis, globally and per component
+type SampleRetentionConfig struct {
+	MaxSamples      int                              `yaml:"max_samples"`      // Max samples kept per sampler/sequence, default 100
+	MaxAge          time.Duration                    `yaml:"max_age"`          // Max sample age, default 24h
+	CleanupInterval time.Duration                    `yaml:"cleanup_interval"` // Periodic trimming interval, default 1h
+	Components      map[string]SampleRetentionPolicy `yaml:"components"`       // Overrides keyed by sampler name, e.g. "ruleset.test"
+}
+
+// OperationRetentionConfig bounds the operation history of each project in Redis, zero values use the defaults
+type OperationRetentionConfig struct {
+	MaxEntries      int           `yaml:"max_entries"`      // Operations kept per project, default 1000
+	MaxAge          time.Duration `yaml:"max_age"`          // Max operation age, default 31 days
+	CompactInterval time.Duration `yaml:"compact_interval"` // Periodic trimming interval, default 10m
 }
 
 // Operation types for project operations
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -12,6 +13,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/cespare/xxhash/v2"
@@ -224,6 +226,41 @@ func AnyToString(tmp interface{}) string {
 	}
 }
 
+// ParseEventTime reads a timestamp from an RFC 3339 string or a unix time in seconds or
+// milliseconds, as event fields usually hold them
+func ParseEventTime(value interface{}) (time.Time, error) {
+	var unix float64
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is neither RFC 3339 nor a unix time", v)
+		}
+		unix = f
+	case float64:
+		unix = v
+	case int:
+		unix = float64(v)
+	case int64:
+		unix = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		unix = f
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to timestamp", value)
+	}
+	if unix >= 1e12 {
+		return time.UnixMilli(int64(unix)).UTC(), nil
+	}
+	return time.Unix(0, int64(unix*float64(time.Second))).UTC(), nil
+}
+
 // GetCheckData traverses a nested map[string]interface{} using a key path (checkKeyList).
 // Returns the string value and whether it exists.
 // Handles map, slice, JSON string, and URL query string as intermediate nodes.
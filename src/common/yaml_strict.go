@@ -0,0 +1,88 @@
+package common
+
+import (
+	"AgentSmith-HUB/logger"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Modes of strict_yaml: how component configs holding keys their type does not know are handled
+const (
+	StrictYAMLOff   = "" // unknown keys are ignored
+	StrictYAMLWarn  = "warn"
+	StrictYAMLError = "error"
+)
+
+// UnknownYAMLField is a config key that no field of the decoded type matches, typically a typo
+type UnknownYAMLField struct {
+	Line  int    `json:"line"`
+	Field string `json:"field"`
+}
+
+// unknownFieldPattern matches the errors yaml.v3 reports for unknown keys with KnownFields
+var unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (\S+) not found in type \S+$`)
+
+// ParseStrictYAML validates a strict_yaml config value
+func ParseStrictYAML(value string) (string, error) {
+	switch value {
+	case StrictYAMLOff, "off":
+		return StrictYAMLOff, nil
+	case StrictYAMLWarn, StrictYAMLError:
+		return value, nil
+	}
+	return "", fmt.Errorf("unsupported strict_yaml '%s' (valid: off, warn, error)", value)
+}
+
+// StrictYAMLMode returns the configured strict_yaml mode, off when unset or invalid
+func StrictYAMLMode() string {
+	mode, _ := ParseStrictYAML(ReadConfig(func(c *HubConfig) string { return c.StrictYAML }))
+	return mode
+}
+
+// UnknownYAMLFields decodes data into a fresh value of target's type with known fields enforced and
+// returns every key it does not know, nested ones included. Other decode errors are left to the
+// lenient decoding that runs anyway.
+func UnknownYAMLFields(data []byte, target interface{}) []UnknownYAMLField {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var typeErr *yaml.TypeError
+	if err := dec.Decode(target); !errors.As(err, &typeErr) {
+		return nil
+	}
+	var fields []UnknownYAMLField
+	for _, msg := range typeErr.Errors {
+		if m := unknownFieldPattern.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			fields = append(fields, UnknownYAMLField{Line: line, Field: m[2]})
+		}
+	}
+	return fields
+}
+
+// UnknownYAMLFieldError reports the first unknown key in the yaml-line format of config parse
+// errors, nil when there is none
+func UnknownYAMLFieldError(fields []UnknownYAMLField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	f := fields[0]
+	if len(fields) == 1 {
+		return fmt.Errorf("YAML parse error: yaml-line %d: unknown field '%s'", f.Line, f.Field)
+	}
+	return fmt.Errorf("YAML parse error: yaml-line %d: unknown field '%s' (and %d more)", f.Line, f.Field, len(fields)-1)
+}
+
+// WarnUnknownYAMLFields logs the unknown keys of a component config under strict_yaml: warn
+func WarnUnknownYAMLFields(component, id string, data []byte, target interface{}) {
+	if StrictYAMLMode() != StrictYAMLWarn {
+		return
+	}
+	for _, f := range UnknownYAMLFields(data, target) {
+		logger.Warn("Unknown key in component config", "component", component, "id", id, "field", f.Field, "line", f.Line)
+	}
+}
@@ -0,0 +1,63 @@
+package input
+
+import (
+	"strings"
+	"testing"
+)
+
+const bufferTestConfig = `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+`
+
+func TestBufferSettingsValidation(t *testing.T) {
+	for _, extra := range []string{"buffer_size: -1", "buffer_size: 100000", "prefetch: -5", "prefetch: 70000"} {
+		if err := Verify("", bufferTestConfig+extra+"\n"); err == nil {
+			t.Fatalf("expected %q to be rejected", extra)
+		}
+	}
+	// 0 keeps the default
+	for _, extra := range []string{"buffer_size: 0", "prefetch: 0"} {
+		if err := Verify("", bufferTestConfig+extra+"\n"); err != nil {
+			t.Fatalf("expected %q to be accepted, got %v", extra, err)
+		}
+	}
+
+	in, err := NewInput("", bufferTestConfig+"buffer_size: 256\nprefetch: 2048\n", "buffered")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	if got := in.DownstreamBufferSize(512); got != 256 {
+		t.Fatalf("DownstreamBufferSize = %d, want 256", got)
+	}
+	if got := in.prefetchSize(); got != 2048 {
+		t.Fatalf("prefetchSize = %d, want 2048", got)
+	}
+}
+
+func TestBufferStats(t *testing.T) {
+	in, err := NewInput("", bufferTestConfig, "buffer-stats")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	if got := in.prefetchSize(); got != DefaultPrefetch {
+		t.Fatalf("prefetchSize = %d, want default %d", got, DefaultPrefetch)
+	}
+
+	ch := make(chan map[string]interface{}, 4)
+	ch <- map[string]interface{}{}
+	in.DownStream["RULESET.rs"] = &ch
+
+	downstream := in.GetBufferStats()["downstream"].(map[string]interface{})
+	stats := downstream["RULESET.rs"].(map[string]interface{})
+	if stats["len"] != 1 || stats["cap"] != 4 || stats["utilization"] != 0.25 {
+		t.Fatalf("unexpected buffer stats: %v", stats)
+	}
+	if !strings.Contains(Verify("", bufferTestConfig+"buffer_size: -1\n").Error(), "buffer_size") {
+		t.Fatal("expected error to name buffer_size")
+	}
+}
@@ -0,0 +1,227 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	defaultRetryBackoff     = 50 * time.Millisecond
+	defaultDeadLetterMaxLen = 1000
+	maxDeadLetterMaxLen     = 100000
+	maxEnqueueRetries       = 20
+)
+
+// Reasons of dead letter entries
+const (
+	DeadLetterParseFailed    = "parse_failed"
+	DeadLetterDownstreamFull = "downstream_full"
+)
+
+// DeliveryConfig retries events a full downstream channel does not take at once, and routes the
+// messages that cannot be parsed or delivered to the dead letter queue of the input
+type DeliveryConfig struct {
+	EnqueueRetries   int    `yaml:"enqueue_retries,omitempty"`     // Retries of a send to a full downstream (0 blocks until it is accepted)
+	RetryBackoff     string `yaml:"retry_backoff,omitempty"`       // Wait of the first retry, doubled on each retry (default 50ms)
+	DeadLetter       bool   `yaml:"dead_letter,omitempty"`         // Dead-letter unparseable messages and events still rejected after the retries
+	DeadLetterMaxLen int    `yaml:"dead_letter_max_len,omitempty"` // Entries the queue keeps, oldest dropped first (default 1000)
+}
+
+// DeadLetter is one message the input could not parse or deliver
+type DeadLetter struct {
+	Input     string      `json:"input"`
+	Reason    string      `json:"reason"`
+	Error     string      `json:"error,omitempty"`
+	Payload   interface{} `json:"payload"` // the raw message, or the event for delivery failures
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// deliveryStats counts retried and dead-lettered events
+type deliveryStats struct {
+	retried         atomic.Uint64 // events a downstream did not take at the first attempt
+	retrySucceeded  atomic.Uint64 // of those, events accepted during the retries
+	deadParse       atomic.Uint64
+	deadUndelivered atomic.Uint64
+}
+
+// reset zeroes the counters, each on its own as the consumers may still be counting
+func (s *deliveryStats) reset() {
+	s.retried.Store(0)
+	s.retrySucceeded.Store(0)
+	s.deadParse.Store(0)
+	s.deadUndelivered.Store(0)
+}
+
+// deadLetterPush stores an entry in the dead letter queue, replaced in tests
+var deadLetterPush = common.RedisLPush
+
+// DeadLetterKey is the Redis list holding the dead letters of an input, newest first
+func DeadLetterKey(inputID string) string {
+	return "hub:dlq:input:" + inputID
+}
+
+// verifyDelivery validates the delivery section of an input config
+func verifyDelivery(cfg *DeliveryConfig) error {
+	if cfg.EnqueueRetries < 0 || cfg.EnqueueRetries > maxEnqueueRetries {
+		return fmt.Errorf("delivery.enqueue_retries must be between 0 and %d, got %d (line: unknown)", maxEnqueueRetries, cfg.EnqueueRetries)
+	}
+	if cfg.RetryBackoff != "" {
+		d, err := time.ParseDuration(cfg.RetryBackoff)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid delivery.retry_backoff '%s', expected a positive duration like 50ms (line: unknown)", cfg.RetryBackoff)
+		}
+	}
+	if cfg.DeadLetterMaxLen < 0 || cfg.DeadLetterMaxLen > maxDeadLetterMaxLen {
+		return fmt.Errorf("delivery.dead_letter_max_len must be between 0 and %d, got %d (line: unknown)", maxDeadLetterMaxLen, cfg.DeadLetterMaxLen)
+	}
+	return nil
+}
+
+func (in *Input) delivery() *DeliveryConfig {
+	if in.Config == nil {
+		return nil
+	}
+	return in.Config.Delivery
+}
+
+func (in *Input) deadLetterEnabled() bool {
+	d := in.delivery()
+	return d != nil && d.DeadLetter
+}
+
+// send hands msg to ch. Without enqueue_retries it blocks until ch accepts it; otherwise each retry
+// waits up to the backoff for room, doubling it every time, and false is returned once the retries
+// are exhausted and the event was dead-lettered instead. Without dead_letter the send then blocks,
+// so an event is never lost.
+func (in *Input) send(ch chan map[string]interface{}, msg map[string]interface{}) bool {
+	return in.sendUntil(ch, msg, nil)
+}
+
+// sendUntil is send giving up, and returning false, once quit is closed. A nil quit never is.
+func (in *Input) sendUntil(ch chan map[string]interface{}, msg map[string]interface{}, quit <-chan struct{}) bool {
+	d := in.delivery()
+	if d == nil || d.EnqueueRetries == 0 {
+		select {
+		case ch <- msg:
+			return true
+		case <-quit:
+			return false
+		}
+	}
+	select {
+	case ch <- msg:
+		return true
+	default:
+	}
+
+	in.deliveryStats.retried.Add(1)
+	backoff := defaultRetryBackoff
+	if b, err := time.ParseDuration(d.RetryBackoff); err == nil && b > 0 {
+		backoff = b
+	}
+	for i := 0; i < d.EnqueueRetries; i++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case ch <- msg:
+			timer.Stop()
+			in.deliveryStats.retrySucceeded.Add(1)
+			return true
+		case <-timer.C:
+		case <-quit:
+			timer.Stop()
+			return false
+		}
+		backoff *= 2
+	}
+
+	if !d.DeadLetter {
+		logger.Warn("Downstream still full after enqueue retries, blocking", "input", in.Id, "retries", d.EnqueueRetries)
+		select {
+		case ch <- msg:
+			return true
+		case <-quit:
+			return false
+		}
+	}
+	in.deliveryStats.deadUndelivered.Add(1)
+	in.deadLetter(DeadLetterDownstreamFull, fmt.Errorf("downstream channel full after %d retries", d.EnqueueRetries), msg)
+	return false
+}
+
+// deadLetter stores a message in the dead letter queue. When the queue cannot be written the
+// message goes to the error log, so it is still never dropped silently.
+func (in *Input) deadLetter(reason string, cause error, payload interface{}) {
+	entry := DeadLetter{Input: in.Id, Reason: reason, Payload: payload, Timestamp: time.Now()}
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+	maxLen := defaultDeadLetterMaxLen
+	if d := in.delivery(); d != nil && d.DeadLetterMaxLen > 0 {
+		maxLen = d.DeadLetterMaxLen
+	}
+
+	data, err := common.SafeMarshal(entry)
+	if err == nil {
+		err = deadLetterPush(DeadLetterKey(in.Id), string(data), int64(maxLen))
+	}
+	if err != nil {
+		logger.Error("Failed to write dead letter", "input", in.Id, "reason", reason, "cause", entry.Error, "error", err,
+			common.ErrorLogContextKey, common.NewErrorLogContext(map[string]interface{}{"reason": reason, "payload": payload}))
+	}
+}
+
+// deadLetterDecoder wraps decode so that messages failing to parse, or decoding to a parse error
+// event, are dead-lettered. They are then reported as errors, which makes the consumers skip them.
+func (in *Input) deadLetterDecoder(decode common.MessageDecoder) common.MessageDecoder {
+	return func(raw []byte) (map[string]interface{}, error) {
+		event, err := decode(raw)
+		if err == nil {
+			reason, failed := event[common.ParseErrorField]
+			if !failed {
+				return event, nil
+			}
+			err = fmt.Errorf("%v", reason)
+		}
+		in.deliveryStats.deadParse.Add(1)
+		in.deadLetter(DeadLetterParseFailed, err, string(raw))
+		return nil, fmt.Errorf("message dead-lettered: %w", err)
+	}
+}
+
+// GetDeliveryStats returns the enqueue retry and dead letter counts, nil without a delivery section
+func (in *Input) GetDeliveryStats() map[string]interface{} {
+	d := in.delivery()
+	if d == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"enqueue_retries":        d.EnqueueRetries,
+		"dead_letter":            d.DeadLetter,
+		"retried":                in.deliveryStats.retried.Load(),
+		"retry_succeeded":        in.deliveryStats.retrySucceeded.Load(),
+		"dead_lettered_parse":    in.deliveryStats.deadParse.Load(),
+		"dead_lettered_delivery": in.deliveryStats.deadUndelivered.Load(),
+	}
+}
+
+// DeadLetters returns the newest dead letters of an input, up to limit
+func DeadLetters(inputID string, limit int) ([]DeadLetter, error) {
+	items, err := common.RedisLRange(DeadLetterKey(inputID), 0, int64(limit)-1)
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]DeadLetter, 0, len(items))
+	for _, item := range items {
+		var letter DeadLetter
+		if err := sonic.Unmarshal([]byte(item), &letter); err != nil {
+			continue
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
@@ -0,0 +1,89 @@
+package input
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestDeliveryRetriesFullDownstreamAndDeadLetters(t *testing.T) {
+	var mu sync.Mutex
+	var letters []DeadLetter
+	prevPush := deadLetterPush
+	t.Cleanup(func() { deadLetterPush = prevPush })
+	deadLetterPush = func(key string, value interface{}, maxLen int64) error {
+		var letter DeadLetter
+		if err := sonic.Unmarshal([]byte(value.(string)), &letter); err != nil {
+			t.Errorf("invalid dead letter %v: %v", value, err)
+		}
+		if key != DeadLetterKey("delivery-input") || maxLen != 50 {
+			t.Errorf("unexpected key %s or max length %d", key, maxLen)
+		}
+		mu.Lock()
+		letters = append(letters, letter)
+		mu.Unlock()
+		return nil
+	}
+
+	raw := staticFieldsInput + "delivery:\n  enqueue_retries: 3\n  retry_backoff: 20ms\n  dead_letter: true\n  dead_letter_max_len: 50\n"
+	in, err := NewInput("", raw, "delivery-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	ch := make(chan map[string]interface{}, 1)
+	in.DownStream = map[string]*chan map[string]interface{}{"out": &ch}
+
+	// The channel is full for a moment: the event goes through on a retry
+	ch <- map[string]interface{}{"n": 0}
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		<-ch
+	}()
+	if undelivered := in.forward(map[string]interface{}{"n": 1}); undelivered != 0 {
+		t.Fatalf("expected the event delivered on retry, %d copies undelivered", undelivered)
+	}
+	if got := <-ch; got["n"] != 1 {
+		t.Fatalf("unexpected event %v", got)
+	}
+	stats := in.GetDeliveryStats()
+	if stats["retried"] != uint64(1) || stats["retry_succeeded"] != uint64(1) || stats["dead_lettered_delivery"] != uint64(0) {
+		t.Fatalf("unexpected stats %v", stats)
+	}
+
+	// A message that cannot be decoded is dead-lettered with its payload
+	if _, err := in.sourceDecoder()([]byte(`user=alice action=login`)); err == nil {
+		t.Fatal("expected the consumers to skip a dead-lettered message")
+	}
+	if len(letters) != 1 || letters[0].Reason != DeadLetterParseFailed || letters[0].Payload != "user=alice action=login" || letters[0].Error == "" {
+		t.Fatalf("unexpected dead letters %+v", letters)
+	}
+
+	// A downstream full through every retry gets the event dead-lettered instead of blocking
+	ch <- map[string]interface{}{"n": 2}
+	if undelivered := in.forward(map[string]interface{}{"n": 3}); undelivered != 1 {
+		t.Fatalf("expected one undelivered copy, got %d", undelivered)
+	}
+	if len(letters) != 2 || letters[1].Reason != DeadLetterDownstreamFull {
+		t.Fatalf("unexpected dead letters %+v", letters)
+	}
+	stats = in.GetDeliveryStats()
+	if stats["dead_lettered_parse"] != uint64(1) || stats["dead_lettered_delivery"] != uint64(1) {
+		t.Fatalf("unexpected stats %v", stats)
+	}
+}
+
+func TestDeliveryVerify(t *testing.T) {
+	for _, section := range []string{
+		"delivery:\n  enqueue_retries: -1\n",
+		"delivery:\n  enqueue_retries: 100\n",
+		"delivery:\n  retry_backoff: soon\n",
+		"delivery:\n  dead_letter_max_len: -5\n",
+	} {
+		if _, err := NewInput("", staticFieldsInput+section, "delivery-input"); err == nil || !strings.Contains(err.Error(), "delivery.") {
+			t.Fatalf("expected a delivery error for %q, got %v", section, err)
+		}
+	}
+}
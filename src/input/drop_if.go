@@ -0,0 +1,93 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// DropIfConfig drops the events whose field matches, before they are forwarded to any downstream.
+// It is meant for cheap filtering at the edge, e.g. health-check noise.
+type DropIfConfig struct {
+	Field string `yaml:"field"`
+	// Type is how the field is compared with Match: EQU (default), INCL, START or END
+	Type  string `yaml:"type,omitempty"`
+	Match string `yaml:"match"`
+
+	fieldList []string // parsed Field path, set by NewInput
+}
+
+// Comparisons of drop_if, named like the ruleset check types
+const (
+	DropIfEqual    = "EQU"
+	DropIfContains = "INCL"
+	DropIfStart    = "START"
+	DropIfEnd      = "END"
+)
+
+func verifyDropIf(cfg *DropIfConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if strings.TrimSpace(cfg.Field) == "" {
+		return fmt.Errorf("drop_if field cannot be empty (line: unknown)")
+	}
+	switch cfg.Type {
+	case "", DropIfEqual, DropIfContains, DropIfStart, DropIfEnd:
+	default:
+		return fmt.Errorf("drop_if type must be %s, %s, %s or %s, got '%s' (line: unknown)", DropIfEqual, DropIfContains, DropIfStart, DropIfEnd, cfg.Type)
+	}
+	if cfg.Match == "" && cfg.Type != "" && cfg.Type != DropIfEqual {
+		return fmt.Errorf("drop_if match cannot be empty for type %s (line: unknown)", cfg.Type)
+	}
+	return nil
+}
+
+// dropped reports whether the event matches drop_if, counting the drop
+func (in *Input) dropped(event map[string]interface{}) bool {
+	if !in.matchesDropIf(event) {
+		return false
+	}
+	atomic.AddUint64(&in.droppedTotal, 1)
+	return true
+}
+
+// matchesDropIf compares the drop_if field of the event. A missing field never matches.
+func (in *Input) matchesDropIf(event map[string]interface{}) bool {
+	if in.Config == nil || in.Config.DropIf == nil {
+		return false
+	}
+	cfg := in.Config.DropIf
+	value, exist := common.GetCheckData(event, cfg.fieldList)
+	if !exist {
+		return false
+	}
+	switch cfg.Type {
+	case DropIfContains:
+		return strings.Contains(value, cfg.Match)
+	case DropIfStart:
+		return strings.HasPrefix(value, cfg.Match)
+	case DropIfEnd:
+		return strings.HasSuffix(value, cfg.Match)
+	default:
+		return value == cfg.Match
+	}
+}
+
+// GetDropIfStats returns the drop_if condition and the count of dropped events, nil without drop_if
+func (in *Input) GetDropIfStats() map[string]interface{} {
+	if in.Config == nil || in.Config.DropIf == nil {
+		return nil
+	}
+	cfgType := in.Config.DropIf.Type
+	if cfgType == "" {
+		cfgType = DropIfEqual
+	}
+	return map[string]interface{}{
+		"field":   in.Config.DropIf.Field,
+		"type":    cfgType,
+		"match":   in.Config.DropIf.Match,
+		"dropped": atomic.LoadUint64(&in.droppedTotal),
+	}
+}
@@ -0,0 +1,63 @@
+package input
+
+import (
+	"strings"
+	"testing"
+)
+
+const dropIfInput = `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+drop_if:
+  field: request.path
+  type: START
+  match: /health
+`
+
+func TestDropIfEventsNeverReachDownstream(t *testing.T) {
+	in, err := NewInput("", dropIfInput, "drop-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	ruleset := make(chan map[string]interface{}, 4)
+	in.DownStream = map[string]*chan map[string]interface{}{"RULESET.web": &ruleset}
+
+	in.ProcessTestData(map[string]interface{}{"request": map[string]interface{}{"path": "/healthz"}})
+	in.ProcessTestData(map[string]interface{}{"request": map[string]interface{}{"path": "/login"}})
+	in.ProcessTestData(map[string]interface{}{"request": map[string]interface{}{"path": "/health/ready"}})
+	in.ProcessTestData(map[string]interface{}{"user": "alice"})
+
+	if len(ruleset) != 2 {
+		t.Fatalf("expected 2 events forwarded, got %d", len(ruleset))
+	}
+	for len(ruleset) > 0 {
+		event := <-ruleset
+		if req, ok := event["request"].(map[string]interface{}); ok && strings.HasPrefix(req["path"].(string), "/health") {
+			t.Fatalf("a health check reached the ruleset: %v", event)
+		}
+	}
+	if dropped := in.GetDropIfStats()["dropped"]; dropped != uint64(2) {
+		t.Fatalf("expected 2 dropped events, got %v", dropped)
+	}
+
+	res, err := in.ParseMessage([]byte(`{"request": {"path": "/health"}}`))
+	if err != nil || !res.Dropped {
+		t.Fatalf("expected the parse preview to report the drop, got %+v, %v", res, err)
+	}
+}
+
+func TestDropIfValidation(t *testing.T) {
+	for extra, want := range map[string]string{
+		"drop_if:\n  match: x\n":                "drop_if field cannot be empty",
+		"drop_if:\n  field: a\n  type: REGEX\n": "drop_if type must be",
+		"drop_if:\n  field: a\n  type: INCL\n":  "drop_if match cannot be empty",
+	} {
+		if err := Verify("", bufferTestConfig+extra); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q for %q, got %v", want, extra, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package input
+
+import (
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// broadcaster is the queue of the broadcast goroutine and the signal of its exit
+type broadcaster struct {
+	events chan map[string]interface{}
+	done   chan struct{}
+	// quit is closed when the input gives up on a clean stop; the goroutine and every send blocked
+	// on the queue or a downstream then return
+	quit     chan struct{}
+	quitOnce sync.Once
+	// mu orders the enqueues against the close of events, so a late send never hits a closed channel
+	mu     sync.RWMutex
+	closed bool
+}
+
+// enqueue hands msg to the broadcast goroutine, returning false when the queue is closed, when the
+// event was dead-lettered or when the broadcast was aborted
+func (b *broadcaster) enqueue(in *Input, msg map[string]interface{}) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return false
+	}
+	return in.sendUntil(b.events, msg, b.quit)
+}
+
+// aborted reports whether quit has been closed
+func aborted(quit <-chan struct{}) bool {
+	select {
+	case <-quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the queue; the goroutine exits once it has delivered what is left
+func (b *broadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.events)
+	}
+}
+
+// abort stops the goroutine without delivering the queued events. The blocked enqueues return
+// first, so closing the queue cannot wait on them.
+func (b *broadcaster) abort() {
+	b.quitOnce.Do(func() { close(b.quit) })
+	b.close()
+}
+
+// Fan-out modes of an input feeding several rulesets
+const (
+	// FanoutDirect sends the same event to each downstream in turn from the consumer goroutine
+	FanoutDirect = "direct"
+	// FanoutBroadcast hands each event to one broadcast goroutine, which delivers it to every downstream
+	FanoutBroadcast = "broadcast"
+)
+
+func verifyFanout(mode string) error {
+	switch mode {
+	case "", FanoutDirect, FanoutBroadcast:
+		return nil
+	}
+	return fmt.Errorf("fanout must be '%s' or '%s', got '%s' (line: unknown)", FanoutDirect, FanoutBroadcast, mode)
+}
+
+// startBroadcast starts the broadcast goroutine when fanout is broadcast and there is more than one
+// downstream. The consumer then only enqueues events, and the goroutine delivers them.
+func (in *Input) startBroadcast() {
+	if in.Config == nil || in.Config.Fanout != FanoutBroadcast || len(in.DownStream) < 2 {
+		return
+	}
+	downstream := make([]chan map[string]interface{}, 0, len(in.DownStream))
+	for _, ch := range in.DownStream {
+		downstream = append(downstream, *ch)
+	}
+	b := &broadcaster{
+		events: make(chan map[string]interface{}, in.prefetchSize()),
+		done:   make(chan struct{}),
+		quit:   make(chan struct{}),
+	}
+	go func() {
+		defer close(b.done)
+		for {
+			select {
+			case msg, ok := <-b.events:
+				if !ok {
+					return
+				}
+				in.broadcastUntil(downstream, msg, b.quit)
+			case <-b.quit:
+				return
+			}
+		}
+	}()
+	in.fanout.Store(b)
+	logger.Info("Input broadcasting to downstream", "input", in.Id, "downstream_count", len(downstream))
+}
+
+// stopBroadcast delivers the events still queued for broadcast and stops its goroutine. It must be
+// called once the consumer goroutines have exited. When the queue does not drain in time the
+// broadcast is aborted, so the goroutine does not outlive the input.
+func (in *Input) stopBroadcast(timeout time.Duration) error {
+	b := in.fanout.Swap(nil)
+	if b == nil {
+		return nil
+	}
+	b.close()
+	select {
+	case <-b.done:
+		return nil
+	case <-time.After(timeout):
+		in.abortBroadcast(b)
+		return fmt.Errorf("timeout waiting for broadcast to drain")
+	}
+}
+
+// abortBroadcast stops the broadcast goroutine while consumers may still be sending to it, dropping
+// the events it has not delivered yet
+func (in *Input) abortBroadcast(b *broadcaster) {
+	if b == nil {
+		return
+	}
+	if queued := len(b.events); queued > 0 {
+		logger.Warn("Aborting broadcast with undelivered events", "input", in.Id, "queued", queued)
+	}
+	b.abort()
+}
+
+// forward sends an event to every downstream, blocking until each has accepted it (or, in broadcast
+// mode, until the broadcast goroutine has) so that no data is lost. With delivery.enqueue_retries and
+// dead_letter a downstream still full after the retries gets the event dead-lettered instead; the
+// returned count of such copies lets the caller release them.
+func (in *Input) forward(msg map[string]interface{}) (undelivered int) {
+	if b := in.fanout.Load(); b != nil {
+		if !b.enqueue(in, msg) {
+			if aborted(b.quit) {
+				// Left unreleased like the copies the aborted goroutine drops
+				return 0
+			}
+			return len(in.DownStream)
+		}
+		return 0
+	}
+	for _, ch := range in.DownStream {
+		if !in.send(*ch, msg) {
+			undelivered++
+		}
+	}
+	return undelivered
+}
+
+// broadcast delivers msg to every downstream. The event is not copied per downstream: as in direct
+// mode they all share it, and only a rule that modifies it works on its own copy. Downstreams with
+// room are served first, so one full channel does not hold back the rest; the others then go through
+// the enqueue retries and dead letter queue of the input, like a direct send.
+func (in *Input) broadcast(downstream []chan map[string]interface{}, msg map[string]interface{}) {
+	in.broadcastUntil(downstream, msg, nil)
+}
+
+// broadcastUntil is broadcast with the sends to full downstreams giving up once quit is closed
+func (in *Input) broadcastUntil(downstream []chan map[string]interface{}, msg map[string]interface{}, quit <-chan struct{}) {
+	var pending []chan map[string]interface{}
+	for _, ch := range downstream {
+		select {
+		case ch <- msg:
+		default:
+			pending = append(pending, ch)
+		}
+	}
+	for _, ch := range pending {
+		if in.sendUntil(ch, msg, quit) {
+			continue
+		}
+		if aborted(quit) {
+			// The input is going away; the copy stays unreleased, so its offset is not committed
+			return
+		}
+		if in.kafkaTxn != nil {
+			// A dead-lettered copy never reaches its downstream to be released there
+			in.kafkaTxn.Done()
+		}
+	}
+}
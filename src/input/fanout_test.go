@@ -0,0 +1,159 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestBroadcastDeliversToEveryDownstream(t *testing.T) {
+	downstream := make([]chan map[string]interface{}, 3)
+	for i := range downstream {
+		downstream[i] = make(chan map[string]interface{}, 1)
+	}
+	// A full channel is served after the others
+	downstream[0] <- map[string]interface{}{}
+	go func() { <-downstream[0] }()
+
+	msg := map[string]interface{}{"user": "admin", "process": map[string]interface{}{"name": "sh"}}
+	(&Input{}).broadcast(downstream, msg)
+	events := make([]map[string]interface{}, len(downstream))
+	for i, ch := range downstream {
+		events[i] = <-ch
+	}
+	for i, event := range events {
+		if event["user"] != "admin" {
+			t.Fatalf("downstream %d got %v", i, event)
+		}
+	}
+}
+
+func TestBroadcastDeadLettersFullDownstream(t *testing.T) {
+	var letters []DeadLetter
+	prevPush := deadLetterPush
+	t.Cleanup(func() { deadLetterPush = prevPush })
+	deadLetterPush = func(key string, value interface{}, maxLen int64) error {
+		var letter DeadLetter
+		if err := sonic.Unmarshal([]byte(value.(string)), &letter); err != nil {
+			t.Errorf("invalid dead letter %v: %v", value, err)
+		}
+		letters = append(letters, letter)
+		return nil
+	}
+
+	in := &Input{Id: "broadcast-input", Config: &InputConfig{Delivery: &DeliveryConfig{EnqueueRetries: 1, RetryBackoff: "10ms", DeadLetter: true}}}
+	full := make(chan map[string]interface{}, 1)
+	full <- map[string]interface{}{}
+	open := make(chan map[string]interface{}, 1)
+	in.broadcast([]chan map[string]interface{}{full, open}, map[string]interface{}{"user": "admin"})
+
+	if got := <-open; got["user"] != "admin" {
+		t.Fatalf("the downstream with room must get the event, got %v", got)
+	}
+	if len(letters) != 1 || letters[0].Reason != DeadLetterDownstreamFull {
+		t.Fatalf("expected the copy for the full downstream dead-lettered, got %+v", letters)
+	}
+	if stats := in.GetDeliveryStats(); stats["retried"] != uint64(1) || stats["dead_lettered_delivery"] != uint64(1) {
+		t.Fatalf("unexpected stats %v", stats)
+	}
+}
+
+func TestStopBroadcastAbortsStuckGoroutine(t *testing.T) {
+	// Neither downstream is read, so the goroutine blocks on the first event and the queue fills
+	a, b := make(chan map[string]interface{}), make(chan map[string]interface{})
+	in := &Input{
+		Id:         "broadcast-input",
+		Config:     &InputConfig{Fanout: FanoutBroadcast, Prefetch: 1},
+		DownStream: map[string]*chan map[string]interface{}{"a": &a, "b": &b},
+	}
+	in.startBroadcast()
+	bc := in.fanout.Load()
+	in.forward(map[string]interface{}{"n": 1})
+	in.forward(map[string]interface{}{"n": 2})
+	blocked := make(chan int, 1)
+	go func() { blocked <- in.forward(map[string]interface{}{"n": 3}) }()
+
+	// Left in place, so the third forward meets the aborted broadcast whenever it runs
+	in.abortBroadcast(bc)
+	select {
+	case <-bc.done:
+	case <-time.After(time.Second):
+		t.Fatal("the aborted broadcast goroutine did not exit")
+	}
+	select {
+	case undelivered := <-blocked:
+		if undelivered != 0 {
+			t.Fatalf("an aborted enqueue must leave its copies unreleased, got %d", undelivered)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the enqueue blocked on the aborted broadcast did not return")
+	}
+	if err := in.stopBroadcast(time.Millisecond); err != nil {
+		t.Fatalf("stopping an aborted broadcast must not wait on it, got %v", err)
+	}
+}
+
+func TestFanoutValidation(t *testing.T) {
+	if err := Verify("", bufferTestConfig+"fanout: broadcast\n"); err != nil {
+		t.Fatalf("expected broadcast to be accepted: %v", err)
+	}
+	if err := Verify("", bufferTestConfig+"fanout: multicast\n"); err == nil || !strings.Contains(err.Error(), "fanout") {
+		t.Fatalf("expected an unknown fanout to be rejected, got %v", err)
+	}
+}
+
+// benchmarkFanout forwards events of one input to the drained channels of a 10-ruleset project
+func benchmarkFanout(b *testing.B, send func(downstream []chan map[string]interface{}, msg map[string]interface{})) {
+	downstream := make([]chan map[string]interface{}, 10)
+	done := make(chan struct{})
+	for i := range downstream {
+		downstream[i] = make(chan map[string]interface{}, 1024)
+		go func(ch chan map[string]interface{}) {
+			for range ch {
+			}
+			done <- struct{}{}
+		}(downstream[i])
+	}
+	event := map[string]interface{}{"user": "admin", "action": "login", "src_ip": "10.0.0.1"}
+	for i := 0; i < 20; i++ {
+		event[fmt.Sprintf("field%d", i)] = i
+	}
+	event["process"] = map[string]interface{}{"name": "sh", "args": []interface{}{"-c", "id"}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		send(downstream, event)
+	}
+	b.StopTimer()
+	for _, ch := range downstream {
+		close(ch)
+		<-done
+	}
+}
+
+// BenchmarkFanout_DirectShared is the direct mode: one shared event, no isolation
+func BenchmarkFanout_DirectShared(b *testing.B) {
+	benchmarkFanout(b, func(downstream []chan map[string]interface{}, msg map[string]interface{}) {
+		for _, ch := range downstream {
+			ch <- msg
+		}
+	})
+}
+
+// BenchmarkFanout_DirectDeepCopy isolates the rulesets by deep copying the event for each
+func BenchmarkFanout_DirectDeepCopy(b *testing.B) {
+	benchmarkFanout(b, func(downstream []chan map[string]interface{}, msg map[string]interface{}) {
+		for _, ch := range downstream {
+			ch <- common.MapDeepCopy(msg)
+		}
+	})
+}
+
+func BenchmarkFanout_Broadcast(b *testing.B) {
+	benchmarkFanout(b, (&Input{}).broadcast)
+}
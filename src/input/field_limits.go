@@ -0,0 +1,82 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"sync/atomic"
+)
+
+// MaxFieldLimit caps max_field_depth and max_field_keys
+const MaxFieldLimit = 1000000
+
+// checkFieldLimits walks an event and reports why it exceeds the configured nesting depth or total
+// key count, or "" when it does not. A limit of 0 is unlimited. The walk stops at the first limit
+// exceeded so oversized events cost no more than the limits themselves.
+func checkFieldLimits(event map[string]interface{}, maxDepth, maxKeys int) string {
+	if maxDepth <= 0 && maxKeys <= 0 {
+		return ""
+	}
+	type frame struct {
+		value interface{}
+		depth int
+	}
+	keys := 0
+	stack := []frame{{event, 1}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch v := f.value.(type) {
+		case map[string]interface{}:
+			if maxDepth > 0 && f.depth > maxDepth {
+				return fmt.Sprintf("field depth exceeds max_field_depth %d", maxDepth)
+			}
+			keys += len(v)
+			if maxKeys > 0 && keys > maxKeys {
+				return fmt.Sprintf("key count exceeds max_field_keys %d", maxKeys)
+			}
+			for _, child := range v {
+				stack = append(stack, frame{child, f.depth + 1})
+			}
+		case []interface{}:
+			if maxDepth > 0 && f.depth > maxDepth {
+				return fmt.Sprintf("field depth exceeds max_field_depth %d", maxDepth)
+			}
+			for _, child := range v {
+				stack = append(stack, frame{child, f.depth + 1})
+			}
+		}
+	}
+	return ""
+}
+
+// divertOversized diverts an event exceeding the field limits: it is not forwarded and is recorded
+// in the error log with the event and the reason, where it can be inspected and replayed.
+func (in *Input) divertOversized(event map[string]interface{}) bool {
+	if in.Config == nil {
+		return false
+	}
+	reason := checkFieldLimits(event, in.Config.MaxFieldDepth, in.Config.MaxFieldKeys)
+	if reason == "" {
+		return false
+	}
+	atomic.AddUint64(&in.divertedTotal, 1)
+	logger.Error("Event exceeds input field limits, diverted", "input", in.Id, "reason", reason,
+		common.ErrorLogContextKey, common.NewErrorLogContext(event))
+	return true
+}
+
+// GetFieldLimitStats returns the configured field limits and the count of diverted events
+func (in *Input) GetFieldLimitStats() map[string]interface{} {
+	stats := map[string]interface{}{
+		"max_field_depth": 0,
+		"max_field_keys":  0,
+		"diverted":        atomic.LoadUint64(&in.divertedTotal),
+	}
+	if in.Config != nil {
+		stats["max_field_depth"] = in.Config.MaxFieldDepth
+		stats["max_field_keys"] = in.Config.MaxFieldKeys
+	}
+	return stats
+}
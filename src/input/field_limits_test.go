@@ -0,0 +1,57 @@
+package input
+
+import "testing"
+
+const fieldLimitsInput = `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+max_field_depth: 3
+max_field_keys: 10
+`
+
+func TestFieldLimitsDivertOversizedEvents(t *testing.T) {
+	in, err := NewInput("", fieldLimitsInput, "limits-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	ch := make(chan map[string]interface{}, 4)
+	in.DownStream = map[string]*chan map[string]interface{}{"out": &ch}
+
+	deep := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": []interface{}{"d"}}}}
+	wide := map[string]interface{}{}
+	for _, k := range []string{"k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9", "k10"} {
+		wide[k] = k
+	}
+	normal := map[string]interface{}{"user": "alice", "host": map[string]interface{}{"name": "web-1", "ips": []interface{}{"10.0.0.1"}}}
+
+	in.ProcessTestData(deep)
+	in.ProcessTestData(wide)
+	in.ProcessTestData(normal)
+
+	if len(ch) != 1 {
+		t.Fatalf("expected only the normal event forwarded, got %d events", len(ch))
+	}
+	if got := <-ch; got["user"] != "alice" {
+		t.Fatalf("unexpected forwarded event %v", got)
+	}
+	if diverted := in.GetFieldLimitStats()["diverted"]; diverted != uint64(2) {
+		t.Fatalf("expected 2 diverted events, got %v", diverted)
+	}
+
+	if reason := checkFieldLimits(deep, 3, 0); reason == "" {
+		t.Fatal("expected the depth limit to be reported")
+	}
+	if reason := checkFieldLimits(deep, 4, 0); reason != "" {
+		t.Fatalf("unexpected violation %q", reason)
+	}
+}
+
+func TestFieldLimitsVerify(t *testing.T) {
+	if _, err := NewInput("", staticFieldsInput+"max_field_depth: -1\n", "limits-input"); err == nil {
+		t.Fatal("expected a negative max_field_depth to be rejected")
+	}
+}
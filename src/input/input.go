@@ -4,6 +4,7 @@ import (
 	"AgentSmith-HUB/common"
 	"AgentSmith-HUB/logger"
 	"fmt"
+	"math/rand"
 	"os"
 	"regexp"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/vjeantet/grok"
 	"gopkg.in/yaml.v3"
 )
@@ -27,24 +29,66 @@ const (
 
 // InputConfig is the YAML config for an input.
 type InputConfig struct {
-	Id          string
-	Type        InputType             `yaml:"type"`
-	Kafka       *KafkaInputConfig     `yaml:"kafka,omitempty"`
-	AliyunSLS   *AliyunSLSInputConfig `yaml:"aliyun_sls,omitempty"`
-	GrokPattern string                `yaml:"grok_pattern,omitempty"`
-	GrokField   string                `yaml:"grok_field,omitempty"`
-	RawConfig   string
+	Id        string
+	Type      InputType             `yaml:"type"`
+	Kafka     *KafkaInputConfig     `yaml:"kafka,omitempty"`
+	AliyunSLS *AliyunSLSInputConfig `yaml:"aliyun_sls,omitempty"`
+	// Format is how message payloads decode: json (default) or xml, Kafka inputs only
+	Format      string `yaml:"format,omitempty"`
+	GrokPattern string `yaml:"grok_pattern,omitempty"`
+	GrokField   string `yaml:"grok_field,omitempty"`
+	// SampleRate forwards only this fraction (0.0-1.0) of events downstream, e.g. for canary rulesets.
+	// Unset means every event is forwarded.
+	SampleRate *float64 `yaml:"sample_rate,omitempty"`
+	// BufferSize is the capacity of the channel to each downstream component (0 uses the project default)
+	BufferSize int `yaml:"buffer_size,omitempty"`
+	// Prefetch is how many events pull-based inputs (Kafka/SLS) may receive ahead of processing (0 uses DefaultPrefetch)
+	Prefetch int `yaml:"prefetch,omitempty"`
+	// Fanout is how events reach several downstream rulesets: direct (default) or broadcast, where one
+	// goroutine delivers each event to every downstream
+	Fanout string `yaml:"fanout,omitempty"`
+	// StaticFields are merged into every event at ingestion, e.g. source, environment or tenant metadata.
+	// Keys already in the event are kept unless StaticFieldsOverride is set.
+	StaticFields         map[string]interface{} `yaml:"static_fields,omitempty"`
+	StaticFieldsOverride bool                   `yaml:"static_fields_override,omitempty"`
+	// Rename maps top-level field names to the names rulesets expect (e.g. srcip: source_ip). It is
+	// applied after parsing and before static fields; RenameKeepOriginal keeps the old fields too.
+	Rename             map[string]string `yaml:"rename,omitempty"`
+	RenameKeepOriginal bool              `yaml:"rename_keep_original,omitempty"`
+	// MaxFieldDepth and MaxFieldKeys bound the nesting depth and the total key count of events (0 is
+	// unlimited). Events exceeding them are diverted to the error log instead of being forwarded.
+	MaxFieldDepth int `yaml:"max_field_depth,omitempty"`
+	MaxFieldKeys  int `yaml:"max_field_keys,omitempty"`
+	// DropIf drops matching events before they are forwarded, e.g. health checks
+	DropIf *DropIfConfig `yaml:"drop_if,omitempty"`
+	// ParseErrorThreshold flags the input as degraded while too many messages fail to parse
+	ParseErrorThreshold *ParseErrorThresholdConfig `yaml:"parse_error_threshold,omitempty"`
+	// Delivery retries sends to full downstream channels and dead-letters what cannot be parsed or delivered
+	Delivery  *DeliveryConfig `yaml:"delivery,omitempty"`
+	RawConfig string
 }
 
-// KafkaInputConfig holds Kafka-specific config.
+const (
+	// DefaultPrefetch is the receive buffer of pull-based inputs
+	DefaultPrefetch = 512
+	// MaxBufferSize and MaxPrefetch cap the configurable buffers to keep memory bounded
+	MaxBufferSize = 65536
+	MaxPrefetch   = 65536
+)
+
+// KafkaInputConfig holds Kafka-specific config. One input may consume several topics, listed in
+// topic and topics, or every topic matching topic_pattern; with topics or topic_pattern events carry
+// their topic in _hub_topic.
 type KafkaInputConfig struct {
-	Brokers     []string                    `yaml:"brokers"`
-	Group       string                      `yaml:"group"`
-	Topic       string                      `yaml:"topic"`
-	Compression common.KafkaCompressionType `yaml:"compression,omitempty"`
-	SASL        *common.KafkaSASLConfig     `yaml:"sasl,omitempty"`
-	TLS         *common.KafkaTLSConfig      `yaml:"tls,omitempty"`
-	OffsetReset string                      `yaml:"offset_reset,omitempty"` // earliest, latest, or none
+	Brokers      []string                    `yaml:"brokers"`
+	Group        string                      `yaml:"group"`
+	Topic        string                      `yaml:"topic,omitempty"`
+	Topics       []string                    `yaml:"topics,omitempty"`
+	TopicPattern string                      `yaml:"topic_pattern,omitempty"` // Regex matched against whole topic names
+	Compression  common.KafkaCompressionType `yaml:"compression,omitempty"`
+	SASL         *common.KafkaSASLConfig     `yaml:"sasl,omitempty"`
+	TLS          *common.KafkaTLSConfig      `yaml:"tls,omitempty"`
+	OffsetReset  string                      `yaml:"offset_reset,omitempty"` // earliest, latest, or none
 }
 
 // AliyunSLSInputConfig holds Aliyun SLS-specific config.
@@ -72,13 +116,24 @@ type Input struct {
 	Type                InputType
 	DownStream          map[string]*chan map[string]interface{}
 
+	// statusMachine serializes the writes of Status and keeps their history
+	statusMachine common.StatusMachine
+
 	// runtime
 	kafkaConsumer *common.KafkaConsumer
 	slsConsumer   *common.AliyunSLSConsumer
 
+	// kafkaTxn is set by the project when its outputs are transactional; the consumer then runs as
+	// a transactional session and every event is tracked until its records are produced
+	kafkaTxn        *common.KafkaTxn
+	kafkaTxnSession *kgo.GroupTransactSession
+
 	// internal message channel for monitoring during shutdown
 	internalMsgChan chan map[string]interface{}
 
+	// broadcast fan-out goroutine, nil in direct mode. Swapped while consumers and stats read it.
+	fanout atomic.Pointer[broadcaster]
+
 	// config cache
 	kafkaCfg     *KafkaInputConfig
 	aliyunSLSCfg *AliyunSLSInputConfig
@@ -86,12 +141,27 @@ type Input struct {
 	consumeTotal      uint64
 	lastReportedTotal uint64 // For calculating increments in 10-second intervals
 
+	// sample_rate counters
+	forwardedTotal uint64
+	skippedTotal   uint64
+	// events diverted by max_field_depth/max_field_keys
+	divertedTotal uint64
+	// events dropped by drop_if
+	droppedTotal uint64
+	// parse error rate of the consumed messages, nil without parse_error_threshold
+	parseErrors *parseErrorTracker
+	// enqueue retries and dead letters of the delivery section
+	deliveryStats deliveryStats
+
 	// sampler
 	sampler *common.Sampler
 
 	// raw config
 	Config *InputConfig
 
+	// decode turns message payloads into events according to Config.Format
+	decode common.MessageDecoder
+
 	// grok parser
 	grokParser *grok.Grok
 
@@ -138,11 +208,67 @@ func Verify(path string, raw string) error {
 		}
 	}
 
+	// With strict_yaml: error, keys InputConfig does not know (such as a misspelled brokerz) are rejected
+	if common.StrictYAMLMode() == common.StrictYAMLError {
+		if err := common.UnknownYAMLFieldError(common.UnknownYAMLFields(data, &InputConfig{})); err != nil {
+			return err
+		}
+	}
+
 	// Validate required fields
 	if cfg.Type == "" {
 		return fmt.Errorf("missing required field 'type' (line: unknown)")
 	}
 
+	if cfg.SampleRate != nil && (*cfg.SampleRate < 0 || *cfg.SampleRate > 1) {
+		return fmt.Errorf("sample_rate must be between 0.0 and 1.0, got %v (line: unknown)", *cfg.SampleRate)
+	}
+	if cfg.BufferSize < 0 || cfg.BufferSize > MaxBufferSize {
+		return fmt.Errorf("buffer_size must be between 1 and %d, or 0 for the default, got %d (line: unknown)", MaxBufferSize, cfg.BufferSize)
+	}
+	if cfg.Prefetch < 0 || cfg.Prefetch > MaxPrefetch {
+		return fmt.Errorf("prefetch must be between 1 and %d, or 0 for the default, got %d (line: unknown)", MaxPrefetch, cfg.Prefetch)
+	}
+
+	if err := verifyFanout(cfg.Fanout); err != nil {
+		return err
+	}
+	if err := verifyDropIf(cfg.DropIf); err != nil {
+		return err
+	}
+
+	if cfg.MaxFieldDepth < 0 || cfg.MaxFieldDepth > MaxFieldLimit {
+		return fmt.Errorf("max_field_depth must be between 1 and %d, got %d (line: unknown)", MaxFieldLimit, cfg.MaxFieldDepth)
+	}
+	if cfg.MaxFieldKeys < 0 || cfg.MaxFieldKeys > MaxFieldLimit {
+		return fmt.Errorf("max_field_keys must be between 1 and %d, got %d (line: unknown)", MaxFieldLimit, cfg.MaxFieldKeys)
+	}
+
+	if cfg.ParseErrorThreshold != nil {
+		if err := verifyParseErrorThreshold(cfg.ParseErrorThreshold); err != nil {
+			return err
+		}
+	}
+	if cfg.Delivery != nil {
+		if err := verifyDelivery(cfg.Delivery); err != nil {
+			return err
+		}
+	}
+
+	for key := range cfg.StaticFields {
+		if strings.TrimSpace(key) == "" || key == "_hub_input" {
+			return fmt.Errorf("invalid static_fields key '%s': keys cannot be empty or _hub_input (line: unknown)", key)
+		}
+	}
+
+	if err := verifyRename(cfg.Rename); err != nil {
+		return err
+	}
+
+	if _, err := common.NewMessageDecoder(cfg.Format); err != nil {
+		return fmt.Errorf("invalid format: %v (line: unknown)", err)
+	}
+
 	// Validate type-specific fields
 	switch cfg.Type {
 	case InputTypeKafka, InputTypeKafkaAzure, InputTypeKafkaAWS:
@@ -152,13 +278,16 @@ func Verify(path string, raw string) error {
 		if len(cfg.Kafka.Brokers) == 0 {
 			return fmt.Errorf("missing required field 'kafka.brokers' for kafka input (line: unknown)")
 		}
-		if cfg.Kafka.Topic == "" {
-			return fmt.Errorf("missing required field 'kafka.topic' for kafka input (line: unknown)")
+		if err := verifyKafkaTopics(cfg.Kafka); err != nil {
+			return err
 		}
 	case InputTypeAliyunSLS:
 		if cfg.AliyunSLS == nil {
 			return fmt.Errorf("missing required field 'aliyun_sls' for aliyunSLS input (line: unknown)")
 		}
+		if cfg.Format != "" && cfg.Format != common.MessageFormatJSON {
+			return fmt.Errorf("format '%s' is not supported by aliyun_sls inputs, SLS delivers key-value logs (line: unknown)", cfg.Format)
+		}
 		// Add more AliyunSLS specific field validation
 	default:
 		return fmt.Errorf("unsupported input type: %s (line: unknown)", cfg.Type)
@@ -167,6 +296,11 @@ func Verify(path string, raw string) error {
 	return nil
 }
 
+// UnknownFields returns the keys of an input config that InputConfig does not know, for strict_yaml warnings
+func UnknownFields(raw string) []common.UnknownYAMLField {
+	return common.UnknownYAMLFields([]byte(raw), &InputConfig{})
+}
+
 // NewInput creates an Input from config and downstreams.
 func NewInput(path string, raw string, id string) (*Input, error) {
 	var cfg InputConfig
@@ -184,6 +318,7 @@ func NewInput(path string, raw string, id string) (*Input, error) {
 		_ = yaml.Unmarshal([]byte(raw), &cfg)
 		cfg.RawConfig = raw
 	}
+	common.WarnUnknownYAMLFields("input", id, []byte(cfg.RawConfig), &InputConfig{})
 
 	in := &Input{
 		Id:                  id,
@@ -198,6 +333,13 @@ func NewInput(path string, raw string, id string) (*Input, error) {
 		Status:              common.StatusStopped,
 	}
 
+	// Verify already checked the format
+	in.decode, _ = common.NewMessageDecoder(cfg.Format)
+	if cfg.DropIf != nil {
+		cfg.DropIf.fieldList = common.StringToList(strings.TrimSpace(cfg.DropIf.Field))
+	}
+	in.parseErrors = newParseErrorTracker(cfg.ParseErrorThreshold)
+
 	// Only create sampler on leader node for performance
 	if common.IsLeader {
 		in.sampler = common.GetSampler("input." + id)
@@ -215,32 +357,30 @@ func NewInput(path string, raw string, id string) (*Input, error) {
 	return in, nil
 }
 
+// grokMessage returns the field of data the grok pattern is applied to
+func (in *Input) grokMessage(data map[string]interface{}) (string, bool) {
+	if in.Config.GrokField != "" {
+		msg, ok := data[in.Config.GrokField].(string)
+		return msg, ok
+	}
+	for _, field := range []string{"message", "msg", "log"} {
+		if msg, ok := data[field].(string); ok {
+			return msg, true
+		}
+	}
+	// If no message field found there is nothing to parse
+	return "", false
+}
+
 // parseWithGrok parses the input data using grok pattern if configured
 func (in *Input) parseWithGrok(data map[string]interface{}) map[string]interface{} {
 	if in.grokParser == nil || in.Config.GrokPattern == "" {
 		return data
 	}
 
-	// Try to find a message field to parse
-	var message string
-	if in.Config.GrokField != "" {
-		if msg, ok := data[in.Config.GrokField].(string); ok {
-			message = msg
-		} else {
-			return data
-		}
-	} else {
-		if msg, ok := data["message"].(string); ok {
-			message = msg
-		} else if msg, ok := data["msg"].(string); ok {
-			message = msg
-		} else if msg, ok := data["log"].(string); ok {
-			message = msg
-		} else {
-			// If no message field found, try to parse the entire data as string
-			// This is a fallback for cases where the data might be a raw string
-			return data
-		}
+	message, ok := in.grokMessage(data)
+	if !ok {
+		return data
 	}
 
 	// Parse with grok
@@ -258,15 +398,55 @@ func (in *Input) parseWithGrok(data map[string]interface{}) map[string]interface
 	return data
 }
 
-// SetStatus sets the input status and error information
-func (in *Input) SetStatus(status common.Status, err error) {
+// TransformSample returns a copy of a sampled event with the fields the consumers add before
+// forwarding it: the input ID, the grok captures, the renames and the static fields. Samples are
+// taken before these transforms.
+func (in *Input) TransformSample(data map[string]interface{}) map[string]interface{} {
+	event := common.MapDeepCopy(data)
+	event["_hub_input"] = in.Id
+	event = in.parseWithGrok(event)
+	in.applyRename(event)
+	in.applyStaticFields(event)
+	return event
+}
+
+// applyStaticFields merges the configured static fields into an event. Values are copied so events
+// never share nested maps or slices.
+func (in *Input) applyStaticFields(data map[string]interface{}) {
+	if in.Config == nil {
+		return
+	}
+	for key, value := range in.Config.StaticFields {
+		if _, exists := data[key]; exists && !in.Config.StaticFieldsOverride {
+			continue
+		}
+		data[key] = common.MapDeepCopyAction(value)
+	}
+}
+
+// SetStatus moves the input to a new status, recording the error as the reason of the transition.
+// Invalid transitions are rejected and leave the status unchanged.
+func (in *Input) SetStatus(status common.Status, err error) error {
+	reason := ""
+	if err != nil {
+		reason = err.Error()
+	}
+	t, terr := in.statusMachine.Transition(&in.Status, status, reason)
+	if terr != nil {
+		logger.Warn("Rejected input status transition", "input", in.Id, "status", status, "error", terr)
+		return terr
+	}
 	if err != nil {
 		in.Err = err
 		logger.Error("Input status changed with error", "input", in.Id, "status", status, "error", err)
 	}
-	in.Status = status
-	t := time.Now()
-	in.StatusChangedAt = &t
+	in.StatusChangedAt = &t.At
+	return nil
+}
+
+// StatusHistory returns the recent status transitions of the input, oldest first
+func (in *Input) StatusHistory() []common.StatusTransition {
+	return in.statusMachine.History()
 }
 
 // cleanup performs cleanup when normal stop fails or panic occurs
@@ -287,6 +467,10 @@ func (in *Input) cleanup() {
 		in.kafkaConsumer.Close()
 		in.kafkaConsumer = nil
 	}
+	if in.kafkaTxnSession != nil {
+		in.kafkaTxnSession.Close()
+		in.kafkaTxnSession = nil
+	}
 
 	if in.slsConsumer != nil {
 		if err := in.slsConsumer.Close(); err != nil {
@@ -298,12 +482,21 @@ func (in *Input) cleanup() {
 	// Clear internal message channel reference
 	in.internalMsgChan = nil
 
+	if err := in.stopBroadcast(time.Second); err != nil {
+		logger.Warn("Failed to drain broadcast during cleanup", "input", in.Id, "error", err)
+	}
+
 	// Clear grok parser
 	in.grokParser = nil
 
 	// Reset atomic counter
 	atomic.StoreUint64(&in.consumeTotal, 0)
 	atomic.StoreUint64(&in.lastReportedTotal, 0)
+	atomic.StoreUint64(&in.forwardedTotal, 0)
+	atomic.StoreUint64(&in.skippedTotal, 0)
+	atomic.StoreUint64(&in.divertedTotal, 0)
+	atomic.StoreUint64(&in.droppedTotal, 0)
+	in.deliveryStats.reset()
 
 	// Note: DownStream connections are managed by Project, not cleared here
 	// Project will call SafeDeleteInputDownstream to properly clean up connections
@@ -322,15 +515,15 @@ func (in *Input) Start() error {
 		}
 	}()
 
-	// Allow restart from stopped state or from error state
-	if in.Status != common.StatusStopped && in.Status != common.StatusError {
+	// Allow restart from stopped state or from error state. The transition is atomic, so of two
+	// concurrent starts only one proceeds.
+	if err := in.SetStatus(common.StatusStarting, nil); err != nil {
 		return fmt.Errorf("input %s is not stopped (status: %s)", in.Id, in.Status)
 	}
 
 	// Clear error state when restarting
 	in.Err = nil
 	in.ResetConsumeTotal()
-	in.SetStatus(common.StatusStarting, nil)
 
 	// Initialize stop channel
 	in.stopChan = make(chan struct{})
@@ -343,6 +536,15 @@ func (in *Input) Start() error {
 	}
 	logger.Info("Input connectivity verified", "input", in.Id, "type", in.Type)
 
+	in.startBroadcast()
+	started := false
+	defer func() {
+		// A failed start leaves no consumer, so the broadcast goroutine can go
+		if !started {
+			in.stopBroadcast(time.Second)
+		}
+	}()
+
 	switch in.Type {
 	case InputTypeKafka, InputTypeKafkaAzure, InputTypeKafkaAWS:
 		if in.kafkaConsumer != nil {
@@ -353,22 +555,54 @@ func (in *Input) Start() error {
 			in.SetStatus(common.StatusError, fmt.Errorf("kafka configuration missing for input %s", in.Id))
 			return fmt.Errorf("kafka configuration missing for input %s", in.Id)
 		}
-		msgChan := make(chan map[string]interface{}, 512)
-		cons, err := common.NewKafkaConsumer(
-			in.kafkaCfg.Brokers,
-			in.kafkaCfg.Group,
-			in.kafkaCfg.Topic,
-			in.kafkaCfg.Compression,
-			in.kafkaCfg.SASL,
-			in.kafkaCfg.TLS,
-			in.kafkaCfg.OffsetReset,
-			msgChan,
-		)
-		if err != nil {
-			in.SetStatus(common.StatusError, fmt.Errorf("failed to create kafka consumer for input %s: %v", in.Id, err))
-			return fmt.Errorf("failed to create kafka consumer for input %s: %v", in.Id, err)
+		if err := in.applyPendingOffsetReset(); err != nil {
+			in.SetStatus(common.StatusError, fmt.Errorf("input %s: %w", in.Id, err))
+			return fmt.Errorf("input %s: %w", in.Id, err)
+		}
+		msgChan := make(chan map[string]interface{}, in.prefetchSize())
+		if in.kafkaTxn != nil {
+			session, err := common.NewKafkaTxnSession(
+				in.kafkaCfg.Brokers,
+				in.kafkaCfg.Group,
+				in.kafkaCfg.SubscribedTopics(),
+				in.kafkaCfg.TopicPattern,
+				in.kafkaTxn.TransactionalID,
+				in.kafkaCfg.Compression,
+				in.kafkaCfg.SASL,
+				in.kafkaCfg.TLS,
+				in.kafkaCfg.OffsetReset,
+			)
+			if err != nil {
+				in.SetStatus(common.StatusError, fmt.Errorf("failed to create kafka transactional consumer for input %s: %v", in.Id, err))
+				return fmt.Errorf("failed to create kafka transactional consumer for input %s: %v", in.Id, err)
+			}
+			in.kafkaTxnSession = session
+			txnStop := in.stopChan
+			in.wg.Add(1)
+			go func() {
+				defer in.wg.Done()
+				in.kafkaTxn.Consume(session, in.sourceDecoder(), in.kafkaCfg.tagsTopic(), msgChan, txnStop)
+			}()
+		} else {
+			cons, err := common.NewKafkaConsumer(
+				in.kafkaCfg.Brokers,
+				in.kafkaCfg.Group,
+				in.kafkaCfg.SubscribedTopics(),
+				in.kafkaCfg.TopicPattern,
+				in.kafkaCfg.tagsTopic(),
+				in.kafkaCfg.Compression,
+				in.kafkaCfg.SASL,
+				in.kafkaCfg.TLS,
+				in.kafkaCfg.OffsetReset,
+				in.sourceDecoder(),
+				msgChan,
+			)
+			if err != nil {
+				in.SetStatus(common.StatusError, fmt.Errorf("failed to create kafka consumer for input %s: %v", in.Id, err))
+				return fmt.Errorf("failed to create kafka consumer for input %s: %v", in.Id, err)
+			}
+			in.kafkaConsumer = cons
 		}
-		in.kafkaConsumer = cons
 		in.internalMsgChan = msgChan // Store reference for monitoring during shutdown only after successful creation
 
 		// Start consumer goroutine with proper management
@@ -396,24 +630,64 @@ func (in *Input) Start() error {
 					// Only increment total count - QPS calculation removed
 					atomic.AddUint64(&in.consumeTotal, 1)
 
+					if msg == nil {
+						msg = make(map[string]interface{})
+					}
+					// Tag the event with a correlation id before it is sampled or forwarded
+					common.EnsureCorrelationID(msg)
+
+					// Divert events exceeding max_field_depth/max_field_keys before any further processing
+					if in.divertOversized(msg) {
+						if in.kafkaTxn != nil {
+							in.kafkaTxn.Done()
+						}
+						continue
+					}
+
 					// Sample the message
 					if in.sampler != nil {
 						in.sampler.Sample(msg, in.ProjectNodeSequence)
 					}
 
-					// Add input ID to message data
-					if msg == nil {
-						msg = make(map[string]interface{})
+					// Drop events not selected by sample_rate
+					if !in.shouldForward() {
+						if in.kafkaTxn != nil {
+							in.kafkaTxn.Done()
+						}
+						continue
 					}
+
+					// Add input ID to message data
 					msg["_hub_input"] = in.Id
 
 					// Parse with grok if configured
 					msg = in.parseWithGrok(msg)
+					in.applyRename(msg)
+					in.applyStaticFields(msg)
+
+					// Drop events matching drop_if before they reach any downstream
+					if in.dropped(msg) {
+						if in.kafkaTxn != nil {
+							in.kafkaTxn.Done()
+						}
+						continue
+					}
+
+					// Track every downstream copy before the consumed event is released
+					if in.kafkaTxn != nil {
+						in.kafkaTxn.Track(len(in.DownStream))
+					}
 
 					// Forward to downstream with blocking sends to ensure no data loss
 					// If any downstream channel is full, this will block and prevent further consumption
-					for _, ch := range in.DownStream {
-						*ch <- msg
+					undelivered := in.forward(msg)
+
+					if in.kafkaTxn != nil {
+						// Dead-lettered copies never reach a downstream to be released there
+						for i := 0; i < undelivered; i++ {
+							in.kafkaTxn.Done()
+						}
+						in.kafkaTxn.Done()
 					}
 				}
 			}
@@ -429,7 +703,7 @@ func (in *Input) Start() error {
 			return fmt.Errorf("sls configuration missing for input %s", in.Id)
 		}
 
-		msgChan := make(chan map[string]interface{}, 512)
+		msgChan := make(chan map[string]interface{}, in.prefetchSize())
 		cons, err := common.NewAliyunSLSConsumer(
 			in.aliyunSLSCfg.Endpoint,
 			in.aliyunSLSCfg.AccessKeyID,
@@ -477,25 +751,43 @@ func (in *Input) Start() error {
 
 					atomic.AddUint64(&in.consumeTotal, 1)
 
+					if msg == nil {
+						msg = make(map[string]interface{})
+					}
+					// Tag the event with a correlation id before it is sampled or forwarded
+					common.EnsureCorrelationID(msg)
+
+					// Divert events exceeding max_field_depth/max_field_keys before any further processing
+					if in.divertOversized(msg) {
+						continue
+					}
+
 					// Sample the message
 					if in.sampler != nil {
 						in.sampler.Sample(msg, in.ProjectNodeSequence)
 					}
 
-					// Add input ID to message data
-					if msg == nil {
-						msg = make(map[string]interface{})
+					// Drop events not selected by sample_rate
+					if !in.shouldForward() {
+						continue
 					}
+
+					// Add input ID to message data
 					msg["_hub_input"] = in.Id
 
 					// Parse with grok if configured
 					msg = in.parseWithGrok(msg)
+					in.applyRename(msg)
+					in.applyStaticFields(msg)
+
+					// Drop events matching drop_if before they reach any downstream
+					if in.dropped(msg) {
+						continue
+					}
 
 					// Forward to downstream with blocking sends to ensure no data loss
 					// If any downstream channel is full, this will block and prevent further consumption
-					for _, ch := range in.DownStream {
-						*ch <- msg
-					}
+					in.forward(msg)
 				}
 			}
 		}()
@@ -505,6 +797,7 @@ func (in *Input) Start() error {
 		return fmt.Errorf("unsupported input type %s", in.Type)
 	}
 
+	started = true
 	in.SetStatus(common.StatusRunning, nil)
 	return nil
 }
@@ -529,14 +822,24 @@ func (in *Input) ProcessTestData(data map[string]interface{}) {
 
 	// Skip sampling in testing mode - not needed for test scenarios
 
-	// Add input ID to message data - same as production logic
+	// Add correlation id and input ID to message data - same as production logic
 	if data == nil {
 		data = make(map[string]interface{})
 	}
+	common.EnsureCorrelationID(data)
+	if in.divertOversized(data) {
+		return
+	}
 	data["_hub_input"] = in.Id
 
 	// Parse with grok if configured - same as production logic
 	data = in.parseWithGrok(data)
+	in.applyRename(data)
+	in.applyStaticFields(data)
+
+	if in.dropped(data) {
+		return
+	}
 
 	// Forward to downstream with blocking sends to ensure no data loss
 	// If any downstream channel is full, this will block and prevent further processing
@@ -547,6 +850,55 @@ func (in *Input) ProcessTestData(data map[string]interface{}) {
 	logger.Debug("Test data processed through input", "input", in.Id, "downstream_count", len(in.DownStream))
 }
 
+// ParseResult is the outcome of running one raw message through the input's parsing
+type ParseResult struct {
+	Event       map[string]interface{} `json:"event"`
+	GrokApplied bool                   `json:"grok_applied"` // a grok pattern is configured and its field was found
+	GrokMatched bool                   `json:"grok_matched"` // the pattern captured at least one field
+	GrokError   string                 `json:"grok_error,omitempty"`
+	Dropped     bool                   `json:"dropped,omitempty"` // drop_if matches, consumers would not forward the event
+}
+
+// ParseMessage decodes raw the way the source consumers do (one JSON object or XML document per
+// message, per the input format) and applies the configured grok pattern, renames and static fields,
+// and checks drop_if, without connecting to the source. Decode failures are returned as errors; consumers drop such messages.
+// Malformed XML is not a failure: it decodes to an event holding the payload and the parse error.
+func (in *Input) ParseMessage(raw []byte) (*ParseResult, error) {
+	decode := in.decode
+	if decode == nil {
+		decode, _ = common.NewMessageDecoder("")
+	}
+	event, err := decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize message: %w", err)
+	}
+
+	res := &ParseResult{Event: event}
+	defer func() {
+		in.applyRename(event)
+		in.applyStaticFields(event)
+		res.Dropped = in.matchesDropIf(event)
+	}()
+	if in.grokParser == nil || in.Config.GrokPattern == "" {
+		return res, nil
+	}
+	message, ok := in.grokMessage(event)
+	if !ok {
+		return res, nil
+	}
+	res.GrokApplied = true
+	values, err := in.grokParser.Parse(in.Config.GrokPattern, message)
+	if err != nil {
+		res.GrokError = err.Error()
+		return res, nil
+	}
+	for key, value := range values {
+		event[key] = value
+	}
+	res.GrokMatched = len(values) > 0
+	return res, nil
+}
+
 // StopForTesting stops the input component quickly for testing purposes
 func (in *Input) StopForTesting() error {
 	logger.Info("Stopping test input", "input", in.Id)
@@ -589,7 +941,9 @@ func (in *Input) Stop() error {
 		// For other states (e.g., StatusStarting), proceed with stop to ensure cleanup
 		logger.Debug("Stopping input from non-running state", "input", in.Id, "current_status", in.Status)
 	}
-	in.SetStatus(common.StatusStopping, nil)
+	if err := in.SetStatus(common.StatusStopping, nil); err != nil {
+		return fmt.Errorf("input %s cannot be stopped (status: %s): %w", in.Id, in.Status, err)
+	}
 
 	// Step 1: Stop consumers first to prevent new messages from flowing in
 	logger.Info("Stopping input consumers to prevent new data", "input", in.Id)
@@ -669,12 +1023,18 @@ func (in *Input) Stop() error {
 
 	select {
 	case <-waitDone:
+		// The consumers are gone, deliver what they left for broadcast
+		if err := in.stopBroadcast(10 * time.Second); err != nil && stopError == nil {
+			stopError = err
+		}
 		logger.Info("Input stopped gracefully", "id", in.Id)
 	case <-time.After(10 * time.Second):
 		logger.Warn("Input stop timeout, forcing cleanup", "id", in.Id)
 		if stopError == nil {
 			stopError = fmt.Errorf("timeout waiting for goroutines to finish")
 		}
+		// A consumer may still be sending; abort the broadcast so that neither it nor the goroutine blocks
+		in.abortBroadcast(in.fanout.Swap(nil))
 	}
 
 	// Use cleanup to ensure all resources are properly released
@@ -698,6 +1058,99 @@ func (in *Input) GetConsumeTotal() uint64 {
 	return atomic.LoadUint64(&in.consumeTotal)
 }
 
+// SetKafkaTxn enables (or with nil disables) transactional consumption for the next start
+func (in *Input) SetKafkaTxn(txn *common.KafkaTxn) {
+	in.kafkaTxn = txn
+}
+
+// IsKafka reports whether the input consumes from Kafka
+func (in *Input) IsKafka() bool {
+	return in.Type == InputTypeKafka || in.Type == InputTypeKafkaAzure || in.Type == InputTypeKafkaAWS
+}
+
+// KafkaBrokers returns the configured brokers of a Kafka input
+func (in *Input) KafkaBrokers() []string {
+	if in.kafkaCfg == nil {
+		return nil
+	}
+	return in.kafkaCfg.Brokers
+}
+
+// shouldForward decides whether an event is forwarded downstream according to sample_rate
+// and records the forwarded/skipped counts.
+func (in *Input) shouldForward() bool {
+	if in.Config == nil || in.Config.SampleRate == nil || *in.Config.SampleRate >= 1 {
+		atomic.AddUint64(&in.forwardedTotal, 1)
+		return true
+	}
+
+	if rand.Float64() < *in.Config.SampleRate {
+		atomic.AddUint64(&in.forwardedTotal, 1)
+		return true
+	}
+	atomic.AddUint64(&in.skippedTotal, 1)
+	return false
+}
+
+// GetSampleRateStats returns the effective sample rate and the forwarded/skipped event counts.
+func (in *Input) GetSampleRateStats() map[string]interface{} {
+	rate := 1.0
+	if in.Config != nil && in.Config.SampleRate != nil {
+		rate = *in.Config.SampleRate
+	}
+	return map[string]interface{}{
+		"sample_rate": rate,
+		"forwarded":   atomic.LoadUint64(&in.forwardedTotal),
+		"skipped":     atomic.LoadUint64(&in.skippedTotal),
+	}
+}
+
+// DownstreamBufferSize returns the configured downstream channel capacity, or def when unset
+func (in *Input) DownstreamBufferSize(def int) int {
+	if in.Config != nil && in.Config.BufferSize > 0 {
+		return in.Config.BufferSize
+	}
+	return def
+}
+
+func (in *Input) prefetchSize() int {
+	if in.Config != nil && in.Config.Prefetch > 0 {
+		return in.Config.Prefetch
+	}
+	return DefaultPrefetch
+}
+
+// GetBufferStats returns the length and capacity of the prefetch buffer and of each downstream channel
+func (in *Input) GetBufferStats() map[string]interface{} {
+	stats := map[string]interface{}{
+		"prefetch": channelUtilization(in.internalMsgChan),
+	}
+	if b := in.fanout.Load(); b != nil {
+		stats["fanout"] = channelUtilization(b.events)
+	}
+	downstream := make(map[string]interface{}, len(in.DownStream))
+	for pns, ch := range in.DownStream {
+		if ch != nil {
+			downstream[pns] = channelUtilization(*ch)
+		}
+	}
+	stats["downstream"] = downstream
+	return stats
+}
+
+func channelUtilization(ch chan map[string]interface{}) map[string]interface{} {
+	length, capacity := len(ch), cap(ch)
+	utilization := 0.0
+	if capacity > 0 {
+		utilization = float64(length) / float64(capacity)
+	}
+	return map[string]interface{}{
+		"len":         length,
+		"cap":         capacity,
+		"utilization": utilization,
+	}
+}
+
 // ResetConsumeTotal resets the total consumed count to zero.
 // This should only be called during component cleanup or forced restart.
 func (in *Input) ResetConsumeTotal() uint64 {
@@ -754,6 +1207,12 @@ func (in *Input) CheckConnectivity() map[string]interface{} {
 			"topic":   in.kafkaCfg.Topic,
 			"group":   in.kafkaCfg.Group,
 		}
+		if len(in.kafkaCfg.Topics) > 0 {
+			connectionInfo["topics"] = in.kafkaCfg.SubscribedTopics()
+		}
+		if in.kafkaCfg.TopicPattern != "" {
+			connectionInfo["topic_pattern"] = in.kafkaCfg.TopicPattern
+		}
 		result["details"].(map[string]interface{})["connection_info"] = connectionInfo
 
 		// Test actual connectivity to Kafka brokers
@@ -768,22 +1227,37 @@ func (in *Input) CheckConnectivity() map[string]interface{} {
 			return result
 		}
 
-		// Test if topic exists
-		topicExists, err := common.TestKafkaTopicExists(in.kafkaCfg.Brokers, in.kafkaCfg.Topic, in.kafkaCfg.SASL, in.kafkaCfg.TLS)
-		if err != nil {
+		// Test if the topics exist; a pattern subscribes to matching topics as they appear
+		var missingTopic string
+		var topicErr error
+		for _, topic := range in.kafkaCfg.SubscribedTopics() {
+			topicExists, err := common.TestKafkaTopicExists(in.kafkaCfg.Brokers, topic, in.kafkaCfg.SASL, in.kafkaCfg.TLS)
+			if err != nil {
+				topicErr = err
+				break
+			}
+			if !topicExists {
+				missingTopic = topic
+				break
+			}
+		}
+		if topicErr != nil {
 			result["status"] = "warning"
 			result["message"] = "Connected to Kafka but failed to verify topic"
 			result["details"].(map[string]interface{})["connection_status"] = "connected_topic_unknown"
 			result["details"].(map[string]interface{})["connection_warnings"] = []map[string]interface{}{
-				{"message": fmt.Sprintf("Could not verify topic existence: %v", err), "severity": "warning"},
+				{"message": fmt.Sprintf("Could not verify topic existence: %v", topicErr), "severity": "warning"},
 			}
-		} else if !topicExists {
+		} else if missingTopic != "" {
 			result["status"] = "error"
 			result["message"] = "Connected to Kafka but topic does not exist"
 			result["details"].(map[string]interface{})["connection_status"] = "connected_topic_missing"
 			result["details"].(map[string]interface{})["connection_errors"] = []map[string]interface{}{
-				{"message": fmt.Sprintf("Topic '%s' does not exist", in.kafkaCfg.Topic), "severity": "error"},
+				{"message": fmt.Sprintf("Topic '%s' does not exist", missingTopic), "severity": "error"},
 			}
+		} else if in.kafkaCfg.TopicPattern != "" {
+			result["details"].(map[string]interface{})["connection_status"] = "connected"
+			result["message"] = "Successfully connected to Kafka, topics matching the pattern are consumed as they appear"
 		} else {
 			result["details"].(map[string]interface{})["connection_status"] = "connected"
 			result["message"] = "Successfully connected to Kafka and verified topic"
@@ -941,6 +1415,7 @@ func NewFromExisting(existing *Input, newProjectNodeSequence string) (*Input, er
 		aliyunSLSCfg:        existing.aliyunSLSCfg,
 		Config:              existing.Config,
 		Status:              common.StatusStopped,
+		decode:              existing.decode,
 		// Note: Runtime fields (kafkaConsumer, slsConsumer, wg, stopChan) are intentionally not copied
 		// as they will be initialized when the input starts
 		// Metrics fields (consumeTotal) are also not copied as they are instance-specific
@@ -963,6 +1438,27 @@ func NewFromExisting(existing *Input, newProjectNodeSequence string) (*Input, er
 	return newInput, nil
 }
 
+// NewWithOverrides creates a new input instance whose config has the given dotted keys replaced,
+// e.g. a project-specific topic. The original component is left untouched.
+func NewWithOverrides(existing *Input, overrides map[string]interface{}, newProjectNodeSequence string) (*Input, error) {
+	if existing == nil {
+		return nil, fmt.Errorf("existing input is nil")
+	}
+
+	raw, err := common.ApplyConfigOverrides(existing.Config.RawConfig, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("input %s: %w", existing.Id, err)
+	}
+
+	newInput, err := NewInput("", raw, existing.Id)
+	if err != nil {
+		return nil, err
+	}
+	newInput.Path = existing.Path
+	newInput.ProjectNodeSequence = newProjectNodeSequence
+	return newInput, nil
+}
+
 // SetTestMode configures the input for test mode by disabling sampling and other global state interactions
 func (in *Input) SetTestMode() {
 	in.sampler = nil // Disable sampling for test instances
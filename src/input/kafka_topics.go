@@ -0,0 +1,78 @@
+package input
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// kafkaTopicName matches the names Kafka accepts for topics
+var kafkaTopicName = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+// SubscribedTopics returns topic followed by topics, without duplicates. It is empty for inputs that
+// subscribe with topic_pattern.
+func (c *KafkaInputConfig) SubscribedTopics() []string {
+	topics := make([]string, 0, len(c.Topics)+1)
+	seen := make(map[string]bool, len(c.Topics)+1)
+	for _, t := range append([]string{c.Topic}, c.Topics...) {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		topics = append(topics, t)
+	}
+	return topics
+}
+
+// tagsTopic reports whether events record their topic in common.KafkaTopicField, which is the case
+// when the input is configured with topics or topic_pattern
+func (c *KafkaInputConfig) tagsTopic() bool {
+	return len(c.Topics) > 0 || c.TopicPattern != ""
+}
+
+// singleTopic returns the only topic of an input, for operations that act on one topic
+func (c *KafkaInputConfig) singleTopic() (string, error) {
+	topics := c.SubscribedTopics()
+	if c.TopicPattern != "" || len(topics) != 1 {
+		return "", fmt.Errorf("only supported for Kafka inputs consuming a single topic")
+	}
+	return topics[0], nil
+}
+
+// SingleKafkaTopic returns the only topic of a Kafka input, an error when it consumes several topics
+// or a topic_pattern, so single topic operations can be rejected before they are scheduled
+func (in *Input) SingleKafkaTopic() (string, error) {
+	if in.kafkaCfg == nil {
+		return "", fmt.Errorf("input %s is not a Kafka input", in.Id)
+	}
+	return in.kafkaCfg.singleTopic()
+}
+
+// verifyKafkaTopics validates the subscription of a Kafka input: topic and topics, or topic_pattern
+func verifyKafkaTopics(cfg *KafkaInputConfig) error {
+	if cfg.TopicPattern != "" {
+		if cfg.Topic != "" || len(cfg.Topics) > 0 {
+			return fmt.Errorf("kafka.topic_pattern cannot be combined with kafka.topic or kafka.topics (line: unknown)")
+		}
+		if _, err := regexp.Compile(cfg.TopicPattern); err != nil {
+			return fmt.Errorf("invalid kafka.topic_pattern '%s': %v (line: unknown)", cfg.TopicPattern, err)
+		}
+		return nil
+	}
+	if cfg.Topic == "" && len(cfg.Topics) == 0 {
+		return fmt.Errorf("missing required field 'kafka.topic' for kafka input (line: unknown)")
+	}
+	if cfg.Topic != "" && !kafkaTopicName.MatchString(cfg.Topic) {
+		return fmt.Errorf("invalid kafka.topic '%s', topic names use letters, digits, '.', '_' and '-' (line: unknown)", cfg.Topic)
+	}
+	seen := make(map[string]bool, len(cfg.Topics))
+	for i, t := range cfg.Topics {
+		if !kafkaTopicName.MatchString(t) {
+			return fmt.Errorf("invalid kafka.topics[%d] '%s', topic names use letters, digits, '.', '_' and '-' (line: unknown)", i, t)
+		}
+		if seen[t] {
+			return fmt.Errorf("duplicate topic '%s' in kafka.topics (line: unknown)", t)
+		}
+		seen[t] = true
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVerifyKafkaTopics(t *testing.T) {
+	valid := []*KafkaInputConfig{
+		{Topic: "events"},
+		{Topics: []string{"auth-logs", "dns.logs"}},
+		{Topic: "events", Topics: []string{"auth-logs"}},
+		{TopicPattern: `logs\..*`},
+	}
+	for _, cfg := range valid {
+		if err := verifyKafkaTopics(cfg); err != nil {
+			t.Errorf("unexpected error for %+v: %v", cfg, err)
+		}
+	}
+
+	invalid := map[string]*KafkaInputConfig{
+		"missing required field":      {},
+		"cannot be combined":          {Topic: "events", TopicPattern: "logs.*"},
+		"invalid kafka.topic_pattern": {TopicPattern: "logs.(*"},
+		"invalid kafka.topics[1]":     {Topics: []string{"auth-logs", "dns logs"}},
+		"invalid kafka.topic ":        {Topic: "a/b"},
+		"duplicate topic":             {Topics: []string{"auth-logs", "auth-logs"}},
+	}
+	for want, cfg := range invalid {
+		err := verifyKafkaTopics(cfg)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q for %+v, got %v", want, cfg, err)
+		}
+	}
+
+	cfg := &KafkaInputConfig{Topic: "events", Topics: []string{"auth-logs", "events"}}
+	if got := cfg.SubscribedTopics(); !reflect.DeepEqual(got, []string{"events", "auth-logs"}) {
+		t.Fatalf("unexpected subscribed topics %v", got)
+	}
+	if _, err := cfg.singleTopic(); err == nil {
+		t.Fatal("expected a multi-topic input to be rejected by single topic operations")
+	}
+	if !cfg.tagsTopic() || (&KafkaInputConfig{Topic: "events"}).tagsTopic() {
+		t.Fatal("only inputs configured with topics or topic_pattern tag their events")
+	}
+	in := &Input{Id: "multi", Type: InputTypeKafka, kafkaCfg: cfg}
+	if _, err := in.SingleKafkaTopic(); err == nil {
+		t.Fatal("expected SingleKafkaTopic to reject a multi-topic input")
+	}
+	in.kafkaCfg = &KafkaInputConfig{Topics: []string{"events"}}
+	if topic, err := in.SingleKafkaTopic(); err != nil || topic != "events" {
+		t.Fatalf("expected the only topic, got %q (%v)", topic, err)
+	}
+}
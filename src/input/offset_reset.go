@@ -0,0 +1,91 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"fmt"
+
+	"github.com/bytedance/sonic"
+)
+
+// resetKafkaGroupOffsets commits the offsets of a reset, replaced in tests
+var resetKafkaGroupOffsets = common.ResetKafkaGroupOffsets
+
+// OffsetResetsKey is the Redis hash holding the offset reset requested for each Kafka input ID until
+// a start applies it. Every node reads it, so the reset is applied by whichever node starts the input.
+const OffsetResetsKey = "hub:offset_resets"
+
+// Redis access of the pending offset resets, replaced in tests
+var (
+	offsetResetHSet   = common.RedisHSet
+	offsetResetHSetNX = common.RedisHSetNX
+	offsetResetHGet   = common.RedisHGet
+	offsetResetHTake  = common.RedisHTake
+	offsetResetHDel   = common.RedisHDel
+)
+
+// SetPendingOffsetReset schedules a consumer group reset for the next start of the input, replacing
+// any reset still pending
+func SetPendingOffsetReset(id string, reset *common.KafkaOffsetReset) error {
+	data, err := sonic.Marshal(reset)
+	if err != nil {
+		return err
+	}
+	return offsetResetHSet(OffsetResetsKey, id, string(data))
+}
+
+// PendingOffsetReset returns the reset scheduled for the input, nil when there is none
+func PendingOffsetReset(id string) (*common.KafkaOffsetReset, error) {
+	value, err := offsetResetHGet(OffsetResetsKey, id)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	return decodeOffsetReset(value)
+}
+
+// ClearPendingOffsetReset cancels the reset scheduled for the input
+func ClearPendingOffsetReset(id string) error {
+	return offsetResetHDel(OffsetResetsKey, id)
+}
+
+func decodeOffsetReset(value string) (*common.KafkaOffsetReset, error) {
+	var reset common.KafkaOffsetReset
+	if err := sonic.UnmarshalString(value, &reset); err != nil {
+		return nil, fmt.Errorf("invalid pending offset reset: %w", err)
+	}
+	return &reset, nil
+}
+
+// applyPendingOffsetReset commits the scheduled reset for the consumer group before the consumer
+// joins it. The reset is taken from Redis first, so when several nodes start the input only one of
+// them applies it. It is put back when it fails, unless a new reset was requested meanwhile, so the
+// next start retries it.
+func (in *Input) applyPendingOffsetReset() error {
+	value, err := offsetResetHTake(OffsetResetsKey, in.Id)
+	if err != nil {
+		return fmt.Errorf("failed to read pending offset reset: %w", err)
+	}
+	if value == "" {
+		return nil
+	}
+	reset, err := decodeOffsetReset(value)
+	if err != nil {
+		return err
+	}
+	topic, err := in.kafkaCfg.singleTopic()
+	if err == nil {
+		var offsets map[int32]int64
+		offsets, err = resetKafkaGroupOffsets(in.kafkaCfg.Brokers, in.kafkaCfg.Group, topic, in.kafkaCfg.SASL, in.kafkaCfg.TLS, reset)
+		if err == nil {
+			logger.Info("Applied Kafka offset reset", "input", in.Id, "group", in.kafkaCfg.Group, "topic", topic, "mode", reset.Mode, "offsets", offsets)
+			return nil
+		}
+		err = fmt.Errorf("failed to apply offset reset (%s): %w", reset.Mode, err)
+	} else {
+		err = fmt.Errorf("offset reset is %w", err)
+	}
+	if _, restoreErr := offsetResetHSetNX(OffsetResetsKey, in.Id, value); restoreErr != nil {
+		logger.Error("Failed to keep the offset reset pending", "input", in.Id, "error", restoreErr)
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"errors"
+	"testing"
+)
+
+func TestPendingOffsetResetAppliedToConsumerGroup(t *testing.T) {
+	orig := resetKafkaGroupOffsets
+	defer func() { resetKafkaGroupOffsets = orig }()
+	fakeOffsetResetStore(t)
+
+	in := &Input{
+		Id:   "offset_reset_test",
+		Type: InputTypeKafka,
+		kafkaCfg: &KafkaInputConfig{
+			Brokers: []string{"b1:9092", "b2:9092"},
+			Group:   "hub-group",
+			Topic:   "events",
+		},
+	}
+	var gotGroup, gotTopic string
+	var gotBrokers []string
+	var gotReset *common.KafkaOffsetReset
+	fail := true
+	resetKafkaGroupOffsets = func(brokers []string, group, topic string, _ *common.KafkaSASLConfig, _ *common.KafkaTLSConfig, reset *common.KafkaOffsetReset) (map[int32]int64, error) {
+		gotBrokers, gotGroup, gotTopic, gotReset = brokers, group, topic, reset
+		if fail {
+			return nil, errors.New("group has active members")
+		}
+		return map[int32]int64{0: 42}, nil
+	}
+
+	// Nothing pending: the consumer group is left alone
+	if err := in.applyPendingOffsetReset(); err != nil || gotReset != nil {
+		t.Fatalf("no reset expected, got err=%v reset=%v", err, gotReset)
+	}
+
+	reset := &common.KafkaOffsetReset{Mode: common.OffsetResetOffset, Partitions: map[int32]int64{0: 42}}
+	if err := SetPendingOffsetReset(in.Id, reset); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := in.applyPendingOffsetReset(); err == nil {
+		t.Fatal("expected the failed commit to be reported")
+	}
+	if pending, _ := PendingOffsetReset(in.Id); pending == nil {
+		t.Fatal("a failed reset must stay pending for the next start")
+	}
+
+	fail = false
+	if err := in.applyPendingOffsetReset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotReset == nil || gotReset.Mode != reset.Mode || gotReset.Partitions[0] != 42 || gotGroup != "hub-group" || gotTopic != "events" || len(gotBrokers) != 2 {
+		t.Fatalf("reset applied with brokers=%v group=%s topic=%s reset=%v", gotBrokers, gotGroup, gotTopic, gotReset)
+	}
+	if pending, _ := PendingOffsetReset(in.Id); pending != nil {
+		t.Fatal("an applied reset must not be applied again")
+	}
+}
+
+// fakeOffsetResetStore keeps the pending resets in memory instead of the Redis hash
+func fakeOffsetResetStore(t *testing.T) {
+	hash := make(map[string]string)
+	origSet, origSetNX, origGet, origTake, origDel := offsetResetHSet, offsetResetHSetNX, offsetResetHGet, offsetResetHTake, offsetResetHDel
+	t.Cleanup(func() {
+		offsetResetHSet, offsetResetHSetNX, offsetResetHGet, offsetResetHTake, offsetResetHDel = origSet, origSetNX, origGet, origTake, origDel
+	})
+	offsetResetHSet = func(_ string, field string, value interface{}) error {
+		hash[field] = value.(string)
+		return nil
+	}
+	offsetResetHSetNX = func(_ string, field string, value interface{}) (bool, error) {
+		if _, ok := hash[field]; ok {
+			return false, nil
+		}
+		hash[field] = value.(string)
+		return true, nil
+	}
+	offsetResetHGet = func(_ string, field string) (string, error) { return hash[field], nil }
+	offsetResetHTake = func(_ string, field string) (string, error) {
+		value := hash[field]
+		delete(hash, field)
+		return value, nil
+	}
+	offsetResetHDel = func(_ string, field string) error {
+		delete(hash, field)
+		return nil
+	}
+}
@@ -0,0 +1,160 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultParseErrorWindow      = time.Minute
+	defaultParseErrorMinMessages = 20
+)
+
+// ParseErrorThresholdConfig degrades the input when too many consumed messages fail to parse, which
+// usually means the upstream schema changed or the format is misconfigured.
+type ParseErrorThresholdConfig struct {
+	Rate        float64 `yaml:"rate"`                   // Fraction of messages (0-1] failing to parse that degrades the input
+	Window      string  `yaml:"window,omitempty"`       // Default 1m
+	MinMessages int     `yaml:"min_messages,omitempty"` // Messages a window needs before its rate is judged (default 20)
+}
+
+// verifyParseErrorThreshold validates the parse_error_threshold section of an input config
+func verifyParseErrorThreshold(cfg *ParseErrorThresholdConfig) error {
+	if cfg.Rate <= 0 || cfg.Rate > 1 {
+		return fmt.Errorf("parse_error_threshold.rate must be greater than 0.0 and at most 1.0, got %v (line: unknown)", cfg.Rate)
+	}
+	if cfg.Window != "" {
+		d, err := time.ParseDuration(cfg.Window)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid parse_error_threshold.window '%s', expected a positive duration like 30s or 5m (line: unknown)", cfg.Window)
+		}
+	}
+	if cfg.MinMessages < 0 {
+		return fmt.Errorf("parse_error_threshold.min_messages cannot be negative (line: unknown)")
+	}
+	return nil
+}
+
+// parseErrorTracker counts the parsed and failed messages of the current window
+type parseErrorTracker struct {
+	mu          sync.Mutex
+	rate        float64
+	window      time.Duration
+	minMessages int
+	start       time.Time
+	total       uint64
+	failed      uint64
+	lastRate    float64 // Rate of the last complete window
+	now         func() time.Time
+}
+
+// newParseErrorTracker builds the tracker of a verified config, nil when no threshold is configured
+func newParseErrorTracker(cfg *ParseErrorThresholdConfig) *parseErrorTracker {
+	if cfg == nil {
+		return nil
+	}
+	t := &parseErrorTracker{
+		rate:        cfg.Rate,
+		window:      defaultParseErrorWindow,
+		minMessages: defaultParseErrorMinMessages,
+		now:         time.Now,
+	}
+	if w, err := time.ParseDuration(cfg.Window); err == nil && w > 0 {
+		t.window = w
+	}
+	if cfg.MinMessages > 0 {
+		t.minMessages = cfg.MinMessages
+	}
+	t.start = t.now()
+	return t
+}
+
+// record counts one message and reports whether the error rate of the window so far is over the
+// threshold. judged is false while the window has too few messages to tell either way.
+func (t *parseErrorTracker) record(failed bool) (over, judged bool, rate float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now := t.now(); now.Sub(t.start) >= t.window {
+		if t.total > 0 {
+			t.lastRate = float64(t.failed) / float64(t.total)
+		}
+		t.start, t.total, t.failed = now, 0, 0
+	}
+	t.total++
+	if failed {
+		t.failed++
+	}
+	if t.total < uint64(t.minMessages) {
+		return false, false, 0
+	}
+	rate = float64(t.failed) / float64(t.total)
+	return rate > t.rate, true, rate
+}
+
+func (t *parseErrorTracker) stats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current := 0.0
+	if t.total > 0 {
+		current = float64(t.failed) / float64(t.total)
+	}
+	return map[string]interface{}{
+		"threshold":       t.rate,
+		"window":          t.window.String(),
+		"window_messages": t.total,
+		"window_failed":   t.failed,
+		"rate":            current,
+		"last_rate":       t.lastRate,
+	}
+}
+
+// sourceDecoder returns the decoder handed to the source consumers. With a parse_error_threshold it
+// records every message that fails to decode, or that decodes to a parse error event, and moves the
+// input between running and degraded as the error rate crosses the threshold. With delivery.dead_letter
+// those messages are then dead-lettered.
+func (in *Input) sourceDecoder() common.MessageDecoder {
+	decode := in.decode
+	if decode == nil {
+		decode, _ = common.NewMessageDecoder("")
+	}
+	if in.parseErrors != nil {
+		tracked := decode
+		decode = func(raw []byte) (map[string]interface{}, error) {
+			event, err := tracked(raw)
+			_, parseError := event[common.ParseErrorField]
+			in.recordParse(err != nil || parseError)
+			return event, err
+		}
+	}
+	if in.deadLetterEnabled() {
+		decode = in.deadLetterDecoder(decode)
+	}
+	return decode
+}
+
+// recordParse counts a parsed message and updates the status of the input accordingly
+func (in *Input) recordParse(failed bool) {
+	over, judged, rate := in.parseErrors.record(failed)
+	if !judged {
+		return
+	}
+	switch {
+	case over && in.Status == common.StatusRunning:
+		in.SetStatus(common.StatusDegraded, fmt.Errorf("parse error rate %.2f exceeds parse_error_threshold %.2f", rate, in.parseErrors.rate))
+	case !over && in.Status == common.StatusDegraded:
+		if in.SetStatus(common.StatusRunning, nil) == nil {
+			in.Err = nil
+		}
+	}
+}
+
+// GetParseErrorStats returns the parse error counts of the current window, nil without a threshold
+func (in *Input) GetParseErrorStats() map[string]interface{} {
+	if in.parseErrors == nil {
+		return nil
+	}
+	return in.parseErrors.stats()
+}
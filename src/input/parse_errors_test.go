@@ -0,0 +1,64 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrorThresholdDegradesInput(t *testing.T) {
+	raw := staticFieldsInput + "parse_error_threshold:\n  rate: 0.5\n  window: 1m\n  min_messages: 10\n"
+	in, err := NewInput("", raw, "parse-errors-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	in.parseErrors.now = func() time.Time { return now }
+	in.parseErrors.start = now
+	in.SetStatus(common.StatusStarting, nil)
+	in.SetStatus(common.StatusRunning, nil)
+
+	decode := in.sourceDecoder()
+	for i := 0; i < 5; i++ {
+		if _, err := decode([]byte(`{"user": "alice"}`)); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := decode([]byte(`user=alice action=login`)); err == nil {
+			t.Fatal("expected a decode error")
+		}
+	}
+
+	if in.Status != common.StatusDegraded {
+		t.Fatalf("expected the input degraded, got %s", in.Status)
+	}
+	if in.Err == nil || !strings.Contains(in.Err.Error(), "exceeds parse_error_threshold 0.50") {
+		t.Fatalf("expected the error rate recorded, got %v", in.Err)
+	}
+
+	// A healthy window brings the input back to running
+	now = now.Add(time.Minute)
+	for i := 0; i < 10; i++ {
+		decode([]byte(`{"user": "bob"}`))
+	}
+	if in.Status != common.StatusRunning || in.Err != nil {
+		t.Fatalf("expected the input running again, got %s (%v)", in.Status, in.Err)
+	}
+	if stats := in.GetParseErrorStats(); stats["last_rate"].(float64) < 0.66 {
+		t.Fatalf("expected the last window rate kept, got %v", stats)
+	}
+}
+
+func TestParseErrorThresholdVerify(t *testing.T) {
+	for _, section := range []string{
+		"parse_error_threshold:\n  rate: 0\n",
+		"parse_error_threshold:\n  rate: 1.5\n",
+		"parse_error_threshold:\n  rate: 0.2\n  window: soon\n",
+	} {
+		if _, err := NewInput("", staticFieldsInput+section, "parse-errors-input"); err == nil || !strings.Contains(err.Error(), "parse_error_threshold") {
+			t.Fatalf("expected %q rejected, got %v", section, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"testing"
+)
+
+const parseTestKafkaConfig = `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+`
+
+func TestParseMessage_JSON(t *testing.T) {
+	in, err := NewInput("", parseTestKafkaConfig, "parse-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := in.ParseMessage([]byte(`{"user":"alice","port":443,"tags":["a","b"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Event["user"] != "alice" || res.Event["port"] != float64(443) || res.GrokApplied {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	for _, raw := range []string{`not json`, `[1,2]`, `null`} {
+		if _, err := in.ParseMessage([]byte(raw)); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestParseMessage_CSVWithGrok(t *testing.T) {
+	in, err := NewInput("", parseTestKafkaConfig+`grok_pattern: "%{DATA:user},%{IP:src_ip},%{INT:status}"
+grok_field: line
+`, "parse-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := in.ParseMessage([]byte(`{"line":"alice,10.0.0.1,200"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.GrokApplied || !res.GrokMatched {
+		t.Fatalf("expected grok to match: %+v", res)
+	}
+	if res.Event["user"] != "alice" || res.Event["src_ip"] != "10.0.0.1" || res.Event["status"] != "200" {
+		t.Fatalf("unexpected parsed fields: %+v", res.Event)
+	}
+
+	res, err = in.ParseMessage([]byte(`{"line":"only-one-column"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.GrokApplied || res.GrokMatched {
+		t.Fatalf("expected a grok mismatch to be reported: %+v", res)
+	}
+}
+
+const windowsSecurityEventXML = `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event">
+  <System>
+    <Provider Name="Microsoft-Windows-Security-Auditing" Guid="{54849625-5478-4994-A5BA-3E3B0328C30D}"/>
+    <EventID>4625</EventID>
+    <Level>0</Level>
+    <TimeCreated SystemTime="2024-03-01T08:15:30.1234567Z"/>
+    <Computer>DC01.corp.local</Computer>
+  </System>
+  <EventData>
+    <Data Name="TargetUserName">administrator</Data>
+    <Data Name="TargetDomainName">CORP</Data>
+    <Data Name="LogonType">3</Data>
+    <Data Name="IpAddress">203.0.113.7</Data>
+  </EventData>
+</Event>`
+
+func TestParseMessage_WindowsSecurityEventXML(t *testing.T) {
+	in, err := NewInput("", parseTestKafkaConfig+"format: xml\n", "parse-xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := in.ParseMessage([]byte(windowsSecurityEventXML))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	event, ok := res.Event["Event"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the root element as top-level key: %+v", res.Event)
+	}
+	if _, ok := event["@xmlns"]; ok {
+		t.Fatalf("namespace declarations must not become attributes: %+v", event)
+	}
+	system := event["System"].(map[string]interface{})
+	if system["EventID"] != "4625" || system["Computer"] != "DC01.corp.local" {
+		t.Fatalf("unexpected System fields: %+v", system)
+	}
+	provider := system["Provider"].(map[string]interface{})
+	if provider["@Name"] != "Microsoft-Windows-Security-Auditing" {
+		t.Fatalf("expected attributes under @name: %+v", provider)
+	}
+	if created := system["TimeCreated"].(map[string]interface{}); created["@SystemTime"] != "2024-03-01T08:15:30.1234567Z" {
+		t.Fatalf("unexpected TimeCreated: %+v", created)
+	}
+
+	data, ok := event["EventData"].(map[string]interface{})["Data"].([]interface{})
+	if !ok || len(data) != 4 {
+		t.Fatalf("expected the repeated Data elements as an array: %+v", event["EventData"])
+	}
+	first := data[0].(map[string]interface{})
+	if first["@Name"] != "TargetUserName" || first["#text"] != "administrator" {
+		t.Fatalf("unexpected Data element: %+v", first)
+	}
+	if last := data[3].(map[string]interface{}); last["#text"] != "203.0.113.7" {
+		t.Fatalf("unexpected Data element order: %+v", data)
+	}
+
+	// A malformed document is kept under the raw field with its parse error
+	broken := `<Event><System><EventID>4625</System></Event>`
+	res, err = in.ParseMessage([]byte(broken))
+	if err != nil {
+		t.Fatalf("malformed XML must not be dropped: %v", err)
+	}
+	if res.Event[common.RawMessageField] != broken || res.Event[common.ParseErrorField] == "" {
+		t.Fatalf("expected the payload and the error of the malformed document: %+v", res.Event)
+	}
+
+	if _, err := NewInput("", parseTestKafkaConfig+"format: csv\n", "parse-bad-format"); err == nil {
+		t.Fatal("expected an unsupported format to be rejected")
+	}
+}
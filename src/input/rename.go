@@ -0,0 +1,51 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+)
+
+// verifyRename validates the rename map of an input config: old name -> new name of top-level fields
+func verifyRename(rename map[string]string) error {
+	targets := make(map[string]string, len(rename))
+	for from, to := range rename {
+		if strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+			return fmt.Errorf("invalid rename '%s: %s': field names cannot be empty (line: unknown)", from, to)
+		}
+		if from == "_hub_input" || to == "_hub_input" {
+			return fmt.Errorf("invalid rename '%s: %s': _hub_input cannot be renamed (line: unknown)", from, to)
+		}
+		if from == to {
+			return fmt.Errorf("invalid rename '%s: %s': the new name is the same as the old one (line: unknown)", from, to)
+		}
+		if other, exists := targets[to]; exists {
+			return fmt.Errorf("invalid rename: both '%s' and '%s' are renamed to '%s' (line: unknown)", other, from, to)
+		}
+		targets[to] = from
+	}
+	return nil
+}
+
+// applyRename renames the configured top-level fields of an event so rulesets see the same names
+// whatever the source. An existing field with the new name is replaced. The old field is removed
+// unless RenameKeepOriginal is set. Fields missing from the event are ignored.
+func (in *Input) applyRename(data map[string]interface{}) {
+	if in.Config == nil || len(in.Config.Rename) == 0 {
+		return
+	}
+	// Read every value first so renames swapping two names do not see each other's result
+	values := make(map[string]interface{}, len(in.Config.Rename))
+	for from := range in.Config.Rename {
+		if value, exists := data[from]; exists {
+			values[from] = value
+		}
+	}
+	if !in.Config.RenameKeepOriginal {
+		for from := range values {
+			delete(data, from)
+		}
+	}
+	for from, value := range values {
+		data[in.Config.Rename[from]] = value
+	}
+}
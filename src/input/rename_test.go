@@ -0,0 +1,73 @@
+package input
+
+import "testing"
+
+const renameInput = `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+rename:
+  srcip: source_ip
+  dst: destination_ip
+`
+
+func TestRenameNormalizesFieldNames(t *testing.T) {
+	in, err := NewInput("", renameInput, "rename-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	ch := make(chan map[string]interface{}, 1)
+	in.DownStream = map[string]*chan map[string]interface{}{"out": &ch}
+
+	in.ProcessTestData(map[string]interface{}{"srcip": "10.0.0.1", "user": "alice"})
+	event := <-ch
+	if event["source_ip"] != "10.0.0.1" || event["user"] != "alice" {
+		t.Fatalf("expected srcip renamed to source_ip: %v", event)
+	}
+	if _, exists := event["srcip"]; exists {
+		t.Fatalf("expected srcip removed: %v", event)
+	}
+	if _, exists := event["destination_ip"]; exists {
+		t.Fatalf("missing fields must not be created: %v", event)
+	}
+}
+
+func TestRenameKeepOriginal(t *testing.T) {
+	in, err := NewInput("", renameInput+"rename_keep_original: true\n", "rename-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	res, err := in.ParseMessage([]byte(`{"srcip": "10.0.0.1"}`))
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+	if res.Event["source_ip"] != "10.0.0.1" || res.Event["srcip"] != "10.0.0.1" {
+		t.Fatalf("expected both names kept: %v", res.Event)
+	}
+}
+
+func TestRenameInvalid(t *testing.T) {
+	for _, rename := range []string{"  a: a\n", "  a: x\n  b: x\n", "  _hub_input: x\n"} {
+		if _, err := NewInput("", renameInput+rename, "rename-input"); err == nil {
+			t.Fatalf("expected rename %q rejected", rename)
+		}
+	}
+}
+
+func TestTransformSampleAppliesRenamesToACopy(t *testing.T) {
+	in, err := NewInput("", renameInput, "rename-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	sample := map[string]interface{}{"srcip": "10.0.0.1"}
+	event := in.TransformSample(sample)
+	if event["source_ip"] != "10.0.0.1" || event["_hub_input"] != "rename-input" {
+		t.Fatalf("expected the sample to get the fields the consumers forward: %v", event)
+	}
+	if _, exists := sample["source_ip"]; exists {
+		t.Fatalf("the stored sample must not change: %v", sample)
+	}
+}
@@ -0,0 +1,59 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"testing"
+	"time"
+)
+
+func TestReplayWindowDecodesTimeBoundedBatch(t *testing.T) {
+	orig := readKafkaWindow
+	defer func() { readKafkaWindow = orig }()
+
+	in, err := NewInput("", parseTestKafkaConfig, "replay-window")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.UnixMilli(1718000000000)
+	end := start.Add(time.Minute)
+	var gotTopic string
+	var gotStart, gotEnd time.Time
+	readKafkaWindow = func(brokers []string, topic string, _ *common.KafkaSASLConfig, _ *common.KafkaTLSConfig, s, e time.Time, limit int) (*common.KafkaWindow, error) {
+		gotTopic, gotStart, gotEnd = topic, s, e
+		// The mock consumer returns the window plus one record produced after it
+		return &common.KafkaWindow{Records: []common.KafkaRecord{
+			{Partition: 0, Offset: 10, Timestamp: start, Value: []byte(`{"user":"alice"}`)},
+			{Partition: 1, Offset: 4, Timestamp: start.Add(30 * time.Second), Value: []byte(`not json`)},
+			{Partition: 0, Offset: 11, Timestamp: start.Add(59 * time.Second), Value: []byte(`{"user":"bob"}`)},
+			{Partition: 1, Offset: 5, Timestamp: end, Value: []byte(`{"user":"late"}`)},
+		}}, nil
+	}
+
+	batch, err := in.ReplayWindow(start, end, 100)
+	if err != nil {
+		t.Fatalf("ReplayWindow: %v", err)
+	}
+	if gotTopic != "test-topic" || !gotStart.Equal(start) || !gotEnd.Equal(end) {
+		t.Fatalf("unexpected window read: topic=%s start=%v end=%v", gotTopic, gotStart, gotEnd)
+	}
+	if batch.Read != 3 || batch.ParseErrors != 1 || batch.Truncated {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	if len(batch.Events) != 2 || batch.Events[0]["user"] != "alice" || batch.Events[1]["user"] != "bob" {
+		t.Fatalf("unexpected events: %+v", batch.Events)
+	}
+
+	// The limit bounds the records replayed even if the reader returned more
+	batch, err = in.ReplayWindow(start, end, 1)
+	if err != nil {
+		t.Fatalf("ReplayWindow: %v", err)
+	}
+	if batch.Read != 1 || !batch.Truncated {
+		t.Fatalf("expected a truncated batch of one record: %+v", batch)
+	}
+
+	if _, err := (&Input{Id: "not-kafka", Type: InputTypeAliyunSLS}).ReplayWindow(start, end, 1); err == nil {
+		t.Fatal("expected replay of a non-Kafka input to be rejected")
+	}
+}
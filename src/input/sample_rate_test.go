@@ -0,0 +1,54 @@
+package input
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSampleRateForwardsFraction(t *testing.T) {
+	config := `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+sample_rate: 0.1
+`
+	in, err := NewInput("", config, "test-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+
+	const total = 100000
+	forwarded := 0
+	for i := 0; i < total; i++ {
+		if in.shouldForward() {
+			forwarded++
+		}
+	}
+
+	ratio := float64(forwarded) / total
+	if math.Abs(ratio-0.1) > 0.01 {
+		t.Fatalf("expected ~10%% forwarded, got %.4f", ratio)
+	}
+
+	stats := in.GetSampleRateStats()
+	if stats["forwarded"].(uint64) != uint64(forwarded) || stats["skipped"].(uint64) != uint64(total-forwarded) {
+		t.Fatalf("unexpected counters: %v", stats)
+	}
+}
+
+func TestSampleRateValidation(t *testing.T) {
+	config := `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  topic: "test-topic"
+sample_rate: 1.5
+`
+	if err := Verify("", config); err == nil {
+		t.Fatalf("expected sample_rate > 1 to be rejected")
+	}
+}
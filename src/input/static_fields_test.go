@@ -0,0 +1,61 @@
+package input
+
+import "testing"
+
+const staticFieldsInput = `
+type: kafka
+kafka:
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+static_fields:
+  environment: prod
+  tenant: acme
+  source:
+    team: secops
+`
+
+func TestStaticFieldsAddedToIngestedEvents(t *testing.T) {
+	in, err := NewInput("", staticFieldsInput, "static-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+	ch := make(chan map[string]interface{}, 2)
+	in.DownStream = map[string]*chan map[string]interface{}{"out": &ch}
+
+	in.ProcessTestData(map[string]interface{}{"user": "alice", "tenant": "globex"})
+	in.ProcessTestData(map[string]interface{}{"user": "bob"})
+
+	first, second := <-ch, <-ch
+	if first["environment"] != "prod" || first["user"] != "alice" {
+		t.Fatalf("static fields not added: %v", first)
+	}
+	if first["tenant"] != "globex" {
+		t.Fatalf("existing key overwritten without override: %v", first)
+	}
+	if second["tenant"] != "acme" {
+		t.Fatalf("expected tenant from static fields, got %v", second)
+	}
+
+	// Nested values are copied per event
+	first["source"].(map[string]interface{})["team"] = "changed"
+	if second["source"].(map[string]interface{})["team"] != "secops" {
+		t.Fatalf("events share nested static values: %v", second)
+	}
+}
+
+func TestStaticFieldsOverride(t *testing.T) {
+	in, err := NewInput("", staticFieldsInput+"static_fields_override: true\n", "static-input")
+	if err != nil {
+		t.Fatalf("Failed to create input: %v", err)
+	}
+
+	res, err := in.ParseMessage([]byte(`{"tenant": "globex"}`))
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+	if res.Event["tenant"] != "acme" {
+		t.Fatalf("expected tenant overridden by static fields, got %v", res.Event)
+	}
+}
@@ -0,0 +1,47 @@
+package input
+
+import (
+	"AgentSmith-HUB/common"
+	"strings"
+	"testing"
+)
+
+const misspelledInput = `type: kafka
+kafka:
+  brokerz:
+    - "localhost:9092"
+  brokers:
+    - "localhost:9092"
+  group: "test-group"
+  topic: "test-topic"
+sample_rat: 0.5
+`
+
+func TestStrictYAMLFlagsMisspelledKeys(t *testing.T) {
+	prev := common.Config
+	t.Cleanup(func() { common.Config = prev })
+
+	common.Config = &common.HubConfig{}
+	if err := Verify("", misspelledInput); err != nil {
+		t.Fatalf("unknown keys must be ignored by default, got %v", err)
+	}
+
+	fields := UnknownFields(misspelledInput)
+	if len(fields) != 2 || fields[0] != (common.UnknownYAMLField{Line: 3, Field: "brokerz"}) || fields[1] != (common.UnknownYAMLField{Line: 9, Field: "sample_rat"}) {
+		t.Fatalf("unexpected unknown fields %+v", fields)
+	}
+
+	common.Config = &common.HubConfig{StrictYAML: common.StrictYAMLWarn}
+	if err := Verify("", misspelledInput); err != nil {
+		t.Fatalf("strict_yaml warn must not reject the config, got %v", err)
+	}
+
+	common.Config = &common.HubConfig{StrictYAML: common.StrictYAMLError}
+	err := Verify("", misspelledInput)
+	if err == nil || !strings.Contains(err.Error(), "yaml-line 3: unknown field 'brokerz' (and 1 more)") {
+		t.Fatalf("expected the misspelled key rejected with its line, got %v", err)
+	}
+	if err := Verify("", staticFieldsInput); err != nil {
+		t.Fatalf("a config with known keys only must pass, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package dns_lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+const (
+	// DefaultTimeout bounds a lookup so a slow resolver cannot stall the rule engine
+	DefaultTimeout = 2 * time.Second
+	MaxTimeout     = 10 * time.Second
+
+	// Resolved names are cached for cacheTTL, names that do not resolve for the shorter negativeTTL
+	cacheTTL    = 5 * time.Minute
+	negativeTTL = time.Minute
+)
+
+// Resolver is the part of net.Resolver the plugin uses
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// resolver performs the lookups, replaced in tests
+var resolver Resolver = net.DefaultResolver
+
+// Events repeat the same hosts and addresses, so answers are cached by mode and value
+var cache, _ = ristretto.NewCache(&ristretto.Config[string, []string]{
+	NumCounters: 100_000, // number of keys to track frequency of.
+	MaxCost:     10_000,  // maximum number of cached answers (cost 1 each).
+	BufferItems: 64,      // number of keys per Get buffer.
+})
+
+// Eval resolves a host name to its addresses (forward) or an IP address to its names (reverse).
+// Returns a list of strings, and false when nothing resolves. Timeouts are not cached.
+// Args: value string, mode string (forward|reverse), timeoutMs int (optional, default 2000, max 10000).
+func Eval(args ...interface{}) (interface{}, bool, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, false, errors.New("dnsLookup requires 2 or 3 arguments: value, mode (forward|reverse), timeoutMs (optional)")
+	}
+	value, ok1 := args[0].(string)
+	mode, ok2 := args[1].(string)
+	if !ok1 || !ok2 {
+		return nil, false, errors.New("value and mode must be strings")
+	}
+	value = strings.TrimSpace(value)
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if value == "" {
+		return nil, false, nil
+	}
+
+	timeout := DefaultTimeout
+	if len(args) == 3 {
+		var ms int
+		switch v := args[2].(type) {
+		case int:
+			ms = v
+		case int64:
+			ms = int(v)
+		case float64:
+			ms = int(v)
+		case string:
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid timeoutMs: %v", v)
+			}
+			ms = i
+		default:
+			return nil, false, fmt.Errorf("unsupported timeoutMs type %T", v)
+		}
+		if ms <= 0 {
+			return nil, false, errors.New("timeoutMs must be positive")
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+		if timeout > MaxTimeout {
+			timeout = MaxTimeout
+		}
+	}
+
+	var lookup func(ctx context.Context, value string) ([]string, error)
+	switch mode {
+	case "forward":
+		lookup = resolver.LookupHost
+	case "reverse":
+		if net.ParseIP(value) == nil {
+			return nil, false, nil // not IP
+		}
+		lookup = resolver.LookupAddr
+	default:
+		return nil, false, fmt.Errorf("unsupported mode '%s', expected forward or reverse", mode)
+	}
+
+	key := mode + "|" + value
+	if answer, ok := cache.Get(key); ok {
+		return result(answer)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	answer, err := lookup(ctx, value)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			cache.SetWithTTL(key, []string{}, 1, negativeTTL)
+			return nil, false, nil
+		}
+		if ctx.Err() != nil {
+			return nil, false, fmt.Errorf("dnsLookup of %s timed out after %v", value, timeout)
+		}
+		return nil, false, fmt.Errorf("dnsLookup of %s failed: %w", value, err)
+	}
+
+	names := make([]string, 0, len(answer))
+	for _, name := range answer {
+		// Reverse answers are fully qualified
+		names = append(names, strings.TrimSuffix(name, "."))
+	}
+	ttl := cacheTTL
+	if len(names) == 0 {
+		ttl = negativeTTL
+	}
+	cache.SetWithTTL(key, names, 1, ttl)
+	return result(names)
+}
+
+// result returns a copy of a cached answer so later changes to the event cannot alter the cache
+func result(answer []string) (interface{}, bool, error) {
+	if len(answer) == 0 {
+		return nil, false, nil
+	}
+	res := make([]string, len(answer))
+	copy(res, answer)
+	return res, true, nil
+}
@@ -0,0 +1,126 @@
+package dns_lookup
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockResolver answers from static tables and counts the lookups reaching it
+type mockResolver struct {
+	hosts map[string][]string
+	addrs map[string][]string
+	delay time.Duration
+	calls int
+}
+
+func (m *mockResolver) wait(ctx context.Context) error {
+	if m.delay == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(m.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *mockResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	m.calls++
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	if addrs, ok := m.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (m *mockResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	m.calls++
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	if names, ok := m.addrs[addr]; ok {
+		return names, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+}
+
+func useResolver(t *testing.T, m *mockResolver) {
+	prev := resolver
+	resolver = m
+	cache.Clear()
+	t.Cleanup(func() {
+		resolver = prev
+		cache.Clear()
+	})
+}
+
+func TestForwardLookupCached(t *testing.T) {
+	m := &mockResolver{hosts: map[string][]string{"example.com": {"93.184.216.34", "2606:2800:220:1::"}}}
+	useResolver(t, m)
+
+	for i := 0; i < 2; i++ {
+		res, ok, err := Eval("example.com", "forward")
+		if err != nil || !ok {
+			t.Fatalf("Eval: ok=%v err=%v", ok, err)
+		}
+		if !reflect.DeepEqual(res, []string{"93.184.216.34", "2606:2800:220:1::"}) {
+			t.Fatalf("unexpected addresses %v", res)
+		}
+		cache.Wait()
+	}
+	if m.calls != 1 {
+		t.Fatalf("expected the second lookup from the cache, resolver called %d times", m.calls)
+	}
+
+	// Unknown hosts resolve to nothing without an error
+	if res, ok, err := Eval("missing.example", "forward"); err != nil || ok || res != nil {
+		t.Fatalf("expected no result for an unknown host, got %v %v %v", res, ok, err)
+	}
+}
+
+func TestReverseLookup(t *testing.T) {
+	useResolver(t, &mockResolver{addrs: map[string][]string{"8.8.8.8": {"dns.google."}}})
+
+	res, ok, err := Eval("8.8.8.8", "reverse")
+	if err != nil || !ok {
+		t.Fatalf("Eval: ok=%v err=%v", ok, err)
+	}
+	if !reflect.DeepEqual(res, []string{"dns.google"}) {
+		t.Fatalf("unexpected names %v", res)
+	}
+
+	if _, ok, err := Eval("not-an-ip", "reverse"); ok || err != nil {
+		t.Fatalf("expected no result for a reverse lookup of a non IP, got %v %v", ok, err)
+	}
+	if _, _, err := Eval("8.8.8.8", "mx"); err == nil {
+		t.Fatalf("expected an error for an unsupported mode")
+	}
+}
+
+func TestLookupTimeout(t *testing.T) {
+	m := &mockResolver{hosts: map[string][]string{"slow.example": {"10.0.0.1"}}, delay: time.Second}
+	useResolver(t, m)
+
+	start := time.Now()
+	_, ok, err := Eval("slow.example", "forward", 20)
+	if ok || err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected a timeout error, got ok=%v err=%v", ok, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("lookup was not bounded by its timeout, took %v", elapsed)
+	}
+
+	// A timeout is not cached, the next call asks the resolver again
+	cache.Wait()
+	m.delay = 0
+	if res, ok, err := Eval("slow.example", "forward"); err != nil || !ok || !reflect.DeepEqual(res, []string{"10.0.0.1"}) {
+		t.Fatalf("expected the retry to resolve, got %v %v %v", res, ok, err)
+	}
+}
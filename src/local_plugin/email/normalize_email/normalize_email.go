@@ -0,0 +1,139 @@
+package normalize_email
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxLocalLength  = 64
+	maxDomainLength = 253
+)
+
+// localSpecials are the characters besides letters and digits allowed in an unquoted local part
+const localSpecials = "!#$%&'*+/=?^_`{|}~.-"
+
+// gmailDomains receive the same mail whatever dots the local part holds
+var gmailDomains = map[string]bool{"gmail.com": true, "googlemail.com": true}
+
+// Eval normalizes an email address for comparing senders and recipients: the domain is lowercased
+// (internationalized domains are converted to punycode), and with stripGmailDots the dots of a
+// Gmail local part are removed and the local part lowercased, since Gmail ignores both.
+// It returns a map with the normalized "address" and whether it is "valid"; an invalid address is
+// returned trimmed.
+// Args: address string, stripGmailDots bool (optional, default false).
+//
+//	<append type="PLUGIN" field="sender">normalizeEmail(from, true)</append>
+//	<check type="EQU" field="sender.valid">false</check>
+func Eval(args ...interface{}) (interface{}, bool, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, false, fmt.Errorf("normalizeEmail requires 1 or 2 arguments: address string, stripGmailDots bool (optional)")
+	}
+	address, ok := args[0].(string)
+	if !ok {
+		return nil, false, fmt.Errorf("address must be a string")
+	}
+	stripDots := false
+	if len(args) == 2 {
+		switch v := args[1].(type) {
+		case bool:
+			stripDots = v
+		case string:
+			stripDots = strings.EqualFold(v, "true")
+		default:
+			return nil, false, fmt.Errorf("stripGmailDots must be a bool")
+		}
+	}
+	normalized, valid := Normalize(address, stripDots)
+	return map[string]interface{}{"address": normalized, "valid": valid}, true, nil
+}
+
+// IsValid reports whether the argument is a syntactically valid email address, for check nodes.
+// Args: address string.
+//
+//	<check type="PLUGIN">!isValidEmail(reply_to)</check>
+func IsValid(args ...interface{}) (bool, error) {
+	if len(args) != 1 {
+		return false, fmt.Errorf("isValidEmail requires exactly 1 argument: address string")
+	}
+	address, ok := args[0].(string)
+	if !ok {
+		return false, fmt.Errorf("argument must be a string")
+	}
+	_, valid := Normalize(address, false)
+	return valid, nil
+}
+
+// Normalize returns the normalized form of address and whether it is valid. Invalid addresses are
+// returned trimmed and otherwise unchanged.
+func Normalize(address string, stripGmailDots bool) (string, bool) {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, "<") && strings.HasSuffix(address, ">") {
+		address = strings.TrimSpace(address[1 : len(address)-1])
+	}
+	local, domain, found := strings.Cut(address, "@")
+	if !found || !validLocal(local) {
+		return address, false
+	}
+	domain, ok := normalizeDomain(domain)
+	if !ok {
+		return address, false
+	}
+	if stripGmailDots && gmailDomains[domain] {
+		local = strings.ToLower(strings.ReplaceAll(local, ".", ""))
+		domain = "gmail.com"
+	}
+	return local + "@" + domain, true
+}
+
+// validLocal checks an unquoted local part: allowed characters, no leading, trailing or double dots
+func validLocal(local string) bool {
+	if local == "" || len(local) > maxLocalLength {
+		return false
+	}
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return false
+	}
+	for _, c := range local {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c > 127 {
+			continue
+		}
+		if !strings.ContainsRune(localSpecials, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeDomain lowercases a domain and converts it to ASCII, rejecting invalid host names, single
+// labels and numeric top-level domains
+func normalizeDomain(domain string) (string, bool) {
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" || strings.ContainsAny(domain, "@[] ") {
+		return "", false
+	}
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil || len(ascii) > maxDomainLength {
+		return "", false
+	}
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", false
+		}
+		for _, c := range label {
+			if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-') {
+				return "", false
+			}
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", false
+	}
+	return ascii, true
+}
@@ -0,0 +1,74 @@
+package normalize_email
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		in        string
+		stripDots bool
+		want      string
+	}{
+		{"Alice.Smith@Example.COM", false, "Alice.Smith@example.com"},
+		{"  <bob@Mail.Example.org.>  ", false, "bob@mail.example.org"},
+		{"J.O.Doe+promo@GMail.com", false, "J.O.Doe+promo@gmail.com"},
+		{"J.O.Doe+promo@GMail.com", true, "jodoe+promo@gmail.com"},
+		{"john.doe@googlemail.com", true, "johndoe@gmail.com"},
+		{"john.doe@outlook.com", true, "john.doe@outlook.com"},
+		{"user@bücher.de", false, "user@xn--bcher-kva.de"},
+	}
+	for _, c := range cases {
+		got, valid := Normalize(c.in, c.stripDots)
+		if !valid || got != c.want {
+			t.Errorf("Normalize(%q, %v) = %q, %v; want %q, true", c.in, c.stripDots, got, valid, c.want)
+		}
+	}
+}
+
+func TestNormalizeRejectsInvalidAddresses(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"plainaddress",
+		"@example.com",
+		"user@",
+		"user@localhost",
+		"user@@example.com",
+		"us er@example.com",
+		".user@example.com",
+		"user.@example.com",
+		"us..er@example.com",
+		"user@-example.com",
+		"user@exa_mple.com",
+		"user@example..com",
+		"user@192.168.0.1",
+		"user@[192.168.0.1]",
+	} {
+		if got, valid := Normalize(in, true); valid {
+			t.Errorf("Normalize(%q) = %q, expected invalid", in, got)
+		}
+	}
+}
+
+func TestEvalAndIsValid(t *testing.T) {
+	res, ok, err := Eval("Phish.Er@GMAIL.com", true)
+	if err != nil || !ok {
+		t.Fatalf("unexpected result: ok=%v err=%v", ok, err)
+	}
+	m := res.(map[string]interface{})
+	if m["address"] != "phisher@gmail.com" || m["valid"] != true {
+		t.Fatalf("unexpected result %v", m)
+	}
+	res, _, _ = Eval(" not-an-email ")
+	if m := res.(map[string]interface{}); m["address"] != "not-an-email" || m["valid"] != false {
+		t.Fatalf("an invalid address must be returned trimmed and flagged, got %v", m)
+	}
+	if _, _, err := Eval(42); err == nil {
+		t.Fatal("expected an error for a non string address")
+	}
+
+	if valid, err := IsValid("a@example.com"); err != nil || !valid {
+		t.Fatalf("expected a valid address, got %v %v", valid, err)
+	}
+	if valid, _ := IsValid("a@example"); valid {
+		t.Fatal("expected a single label domain to be invalid")
+	}
+}
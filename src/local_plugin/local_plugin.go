@@ -2,6 +2,7 @@ package local_plugin
 
 import (
 	"AgentSmith-HUB/local_plugin/cidr_match"
+	"AgentSmith-HUB/local_plugin/dns_lookup"
 	"AgentSmith-HUB/local_plugin/is_private_ip"
 	"AgentSmith-HUB/local_plugin/parse_json_data"
 
@@ -30,6 +31,7 @@ import (
 	pua "AgentSmith-HUB/local_plugin/user_agent/parse_user_agent"
 
 	// string manipulation
+	sentropy "AgentSmith-HUB/local_plugin/string/entropy"
 	sreplace "AgentSmith-HUB/local_plugin/string/replace"
 
 	// regex
@@ -39,6 +41,9 @@ import (
 	// alert suppression
 	suppressonce "AgentSmith-HUB/local_plugin/suppress_once"
 
+	// email
+	nemail "AgentSmith-HUB/local_plugin/email/normalize_email"
+
 	// threat intelligence
 	shodan "AgentSmith-HUB/local_plugin/shodan"
 	threatbook "AgentSmith-HUB/local_plugin/threatbook"
@@ -51,6 +56,7 @@ var LocalPluginBoolRes = map[string]func(...interface{}) (bool, error){
 	"cidrMatch":    cidr_match.Eval,
 	"geoMatch":     geo_match.Eval,
 	"suppressOnce": suppressonce.Eval,
+	"isValidEmail": nemail.IsValid,
 }
 
 // for append or other usage
@@ -78,8 +84,12 @@ var LocalPluginInterfaceAndBoolRes = map[string]func(...interface{}) (interface{
 	// user agent
 	"parseUA": pua.Eval,
 
+	// email
+	"normalizeEmail": nemail.Eval,
+
 	// string manipulation
 	"replace": sreplace.Eval,
+	"entropy": sentropy.Eval,
 
 	// regex
 	"regexExtract": rextract.Eval,
@@ -89,6 +99,9 @@ var LocalPluginInterfaceAndBoolRes = map[string]func(...interface{}) (interface{
 	"virusTotal": virustotal.Eval,
 	"shodan":     shodan.Eval,
 	"threatBook": threatbook.Eval,
+
+	// dns
+	"dnsLookup": dns_lookup.Eval,
 }
 
 var LocalPluginDesc = map[string]string{
@@ -97,6 +110,7 @@ var LocalPluginDesc = map[string]string{
 	"cidrMatch":    "Check node: true if IP within CIDR. Args: ip string, cidr string.",
 	"geoMatch":     "Check node: true if IP country ISO matches expected. Args: ip, countryISO.",
 	"suppressOnce": "Check node: alert suppression. Args: key(any), windowSec, ruleid(optional). Returns true only first time within window. Use ruleid to isolate different rules.",
+	"isValidEmail": "Check node: true if the string is a syntactically valid email address. Args: address string.",
 
 	// time append
 	"now":       "Append: current time. Args: optional format (unix|ms|rfc3339).",
@@ -117,13 +131,17 @@ var LocalPluginDesc = map[string]string{
 	"extractSubdomain": "Append: extract subdomain from host. Args: host string.",
 
 	// ua
-	"parseUA": "Append: parse user agent to map. Args: ua string.",
+	"parseUA": "Append: parse user agent to map (browser, version, os, platform, device, mobile, bot). Args: ua string.",
+
+	// email
+	"normalizeEmail": "Append: normalize an email address to a map (address, valid): lowercased, punycode domain; with stripGmailDots the dots of a Gmail local part are removed. Args: address string, stripGmailDots bool (optional).",
 
 	// misc
 	"parseJSON": "Append: parse JSON string into map. Args: json string.",
 
 	// string manipulation
 	"replace": "Append: replace all occurrences of substring. Args: input, old, new.",
+	"entropy": "Append: Shannon entropy of a string in bits per character (float), high for random strings such as DGA domains. Args: input string.",
 
 	// regex
 	"regexExtract": "Append: extract text using regex. Returns match or capture groups. Args: input, pattern.",
@@ -133,4 +151,7 @@ var LocalPluginDesc = map[string]string{
 	"virusTotal": "Append: query VirusTotal for file hash reputation. Returns detection info with caching. Args: hash string (MD5/SHA1/SHA256), apiKey string (optional - fallback to VIRUSTOTAL_API_KEY env var).",
 	"shodan":     "Append: query Shodan for IP address infrastructure info. Returns host details with caching. Args: ip string (IPv4/IPv6), apiKey string (optional - fallback to SHODAN_API_KEY env var).",
 	"threatBook": "Append: query ThreatBook (微步在线) for threat intelligence. Returns comprehensive threat info with caching. Args: queryValue string, queryType string (ip/domain/file/url), apiKey string (optional - fallback to THREATBOOK_API_KEY env var).",
+
+	// dns
+	"dnsLookup": "Append: resolve a host to its addresses (forward) or an IP to its names (reverse), cached with a TTL. Returns a list of strings. Args: value string, mode string (forward/reverse), timeoutMs int (optional, default 2000, max 10000).",
 }
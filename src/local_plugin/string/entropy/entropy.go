@@ -0,0 +1,42 @@
+package entropy
+
+import (
+	"fmt"
+	"math"
+)
+
+// Eval returns the Shannon entropy of a string in bits per character, computed over its runes.
+// Random looking values such as DGA domains score well above dictionary words.
+// Args: input string.
+//
+//	<append type="PLUGIN" field="domain_entropy">entropy(domain)</append>
+//	<check type="MT" field="domain_entropy">3.5</check>
+func Eval(args ...interface{}) (interface{}, bool, error) {
+	if len(args) != 1 {
+		return nil, false, fmt.Errorf("entropy requires 1 argument: input string")
+	}
+	input, ok := args[0].(string)
+	if !ok {
+		return nil, false, fmt.Errorf("argument must be a string")
+	}
+	return Shannon(input), true, nil
+}
+
+// Shannon computes the entropy of s in bits per rune, 0 for an empty string
+func Shannon(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	var h float64
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
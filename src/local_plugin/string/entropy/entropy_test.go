@@ -0,0 +1,30 @@
+package entropy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEntropyDictionaryWordVsRandomString(t *testing.T) {
+	word, ok, err := Eval("facebook")
+	if err != nil || !ok {
+		t.Fatalf("unexpected result: ok=%v err=%v", ok, err)
+	}
+	random, _, _ := Eval("xj4k9qz2vw7pl0mb")
+	if word.(float64) >= random.(float64) {
+		t.Fatalf("expected the random string (%v) to score above the dictionary word (%v)", random, word)
+	}
+	if random.(float64) < 3.5 {
+		t.Fatalf("expected a random 16 character string to exceed 3.5 bits, got %v", random)
+	}
+
+	if h := Shannon("aaaa"); h != 0 {
+		t.Fatalf("a repeated character has no entropy, got %v", h)
+	}
+	if h := Shannon("abcd"); math.Abs(h-2) > 1e-9 {
+		t.Fatalf("4 distinct characters carry 2 bits each, got %v", h)
+	}
+	if _, _, err := Eval(42); err == nil {
+		t.Fatal("expected an error for a non string argument")
+	}
+}
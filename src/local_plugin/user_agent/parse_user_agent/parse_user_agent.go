@@ -2,11 +2,20 @@ package parse_user_agent
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/dgraph-io/ristretto/v2"
 	"github.com/mssola/user_agent"
 )
 
-// Eval parses user agent string and returns map with browser, version, os, platform.
+// Web logs repeat a small set of user agents, so parsed results are cached by UA string
+var cache, _ = ristretto.NewCache(&ristretto.Config[string, map[string]interface{}]{
+	NumCounters: 100_000, // number of keys to track frequency of.
+	MaxCost:     10_000,  // maximum number of cached user agents (cost 1 each).
+	BufferItems: 64,      // number of keys per Get buffer.
+})
+
+// Eval parses user agent string and returns map with browser, version, os, platform, device.
 func Eval(args ...interface{}) (interface{}, bool, error) {
 	if len(args) != 1 {
 		return nil, false, fmt.Errorf("parse_user_agent requires 1 string arg")
@@ -15,15 +24,46 @@ func Eval(args ...interface{}) (interface{}, bool, error) {
 	if !ok {
 		return nil, false, fmt.Errorf("arg must be string")
 	}
+
+	out, ok := cache.Get(uaStr)
+	if !ok {
+		out = parse(uaStr)
+		cache.Set(uaStr, out, 1)
+	}
+
+	// Return a copy so later appends or deletes cannot alter the cached entry
+	res := make(map[string]interface{}, len(out))
+	for k, v := range out {
+		res[k] = v
+	}
+	return res, true, nil
+}
+
+func parse(uaStr string) map[string]interface{} {
 	ua := user_agent.New(uaStr)
 	name, ver := ua.Browser()
-	out := map[string]interface{}{
+	return map[string]interface{}{
 		"browser":  name,
 		"version":  ver,
 		"os":       ua.OS(),
 		"platform": ua.Platform(),
 		"mobile":   ua.Mobile(),
 		"bot":      ua.Bot(),
+		"device":   deviceType(ua, uaStr),
+	}
+}
+
+// deviceType classifies the client as bot, tablet, mobile or desktop
+func deviceType(ua *user_agent.UserAgent, uaStr string) string {
+	switch {
+	case ua.Bot():
+		return "bot"
+	case strings.Contains(uaStr, "iPad") || strings.Contains(uaStr, "Tablet") ||
+		(strings.Contains(uaStr, "Android") && !strings.Contains(uaStr, "Mobile")):
+		return "tablet"
+	case ua.Mobile():
+		return "mobile"
+	default:
+		return "desktop"
 	}
-	return out, true, nil
 }
@@ -0,0 +1,81 @@
+package parse_user_agent
+
+import "testing"
+
+func TestEvalCommonUserAgents(t *testing.T) {
+	tests := []struct {
+		name    string
+		ua      string
+		browser string
+		os      string
+		device  string
+	}{
+		{
+			name:    "desktop chrome",
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			browser: "Chrome",
+			os:      "Windows 10",
+			device:  "desktop",
+		},
+		{
+			name:    "iphone safari",
+			ua:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			browser: "Safari",
+			os:      "CPU iPhone OS 17_1 like Mac OS X",
+			device:  "mobile",
+		},
+		{
+			name:    "ipad safari",
+			ua:      "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			browser: "Safari",
+			os:      "CPU OS 16_6 like Mac OS X",
+			device:  "tablet",
+		},
+		{
+			name:    "googlebot",
+			ua:      "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			browser: "Googlebot",
+			device:  "bot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ok, err := Eval(tt.ua)
+			if err != nil || !ok {
+				t.Fatalf("Eval returned ok=%v err=%v", ok, err)
+			}
+			out := res.(map[string]interface{})
+			if out["browser"] != tt.browser {
+				t.Errorf("browser = %v, want %v", out["browser"], tt.browser)
+			}
+			if tt.os != "" && out["os"] != tt.os {
+				t.Errorf("os = %v, want %v", out["os"], tt.os)
+			}
+			if out["device"] != tt.device {
+				t.Errorf("device = %v, want %v", out["device"], tt.device)
+			}
+		})
+	}
+}
+
+func TestEvalCachedResultIsNotShared(t *testing.T) {
+	ua := "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0"
+	first, _, _ := Eval(ua)
+	cache.Wait()
+
+	first.(map[string]interface{})["browser"] = "modified"
+	second, _, _ := Eval(ua)
+	if second.(map[string]interface{})["browser"] != "Firefox" {
+		t.Fatalf("cached result was modified through a returned map: %v", second)
+	}
+}
+
+func TestEvalInvalidArgs(t *testing.T) {
+	if _, _, err := Eval(); err == nil {
+		t.Fatal("expected error for missing argument")
+	}
+	if _, _, err := Eval(42); err == nil {
+		t.Fatal("expected error for non-string argument")
+	}
+}
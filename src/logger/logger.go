@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -19,6 +20,34 @@ var l *slog.Logger
 var accessLogger *lumberjack.Logger
 var pluginLogger *slog.Logger
 
+// level is the minimum level of the hub and plugin loggers, changed live by SetLevel
+var level = new(slog.LevelVar)
+
+// ParseLevel reads a log_level config value: debug, info (default), warn or error
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unsupported log level '%s', expected debug, info, warn or error", value)
+}
+
+// SetLevel changes the minimum level of the hub and plugin loggers
+func SetLevel(lvl slog.Level) {
+	level.Set(lvl)
+}
+
+// GetLevel returns the minimum level of the hub and plugin loggers
+func GetLevel() slog.Level {
+	return level.Level()
+}
+
 // getLogDir returns the appropriate log directory based on the operating system
 func getLogDir() string {
 	if runtime.GOOS == "darwin" {
@@ -105,7 +134,7 @@ func initLoggerWithRedisAndNodeID(nodeID string, redisWriter func(entry RedisErr
 			if err := os.MkdirAll("./logs", 0755); err != nil {
 				// If we can't create any log directory, write to stderr
 				handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
-					Level: slog.LevelInfo,
+					Level: level,
 				})
 				logger := slog.New(handler)
 				slog.SetDefault(logger)
@@ -116,7 +145,7 @@ func initLoggerWithRedisAndNodeID(nodeID string, redisWriter func(entry RedisErr
 		}
 
 		fileHandler := slog.NewJSONHandler(logFile, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
+			Level: level,
 		})
 
 		var handler slog.Handler = fileHandler
@@ -141,7 +170,7 @@ func initLoggerWithRedisAndNodeID(nodeID string, redisWriter func(entry RedisErr
 	}
 
 	fileHandler := slog.NewJSONHandler(logFile, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: level,
 	})
 
 	var handler slog.Handler = fileHandler
@@ -194,7 +223,7 @@ func initPluginLoggerWithRedisAndNodeID(nodeID string, redisWriter func(entry Re
 			if err := os.MkdirAll("./logs", 0755); err != nil {
 				// Return a logger that writes to stderr as fallback
 				fileHandler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
-					Level: slog.LevelInfo,
+					Level: level,
 				})
 
 				var handler slog.Handler = fileHandler
@@ -219,7 +248,7 @@ func initPluginLoggerWithRedisAndNodeID(nodeID string, redisWriter func(entry Re
 	}
 
 	fileHandler := slog.NewJSONHandler(pluginLogFile, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: level,
 	})
 
 	var handler slog.Handler = fileHandler
@@ -157,8 +157,14 @@ func main() {
 			logger.Warn("Failed to store leader token in Redis", "error", err)
 		}
 
-		loadLocalComponents()
-		loadLocalProjects()
+		if err := strictStartupError(loadLocalComponents()); err != nil {
+			logger.Error("Aborting startup", "error", err)
+			os.Exit(1)
+		}
+		if err := strictStartupError(loadLocalProjects()); err != nil {
+			logger.Error("Aborting startup", "error", err)
+			os.Exit(1)
+		}
 
 		common.InitClusterSystemManager()
 		err = cluster.GlobalClusterManager.Start()
@@ -167,6 +173,9 @@ func main() {
 			return
 		}
 
+		common.StartRuleActivityReporter()
+		common.StartOperationCompaction()
+
 		go api.ServerStart(*apiListen) // start Echo API on specified address
 		logger.Info("Leader API server starting", "address", *apiListen)
 	} else {
@@ -178,6 +187,17 @@ func main() {
 		logger.Info("Follower API server starting", "address", *apiListen)
 	}
 
+	// SIGHUP re-reads config.yaml, applying the settings that are safe to change live
+	go func() {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		for range reload {
+			if _, err := common.ReloadHubConfig(); err != nil {
+				logger.Error("Failed to reload hub config", "error", err)
+			}
+		}
+	}()
+
 	// ========== Graceful shutdown handling ==========
 	shutdownCtx, stopSignal := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stopSignal()
@@ -234,6 +254,7 @@ func main() {
 			}
 
 			common.StopClusterSystemManager()
+			common.StopRuleActivityReporter()
 			common.StopDailyStatsManager()
 			if rsm := common.GetRedisSampleManager(); rsm != nil {
 				rsm.Close()
@@ -276,49 +297,65 @@ func traverseComponents(dir, suffix string) []string {
 	return files
 }
 
-func loadLocalComponents() {
-	var err error
+// strictStartupError returns an error listing the load failures when strict_startup is enabled,
+// nil otherwise so the hub keeps running with error placeholders
+func strictStartupError(failures []string) error {
+	if !common.Config.StrictStartup || len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("strict_startup: %d component(s) failed to load: %s", len(failures), strings.Join(failures, "; "))
+}
+
+// loadLocalComponents loads plugins, inputs, outputs and rulesets from the config root and returns
+// a "type/id: error" entry for each component that failed to load
+func loadLocalComponents() []string {
+	var failures []string
 	// Only leader loads local components
 	root := common.Config.ConfigRoot
 
-	// plugins
-	for _, f := range traverseComponents(path.Join(root, "plugin"), ".go") {
+	// plugins, compiled concurrently within max_plugin_compilations
+	pluginFiles := traverseComponents(path.Join(root, "plugin"), ".go")
+	sources := make([]plugin.PluginSource, 0, len(pluginFiles))
+	for _, f := range pluginFiles {
 		name := common.GetFileNameWithoutExt(f)
 		if content, err := os.ReadFile(f); err == nil {
 			// Update global config map
 			common.SetRawConfig("plugin", name, string(content))
 		}
-		err = plugin.NewPlugin(f, "", name, plugin.YAEGI_PLUGIN)
-		if err != nil {
-			logger.Error("Failed to load plugin", "file", f, "error", err)
-			// Create an error placeholder plugin to show in list
-			errorPlugin := &plugin.Plugin{
-				Name:   name,
-				Path:   f,
-				Type:   plugin.YAEGI_PLUGIN,
-				Status: common.StatusError,
-				Err:    err,
-			}
-			// Read raw config for display purposes
-			if content, readErr := os.ReadFile(f); readErr == nil {
-				errorPlugin.Payload = content
-			}
-			// Add to global plugin map with mutex protection
-			common.GlobalMu.Lock()
-			plugin.Plugins[name] = errorPlugin
-			common.GlobalMu.Unlock()
+		sources = append(sources, plugin.PluginSource{Name: name, Path: f})
+	}
+	pluginErrors := plugin.LoadPlugins(sources)
+	for _, src := range sources {
+		name, f := src.Name, src.Path
+		err, failed := pluginErrors[name]
+		if !failed {
+			continue
+		}
+		logger.Error("Failed to load plugin", "file", f, "error", err)
+		failures = append(failures, fmt.Sprintf("plugin/%s: %v", name, err))
+		// Create an error placeholder plugin to show in list
+		errorPlugin := &plugin.Plugin{
+			Name:   name,
+			Path:   f,
+			Type:   plugin.YAEGI_PLUGIN,
+			Status: common.StatusError,
+			Err:    err,
 		}
+		// Read raw config for display purposes
+		if content, readErr := os.ReadFile(f); readErr == nil {
+			errorPlugin.Payload = content
+		}
+		// Add to global plugin map with mutex protection
+		plugin.SetPlugin(name, errorPlugin)
 	}
 	// Load plugin .new files
 	for _, f := range traverseComponents(path.Join(root, "plugin"), ".go.new") {
-		common.GlobalMu.Lock()
 		name := strings.TrimSuffix(common.GetFileNameWithoutExt(f), ".go")
 		if content, err := os.ReadFile(f); err != nil {
 			logger.Error("Failed to load new plugin", "file", f, "error", err)
 		} else {
-			plugin.PluginsNew[name] = string(content)
+			plugin.SetPluginNew(name, string(content))
 		}
-		common.GlobalMu.Unlock()
 	}
 
 	// inputs
@@ -330,6 +367,7 @@ func loadLocalComponents() {
 		}
 		if inp, err := input.NewInput(f, "", id); err != nil {
 			logger.Error("Failed to load new input", "file", f, "error", err)
+			failures = append(failures, fmt.Sprintf("input/%s: %v", id, err))
 			// Create an error placeholder input to show in list
 			errorInput := &input.Input{
 				Id:     id,
@@ -366,6 +404,7 @@ func loadLocalComponents() {
 		}
 		if out, err := output.NewOutput(f, "", id); err != nil {
 			logger.Error("Failed to load output", "file", f, "error", err)
+			failures = append(failures, fmt.Sprintf("output/%s: %v", id, err))
 			// Create an error placeholder output to show in list
 			errorOutput := &output.Output{
 				Id:     id,
@@ -402,6 +441,7 @@ func loadLocalComponents() {
 		}
 		if rs, err := rules_engine.NewRuleset(f, "", id); err != nil {
 			logger.Error("Failed to load ruleset", "file", f, "error", err)
+			failures = append(failures, fmt.Sprintf("ruleset/%s: %v", id, err))
 			// Create an error placeholder ruleset to show in list
 			errorRuleset := &rules_engine.Ruleset{
 				RulesetID: id,
@@ -428,10 +468,14 @@ func loadLocalComponents() {
 		}
 	}
 
-	logger.Info("Leader finished loading local components")
+	logger.Info("Leader finished loading local components", "failed", len(failures))
+	return failures
 }
 
-func loadLocalProjects() {
+// loadLocalProjects loads the projects from the config root, restores the ones the user left
+// running, and returns a "project/id: error" entry for each project that failed to load
+func loadLocalProjects() []string {
+	var failures []string
 	root := common.Config.ConfigRoot
 	for _, f := range traverseComponents(path.Join(root, "project"), ".yaml") {
 		id := common.GetFileNameWithoutExt(f)
@@ -469,6 +513,7 @@ func loadLocalProjects() {
 			}
 		} else {
 			logger.Error("Failed to create project", "project", id, "error", err)
+			failures = append(failures, fmt.Sprintf("project/%s: %v", id, err))
 			// Create an error placeholder project to show in list
 			errorProject := &project.Project{
 				Id:     id,
@@ -497,6 +542,7 @@ func loadLocalProjects() {
 		}
 	}
 	logger.Info("Finished loading and start local projects", "total_projects", project.GetProjectsCount())
+	return failures
 }
 
 // readToken reads token from environment variable first, then from .token file, or creates one when create==true.
@@ -523,66 +569,89 @@ func readToken(create bool) (string, error) {
 
 // loadHubConfig loads config.yaml inside given root directory into common.Config.
 func loadHubConfig(root string) error {
-	// Initialize config
-	common.Config = &common.HubConfig{}
+	cfg, err := readHubConfig(root)
+	if err != nil {
+		return err
+	}
+	common.Config = cfg
+
+	lvl, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(lvl)
+
+	// SIGHUP and POST /reload-config read the file again the same way
+	common.SetHubConfigLoader(func() (*common.HubConfig, error) {
+		return readHubConfig(root)
+	})
+
+	logger.Info("Final Redis configuration", "host", cfg.Redis, "password_set", cfg.RedisPassword != "")
+	logger.Info("SIMD configuration", "enabled", cfg.SIMDEnabled)
+	return nil
+}
+
+// readHubConfig parses config.yaml inside given root directory with the environment overrides applied.
+func readHubConfig(root string) (*common.HubConfig, error) {
+	cfg := &common.HubConfig{}
 
 	// Try to load from config file first
 	cfgFile := filepath.Join(root, "config.yaml")
 	if data, err := os.ReadFile(cfgFile); err == nil {
-		if err := yaml.Unmarshal(data, &common.Config); err != nil {
-			logger.Error("Failed to parse config.yaml", "error", err)
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config.yaml: %w", err)
 		}
 	}
 
 	// Override with environment variables if set
 	if envRedis := os.Getenv("REDIS_HOST"); envRedis != "" {
-		common.Config.Redis = envRedis
+		cfg.Redis = envRedis
 		logger.Info("Using Redis host from environment variable", "host", envRedis)
 	}
 
 	if envRedisPort := os.Getenv("REDIS_PORT"); envRedisPort != "" {
 		// If REDIS_HOST is set, append port to it
-		if common.Config.Redis == "" {
-			common.Config.Redis = "localhost:" + envRedisPort
+		if cfg.Redis == "" {
+			cfg.Redis = "localhost:" + envRedisPort
 		} else {
 			// Extract host from current Redis config and append new port
-			if strings.Contains(common.Config.Redis, ":") {
-				host := strings.Split(common.Config.Redis, ":")[0]
-				common.Config.Redis = host + ":" + envRedisPort
+			if strings.Contains(cfg.Redis, ":") {
+				host := strings.Split(cfg.Redis, ":")[0]
+				cfg.Redis = host + ":" + envRedisPort
 			} else {
-				common.Config.Redis = common.Config.Redis + ":" + envRedisPort
+				cfg.Redis = cfg.Redis + ":" + envRedisPort
 			}
 		}
 		logger.Info("Using Redis port from environment variable", "port", envRedisPort)
 	}
 
 	if envRedisPassword := os.Getenv("REDIS_PASSWORD"); envRedisPassword != "" {
-		common.Config.RedisPassword = envRedisPassword
+		cfg.RedisPassword = envRedisPassword
 		logger.Info("Using Redis password from environment variable")
 	}
 
 	// Override SIMD configuration with environment variable if set
 	if envSIMDEnabled := os.Getenv("SIMD_ENABLED"); envSIMDEnabled != "" {
 		simdEnabled := strings.ToLower(envSIMDEnabled) == "true" || envSIMDEnabled == "1"
-		common.Config.SIMDEnabled = simdEnabled
+		cfg.SIMDEnabled = simdEnabled
 		logger.Info("Using SIMD enabled from environment variable", "enabled", simdEnabled)
 	}
 
 	// OIDC/OAuth2 environment overrides
 	if v := os.Getenv("OIDC_ENABLED"); v != "" {
-		common.Config.OIDCEnabled = strings.ToLower(v) == "true" || v == "1"
-		logger.Info("Using OIDC enabled from environment variable", "enabled", common.Config.OIDCEnabled)
+		cfg.OIDCEnabled = strings.ToLower(v) == "true" || v == "1"
+		logger.Info("Using OIDC enabled from environment variable", "enabled", cfg.OIDCEnabled)
 	}
 	if v := os.Getenv("OIDC_ISSUER"); v != "" {
-		common.Config.OIDCIssuer = v
+		cfg.OIDCIssuer = v
 		logger.Info("Using OIDC issuer from environment variable")
 	}
 	if v := os.Getenv("OIDC_CLIENT_ID"); v != "" {
-		common.Config.OIDCClientID = v
+		cfg.OIDCClientID = v
 		logger.Info("Using OIDC client_id from environment variable")
 	}
 	if v := os.Getenv("OIDC_USERNAME_CLAIM"); v != "" {
-		common.Config.OIDCUsernameClaim = v
+		cfg.OIDCUsernameClaim = v
 	}
 	if v := os.Getenv("OIDC_ALLOWED_USERS"); v != "" {
 		parts := strings.Split(v, ",")
@@ -593,48 +662,45 @@ func loadHubConfig(root string) error {
 				allowed = append(allowed, p)
 			}
 		}
-		common.Config.OIDCAllowedUsers = allowed
+		cfg.OIDCAllowedUsers = allowed
 	}
 	if v := os.Getenv("OIDC_REDIRECT_URI"); v != "" {
-		common.Config.OIDCRedirectURI = v
+		cfg.OIDCRedirectURI = v
 	}
 	if v := os.Getenv("OIDC_SCOPE"); v != "" {
-		common.Config.OIDCScope = v
+		cfg.OIDCScope = v
 	}
 
 	// Set OIDC defaults and validations during config parsing
-	if common.Config.OIDCEnabled {
-		if common.Config.OIDCScope == "" {
-			common.Config.OIDCScope = "openid profile email"
+	if cfg.OIDCEnabled {
+		if cfg.OIDCScope == "" {
+			cfg.OIDCScope = "openid profile email"
 		}
-		if common.Config.OIDCUsernameClaim == "" {
-			common.Config.OIDCUsernameClaim = "preferred_username"
+		if cfg.OIDCUsernameClaim == "" {
+			cfg.OIDCUsernameClaim = "preferred_username"
 		}
 		// issuer and client_id are required when OIDC is enabled
-		if strings.TrimSpace(common.Config.OIDCIssuer) == "" {
-			return fmt.Errorf("OIDC is enabled but OIDC_ISSUER (oidc_issuer) is not set")
+		if strings.TrimSpace(cfg.OIDCIssuer) == "" {
+			return nil, fmt.Errorf("OIDC is enabled but OIDC_ISSUER (oidc_issuer) is not set")
 		}
-		if strings.TrimSpace(common.Config.OIDCClientID) == "" {
-			return fmt.Errorf("OIDC is enabled but OIDC_CLIENT_ID (oidc_client_id) is not set")
+		if strings.TrimSpace(cfg.OIDCClientID) == "" {
+			return nil, fmt.Errorf("OIDC is enabled but OIDC_CLIENT_ID (oidc_client_id) is not set")
 		}
 		// redirect_uri is required when OIDC is enabled
-		if strings.TrimSpace(common.Config.OIDCRedirectURI) == "" {
-			return fmt.Errorf("OIDC is enabled but OIDC_REDIRECT_URI (oidc_redirect_uri) is not set")
+		if strings.TrimSpace(cfg.OIDCRedirectURI) == "" {
+			return nil, fmt.Errorf("OIDC is enabled but OIDC_REDIRECT_URI (oidc_redirect_uri) is not set")
 		}
 	}
 
 	// Set config root
-	common.Config.ConfigRoot = root
+	cfg.ConfigRoot = root
 
 	// Validate Redis configuration
-	if common.Config.Redis == "" {
-		return fmt.Errorf("Redis host not configured. Please set REDIS_HOST environment variable or configure in config.yaml")
+	if cfg.Redis == "" {
+		return nil, fmt.Errorf("Redis host not configured. Please set REDIS_HOST environment variable or configure in config.yaml")
 	}
 
-	logger.Info("Final Redis configuration", "host", common.Config.Redis, "password_set", common.Config.RedisPassword != "")
-	logger.Info("SIMD configuration", "enabled", common.Config.SIMDEnabled)
-
-	return nil
+	return cfg, nil
 }
 
 // startPprofServer starts the pprof HTTP server if enabled in configuration
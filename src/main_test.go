@@ -0,0 +1,95 @@
+package main
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStrictStartupWithBrokenComponent(t *testing.T) {
+	origConfig := common.Config
+	defer func() { common.Config = origConfig }()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "ruleset"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "ruleset", "broken.xml"), []byte("<root type=\"DETECTION\"><rule id=\"r1\">"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	common.Config = &common.HubConfig{ConfigRoot: root}
+	failures := loadLocalComponents()
+	if len(failures) != 1 || !strings.HasPrefix(failures[0], "ruleset/broken: ") {
+		t.Fatalf("expected one ruleset failure, got %v", failures)
+	}
+
+	if err := strictStartupError(failures); err != nil {
+		t.Fatalf("lenient startup should not abort, got %v", err)
+	}
+
+	common.Config.StrictStartup = true
+	err := strictStartupError(failures)
+	if err == nil || !strings.Contains(err.Error(), "ruleset/broken") {
+		t.Fatalf("strict startup should abort naming the broken ruleset, got %v", err)
+	}
+	if err := strictStartupError(nil); err != nil {
+		t.Fatalf("strict startup without failures should not abort, got %v", err)
+	}
+}
+
+func TestReloadHubConfigAppliesSafeChanges(t *testing.T) {
+	origConfig := common.Config
+	origLevel := logger.GetLevel()
+	defer func() {
+		common.Config = origConfig
+		logger.SetLevel(origLevel)
+	}()
+
+	root := t.TempDir()
+	writeConfig := func(content string) {
+		if err := os.WriteFile(filepath.Join(root, "config.yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("redis: 10.0.0.1:6379\nlog_level: info\n")
+	if err := loadHubConfig(root); err != nil {
+		t.Fatal(err)
+	}
+	if logger.GetLevel() != slog.LevelInfo {
+		t.Fatalf("expected info level at startup, got %v", logger.GetLevel())
+	}
+
+	writeConfig("redis: 10.0.0.2:6379\nlog_level: debug\n")
+	result, err := common.ReloadHubConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.GetLevel() != slog.LevelDebug || common.Config.LogLevel != "debug" {
+		t.Fatalf("expected the log level to change live, got %v", logger.GetLevel())
+	}
+	if common.Config.Redis != "10.0.0.1:6379" {
+		t.Fatalf("the redis host must keep its running value until restart, got %s", common.Config.Redis)
+	}
+	if !reflect.DeepEqual(result.Applied, []string{"log_level"}) || !reflect.DeepEqual(result.RestartRequired, []string{"redis"}) {
+		t.Fatalf("expected log_level applied and redis restart-required, got %+v", result)
+	}
+
+	// An invalid file changes nothing
+	writeConfig("redis: 10.0.0.1:6379\nlog_level: loud\n")
+	if _, err := common.ReloadHubConfig(); err == nil || logger.GetLevel() != slog.LevelDebug {
+		t.Fatalf("expected an invalid log level to be rejected, got %v", err)
+	}
+
+	// Nor does a file that is not valid YAML, even with the Redis host coming from the environment
+	t.Setenv("REDIS_HOST", "10.0.0.1:6379")
+	writeConfig("log_level: [info\n")
+	if _, err := common.ReloadHubConfig(); err == nil || common.Config.LogLevel != "debug" {
+		t.Fatalf("expected a malformed config.yaml to be rejected, got %v (log_level %q)", err, common.Config.LogLevel)
+	}
+}
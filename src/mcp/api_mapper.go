@@ -4814,7 +4814,7 @@ func Eval(funcName string, params ...interface{}) (interface{}, error) {
 		%s
 		
 		// TODO: Implement your action logic here
-		// Example: fmt.Printf("Action executed with: %s\n", value)
+		// Example: fmt.Printf("Action executed with: %%s\n", value)
 		fmt.Printf("Action executed\n")
 		return true, nil
 	}
@@ -4967,31 +4967,39 @@ func (m *APIMapper) handleCreateRuleComplete(args map[string]interface{}) (commo
 		results = append(results, fmt.Sprintf("🔗 Target Projects: %s", targetProjects))
 	}
 
-	// Step 1: Auto-fetch sample data if not provided
+	// Step 1: Auto-fetch real sample data from the target projects' inputs if not provided
 	if sampleData == "" && targetProjects != "" {
 		results = append(results, "\n## Step 1: Auto-fetching Sample Data")
-		dataArgs := map[string]interface{}{
-			"target_projects": targetProjects,
-			"rule_purpose":    rulePurpose,
-			"analysis_mode":   "basic",
-		}
-		dataResult, err := m.handleGetSamplersDataIntelligent(dataArgs)
-		if err == nil && !dataResult.IsError && len(dataResult.Content) > 0 {
-			// Extract JSON from the response
-			content := dataResult.Content[0].Text
-			if strings.Contains(content, "```json") {
-				start := strings.Index(content, "```json") + 7
-				end := strings.Index(content[start:], "```")
-				if end > 0 {
-					sampleData = content[start : start+end]
-					results = append(results, "✓ Sample data auto-fetched from target projects")
-				}
+		samples, notes, err := m.fetchProjectSamples(targetProjects, maxAutoFetchedSamples)
+		if err != nil {
+			return errors.NewValidationErrorWithSuggestions(
+				fmt.Sprintf("cannot fetch sample data: %v", err),
+				[]string{
+					"Use 'get_projects' to list the available project IDs",
+					"Provide real JSON events in the 'sample_data' parameter instead",
+				},
+			).ToMCPResult(), nil
+		}
+		if len(samples) == 0 {
+			text := "❌ NO SAMPLE DATA: the inputs of the target projects have not sampled any events yet.\n"
+			if len(notes) > 0 {
+				text += "\n- " + strings.Join(notes, "\n- ") + "\n"
 			}
+			text += "\n🎯 Start the projects and let events flow, or provide real JSON events in the 'sample_data' parameter."
+			return common.MCPToolResult{
+				Content: []common.MCPToolContent{{Type: "text", Text: text}},
+				IsError: true,
+			}, nil
 		}
-
-		if sampleData == "" {
-			results = append(results, "⚠️ No sample data available - will generate template rule")
+		encoded, err := json.Marshal(samples)
+		if err != nil {
+			return common.MCPToolResult{
+				Content: []common.MCPToolContent{{Type: "text", Text: fmt.Sprintf("Failed to encode sample data: %v", err)}},
+				IsError: true,
+			}, nil
 		}
+		sampleData = string(encoded)
+		results = append(results, fmt.Sprintf("✓ %d real sample events fetched from target projects", len(samples)))
 	}
 
 	// Step 2: Generate intelligent rule
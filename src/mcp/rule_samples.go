@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxAutoFetchedSamples caps the real events create_rule_complete collects from the target projects
+const maxAutoFetchedSamples = 5
+
+// resolveTargetProjects turns the target_projects argument into project IDs. "auto" selects every
+// project known to the backend.
+func (m *APIMapper) resolveTargetProjects(targetProjects string) ([]string, error) {
+	var ids []string
+	if strings.TrimSpace(targetProjects) != "auto" {
+		for _, id := range strings.Split(targetProjects, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	response, err := m.makeHTTPRequest("GET", "/projects", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	var projects []map[string]interface{}
+	if err := json.Unmarshal(response, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse project list: %w", err)
+	}
+	for _, p := range projects {
+		if id, ok := p["id"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// fetchProjectSamples collects real sampled events from the inputs of the target projects through
+// get_samplers_data, at most limit of them. The problems met on the way are returned as notes so an
+// empty result can be explained; an error is returned only when no project could be resolved.
+func (m *APIMapper) fetchProjectSamples(targetProjects string, limit int) ([]map[string]interface{}, []string, error) {
+	projectIDs, err := m.resolveTargetProjects(targetProjects)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(projectIDs) == 0 {
+		return nil, nil, fmt.Errorf("no target projects found in '%s'", targetProjects)
+	}
+
+	var samples []map[string]interface{}
+	var notes []string
+	for _, projectID := range projectIDs {
+		response, err := m.makeHTTPRequest("GET", "/project-inputs/"+url.PathEscape(projectID), nil, true)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("project %s: failed to list inputs: %v", projectID, err))
+			continue
+		}
+		var projectInputs struct {
+			Inputs []map[string]string `json:"inputs"`
+		}
+		if err := json.Unmarshal(response, &projectInputs); err != nil {
+			notes = append(notes, fmt.Sprintf("project %s: failed to parse inputs: %v", projectID, err))
+			continue
+		}
+		if len(projectInputs.Inputs) == 0 {
+			notes = append(notes, fmt.Sprintf("project %s: no inputs", projectID))
+			continue
+		}
+
+		for _, in := range projectInputs.Inputs {
+			inputID := in["name"]
+			if inputID == "" {
+				continue
+			}
+			inputSamples, err := m.fetchInputSamples(inputID, limit-len(samples))
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("input %s: %v", inputID, err))
+				continue
+			}
+			if len(inputSamples) == 0 {
+				notes = append(notes, fmt.Sprintf("input %s: no sampled events yet", inputID))
+				continue
+			}
+			samples = append(samples, inputSamples...)
+			if len(samples) >= limit {
+				return samples, notes, nil
+			}
+		}
+	}
+	return samples, notes, nil
+}
+
+// fetchInputSamples returns up to limit sampled events of an input, as get_samplers_data returns them
+func (m *APIMapper) fetchInputSamples(inputID string, limit int) ([]map[string]interface{}, error) {
+	query := url.Values{}
+	query.Set("name", "input")
+	query.Set("projectNodeSequence", "input."+inputID)
+	response, err := m.makeHTTPRequest("GET", "/samplers/data?"+query.Encode(), nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Input map[string][]struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"input"`
+	}
+	if err := json.Unmarshal(response, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse sample data: %w", err)
+	}
+
+	sequences := make([]string, 0, len(data.Input))
+	for sequence := range data.Input {
+		sequences = append(sequences, sequence)
+	}
+	sort.Strings(sequences)
+
+	var samples []map[string]interface{}
+	for _, sequence := range sequences {
+		for _, s := range data.Input[sequence] {
+			if len(s.Data) == 0 {
+				continue
+			}
+			samples = append(samples, s.Data)
+			if len(samples) >= limit {
+				return samples, nil
+			}
+		}
+	}
+	return samples, nil
+}
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sampleBackend mocks the hub API: project p1 reads from input in1, whose sampler holds samples
+func sampleBackend(t *testing.T, samples []map[string]interface{}) (*httptest.Server, *[]string, *sync.Mutex) {
+	var mu sync.Mutex
+	var testBodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/project-inputs/p1":
+			w.Write([]byte(`{"success": true, "inputs": [{"id": "input.in1", "name": "in1"}]}`))
+		case r.URL.Path == "/samplers/data":
+			if r.URL.Query().Get("projectNodeSequence") != "input.in1" {
+				t.Errorf("unexpected sampler query %s", r.URL.RawQuery)
+			}
+			flow := make([]map[string]interface{}, 0, len(samples))
+			for _, s := range samples {
+				flow = append(flow, map[string]interface{}{"data": s, "project_node_sequence": "INPUT.in1"})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"input": map[string]interface{}{"INPUT.in1": flow}})
+		case strings.HasPrefix(r.URL.Path, "/test-ruleset/"):
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			testBodies = append(testBodies, string(body))
+			mu.Unlock()
+			w.Write([]byte(`{"success": true}`))
+		default:
+			w.Write([]byte(`{"success": true}`))
+		}
+	}))
+	return srv, &testBodies, &mu
+}
+
+func TestCreateRuleComplete_FetchesRealSamples(t *testing.T) {
+	srv, testBodies, mu := sampleBackend(t, []map[string]interface{}{
+		{"event_type": "login", "user": "alice", "src_ip": "10.0.0.1"},
+		{"event_type": "login", "user": "bob", "src_ip": "10.0.0.2"},
+	})
+	defer srv.Close()
+	m := NewAPIMapper(srv.URL, "token")
+
+	result, err := m.handleCreateRuleComplete(map[string]interface{}{
+		"ruleset_id":      "rs1",
+		"rule_purpose":    "detect logins",
+		"target_projects": "p1",
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected failure: %v %+v", err, result)
+	}
+	if !strings.Contains(result.Content[0].Text, "2 real sample events fetched") {
+		t.Fatalf("expected the samples to be fetched, got %s", result.Content[0].Text)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(*testBodies) == 0 || !strings.Contains((*testBodies)[0], "alice") {
+		t.Fatalf("expected the rule tested with the fetched samples, got %v", *testBodies)
+	}
+}
+
+func TestCreateRuleComplete_NoSamplesFailsClearly(t *testing.T) {
+	srv, _, _ := sampleBackend(t, nil)
+	defer srv.Close()
+	m := NewAPIMapper(srv.URL, "token")
+
+	result, err := m.handleCreateRuleComplete(map[string]interface{}{
+		"ruleset_id":      "rs1",
+		"rule_purpose":    "detect logins",
+		"target_projects": "p1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := result.Content[0].Text
+	if !result.IsError || !strings.Contains(text, "NO SAMPLE DATA") || !strings.Contains(text, "input in1: no sampled events yet") {
+		t.Fatalf("expected a clear no-sample error, got %s", text)
+	}
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+const deadLetterMaxLen = 1000
+
+// Reasons of dead letter entries
+const (
+	DeadLetterInsertFailed = "insert_failed"
+)
+
+// DeadLetter is one event the output could not write
+type DeadLetter struct {
+	Output    string                 `json:"output"`
+	Reason    string                 `json:"reason"`
+	Error     string                 `json:"error,omitempty"`
+	Event     map[string]interface{} `json:"event"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// deadLetterPush stores an entry in the dead letter queue, replaced in tests
+var deadLetterPush = common.RedisLPush
+
+// DeadLetterKey is the Redis list holding the dead letters of an output, newest first
+func DeadLetterKey(outputID string) string {
+	return "hub:dlq:output:" + outputID
+}
+
+// deadLetter stores an event in the dead letter queue of the output. When the queue cannot be
+// written the event goes to the error log, so it is still never dropped silently.
+func (out *Output) deadLetter(reason string, cause error, event map[string]interface{}) {
+	entry := DeadLetter{Output: out.Id, Reason: reason, Event: event, Timestamp: time.Now()}
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+	data, err := common.SafeMarshal(entry)
+	if err == nil {
+		err = deadLetterPush(DeadLetterKey(out.Id), string(data), deadLetterMaxLen)
+	}
+	if err != nil {
+		logger.Error("Failed to write dead letter", "output", out.Id, "reason", reason, "cause", entry.Error, "error", err,
+			common.ErrorLogContextKey, common.NewErrorLogContext(event))
+	}
+}
+
+// DeadLetters returns the newest dead letters of an output, up to limit
+func DeadLetters(outputID string, limit int) ([]DeadLetter, error) {
+	items, err := common.RedisLRange(DeadLetterKey(outputID), 0, int64(limit)-1)
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]DeadLetter, 0, len(items))
+	for _, item := range items {
+		var letter DeadLetter
+		if err := sonic.Unmarshal([]byte(item), &letter); err != nil {
+			continue
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
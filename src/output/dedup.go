@@ -0,0 +1,184 @@
+package output
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"AgentSmith-HUB/rules_engine"
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultDedupWindow  = time.Minute
+	defaultDedupMaxKeys = 100000
+)
+
+// DedupConfig suppresses writing events whose key was already written within the window,
+// e.g. duplicates caused by upstream retries.
+type DedupConfig struct {
+	Fields  []string `yaml:"fields"`             // Event fields forming the key
+	Window  string   `yaml:"window,omitempty"`   // Default 1m
+	MaxKeys int      `yaml:"max_keys,omitempty"` // Bound of remembered keys, the oldest are evicted first (default 100000)
+	// Cluster claims each key in Redis for the window, so nodes processing overlapping data write a
+	// detection once. The key also holds the ids of the rules that hit.
+	Cluster bool `yaml:"cluster,omitempty"`
+}
+
+// claimClusterKey claims a dedup key for the cluster until ttl expires, reporting whether this
+// node got it first. Replaced in tests.
+var claimClusterKey = func(key string, ttl time.Duration) (bool, error) {
+	seconds := int((ttl + time.Second - 1) / time.Second)
+	return common.RedisSetNX(key, 1, seconds)
+}
+
+// verifyDedupConfig validates the dedup section of an output config
+func verifyDedupConfig(cfg *DedupConfig) error {
+	if len(cfg.Fields) == 0 {
+		return fmt.Errorf("missing required field 'dedup.fields' (line: unknown)")
+	}
+	for _, f := range cfg.Fields {
+		if strings.TrimSpace(f) == "" {
+			return fmt.Errorf("dedup.fields cannot contain an empty field (line: unknown)")
+		}
+	}
+	if cfg.Window != "" {
+		d, err := time.ParseDuration(cfg.Window)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid dedup.window '%s', expected a positive duration like 30s or 5m (line: unknown)", cfg.Window)
+		}
+	}
+	if cfg.MaxKeys < 0 {
+		return fmt.Errorf("dedup.max_keys cannot be negative (line: unknown)")
+	}
+	return nil
+}
+
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+// outputDedup remembers the keys written within the window, bounded by maxKeys
+type outputDedup struct {
+	mu         sync.Mutex
+	fieldLists [][]string
+	window     time.Duration
+	maxKeys    int
+	entries    map[string]*list.Element
+	order      *list.List // Oldest first
+	suppressed uint64
+	now        func() time.Time
+	// clusterPrefix namespaces the Redis keys of the output, empty unless dedup is cluster-wide
+	clusterPrefix string
+}
+
+// newOutputDedup builds the deduplicator of a verified config, nil when dedup is not configured
+func newOutputDedup(cfg *DedupConfig, outputID string) *outputDedup {
+	if cfg == nil {
+		return nil
+	}
+	d := &outputDedup{
+		window:  defaultDedupWindow,
+		maxKeys: defaultDedupMaxKeys,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+	if w, err := time.ParseDuration(cfg.Window); err == nil && w > 0 {
+		d.window = w
+	}
+	if cfg.MaxKeys > 0 {
+		d.maxKeys = cfg.MaxKeys
+	}
+	for _, f := range cfg.Fields {
+		d.fieldLists = append(d.fieldLists, common.StringToList(strings.TrimSpace(f)))
+	}
+	if cfg.Cluster {
+		d.clusterPrefix = "output_dedup:" + outputID + ":"
+	}
+	return d
+}
+
+// Duplicate reports whether the event should be suppressed and otherwise remembers its key.
+// Events without any of the key fields are never suppressed. A nil deduplicator keeps everything.
+func (d *outputDedup) Duplicate(msg map[string]interface{}) bool {
+	if d == nil {
+		return false
+	}
+	parts := make([]string, len(d.fieldLists))
+	found := false
+	for i, fl := range d.fieldLists {
+		if v, ok := common.GetCheckData(msg, fl); ok {
+			parts[i] = v
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+	key := strings.Join(parts, "\x1f")
+	if d.clusterPrefix != "" {
+		if ruleIDs, ok := msg[rules_engine.HitRuleIdFieldName].(string); ok {
+			key = ruleIDs + "\x1e" + key
+		}
+	}
+
+	d.mu.Lock()
+	now := d.now()
+	for e := d.order.Front(); e != nil; e = d.order.Front() {
+		entry := e.Value.(*dedupEntry)
+		if entry.expires.After(now) {
+			break
+		}
+		d.order.Remove(e)
+		delete(d.entries, entry.key)
+	}
+
+	if _, ok := d.entries[key]; ok {
+		d.mu.Unlock()
+		atomic.AddUint64(&d.suppressed, 1)
+		return true
+	}
+	if d.clusterPrefix == "" {
+		d.remember(key, now)
+		d.mu.Unlock()
+		return false
+	}
+	d.mu.Unlock()
+
+	// Another node may have written the same detection, the first claim in Redis wins. The event is
+	// written when Redis is unavailable, a duplicate is better than a lost detection.
+	duplicate := false
+	claimed, err := claimClusterKey(d.clusterPrefix+common.XXHash64(key), d.window)
+	if err != nil {
+		logger.Warn("Failed to claim cluster dedup key, writing the event", "error", err)
+	} else if !claimed {
+		atomic.AddUint64(&d.suppressed, 1)
+		duplicate = true
+	}
+	d.mu.Lock()
+	d.remember(key, now)
+	d.mu.Unlock()
+	return duplicate
+}
+
+// remember keeps a written key for the window, evicting the oldest key at the bound. Callers hold mu.
+func (d *outputDedup) remember(key string, now time.Time) {
+	if d.order.Len() >= d.maxKeys {
+		oldest := d.order.Front()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dedupEntry).key)
+	}
+	d.entries[key] = d.order.PushBack(&dedupEntry{key: key, expires: now.Add(d.window)})
+}
+
+func (d *outputDedup) Suppressed() uint64 {
+	if d == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&d.suppressed)
+}
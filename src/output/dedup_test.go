@@ -0,0 +1,153 @@
+package output
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOutputDedup_WritesDuplicateKeysOnce(t *testing.T) {
+	out, err := NewOutput("", "type: print\ndedup:\n  fields: [event_id]\n  window: 1m\n", "dedup_out")
+	if err != nil {
+		t.Fatalf("NewOutput: %v", err)
+	}
+	out.SetTestMode()
+
+	up := make(chan map[string]interface{}, 10)
+	collected := make(chan map[string]interface{}, 10)
+	out.UpStream["up"] = &up
+	out.TestCollectionChan = &collected
+
+	if err := out.StartForTesting(); err != nil {
+		t.Fatalf("StartForTesting: %v", err)
+	}
+	up <- map[string]interface{}{"event_id": "a", "n": 1}
+	up <- map[string]interface{}{"event_id": "a", "n": 2}
+	up <- map[string]interface{}{"event_id": "b", "n": 3}
+	up <- map[string]interface{}{"n": 4} // no key, never suppressed
+
+	deadline := time.After(2 * time.Second)
+	var got []int
+	for len(got) < 3 {
+		select {
+		case msg := <-collected:
+			got = append(got, msg["n"].(int))
+		case <-deadline:
+			t.Fatalf("timed out, collected %v", got)
+		}
+	}
+	_ = out.StopForTesting()
+
+	if len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 4 {
+		t.Fatalf("unexpected written events: %v", got)
+	}
+	if n := out.GetDedupSuppressed(); n != 1 {
+		t.Fatalf("expected 1 suppressed event, got %d", n)
+	}
+}
+
+func TestOutputDedup_WindowAndBound(t *testing.T) {
+	d := newOutputDedup(&DedupConfig{Fields: []string{"id"}, Window: "10s", MaxKeys: 2}, "dedup_out")
+	now := time.Unix(1000, 0)
+	d.now = func() time.Time { return now }
+
+	ev := func(id string) map[string]interface{} { return map[string]interface{}{"id": id} }
+	if d.Duplicate(ev("a")) || !d.Duplicate(ev("a")) {
+		t.Fatal("second 'a' within the window must be suppressed")
+	}
+
+	now = now.Add(11 * time.Second)
+	if d.Duplicate(ev("a")) {
+		t.Fatal("'a' must be written again after the window")
+	}
+
+	// Bound of two keys: adding c evicts a
+	d.Duplicate(ev("b"))
+	d.Duplicate(ev("c"))
+	if d.Duplicate(ev("a")) {
+		t.Fatal("evicted key must be written again")
+	}
+}
+
+func TestVerifyDedupConfig(t *testing.T) {
+	if err := Verify("", "type: print\ndedup:\n  window: 1m\n"); err == nil {
+		t.Fatal("dedup without fields must be rejected")
+	}
+	if err := Verify("", "type: print\ndedup:\n  fields: [id]\n  window: soon\n"); err == nil {
+		t.Fatal("invalid dedup window must be rejected")
+	}
+}
+
+func TestOutputDedup_ClusterWritesDetectionOnce(t *testing.T) {
+	// Redis SETNX shared by the nodes
+	var mu sync.Mutex
+	claimed := make(map[string]bool)
+	prev := claimClusterKey
+	claimClusterKey = func(key string, ttl time.Duration) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if claimed[key] {
+			return false, nil
+		}
+		claimed[key] = true
+		return true, nil
+	}
+	defer func() { claimClusterKey = prev }()
+
+	config := "type: print\ndedup:\n  fields: [fingerprint]\n  window: 10m\n  cluster: true\n"
+	collected := make(chan map[string]interface{}, 10)
+	nodes := make([]*Output, 2)
+	ups := make([]chan map[string]interface{}, 2)
+	for i := range nodes {
+		out, err := NewOutput("", config, "cluster_dedup_out")
+		if err != nil {
+			t.Fatalf("NewOutput: %v", err)
+		}
+		out.SetTestMode()
+		ups[i] = make(chan map[string]interface{}, 10)
+		out.UpStream["up"] = &ups[i]
+		out.TestCollectionChan = &collected
+		if err := out.StartForTesting(); err != nil {
+			t.Fatalf("StartForTesting: %v", err)
+		}
+		nodes[i] = out
+	}
+
+	detection := func(node int, rule string) map[string]interface{} {
+		return map[string]interface{}{"fingerprint": "host1|proc", "_hub_hit_rule_id": rule, "node": node}
+	}
+	// Both nodes produce the same detection, a different rule on the same fingerprint is another one
+	ups[0] <- detection(0, "rule_a")
+	ups[1] <- detection(1, "rule_a")
+	ups[1] <- detection(1, "rule_b")
+
+	var got []map[string]interface{}
+	deadline := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case msg := <-collected:
+			got = append(got, msg)
+		case <-deadline:
+			t.Fatalf("timed out, collected %v", got)
+		}
+	}
+	select {
+	case msg := <-collected:
+		t.Fatalf("the detection was written twice: %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	for _, out := range nodes {
+		_ = out.StopForTesting()
+	}
+
+	rules := map[string]int{}
+	for _, msg := range got {
+		rules[msg["_hub_hit_rule_id"].(string)]++
+	}
+	if rules["rule_a"] != 1 || rules["rule_b"] != 1 {
+		t.Fatalf("expected one write per detection, got %v", got)
+	}
+	if n := nodes[0].GetDedupSuppressed() + nodes[1].GetDedupSuppressed(); n != 1 {
+		t.Fatalf("expected 1 suppressed event across the nodes, got %d", n)
+	}
+}
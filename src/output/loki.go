@@ -0,0 +1,215 @@
+package output
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxLokiLabels is the default limit of label names per stream of Loki (max_label_names_per_series)
+const maxLokiLabels = 15
+
+var lokiLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// verifyLokiConfig validates the URL and the labels of a Loki output
+func verifyLokiConfig(cfg *LokiOutputConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("missing required field 'loki' for loki output (line: unknown)")
+	}
+	if cfg.URL == "" {
+		return fmt.Errorf("missing required field 'loki.url' for loki output (line: unknown)")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid 'loki.url' %q: must be an http or https URL (line: unknown)", cfg.URL)
+	}
+
+	if len(cfg.Labels)+len(cfg.StaticLabels) == 0 {
+		return fmt.Errorf("loki output needs at least one label in 'loki.labels' or 'loki.static_labels' (line: unknown)")
+	}
+	if len(cfg.Labels)+len(cfg.StaticLabels) > maxLokiLabels {
+		return fmt.Errorf("loki output has %d labels, Loki accepts at most %d per stream (line: unknown)", len(cfg.Labels)+len(cfg.StaticLabels), maxLokiLabels)
+	}
+	for name, field := range cfg.Labels {
+		if err := verifyLokiLabelName(name); err != nil {
+			return err
+		}
+		if strings.TrimSpace(field) == "" {
+			return fmt.Errorf("loki label %s needs an event field (line: unknown)", name)
+		}
+		if _, ok := cfg.StaticLabels[name]; ok {
+			return fmt.Errorf("loki label %s is both in 'loki.labels' and 'loki.static_labels' (line: unknown)", name)
+		}
+	}
+	for name, value := range cfg.StaticLabels {
+		if err := verifyLokiLabelName(name); err != nil {
+			return err
+		}
+		if value == "" {
+			return fmt.Errorf("loki static label %s cannot be empty (line: unknown)", name)
+		}
+	}
+
+	if cfg.MaxLabelValues < 0 {
+		return fmt.Errorf("'loki.max_label_values' cannot be negative (line: unknown)")
+	}
+	if cfg.BatchSize < 0 {
+		return fmt.Errorf("'loki.batch_size' cannot be negative (line: unknown)")
+	}
+	if cfg.FlushDur != "" {
+		if d, err := time.ParseDuration(cfg.FlushDur); err != nil || d <= 0 {
+			return fmt.Errorf("invalid 'loki.flush_dur' %q: must be a positive duration like 3s (line: unknown)", cfg.FlushDur)
+		}
+	}
+	if cfg.Auth != nil && cfg.Auth.Type != "basic" && cfg.Auth.Type != "bearer" {
+		return fmt.Errorf("invalid 'loki.auth.type' %q: must be basic or bearer (line: unknown)", cfg.Auth.Type)
+	}
+	if err := common.VerifyConnPoolConfig("loki.pool", cfg.Pool); err != nil {
+		return fmt.Errorf("%v (line: unknown)", err)
+	}
+	return nil
+}
+
+func verifyLokiLabelName(name string) error {
+	if !lokiLabelName.MatchString(name) {
+		return fmt.Errorf("invalid loki label name %q: must match %s (line: unknown)", name, lokiLabelName.String())
+	}
+	if strings.HasPrefix(name, "__") {
+		return fmt.Errorf("invalid loki label name %q: names starting with __ are reserved by Loki (line: unknown)", name)
+	}
+	return nil
+}
+
+// newLokiProducer creates the producer of a Loki output, pushing what it receives on msgChan
+func (out *Output) newLokiProducer(msgChan chan map[string]interface{}) *common.LokiProducer {
+	batchSize := 100
+	if out.lokiCfg.BatchSize > 0 {
+		batchSize = out.lokiCfg.BatchSize
+	}
+	flushDur := 3 * time.Second
+	if out.lokiCfg.FlushDur != "" {
+		if d, err := time.ParseDuration(out.lokiCfg.FlushDur); err == nil {
+			flushDur = d
+		}
+	}
+	labeler := common.NewLokiLabeler(out.lokiCfg.StaticLabels, out.lokiCfg.Labels, out.lokiCfg.MaxLabelValues, out.Id)
+	return common.NewLokiProducer(out.lokiCfg.URL, out.lokiCfg.TenantID, out.lokiCfg.Auth, labeler, out.lokiCfg.TimestampField, msgChan, batchSize, flushDur, common.OutputConnPool(out.Id, out.lokiCfg.Pool), common.OutputLatency(out.Id))
+}
+
+// startLoki starts the producer of a Loki output and the goroutine feeding it from the upstreams
+func (out *Output) startLoki(hasTestCollector bool) error {
+	if out.lokiProducer != nil {
+		return fmt.Errorf("loki producer already running for output %s", out.Id)
+	}
+	if out.lokiCfg == nil {
+		return fmt.Errorf("loki configuration missing for output %s", out.Id)
+	}
+
+	msgChan := make(chan map[string]interface{}, 1024)
+	out.lokiProducer = out.newLokiProducer(msgChan)
+	if out.stopChan == nil {
+		out.stopChan = make(chan struct{})
+	}
+
+	out.wg.Add(1)
+	go func() {
+		defer out.wg.Done()
+		defer close(msgChan) // Close msgChan when UpStream processing is done
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic in loki output goroutine", "output", out.Id, "panic", r)
+			}
+		}()
+
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-out.stopChan:
+				logger.Debug("Loki output goroutine received stop signal", "id", out.Id)
+				return
+			case <-ticker.C:
+			}
+
+			for _, up := range out.UpStream {
+				select {
+				case <-out.stopChan:
+					logger.Debug("Loki output goroutine received stop signal during upstream processing", "id", out.Id)
+					return
+				default:
+				}
+
+				select {
+				case msg, ok := <-*up:
+					if !ok {
+						continue
+					}
+					if out.dedup.Duplicate(msg) {
+						continue
+					}
+					atomic.AddUint64(&out.produceTotal, 1)
+					if out.sampler != nil {
+						out.sampler.Sample(msg, out.ProjectNodeSequence)
+					}
+
+					enhancedMsg := out.enhanceMessageWithProjectNodeSequence(msg)
+					if hasTestCollector {
+						select {
+						case *out.TestCollectionChan <- enhancedMsg:
+						default:
+							logger.Warn("Test collection channel full, dropping message", "id", out.Id, "type", "loki")
+						}
+					}
+
+					select {
+					case msgChan <- enhancedMsg:
+					default:
+						logger.Warn("Loki producer channel full, dropping message", "id", out.Id)
+					}
+				default:
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// checkLokiConnectivity fills the connectivity result of a Loki output
+func (out *Output) checkLokiConnectivity(result map[string]interface{}) {
+	details := result["details"].(map[string]interface{})
+	if out.lokiCfg == nil {
+		result["status"] = "error"
+		result["message"] = "Loki configuration missing"
+		details["connection_status"] = "not_configured"
+		details["connection_errors"] = []map[string]interface{}{
+			{"message": "Loki configuration is incomplete or missing", "severity": "error"},
+		}
+		return
+	}
+
+	details["connection_info"] = map[string]interface{}{
+		"url":       common.LokiPushURL(out.lokiCfg.URL),
+		"tenant_id": out.lokiCfg.TenantID,
+	}
+	if err := common.TestLokiConnection(out.lokiCfg.URL, out.lokiCfg.TenantID, out.lokiCfg.Auth); err != nil {
+		result["status"] = "error"
+		result["message"] = "Failed to connect to Loki"
+		details["connection_status"] = "connection_failed"
+		details["connection_errors"] = []map[string]interface{}{
+			{"message": err.Error(), "severity": "error"},
+		}
+		return
+	}
+	details["connection_status"] = "connected"
+	result["message"] = "Successfully connected to Loki"
+	details["metrics"] = map[string]interface{}{
+		"produce_total":   out.GetProduceTotal(),
+		"producer_active": out.lokiProducer != nil,
+	}
+}
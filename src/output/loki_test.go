@@ -0,0 +1,30 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerify_LokiConfig(t *testing.T) {
+	valid := "type: loki\nloki:\n  url: http://loki:3100\n  labels:\n    host: host.name\n  static_labels:\n    job: hub\n"
+	if err := Verify("", valid); err != nil {
+		t.Fatalf("expected a valid config, got %v", err)
+	}
+
+	cases := map[string]string{
+		"missing url":    "type: loki\nloki:\n  labels:\n    host: host\n",
+		"bad scheme":     "type: loki\nloki:\n  url: loki:3100\n  labels:\n    host: host\n",
+		"no labels":      "type: loki\nloki:\n  url: http://loki:3100\n",
+		"bad label":      "type: loki\nloki:\n  url: http://loki:3100\n  labels:\n    host-name: host\n",
+		"reserved label": "type: loki\nloki:\n  url: http://loki:3100\n  static_labels:\n    __name__: hub\n",
+		"empty field":    "type: loki\nloki:\n  url: http://loki:3100\n  labels:\n    host: \"\"\n",
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Verify("", raw)
+			if err == nil || !strings.Contains(err.Error(), "loki") {
+				t.Fatalf("expected a loki config error, got %v", err)
+			}
+		})
+	}
+}
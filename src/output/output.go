@@ -3,7 +3,6 @@ package output
 import (
 	"AgentSmith-HUB/common"
 	"AgentSmith-HUB/logger"
-	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
@@ -25,6 +24,8 @@ const (
 	OutputTypeElasticsearch OutputType = "elasticsearch"
 	OutputTypeAliyunSLS     OutputType = "aliyun_sls"
 	OutputTypePrint         OutputType = "print"
+	OutputTypeLoki          OutputType = "loki"
+	OutputTypeSQL           OutputType = "sql"
 )
 
 // OutputConfig is the YAML config for an output.
@@ -34,7 +35,13 @@ type OutputConfig struct {
 	Kafka         *KafkaOutputConfig         `yaml:"kafka,omitempty"`
 	Elasticsearch *ElasticsearchOutputConfig `yaml:"elasticsearch,omitempty"`
 	AliyunSLS     *AliyunSLSOutputConfig     `yaml:"aliyun_sls,omitempty"`
-	RawConfig     string
+	Loki          *LokiOutputConfig          `yaml:"loki,omitempty"`
+	SQL           *SQLOutputConfig           `yaml:"sql,omitempty"`
+	Dedup         *DedupConfig               `yaml:"dedup,omitempty"`
+	// Templates sets event fields from Go templates before writing, e.g. a human-readable summary
+	// referencing {{.HitRuleName}} and event fields
+	Templates map[string]string `yaml:"templates,omitempty"`
+	RawConfig string
 }
 
 // KafkaOutputConfig holds Kafka-specific config.
@@ -45,6 +52,8 @@ type KafkaOutputConfig struct {
 	SASL        *common.KafkaSASLConfig     `yaml:"sasl,omitempty"`
 	TLS         *common.KafkaTLSConfig      `yaml:"tls,omitempty"`
 	Key         string                      `yaml:"key"`
+	// Transactional produces inside the Kafka input's consumer transactions (exactly-once Kafka to Kafka)
+	Transactional bool `yaml:"transactional,omitempty"`
 }
 
 // ElasticsearchOutputConfig holds Elasticsearch-specific config.
@@ -54,6 +63,7 @@ type ElasticsearchOutputConfig struct {
 	BatchSize int                             `yaml:"batch_size,omitempty"`
 	FlushDur  string                          `yaml:"flush_dur,omitempty"`
 	Auth      *common.ElasticsearchAuthConfig `yaml:"auth,omitempty"`
+	Pool      *common.ConnPoolConfig          `yaml:"pool,omitempty"`
 }
 
 // AliyunSLSOutputConfig holds Aliyun SLS-specific config.
@@ -65,6 +75,22 @@ type AliyunSLSOutputConfig struct {
 	Logstore        string `yaml:"logstore"`
 }
 
+// LokiOutputConfig holds Loki-specific config.
+type LokiOutputConfig struct {
+	URL      string `yaml:"url"`
+	TenantID string `yaml:"tenant_id,omitempty"` // Sent as X-Scope-OrgID
+	// Labels maps label names to the event field holding their value
+	Labels       map[string]string `yaml:"labels,omitempty"`
+	StaticLabels map[string]string `yaml:"static_labels,omitempty"`
+	// MaxLabelValues caps the distinct values of each label taken from an event field
+	MaxLabelValues int                    `yaml:"max_label_values,omitempty"`
+	TimestampField string                 `yaml:"timestamp_field,omitempty"` // Default is the time the event is written
+	BatchSize      int                    `yaml:"batch_size,omitempty"`
+	FlushDur       string                 `yaml:"flush_dur,omitempty"`
+	Auth           *common.LokiAuthConfig `yaml:"auth,omitempty"`
+	Pool           *common.ConnPoolConfig `yaml:"pool,omitempty"`
+}
+
 // Output is the runtime output instance.
 type Output struct {
 	Status              common.Status
@@ -76,15 +102,25 @@ type Output struct {
 	Type                OutputType
 	UpStream            map[string]*chan map[string]interface{}
 
+	// statusMachine serializes the writes of Status and keeps their history
+	statusMachine common.StatusMachine
+
 	// runtime
 	kafkaProducer         *common.KafkaProducer
 	elasticsearchProducer *common.ElasticsearchProducer
+	lokiProducer          *common.LokiProducer
+	sqlProducer           *common.SQLProducer
+	kafkaTxn              *common.KafkaTxn // set by the project for transactional Kafka outputs
+	dedup                 *outputDedup     // nil unless the config has a dedup section
+	templates             *outputTemplates // nil unless the config has templates
 	wg                    sync.WaitGroup
 
 	// config cache
 	kafkaCfg         *KafkaOutputConfig
 	elasticsearchCfg *ElasticsearchOutputConfig
 	aliyunSLSCfg     *AliyunSLSOutputConfig
+	lokiCfg          *LokiOutputConfig
+	sqlCfg           *SQLOutputConfig
 
 	// metrics - only total count is needed now
 	produceTotal      uint64 // cumulative production total
@@ -141,11 +177,27 @@ func Verify(path string, raw string) error {
 		}
 	}
 
+	// With strict_yaml: error, keys OutputConfig does not know (such as a misspelled brokerz) are rejected
+	if common.StrictYAMLMode() == common.StrictYAMLError {
+		if err := common.UnknownYAMLFieldError(common.UnknownYAMLFields(data, &OutputConfig{})); err != nil {
+			return err
+		}
+	}
+
 	// Validate required fields
 	if cfg.Type == "" {
 		return fmt.Errorf("missing required field 'type' (line: unknown)")
 	}
 
+	if cfg.Dedup != nil {
+		if err := verifyDedupConfig(cfg.Dedup); err != nil {
+			return err
+		}
+	}
+	if _, err := parseTemplates(cfg.Templates); err != nil {
+		return err
+	}
+
 	// Validate type-specific fields
 	switch cfg.Type {
 	case OutputTypeKafka, OutputTypeKafkaAzure, OutputTypeKafkaAWS:
@@ -168,11 +220,22 @@ func Verify(path string, raw string) error {
 		if cfg.Elasticsearch.Index == "" {
 			return fmt.Errorf("missing required field 'elasticsearch.index' for elasticsearch output (line: unknown)")
 		}
+		if err := common.VerifyConnPoolConfig("elasticsearch.pool", cfg.Elasticsearch.Pool); err != nil {
+			return fmt.Errorf("%v (line: unknown)", err)
+		}
 	case OutputTypeAliyunSLS:
 		if cfg.AliyunSLS == nil {
 			return fmt.Errorf("missing required field 'aliyun_sls' for aliyunSLS output (line: unknown)")
 		}
 		// Add more AliyunSLS specific field validation
+	case OutputTypeLoki:
+		if err := verifyLokiConfig(cfg.Loki); err != nil {
+			return err
+		}
+	case OutputTypeSQL:
+		if err := verifySQLConfig(cfg.SQL); err != nil {
+			return err
+		}
 	case OutputTypePrint:
 		// Print output doesn't require external connectivity
 	default:
@@ -182,6 +245,11 @@ func Verify(path string, raw string) error {
 	return nil
 }
 
+// UnknownFields returns the keys of an output config that OutputConfig does not know, for strict_yaml warnings
+func UnknownFields(raw string) []common.UnknownYAMLField {
+	return common.UnknownYAMLFields([]byte(raw), &OutputConfig{})
+}
+
 // NewOutput creates an Output from config and upstreams.
 func NewOutput(path string, raw string, id string) (*Output, error) {
 	var cfg OutputConfig
@@ -199,6 +267,7 @@ func NewOutput(path string, raw string, id string) (*Output, error) {
 		_ = yaml.Unmarshal([]byte(raw), &cfg)
 		cfg.RawConfig = raw
 	}
+	common.WarnUnknownYAMLFields("output", id, []byte(cfg.RawConfig), &OutputConfig{})
 
 	out := &Output{
 		Id:               id,
@@ -208,7 +277,11 @@ func NewOutput(path string, raw string, id string) (*Output, error) {
 		kafkaCfg:         cfg.Kafka,
 		elasticsearchCfg: cfg.Elasticsearch,
 		aliyunSLSCfg:     cfg.AliyunSLS,
+		lokiCfg:          cfg.Loki,
+		sqlCfg:           cfg.SQL,
 		Config:           &cfg,
+		dedup:            newOutputDedup(cfg.Dedup, id),
+		templates:        newOutputTemplates(cfg.Templates, id),
 		sampler:          nil, // Will be set below based on cluster role
 		Status:           common.StatusStopped,
 	}
@@ -220,15 +293,29 @@ func NewOutput(path string, raw string, id string) (*Output, error) {
 	return out, nil
 }
 
-// SetStatus sets the output status and error information
-func (out *Output) SetStatus(status common.Status, err error) {
+// SetStatus moves the output to a new status, recording the error as the reason of the transition.
+// Invalid transitions are rejected and leave the status unchanged.
+func (out *Output) SetStatus(status common.Status, err error) error {
+	reason := ""
+	if err != nil {
+		reason = err.Error()
+	}
+	t, terr := out.statusMachine.Transition(&out.Status, status, reason)
+	if terr != nil {
+		logger.Warn("Rejected output status transition", "output", out.Id, "status", status, "error", terr)
+		return terr
+	}
 	if err != nil {
 		out.Err = err
 		logger.Error("Output status changed with error", "output", out.Id, "status", status, "error", err)
 	}
-	out.Status = status
-	t := time.Now()
-	out.StatusChangedAt = &t
+	out.StatusChangedAt = &t.At
+	return nil
+}
+
+// StatusHistory returns the recent status transitions of the output, oldest first
+func (out *Output) StatusHistory() []common.StatusTransition {
+	return out.statusMachine.History()
 }
 
 // cleanup performs cleanup when normal stop fails or panic occurs
@@ -246,6 +333,16 @@ func (out *Output) cleanup() {
 		out.elasticsearchProducer = nil
 	}
 
+	if out.lokiProducer != nil {
+		out.lokiProducer.Close()
+		out.lokiProducer = nil
+	}
+
+	if out.sqlProducer != nil {
+		out.sqlProducer.Close()
+		out.sqlProducer = nil
+	}
+
 	// Reset atomic counter
 	atomic.StoreUint64(&out.produceTotal, 0)
 	atomic.StoreUint64(&out.lastReportedTotal, 0)
@@ -257,7 +354,8 @@ func (out *Output) cleanup() {
 	out.UpStream = make(map[string]*chan map[string]interface{})
 }
 
-// enhanceMessageWithProjectNodeSequence adds ProjectNodeSequence and output metadata to the message
+// enhanceMessageWithProjectNodeSequence adds ProjectNodeSequence and output metadata to the message,
+// and renders the configured templates into it
 func (out *Output) enhanceMessageWithProjectNodeSequence(msg map[string]interface{}) map[string]interface{} {
 	// Create a copy of the original message to avoid modifying the original
 	enhancedMsg := make(map[string]interface{})
@@ -268,6 +366,7 @@ func (out *Output) enhanceMessageWithProjectNodeSequence(msg map[string]interfac
 	// Add ProjectNodeSequence information
 	enhancedMsg["_hub_project_node_sequence"] = out.ProjectNodeSequence
 	enhancedMsg["_hub_output_timestamp"] = time.Now().UTC().Format(time.RFC3339)
+	out.templates.Render(enhancedMsg)
 
 	return enhancedMsg
 }
@@ -278,12 +377,16 @@ func (out *Output) StartForTesting() error {
 	if out.Status != common.StatusStopped {
 		return fmt.Errorf("output %s is not stopped", out.Id)
 	}
+	if err := out.SetStatus(common.StatusStarting, nil); err != nil {
+		return fmt.Errorf("output %s is not stopped", out.Id)
+	}
 
 	out.ResetProduceTotal()
-	out.SetStatus(common.StatusStarting, nil)
 
-	// Initialize stop channel for testing
+	// Initialize stop channel for testing, the goroutine keeps its own reference as StopForTesting
+	// clears the field
 	out.stopChan = make(chan struct{})
+	stopChan := out.stopChan
 
 	// Start single goroutine to read from UpStream and send to TestCollectionChan only
 	out.wg.Add(1)
@@ -302,13 +405,13 @@ func (out *Output) StartForTesting() error {
 
 		for {
 			select {
-			case <-out.stopChan:
+			case <-stopChan:
 				logger.Debug("Testing output goroutine received stop signal", "id", out.Id)
 				return
 			case <-ticker.C:
 				// Check for stop signal before processing
 				select {
-				case <-out.stopChan:
+				case <-stopChan:
 					logger.Debug("Testing output goroutine received stop signal before processing", "id", out.Id)
 					return
 				default:
@@ -318,7 +421,7 @@ func (out *Output) StartForTesting() error {
 				for _, up := range out.UpStream {
 					// Check stop signal again during loop iteration
 					select {
-					case <-out.stopChan:
+					case <-stopChan:
 						logger.Debug("Testing output goroutine received stop signal during upstream processing", "id", out.Id)
 						return
 					default:
@@ -330,6 +433,9 @@ func (out *Output) StartForTesting() error {
 							// Channel is closed, skip this channel
 							continue
 						}
+						if out.dedup.Duplicate(msg) {
+							continue
+						}
 						atomic.AddUint64(&out.produceTotal, 1)
 
 						// Skip sampling in testing mode (handled by SetTestMode)
@@ -354,7 +460,7 @@ func (out *Output) StartForTesting() error {
 
 				// Final check for stop signal after processing
 				select {
-				case <-out.stopChan:
+				case <-stopChan:
 					logger.Debug("Testing output goroutine received stop signal after processing", "id", out.Id)
 					return
 				default:
@@ -382,15 +488,15 @@ func (out *Output) Start() error {
 		}
 	}()
 
-	// Allow restart from stopped state or from error state
-	if out.Status != common.StatusStopped && out.Status != common.StatusError {
+	// Allow restart from stopped state or from error state. The transition is atomic, so of two
+	// concurrent starts only one proceeds.
+	if err := out.SetStatus(common.StatusStarting, nil); err != nil {
 		return fmt.Errorf("output %s is not stopped (status: %s)", out.Id, out.Status)
 	}
 
 	// Clear error state when restarting
 	out.Err = nil
 	out.ResetProduceTotal()
-	out.SetStatus(common.StatusStarting, nil)
 	// Perform connectivity check first before starting (skip for print type as it doesn't need external connectivity)
 	if out.Type != OutputTypePrint {
 		connectivityResult := out.CheckConnectivity()
@@ -417,6 +523,13 @@ func (out *Output) Start() error {
 			return fmt.Errorf("kafka configuration missing for output %s", out.Id)
 		}
 
+		if out.kafkaTxn != nil {
+			out.stopChan = make(chan struct{})
+			out.wg.Add(1)
+			go out.runKafkaTxn(hasTestCollector)
+			break
+		}
+
 		msgChan := make(chan map[string]interface{}, 1024)
 		producer, err := common.NewKafkaProducer(
 			out.kafkaCfg.Brokers,
@@ -426,6 +539,7 @@ func (out *Output) Start() error {
 			msgChan,
 			out.kafkaCfg.Key,
 			out.kafkaCfg.TLS,
+			common.OutputLatency(out.Id),
 		)
 		if err != nil {
 			out.SetStatus(common.StatusError, fmt.Errorf("failed to create kafka producer for output %s: %v", out.Id, err))
@@ -482,6 +596,9 @@ func (out *Output) Start() error {
 								// Channel is closed, skip this channel
 								continue
 							}
+							if out.dedup.Duplicate(msg) {
+								continue
+							}
 
 							// Always count/sample; duplication handled below
 							// Count immediately at upstream read to ensure all messages are counted
@@ -556,6 +673,8 @@ func (out *Output) Start() error {
 			batchSize,
 			flushDur,
 			out.elasticsearchCfg.Auth,
+			common.OutputConnPool(out.Id, out.elasticsearchCfg.Pool),
+			common.OutputLatency(out.Id),
 		)
 		if err != nil {
 			out.SetStatus(common.StatusError, fmt.Errorf("failed to create elasticsearch producer for output %s: %v", out.Id, err))
@@ -614,6 +733,9 @@ func (out *Output) Start() error {
 								// Channel is closed, skip this channel
 								continue
 							}
+							if out.dedup.Duplicate(msg) {
+								continue
+							}
 
 							// Always count/sample; duplication handled separately
 							// Count immediately at upstream read to ensure all messages are counted
@@ -708,6 +830,9 @@ func (out *Output) Start() error {
 								// Channel is closed, skip this channel
 								continue
 							}
+							if out.dedup.Duplicate(msg) {
+								continue
+							}
 							// Always count/sample.
 							// Count immediately at upstream read to ensure all messages are counted
 							atomic.AddUint64(&out.produceTotal, 1)
@@ -729,8 +854,10 @@ func (out *Output) Start() error {
 
 							// Enhance message with ProjectNodeSequence information for actual output
 							enhancedMsg := out.enhanceMessageWithProjectNodeSequence(msg)
-							data, _ := json.Marshal(enhancedMsg)
+							start := time.Now()
+							data, _ := common.SafeMarshal(enhancedMsg)
 							logger.Info("[Print Output]", "data", string(data))
+							common.OutputLatency(out.Id).Observe(time.Since(start))
 						default:
 							// No message available from this channel, continue to next
 						}
@@ -747,6 +874,18 @@ func (out *Output) Start() error {
 			}
 		}()
 
+	case OutputTypeLoki:
+		if err := out.startLoki(hasTestCollector); err != nil {
+			out.SetStatus(common.StatusError, err)
+			return err
+		}
+
+	case OutputTypeSQL:
+		if err := out.startSQL(hasTestCollector); err != nil {
+			out.SetStatus(common.StatusError, err)
+			return err
+		}
+
 	case OutputTypeAliyunSLS:
 		out.SetStatus(common.StatusError, fmt.Errorf("aliyun SLS output not implemented yet"))
 		return fmt.Errorf("aliyun SLS output not implemented yet")
@@ -756,6 +895,92 @@ func (out *Output) Start() error {
 	return nil
 }
 
+// runKafkaTxn produces upstream messages inside the open transaction of the project's Kafka input.
+// Unlike the regular producer path it never drops a message: the transaction waits for every record.
+func (out *Output) runKafkaTxn(hasTestCollector bool) {
+	defer out.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Panic in kafka transactional output goroutine", "output", out.Id, "panic", r)
+		}
+	}()
+
+	keyFieldList := common.StringToList(out.kafkaCfg.Key)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-out.stopChan:
+			logger.Debug("Kafka transactional output goroutine received stop signal", "id", out.Id)
+			return
+		case <-ticker.C:
+		}
+
+		for _, up := range out.UpStream {
+		drain:
+			for {
+				select {
+				case msg, ok := <-*up:
+					if !ok {
+						break drain
+					}
+					if out.dedup.Duplicate(msg) {
+						// Suppressed duplicates are handled as far as the transaction is concerned
+						out.kafkaTxn.Done()
+						continue
+					}
+					atomic.AddUint64(&out.produceTotal, 1)
+					if out.sampler != nil {
+						out.sampler.Sample(msg, out.ProjectNodeSequence)
+					}
+
+					enhancedMsg := out.enhanceMessageWithProjectNodeSequence(msg)
+					if hasTestCollector {
+						select {
+						case *out.TestCollectionChan <- enhancedMsg:
+						default:
+							logger.Warn("Test collection channel full, dropping message", "id", out.Id, "type", "kafka")
+						}
+					}
+
+					rec, err := common.NewKafkaRecord(out.kafkaCfg.Topic, keyFieldList, enhancedMsg)
+					if err != nil {
+						logger.Error("[KafkaTxn] failed to serialize message", "output", out.Id, "error", err.Error(), common.ErrorLogContextKey, common.NewErrorLogContext(enhancedMsg))
+						out.kafkaTxn.Done()
+						continue
+					}
+					out.kafkaTxn.Produce(rec, enhancedMsg)
+				default:
+					break drain
+				}
+			}
+		}
+	}
+}
+
+// SetKafkaTxn binds a transactional Kafka output to its project's transaction (nil unbinds it)
+func (out *Output) SetKafkaTxn(txn *common.KafkaTxn) {
+	out.kafkaTxn = txn
+}
+
+// IsKafkaTransactional reports whether the output is a Kafka output with transactional enabled
+func (out *Output) IsKafkaTransactional() bool {
+	switch out.Type {
+	case OutputTypeKafka, OutputTypeKafkaAzure, OutputTypeKafkaAWS:
+		return out.kafkaCfg != nil && out.kafkaCfg.Transactional
+	}
+	return false
+}
+
+// KafkaBrokers returns the configured brokers of a Kafka output
+func (out *Output) KafkaBrokers() []string {
+	if out.kafkaCfg == nil {
+		return nil
+	}
+	return out.kafkaCfg.Brokers
+}
+
 // Stop stops the output producer and waits for all routines to finish.
 func (out *Output) Stop() error {
 	if out.Status != common.StatusRunning && out.Status != common.StatusError {
@@ -767,7 +992,9 @@ func (out *Output) Stop() error {
 		// For other states (e.g., StatusStarting), proceed with stop to ensure cleanup
 		logger.Debug("Stopping output from non-running state", "output", out.Id, "current_status", out.Status)
 	}
-	out.SetStatus(common.StatusStopping, nil)
+	if err := out.SetStatus(common.StatusStopping, nil); err != nil {
+		return fmt.Errorf("output %s cannot be stopped (status: %s): %w", out.Id, out.Status, err)
+	}
 	logger.Info("Starting output stop process", "id", out.Id, "type", out.Type, "current_status", out.Status)
 
 	// Step 1: Signal all output goroutines to stop first
@@ -791,6 +1018,16 @@ func (out *Output) Stop() error {
 		out.elasticsearchProducer.Close()
 		out.elasticsearchProducer = nil
 	}
+	if out.lokiProducer != nil {
+		logger.Debug("Closing loki producer", "id", out.Id)
+		out.lokiProducer.Close()
+		out.lokiProducer = nil
+	}
+	if out.sqlProducer != nil {
+		logger.Debug("Closing sql producer", "id", out.Id)
+		out.sqlProducer.Close()
+		out.sqlProducer = nil
+	}
 
 	// Step 3: Wait for goroutines to finish with timeout and force cleanup if needed
 	logger.Info("Waiting for output goroutines to finish", "id", out.Id)
@@ -832,6 +1069,11 @@ func (out *Output) GetProduceTotal() uint64 {
 	return atomic.LoadUint64(&out.produceTotal)
 }
 
+// GetDedupSuppressed returns the number of events not written because of the dedup window
+func (out *Output) GetDedupSuppressed() uint64 {
+	return out.dedup.Suppressed()
+}
+
 // ResetProduceTotal resets the total produced count to zero.
 // This should only be called during component cleanup or forced restart.
 func (out *Output) ResetProduceTotal() uint64 {
@@ -864,10 +1106,7 @@ func (out *Output) StopForTesting() error {
 		out.stopChan = nil
 	}
 
-	// Step 2: Clear test collection channel
-	out.TestCollectionChan = nil
-
-	// Step 3: Wait for goroutines with very short timeout
+	// Step 2: Wait for goroutines with very short timeout
 	waitDone := make(chan struct{})
 	go func() {
 		out.wg.Wait()
@@ -881,6 +1120,9 @@ func (out *Output) StopForTesting() error {
 		logger.Warn("Timeout waiting for test output goroutines, proceeding anyway", "id", out.Id)
 	}
 
+	// Step 3: Clear test collection channel once the goroutine no longer sends to it
+	out.TestCollectionChan = nil
+
 	// Step 4: Reset atomic counter for testing cleanup
 	previousTotal := atomic.LoadUint64(&out.produceTotal)
 	atomic.StoreUint64(&out.produceTotal, 0)
@@ -1058,6 +1300,12 @@ func (out *Output) CheckConnectivity() map[string]interface{} {
 		}
 		return result
 
+	case OutputTypeLoki:
+		out.checkLokiConnectivity(result)
+
+	case OutputTypeSQL:
+		out.checkSQLConnectivity(result)
+
 	case OutputTypeAliyunSLS:
 		if out.aliyunSLSCfg == nil {
 			result["status"] = "error"
@@ -1173,7 +1421,11 @@ func NewFromExisting(existing *Output, newProjectNodeSequence string) (*Output,
 		kafkaCfg:            existing.kafkaCfg,
 		elasticsearchCfg:    existing.elasticsearchCfg,
 		aliyunSLSCfg:        existing.aliyunSLSCfg,
+		lokiCfg:             existing.lokiCfg,
+		sqlCfg:              existing.sqlCfg,
 		Config:              existing.Config,
+		dedup:               newOutputDedup(existing.Config.Dedup, existing.Id),
+		templates:           existing.templates,
 		Status:              common.StatusStopped, // Initialize status to stopped
 		TestCollectionChan:  nil,                  // Reset for new instance
 	}
@@ -1186,6 +1438,27 @@ func NewFromExisting(existing *Output, newProjectNodeSequence string) (*Output,
 	return newOutput, nil
 }
 
+// NewWithOverrides creates a new output instance whose config has the given dotted keys replaced,
+// e.g. a project-specific topic. The original component is left untouched.
+func NewWithOverrides(existing *Output, overrides map[string]interface{}, newProjectNodeSequence string) (*Output, error) {
+	if existing == nil {
+		return nil, fmt.Errorf("existing output is nil")
+	}
+
+	raw, err := common.ApplyConfigOverrides(existing.Config.RawConfig, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("output %s: %w", existing.Id, err)
+	}
+
+	newOutput, err := NewOutput("", raw, existing.Id)
+	if err != nil {
+		return nil, err
+	}
+	newOutput.Path = existing.Path
+	newOutput.ProjectNodeSequence = newProjectNodeSequence
+	return newOutput, nil
+}
+
 // SetTestMode configures the output for test mode by disabling sampling and other global state interactions
 func (out *Output) SetTestMode() {
 	out.sampler = nil // Disable sampling for test instances
@@ -1213,6 +1486,14 @@ func (out *Output) GetPendingMessageCount() int {
 		if out.elasticsearchProducer != nil && out.elasticsearchProducer.MsgChan != nil {
 			pendingCount += len(out.elasticsearchProducer.MsgChan)
 		}
+	case OutputTypeLoki:
+		if out.lokiProducer != nil && out.lokiProducer.MsgChan != nil {
+			pendingCount += len(out.lokiProducer.MsgChan)
+		}
+	case OutputTypeSQL:
+		if out.sqlProducer != nil && out.sqlProducer.MsgChan != nil {
+			pendingCount += len(out.sqlProducer.MsgChan)
+		}
 	}
 
 	return pendingCount
@@ -0,0 +1,238 @@
+package output
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SQLOutputConfig holds the config of a PostgreSQL or MySQL output
+type SQLOutputConfig struct {
+	Driver string `yaml:"driver"` // postgres or mysql
+	DSN    string `yaml:"dsn"`
+	Table  string `yaml:"table"` // Optionally qualified by a schema: schema.table
+	// Columns maps the table columns to the event fields inserted into them
+	Columns   []SQLColumnConfig      `yaml:"columns"`
+	BatchSize int                    `yaml:"batch_size,omitempty"`
+	FlushDur  string                 `yaml:"flush_dur,omitempty"`
+	Pool      *common.ConnPoolConfig `yaml:"pool,omitempty"`
+}
+
+// SQLColumnConfig maps a column to an event field, the value is coerced to the column type
+type SQLColumnConfig struct {
+	Column string `yaml:"column"`
+	Field  string `yaml:"field"`
+	Type   string `yaml:"type,omitempty"` // text (default), int, float, bool, timestamp or json
+}
+
+// verifySQLConfig validates the DSN, the table and the column mapping of a SQL output
+func verifySQLConfig(cfg *SQLOutputConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("missing required field 'sql' for sql output (line: unknown)")
+	}
+	if cfg.Driver != common.SQLDriverPostgres && cfg.Driver != common.SQLDriverMySQL {
+		return fmt.Errorf("invalid 'sql.driver' %q: must be postgres or mysql (line: unknown)", cfg.Driver)
+	}
+	if cfg.DSN == "" {
+		return fmt.Errorf("missing required field 'sql.dsn' for sql output (line: unknown)")
+	}
+	if err := common.VerifySQLDSN(cfg.Driver, cfg.DSN); err != nil {
+		return fmt.Errorf("invalid 'sql.dsn' for %s: %v (line: unknown)", cfg.Driver, err)
+	}
+	if cfg.Table == "" {
+		return fmt.Errorf("missing required field 'sql.table' for sql output (line: unknown)")
+	}
+	if err := common.VerifySQLIdentifier(cfg.Table, true); err != nil {
+		return fmt.Errorf("invalid 'sql.table': %v (line: unknown)", err)
+	}
+
+	if len(cfg.Columns) == 0 {
+		return fmt.Errorf("sql output needs at least one column in 'sql.columns' (line: unknown)")
+	}
+	seen := make(map[string]bool, len(cfg.Columns))
+	for _, col := range cfg.Columns {
+		if err := common.VerifySQLIdentifier(col.Column, false); err != nil {
+			return fmt.Errorf("invalid sql column: %v (line: unknown)", err)
+		}
+		if seen[strings.ToLower(col.Column)] {
+			return fmt.Errorf("sql column %s is mapped more than once (line: unknown)", col.Column)
+		}
+		seen[strings.ToLower(col.Column)] = true
+		if strings.TrimSpace(col.Field) == "" {
+			return fmt.Errorf("sql column %s needs an event field (line: unknown)", col.Column)
+		}
+		if err := common.VerifySQLColumnType(col.Type); err != nil {
+			return fmt.Errorf("sql column %s: %v (line: unknown)", col.Column, err)
+		}
+	}
+
+	if cfg.BatchSize < 0 {
+		return fmt.Errorf("'sql.batch_size' cannot be negative (line: unknown)")
+	}
+	if cfg.FlushDur != "" {
+		if d, err := time.ParseDuration(cfg.FlushDur); err != nil || d <= 0 {
+			return fmt.Errorf("invalid 'sql.flush_dur' %q: must be a positive duration like 3s (line: unknown)", cfg.FlushDur)
+		}
+	}
+	if err := common.VerifyConnPoolConfig("sql.pool", cfg.Pool); err != nil {
+		return fmt.Errorf("%v (line: unknown)", err)
+	}
+	return nil
+}
+
+// sqlColumns returns the column mapping with the field paths split
+func (cfg *SQLOutputConfig) sqlColumns() []common.SQLColumn {
+	columns := make([]common.SQLColumn, len(cfg.Columns))
+	for i, col := range cfg.Columns {
+		columns[i] = common.SQLColumn{Name: col.Column, Field: common.StringToList(strings.TrimSpace(col.Field)), Type: col.Type}
+	}
+	return columns
+}
+
+// newSQLProducer creates the producer of a SQL output, inserting what it receives on msgChan
+func (out *Output) newSQLProducer(msgChan chan map[string]interface{}) (*common.SQLProducer, error) {
+	batchSize := 100
+	if out.sqlCfg.BatchSize > 0 {
+		batchSize = out.sqlCfg.BatchSize
+	}
+	flushDur := 3 * time.Second
+	if out.sqlCfg.FlushDur != "" {
+		if d, err := time.ParseDuration(out.sqlCfg.FlushDur); err == nil {
+			flushDur = d
+		}
+	}
+	db, err := common.OpenSQL(out.sqlCfg.Driver, out.sqlCfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s connection: %w", out.sqlCfg.Driver, err)
+	}
+	common.OutputConnPool(out.Id, out.sqlCfg.Pool).ConfigureDB(db)
+	return common.NewSQLProducer(db, out.sqlCfg.Driver, out.sqlCfg.Table, out.sqlCfg.sqlColumns(), msgChan, batchSize, flushDur,
+		func(event map[string]interface{}, err error) { out.deadLetter(DeadLetterInsertFailed, err, event) },
+		common.OutputLatency(out.Id)), nil
+}
+
+// startSQL starts the producer of a SQL output and the goroutine feeding it from the upstreams
+func (out *Output) startSQL(hasTestCollector bool) error {
+	if out.sqlProducer != nil {
+		return fmt.Errorf("sql producer already running for output %s", out.Id)
+	}
+	if out.sqlCfg == nil {
+		return fmt.Errorf("sql configuration missing for output %s", out.Id)
+	}
+
+	msgChan := make(chan map[string]interface{}, 1024)
+	producer, err := out.newSQLProducer(msgChan)
+	if err != nil {
+		return err
+	}
+	out.sqlProducer = producer
+	if out.stopChan == nil {
+		out.stopChan = make(chan struct{})
+	}
+
+	out.wg.Add(1)
+	go func() {
+		defer out.wg.Done()
+		defer close(msgChan) // Close msgChan when UpStream processing is done
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic in sql output goroutine", "output", out.Id, "panic", r)
+			}
+		}()
+
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-out.stopChan:
+				logger.Debug("SQL output goroutine received stop signal", "id", out.Id)
+				return
+			case <-ticker.C:
+			}
+
+			for _, up := range out.UpStream {
+				select {
+				case <-out.stopChan:
+					logger.Debug("SQL output goroutine received stop signal during upstream processing", "id", out.Id)
+					return
+				default:
+				}
+
+				select {
+				case msg, ok := <-*up:
+					if !ok {
+						continue
+					}
+					if out.dedup.Duplicate(msg) {
+						continue
+					}
+					atomic.AddUint64(&out.produceTotal, 1)
+					if out.sampler != nil {
+						out.sampler.Sample(msg, out.ProjectNodeSequence)
+					}
+
+					enhancedMsg := out.enhanceMessageWithProjectNodeSequence(msg)
+					if hasTestCollector {
+						select {
+						case *out.TestCollectionChan <- enhancedMsg:
+						default:
+							logger.Warn("Test collection channel full, dropping message", "id", out.Id, "type", "sql")
+						}
+					}
+
+					select {
+					case msgChan <- enhancedMsg:
+					default:
+						logger.Warn("SQL producer channel full, dropping message", "id", out.Id)
+					}
+				default:
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// checkSQLConnectivity fills the connectivity result of a SQL output
+func (out *Output) checkSQLConnectivity(result map[string]interface{}) {
+	details := result["details"].(map[string]interface{})
+	if out.sqlCfg == nil {
+		result["status"] = "error"
+		result["message"] = "SQL configuration missing"
+		details["connection_status"] = "not_configured"
+		details["connection_errors"] = []map[string]interface{}{
+			{"message": "SQL configuration is incomplete or missing", "severity": "error"},
+		}
+		return
+	}
+
+	details["connection_info"] = map[string]interface{}{
+		"driver": out.sqlCfg.Driver,
+		"table":  out.sqlCfg.Table,
+	}
+	if err := common.TestSQLConnection(out.sqlCfg.Driver, out.sqlCfg.DSN); err != nil {
+		result["status"] = "error"
+		result["message"] = "Failed to connect to the database"
+		details["connection_status"] = "connection_failed"
+		details["connection_errors"] = []map[string]interface{}{
+			{"message": err.Error(), "severity": "error"},
+		}
+		return
+	}
+	details["connection_status"] = "connected"
+	result["message"] = "Successfully connected to the database"
+	metrics := map[string]interface{}{
+		"produce_total":   out.GetProduceTotal(),
+		"producer_active": out.sqlProducer != nil,
+	}
+	if out.sqlProducer != nil {
+		inserted, failed := out.sqlProducer.Stats()
+		metrics["rows_inserted"] = inserted
+		metrics["rows_failed"] = failed
+	}
+	details["metrics"] = metrics
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"AgentSmith-HUB/common"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const sqlColumnsYAML = "  columns:\n    - column: user_name\n      field: user.name\n    - column: port\n      field: port\n      type: int\n"
+
+func TestVerify_SQLConfig(t *testing.T) {
+	valid := map[string]string{
+		"postgres url": "type: sql\nsql:\n  driver: postgres\n  dsn: postgres://hub:secret@db:5432/hub?sslmode=disable\n  table: public.alerts\n" + sqlColumnsYAML,
+		"postgres kv":  "type: sql\nsql:\n  driver: postgres\n  dsn: host=db user=hub dbname=hub\n  table: alerts\n" + sqlColumnsYAML,
+		"mysql":        "type: sql\nsql:\n  driver: mysql\n  dsn: hub:secret@tcp(db:3306)/hub\n  table: alerts\n  flush_dur: 5s\n" + sqlColumnsYAML,
+	}
+	for name, raw := range valid {
+		if err := Verify("", raw); err != nil {
+			t.Fatalf("%s: expected a valid config, got %v", name, err)
+		}
+	}
+
+	cases := map[string]string{
+		"bad driver":   "type: sql\nsql:\n  driver: oracle\n  dsn: x\n  table: alerts\n" + sqlColumnsYAML,
+		"bad dsn":      "type: sql\nsql:\n  driver: mysql\n  dsn: not a dsn\n  table: alerts\n" + sqlColumnsYAML,
+		"bad pg dsn":   "type: sql\nsql:\n  driver: postgres\n  dsn: \"host='db\"\n  table: alerts\n" + sqlColumnsYAML,
+		"bad table":    "type: sql\nsql:\n  driver: mysql\n  dsn: hub@tcp(db)/hub\n  table: alerts;drop\n" + sqlColumnsYAML,
+		"no columns":   "type: sql\nsql:\n  driver: mysql\n  dsn: hub@tcp(db)/hub\n  table: alerts\n",
+		"bad type":     "type: sql\nsql:\n  driver: mysql\n  dsn: hub@tcp(db)/hub\n  table: alerts\n  columns:\n    - column: a\n      field: a\n      type: blob\n",
+		"duplicate":    "type: sql\nsql:\n  driver: mysql\n  dsn: hub@tcp(db)/hub\n  table: alerts\n  columns:\n    - column: a\n      field: a\n    - column: A\n      field: b\n",
+		"empty field":  "type: sql\nsql:\n  driver: mysql\n  dsn: hub@tcp(db)/hub\n  table: alerts\n  columns:\n    - column: a\n",
+		"bad flushdur": "type: sql\nsql:\n  driver: mysql\n  dsn: hub@tcp(db)/hub\n  table: alerts\n  flush_dur: soon\n" + sqlColumnsYAML,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Verify("", raw)
+			if err == nil || !strings.Contains(err.Error(), "sql") {
+				t.Fatalf("expected a sql config error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDeadLetter_StoresFailedRow(t *testing.T) {
+	var key, stored string
+	deadLetterPush = func(k string, value interface{}, maxLen int64) error {
+		key, stored = k, value.(string)
+		return nil
+	}
+	defer func() { deadLetterPush = common.RedisLPush }()
+
+	out := &Output{Id: "pg_alerts"}
+	out.deadLetter(DeadLetterInsertFailed, fmt.Errorf("constraint violation"), map[string]interface{}{"user": "alice"})
+
+	var letter DeadLetter
+	if err := json.Unmarshal([]byte(stored), &letter); err != nil {
+		t.Fatalf("unexpected entry %q: %v", stored, err)
+	}
+	if key != "hub:dlq:output:pg_alerts" || letter.Reason != DeadLetterInsertFailed || letter.Error != "constraint violation" || letter.Event["user"] != "alice" {
+		t.Fatalf("unexpected dead letter %s %+v", key, letter)
+	}
+}
@@ -0,0 +1,103 @@
+package output
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"AgentSmith-HUB/rules_engine"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Keys of the hit rule metadata in the data of output templates
+const (
+	TemplateHitRuleID   = "HitRuleID"
+	TemplateHitRuleName = "HitRuleName"
+)
+
+// templateNoValue is what text/template prints for a missing map key, rendered as empty instead
+const templateNoValue = "<no value>"
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"default": func(def interface{}, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"json": func(v interface{}) (string, error) {
+		data, err := common.SafeMarshal(v)
+		return string(data), err
+	},
+}
+
+type fieldTemplate struct {
+	field string
+	tmpl  *template.Template
+}
+
+// outputTemplates renders the configured templates into fields of each written event
+type outputTemplates struct {
+	outputID  string
+	templates []fieldTemplate // Sorted by field
+}
+
+// parseTemplates compiles the templates section of an output config, keyed by the field they set
+func parseTemplates(cfg map[string]string) ([]fieldTemplate, error) {
+	fields := make([]string, 0, len(cfg))
+	for field := range cfg {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	templates := make([]fieldTemplate, 0, len(fields))
+	for _, field := range fields {
+		if strings.TrimSpace(field) == "" {
+			return nil, fmt.Errorf("templates cannot set an empty field (line: unknown)")
+		}
+		tmpl, err := template.New(field).Funcs(templateFuncs).Parse(cfg[field])
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for field '%s': %v (line: unknown)", field, err)
+		}
+		templates = append(templates, fieldTemplate{field: field, tmpl: tmpl})
+	}
+	return templates, nil
+}
+
+// newOutputTemplates builds the templates of a verified config, nil when none is configured
+func newOutputTemplates(cfg map[string]string, outputID string) *outputTemplates {
+	if len(cfg) == 0 {
+		return nil
+	}
+	templates, _ := parseTemplates(cfg)
+	return &outputTemplates{outputID: outputID, templates: templates}
+}
+
+// Render sets each template field of msg. Templates see the event fields, {{.severity}} for example,
+// and the hit rule metadata as {{.HitRuleID}} and {{.HitRuleName}}. A template that fails to render
+// leaves its field unset.
+func (t *outputTemplates) Render(msg map[string]interface{}) {
+	if t == nil {
+		return
+	}
+	data := make(map[string]interface{}, len(msg)+2)
+	for k, v := range msg {
+		data[k] = v
+	}
+	data[TemplateHitRuleID] = msg[rules_engine.HitRuleIdFieldName]
+	data[TemplateHitRuleName] = msg[rules_engine.HitRuleNameFieldName]
+
+	var buf bytes.Buffer
+	for _, ft := range t.templates {
+		buf.Reset()
+		if err := ft.tmpl.Execute(&buf, data); err != nil {
+			logger.Warn("Failed to render output template", "output", t.outputID, "field", ft.field, "error", err)
+			continue
+		}
+		msg[ft.field] = strings.ReplaceAll(buf.String(), templateNoValue, "")
+	}
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"AgentSmith-HUB/rules_engine"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestOutputTemplates_RenderWebhookBodyWithRuleMetadata(t *testing.T) {
+	rs, err := rules_engine.NewRuleset("", `
+<root type="DETECTION" name="ssh">
+  <rule id="brute_force" name="SSH brute force">
+    <check type="EQU" field="event">ssh_login_failed</check>
+    <append field="severity">high</append>
+  </rule>
+</root>`, "ssh")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	rs.SetTestMode()
+	results := rs.EngineCheck(map[string]interface{}{"event": "ssh_login_failed", "src_ip": "10.0.0.7"})
+	if len(results) != 1 {
+		t.Fatalf("expected one hit, got %v", results)
+	}
+
+	raw := "type: print\ntemplates:\n" +
+		"  body: '{\"text\": \"[{{upper .severity}}] {{.HitRuleName}} from {{.src_ip}}\", \"rule\": {{json .HitRuleID}}, \"user\": \"{{.user}}\"}'\n"
+	out, err := NewOutput("", raw, "webhook_out")
+	if err != nil {
+		t.Fatalf("NewOutput: %v", err)
+	}
+	msg := out.enhanceMessageWithProjectNodeSequence(results[0])
+
+	var body map[string]interface{}
+	if err := sonic.Unmarshal([]byte(msg["body"].(string)), &body); err != nil {
+		t.Fatalf("rendered body is not JSON: %v (%v)", err, msg["body"])
+	}
+	if body["text"] != "[HIGH] SSH brute force from 10.0.0.7" || body["rule"] != "ssh.brute_force" || body["user"] != "" {
+		t.Fatalf("unexpected body %v", body)
+	}
+	if _, ok := results[0]["body"]; ok {
+		t.Fatal("the template must not modify the upstream event")
+	}
+}
+
+func TestOutputTemplates_Verify(t *testing.T) {
+	if _, err := NewOutput("", "type: print\ntemplates:\n  summary: '{{.HitRuleName'\n", "tmpl_out"); err == nil || !strings.Contains(err.Error(), "summary") {
+		t.Fatalf("expected an invalid template error, got %v", err)
+	}
+}
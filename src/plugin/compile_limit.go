@@ -0,0 +1,121 @@
+package plugin
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// compileGuard is a counting semaphore bounding the yaegi compilations running at once. Compiling a
+// plugin keeps a core busy, so loading many plugins at startup or in a bulk apply would otherwise
+// saturate the node while it is also processing events.
+type compileGuard struct {
+	slots    chan struct{}
+	inFlight int64
+	peak     int64
+}
+
+func newCompileGuard(limit int) *compileGuard {
+	return &compileGuard{slots: make(chan struct{}, limit)}
+}
+
+func (g *compileGuard) acquire() {
+	g.slots <- struct{}{}
+	n := atomic.AddInt64(&g.inFlight, 1)
+	for {
+		peak := atomic.LoadInt64(&g.peak)
+		if n <= peak || atomic.CompareAndSwapInt64(&g.peak, peak, n) {
+			break
+		}
+	}
+}
+
+func (g *compileGuard) release() {
+	atomic.AddInt64(&g.inFlight, -1)
+	<-g.slots
+}
+
+var (
+	compileSlots     *compileGuard
+	compileSlotsOnce sync.Once
+)
+
+// getCompileLimit returns the configured max_plugin_compilations, or half the cores
+func getCompileLimit() int {
+	if common.Config != nil && common.Config.MaxPluginCompilations > 0 {
+		return common.Config.MaxPluginCompilations
+	}
+	limit := runtime.NumCPU() / 2
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// globalCompileGuard returns the process wide guard, created from the hub config on first use
+func globalCompileGuard() *compileGuard {
+	compileSlotsOnce.Do(func() {
+		compileSlots = newCompileGuard(getCompileLimit())
+	})
+	return compileSlots
+}
+
+// PluginSource is a plugin loaded by LoadPlugins, from a file or from raw code
+type PluginSource struct {
+	Name string
+	Path string
+	Raw  string
+}
+
+// LoadPlugins loads and registers plugins, compiling as many at once as the compile limit allows,
+// and logs the progress. It returns the load error of each plugin that failed, keyed by name.
+func LoadPlugins(sources []PluginSource) map[string]error {
+	failures := make(map[string]error)
+	total := len(sources)
+	if total == 0 {
+		return failures
+	}
+
+	workers := cap(globalCompileGuard().slots)
+	if workers > total {
+		workers = total
+	}
+	step := total / 10
+	if step < 1 {
+		step = 1
+	}
+	logger.Info("Loading plugins", "total", total, "concurrency", workers)
+
+	var (
+		mu   sync.Mutex
+		done int
+		wg   sync.WaitGroup
+	)
+	jobs := make(chan PluginSource)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range jobs {
+				err := NewPlugin(src.Path, src.Raw, src.Name, YAEGI_PLUGIN)
+				mu.Lock()
+				done++
+				if err != nil {
+					failures[src.Name] = err
+				}
+				if done%step == 0 || done == total {
+					logger.Info("Plugin load progress", "loaded", done, "total", total, "failed", len(failures))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, src := range sources {
+		jobs <- src
+	}
+	close(jobs)
+	wg.Wait()
+	return failures
+}
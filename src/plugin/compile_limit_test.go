@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLoadPlugins_RespectsCompileLimit(t *testing.T) {
+	globalCompileGuard()
+	orig := compileSlots
+	compileSlots = newCompileGuard(2)
+	t.Cleanup(func() { compileSlots = orig })
+
+	sources := make([]PluginSource, 8)
+	for i := range sources {
+		sources[i] = PluginSource{Name: fmt.Sprintf("bulk_plugin_%d", i), Raw: racePluginCode}
+	}
+	t.Cleanup(func() {
+		PluginsMu.Lock()
+		defer PluginsMu.Unlock()
+		for _, src := range sources {
+			delete(Plugins, src.Name)
+		}
+	})
+
+	if failures := LoadPlugins(sources); len(failures) != 0 {
+		t.Fatalf("expected every plugin to load, got %v", failures)
+	}
+	if peak := atomic.LoadInt64(&compileSlots.peak); peak < 1 || peak > 2 {
+		t.Fatalf("expected at most 2 compilations at once, peak was %d", peak)
+	}
+	for _, src := range sources {
+		if _, ok := GetPlugin(src.Name); !ok {
+			t.Fatalf("expected %s to be registered", src.Name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PluginDependencies describes what a yaegi plugin's source depends on
+type PluginDependencies struct {
+	// Imports are the imported package paths, sorted
+	Imports []string `json:"imports"`
+	// DisallowedImports are imports outside the standard library allowlist
+	DisallowedImports []string `json:"disallowed_imports"`
+	// PackageCalls maps an import path to the selectors used from it, e.g. "strings" -> ["Contains"]
+	PackageCalls map[string][]string `json:"package_calls"`
+	// Functions are the functions declared in the plugin itself
+	Functions []string `json:"functions"`
+	// PluginReferences are other known plugins named in the source (identifiers or string literals)
+	PluginReferences []string `json:"plugin_references"`
+}
+
+// ExtractDependencies parses plugin source and collects its imports, the package members it uses,
+// its own functions and references to any of knownPlugins (self excluded)
+func ExtractDependencies(source string, self string, knownPlugins []string) (*PluginDependencies, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse plugin code: %w", err)
+	}
+
+	deps := &PluginDependencies{
+		Imports:           []string{},
+		DisallowedImports: []string{},
+		PackageCalls:      make(map[string][]string),
+		Functions:         []string{},
+		PluginReferences:  []string{},
+	}
+
+	// Map the name each import is referred to by (alias or last path element) to its path
+	importNames := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		deps.Imports = append(deps.Imports, importPath)
+		if !isStandardLibraryPackage(importPath) {
+			deps.DisallowedImports = append(deps.DisallowedImports, importPath)
+		}
+
+		name := importPath[strings.LastIndex(importPath, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name != "_" && name != "." {
+			importNames[name] = importPath
+		}
+	}
+
+	known := make(map[string]bool, len(knownPlugins))
+	for _, name := range knownPlugins {
+		if name != self {
+			known[name] = true
+		}
+	}
+
+	calls := make(map[string]map[string]bool)
+	references := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncDecl:
+			if node.Recv == nil {
+				deps.Functions = append(deps.Functions, node.Name.Name)
+			}
+		case *ast.SelectorExpr:
+			if ident, ok := node.X.(*ast.Ident); ok {
+				if importPath, ok := importNames[ident.Name]; ok {
+					if calls[importPath] == nil {
+						calls[importPath] = make(map[string]bool)
+					}
+					calls[importPath][node.Sel.Name] = true
+				}
+			}
+		case *ast.Ident:
+			if known[node.Name] {
+				references[node.Name] = true
+			}
+		case *ast.BasicLit:
+			if node.Kind == token.STRING {
+				if value, err := strconv.Unquote(node.Value); err == nil && known[value] {
+					references[value] = true
+				}
+			}
+		}
+		return true
+	})
+
+	for importPath, members := range calls {
+		deps.PackageCalls[importPath] = sortedKeys(members)
+	}
+	deps.PluginReferences = sortedKeys(references)
+	sort.Strings(deps.Imports)
+	sort.Strings(deps.DisallowedImports)
+	sort.Strings(deps.Functions)
+	return deps, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
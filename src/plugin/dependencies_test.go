@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+const dependenciesPluginCode = `package plugin
+
+import (
+	"strings"
+	enc "encoding/json"
+	"github.com/example/helper"
+)
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func Eval(args ...interface{}) (bool, error) {
+	var v map[string]interface{}
+	_ = enc.Unmarshal([]byte(args[0].(string)), &v)
+	_ = helper.Do()
+	// Mirrors the built-in plugin of the same name
+	if normalize("isPrivateIP") == "" {
+		return false, nil
+	}
+	return strings.Contains(v["host"].(string), "corp"), nil
+}
+`
+
+func TestExtractDependencies(t *testing.T) {
+	deps, err := ExtractDependencies(dependenciesPluginCode, "self", []string{"isPrivateIP", "self", "unused"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"encoding/json", "github.com/example/helper", "strings"}; !reflect.DeepEqual(deps.Imports, want) {
+		t.Fatalf("imports = %v, want %v", deps.Imports, want)
+	}
+	if want := []string{"github.com/example/helper"}; !reflect.DeepEqual(deps.DisallowedImports, want) {
+		t.Fatalf("disallowed imports = %v, want %v", deps.DisallowedImports, want)
+	}
+	if want := []string{"Contains", "ToLower", "TrimSpace"}; !reflect.DeepEqual(deps.PackageCalls["strings"], want) {
+		t.Fatalf("strings calls = %v, want %v", deps.PackageCalls["strings"], want)
+	}
+	// Aliased imports are resolved to their path
+	if want := []string{"Unmarshal"}; !reflect.DeepEqual(deps.PackageCalls["encoding/json"], want) {
+		t.Fatalf("encoding/json calls = %v, want %v", deps.PackageCalls["encoding/json"], want)
+	}
+	if want := []string{"Eval", "normalize"}; !reflect.DeepEqual(deps.Functions, want) {
+		t.Fatalf("functions = %v, want %v", deps.Functions, want)
+	}
+	if want := []string{"isPrivateIP"}; !reflect.DeepEqual(deps.PluginReferences, want) {
+		t.Fatalf("plugin references = %v, want %v", deps.PluginReferences, want)
+	}
+}
+
+func TestExtractDependencies_InvalidSource(t *testing.T) {
+	if _, err := ExtractDependencies("not go code", "p", nil); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
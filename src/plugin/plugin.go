@@ -68,6 +68,9 @@ type PluginParameter struct {
 	Required bool   `json:"required"`
 }
 
+// Plugins and PluginsNew are guarded by PluginsMu. Use the accessor functions below instead of
+// touching the maps directly. PluginsMu is a leaf lock: it may be taken while common.GlobalMu is
+// held, but never the other way around.
 var Plugins = make(map[string]*Plugin)
 var PluginsNew = make(map[string]string)
 var PluginsMu sync.RWMutex
@@ -149,9 +152,7 @@ func NewPlugin(path string, raw string, name string, pluginType int) error {
 		return fmt.Errorf("plugin yaegi load err %s: %w", name, err)
 	}
 
-	PluginsMu.Lock()
-	Plugins[p.Name] = p
-	PluginsMu.Unlock()
+	SetPlugin(p.Name, p)
 	return nil
 }
 
@@ -183,6 +184,10 @@ func NewTestPlugin(path string, raw string, name string, pluginType int) (*Plugi
 }
 
 func (p *Plugin) yaegiLoad() error {
+	guard := globalCompileGuard()
+	guard.acquire()
+	defer guard.release()
+
 	p.yaegiIntp = interp.New(interp.Options{})
 	err := p.yaegiIntp.Use(stdlib.Symbols)
 
@@ -836,6 +841,9 @@ func SafeDeletePlugin(id string) ([]string, error) {
 	common.GlobalMu.Lock()
 	defer common.GlobalMu.Unlock()
 
+	PluginsMu.Lock()
+	defer PluginsMu.Unlock()
+
 	// Check if component exists
 	_, componentExists := Plugins[id]
 	if !componentExists {
@@ -872,22 +880,67 @@ func SafeDeletePlugin(id string) ([]string, error) {
 	return affectedProjects, nil
 }
 
+// Safe accessor functions for Plugins map
+
+// GetPlugin returns a loaded plugin by name
+func GetPlugin(name string) (*Plugin, bool) {
+	PluginsMu.RLock()
+	defer PluginsMu.RUnlock()
+	p, ok := Plugins[name]
+	return p, ok
+}
+
+// SetPlugin registers or replaces a loaded plugin
+func SetPlugin(name string, p *Plugin) {
+	PluginsMu.Lock()
+	defer PluginsMu.Unlock()
+	Plugins[name] = p
+}
+
+// GetAllPlugins returns a snapshot of all loaded plugins
+func GetAllPlugins() map[string]*Plugin {
+	PluginsMu.RLock()
+	defer PluginsMu.RUnlock()
+
+	result := make(map[string]*Plugin, len(Plugins))
+	for name, p := range Plugins {
+		result[name] = p
+	}
+	return result
+}
+
+// PluginCount returns the number of loaded plugins
+func PluginCount() int {
+	PluginsMu.RLock()
+	defer PluginsMu.RUnlock()
+	return len(Plugins)
+}
+
 // Safe accessor functions for PluginsNew map
+
+// GetPluginNew returns the temporary content of a plugin
+func GetPluginNew(id string) (string, bool) {
+	PluginsMu.RLock()
+	defer PluginsMu.RUnlock()
+	content, ok := PluginsNew[id]
+	return content, ok
+}
+
 func SetPluginNew(id, content string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
+	PluginsMu.Lock()
+	defer PluginsMu.Unlock()
 	PluginsNew[id] = content
 }
 
 func DeletePluginNew(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
+	PluginsMu.Lock()
+	defer PluginsMu.Unlock()
 	delete(PluginsNew, id)
 }
 
 func GetAllPluginsNew() map[string]string {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
+	PluginsMu.RLock()
+	defer PluginsMu.RUnlock()
 
 	result := make(map[string]string)
 	for id, content := range PluginsNew {
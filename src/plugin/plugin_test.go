@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+const racePluginCode = `package plugin
+
+import "errors"
+
+func Eval(args ...interface{}) (bool, error) {
+	if len(args) == 0 {
+		return false, errors.New("missing argument")
+	}
+	return true, nil
+}
+`
+
+// Run with -race: concurrent readers must not race with plugin reloads.
+func TestPluginRegistry_ConcurrentAccess(t *testing.T) {
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, _ = GetPlugin("race_plugin")
+				_, _ = GetPluginNew("race_plugin")
+				for _, p := range GetAllPlugins() {
+					_ = p.Name
+				}
+				_ = GetAllPluginsNew()
+				_ = PluginCount()
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		SetPluginNew("race_plugin", fmt.Sprintf("%s// %d\n", racePluginCode, i))
+		if err := NewPlugin("", racePluginCode, "race_plugin", YAEGI_PLUGIN); err != nil {
+			close(stop)
+			wg.Wait()
+			t.Fatalf("NewPlugin error: %v", err)
+		}
+		DeletePluginNew("race_plugin")
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if _, ok := GetPlugin("race_plugin"); !ok {
+		t.Fatalf("expected reloaded plugin to be registered")
+	}
+	if _, err := SafeDeletePlugin("race_plugin"); err != nil {
+		t.Fatalf("SafeDeletePlugin error: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package project
+
+import (
+	"testing"
+	"time"
+
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/output"
+	"AgentSmith-HUB/rules_engine"
+)
+
+const correlationTestRuleset = `<root type="DETECTION" name="correlation">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="action">login</check>
+  </rule>
+</root>`
+
+func TestCorrelationIDStableFromInputToOutput(t *testing.T) {
+	in, err := input.NewInput("", varsTestInput, "corr_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	rs, err := rules_engine.NewRuleset("", correlationTestRuleset, "corr_rs")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	out, err := output.NewOutput("", varsTestOutput, "corr_out")
+	if err != nil {
+		t.Fatalf("NewOutput: %v", err)
+	}
+
+	inputCh := make(chan map[string]interface{}, 1)
+	in.DownStream["corr"] = &inputCh
+	in.ProcessTestData(map[string]interface{}{"action": "login"})
+
+	event := <-inputCh
+	id, ok := event[common.DefaultCorrelationIDField].(string)
+	if !ok || id == "" {
+		t.Fatalf("input did not inject a correlation id: %v", event)
+	}
+
+	results := rs.EngineCheck(event)
+	if len(results) != 1 {
+		t.Fatalf("expected one ruleset result, got %d", len(results))
+	}
+
+	upstream := make(chan map[string]interface{}, 1)
+	collected := make(chan map[string]interface{}, 1)
+	out.UpStream["corr"] = &upstream
+	out.TestCollectionChan = &collected
+	out.SetTestMode()
+	if err := out.StartForTesting(); err != nil {
+		t.Fatalf("StartForTesting: %v", err)
+	}
+	defer out.StopForTesting()
+
+	upstream <- results[0]
+	select {
+	case msg := <-collected:
+		if got := msg[common.DefaultCorrelationIDField]; got != id {
+			t.Fatalf("output correlation id = %v, want %s", got, id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("output did not emit the event")
+	}
+
+	// An id assigned upstream is kept as-is
+	in.ProcessTestData(map[string]interface{}{common.DefaultCorrelationIDField: "upstream-id"})
+	if got := (<-inputCh)[common.DefaultCorrelationIDField]; got != "upstream-id" {
+		t.Fatalf("existing correlation id replaced with %v", got)
+	}
+}
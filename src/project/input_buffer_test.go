@@ -0,0 +1,58 @@
+package project
+
+import (
+	"testing"
+
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/output"
+)
+
+func TestInputBufferSizeFlowsIntoChannels(t *testing.T) {
+	cases := map[string]struct {
+		raw  string
+		want int
+	}{
+		"configured": {varsTestInput + "buffer_size: 64\n", 64},
+		"default":    {varsTestInput, 1024},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			in, err := input.NewInput("", tc.raw, "buf_in")
+			if err != nil {
+				t.Fatalf("NewInput: %v", err)
+			}
+			out, err := output.NewOutput("", varsTestOutput, "buf_out")
+			if err != nil {
+				t.Fatalf("NewOutput: %v", err)
+			}
+			SetInput("buf_in", in)
+			SetOutput("buf_out", out)
+			defer DeleteInput("buf_in")
+			defer DeleteOutput("buf_out")
+
+			p := newVarsTestProject("buf_project", "")
+			p.Config.Content = "INPUT.buf_in -> OUTPUT.buf_out"
+			p.Config.Vars = nil
+			if err := p.parseContent(); err != nil {
+				t.Fatalf("parseContent: %v", err)
+			}
+			if err := p.initComponents(); err != nil {
+				t.Fatalf("initComponents: %v", err)
+			}
+			defer func() {
+				for pns := range p.Outputs {
+					DeletePNSOutput(pns)
+				}
+			}()
+
+			if len(in.DownStream) != 1 {
+				t.Fatalf("expected 1 downstream channel, got %d", len(in.DownStream))
+			}
+			for pns, ch := range in.DownStream {
+				if got := cap(*ch); got != tc.want {
+					t.Fatalf("channel %s capacity = %d, want %d", pns, got, tc.want)
+				}
+			}
+		})
+	}
+}
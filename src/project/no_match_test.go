@@ -0,0 +1,198 @@
+package project
+
+import (
+	"testing"
+	"time"
+
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/output"
+	"AgentSmith-HUB/rules_engine"
+)
+
+func TestEmitNoMatchRoutesUnmatchedEvents(t *testing.T) {
+	in, err := input.NewInput("", varsTestInput, "nomatch_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	rs, err := rules_engine.NewRuleset("", `<root type="DETECTION" name="nomatch"><rule id="r1" name="r1"><check type="EQU" field="a">1</check></rule></root>`, "nomatch_rs")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	for _, id := range []string{"nomatch_alerts", "nomatch_audit"} {
+		out, err := output.NewOutput("", varsTestOutput, id)
+		if err != nil {
+			t.Fatalf("NewOutput: %v", err)
+		}
+		SetOutput(id, out)
+		defer DeleteOutput(id)
+	}
+	SetInput("nomatch_in", in)
+	SetRuleset("nomatch_rs", rs)
+	defer DeleteInput("nomatch_in")
+	defer DeleteRuleset("nomatch_rs")
+
+	// run wires the project and feeds one matching and one unmatched event through its ruleset.
+	// It returns the events on the alert channel and on the emit_no_match channel, if any.
+	run := func(id string, emit *EmitNoMatchConfig) (alerts, noMatch *chan map[string]interface{}) {
+		p := &Project{
+			Id: id,
+			Config: &ProjectConfig{
+				Id:          id,
+				Content:     "INPUT.nomatch_in -> RULESET.nomatch_rs\nRULESET.nomatch_rs -> OUTPUT.nomatch_alerts",
+				EmitNoMatch: emit,
+			},
+			Inputs:      make(map[string]*input.Input),
+			Outputs:     make(map[string]*output.Output),
+			Rulesets:    make(map[string]*rules_engine.Ruleset),
+			MsgChannels: make(map[string]*chan map[string]interface{}),
+		}
+		if err := p.parseContent(); err != nil {
+			t.Fatalf("parseContent(%s): %v", id, err)
+		}
+		if err := p.initComponents(); err != nil {
+			t.Fatalf("initComponents(%s): %v", id, err)
+		}
+		t.Cleanup(func() {
+			for pns := range p.Rulesets {
+				DeletePNSRuleset(pns)
+			}
+			for pns := range p.Outputs {
+				DeletePNSOutput(pns)
+			}
+		})
+
+		var rsPNS string
+		for _, node := range p.FlowNodes {
+			switch {
+			case node.ToType == "RULESET":
+				rsPNS = node.ToPNS
+			case node.NoMatch:
+				noMatch = p.MsgChannels[node.ToPNS]
+			case node.ToType == "OUTPUT":
+				alerts = p.MsgChannels[node.ToPNS]
+			}
+		}
+		projectRs := p.Rulesets[rsPNS]
+		if err := projectRs.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { projectRs.Stop() })
+		up := p.MsgChannels[rsPNS]
+		*up <- map[string]interface{}{"a": "1"}
+		*up <- map[string]interface{}{"a": "2"}
+		return alerts, noMatch
+	}
+
+	alerts, noMatch := run("nomatch_enabled", &EmitNoMatchConfig{Output: "nomatch_audit"})
+	if noMatch == nil {
+		t.Fatal("emit_no_match did not create a channel to the audit output")
+	}
+	select {
+	case event := <-*noMatch:
+		if event["a"] != "2" || event[rules_engine.NoMatchFieldName] != "nomatch_rs" {
+			t.Fatalf("unexpected no-match event: %v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the unmatched event was not routed to the audit output")
+	}
+	select {
+	case event := <-*alerts:
+		if event["a"] != "1" {
+			t.Fatalf("unexpected alert: %v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the matching event was not sent to the alert output")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(*noMatch) != 0 || len(*alerts) != 0 {
+		t.Fatalf("unexpected extra events: %d no-match, %d alerts", len(*noMatch), len(*alerts))
+	}
+
+	alerts, noMatch = run("nomatch_disabled", nil)
+	if noMatch != nil {
+		t.Fatal("a project without emit_no_match must not route unmatched events")
+	}
+	select {
+	case event := <-*alerts:
+		if event["a"] != "1" {
+			t.Fatalf("the unmatched event reached the alert output: %v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the matching event was not sent to the alert output")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(*alerts) != 0 {
+		t.Fatalf("unexpected extra alerts: %d", len(*alerts))
+	}
+}
+
+func TestUnmatchedEventsCountAsUnrouted(t *testing.T) {
+	in, err := input.NewInput("", varsTestInput, "unrouted_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	rs, err := rules_engine.NewRuleset("", `<root type="DETECTION" name="unrouted"><rule id="r1" name="r1"><check type="EQU" field="a">1</check></rule></root>`, "unrouted_rs")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	out, err := output.NewOutput("", varsTestOutput, "unrouted_alerts")
+	if err != nil {
+		t.Fatalf("NewOutput: %v", err)
+	}
+	SetInput("unrouted_in", in)
+	SetRuleset("unrouted_rs", rs)
+	SetOutput("unrouted_alerts", out)
+	defer DeleteInput("unrouted_in")
+	defer DeleteRuleset("unrouted_rs")
+	defer DeleteOutput("unrouted_alerts")
+
+	p := &Project{
+		Id: "unrouted",
+		Config: &ProjectConfig{
+			Id:      "unrouted",
+			Content: "INPUT.unrouted_in -> RULESET.unrouted_rs\nRULESET.unrouted_rs -> OUTPUT.unrouted_alerts",
+		},
+		Inputs:      make(map[string]*input.Input),
+		Outputs:     make(map[string]*output.Output),
+		Rulesets:    make(map[string]*rules_engine.Ruleset),
+		MsgChannels: make(map[string]*chan map[string]interface{}),
+	}
+	if err := p.parseContent(); err != nil {
+		t.Fatalf("parseContent: %v", err)
+	}
+	if err := p.initComponents(); err != nil {
+		t.Fatalf("initComponents: %v", err)
+	}
+	defer func() {
+		for pns := range p.Rulesets {
+			DeletePNSRuleset(pns)
+		}
+		for pns := range p.Outputs {
+			DeletePNSOutput(pns)
+		}
+	}()
+
+	var rsPNS string
+	for _, node := range p.FlowNodes {
+		if node.NoMatch {
+			t.Fatal("a project without emit_no_match must not route unmatched events")
+		}
+		if node.ToType == "RULESET" {
+			rsPNS = node.ToPNS
+		}
+	}
+	projectRs := p.Rulesets[rsPNS]
+	if err := projectRs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer projectRs.Stop()
+	*p.MsgChannels[rsPNS] <- map[string]interface{}{"a": "2"}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.UnroutedEvents() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 1 unrouted event, got %d", p.UnroutedEvents())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,124 @@
+package project
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PreviewNode is a component referenced by the content of a project
+type PreviewNode struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Exists    bool   `json:"exists"`
+	Temporary bool   `json:"temporary"` // Only exists as an unsaved change
+}
+
+// PreviewEdge is a data flow of the content
+type PreviewEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Line int    `json:"line"`
+}
+
+// PreviewMissing is a reference to a component that is not saved
+type PreviewMissing struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Line      int    `json:"line"`
+	Position  string `json:"position"`  // source or destination
+	Temporary bool   `json:"temporary"` // The component only exists as an unsaved change
+}
+
+// PreviewError is a validation error, Line is 0 when the error is not tied to a line
+type PreviewError struct {
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProjectPreview is the topology of a project DSL resolved against the saved components. Lines are
+// those of the full YAML.
+type ProjectPreview struct {
+	Valid   bool             `json:"valid"`
+	Nodes   []PreviewNode    `json:"nodes"`
+	Edges   []PreviewEdge    `json:"edges"`
+	Missing []PreviewMissing `json:"missing"`
+	Errors  []PreviewError   `json:"errors"`
+}
+
+var previewErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// Preview validates a project DSL like Verify and resolves its topology without creating the
+// project. Every reference to a missing component is listed, not only the first one.
+func Preview(raw string) *ProjectPreview {
+	preview := &ProjectPreview{
+		Nodes:   []PreviewNode{},
+		Edges:   []PreviewEdge{},
+		Missing: []PreviewMissing{},
+		Errors:  []PreviewError{},
+	}
+
+	if err := Verify("", raw); err != nil {
+		previewErr := PreviewError{Message: err.Error()}
+		if match := previewErrorLine.FindStringSubmatch(previewErr.Message); len(match) > 1 {
+			previewErr.Line, _ = strconv.Atoi(match[1])
+		}
+		preview.Errors = append(preview.Errors, previewErr)
+	}
+
+	var cfg ProjectConfig
+	if err := yaml.Unmarshal([]byte(raw), &cfg); err != nil {
+		return preview
+	}
+	p := &Project{Config: &cfg}
+	if _, err := p.parseFlowLines(); err != nil {
+		// The content has no topology to show, Verify already reported why
+		return preview
+	}
+
+	offset, _ := contentLineOffset(raw)
+	contentLines := contentLineNumbers(cfg.Content)
+	seen := make(map[string]bool)
+	addNode := func(componentType, id string, line int, position string) {
+		exists, tempExists := ValidateComponent(componentType, id)
+		if !exists {
+			preview.Missing = append(preview.Missing, PreviewMissing{
+				Type:      strings.ToLower(componentType),
+				ID:        id,
+				Line:      line,
+				Position:  position,
+				Temporary: tempExists,
+			})
+		}
+		key := componentType + "." + id
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		preview.Nodes = append(preview.Nodes, PreviewNode{
+			Type:      strings.ToLower(componentType),
+			ID:        id,
+			Exists:    exists,
+			Temporary: !exists && tempExists,
+		})
+	}
+
+	for _, node := range p.FlowNodes {
+		line := 0
+		if contentLine, ok := contentLines[node.Content]; ok {
+			line = contentLine + offset
+		}
+		addNode(node.FromType, node.FromID, line, "source")
+		addNode(node.ToType, node.ToID, line, "destination")
+		preview.Edges = append(preview.Edges, PreviewEdge{
+			From: getNodeFromKey(node),
+			To:   getNodeToKey(node),
+			Line: line,
+		})
+	}
+
+	preview.Valid = len(preview.Errors) == 0
+	return preview
+}
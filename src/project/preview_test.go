@@ -0,0 +1,43 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"AgentSmith-HUB/input"
+)
+
+func TestPreviewFlagsMissingOutputWithLine(t *testing.T) {
+	in, err := input.NewInput("", varsTestInput, "preview_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	SetInput("preview_in", in)
+	defer DeleteInput("preview_in")
+
+	raw := `content: |
+  INPUT.preview_in -> OUTPUT.preview_missing_out
+`
+	preview := Preview(raw)
+
+	if preview.Valid {
+		t.Fatalf("expected the preview to be invalid")
+	}
+	if len(preview.Missing) != 1 {
+		t.Fatalf("expected 1 missing component, got %+v", preview.Missing)
+	}
+	missing := preview.Missing[0]
+	if missing.Type != "output" || missing.ID != "preview_missing_out" || missing.Position != "destination" || missing.Line != 2 {
+		t.Fatalf("unexpected missing component %+v", missing)
+	}
+	if len(preview.Errors) != 1 || preview.Errors[0].Line != 2 || !strings.Contains(preview.Errors[0].Message, "preview_missing_out") {
+		t.Fatalf("expected the validation error at line 2, got %+v", preview.Errors)
+	}
+
+	if len(preview.Edges) != 1 || preview.Edges[0].From != "INPUT.preview_in" || preview.Edges[0].To != "OUTPUT.preview_missing_out" {
+		t.Fatalf("unexpected edges %+v", preview.Edges)
+	}
+	if len(preview.Nodes) != 2 || !preview.Nodes[0].Exists || preview.Nodes[1].Exists {
+		t.Fatalf("unexpected nodes %+v", preview.Nodes)
+	}
+}
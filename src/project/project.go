@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -82,7 +83,7 @@ func collectAllComponentStats() []common.DailyStatsData {
 
 	// Collect plugin statistics (plugins are global, no project lock needed)
 	// Only collect if there are running projects or if increments are greater than 0
-	for pluginName, p := range plugin.Plugins {
+	for pluginName, p := range plugin.GetAllPlugins() {
 		// Plugin success statistics - use increment method
 		successIncrement := p.GetSuccessIncrementAndUpdate()
 		if successIncrement > 0 {
@@ -111,6 +112,40 @@ func collectAllComponentStats() []common.DailyStatsData {
 	return components
 }
 
+// collectRuleHits returns the per-rule hit increments of all running projects
+func collectRuleHits() map[common.RuleHitKey]uint64 {
+	hits := make(map[common.RuleHitKey]uint64)
+	ForEachProject(func(_ string, proj *Project) bool {
+		if proj.Status != common.StatusRunning {
+			return true
+		}
+		for _, r := range proj.Rulesets {
+			for ruleID, n := range r.GetRuleHitIncrementsAndUpdate() {
+				hits[common.RuleHitKey{ProjectID: proj.Id, RulesetID: r.RulesetID, RuleID: ruleID}] += n
+			}
+		}
+		return true
+	})
+	return hits
+}
+
+// deployedRules lists every rule of the running projects
+func deployedRules() []common.RuleHitKey {
+	var rules []common.RuleHitKey
+	ForEachProject(func(_ string, proj *Project) bool {
+		if proj.Status != common.StatusRunning {
+			return true
+		}
+		for _, r := range proj.Rulesets {
+			for _, ruleID := range r.RuleIDs() {
+				rules = append(rules, common.RuleHitKey{ProjectID: proj.Id, RulesetID: r.RulesetID, RuleID: ruleID})
+			}
+		}
+		return true
+	})
+	return rules
+}
+
 // GetAffectedProjects returns the list of project IDs affected by component changes
 func GetAffectedProjects(componentType string, componentID string) []string {
 	affectedProjects := make(map[string]struct{})
@@ -329,21 +364,24 @@ func SetProjectErrorStatus(projectID string, componentErrors []common.ProjectCom
 
 func init() {
 	GlobalProject = &GlobalProjectInfo{}
-	GlobalProject.Projects = make(map[string]*Project)
-	GlobalProject.Inputs = make(map[string]*input.Input)
-	GlobalProject.Outputs = make(map[string]*output.Output)
-	GlobalProject.Rulesets = make(map[string]*rules_engine.Ruleset)
+	GlobalProject.Projects = make(GuardedMap[*Project])
+	GlobalProject.Inputs = make(GuardedMap[*input.Input])
+	GlobalProject.Outputs = make(GuardedMap[*output.Output])
+	GlobalProject.Rulesets = make(GuardedMap[*rules_engine.Ruleset])
 
-	GlobalProject.PNSOutputs = make(map[string]*output.Output)
-	GlobalProject.PNSRulesets = make(map[string]*rules_engine.Ruleset)
+	GlobalProject.PNSInputs = make(GuardedMap[*input.Input])
+	GlobalProject.PNSOutputs = make(GuardedMap[*output.Output])
+	GlobalProject.PNSRulesets = make(GuardedMap[*rules_engine.Ruleset])
 
-	GlobalProject.ProjectsNew = make(map[string]string)
-	GlobalProject.InputsNew = make(map[string]string)
-	GlobalProject.OutputsNew = make(map[string]string)
-	GlobalProject.RulesetsNew = make(map[string]string)
+	GlobalProject.ProjectsNew = make(GuardedMap[string])
+	GlobalProject.InputsNew = make(GuardedMap[string])
+	GlobalProject.OutputsNew = make(GuardedMap[string])
+	GlobalProject.RulesetsNew = make(GuardedMap[string])
 
 	// AllProjectRawConfig is now managed through common.SetRawConfig functions
 	common.SetStatsCollector(collectAllComponentStats)
+	common.SetRuleHitCollector(collectRuleHits)
+	common.SetDeployedRulesProvider(deployedRules)
 
 	// Register the component checker function
 	common.SetProjectComponentChecker(checkAllProjectComponentsImpl)
@@ -419,19 +457,8 @@ func Verify(path string, raw string) error {
 			contentLineNum, _ := strconv.Atoi(match[1])
 
 			// Calculate the actual line number in the full YAML
-			// Find the line number of 'content:' in the original YAML
-			lines := strings.Split(raw, "\n")
-			contentLineIndex := -1
-			for i, line := range lines {
-				if strings.TrimSpace(line) == "content:" || strings.TrimSpace(line) == "content: |" {
-					contentLineIndex = i
-					break
-				}
-			}
-
-			if contentLineIndex != -1 {
-				// Adjust line number: content line number + content line index + 1
-				actualLineNum := contentLineNum + contentLineIndex + 1
+			if offset, ok := contentLineOffset(raw); ok {
+				actualLineNum := contentLineNum + offset
 				// Replace the line number in the error message
 				errMsg = regexp.MustCompile(`at line \d+`).ReplaceAllString(errMsg, fmt.Sprintf("at line %d", actualLineNum))
 			}
@@ -505,8 +532,68 @@ func NewProject(path string, raw string, id string, test bool) (*Project, error)
 	return p, nil
 }
 
+// contentLineOffset returns what to add to a line number of the content to get its line in the full
+// YAML, found from the position of the 'content:' key
+func contentLineOffset(raw string) (int, bool) {
+	for i, line := range strings.Split(raw, "\n") {
+		if strings.TrimSpace(line) == "content:" || strings.TrimSpace(line) == "content: |" {
+			// Adjust line number: content line number + content line index + 1
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// contentLineNumbers maps each flow line of the content to its line number, skipping empty lines and comments
+func contentLineNumbers(content string) map[string]int {
+	lineMap := make(map[string]int)
+	for i, line := range strings.Split(content, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+		lineMap[trimmedLine] = i + 1
+	}
+	return lineMap
+}
+
 // parseContent parses the project content to build the data flow graph
 func (p *Project) parseContent() error {
+	flowGraph, err := p.parseFlowLines()
+	if err != nil {
+		return err
+	}
+
+	// check loop
+	if err := p.detectCycle(); err != nil {
+		return err
+	}
+
+	p.getPNS()
+
+	// Check if all referenced components exist
+	if err := p.validateComponentExistence(flowGraph); err != nil {
+		return err
+	}
+
+	if err := p.validateVars(); err != nil {
+		return err
+	}
+
+	if err := p.validateLimits(); err != nil {
+		return err
+	}
+
+	if err := p.addNoMatchNodes(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseFlowLines parses the "TYPE.id -> TYPE.id" lines of the content into p.FlowNodes and returns
+// the flow graph keyed by edge, without checking that the components exist
+func (p *Project) parseFlowLines() (map[string][]string, error) {
 	flowGraph := make(map[string][]string)
 	lines := strings.Split(p.Config.Content, "\n")
 	edgeSet := make(map[string]struct{}) // Used to detect duplicate flows
@@ -531,17 +618,17 @@ func (p *Project) parseContent() error {
 		if len(parts) != 2 {
 			// Check for invalid arrow-like patterns and provide specific error messages
 			if strings.Contains(line, "→") {
-				return fmt.Errorf("invalid arrow format at line %d: use '->' instead of '→' in %q", lineNum+1, line)
+				return nil, fmt.Errorf("invalid arrow format at line %d: use '->' instead of '→' in %q", lineNum+1, line)
 			} else if strings.Contains(line, "—>") {
-				return fmt.Errorf("invalid arrow format at line %d: use '->' instead of '—>' in %q", lineNum+1, line)
+				return nil, fmt.Errorf("invalid arrow format at line %d: use '->' instead of '—>' in %q", lineNum+1, line)
 			} else if strings.Contains(line, "-->") {
-				return fmt.Errorf("invalid arrow format at line %d: use '->' instead of '-->' in %q", lineNum+1, line)
+				return nil, fmt.Errorf("invalid arrow format at line %d: use '->' instead of '-->' in %q", lineNum+1, line)
 			} else if strings.Contains(line, "=>") {
-				return fmt.Errorf("invalid arrow format at line %d: use '=>' instead of '=>' in %q", lineNum+1, line)
+				return nil, fmt.Errorf("invalid arrow format at line %d: use '=>' instead of '=>' in %q", lineNum+1, line)
 			} else if strings.Contains(line, "—") || strings.Contains(line, "–") || strings.Contains(line, "―") {
-				return fmt.Errorf("invalid arrow format at line %d: use '->' instead of dash characters in %q", lineNum+1, line)
+				return nil, fmt.Errorf("invalid arrow format at line %d: use '->' instead of dash characters in %q", lineNum+1, line)
 			}
-			return fmt.Errorf("invalid line format at line %d: missing or invalid arrow operator in %q (use '->')", lineNum+1, line)
+			return nil, fmt.Errorf("invalid line format at line %d: missing or invalid arrow operator in %q (use '->')", lineNum+1, line)
 		}
 
 		from := strings.TrimSpace(parts[0])
@@ -552,22 +639,22 @@ func (p *Project) parseContent() error {
 		toType, toID := parseNode(to)
 
 		if fromType == "" || toType == "" {
-			return fmt.Errorf("invalid node format at line %d: %s -> %s (expected format: TYPE.ID -> TYPE.ID)", lineNum+1, from, to)
+			return nil, fmt.Errorf("invalid node format at line %d: %s -> %s (expected format: TYPE.ID -> TYPE.ID)", lineNum+1, from, to)
 		}
 
 		// Validate flow rules
 		if toType == "INPUT" {
-			return fmt.Errorf("INPUT node %q cannot be a destination at line %d", to, lineNum+1)
+			return nil, fmt.Errorf("INPUT node %q cannot be a destination at line %d", to, lineNum+1)
 		}
 
 		if fromType == "OUTPUT" {
-			return fmt.Errorf("OUTPUT node %q cannot be a source at line %d", from, lineNum+1)
+			return nil, fmt.Errorf("OUTPUT node %q cannot be a source at line %d", from, lineNum+1)
 		}
 
 		// Check for duplicate flows
 		edgeKey := from + "->" + to
 		if _, exists := edgeSet[edgeKey]; exists {
-			return fmt.Errorf("duplicate data flow detected at line %d: %s", lineNum+1, edgeKey)
+			return nil, fmt.Errorf("duplicate data flow detected at line %d: %s", lineNum+1, edgeKey)
 		}
 		edgeSet[edgeKey] = struct{}{}
 
@@ -587,21 +674,201 @@ func (p *Project) parseContent() error {
 		p.BackUpFlowNodes = append(p.BackUpFlowNodes, tmpNode)
 	}
 
-	// check loop
-	if err := p.detectCycle(); err != nil {
-		return err
+	return flowGraph, nil
+}
+
+// addNoMatchNodes adds an edge from every detection ruleset of the project to the emit_no_match
+// output, so the output instances are created, started and stopped with the rest of the flow
+func (p *Project) addNoMatchNodes() error {
+	if p.Config == nil || p.Config.EmitNoMatch == nil {
+		return nil
+	}
+	outputID := strings.TrimSpace(p.Config.EmitNoMatch.Output)
+	if outputID == "" {
+		return fmt.Errorf("emit_no_match.output cannot be empty")
+	}
+	if exists, tempExists := ValidateComponent("OUTPUT", outputID); !exists {
+		if tempExists {
+			return fmt.Errorf("emit_no_match cannot reference temporary output component '%s', please save it first", outputID)
+		}
+		return fmt.Errorf("emit_no_match output component '%s' not found", outputID)
 	}
 
-	p.getPNS()
+	seen := make(map[string]bool)
+	var nodes []FlowNode
+	addRuleset := func(id, pns string) {
+		if seen[pns] {
+			return
+		}
+		seen[pns] = true
+		if rs, ok := GetRuleset(id); !ok || !rs.IsDetection {
+			return
+		}
+		nodes = append(nodes, FlowNode{
+			FromPNS:  pns,
+			ToPNS:    pns + ".NO_MATCH.OUTPUT." + outputID,
+			Content:  fmt.Sprintf("RULESET.%s -> OUTPUT.%s (emit_no_match)", id, outputID),
+			FromType: "RULESET",
+			ToType:   "OUTPUT",
+			FromID:   id,
+			ToID:     outputID,
+			NoMatch:  true,
+		})
+	}
+	for _, node := range p.FlowNodes {
+		if node.FromType == "RULESET" {
+			addRuleset(node.FromID, node.FromPNS)
+		}
+		if node.ToType == "RULESET" {
+			addRuleset(node.ToID, node.ToPNS)
+		}
+	}
+	p.FlowNodes = append(p.FlowNodes, nodes...)
+	return nil
+}
 
-	// Check if all referenced components exist
-	if err := p.validateComponentExistence(flowGraph); err != nil {
-		return err
+// UnroutedEvents returns how many events the detection rulesets of the running project dropped
+// because no rule matched and the project does not set emit_no_match
+func (p *Project) UnroutedEvents() uint64 {
+	var total uint64
+	for _, rs := range p.Rulesets {
+		total += rs.GetUnroutedEvents()
+	}
+	return total
+}
+
+// validateLimits checks the soft limits of the project
+func (p *Project) validateLimits() error {
+	if p.Config == nil || p.Config.Limits == nil {
+		return nil
+	}
+	if p.Config.Limits.MaxTasks < 0 {
+		return fmt.Errorf("limits.max_tasks cannot be negative")
+	}
+	if p.Config.Limits.MaxMemoryMB < 0 {
+		return fmt.Errorf("limits.max_memory_mb cannot be negative")
+	}
+	return nil
+}
+
+// setupBudget shares one budget built from the project limits between the project's rulesets
+func (p *Project) setupBudget() {
+	p.budget = nil
+	if p.Config != nil && p.Config.Limits != nil && !p.Testing {
+		p.budget = rules_engine.NewProjectBudget(p.Config.Limits.MaxTasks, p.Config.Limits.MaxMemoryMB)
+	}
+	for _, rs := range p.Rulesets {
+		rs.SetProjectBudget(p.budget)
+	}
+}
+
+// BudgetStats returns the usage of the project against its soft limits, ok is false when the
+// running instance has none
+func (p *Project) BudgetStats() (rules_engine.ProjectBudgetStats, bool) {
+	if p.budget == nil {
+		return rules_engine.ProjectBudgetStats{}, false
+	}
+	return p.budget.Stats(), true
+}
+
+// componentVars returns the project var overrides for a node, e.g. ("INPUT", "kafka_in")
+func (p *Project) componentVars(componentType, id string) map[string]interface{} {
+	if p.Config == nil {
+		return nil
+	}
+	for node, overrides := range p.Config.Vars {
+		if t, nodeID := parseNode(node); t == componentType && nodeID == id {
+			return overrides
+		}
+	}
+	return nil
+}
+
+// channelCapacity returns the capacity of the channel feeding node.ToPNS: the buffer_size of the
+// upstream input (project vars included) when set, def otherwise
+func (p *Project) channelCapacity(node *FlowNode, def int) int {
+	if node.FromType != "INPUT" {
+		return def
 	}
+	if size, ok := p.componentVars("INPUT", node.FromID)["buffer_size"].(int); ok && size > 0 {
+		return size
+	}
+	if in, ok := GetInput(node.FromID); ok {
+		return in.DownstreamBufferSize(def)
+	}
+	return def
+}
 
+// validateVars checks that vars only target INPUT/OUTPUT nodes of this project
+// and that every overridden key exists in the component config
+func (p *Project) validateVars() error {
+	if p.Config == nil || len(p.Config.Vars) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	for _, node := range p.FlowNodes {
+		used[node.FromType+"."+node.FromID] = true
+		used[node.ToType+"."+node.ToID] = true
+	}
+
+	nodes := make([]string, 0, len(p.Config.Vars))
+	for node := range p.Config.Vars {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		componentType, id := parseNode(node)
+		if componentType != "INPUT" && componentType != "OUTPUT" {
+			return fmt.Errorf("invalid vars target %q: only INPUT and OUTPUT components can be overridden", node)
+		}
+		if !used[componentType+"."+id] {
+			return fmt.Errorf("invalid vars target %q: component is not used in project content", node)
+		}
+
+		overrides := p.Config.Vars[node]
+		if componentType == "INPUT" {
+			if in, exists := GetInput(id); exists {
+				raw, err := common.ApplyConfigOverrides(in.Config.RawConfig, overrides)
+				if err != nil {
+					return fmt.Errorf("invalid vars for %s: %w", node, err)
+				}
+				if err := input.Verify("", raw); err != nil {
+					return fmt.Errorf("invalid vars for %s: %w", node, err)
+				}
+			}
+		} else {
+			if out, exists := GetOutput(id); exists {
+				raw, err := common.ApplyConfigOverrides(out.Config.RawConfig, overrides)
+				if err != nil {
+					return fmt.Errorf("invalid vars for %s: %w", node, err)
+				}
+				if err := output.Verify("", raw); err != nil {
+					return fmt.Errorf("invalid vars for %s: %w", node, err)
+				}
+			}
+		}
+	}
 	return nil
 }
 
+// newInputInstance copies an input for a project node sequence, applying project vars when present
+func newInputInstance(existing *input.Input, overrides map[string]interface{}, pns string) (*input.Input, error) {
+	if len(overrides) > 0 {
+		return input.NewWithOverrides(existing, overrides, pns)
+	}
+	return input.NewFromExisting(existing, pns)
+}
+
+// newOutputInstance copies an output for a project node sequence, applying project vars when present
+func newOutputInstance(existing *output.Output, overrides map[string]interface{}, pns string) (*output.Output, error) {
+	if len(overrides) > 0 {
+		return output.NewWithOverrides(existing, overrides, pns)
+	}
+	return output.NewFromExisting(existing, pns)
+}
+
 func getNodeToKey(node FlowNode) string {
 	return node.ToType + "." + node.ToID
 }
@@ -617,22 +884,7 @@ func (p *Project) detectCycle() error {
 	nodeLines := make(map[string]int) // Track line numbers for error reporting
 
 	// Create a map to store line numbers for each flow node content
-	contentLineMap := make(map[string]int)
-	lines := strings.Split(p.Config.Content, "\n")
-	actualLineNum := 0
-
-	for i, line := range lines {
-		actualLineNum = i + 1
-		trimmedLine := strings.TrimSpace(line)
-
-		// Skip empty lines and comment lines when building the map
-		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
-			continue
-		}
-
-		// Store the actual line number for this content
-		contentLineMap[trimmedLine] = actualLineNum
-	}
+	contentLineMap := contentLineNumbers(p.Config.Content)
 
 	for _, node := range p.FlowNodes {
 		fromKey := getNodeFromKey(node)
@@ -743,6 +995,10 @@ func (p *Project) getPNS() {
 		if p.Testing {
 			p.FlowNodes[i].FromPNS = fmt.Sprintf("TEST_%s_%s", p.Id, fromSequence)
 			p.FlowNodes[i].ToPNS = fmt.Sprintf("TEST_%s_%s", p.Id, toSequence)
+		} else if p.scopedByProject() {
+			// These projects run their own component instances, so scope sequences by project
+			p.FlowNodes[i].FromPNS = fmt.Sprintf("PROJECT.%s.%s", p.Id, fromSequence)
+			p.FlowNodes[i].ToPNS = fmt.Sprintf("PROJECT.%s.%s", p.Id, toSequence)
 		} else {
 			p.FlowNodes[i].FromPNS = fromSequence
 			p.FlowNodes[i].ToPNS = toSequence
@@ -750,6 +1006,13 @@ func (p *Project) getPNS() {
 	}
 }
 
+// scopedByProject reports whether the project runs its own ruleset and output instances instead of
+// sharing them with projects that have the same sequences: vars change the component configs, while
+// limits and emit_no_match are bound to the rulesets of one project
+func (p *Project) scopedByProject() bool {
+	return p.Config != nil && (len(p.Config.Vars) > 0 || p.Config.Limits != nil || p.Config.EmitNoMatch != nil)
+}
+
 // parseNode splits "TYPE.name" into ("TYPE", "name")
 func parseNode(s string) (string, string) {
 	parts := strings.SplitN(s, ".", 2)
@@ -781,22 +1044,8 @@ func (p *Project) validateComponentExistence(flowGraph map[string][]string) erro
 	}
 
 	// Create a map to store line numbers for each flow node content
-	contentLineMap := make(map[string]int)
+	contentLineMap := contentLineNumbers(p.Config.Content)
 	lines := strings.Split(p.Config.Content, "\n")
-	actualLineNum := 0
-
-	for i, line := range lines {
-		actualLineNum = i + 1
-		trimmedLine := strings.TrimSpace(line)
-
-		// Skip empty lines and comment lines when building the map
-		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
-			continue
-		}
-
-		// Store the actual line number for this content
-		contentLineMap[trimmedLine] = actualLineNum
-	}
 
 	for _, node := range p.FlowNodes {
 		// Get the line number from our map
@@ -944,6 +1193,14 @@ func (p *Project) Start(lock bool) error {
 		return fmt.Errorf("failed to initialize project components: %w", err)
 	}
 
+	err = p.setupKafkaTxn()
+	if err != nil {
+		_ = p.stopComponentsInternal()
+		p.SetProjectStatus(common.StatusError, err)
+		return err
+	}
+	p.setupBudget()
+
 	err = p.runComponents()
 	if err != nil {
 		// Stop all components that were initialized and may have been started
@@ -1317,9 +1574,14 @@ func (p *Project) disconnectInputsFromDownstream() {
 	for id, in := range inputs {
 		rightNodes := p.getPartner("right", id)
 
+		_, isPNSInput := GetPNSInput(id)
 		for _, downstreamID := range rightNodes {
 			// Use the safe deletion function to properly clean up downstream connections
-			SafeDeleteInputDownstream(in.Id, downstreamID)
+			if isPNSInput {
+				SafeDeletePNSInputDownstream(id, downstreamID)
+			} else {
+				SafeDeleteInputDownstream(in.Id, downstreamID)
+			}
 			logger.Debug("Disconnected input from downstream",
 				"project", p.Id, "input", in.Id, "downstream", downstreamID)
 		}
@@ -1334,9 +1596,21 @@ func (p *Project) stopInputComponents() []error {
 	var stopErrors []error
 	inputs := p.GetProjectInputs()
 	for id, in := range inputs {
+		// Project-specific inputs (from project vars) belong to this project only
+		if pnsInput, isPNSInput := GetPNSInput(id); isPNSInput {
+			DeletePNSInput(id)
+			if err := pnsInput.Stop(); err != nil {
+				logger.Error("Failed to stop project input", "project", p.Id, "input", in.Id, "sequence", id, "error", err)
+				stopErrors = append(stopErrors, fmt.Errorf("input %s: %w", id, err))
+			} else {
+				logger.Info("Stopped project input", "project", p.Id, "input", in.Id, "sequence", id)
+			}
+			continue
+		}
+
 		// Input components need reference counting to determine if they should be stopped
 		// Only stop when no other projects are using this input (excluding current project)
-		if CalculateRefCount(id, p.Id) == 0 {
+		if CalculateInputRefCount(in.Id, p.Id) == 0 {
 			// Use safe accessor to get global input
 			globalInput, exists := GetInput(in.Id)
 
@@ -1365,7 +1639,7 @@ func (p *Project) stopInputComponents() []error {
 			}
 		} else {
 			logger.Debug("Input still in use by other projects, not stopping",
-				"project", p.Id, "input", in.Id, "ref_count", CalculateRefCount(id, p.Id))
+				"project", p.Id, "input", in.Id, "ref_count", CalculateInputRefCount(in.Id, p.Id))
 		}
 	}
 	return stopErrors
@@ -1485,11 +1759,14 @@ func (p *Project) initComponents() error {
 			DeletePNSRuleset(pns)
 		}
 
-		// Clean up created PNS outputs (only if not in testing mode)
+		// Clean up created PNS outputs and project-specific inputs (only if not in testing mode)
 		if !p.Testing {
 			for pns := range p.Outputs {
 				DeletePNSOutput(pns)
 			}
+			for pns := range p.Inputs {
+				DeletePNSInput(pns)
+			}
 		}
 
 		// Clear project maps
@@ -1541,7 +1818,7 @@ func (p *Project) initComponents() error {
 				p.Rulesets[node.ToPNS] = rs
 
 				nodeChannelStatus[node.ToPNS] = true
-				c := make(chan map[string]interface{}, 512)
+				c := make(chan map[string]interface{}, p.channelCapacity(node, 512))
 				p.MsgChannels[node.ToPNS] = &c
 				rs.UpStream[node.ToPNS] = &c
 			}
@@ -1557,7 +1834,7 @@ func (p *Project) initComponents() error {
 				}
 
 				// Create a new output instance for testing based on the original config
-				testOutput, err := output.NewFromExisting(originalOutput, node.ToPNS)
+				testOutput, err := newOutputInstance(originalOutput, p.componentVars("OUTPUT", node.ToID), node.ToPNS)
 				if err != nil {
 					// Set the original output to error state
 					if originalOutput != nil {
@@ -1573,7 +1850,7 @@ func (p *Project) initComponents() error {
 				p.Outputs[node.ToPNS] = testOutput
 
 				nodeChannelStatus[node.ToPNS] = true
-				c := make(chan map[string]interface{}, 1024)
+				c := make(chan map[string]interface{}, p.channelCapacity(node, 1024))
 				p.MsgChannels[node.ToPNS] = &c
 				testOutput.UpStream[node.ToPNS] = &c
 			} else {
@@ -1592,7 +1869,7 @@ func (p *Project) initComponents() error {
 						return fmt.Errorf("output component not found: %s", node.ToID)
 					}
 
-					o, err := output.NewFromExisting(originalOutput, node.ToPNS)
+					o, err := newOutputInstance(originalOutput, p.componentVars("OUTPUT", node.ToID), node.ToPNS)
 					if err != nil {
 						// Set the original output to error state
 						if originalOutput != nil {
@@ -1608,7 +1885,7 @@ func (p *Project) initComponents() error {
 					p.Outputs[node.ToPNS] = o
 
 					nodeChannelStatus[node.ToPNS] = true
-					c := make(chan map[string]interface{}, 1024)
+					c := make(chan map[string]interface{}, p.channelCapacity(node, 1024))
 					p.MsgChannels[node.ToPNS] = &c
 					o.UpStream[node.ToPNS] = &c
 				}
@@ -1663,7 +1940,7 @@ func (p *Project) initComponents() error {
 				}
 
 				// Create a new input instance for testing based on the original config
-				testInput, err := input.NewFromExisting(originalInput, node.FromPNS)
+				testInput, err := newInputInstance(originalInput, p.componentVars("INPUT", node.FromID), node.FromPNS)
 				if err != nil {
 					// Set the original input to error state
 					if originalInput != nil {
@@ -1677,6 +1954,25 @@ func (p *Project) initComponents() error {
 				testInput.SetTestMode() // Disable sampling and global state interactions
 
 				p.Inputs[node.FromPNS] = testInput
+			} else if overrides := p.componentVars("INPUT", node.FromID); len(overrides) > 0 {
+				// Project vars: run a project-specific input instead of the shared one
+				pnsInput, exists := GetPNSInput(node.FromPNS)
+				if !exists {
+					originalInput, ok := GetInput(node.FromID)
+					if !ok {
+						cleanup()
+						return fmt.Errorf("input component not found: %s", node.FromID)
+					}
+
+					var err error
+					pnsInput, err = newInputInstance(originalInput, overrides, node.FromPNS)
+					if err != nil {
+						cleanup()
+						return fmt.Errorf("failed to create input with project vars: %s %w", node.FromPNS, err)
+					}
+					SetPNSInput(node.FromPNS, pnsInput)
+				}
+				p.Inputs[node.FromPNS] = pnsInput
 			} else {
 				// Production mode: create input instance with correct ProjectNodeSequence
 				originalInput, exists := GetInput(node.FromID)
@@ -1697,7 +1993,12 @@ func (p *Project) initComponents() error {
 		// Establish connections from FROM components to TO components
 		switch node.FromType {
 		case "RULESET":
-			if fromRs, exists := p.Rulesets[node.FromPNS]; exists {
+			if fromRs, exists := p.Rulesets[node.FromPNS]; exists && node.NoMatch {
+				// The emit_no_match edge only receives the events no rule matched
+				if toChannel, channelExists := p.MsgChannels[node.ToPNS]; channelExists {
+					fromRs.SetNoMatchDownStream(toChannel)
+				}
+			} else if exists {
 				// Always try to establish connection regardless of channel creation status
 				// This ensures shared PNS components get properly connected
 				if toChannel, channelExists := p.MsgChannels[node.ToPNS]; channelExists {
@@ -1773,6 +2074,69 @@ func (p *Project) initComponents() error {
 	return nil
 }
 
+// setupKafkaTxn binds the project's components to one Kafka transaction when its outputs are
+// transactional. Offsets and produced records can only share a transaction on one cluster, so such a
+// project needs exactly one Kafka input and only transactional Kafka outputs on the input's brokers.
+func (p *Project) setupKafkaTxn() error {
+	transactional := 0
+	for _, out := range p.Outputs {
+		if out.IsKafkaTransactional() {
+			transactional++
+		}
+	}
+
+	var txn *common.KafkaTxn
+	if transactional > 0 && !p.Testing {
+		if transactional != len(p.Outputs) {
+			return fmt.Errorf("project %s mixes transactional kafka outputs with other outputs", p.Id)
+		}
+		if len(p.Inputs) != 1 {
+			return fmt.Errorf("project %s has transactional kafka outputs and needs exactly one input, got %d", p.Id, len(p.Inputs))
+		}
+		var in *input.Input
+		for _, i := range p.Inputs {
+			in = i
+		}
+		if !in.IsKafka() {
+			return fmt.Errorf("project %s has transactional kafka outputs but input %s is not a kafka input", p.Id, in.Id)
+		}
+		for _, out := range p.Outputs {
+			if !sameBrokers(in.KafkaBrokers(), out.KafkaBrokers()) {
+				return fmt.Errorf("transactional kafka output %s must use the same brokers as input %s", out.Id, in.Id)
+			}
+		}
+		// One transactional id per project and node so cluster nodes do not fence each other
+		txn = common.NewKafkaTxn(fmt.Sprintf("agentsmith-hub.%s.%s", p.Id, common.GetNodeID()))
+	}
+
+	for _, in := range p.Inputs {
+		in.SetKafkaTxn(txn)
+	}
+	for _, rs := range p.Rulesets {
+		rs.SetKafkaTxn(txn)
+	}
+	for _, out := range p.Outputs {
+		out.SetKafkaTxn(txn)
+	}
+	return nil
+}
+
+func sameBrokers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, broker := range a {
+		seen[broker] = true
+	}
+	for _, broker := range b {
+		if !seen[broker] {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *Project) runComponents() error {
 	// Start components in reverse dependency order: outputs -> rulesets -> inputs
 	// This ensures downstream components are ready before upstream starts producing data
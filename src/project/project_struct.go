@@ -21,21 +21,83 @@ type FlowNode struct {
 	ToID     string
 	FromInit bool
 	ToInit   bool
+	// NoMatch marks the edge emit_no_match adds from a detection ruleset to the audit output, it only
+	// carries the events no rule matched
+	NoMatch bool
+}
+
+// GuardedMap is a typed component map guarded by common.GlobalMu.
+// Its methods take the lock themselves; code that already holds common.GlobalMu
+// (e.g. the Safe* deletion functions) keeps indexing the map directly.
+type GuardedMap[V any] map[string]V
+
+// Get returns the value stored under id
+func (m *GuardedMap[V]) Get(id string) (V, bool) {
+	common.GlobalMu.RLock()
+	defer common.GlobalMu.RUnlock()
+	v, ok := (*m)[id]
+	return v, ok
+}
+
+// Set stores v under id, allocating the map if needed
+func (m *GuardedMap[V]) Set(id string, v V) {
+	common.GlobalMu.Lock()
+	defer common.GlobalMu.Unlock()
+	if *m == nil {
+		*m = make(GuardedMap[V])
+	}
+	(*m)[id] = v
+}
+
+// Delete removes id from the map
+func (m *GuardedMap[V]) Delete(id string) {
+	common.GlobalMu.Lock()
+	defer common.GlobalMu.Unlock()
+	delete(*m, id)
+}
+
+// Len returns the number of entries
+func (m *GuardedMap[V]) Len() int {
+	common.GlobalMu.RLock()
+	defer common.GlobalMu.RUnlock()
+	return len(*m)
+}
+
+// Snapshot returns a copy of the map that is safe to use without holding the lock
+func (m *GuardedMap[V]) Snapshot() map[string]V {
+	common.GlobalMu.RLock()
+	defer common.GlobalMu.RUnlock()
+	res := make(map[string]V, len(*m))
+	for id, v := range *m {
+		res[id] = v
+	}
+	return res
+}
+
+// Range calls fn for every entry of a snapshot until fn returns false.
+// The lock is not held while fn runs, so fn may call other accessors.
+func (m *GuardedMap[V]) Range(fn func(id string, v V) bool) {
+	for id, v := range m.Snapshot() {
+		if !fn(id, v) {
+			break
+		}
+	}
 }
 
 type GlobalProjectInfo struct {
-	Projects map[string]*Project
-	Inputs   map[string]*input.Input
-	Outputs  map[string]*output.Output
-	Rulesets map[string]*rules_engine.Ruleset
+	Projects GuardedMap[*Project]
+	Inputs   GuardedMap[*input.Input]
+	Outputs  GuardedMap[*output.Output]
+	Rulesets GuardedMap[*rules_engine.Ruleset]
 
-	PNSOutputs  map[string]*output.Output
-	PNSRulesets map[string]*rules_engine.Ruleset
+	PNSInputs   GuardedMap[*input.Input] // Project-specific inputs created from project vars
+	PNSOutputs  GuardedMap[*output.Output]
+	PNSRulesets GuardedMap[*rules_engine.Ruleset]
 
-	ProjectsNew map[string]string
-	InputsNew   map[string]string
-	OutputsNew  map[string]string
-	RulesetsNew map[string]string
+	ProjectsNew GuardedMap[string]
+	InputsNew   GuardedMap[string]
+	OutputsNew  GuardedMap[string]
+	RulesetsNew GuardedMap[string]
 }
 
 // CalculateRefCount dynamically calculates how many running projects are using the given PNS
@@ -77,6 +139,35 @@ func CalculateRefCountUnsafe(pns string, excludeProjectID ...string) int {
 	return count
 }
 
+// CalculateInputRefCount counts running projects that use the shared instance of an input.
+// Projects that override the input with vars run their own instance and are not counted.
+func CalculateInputRefCount(inputID string, excludeProjectID ...string) int {
+	common.GlobalMu.RLock()
+	defer common.GlobalMu.RUnlock()
+
+	var excludeID string
+	if len(excludeProjectID) > 0 {
+		excludeID = excludeProjectID[0]
+	}
+
+	count := 0
+	for projectID, proj := range GlobalProject.Projects {
+		if projectID == excludeID || proj.Status != common.StatusRunning {
+			continue
+		}
+		if len(proj.componentVars("INPUT", inputID)) > 0 {
+			continue
+		}
+		for _, node := range proj.FlowNodes {
+			if node.FromType == "INPUT" && node.FromID == inputID {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
 // GetRefCount is kept for backward compatibility, now uses dynamic calculation
 func GetRefCount(id string) int {
 	return CalculateRefCount(id)
@@ -84,10 +175,29 @@ func GetRefCount(id string) int {
 
 // ProjectConfig holds the configuration for a project
 type ProjectConfig struct {
-	Id        string
-	Content   string `yaml:"content"`
-	RawConfig string
-	Path      string
+	Id      string
+	Content string `yaml:"content"`
+	// Vars overrides config keys of referenced components for this project only,
+	// keyed by node (e.g. "INPUT.kafka_in") and then by dotted config key (e.g. "kafka.topic")
+	Vars map[string]map[string]interface{} `yaml:"vars,omitempty"`
+	// Limits is the soft budget of the project in the rule engine, events above it are shed
+	Limits *ProjectLimits `yaml:"limits,omitempty"`
+	// EmitNoMatch routes the events no rule of a detection ruleset matched to an output, for auditing
+	EmitNoMatch *EmitNoMatchConfig `yaml:"emit_no_match,omitempty"`
+	RawConfig   string
+	Path        string
+}
+
+// ProjectLimits bounds what one project may hold in the rule engine so it cannot starve the others
+type ProjectLimits struct {
+	MaxTasks    int `yaml:"max_tasks,omitempty"`     // Engine tasks in flight across the project's rulesets
+	MaxMemoryMB int `yaml:"max_memory_mb,omitempty"` // Estimated size of the events in flight
+}
+
+// EmitNoMatchConfig names the output receiving the events that matched no detection rule, flagged
+// with rules_engine.NoMatchFieldName. Opt-in since it can carry most of the input volume.
+type EmitNoMatchConfig struct {
+	Output string `yaml:"output"`
 }
 
 // Project represents a project
@@ -113,6 +223,9 @@ type Project struct {
 	// Data flow
 	MsgChannels map[string]*chan map[string]interface{} `json:"-"` // Channels for message passing between components
 
+	// Soft limits of the running instance, nil when the project has none
+	budget *rules_engine.ProjectBudget
+
 	// Restart cooldown
 	lastRestartTime time.Time
 	restartMu       sync.Mutex
@@ -167,8 +280,10 @@ func (p *Project) GetProjectInputs() map[string]*input.Input {
 					inp = testInp
 				}
 			} else {
-				// Production mode: get original input component
-				if originalInp, exists := GetInput(node.FromID); exists {
+				// Production mode: project-specific instance first, then original input component
+				if pnsInp, exists := GetPNSInput(node.FromPNS); exists {
+					inp = pnsInp
+				} else if originalInp, exists := GetInput(node.FromID); exists {
 					inp = originalInp
 				}
 			}
@@ -266,8 +381,10 @@ func (p *Project) GetProjectInputsUnsafe() map[string]*input.Input {
 					inp = testInp
 				}
 			} else {
-				// Production mode: get original input component
-				if originalInp, exists := GlobalProject.Inputs[node.FromID]; exists {
+				// Production mode: project-specific instance first, then original input component
+				if pnsInp, exists := GlobalProject.PNSInputs[node.FromPNS]; exists {
+					inp = pnsInp
+				} else if originalInp, exists := GlobalProject.Inputs[node.FromID]; exists {
 					inp = originalInp
 				}
 			}
@@ -311,276 +428,175 @@ func (p *Project) GetProjectOutputsUnsafe() map[string]*output.Output {
 
 // Project accessors
 func GetProject(id string) (*Project, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	proj, exists := GlobalProject.Projects[id]
-	return proj, exists
+	return GlobalProject.Projects.Get(id)
 }
 
 func SetProject(id string, project *Project) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	GlobalProject.Projects[id] = project
+	GlobalProject.Projects.Set(id, project)
 }
 
 func DeleteProject(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.Projects, id)
+	GlobalProject.Projects.Delete(id)
 }
 
 func GetAllProjects() map[string]*Project {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	// Return a copy to avoid external modification
-	projects := make(map[string]*Project)
-	for id, proj := range GlobalProject.Projects {
-		projects[id] = proj
-	}
-	return projects
+	return GlobalProject.Projects.Snapshot()
 }
 
 func GetProjectsCount() int {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	return len(GlobalProject.Projects)
+	return GlobalProject.Projects.Len()
 }
 
 // Input accessors
 func GetInput(id string) (*input.Input, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	inp, exists := GlobalProject.Inputs[id]
-	return inp, exists
+	return GlobalProject.Inputs.Get(id)
 }
 
 func SetInput(id string, inp *input.Input) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.Inputs == nil {
-		GlobalProject.Inputs = make(map[string]*input.Input)
-	}
-	GlobalProject.Inputs[id] = inp
+	GlobalProject.Inputs.Set(id, inp)
 }
 
 func DeleteInput(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.Inputs, id)
+	GlobalProject.Inputs.Delete(id)
 }
 
 func GetAllInputs() map[string]*input.Input {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	// Return a copy to avoid external modification
-	inputs := make(map[string]*input.Input)
-	for id, inp := range GlobalProject.Inputs {
-		inputs[id] = inp
-	}
-	return inputs
+	return GlobalProject.Inputs.Snapshot()
 }
 
 // Output accessors
 func GetOutput(id string) (*output.Output, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	out, exists := GlobalProject.Outputs[id]
-	return out, exists
+	return GlobalProject.Outputs.Get(id)
 }
 
 func SetOutput(id string, out *output.Output) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.Outputs == nil {
-		GlobalProject.Outputs = make(map[string]*output.Output)
-	}
-	GlobalProject.Outputs[id] = out
+	GlobalProject.Outputs.Set(id, out)
 }
 
 func DeleteOutput(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.Outputs, id)
+	GlobalProject.Outputs.Delete(id)
 }
 
 func GetAllOutputs() map[string]*output.Output {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	// Return a copy to avoid external modification
-	outputs := make(map[string]*output.Output)
-	for id, out := range GlobalProject.Outputs {
-		outputs[id] = out
-	}
-	return outputs
+	return GlobalProject.Outputs.Snapshot()
 }
 
 // Ruleset accessors
 func GetRuleset(id string) (*rules_engine.Ruleset, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	rs, exists := GlobalProject.Rulesets[id]
-	return rs, exists
+	return GlobalProject.Rulesets.Get(id)
 }
 
 func SetRuleset(id string, rs *rules_engine.Ruleset) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.Rulesets == nil {
-		GlobalProject.Rulesets = make(map[string]*rules_engine.Ruleset)
-	}
-	GlobalProject.Rulesets[id] = rs
+	GlobalProject.Rulesets.Set(id, rs)
 }
 
 func DeleteRuleset(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.Rulesets, id)
+	GlobalProject.Rulesets.Delete(id)
 }
 
 func GetAllRulesets() map[string]*rules_engine.Ruleset {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	// Return a copy to avoid external modification
-	rulesets := make(map[string]*rules_engine.Ruleset)
-	for id, rs := range GlobalProject.Rulesets {
-		rulesets[id] = rs
-	}
-	return rulesets
+	return GlobalProject.Rulesets.Snapshot()
+}
+
+// PNS Input accessors
+func GetPNSInput(pns string) (*input.Input, bool) {
+	return GlobalProject.PNSInputs.Get(pns)
+}
+
+func SetPNSInput(pns string, inp *input.Input) {
+	GlobalProject.PNSInputs.Set(pns, inp)
+}
+
+func DeletePNSInput(pns string) {
+	GlobalProject.PNSInputs.Delete(pns)
 }
 
 // PNS Output accessors
 func GetPNSOutput(pns string) (*output.Output, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	out, exists := GlobalProject.PNSOutputs[pns]
-	return out, exists
+	return GlobalProject.PNSOutputs.Get(pns)
 }
 
 func SetPNSOutput(pns string, out *output.Output) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.PNSOutputs == nil {
-		GlobalProject.PNSOutputs = make(map[string]*output.Output)
-	}
-	GlobalProject.PNSOutputs[pns] = out
+	GlobalProject.PNSOutputs.Set(pns, out)
 }
 
 func DeletePNSOutput(pns string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.PNSOutputs, pns)
+	GlobalProject.PNSOutputs.Delete(pns)
 }
 
 // PNS Ruleset accessors
 func GetPNSRuleset(pns string) (*rules_engine.Ruleset, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	rs, exists := GlobalProject.PNSRulesets[pns]
-	return rs, exists
+	return GlobalProject.PNSRulesets.Get(pns)
 }
 
 func SetPNSRuleset(pns string, rs *rules_engine.Ruleset) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.PNSRulesets == nil {
-		GlobalProject.PNSRulesets = make(map[string]*rules_engine.Ruleset)
-	}
-	GlobalProject.PNSRulesets[pns] = rs
+	GlobalProject.PNSRulesets.Set(pns, rs)
 }
 
 func DeletePNSRuleset(pns string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.PNSRulesets, pns)
+	GlobalProject.PNSRulesets.Delete(pns)
+}
+
+// RulesetDecodeFailures returns how many payloads the DECODE appends of the project instances of a
+// ruleset could not decode
+func RulesetDecodeFailures(id string) uint64 {
+	var total uint64
+	GlobalProject.PNSRulesets.Range(func(_ string, rs *rules_engine.Ruleset) bool {
+		if rs.RulesetID == id {
+			total += rs.GetDecodeFailures()
+		}
+		return true
+	})
+	return total
 }
 
 // New/Temporary content accessors
 func GetProjectNew(id string) (string, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	content, exists := GlobalProject.ProjectsNew[id]
-	return content, exists
+	return GlobalProject.ProjectsNew.Get(id)
 }
 
 func SetProjectNew(id string, content string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.ProjectsNew == nil {
-		GlobalProject.ProjectsNew = make(map[string]string)
-	}
-	GlobalProject.ProjectsNew[id] = content
+	GlobalProject.ProjectsNew.Set(id, content)
 }
 
 func DeleteProjectNew(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.ProjectsNew, id)
+	GlobalProject.ProjectsNew.Delete(id)
 }
 
 func GetInputNew(id string) (string, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	content, exists := GlobalProject.InputsNew[id]
-	return content, exists
+	return GlobalProject.InputsNew.Get(id)
 }
 
 func SetInputNew(id string, content string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.InputsNew == nil {
-		GlobalProject.InputsNew = make(map[string]string)
-	}
-	GlobalProject.InputsNew[id] = content
+	GlobalProject.InputsNew.Set(id, content)
 }
 
 func DeleteInputNew(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.InputsNew, id)
+	GlobalProject.InputsNew.Delete(id)
 }
 
 func GetOutputNew(id string) (string, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	content, exists := GlobalProject.OutputsNew[id]
-	return content, exists
+	return GlobalProject.OutputsNew.Get(id)
 }
 
 func SetOutputNew(id string, content string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.OutputsNew == nil {
-		GlobalProject.OutputsNew = make(map[string]string)
-	}
-	GlobalProject.OutputsNew[id] = content
+	GlobalProject.OutputsNew.Set(id, content)
 }
 
 func DeleteOutputNew(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.OutputsNew, id)
+	GlobalProject.OutputsNew.Delete(id)
 }
 
 func GetRulesetNew(id string) (string, bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	content, exists := GlobalProject.RulesetsNew[id]
-	return content, exists
+	return GlobalProject.RulesetsNew.Get(id)
 }
 
 func SetRulesetNew(id string, content string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	if GlobalProject.RulesetsNew == nil {
-		GlobalProject.RulesetsNew = make(map[string]string)
-	}
-	GlobalProject.RulesetsNew[id] = content
+	GlobalProject.RulesetsNew.Set(id, content)
 }
 
 func DeleteRulesetNew(id string) {
-	common.GlobalMu.Lock()
-	defer common.GlobalMu.Unlock()
-	delete(GlobalProject.RulesetsNew, id)
+	GlobalProject.RulesetsNew.Delete(id)
 }
 
 // Component validation helpers
@@ -603,15 +619,9 @@ func ValidateComponent(componentType, componentID string) (exists bool, tempExis
 }
 
 // Safe iteration functions
+// Iteration runs over a snapshot, so callbacks may use the other accessors without deadlocking
 func ForEachProject(fn func(id string, project *Project) bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	for id, proj := range GlobalProject.Projects {
-		if !fn(id, proj) {
-			break
-		}
-	}
+	GlobalProject.Projects.Range(fn)
 }
 
 // Unsafe iteration functions - use with caution, caller must ensure proper locking
@@ -624,36 +634,15 @@ func ForEachProjectUnsafa(fn func(id string, project *Project) bool) {
 }
 
 func ForEachInput(fn func(id string, inp *input.Input) bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	for id, inp := range GlobalProject.Inputs {
-		if !fn(id, inp) {
-			break
-		}
-	}
+	GlobalProject.Inputs.Range(fn)
 }
 
 func ForEachOutput(fn func(id string, out *output.Output) bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	for id, out := range GlobalProject.Outputs {
-		if !fn(id, out) {
-			break
-		}
-	}
+	GlobalProject.Outputs.Range(fn)
 }
 
 func ForEachRuleset(fn func(id string, rs *rules_engine.Ruleset) bool) {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	for id, rs := range GlobalProject.Rulesets {
-		if !fn(id, rs) {
-			break
-		}
-	}
+	GlobalProject.Rulesets.Range(fn)
 }
 
 // Helper function to safely access input downstream
@@ -666,6 +655,16 @@ func SafeDeleteInputDownstream(inputID, downstreamID string) {
 	}
 }
 
+// SafeDeletePNSInputDownstream removes a downstream connection from a project-specific input
+func SafeDeletePNSInputDownstream(pns, downstreamID string) {
+	common.GlobalMu.Lock()
+	defer common.GlobalMu.Unlock()
+
+	if i, exists := GlobalProject.PNSInputs[pns]; exists {
+		delete(i.DownStream, downstreamID)
+	}
+}
+
 // Helper function to safely access input downstream
 func SafeDeleteRulesetDownstream(rulesetID, downstreamID string) {
 	common.GlobalMu.Lock()
@@ -678,71 +677,37 @@ func SafeDeleteRulesetDownstream(rulesetID, downstreamID string) {
 
 // GetAllProjectsNew returns a copy of all projects new map
 func GetAllProjectsNew() map[string]string {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	result := make(map[string]string)
-	for id, content := range GlobalProject.ProjectsNew {
-		result[id] = content
-	}
-	return result
+	return GlobalProject.ProjectsNew.Snapshot()
 }
 
 // GetAllInputsNew returns a copy of all inputs new map
 func GetAllInputsNew() map[string]string {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	result := make(map[string]string)
-	for id, content := range GlobalProject.InputsNew {
-		result[id] = content
-	}
-	return result
+	return GlobalProject.InputsNew.Snapshot()
 }
 
 // GetAllOutputsNew returns a copy of all outputs new map
 func GetAllOutputsNew() map[string]string {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	result := make(map[string]string)
-	for id, content := range GlobalProject.OutputsNew {
-		result[id] = content
-	}
-	return result
+	return GlobalProject.OutputsNew.Snapshot()
 }
 
 // GetAllRulesetsNew returns a copy of all rulesets new map
 func GetAllRulesetsNew() map[string]string {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-
-	result := make(map[string]string)
-	for id, content := range GlobalProject.RulesetsNew {
-		result[id] = content
-	}
-	return result
+	return GlobalProject.RulesetsNew.Snapshot()
 }
 
 // GetInputsCount returns the count of inputs
 func GetInputsCount() int {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	return len(GlobalProject.Inputs)
+	return GlobalProject.Inputs.Len()
 }
 
 // GetOutputsCount returns the count of outputs
 func GetOutputsCount() int {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	return len(GlobalProject.Outputs)
+	return GlobalProject.Outputs.Len()
 }
 
 // GetRulesetsCount returns the count of rulesets
 func GetRulesetsCount() int {
-	common.GlobalMu.RLock()
-	defer common.GlobalMu.RUnlock()
-	return len(GlobalProject.Rulesets)
+	return GlobalProject.Rulesets.Len()
 }
 
 // ===== Safe deletion functions with internal locking =====
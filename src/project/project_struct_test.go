@@ -0,0 +1,85 @@
+package project
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"AgentSmith-HUB/input"
+)
+
+func TestGuardedMapBasic(t *testing.T) {
+	var m GuardedMap[int]
+
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("expected empty map")
+	}
+	m.Set("a", 1)
+	m.Set("b", 2)
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v", v, ok)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", m.Len())
+	}
+
+	snap := m.Snapshot()
+	m.Delete("a")
+	if _, ok := snap["a"]; !ok {
+		t.Fatal("snapshot must not be affected by later deletes")
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("expected a to be deleted")
+	}
+}
+
+func TestForEachCallbackMayUseAccessors(t *testing.T) {
+	SetInput("foreach_test", &input.Input{Id: "foreach_test"})
+	defer DeleteInput("foreach_test")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ForEachInput(func(id string, _ *input.Input) bool {
+			// Lookups and writes from inside the callback must not deadlock
+			GetInput(id)
+			SetInputNew(id, "content")
+			DeleteInputNew(id)
+			return true
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ForEachInput deadlocked when the callback used other accessors")
+	}
+}
+
+func TestAccessorsConcurrentIterateAndMutate(t *testing.T) {
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				id := fmt.Sprintf("race_%d_%d", w, i)
+				SetInput(id, &input.Input{Id: id})
+				SetRulesetNew(id, "content")
+				DeleteInput(id)
+				DeleteRulesetNew(id)
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				ForEachInput(func(string, *input.Input) bool { return true })
+				_ = GetAllInputs()
+				_ = GetAllRulesetsNew()
+				_ = GetInputsCount()
+			}
+		}()
+	}
+	wg.Wait()
+}
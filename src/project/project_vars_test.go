@@ -0,0 +1,178 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"AgentSmith-HUB/input"
+	"AgentSmith-HUB/output"
+	"AgentSmith-HUB/rules_engine"
+)
+
+const varsTestInput = `type: kafka
+kafka:
+  brokers:
+    - 127.0.0.1:9092
+  group: hub
+  topic: default_topic
+`
+
+const varsTestOutput = `type: print
+`
+
+func newVarsTestProject(id, topic string) *Project {
+	return &Project{
+		Id: id,
+		Config: &ProjectConfig{
+			Id:      id,
+			Content: "INPUT.vars_in -> OUTPUT.vars_out",
+			Vars: map[string]map[string]interface{}{
+				"INPUT.vars_in": {"kafka.topic": topic},
+			},
+		},
+		Inputs:      make(map[string]*input.Input),
+		Outputs:     make(map[string]*output.Output),
+		Rulesets:    make(map[string]*rules_engine.Ruleset),
+		MsgChannels: make(map[string]*chan map[string]interface{}),
+	}
+}
+
+func TestProjectVarsOverrideSharedInput(t *testing.T) {
+	in, err := input.NewInput("", varsTestInput, "vars_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	out, err := output.NewOutput("", varsTestOutput, "vars_out")
+	if err != nil {
+		t.Fatalf("NewOutput: %v", err)
+	}
+	SetInput("vars_in", in)
+	SetOutput("vars_out", out)
+	defer DeleteInput("vars_in")
+	defer DeleteOutput("vars_out")
+
+	topics := map[string]string{"tenant_a": "tenant_a_events", "tenant_b": "tenant_b_events"}
+	for id, topic := range topics {
+		p := newVarsTestProject(id, topic)
+		if err := p.parseContent(); err != nil {
+			t.Fatalf("parseContent(%s): %v", id, err)
+		}
+		if err := p.initComponents(); err != nil {
+			t.Fatalf("initComponents(%s): %v", id, err)
+		}
+
+		pns := "PROJECT." + id + ".INPUT.vars_in"
+		projectInput, ok := p.Inputs[pns]
+		if !ok {
+			t.Fatalf("project %s has no input at %s: %v", id, pns, p.Inputs)
+		}
+		if projectInput == in {
+			t.Fatalf("project %s must not reuse the shared input instance", id)
+		}
+		if projectInput.Config.Kafka.Topic != topic {
+			t.Fatalf("project %s topic = %s, want %s", id, projectInput.Config.Kafka.Topic, topic)
+		}
+		if pnsInput, ok := GetPNSInput(pns); !ok || pnsInput != projectInput {
+			t.Fatalf("project %s input not registered as PNS input", id)
+		}
+
+		DeletePNSInput(pns)
+		for outPNS := range p.Outputs {
+			DeletePNSOutput(outPNS)
+		}
+	}
+
+	if in.Config.Kafka.Topic != "default_topic" {
+		t.Fatalf("shared input topic changed to %s", in.Config.Kafka.Topic)
+	}
+}
+
+func TestProjectVarsValidation(t *testing.T) {
+	in, err := input.NewInput("", varsTestInput, "vars_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	out, err := output.NewOutput("", varsTestOutput, "vars_out")
+	if err != nil {
+		t.Fatalf("NewOutput: %v", err)
+	}
+	SetInput("vars_in", in)
+	SetOutput("vars_out", out)
+	defer DeleteInput("vars_in")
+	defer DeleteOutput("vars_out")
+
+	cases := map[string]struct {
+		vars map[string]map[string]interface{}
+		want string
+	}{
+		"unknown key":      {map[string]map[string]interface{}{"INPUT.vars_in": {"kafka.topicx": "t"}}, "does not exist"},
+		"unused component": {map[string]map[string]interface{}{"INPUT.other": {"kafka.topic": "t"}}, "not used in project content"},
+		"ruleset target":   {map[string]map[string]interface{}{"RULESET.rs": {"x": "t"}}, "only INPUT and OUTPUT"},
+		"invalid result":   {map[string]map[string]interface{}{"INPUT.vars_in": {"kafka.topic": ""}}, "kafka.topic"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := newVarsTestProject("vars_validate", "t")
+			p.Config.Vars = tc.vars
+			err := p.parseContent()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("parseContent error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestProjectLimitsDoNotShareRulesets(t *testing.T) {
+	in, err := input.NewInput("", varsTestInput, "limits_in")
+	if err != nil {
+		t.Fatalf("NewInput: %v", err)
+	}
+	rs, err := rules_engine.NewRuleset("", `<root type="DETECTION" name="limits"><rule id="r1" name="r1"><check type="NOTNULL" field="a"/></rule></root>`, "limits_rs")
+	if err != nil {
+		t.Fatalf("NewRuleset: %v", err)
+	}
+	SetInput("limits_in", in)
+	SetRuleset("limits_rs", rs)
+	defer DeleteInput("limits_in")
+	defer DeleteRuleset("limits_rs")
+
+	newProject := func(id string, limits *ProjectLimits) *Project {
+		p := &Project{
+			Id:          id,
+			Config:      &ProjectConfig{Id: id, Content: "INPUT.limits_in -> RULESET.limits_rs", Limits: limits},
+			Inputs:      make(map[string]*input.Input),
+			Outputs:     make(map[string]*output.Output),
+			Rulesets:    make(map[string]*rules_engine.Ruleset),
+			MsgChannels: make(map[string]*chan map[string]interface{}),
+		}
+		if err := p.parseContent(); err != nil {
+			t.Fatalf("parseContent(%s): %v", id, err)
+		}
+		if err := p.initComponents(); err != nil {
+			t.Fatalf("initComponents(%s): %v", id, err)
+		}
+		p.setupBudget()
+		t.Cleanup(func() {
+			for pns := range p.Rulesets {
+				DeletePNSRuleset(pns)
+			}
+		})
+		return p
+	}
+
+	shared := newProject("limits_shared", nil)
+	limited := newProject("limits_limited", &ProjectLimits{MaxTasks: 1})
+	for sharedPNS, sharedRs := range shared.Rulesets {
+		for limitedPNS, limitedRs := range limited.Rulesets {
+			if sharedRs == limitedRs || sharedPNS == limitedPNS {
+				t.Fatalf("the project with limits reuses ruleset instance %s of another project", sharedPNS)
+			}
+		}
+	}
+	if _, ok := shared.BudgetStats(); ok {
+		t.Fatal("the project without limits must not report a budget")
+	}
+	if stats, ok := limited.BudgetStats(); !ok || stats.MaxTasks != 1 {
+		t.Fatalf("unexpected budget of the limited project: %+v %v", stats, ok)
+	}
+}
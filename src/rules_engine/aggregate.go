@@ -0,0 +1,152 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AggregateFieldName holds the summary attached to an aggregated alert
+const AggregateFieldName = "_hub_aggregate"
+
+const (
+	defaultAggregateSamples = 5
+	maxAggregateSamples     = 100
+)
+
+// Aggregate collapses the matches of a rule that share a group key within a window into one alert.
+// Unlike a threshold it does not decide whether the rule fires, it summarizes the firings.
+type Aggregate struct {
+	GroupBy       string     `xml:"group_by,attr"` // Comma separated fields, empty groups by rule only
+	GroupByFields []string   // Field names in declaration order
+	GroupByList   [][]string // Parsed field paths, indexed like GroupByFields
+	Window        string     `xml:"window,attr"`
+	WindowInt     int        // Parsed window in seconds
+	Samples       int        `xml:"samples,attr"` // Occurrences kept in the aggregated alert
+}
+
+// alertGroup is one open aggregation window
+type alertGroup struct {
+	first     map[string]interface{}
+	samples   []interface{}
+	count     int
+	firstSeen time.Time
+	lastSeen  time.Time
+	deadline  time.Time
+}
+
+// alertAggregator holds the open windows of all aggregating rules of a ruleset
+type alertAggregator struct {
+	mu     sync.Mutex
+	groups map[string]*alertGroup
+	order  []string // group keys by window start, so flushes keep the order alerts started in
+}
+
+func newAlertAggregator() *alertAggregator {
+	return &alertAggregator{groups: make(map[string]*alertGroup)}
+}
+
+// hasAggregateRules reports whether any rule of the ruleset aggregates its alerts
+func (r *Ruleset) hasAggregateRules() bool {
+	for i := range r.Rules {
+		if r.Rules[i].Aggregate != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// add records a match of rule, opening a window for its group key when none is open
+func (a *alertAggregator) add(rule *Rule, data map[string]interface{}, ruleCache map[string]common.CheckCoreCache, now time.Time) {
+	agg := rule.Aggregate
+
+	sb := stringBuilderPool.Get().(*strings.Builder)
+	sb.Reset()
+	sb.WriteString(rule.ID)
+	for i, field := range agg.GroupByFields {
+		v, _ := GetCheckDataFromCache(ruleCache, field, data, agg.GroupByList[i])
+		sb.WriteByte(0)
+		sb.WriteString(v)
+	}
+	key := sb.String()
+	stringBuilderPool.Put(sb)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	g, ok := a.groups[key]
+	if !ok {
+		g = &alertGroup{
+			first:     common.MapDeepCopy(data),
+			firstSeen: now,
+			deadline:  now.Add(time.Duration(agg.WindowInt) * time.Second),
+		}
+		a.groups[key] = g
+		a.order = append(a.order, key)
+	}
+	g.count++
+	g.lastSeen = now
+	if len(g.samples) < agg.Samples {
+		g.samples = append(g.samples, common.MapDeepCopy(data))
+	}
+}
+
+// flush returns the alerts of windows that ended before now, or of all windows when force is set
+func (a *alertAggregator) flush(now time.Time, force bool) []map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var alerts []map[string]interface{}
+	remaining := a.order[:0]
+	for _, key := range a.order {
+		g := a.groups[key]
+		if !force && now.Before(g.deadline) {
+			remaining = append(remaining, key)
+			continue
+		}
+		delete(a.groups, key)
+
+		alert := make(map[string]interface{}, len(g.first)+1)
+		for k, v := range g.first {
+			alert[k] = v
+		}
+		alert[AggregateFieldName] = map[string]interface{}{
+			"count":      g.count,
+			"samples":    g.samples,
+			"first_seen": g.firstSeen.Unix(),
+			"last_seen":  g.lastSeen.Unix(),
+		}
+		alerts = append(alerts, alert)
+	}
+	a.order = remaining
+	return alerts
+}
+
+// sendAggregates flushes finished windows to the downstream channels. Alerts that cannot be
+// delivered within timeout are dropped and logged, so a stopped output cannot block the ruleset.
+func (r *Ruleset) sendAggregates(now time.Time, force bool, timeout time.Duration) {
+	aggregator := r.aggregator
+	if aggregator == nil {
+		return
+	}
+	alerts := aggregator.flush(now, force)
+	if len(alerts) == 0 {
+		return
+	}
+	if r.kafkaTxn != nil {
+		r.kafkaTxn.Track(len(alerts) * len(r.DownStream))
+	}
+	for _, alert := range alerts {
+		for id, downCh := range r.DownStream {
+			select {
+			case *downCh <- alert:
+			case <-time.After(timeout):
+				logger.Warn("Dropping aggregated alert, downstream not accepting", "ruleset", r.RulesetID, "downstream", id)
+				if r.kafkaTxn != nil {
+					r.kafkaTxn.Done()
+				}
+			}
+		}
+	}
+}
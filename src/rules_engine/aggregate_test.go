@@ -0,0 +1,64 @@
+package rules_engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregate_CollapsesMatchesInWindow(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION" name="agg">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="action">login_failed</check>
+    <aggregate group_by="src_ip" window="1m" samples="3"/>
+  </rule>
+</root>`)
+	rs.aggregator = newAlertAggregator()
+
+	for i := 0; i < 50; i++ {
+		data := map[string]interface{}{"action": "login_failed", "src_ip": "10.0.0.1", "seq": i}
+		if res := rs.EngineCheck(data); len(res) != 0 {
+			t.Fatalf("event %d: expected aggregated match to be held back, got %v", i, res)
+		}
+	}
+	rs.EngineCheck(map[string]interface{}{"action": "login_failed", "src_ip": "10.0.0.2"})
+	rs.EngineCheck(map[string]interface{}{"action": "login_ok", "src_ip": "10.0.0.1"})
+
+	if alerts := rs.aggregator.flush(time.Now(), false); len(alerts) != 0 {
+		t.Fatalf("expected no alerts before the window ends, got %d", len(alerts))
+	}
+
+	alerts := rs.aggregator.flush(time.Now().Add(2*time.Minute), false)
+	if len(alerts) != 2 {
+		t.Fatalf("expected one alert per group, got %d", len(alerts))
+	}
+	summary := alerts[0][AggregateFieldName].(map[string]interface{})
+	if alerts[0]["src_ip"] != "10.0.0.1" || summary["count"] != 50 {
+		t.Fatalf("expected 50 events from 10.0.0.1, got src_ip=%v count=%v", alerts[0]["src_ip"], summary["count"])
+	}
+	if samples := summary["samples"].([]interface{}); len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(samples))
+	}
+	if alerts[0][HitRuleIdFieldName] != "TEST.RS.r1" {
+		t.Fatalf("expected hit rule id on the aggregated alert, got %v", alerts[0][HitRuleIdFieldName])
+	}
+	if summary := alerts[1][AggregateFieldName].(map[string]interface{}); summary["count"] != 1 {
+		t.Fatalf("expected a single event from 10.0.0.2, got %v", summary["count"])
+	}
+
+	if alerts := rs.aggregator.flush(time.Now().Add(time.Hour), true); len(alerts) != 0 {
+		t.Fatalf("expected flushed windows to be closed, got %d", len(alerts))
+	}
+}
+
+func TestAggregate_InvalidConfig(t *testing.T) {
+	for name, xml := range map[string]string{
+		"missing window": `<root type="DETECTION"><rule id="r1"><check type="EQU" field="a">b</check><aggregate group_by="a"/></rule></root>`,
+		"bad samples":    `<root type="DETECTION"><rule id="r1"><check type="EQU" field="a">b</check><aggregate window="1m" samples="1000"/></rule></root>`,
+		"exclude":        `<root type="EXCLUDE"><rule id="r1"><check type="EQU" field="a">b</check><aggregate window="1m"/></rule></root>`,
+	} {
+		if _, err := ParseRuleset([]byte(xml)); err == nil {
+			t.Errorf("%s: expected a parse error", name)
+		}
+	}
+}
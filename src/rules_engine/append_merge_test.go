@@ -0,0 +1,85 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendMerge_NestedEnrichment(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="append-merge">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="action">login</check>
+    <append type="MERGE" field="enrich.asset">{"owner": "secops", "tags": ["prod"], "geo": {"country": "DE"}}</append>
+  </rule>
+</root>`
+	rs := buildRulesetFromXML(t, xml)
+
+	for i := 0; i < 2; i++ {
+		event := map[string]interface{}{
+			"action": "login",
+			"enrich": map[string]interface{}{
+				"asset": map[string]interface{}{"id": "a1", "geo": map[string]interface{}{"city": "Berlin"}},
+			},
+		}
+		results := rs.EngineCheck(event)
+		if len(results) != 1 {
+			t.Fatalf("expected a hit, got %d results", len(results))
+		}
+
+		asset := results[0]["enrich"].(map[string]interface{})["asset"].(map[string]interface{})
+		geo := asset["geo"].(map[string]interface{})
+		if asset["id"] != "a1" || asset["owner"] != "secops" || geo["city"] != "Berlin" || geo["country"] != "DE" {
+			t.Fatalf("unexpected merged object %v", asset)
+		}
+		tags := asset["tags"].([]interface{})
+		if len(tags) != 1 || tags[0] != "prod" {
+			t.Fatalf("unexpected tags %v", tags)
+		}
+		// The static object must not be shared between events
+		asset["tags"] = append(tags, "changed")
+	}
+}
+
+func TestAppendMerge_CreatesMissingPath(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="append-merge">
+  <rule id="r1" name="r1">
+    <check type="NOTNULL" field="action" />
+    <append type="MERGE" field="_$ORIDATA">{"meta": {"source": "hub"}}</append>
+    <append type="MERGE" field="a.b">{"c": 1}</append>
+  </rule>
+</root>`
+	rs := buildRulesetFromXML(t, xml)
+
+	results := rs.EngineCheck(map[string]interface{}{"action": "x", "a": "not an object"})
+	if len(results) != 1 {
+		t.Fatalf("expected a hit, got %d results", len(results))
+	}
+	if results[0]["meta"].(map[string]interface{})["source"] != "hub" {
+		t.Fatalf("expected meta merged into the event, got %v", results[0])
+	}
+	if results[0]["a"].(map[string]interface{})["b"].(map[string]interface{})["c"] != float64(1) {
+		t.Fatalf("expected a.b.c, got %v", results[0]["a"])
+	}
+}
+
+func TestAppendMerge_InvalidJSON(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="append-merge">
+  <rule id="r1" name="r1">
+    <check type="NOTNULL" field="action" />
+    <append type="MERGE" field="enrich">{"owner": </append>
+  </rule>
+</root>`
+	if _, err := ParseRuleset([]byte(xml)); err == nil || !strings.Contains(err.Error(), "JSON") {
+		t.Fatalf("expected a JSON error, got %v", err)
+	}
+	result, err := ValidateWithDetails("", xml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsValid {
+		t.Fatal("expected the ruleset to be invalid")
+	}
+}
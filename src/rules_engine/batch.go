@@ -0,0 +1,104 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"sync/atomic"
+)
+
+// MaxBatchSize caps the root attribute batch_size
+const MaxBatchSize = 1000
+
+// EngineCheckBatch runs the rules on each event of a batch, sharing the rule cache and the result
+// sizing between them. Results are independent per event: results[i] is what EngineCheck(events[i])
+// returns.
+func (r *Ruleset) EngineCheckBatch(events []map[string]interface{}) [][]map[string]interface{} {
+	results := make([][]map[string]interface{}, len(events))
+	ruleCache := ruleCachePool.Get().(map[string]common.CheckCoreCache)
+	initialCap := r.resultCap()
+	for i, data := range events {
+		results[i] = r.evalRules(data, nil, ruleCache, initialCap)
+	}
+	ruleCachePool.Put(ruleCache)
+	return results
+}
+
+// checkAndSampleBatch is checkAndSample for a batch. Sampled rulesets check event by event since the
+// sampler may trace any of them.
+func (r *Ruleset) checkAndSampleBatch(events []map[string]interface{}) [][]map[string]interface{} {
+	if !r.isTestMode && r.sampler != nil && !r.Quiet {
+		results := make([][]map[string]interface{}, len(events))
+		for i, data := range events {
+			results[i] = r.checkAndSample(data)
+		}
+		return results
+	}
+	if !r.isTestMode {
+		atomic.AddUint64(&r.processTotal, uint64(len(events)))
+	}
+	return r.EngineCheckBatch(events)
+}
+
+// fillBatch takes the events already queued on ch behind data, without waiting, until the batch holds
+// BatchSize events. Events over the project budget are shed like single events.
+func (r *Ruleset) fillBatch(ch chan map[string]interface{}, data map[string]interface{}, size int64) ([]map[string]interface{}, []int64) {
+	events := make([]map[string]interface{}, 1, r.BatchSize)
+	sizes := make([]int64, 1, r.BatchSize)
+	events[0], sizes[0] = data, size
+	for len(events) < r.BatchSize {
+		var next map[string]interface{}
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				// The next receive of the upstream loop sees the close
+				return events, sizes
+			}
+			next = msg
+		default:
+			return events, sizes
+		}
+		nextSize, ok := r.budget.tryAcquire(next)
+		if !ok {
+			if r.kafkaTxn != nil {
+				r.kafkaTxn.Done()
+			}
+			continue
+		}
+		events = append(events, next)
+		sizes = append(sizes, nextSize)
+	}
+	return events, sizes
+}
+
+// deliverBatch sends the results of each event of a checked batch downstream, releasing their budget
+func (r *Ruleset) deliverBatch(events []map[string]interface{}, sizes []int64, results [][]map[string]interface{}) {
+	for i, data := range events {
+		r.deliver(data, results[i])
+		r.budget.release(sizes[i])
+	}
+}
+
+// deliver sends the results of one event downstream, and the event itself to the no-match
+// downstream when nothing matched
+func (r *Ruleset) deliver(data map[string]interface{}, results []map[string]interface{}) {
+	noMatch := r.noMatchEvent(data, results)
+	// In a transactional project the results are tracked before the event is released
+	if r.kafkaTxn != nil {
+		tracked := len(results) * len(r.DownStream)
+		if noMatch != nil {
+			tracked++
+		}
+		r.kafkaTxn.Track(tracked)
+	}
+	// Send results to downstream channels - blocking to ensure no data loss
+	for _, res := range results {
+		for _, downCh := range r.DownStream {
+			*downCh <- res // Blocking write to ensure data integrity
+		}
+	}
+	if noMatch != nil {
+		*r.noMatchDownStream <- noMatch
+	}
+	if r.kafkaTxn != nil {
+		r.kafkaTxn.Done()
+	}
+}
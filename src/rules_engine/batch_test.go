@@ -0,0 +1,91 @@
+package rules_engine
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func batchTestEvents(n int) []map[string]interface{} {
+	events := make([]map[string]interface{}, n)
+	for i := range events {
+		switch i % 3 {
+		case 0:
+			events[i] = map[string]interface{}{"action": "login", "user": fmt.Sprintf("admin%d", i)}
+		case 1:
+			events[i] = map[string]interface{}{"action": "logout", "path": "/tmp/run.sh"}
+		default:
+			events[i] = map[string]interface{}{"action": "read", "path": "/var/log"}
+		}
+	}
+	return events
+}
+
+func TestEngineCheckBatch_MatchesSingleEvents(t *testing.T) {
+	rs := buildRulesetFromXML(t, `<root type="DETECTION" batch_size="50">`+quietBenchRules)
+
+	batch := rs.EngineCheckBatch(batchTestEvents(30))
+	single := batchTestEvents(30)
+	for i, data := range single {
+		if want := rs.EngineCheck(data); !reflect.DeepEqual(batch[i], want) {
+			t.Fatalf("event %d: batch results %v differ from single results %v", i, batch[i], want)
+		}
+	}
+	if len(batch[2]) != 0 || len(batch[0]) != 2 {
+		t.Fatalf("expected 2 results for a login by an admin and none for a read, got %d and %d", len(batch[0]), len(batch[2]))
+	}
+}
+
+func TestFillBatch_TakesQueuedEventsUpToBatchSize(t *testing.T) {
+	rs := buildRulesetFromXML(t, `<root type="DETECTION" batch_size="4">`+quietBenchRules)
+	ch := make(chan map[string]interface{}, 10)
+	for _, e := range batchTestEvents(6) {
+		ch <- e
+	}
+
+	events, sizes := rs.fillBatch(ch, <-ch, 0)
+	if len(events) != 4 || len(sizes) != 4 || len(ch) != 2 {
+		t.Fatalf("expected a batch of 4 with 2 events left queued, got %d and %d", len(events), len(ch))
+	}
+	close(ch)
+	events, _ = rs.fillBatch(ch, <-ch, 0)
+	if len(events) != 2 {
+		t.Fatalf("expected the queued remainder in a short batch, got %d", len(events))
+	}
+}
+
+// benchmarkDelivery checks and delivers events to a drained downstream like the upstream loop does
+func benchmarkDelivery(b *testing.B, batchSize int) {
+	rs := newBenchRuleset(b, fmt.Sprintf(`<root type="DETECTION" quiet="true" batch_size="%d">`, batchSize))
+	down := make(chan map[string]interface{}, 1024)
+	rs.DownStream = map[string]*chan map[string]interface{}{"out": &down}
+	done := make(chan struct{})
+	go func() {
+		for range down {
+		}
+		close(done)
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i += batchSize {
+		// Matches annotate the events, so each iteration needs its own
+		events := batchTestEvents(batchSize)
+		if batchSize == 1 {
+			rs.deliver(events[0], rs.checkAndSample(events[0]))
+			continue
+		}
+		rs.deliverBatch(events, make([]int64, batchSize), rs.checkAndSampleBatch(events))
+	}
+	b.StopTimer()
+	close(down)
+	<-done
+}
+
+func BenchmarkEngineDelivery_Single(b *testing.B) {
+	benchmarkDelivery(b, 1)
+}
+
+func BenchmarkEngineDelivery_Batch100(b *testing.B) {
+	benchmarkDelivery(b, 100)
+}
@@ -0,0 +1,88 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// Check attribute values match="any|all", evaluating a check on each element of an array field
+const (
+	CheckMatchAny = "any"
+	CheckMatchAll = "all"
+)
+
+// checkMatchError rejects an unknown match mode and match on checks that do not compare the field
+func checkMatchError(checkNode *CheckNodes) error {
+	if checkNode.Match == "" {
+		return nil
+	}
+	if checkNode.Match != CheckMatchAny && checkNode.Match != CheckMatchAll {
+		return fmt.Errorf("check match must be '%s' or '%s', got '%s'", CheckMatchAny, CheckMatchAll, checkNode.Match)
+	}
+	switch checkNode.Type {
+	case "ISNULL", "NOTNULL", "PLUGIN":
+		return fmt.Errorf("check match does not apply to type '%s'", checkNode.Type)
+	}
+	return nil
+}
+
+// validateCheckMatch reports an invalid match attribute of a check node
+func validateCheckMatch(checkNode *CheckNodes, line int, ruleID string, result *ValidationResult) {
+	if err := checkMatchError(checkNode); err != nil {
+		result.IsValid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Line:    line,
+			Message: err.Error(),
+			Detail:  fmt.Sprintf("Rule ID: %s", ruleID),
+		})
+	}
+}
+
+// fieldElements returns the elements of an array field as strings. A JSON array encoded as a string
+// is decoded first; any other value is a single element.
+func fieldElements(data map[string]interface{}, fieldList []string) ([]string, bool) {
+	value, exist := common.GetCheckDataWithType(data, fieldList)
+	if !exist {
+		return nil, false
+	}
+	if s, ok := value.(string); ok {
+		trimmed := strings.TrimSpace(s)
+		if !strings.HasPrefix(trimmed, "[") {
+			return []string{s}, true
+		}
+		var list []interface{}
+		if err := sonic.UnmarshalString(trimmed, &list); err != nil {
+			return []string{s}, true
+		}
+		value = list
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []string{common.AnyToString(value)}, true
+	}
+	elements := make([]string, rv.Len())
+	for i := range elements {
+		elements[i] = common.AnyToString(rv.Index(i).Interface())
+	}
+	return elements, true
+}
+
+// checkElements evaluates the check on each element of the field: match="any" needs one element to
+// satisfy it, match="all" every element. A missing field or an empty array never matches.
+func (r *Ruleset) checkElements(checkNode *CheckNodes, data map[string]interface{}, checkNodeValue string, checkNodeValueFromRaw bool, ruleCache map[string]common.CheckCoreCache, regexResultCache *RegexResultCache) bool {
+	elements, exist := fieldElements(data, checkNode.FieldList)
+	if !exist || len(elements) == 0 {
+		return false
+	}
+	all := checkNode.Match == CheckMatchAll
+	for _, element := range elements {
+		if r.compareCheckData(checkNode, element, checkNodeValue, checkNodeValueFromRaw, ruleCache, regexResultCache) != all {
+			return !all
+		}
+	}
+	return all
+}
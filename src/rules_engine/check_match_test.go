@@ -0,0 +1,71 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+const checkMatchTestRuleset = `
+<root type="DETECTION" name="check-match">
+  <rule id="any_domain" name="any suspicious domain">
+    <check type="END" field="domains" match="any">.evil.example</check>
+  </rule>
+  <rule id="all_internal" name="only internal parents">
+    <check type="REGEX" field="pid_tree" match="all">^(systemd|sshd|bash)$</check>
+  </rule>
+</root>`
+
+func hitRules(results []map[string]interface{}) string {
+	ids := make([]string, 0, len(results))
+	for _, res := range results {
+		ids = append(ids, res[HitRuleIdFieldName].(string))
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestCheckMatch_AnyElement(t *testing.T) {
+	rs := buildRulesetFromXML(t, checkMatchTestRuleset)
+
+	got := hitRules(rs.EngineCheck(map[string]interface{}{
+		"domains": []interface{}{"example.com", "cdn.evil.example", "example.org"},
+	}))
+	if got != "TEST.RS.any_domain" {
+		t.Fatalf("expected any_domain to match one element, got %q", got)
+	}
+	if got := hitRules(rs.EngineCheck(map[string]interface{}{"domains": []interface{}{"example.com", "example.org"}})); got != "" {
+		t.Fatalf("expected no match without a suspicious element, got %q", got)
+	}
+	// A JSON array held as a string is split too
+	if got := hitRules(rs.EngineCheck(map[string]interface{}{"domains": `["a.evil.example"]`})); got != "TEST.RS.any_domain" {
+		t.Fatalf("expected a JSON-encoded array to be checked by element, got %q", got)
+	}
+}
+
+func TestCheckMatch_AllElements(t *testing.T) {
+	rs := buildRulesetFromXML(t, checkMatchTestRuleset)
+
+	if got := hitRules(rs.EngineCheck(map[string]interface{}{"pid_tree": []interface{}{"systemd", "sshd", "bash"}})); got != "TEST.RS.all_internal" {
+		t.Fatalf("expected all_internal to match, got %q", got)
+	}
+	if got := hitRules(rs.EngineCheck(map[string]interface{}{"pid_tree": []interface{}{"systemd", "nginx", "bash"}})); got != "" {
+		t.Fatalf("expected one foreign element to fail the check, got %q", got)
+	}
+	if got := hitRules(rs.EngineCheck(map[string]interface{}{"pid_tree": []interface{}{}})); got != "" {
+		t.Fatalf("expected an empty array not to match, got %q", got)
+	}
+}
+
+func TestCheckMatch_Verify(t *testing.T) {
+	for check, want := range map[string]string{
+		`<check type="INCL" field="a" match="some">x</check>`: "match must be 'any' or 'all'",
+		`<check type="NOTNULL" field="a" match="any" />`:      "does not apply to type 'NOTNULL'",
+	} {
+		xml := `<root type="DETECTION"><rule id="r1">` + check + `</rule></root>`
+		if _, err := ParseRuleset([]byte(xml)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q for %s, got %v", want, check, err)
+		}
+		if res, _ := ValidateWithDetails("", xml); res.IsValid {
+			t.Fatalf("expected validation to reject %s", check)
+		}
+	}
+}
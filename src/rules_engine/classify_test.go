@@ -0,0 +1,64 @@
+package rules_engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThresholdClassify_RecordsDistinctValues(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION" name="classify">
+  <rule id="r1" name="port scan">
+    <check type="EQU" field="action">connect</check>
+    <threshold group_by="src_ip" range="5m" count_type="CLASSIFY" count_field="dst_port" local_cache="true" classify_values_field="ports">2</threshold>
+  </rule>
+</root>`)
+
+	var res []map[string]interface{}
+	for _, port := range []string{"22", "80", "22", "443"} {
+		event := map[string]interface{}{"action": "connect", "src_ip": "10.0.0.1", "dst_port": port}
+		res = rs.EngineCheck(event)
+		if _, ok := event["ports"]; ok {
+			t.Fatalf("input event must not be modified")
+		}
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected the third distinct port to fire, got %d results", len(res))
+	}
+	if got := res[0]["ports"]; !reflect.DeepEqual(got, []string{"22", "443", "80"}) {
+		t.Fatalf("unexpected classify values: %v", got)
+	}
+	// Only the requested field is added, the event keeps its shape otherwise
+	if _, ok := res[0]["_hub_classify_count"]; ok {
+		t.Fatalf("the distinct count must only be recorded when classify_count_field asks for it: %v", res[0])
+	}
+}
+
+func TestThreshold_FireCountField(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION" name="fire-count">
+  <rule id="r1" name="exfiltration">
+    <check type="EQU" field="action">upload</check>
+    <threshold group_by="user" range="5m" count_type="SUM" count_field="mb" local_cache="true" fire_count_field="_threshold_count">10</threshold>
+  </rule>
+  <rule id="r2" name="port scan">
+    <check type="EQU" field="action">connect</check>
+    <threshold group_by="src_ip" range="5m" count_type="CLASSIFY" count_field="dst_port" local_cache="true" fire_count_field="_threshold_count">2</threshold>
+  </rule>
+</root>`)
+
+	var res []map[string]interface{}
+	for _, mb := range []string{"5", "4", "3"} {
+		res = rs.EngineCheck(map[string]interface{}{"action": "upload", "user": "bob", "mb": mb})
+	}
+	if len(res) != 1 || res[0]["_threshold_count"] != 12 {
+		t.Fatalf("expected the threshold of 10 to fire with _threshold_count 12, got %v", res)
+	}
+
+	for _, port := range []string{"22", "80", "443"} {
+		res = rs.EngineCheck(map[string]interface{}{"action": "connect", "src_ip": "10.0.0.1", "dst_port": port})
+	}
+	if len(res) != 1 || res[0]["_threshold_count"] != 3 {
+		t.Fatalf("expected CLASSIFY to record the distinct count, got %v", res)
+	}
+}
@@ -0,0 +1,323 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/plugin"
+	"fmt"
+	"strings"
+)
+
+// CompiledRuleset is a read-only view of a built ruleset: how the engine interpreted the XML, with
+// resolved field paths, parsed conditions, plugin bindings and operations in execution order.
+type CompiledRuleset struct {
+	ID            string         `json:"id"`
+	Name          string         `json:"name,omitempty"`
+	Type          string         `json:"type"`
+	IsDetection   bool           `json:"is_detection"`
+	EngineVersion int            `json:"engine_version"` // Effective version, the current one when not declared
+	SampleTrace   bool           `json:"sample_trace,omitempty"`
+	Quiet         bool           `json:"quiet,omitempty"`
+	SampleMode    string         `json:"sample,omitempty"`
+	Rules         []CompiledRule `json:"rules"`
+	// SkippedRules failed to build under on_rule_error="skip" and are not evaluated
+	SkippedRules []RuleBuildError `json:"skipped_rules,omitempty"`
+}
+
+type CompiledRule struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name,omitempty"`
+	Scope      string              `json:"scope,omitempty"` // Field paths below are already resolved within it
+	Group      string              `json:"group,omitempty"`
+	Requires   []string            `json:"requires,omitempty"`
+	Operations []CompiledOperation `json:"operations"`
+	Aggregate  *CompiledAggregate  `json:"aggregate,omitempty"`
+	Cooldown   *CompiledCooldown   `json:"cooldown,omitempty"`
+}
+
+// CompiledOperation is one entry of the rule queue; exactly one of the detail fields is set
+type CompiledOperation struct {
+	Type      string             `json:"type"` // checklist, check, threshold, append, del, plugin, iterator
+	ID        int                `json:"id"`
+	Check     *CompiledCheck     `json:"check,omitempty"`
+	Checklist *CompiledChecklist `json:"checklist,omitempty"`
+	Threshold *CompiledThreshold `json:"threshold,omitempty"`
+	Append    *CompiledAppend    `json:"append,omitempty"`
+	Del       [][]string         `json:"del,omitempty"`
+	Plugin    *CompiledPlugin    `json:"plugin,omitempty"`
+	Iterator  *CompiledIterator  `json:"iterator,omitempty"`
+}
+
+type CompiledCheck struct {
+	ID        string          `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	Field     string          `json:"field,omitempty"`
+	FieldPath []string        `json:"field_path,omitempty"`
+	Value     string          `json:"value"`
+	Logic     string          `json:"logic,omitempty"`
+	Delimiter string          `json:"delimiter,omitempty"`
+	Values    []string        `json:"values,omitempty"` // Value split by the delimiter
+	Strict    bool            `json:"strict,omitempty"`
+	OnError   string          `json:"on_error,omitempty"`
+	As        string          `json:"as,omitempty"`
+	Match     string          `json:"match,omitempty"`
+	Plugin    *CompiledPlugin `json:"plugin,omitempty"`
+}
+
+type CompiledChecklist struct {
+	Condition       string              `json:"condition,omitempty"`
+	ParsedCondition string              `json:"parsed_condition,omitempty"` // Fully parenthesized
+	Checks          []CompiledCheck     `json:"checks"`
+	Thresholds      []CompiledThreshold `json:"thresholds,omitempty"`
+}
+
+type CompiledThreshold struct {
+	ID           string              `json:"id,omitempty"`
+	GroupBy      map[string][]string `json:"group_by"`
+	Range        string              `json:"range"`
+	RangeSeconds int                 `json:"range_seconds"`
+	Value        int                 `json:"value"`
+	CountType    string              `json:"count_type,omitempty"`
+	CountField   []string            `json:"count_field,omitempty"`
+	LocalCache   bool                `json:"local_cache"`
+	GroupByID    string              `json:"group_by_id"` // Prefix of the counter keys
+
+	ClassifyValuesField string `json:"classify_values_field,omitempty"`
+	ClassifyCountField  string `json:"classify_count_field,omitempty"`
+	FireCountField      string `json:"fire_count_field,omitempty"`
+}
+
+type CompiledAppend struct {
+	Field    string          `json:"field"`
+	Type     string          `json:"type,omitempty"`
+	Value    string          `json:"value"`
+	Encoding string          `json:"encoding,omitempty"`
+	Plugin   *CompiledPlugin `json:"plugin,omitempty"`
+}
+
+// CompiledPlugin is a plugin call bound to a loaded plugin
+type CompiledPlugin struct {
+	Call    string              `json:"call"`
+	Name    string              `json:"name,omitempty"`
+	Kind    string              `json:"kind,omitempty"` // local or yaegi
+	Negated bool                `json:"negated,omitempty"`
+	Args    []CompiledPluginArg `json:"args"`
+}
+
+type CompiledPluginArg struct {
+	Kind  string      `json:"kind"` // literal, field or original event
+	Value interface{} `json:"value,omitempty"`
+}
+
+type CompiledIterator struct {
+	Type       string              `json:"type"`
+	Field      string              `json:"field"`
+	FieldPath  []string            `json:"field_path,omitempty"`
+	Variable   string              `json:"variable"`
+	Checks     []CompiledCheck     `json:"checks,omitempty"`
+	Thresholds []CompiledThreshold `json:"thresholds,omitempty"`
+	Checklists []CompiledChecklist `json:"checklists,omitempty"`
+}
+
+type CompiledAggregate struct {
+	GroupBy       []string `json:"group_by,omitempty"`
+	WindowSeconds int      `json:"window_seconds"`
+	Samples       int      `json:"samples"`
+}
+
+type CompiledCooldown struct {
+	GroupBy         []string `json:"group_by,omitempty"`
+	DurationSeconds int      `json:"duration_seconds"`
+	LocalCache      bool     `json:"local_cache"`
+}
+
+var operatorTypeNames = map[OperatorType]string{
+	T_CheckList: "checklist",
+	T_Check:     "check",
+	T_Threshold: "threshold",
+	T_Append:    "append",
+	T_Del:       "del",
+	T_Plugin:    "plugin",
+	T_Iterator:  "iterator",
+}
+
+// Compiled returns the introspection view of a built ruleset
+func (r *Ruleset) Compiled() CompiledRuleset {
+	c := CompiledRuleset{
+		ID:            r.RulesetID,
+		Name:          r.Name,
+		Type:          r.Type,
+		IsDetection:   r.IsDetection,
+		EngineVersion: effectiveEngineVersion(r.EngineVersion),
+		SampleTrace:   r.SampleTrace,
+		SampleMode:    r.SampleMode,
+		Quiet:         r.Quiet,
+		Rules:         make([]CompiledRule, 0, len(r.Rules)),
+		SkippedRules:  r.RuleErrors,
+	}
+	for i := range r.Rules {
+		c.Rules = append(c.Rules, compileRule(&r.Rules[i]))
+	}
+	return c
+}
+
+func compileRule(rule *Rule) CompiledRule {
+	cr := CompiledRule{ID: rule.ID, Name: rule.Name, Scope: rule.Scope, Group: rule.Group, Requires: rule.Requires, Operations: []CompiledOperation{}}
+	if rule.Queue != nil {
+		for _, op := range *rule.Queue {
+			co := CompiledOperation{Type: operatorTypeNames[op.Type], ID: op.ID}
+			switch op.Type {
+			case T_CheckList:
+				if checklist, ok := rule.ChecklistMap[op.ID]; ok {
+					cl := compileChecklist(&checklist)
+					co.Checklist = &cl
+				}
+			case T_Check:
+				if node, ok := rule.CheckMap[op.ID]; ok {
+					check := compileCheck(&node)
+					co.Check = &check
+				}
+			case T_Threshold:
+				if threshold, ok := rule.ThresholdMap[op.ID]; ok {
+					t := compileThreshold(&threshold)
+					co.Threshold = &t
+				}
+			case T_Append:
+				if a, ok := rule.AppendsMap[op.ID]; ok {
+					co.Append = &CompiledAppend{Field: a.FieldName, Type: a.Type, Value: a.Value, Encoding: a.Encoding}
+					if a.usesPlugin() {
+						co.Append.Plugin = compilePlugin(a.Value, a.Plugin, a.PluginArgs, false)
+					}
+				}
+			case T_Del:
+				co.Del = rule.DelMap[op.ID]
+			case T_Plugin:
+				if p, ok := rule.PluginMap[op.ID]; ok {
+					co.Plugin = compilePlugin(p.Value, p.Plugin, p.PluginArgs, false)
+				}
+			case T_Iterator:
+				if iterator, ok := rule.IteratorMap[op.ID]; ok {
+					co.Iterator = compileIterator(&iterator)
+				}
+			}
+			cr.Operations = append(cr.Operations, co)
+		}
+	}
+	if agg := rule.Aggregate; agg != nil {
+		cr.Aggregate = &CompiledAggregate{GroupBy: agg.GroupByFields, WindowSeconds: agg.WindowInt, Samples: agg.Samples}
+	}
+	if cd := rule.Cooldown; cd != nil {
+		cr.Cooldown = &CompiledCooldown{GroupBy: cd.GroupByFields, DurationSeconds: cd.DurationInt, LocalCache: cd.LocalCache}
+	}
+	return cr
+}
+
+func compileCheck(node *CheckNodes) CompiledCheck {
+	c := CompiledCheck{
+		ID:        node.ID,
+		Type:      node.Type,
+		Field:     node.Field,
+		FieldPath: node.FieldList,
+		Value:     node.Value,
+		Logic:     node.Logic,
+		Delimiter: node.Delimiter,
+		Values:    node.DelimiterFieldList,
+		Strict:    node.Strict,
+		OnError:   node.OnError,
+		As:        node.As,
+		Match:     node.Match,
+	}
+	if node.Type == "PLUGIN" {
+		c.Plugin = compilePlugin(node.Value, node.Plugin, node.PluginArgs, node.IsNegated)
+	}
+	return c
+}
+
+func compileChecklist(checklist *Checklist) CompiledChecklist {
+	cl := CompiledChecklist{Condition: checklist.Condition, Checks: make([]CompiledCheck, 0, len(checklist.CheckNodes))}
+	if checklist.ConditionAST != nil && checklist.ConditionAST.ExprAST != nil {
+		cl.ParsedCondition = conditionString(checklist.ConditionAST.ExprAST)
+	}
+	for i := range checklist.CheckNodes {
+		cl.Checks = append(cl.Checks, compileCheck(&checklist.CheckNodes[i]))
+	}
+	for i := range checklist.ThresholdNodes {
+		cl.Thresholds = append(cl.Thresholds, compileThreshold(&checklist.ThresholdNodes[i]))
+	}
+	return cl
+}
+
+func compileThreshold(threshold *Threshold) CompiledThreshold {
+	return CompiledThreshold{
+		ID:           threshold.ID,
+		GroupBy:      threshold.GroupByList,
+		Range:        threshold.Range,
+		RangeSeconds: threshold.RangeInt,
+		Value:        threshold.Value,
+		CountType:    threshold.CountType,
+		CountField:   threshold.CountFieldList,
+		LocalCache:   threshold.LocalCache,
+		GroupByID:    threshold.GroupByID,
+
+		ClassifyValuesField: threshold.ClassifyValuesField,
+		ClassifyCountField:  threshold.ClassifyCountField,
+		FireCountField:      threshold.FireCountField,
+	}
+}
+
+func compileIterator(iterator *Iterator) *CompiledIterator {
+	ci := &CompiledIterator{
+		Type:      iterator.Type,
+		Field:     iterator.Field,
+		FieldPath: iterator.FieldList,
+		Variable:  iterator.Variable,
+	}
+	for i := range iterator.CheckNodes {
+		ci.Checks = append(ci.Checks, compileCheck(&iterator.CheckNodes[i]))
+	}
+	for i := range iterator.ThresholdNodes {
+		ci.Thresholds = append(ci.Thresholds, compileThreshold(&iterator.ThresholdNodes[i]))
+	}
+	for i := range iterator.Checklists {
+		ci.Checklists = append(ci.Checklists, compileChecklist(&iterator.Checklists[i]))
+	}
+	return ci
+}
+
+var pluginArgKinds = map[int]string{0: "literal", 1: "field", 2: "original_event"}
+
+func compilePlugin(call string, p *plugin.Plugin, args []*PluginArg, negated bool) *CompiledPlugin {
+	cp := &CompiledPlugin{Call: strings.TrimSpace(call), Negated: negated, Args: make([]CompiledPluginArg, 0, len(args))}
+	if p != nil {
+		cp.Name = p.Name
+		cp.Kind = "local"
+		if p.Type == 1 {
+			cp.Kind = "yaegi"
+		}
+	}
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		a := CompiledPluginArg{Kind: pluginArgKinds[arg.Type]}
+		if arg.Type != 2 {
+			a.Value = arg.Value
+		}
+		cp.Args = append(cp.Args, a)
+	}
+	return cp
+}
+
+var conditionOpNames = map[string]string{"&": "and", "|": "or", "!": "not"}
+
+// conditionString renders a parsed checklist condition fully parenthesized, with the DSL keywords
+func conditionString(expr ExprAST) string {
+	switch e := expr.(type) {
+	case NumberExprAST:
+		return e.Val
+	case BinaryExprAST:
+		return fmt.Sprintf("(%s %s %s)", conditionString(e.Lhs), conditionOpNames[e.Op], conditionString(e.Rhs))
+	case UnaryExprAST:
+		return fmt.Sprintf("(%s %s)", conditionOpNames[e.Op], conditionString(e.Operand))
+	default:
+		return ""
+	}
+}
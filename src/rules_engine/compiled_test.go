@@ -0,0 +1,61 @@
+package rules_engine
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompiled_KnownRuleset(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION" name="compiled">
+  <rule id="r1" name="brute force">
+    <checklist condition="a and (b or not c)">
+      <check id="a" type="EQU" field="event.action">login</check>
+      <check id="b" type="INCL" field="user" logic="OR" delimiter="|">admin|root</check>
+      <check id="c" type="EQU" field="result">ok</check>
+    </checklist>
+    <threshold group_by="src_ip" range="5m">10</threshold>
+    <append field="tag">bf</append>
+  </rule>
+</root>`)
+
+	c := rs.Compiled()
+	if c.ID != "TEST.RS" || c.Type != "DETECTION" || !c.IsDetection || c.EngineVersion != CurrentEngineVersion {
+		t.Fatalf("unexpected ruleset header: %+v", c)
+	}
+	if len(c.Rules) != 1 {
+		t.Fatalf("expected 1 rule, got %d", len(c.Rules))
+	}
+	ops := c.Rules[0].Operations
+	var types []string
+	for _, op := range ops {
+		types = append(types, op.Type)
+	}
+	if !reflect.DeepEqual(types, []string{"checklist", "threshold", "append"}) {
+		t.Fatalf("unexpected operation order: %v", types)
+	}
+
+	cl := ops[0].Checklist
+	if cl.ParsedCondition != "(a and (b or (not c)))" {
+		t.Fatalf("unexpected parsed condition: %q", cl.ParsedCondition)
+	}
+	if !reflect.DeepEqual(cl.Checks[0].FieldPath, []string{"event", "action"}) {
+		t.Fatalf("unexpected field path: %v", cl.Checks[0].FieldPath)
+	}
+	if b := cl.Checks[1]; b.Logic != "OR" || !reflect.DeepEqual(b.Values, []string{"admin", "root"}) {
+		t.Fatalf("unexpected logic check: %+v", b)
+	}
+
+	th := ops[1].Threshold
+	if th.RangeSeconds != 300 || th.Value != 10 || !reflect.DeepEqual(th.GroupBy, map[string][]string{"src_ip": {"src_ip"}}) {
+		t.Fatalf("unexpected threshold: %+v", th)
+	}
+	if a := ops[2].Append; a.Field != "tag" || a.Value != "bf" {
+		t.Fatalf("unexpected append: %+v", a)
+	}
+
+	if _, err := json.Marshal(c); err != nil {
+		t.Fatalf("compiled ruleset must be JSON encodable: %v", err)
+	}
+}
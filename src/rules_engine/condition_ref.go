@@ -0,0 +1,99 @@
+package rules_engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// conditionRefs caches the positional references of the first check nodes, checklists rarely have more
+var conditionRefs = func() []string {
+	refs := make([]string, 32)
+	for i := range refs {
+		refs[i] = "n" + strconv.Itoa(i+1)
+	}
+	return refs
+}()
+
+// ConditionRef is the positional reference of the check node at index i of a checklist: n1 for the
+// first node, n2 for the second and so on. A condition can use it whether the node has an id or not.
+func ConditionRef(i int) string {
+	if i < len(conditionRefs) {
+		return conditionRefs[i]
+	}
+	return "n" + strconv.Itoa(i+1)
+}
+
+// conditionRefIndex returns the check node index a positional reference points to
+func conditionRefIndex(ref string) (int, bool) {
+	if len(ref) < 2 || ref[0] != 'n' || ref[1] == '0' {
+		return 0, false
+	}
+	n, err := strconv.Atoi(ref[1:])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// checkConditionNodeID checks the id of the check node at index i of a checklist with a condition.
+// Ids shaped like a positional reference are only allowed on the node they point to.
+func checkConditionNodeID(id string, i int) error {
+	if index, ok := conditionRefIndex(id); ok && index != i {
+		return fmt.Errorf("check node id %s is reserved for the positional reference of check node %d", id, index+1)
+	}
+	return nil
+}
+
+// checkConditionRefs checks that the positional references of a condition point to check nodes of
+// the checklist. ids holds the explicit ids of the checklist nodes.
+func checkConditionRefs(ast *ReCepAST, nodeCount int, ids map[string]bool) error {
+	for literal := range ast.AllLiteral {
+		if ids[literal] {
+			continue
+		}
+		if index, ok := conditionRefIndex(literal); ok && index >= nodeCount {
+			return fmt.Errorf("condition references %s but the checklist has %d check nodes", literal, nodeCount)
+		}
+	}
+	return nil
+}
+
+// bindConditionRefs registers the references the condition of a checklist can use for its check
+// nodes: the positional reference of every node, plus its id when it has one. Nodes without an id
+// take their positional reference as id.
+func bindConditionRefs(checklist *Checklist, ruleID string) error {
+	ids := make(map[string]bool, len(checklist.CheckNodes)+len(checklist.ThresholdNodes))
+	for i := range checklist.CheckNodes {
+		node := &checklist.CheckNodes[i]
+		node.ID = strings.TrimSpace(node.ID)
+		ref := ConditionRef(i)
+		if node.ID == "" {
+			node.ID = ref
+		} else if err := checkConditionNodeID(node.ID, i); err != nil {
+			return fmt.Errorf("%w: %s", err, ruleID)
+		}
+
+		if _, ok := checklist.ConditionMap[node.ID]; ok {
+			return fmt.Errorf("check node id cannot be repeated: %s", ruleID)
+		}
+		checklist.ConditionMap[node.ID] = false
+		if node.ID != ref {
+			node.Ref = ref
+			checklist.ConditionMap[ref] = false
+		}
+		ids[node.ID] = true
+	}
+	for i, threshold := range checklist.ThresholdNodes {
+		if threshold.ID != "" {
+			ids[threshold.ID] = true
+		} else {
+			ids[fmt.Sprintf("threshold_%d", i)] = true
+		}
+	}
+
+	if err := checkConditionRefs(checklist.ConditionAST, len(checklist.CheckNodes), ids); err != nil {
+		return fmt.Errorf("%w: %s", err, ruleID)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConditionRefs_Positional(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="condition-refs">
+  <rule id="r1" name="r1">
+    <checklist condition="(n1 or n2) and not n3">
+      <check type="EQU" field="a">x</check>
+      <check type="EQU" field="b">y</check>
+      <check type="REGEX" field="c">^skip</check>
+    </checklist>
+  </rule>
+</root>`
+	rs := buildRulesetFromXML(t, xml)
+
+	cases := []struct {
+		data map[string]interface{}
+		hit  bool
+	}{
+		{map[string]interface{}{"a": "x", "c": "keep"}, true},
+		{map[string]interface{}{"b": "y", "c": "keep"}, true},
+		{map[string]interface{}{"a": "x", "c": "skip me"}, false},
+		{map[string]interface{}{"a": "z", "b": "z", "c": "keep"}, false},
+	}
+	for i, tc := range cases {
+		if hit := len(rs.EngineCheck(tc.data)) == 1; hit != tc.hit {
+			t.Fatalf("case %d: expected hit=%v, got %v", i, tc.hit, hit)
+		}
+	}
+}
+
+func TestConditionRefs_MixedWithIds(t *testing.T) {
+	// Positions follow the document order even though the engine runs REGEX nodes last, ids and
+	// positions can both reference a node
+	xml := `
+<root type="DETECTION" name="condition-refs">
+  <rule id="r1" name="r1">
+    <checklist condition="suspicious and n2 and not n1">
+      <check id="internal" type="REGEX" field="ip">^10\.</check>
+      <check type="NOTNULL" field="ip" />
+      <check id="suspicious" type="INCL" field="cmd">powershell</check>
+    </checklist>
+  </rule>
+</root>`
+	rs := buildRulesetFromXML(t, xml)
+
+	if len(rs.EngineCheck(map[string]interface{}{"ip": "192.168.1.1", "cmd": "powershell -enc"})) != 1 {
+		t.Fatalf("expected a hit for a non 10. address")
+	}
+	if len(rs.EngineCheck(map[string]interface{}{"cmd": "powershell -enc"})) != 0 {
+		t.Fatalf("n2 is false without an ip, expected no hit")
+	}
+	if len(rs.EngineCheck(map[string]interface{}{"ip": "10.0.0.1", "cmd": "powershell -enc"})) != 0 {
+		t.Fatalf("n1 references the node with id internal, expected no hit")
+	}
+}
+
+func TestConditionRefs_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"out of range": `
+<root type="DETECTION" name="condition-refs">
+  <rule id="r1" name="r1">
+    <checklist condition="n1 and n3">
+      <check type="EQU" field="a">x</check>
+      <check type="EQU" field="b">y</check>
+    </checklist>
+  </rule>
+</root>`,
+		"reserved id": `
+<root type="DETECTION" name="condition-refs">
+  <rule id="r1" name="r1">
+    <checklist condition="n1 and n2">
+      <check id="n2" type="EQU" field="a">x</check>
+      <check type="EQU" field="b">y</check>
+    </checklist>
+  </rule>
+</root>`,
+	}
+	for name, xml := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := ValidateWithDetails("", xml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.IsValid {
+				t.Fatalf("expected the ruleset to be invalid")
+			}
+
+			rs, err := ParseRuleset([]byte(xml))
+			if err != nil {
+				return
+			}
+			rs.RulesetID = "TEST.RS"
+			if err := RulesetBuild(rs); err == nil || !strings.Contains(err.Error(), "r1") {
+				t.Fatalf("expected the build to fail on rule r1, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"strings"
+	"time"
+)
+
+// cooldownNow is the clock of local cooldowns, replaced in tests
+var cooldownNow = time.Now
+
+// Cooldown silences a rule for a period after it fires. Unlike a threshold it does not count matches
+// and unlike aggregate nothing is emitted later: the matches of a cooling group are dropped.
+type Cooldown struct {
+	Duration      string     `xml:"duration,attr"`
+	DurationInt   int        // Parsed duration in seconds
+	GroupBy       string     `xml:"group_by,attr"` // Comma separated fields, empty cools the whole rule
+	GroupByFields []string   // Field names in declaration order
+	GroupByList   [][]string // Parsed field paths, indexed like GroupByFields
+	LocalCache    bool       `xml:"local_cache,attr"` // Keep the cooldown on this node instead of Redis
+	GroupByID     string     // Ruleset and rule ID, prefix of the cooldown keys
+}
+
+// cooldownKey returns the cache key of the group a matched event belongs to, ns is the state
+// namespace of the ruleset
+func (cd *Cooldown) cooldownKey(ns string, data map[string]interface{}, ruleCache map[string]common.CheckCoreCache) string {
+	sb := stringBuilderPool.Get().(*strings.Builder)
+	sb.Reset()
+	sb.WriteString(ns)
+	sb.WriteString(cd.GroupByID)
+	for i, path := range cd.GroupByList {
+		tmpData, _ := GetCheckDataFromCache(ruleCache, cd.GroupByFields[i], data, path)
+		sb.WriteString("\x00")
+		sb.WriteString(tmpData)
+	}
+	key := "CD_" + common.XXHash64(sb.String())
+	stringBuilderPool.Put(sb)
+	return key
+}
+
+// startCooldown reports whether a matched rule may fire, starting its cooldown when it does.
+// While the group of the event is cooling down the match is suppressed.
+func (r *Ruleset) startCooldown(rule *Rule, data map[string]interface{}, ruleCache map[string]common.CheckCoreCache) bool {
+	cd := rule.Cooldown
+	key := cd.cooldownKey(r.stateNamespace, data, ruleCache)
+
+	if !cd.LocalCache {
+		started, err := common.RedisSetNX(key, 1, cd.DurationInt)
+		if err != nil {
+			// An unreachable Redis must not hide alerts
+			logger.Warn("Rule cooldown check failed, firing anyway", "ruleset", r.RulesetID, "rule", rule.ID, "error", err)
+			return true
+		}
+		return started
+	}
+
+	// The expiry is kept as the value so cooldowns follow cooldownNow, the TTL only evicts the key
+	now := cooldownNow().Unix()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if until, ok := r.Cache.Get(key); ok && int64(until) > now {
+		return false
+	}
+	if r.Cache.SetWithTTL(key, int(now)+cd.DurationInt, 1, time.Duration(cd.DurationInt)*time.Second) {
+		// Wait for the cache to be ready (ristretto is async)
+		r.Cache.Wait()
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package rules_engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldown_SilencesRuleUntilExpired(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION" name="cooldown">
+  <rule id="r1" name="login failure">
+    <check type="EQU" field="action">login_failed</check>
+    <cooldown duration="10m" group_by="src_ip" local_cache="true"/>
+  </rule>
+</root>`)
+
+	now := time.Now()
+	cooldownNow = func() time.Time { return now }
+	defer func() { cooldownNow = time.Now }()
+
+	fire := func(ip string) int {
+		return len(rs.EngineCheck(map[string]interface{}{"action": "login_failed", "src_ip": ip}))
+	}
+
+	if n := fire("10.0.0.1"); n != 1 {
+		t.Fatalf("expected the first match to fire, got %d results", n)
+	}
+	for i := 0; i < 3; i++ {
+		if n := fire("10.0.0.1"); n != 0 {
+			t.Fatalf("expected the rule to be silent during its cooldown, got %d results", n)
+		}
+	}
+	// Other groups are not affected
+	if n := fire("10.0.0.2"); n != 1 {
+		t.Fatalf("expected another src_ip to fire, got %d results", n)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if n := fire("10.0.0.1"); n != 1 {
+		t.Fatalf("expected the rule to fire again after its cooldown, got %d results", n)
+	}
+	if n := fire("10.0.0.1"); n != 0 {
+		t.Fatalf("expected a new cooldown after firing again, got %d results", n)
+	}
+}
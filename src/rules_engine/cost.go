@@ -0,0 +1,191 @@
+package rules_engine
+
+// Weights of the cost estimate, in units of one plain comparison check such as EQU
+const (
+	costCheck          = 1.0
+	costRegex          = 10.0
+	costPlugin         = 20.0
+	costDelimiterValue = 0.5  // each value of a delimiter list past the first
+	costThreshold      = 15.0 // a Redis round trip per event
+	costLocalThreshold = 5.0
+	costClassify       = 5.0 // CLASSIFY also keeps the distinct values
+	costAppend         = 1.0
+	costDecode         = 2.0
+	costDel            = 0.5
+	// costIterations is how many elements an iterator is assumed to walk
+	costIterations = 5.0
+	// costNarrowing is the share of events assumed to pass a check, so an operation queued after a
+	// check only counts this fraction of its cost
+	costNarrowing = 0.5
+)
+
+// Levels of a cost estimate
+const (
+	CostLow    = "low"
+	CostMedium = "medium"
+	CostHigh   = "high"
+
+	costMediumScore = 50.0
+	costHighScore   = 200.0
+)
+
+// RulesetCost estimates what a ruleset costs per event. Scores are relative, in units of one EQU
+// check, and only meant for comparing rulesets and rules before deployment.
+type RulesetCost struct {
+	ID    string     `json:"id"`
+	Score float64    `json:"score"`
+	Level string     `json:"level"` // low, medium or high
+	Rules []RuleCost `json:"rules"`
+}
+
+// RuleCost is the estimated per-event cost of one rule
+type RuleCost struct {
+	ID         string  `json:"id"`
+	Score      float64 `json:"score"`
+	Checks     int     `json:"checks"`
+	Regex      int     `json:"regex"`
+	Plugins    int     `json:"plugins"`
+	Thresholds int     `json:"thresholds"`
+	// Narrowed is set when a cheap check runs first, so most events never reach the costly operations
+	Narrowed bool `json:"narrowed"`
+}
+
+// Cost returns the per-event cost estimate of a built ruleset. Every rule counts for each event,
+// except with first_match where rules after a match are skipped, which the estimate ignores.
+func (r *Ruleset) Cost() RulesetCost {
+	c := RulesetCost{ID: r.RulesetID, Rules: make([]RuleCost, 0, len(r.Rules))}
+	for i := range r.Rules {
+		rc := ruleCost(&r.Rules[i])
+		c.Score += rc.Score
+		c.Rules = append(c.Rules, rc)
+	}
+	c.Score = roundCost(c.Score)
+	c.Level = costLevel(c.Score)
+	return c
+}
+
+func costLevel(score float64) string {
+	switch {
+	case score >= costHighScore:
+		return CostHigh
+	case score >= costMediumScore:
+		return CostMedium
+	}
+	return CostLow
+}
+
+func roundCost(score float64) float64 {
+	return float64(int(score*100+0.5)) / 100
+}
+
+// ruleCost walks the rule queue in execution order. Each check, checklist, iterator or threshold may
+// stop the rule, so the operations after it are weighted by costNarrowing.
+func ruleCost(rule *Rule) RuleCost {
+	rc := RuleCost{ID: rule.ID}
+	if rule.Queue == nil {
+		return rc
+	}
+	reach := 1.0
+	for i, op := range *rule.Queue {
+		var cost float64
+		gates := false
+		switch op.Type {
+		case T_Check:
+			if node, ok := rule.CheckMap[op.ID]; ok {
+				cost = rc.addCheck(&node)
+				gates = true
+				if i == 0 && node.Type != "REGEX" && node.Type != "PLUGIN" {
+					rc.Narrowed = true
+				}
+			}
+		case T_CheckList:
+			if checklist, ok := rule.ChecklistMap[op.ID]; ok {
+				cost = rc.addChecklist(&checklist)
+				gates = true
+			}
+		case T_Threshold:
+			if threshold, ok := rule.ThresholdMap[op.ID]; ok {
+				cost = rc.addThreshold(&threshold)
+				gates = true
+			}
+		case T_Iterator:
+			if iterator, ok := rule.IteratorMap[op.ID]; ok {
+				var inner float64
+				for j := range iterator.CheckNodes {
+					inner += rc.addCheck(&iterator.CheckNodes[j])
+				}
+				for j := range iterator.ThresholdNodes {
+					inner += rc.addThreshold(&iterator.ThresholdNodes[j])
+				}
+				for j := range iterator.Checklists {
+					inner += rc.addChecklist(&iterator.Checklists[j])
+				}
+				cost = inner * costIterations
+				gates = true
+			}
+		case T_Append:
+			if a, ok := rule.AppendsMap[op.ID]; ok {
+				switch {
+				case a.usesPlugin():
+					rc.Plugins++
+					cost = costPlugin
+				case a.Type == AppendDecode:
+					cost = costDecode
+				default:
+					cost = costAppend
+				}
+			}
+		case T_Plugin:
+			rc.Plugins++
+			cost = costPlugin
+		case T_Del:
+			cost = costDel * float64(len(rule.DelMap[op.ID]))
+		}
+		rc.Score += cost * reach
+		if gates {
+			reach *= costNarrowing
+		}
+	}
+	rc.Score = roundCost(rc.Score)
+	return rc
+}
+
+func (rc *RuleCost) addCheck(node *CheckNodes) float64 {
+	rc.Checks++
+	cost := costCheck
+	switch node.Type {
+	case "REGEX":
+		rc.Regex++
+		cost = costRegex
+	case "PLUGIN":
+		rc.Plugins++
+		cost = costPlugin
+	}
+	if n := len(node.DelimiterFieldList); n > 1 {
+		cost += costDelimiterValue * float64(n-1)
+	}
+	return cost
+}
+
+func (rc *RuleCost) addChecklist(checklist *Checklist) float64 {
+	var cost float64
+	for i := range checklist.CheckNodes {
+		cost += rc.addCheck(&checklist.CheckNodes[i])
+	}
+	for i := range checklist.ThresholdNodes {
+		cost += rc.addThreshold(&checklist.ThresholdNodes[i])
+	}
+	return cost
+}
+
+func (rc *RuleCost) addThreshold(threshold *Threshold) float64 {
+	rc.Thresholds++
+	cost := costThreshold
+	if threshold.LocalCache {
+		cost = costLocalThreshold
+	}
+	if threshold.CountType == "CLASSIFY" {
+		cost += costClassify
+	}
+	return cost
+}
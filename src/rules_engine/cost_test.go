@@ -0,0 +1,57 @@
+package rules_engine
+
+import "testing"
+
+func TestRulesetCost_RegexHeavyScoresHigher(t *testing.T) {
+	simple := buildRulesetFromXML(t, `
+<root type="DETECTION" name="simple">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="action">login</check>
+  </rule>
+  <rule id="r2" name="r2">
+    <check type="EQU" field="action">logout</check>
+  </rule>
+</root>`)
+	regexHeavy := buildRulesetFromXML(t, `
+<root type="DETECTION" name="regex">
+  <rule id="r1" name="r1">
+    <check type="REGEX" field="cmd">(?i)powershell.+-enc</check>
+    <check type="REGEX" field="path">^/tmp/[a-z0-9]+$</check>
+  </rule>
+  <rule id="r2" name="r2">
+    <check type="REGEX" field="url">https?://\d+\.\d+\.\d+\.\d+/</check>
+  </rule>
+</root>`)
+
+	simpleCost, regexCost := simple.Cost(), regexHeavy.Cost()
+	if regexCost.Score <= simpleCost.Score {
+		t.Fatalf("expected the regex ruleset to cost more, got %v <= %v", regexCost.Score, simpleCost.Score)
+	}
+	if simpleCost.Level != CostLow || len(simpleCost.Rules) != 2 || !simpleCost.Rules[0].Narrowed {
+		t.Fatalf("unexpected simple cost %+v", simpleCost)
+	}
+	if r := regexCost.Rules[0]; r.Regex != 2 || r.Narrowed {
+		t.Fatalf("unexpected regex rule cost %+v", r)
+	}
+}
+
+func TestRulesetCost_CheapCheckNarrowsCostlyOperations(t *testing.T) {
+	gated := buildRulesetFromXML(t, `
+<root type="DETECTION" name="gated">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="event">exec</check>
+    <check type="REGEX" field="cmd">curl .+\|\s*sh</check>
+    <threshold group_by="host" range="5m">3</threshold>
+  </rule>
+</root>`)
+	ungated := buildRulesetFromXML(t, `
+<root type="DETECTION" name="ungated">
+  <rule id="r1" name="r1">
+    <check type="REGEX" field="cmd">curl .+\|\s*sh</check>
+    <threshold group_by="host" range="5m">3</threshold>
+  </rule>
+</root>`)
+	if g, u := gated.Cost().Score, ungated.Cost().Score; g >= u {
+		t.Fatalf("expected a leading EQU check to narrow the cost, got %v >= %v", g, u)
+	}
+}
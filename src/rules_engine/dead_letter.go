@@ -0,0 +1,65 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/logger"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+const deadLetterMaxLen = 1000
+
+// Reasons of dead letter entries
+const (
+	DeadLetterCheckFailed = "check_failed" // a check with on_error="error" failed
+)
+
+// DeadLetter is one event a ruleset routed away instead of evaluating it
+type DeadLetter struct {
+	Ruleset   string                 `json:"ruleset"`
+	Rule      string                 `json:"rule"`
+	Reason    string                 `json:"reason"`
+	Error     string                 `json:"error,omitempty"`
+	Event     map[string]interface{} `json:"event"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// deadLetterPush stores an entry in the dead letter queue, replaced in tests
+var deadLetterPush = common.RedisLPush
+
+// DeadLetterKey is the Redis list holding the dead letters of a ruleset, newest first
+func DeadLetterKey(rulesetID string) string {
+	return "hub:dlq:ruleset:" + rulesetID
+}
+
+// deadLetter stores an event in the dead letter queue of the ruleset. When the queue cannot be
+// written the event goes to the error log, so it is still never dropped silently.
+func (r *Ruleset) deadLetter(rule *Rule, reason, cause string, event map[string]interface{}) {
+	entry := DeadLetter{Ruleset: r.RulesetID, Rule: rule.ID, Reason: reason, Error: cause, Event: event, Timestamp: time.Now()}
+	data, err := common.SafeMarshal(entry)
+	if err == nil {
+		err = deadLetterPush(DeadLetterKey(r.RulesetID), string(data), deadLetterMaxLen)
+	}
+	if err != nil {
+		logger.Error("Failed to write dead letter", "ruleset", r.RulesetID, "rule", rule.ID, "reason", reason, "cause", cause, "error", err,
+			common.ErrorLogContextKey, common.NewErrorLogContext(event))
+	}
+}
+
+// DeadLetters returns the newest dead letters of a ruleset, up to limit
+func DeadLetters(rulesetID string, limit int) ([]DeadLetter, error) {
+	items, err := common.RedisLRange(DeadLetterKey(rulesetID), 0, int64(limit)-1)
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]DeadLetter, 0, len(items))
+	for _, item := range items {
+		var letter DeadLetter
+		if err := sonic.Unmarshal([]byte(item), &letter); err != nil {
+			continue
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
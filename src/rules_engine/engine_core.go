@@ -16,6 +16,10 @@ import (
 
 const HitRuleIdFieldName = "_hub_hit_rule_id"
 
+// HitRuleNameFieldName holds the names of the hit rules, in the order of their IDs; rules without a
+// name are listed by ID
+const HitRuleNameFieldName = "_hub_hit_rule_name"
+
 // SIMD statistics variables
 var (
 	simdEnabled bool = false // SIMD enable flag, will be set from config
@@ -74,14 +78,14 @@ func (r *Ruleset) Start() error {
 		}
 	}()
 
-	// Allow restart from stopped state or from error state
-	if r.Status != common.StatusStopped && r.Status != common.StatusError {
+	// Allow restart from stopped state or from error state. The transition is atomic, so of two
+	// concurrent starts only one proceeds.
+	if err := r.SetStatus(common.StatusStarting, nil); err != nil {
 		return fmt.Errorf("cannot start ruleset engine, current status: %s", r.Status)
 	}
 
 	// Clear error state when restarting
 	r.Err = nil
-	r.SetStatus(common.StatusStarting, nil)
 
 	// Initialize regex result cache if not already initialized
 	if r.RegexResultCache == nil {
@@ -89,11 +93,14 @@ func (r *Ruleset) Start() error {
 	}
 
 	r.ResetProcessTotal()
+	r.resetRuleHits()
 	if r.stopChan != nil {
 		r.SetStatus(common.StatusError, fmt.Errorf("already started: %v", r.RulesetID))
 		return fmt.Errorf("already started: %v", r.RulesetID)
 	}
 	r.stopChan = make(chan struct{})
+	// The goroutines keep their own reference, cleanup clears the field when the ruleset stops
+	stopChan := r.stopChan
 
 	var err error
 	minPoolSize := getMinPoolSize()
@@ -111,7 +118,7 @@ func (r *Ruleset) Start() error {
 		maxPoolSize := getMaxPoolSize()
 		for {
 			select {
-			case <-r.stopChan:
+			case <-stopChan:
 				return
 			case <-ticker.C:
 				totalBacklog := 0
@@ -154,6 +161,25 @@ func (r *Ruleset) Start() error {
 		}
 	}()
 
+	if r.hasAggregateRules() && !r.isTestMode {
+		r.aggregator = newAlertAggregator()
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stopChan:
+					return
+				case now := <-ticker.C:
+					r.sendAggregates(now, false, 5*time.Second)
+				}
+			}
+		}()
+	}
+
+	guard := globalTaskGuard()
 	for upID, upCh := range r.UpStream {
 		go func(id string, ch *chan map[string]interface{}) {
 			defer func() {
@@ -166,36 +192,46 @@ func (r *Ruleset) Start() error {
 
 			for {
 				select {
-				case <-r.stopChan:
+				case <-stopChan:
 					return
 				case data, ok := <-*ch:
 					if !ok {
 						return
 					}
 
-					task := func() {
-						// Only count and sample in production mode (not test mode)
-						// Test mode flag is pre-computed during ruleset initialization for performance
-						if !r.isTestMode {
-							atomic.AddUint64(&r.processTotal, 1)
-							if r.sampler != nil {
-								_ = r.sampler.Sample(data, r.ProjectNodeSequence)
-							}
+					// Over its project budget the event is shed, leaving the global slots to other projects
+					size, ok := r.budget.tryAcquire(data)
+					if !ok {
+						if r.kafkaTxn != nil {
+							r.kafkaTxn.Done()
 						}
+						continue
+					}
 
-						// Now perform rule checking on the input data
-						results := r.EngineCheck(data)
-						// Send results to downstream channels - blocking to ensure no data loss
-						for _, res := range results {
-							for _, downCh := range r.DownStream {
-								*downCh <- res // Blocking write to ensure data integrity
-							}
+					task := func() {
+						defer r.budget.release(size)
+						var results []map[string]interface{}
+						guard.evaluateThenDeliver(
+							func() { results = r.checkAndSample(data) },
+							func() { r.deliver(data, results) })
+					}
+					if r.BatchSize > 1 {
+						// The events already queued behind this one share its pool task
+						events, sizes := r.fillBatch(*ch, data, size)
+						task = func() {
+							var results [][]map[string]interface{}
+							guard.evaluateThenDeliver(
+								func() { results = r.checkAndSampleBatch(events) },
+								func() { r.deliverBatch(events, sizes, results) })
 						}
 					}
 
+					// Hold a global slot while the task evaluates so all rulesets together stay within max_engine_tasks
+					guard.acquire()
+
 					// PERFORMANCE FIX: Improved task submission with backpressure handling
 					select {
-					case <-r.stopChan:
+					case <-stopChan:
 						// Ruleset is stopping, execute synchronously to not lose the message
 						logger.Info("Ruleset stopping, executing final task synchronously",
 							"ruleset", r.RulesetID)
@@ -214,7 +250,8 @@ func (r *Ruleset) Start() error {
 		}(upID, upCh)
 	}
 
-	r.SetStatus(common.StatusRunning, nil)
+	// Rules skipped under on_rule_error="skip" show up as the error of the running ruleset
+	r.SetStatus(common.StatusRunning, r.ruleErrorsSummary())
 	return nil
 }
 
@@ -239,7 +276,9 @@ func (r *Ruleset) Stop() error {
 		// For other states (e.g., StatusStarting), proceed with stop to ensure cleanup
 		logger.Debug("Stopping ruleset from non-running state", "ruleset", r.RulesetID, "current_status", r.Status)
 	}
-	r.SetStatus(common.StatusStopping, nil)
+	if err := r.SetStatus(common.StatusStopping, nil); err != nil {
+		return fmt.Errorf("cannot stop ruleset engine, current status: %s: %w", r.Status, err)
+	}
 
 	// Safely close stopChan if it exists and is not already closed
 	if r.stopChan != nil {
@@ -369,6 +408,9 @@ func (r *Ruleset) Stop() error {
 		}
 	}
 
+	// Windows still open are emitted early rather than lost
+	r.sendAggregates(time.Now(), true, 2*time.Second)
+
 	// Use cleanup to ensure all resources are properly released
 	r.cleanup()
 
@@ -382,8 +424,49 @@ func (r *Ruleset) Stop() error {
 	}
 }
 
+// checkAndSample counts and samples the event, then runs the rules on it
+func (r *Ruleset) checkAndSample(data map[string]interface{}) []map[string]interface{} {
+	// Only count and sample in production mode (not test mode)
+	// Test mode flag is pre-computed during ruleset initialization for performance
+	if r.isTestMode {
+		return r.EngineCheck(data)
+	}
+	atomic.AddUint64(&r.processTotal, 1)
+	if r.sampler == nil || r.Quiet {
+		return r.EngineCheck(data)
+	}
+	if r.sampleByOutcome() {
+		return r.checkAndSampleOutcome(data)
+	}
+
+	if !r.SampleTrace {
+		_ = r.sampler.Sample(data, r.ProjectNodeSequence)
+		return r.EngineCheck(data)
+	}
+	if !r.sampler.Claim(r.ProjectNodeSequence) {
+		return r.EngineCheck(data)
+	}
+	results, sample, trace := r.tracedCheck(data)
+	r.sampler.StoreClaimed(sample, trace, r.ProjectNodeSequence)
+	return results
+}
+
 // EngineCheck executes all rules in the ruleset on the provided data using the new flexible syntax.
 func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interface{} {
+	return r.engineCheck(data, nil)
+}
+
+// engineCheck runs the rules, recording rule and check node results into trace when it is not nil
+func (r *Ruleset) engineCheck(data map[string]interface{}, trace *EvalTrace) []map[string]interface{} {
+	ruleCache := ruleCachePool.Get().(map[string]common.CheckCoreCache)
+	result := r.evalRules(data, trace, ruleCache, r.resultCap())
+	// put back to pool
+	ruleCachePool.Put(ruleCache)
+	return result
+}
+
+// resultCap estimates the number of results of one event
+func (r *Ruleset) resultCap() int {
 	// Pre-allocate result slice with better capacity estimation
 	var initialCap int
 	if r.IsDetection {
@@ -396,8 +479,12 @@ func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interfac
 		// For exclude rules, usually only 1 result
 		initialCap = 1
 	}
+	return initialCap
+}
+
+// evalRules runs the rules on one event with a rule cache of the caller, cleared first
+func (r *Ruleset) evalRules(data map[string]interface{}, trace *EvalTrace, ruleCache map[string]common.CheckCoreCache, initialCap int) []map[string]interface{} {
 	finalRes := make([]map[string]interface{}, 0, initialCap)
-	ruleCache := ruleCachePool.Get().(map[string]common.CheckCoreCache)
 
 	// More efficient cache clearing - only clear if not empty
 	if len(ruleCache) > 0 {
@@ -407,13 +494,16 @@ func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interfac
 		}
 	}
 
+	if trace != nil {
+		ruleCache[traceCacheKey] = common.CheckCoreCache{Exist: true, TypedData: trace}
+	}
+
 	// For exclude, keep track of the last modified data
 	var lastModifiedData map[string]interface{}
 
 	// For empty exclude, data should pass through
 	if !r.IsDetection && len(r.Rules) == 0 {
 		// Empty exclude means all data passes through
-		ruleCachePool.Put(ruleCache)
 		// Reuse the same slice pattern for consistency
 		result := make([]map[string]interface{}, 1)
 		result[0] = data
@@ -424,6 +514,19 @@ func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interfac
 	for ruleIndex := range r.Rules {
 		rule := &r.Rules[ruleIndex] // Use pointer to avoid copying
 
+		// An event missing a required field skips the rule: it does not match and runs no operation
+		if len(rule.RequiresList) > 0 {
+			if missing := rule.missingRequiredField(data); missing != "" {
+				if trace != nil {
+					trace.Rules = append(trace.Rules, RuleTrace{ID: rule.ID, MissingField: missing})
+				}
+				if !r.IsDetection && lastModifiedData == nil {
+					lastModifiedData = data
+				}
+				continue
+			}
+		}
+
 		// Create data copy for this rule execution only if rule modifies data
 		var dataCopy map[string]interface{}
 		if r.ruleModifiesData(rule) {
@@ -432,9 +535,34 @@ func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interfac
 			dataCopy = data // Use original data if rule doesn't modify it
 		}
 
+		if trace != nil {
+			trace.Rules = append(trace.Rules, RuleTrace{ID: rule.ID})
+		}
+
 		// Execute all operations in the order specified by the Queue
 		ruleCheckRes := r.executeRuleOperations(rule, dataCopy, ruleCache)
 
+		if trace != nil {
+			trace.Rules[len(trace.Rules)-1].Matched = ruleCheckRes
+		}
+
+		// A check with on_error="error" failed: the event leaves the ruleset for its dead letter queue
+		if routed, ok := ruleCache[onErrorRouteCacheKey]; ok {
+			if !r.isTestMode {
+				r.deadLetter(rule, DeadLetterCheckFailed, routed.Data, data)
+			}
+			return make([]map[string]interface{}, 0)
+		}
+
+		// A cooling rule matches but does not fire
+		if ruleCheckRes && rule.Cooldown != nil && !r.startCooldown(rule, dataCopy, ruleCache) {
+			ruleCheckRes = false
+		}
+
+		if ruleCheckRes && !r.isTestMode && !r.Quiet {
+			r.countRuleHit(ruleIndex)
+		}
+
 		// Handle rule result based on ruleset type
 		if r.IsDetection {
 			// For detection rules, if rule passes, add to results
@@ -448,8 +576,18 @@ func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interfac
 				sb.WriteString(rule.ID)
 				addHitRuleID(dataCopy, sb.String())
 				stringBuilderPool.Put(sb)
-				// Add to final result
-				finalRes = append(finalRes, dataCopy)
+				addHitRuleName(dataCopy, rule)
+				if rule.Aggregate != nil && r.aggregator != nil {
+					// Emitted as one alert when the group's window ends
+					r.aggregator.add(rule, dataCopy, ruleCache, time.Now())
+				} else {
+					// Add to final result
+					finalRes = append(finalRes, dataCopy)
+				}
+				// first_match: the later rules are lower priority and are skipped for this event
+				if r.FirstMatch {
+					break
+				}
 			}
 		} else {
 			// For exclude rules
@@ -458,7 +596,6 @@ func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interfac
 
 			if ruleCheckRes {
 				// If exclude rule passes, data is excluded (filtered) - don't pass forward (return empty)
-				ruleCachePool.Put(ruleCache)
 				return make([]map[string]interface{}, 0)
 			}
 		}
@@ -469,10 +606,6 @@ func (r *Ruleset) EngineCheck(data map[string]interface{}) []map[string]interfac
 		finalRes = append(finalRes, lastModifiedData)
 	}
 
-	// put back to pool
-	ruleCachePool.Put(ruleCache)
-	ruleCache = nil
-
 	// Create a copy of the result to return, since we're using a pooled slice
 	result := make([]map[string]interface{}, len(finalRes))
 	copy(result, finalRes)
@@ -566,6 +699,9 @@ func (r *Ruleset) executeCheckList(rule *Rule, operationID int, data map[string]
 
 		if checklist.ConditionFlag {
 			conditionMap[checkNode.ID] = checkResult
+			if checkNode.Ref != "" {
+				conditionMap[checkNode.Ref] = checkResult
+			}
 		} else {
 			// Simple AND logic for non-condition checklists
 			if !checkResult {
@@ -622,28 +758,47 @@ func (r *Ruleset) executeCheck(rule *Rule, operationID int, data map[string]inte
 
 // executeCheckNode executes a single check node
 func (r *Ruleset) executeCheckNode(checkNode *CheckNodes, data map[string]interface{}, ruleCache map[string]common.CheckCoreCache) bool {
+	res := r.evalCheckNode(checkNode, data, ruleCache)
+	if r.SampleTrace {
+		recordNodeTrace(ruleCache, checkNode, res)
+	}
+	return res
+}
+
+// evalCheckNode evaluates a check node, expanding AND/OR delimited values
+func (r *Ruleset) evalCheckNode(checkNode *CheckNodes, data map[string]interface{}, ruleCache map[string]common.CheckCoreCache) bool {
 	var checkNodeValue string
 	var checkNodeValueFromRaw bool
 
+	// A value referencing a missing field (_$field) never matches, instead of comparing against "".
+	// Rulesets pinned to engine_version 1 keep comparing against "".
+	var exist bool
+	missingRefAsEmpty := r.EngineVersion == EngineVersion1
 	switch checkNode.Logic {
 	case "":
 		if hasFromRawPrefix(checkNode.Value) {
-			checkNodeValue = GetRuleValueFromRawFromCache(ruleCache, checkNode.Value, data)
+			checkNodeValue, exist = GetRuleValueFromRawWithExist(ruleCache, checkNode.Value, data)
+			if !exist && !missingRefAsEmpty {
+				return false
+			}
 			checkNodeValueFromRaw = true
 		} else {
 			checkNodeValue = checkNode.Value
 		}
-		return checkNodeLogic(checkNode, data, checkNodeValue, checkNodeValueFromRaw, ruleCache, r.RegexResultCache)
+		return r.checkNodeLogic(checkNode, data, checkNodeValue, checkNodeValueFromRaw, ruleCache, r.RegexResultCache)
 	case "AND":
 		for _, v := range checkNode.DelimiterFieldList {
 			if hasFromRawPrefix(v) {
-				checkNodeValue = GetRuleValueFromRawFromCache(ruleCache, v, data)
+				checkNodeValue, exist = GetRuleValueFromRawWithExist(ruleCache, v, data)
+				if !exist && !missingRefAsEmpty {
+					return false
+				}
 				checkNodeValueFromRaw = true
 			} else {
 				checkNodeValue = v
 				checkNodeValueFromRaw = false
 			}
-			if !checkNodeLogic(checkNode, data, checkNodeValue, checkNodeValueFromRaw, ruleCache, r.RegexResultCache) {
+			if !r.checkNodeLogic(checkNode, data, checkNodeValue, checkNodeValueFromRaw, ruleCache, r.RegexResultCache) {
 				return false
 			}
 		}
@@ -651,13 +806,16 @@ func (r *Ruleset) executeCheckNode(checkNode *CheckNodes, data map[string]interf
 	case "OR":
 		for _, v := range checkNode.DelimiterFieldList {
 			if hasFromRawPrefix(v) {
-				checkNodeValue = GetRuleValueFromRawFromCache(ruleCache, v, data)
+				checkNodeValue, exist = GetRuleValueFromRawWithExist(ruleCache, v, data)
+				if !exist && !missingRefAsEmpty {
+					continue
+				}
 				checkNodeValueFromRaw = true
 			} else {
 				checkNodeValue = v
 				checkNodeValueFromRaw = false
 			}
-			if checkNodeLogic(checkNode, data, checkNodeValue, checkNodeValueFromRaw, ruleCache, r.RegexResultCache) {
+			if r.checkNodeLogic(checkNode, data, checkNodeValue, checkNodeValueFromRaw, ruleCache, r.RegexResultCache) {
 				return true
 			}
 		}
@@ -678,10 +836,12 @@ func (r *Ruleset) executeThreshold(rule *Rule, operationID int, data map[string]
 	// Use strings.Builder pool for better performance
 	sb := stringBuilderPool.Get().(*strings.Builder)
 	sb.Reset()
+	sb.WriteString(r.stateNamespace)
 	sb.WriteString(threshold.GroupByID)
 
-	for k, v := range threshold.GroupByList {
-		tmpData, _ := GetCheckDataFromCache(ruleCache, k, data, v)
+	// Fields are taken in sorted order, so every event and every node builds the same key for a group
+	for _, k := range sortedGroupByFields(threshold.GroupByList) {
+		tmpData, _ := GetCheckDataFromCache(ruleCache, k, data, threshold.GroupByList[k])
 		sb.WriteString(tmpData)
 	}
 	groupByKey := common.XXHash64(sb.String())
@@ -689,6 +849,8 @@ func (r *Ruleset) executeThreshold(rule *Rule, operationID int, data map[string]
 
 	var ruleCheckRes bool
 	var err error
+	// count is the count or sum reached, recorded in fire_count_field when the threshold fires
+	var count int
 
 	switch threshold.CountType {
 	case "":
@@ -701,9 +863,9 @@ func (r *Ruleset) executeThreshold(rule *Rule, operationID int, data map[string]
 		stringBuilderPool.Put(sb)
 
 		if threshold.LocalCache {
-			ruleCheckRes, err = r.LocalCacheFRQSum(prefixedKey, 1, threshold.RangeInt, threshold.Value)
+			ruleCheckRes, count, err = r.LocalCacheFRQSum(prefixedKey, 1, threshold.RangeInt, threshold.Value)
 		} else {
-			ruleCheckRes, err = RedisFRQSum(prefixedKey, 1, threshold.RangeInt, threshold.Value)
+			ruleCheckRes, count, err = RedisFRQSum(prefixedKey, 1, threshold.RangeInt, threshold.Value)
 		}
 
 	case "SUM":
@@ -726,9 +888,9 @@ func (r *Ruleset) executeThreshold(rule *Rule, operationID int, data map[string]
 		}
 
 		if threshold.LocalCache {
-			ruleCheckRes, err = r.LocalCacheFRQSum(prefixedKey, sumData, threshold.RangeInt, threshold.Value)
+			ruleCheckRes, count, err = r.LocalCacheFRQSum(prefixedKey, sumData, threshold.RangeInt, threshold.Value)
 		} else {
-			ruleCheckRes, err = RedisFRQSum(prefixedKey, sumData, threshold.RangeInt, threshold.Value)
+			ruleCheckRes, count, err = RedisFRQSum(prefixedKey, sumData, threshold.RangeInt, threshold.Value)
 		}
 
 	case "CLASSIFY":
@@ -751,10 +913,21 @@ func (r *Ruleset) executeThreshold(rule *Rule, operationID int, data map[string]
 		tmpKey := sb.String()
 		stringBuilderPool.Put(sb)
 
+		var values []string
 		if threshold.LocalCache {
-			ruleCheckRes, err = r.LocalCacheFRQClassify(tmpKey, prefixedKey, threshold.RangeInt, threshold.Value)
+			ruleCheckRes, values, err = r.LocalCacheFRQClassify(tmpKey, prefixedKey, classifyData, threshold.RangeInt, threshold.Value)
 		} else {
-			ruleCheckRes, err = RedisFRQClassify(tmpKey, prefixedKey, threshold.RangeInt, threshold.Value)
+			ruleCheckRes, values, err = RedisFRQClassify(prefixedKey, classifyData, threshold.RangeInt, threshold.Value)
+		}
+		if ruleCheckRes && err == nil {
+			// Show which values made the threshold fire, only when the threshold asks for them
+			if threshold.ClassifyValuesField != "" {
+				data[threshold.ClassifyValuesField] = values
+			}
+			if threshold.ClassifyCountField != "" {
+				data[threshold.ClassifyCountField] = len(values)
+			}
+			count = len(values)
 		}
 	}
 
@@ -763,6 +936,10 @@ func (r *Ruleset) executeThreshold(rule *Rule, operationID int, data map[string]
 		return false
 	}
 
+	if ruleCheckRes && threshold.FireCountField != "" {
+		data[threshold.FireCountField] = count
+	}
+
 	return ruleCheckRes
 }
 
@@ -773,6 +950,15 @@ func (r *Ruleset) executeAppend(rule *Rule, operationID int, dataCopy map[string
 		return
 	}
 
+	if appendOp.Type == AppendMerge {
+		r.executeMergeAppend(&appendOp, dataCopy, ruleCache)
+		return
+	}
+	if appendOp.Type == AppendDecode {
+		r.executeDecodeAppend(&appendOp, dataCopy, ruleCache)
+		return
+	}
+
 	if appendOp.Type == "" {
 		appendData := appendOp.Value
 		if hasFromRawPrefix(appendOp.Value) {
@@ -787,7 +973,7 @@ func (r *Ruleset) executeAppend(rule *Rule, operationID int, dataCopy map[string
 		// Check plugin return type to determine which evaluation method to use
 		if appendOp.Plugin.ReturnType == "bool" {
 			// For check-type plugins (bool return type), use FuncEvalCheckNode and get the boolean result
-			boolResult, err := appendOp.Plugin.FuncEvalCheckNode(args...)
+			boolResult, err := r.evalPluginCheck(appendOp.Plugin, args)
 			if err == nil {
 				dataCopy[appendOp.FieldName] = boolResult
 			} else {
@@ -795,7 +981,7 @@ func (r *Ruleset) executeAppend(rule *Rule, operationID int, dataCopy map[string
 			}
 		} else {
 			// For interface{} type plugins, use the original FuncEvalOther logic
-			res, ok, err := appendOp.Plugin.FuncEvalOther(args...)
+			res, ok, err := r.evalPluginOther(appendOp.Plugin, args)
 			if err == nil && ok {
 				if appendOp.FieldName == PluginArgFromRawSymbol {
 					if r, ok := res.(map[string]interface{}); ok {
@@ -1023,13 +1209,20 @@ func (r *Ruleset) executeIteratorThreshold(threshold *Threshold, data map[string
 }
 
 // checkNodeLogic executes the check logic for a single check node.
-func checkNodeLogic(checkNode *CheckNodes, data map[string]interface{}, checkNodeValue string, checkNodeValueFromRaw bool, ruleCache map[string]common.CheckCoreCache, regexResultCache *RegexResultCache) bool {
-	var checkListFlag = false
+func (r *Ruleset) checkNodeLogic(checkNode *CheckNodes, data map[string]interface{}, checkNodeValue string, checkNodeValueFromRaw bool, ruleCache map[string]common.CheckCoreCache, regexResultCache *RegexResultCache) bool {
+	if checkNode.Match != "" {
+		return r.checkElements(checkNode, data, checkNodeValue, checkNodeValueFromRaw, ruleCache, regexResultCache)
+	}
 
 	needCheckData, exist := common.GetCheckData(data, checkNode.FieldList)
 
 	// CRITICAL FIX: Handle field existence properly for ISNULL and NOTNULL checks
+	// Non-strict (default): an absent key, a JSON null and an empty/whitespace-only value are all null.
+	// Strict: only an absent key (or JSON null) is null; an empty value is present and therefore not null.
 	if checkNode.Type == "ISNULL" {
+		if checkNode.Strict {
+			return !exist
+		}
 		// For ISNULL: field doesn't exist OR field exists but is empty (including whitespace-only)
 		if !exist || strings.TrimSpace(needCheckData) == "" {
 			return true
@@ -1039,6 +1232,9 @@ func checkNodeLogic(checkNode *CheckNodes, data map[string]interface{}, checkNod
 	}
 
 	if checkNode.Type == "NOTNULL" {
+		if checkNode.Strict {
+			return exist
+		}
 		// For NOTNULL: field must exist AND not be empty (including whitespace-only)
 		if !exist || strings.TrimSpace(needCheckData) == "" {
 			return false
@@ -1052,6 +1248,28 @@ func checkNodeLogic(checkNode *CheckNodes, data map[string]interface{}, checkNod
 		return false
 	}
 
+	if checkNode.Type == "PLUGIN" {
+		args := GetPluginRealArgs(checkNode.PluginArgs, data, ruleCache)
+		result, err := r.evalPluginCheck(checkNode.Plugin, args)
+		if err != nil {
+			return checkErrorResult(checkNode, ruleCache, err)
+		}
+
+		// Check if plugin function should be negated (starts with !)
+		if checkNode.IsNegated {
+			return !result
+		}
+
+		return result
+	}
+
+	return r.compareCheckData(checkNode, needCheckData, checkNodeValue, checkNodeValueFromRaw, ruleCache, regexResultCache)
+}
+
+// compareCheckData compares the field data against the check value according to the check type
+func (r *Ruleset) compareCheckData(checkNode *CheckNodes, needCheckData string, checkNodeValue string, checkNodeValueFromRaw bool, ruleCache map[string]common.CheckCoreCache, regexResultCache *RegexResultCache) bool {
+	var checkListFlag = false
+
 	switch checkNode.Type {
 	case "REGEX":
 		if !checkNodeValueFromRaw {
@@ -1063,24 +1281,10 @@ func checkNodeLogic(checkNode *CheckNodes, data map[string]interface{}, checkNod
 			// This maintains the same behavior as original
 			regex, err := GetCompiledRegex(checkNodeValue)
 			if err != nil {
-				break
+				return checkErrorResult(checkNode, ruleCache, err)
 			}
 			checkListFlag, _ = REGEX(needCheckData, regex)
 		}
-	case "PLUGIN":
-		args := GetPluginRealArgs(checkNode.PluginArgs, data, ruleCache)
-		result, err := checkNode.Plugin.FuncEvalCheckNode(args...)
-		if err != nil {
-			return false
-		}
-
-		// Check if plugin function should be negated (starts with !)
-		if checkNode.IsNegated {
-			return !result
-		}
-
-		return result
-
 	default:
 		// SIMD optimization path: intelligently choose whether to use SIMD
 		if shouldUseSIMD(checkNode.Type, needCheckData, checkNodeValue) {
@@ -1110,16 +1314,49 @@ func checkNodeLogic(checkNode *CheckNodes, data map[string]interface{}, checkNod
 	return checkListFlag
 }
 
+// onErrorRouteCacheKey marks in the per-event rule cache that a check asked to route the event to
+// the dead letter queue. Field paths never start with a NUL byte, so it cannot collide with cached fields.
+const onErrorRouteCacheKey = "\x00on_error_route"
+
+// checkErrorResult resolves a failed PLUGIN/REGEX evaluation according to the node's on_error mode
+func checkErrorResult(checkNode *CheckNodes, ruleCache map[string]common.CheckCoreCache, err error) bool {
+	switch checkNode.OnError {
+	case OnErrorMatch:
+		return true
+	case OnErrorError:
+		if _, marked := ruleCache[onErrorRouteCacheKey]; !marked {
+			ruleCache[onErrorRouteCacheKey] = common.CheckCoreCache{Exist: true, Data: err.Error()}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
 // addHitRuleID appends the hit rule ID to the data map.
 func addHitRuleID(data map[string]interface{}, ruleID string) {
+	appendHitValue(data, HitRuleIdFieldName, ruleID)
+}
+
+// addHitRuleName appends the name of the hit rule, or its ID when it has none, to the data map
+func addHitRuleName(data map[string]interface{}, rule *Rule) {
+	name := rule.Name
+	if name == "" {
+		name = rule.ID
+	}
+	appendHitValue(data, HitRuleNameFieldName, name)
+}
+
+// appendHitValue appends value to the comma separated list in field
+func appendHitValue(data map[string]interface{}, field, value string) {
 	// data is guaranteed to be non-nil when called from EngineCheck
-	if existingID, ok := data[HitRuleIdFieldName]; !ok {
-		data[HitRuleIdFieldName] = ruleID
+	if existing, ok := data[field]; !ok {
+		data[field] = value
 	} else {
-		// Check if this is the same rule ID to avoid duplication
-		existingStr := existingID.(string)
-		if existingStr == ruleID {
-			// Same rule ID, don't duplicate
+		// Check if this is the same value to avoid duplication
+		existingStr, _ := existing.(string)
+		if existingStr == value {
+			// Same value, don't duplicate
 			return
 		}
 		// Use strings.Builder pool for efficient string concatenation
@@ -1127,8 +1364,8 @@ func addHitRuleID(data map[string]interface{}, ruleID string) {
 		sb.Reset()
 		sb.WriteString(existingStr)
 		sb.WriteString(",")
-		sb.WriteString(ruleID)
-		data[HitRuleIdFieldName] = sb.String()
+		sb.WriteString(value)
+		data[field] = sb.String()
 		stringBuilderPool.Put(sb)
 	}
 }
@@ -1171,6 +1408,16 @@ func (r *Ruleset) ruleModifiesData(rule *Rule) bool {
 		switch op.Type {
 		case T_Append, T_Del, T_Plugin:
 			return true // These operations modify data
+		case T_Threshold:
+			if threshold, ok := rule.ThresholdMap[op.ID]; ok && threshold.recordsOnFire() {
+				return true
+			}
+		case T_CheckList:
+			for _, threshold := range rule.ChecklistMap[op.ID].ThresholdNodes {
+				if threshold.recordsOnFire() {
+					return true
+				}
+			}
 		}
 	}
 	return false
@@ -16,6 +16,8 @@ import (
 type XMLDecoder struct {
 	*xml.Decoder
 	line int
+
+	engineVersion int // root engine_version, 0 when not declared
 }
 
 // NewXMLDecoder creates a new XMLDecoder
@@ -37,6 +39,10 @@ func (d *XMLDecoder) Token() (xml.Token, error) {
 	switch t := token.(type) {
 	case xml.CharData:
 		d.line += strings.Count(string(t), "\n")
+	case xml.StartElement:
+		if err := d.checkElementVersion(t); err != nil {
+			return token, err
+		}
 	}
 
 	return token, nil
@@ -54,18 +60,8 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 	var operatorIDCounter int
 	var currentLine int = 1
 
-	for {
-		// Track current line before getting token
-		currentLine = decoder.line
-
-		token, err := decoder.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("error parsing XML at line %d: %v", currentLine, err)
-		}
-
+	// handleToken applies one token to the ruleset being built
+	handleToken := func(token xml.Token, currentLine int) error {
 		switch element := token.(type) {
 		case xml.StartElement:
 			// Store line number for this element
@@ -78,7 +74,7 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 					switch attr.Name.Local {
 					case "type":
 						if attr.Value != "DETECTION" && attr.Value != "EXCLUDE" {
-							return nil, fmt.Errorf("root type must be 'DETECTION' or 'EXCLUDE', got '%s' at line %d", attr.Value, elementLine)
+							return fmt.Errorf("root type must be 'DETECTION' or 'EXCLUDE', got '%s' at line %d", attr.Value, elementLine)
 						}
 						ruleset.Type = attr.Value
 						ruleset.IsDetection = strings.ToUpper(attr.Value) == "DETECTION"
@@ -86,8 +82,60 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 						ruleset.Name = attr.Value
 					case "author":
 						ruleset.Author = attr.Value
+					case "engine_version":
+						// Validated by the decoder, which applies it to the elements that follow
+						ruleset.EngineVersion = decoder.engineVersion
+					case "sample_trace":
+						v, err := strconv.ParseBool(attr.Value)
+						if err != nil {
+							return fmt.Errorf("root sample_trace must be 'true' or 'false', got '%s' at line %d", attr.Value, elementLine)
+						}
+						ruleset.SampleTrace = v
+					case "on_rule_error":
+						if attr.Value != OnRuleErrorFail && attr.Value != OnRuleErrorSkip {
+							return fmt.Errorf("root on_rule_error must be '%s' or '%s', got '%s' at line %d", OnRuleErrorFail, OnRuleErrorSkip, attr.Value, elementLine)
+						}
+						ruleset.OnRuleError = attr.Value
+					case "quiet":
+						v, err := strconv.ParseBool(attr.Value)
+						if err != nil {
+							return fmt.Errorf("root quiet must be 'true' or 'false', got '%s' at line %d", attr.Value, elementLine)
+						}
+						ruleset.Quiet = v
+					case "sample":
+						if attr.Value != SampleAll && attr.Value != SampleMatched && attr.Value != SampleUnmatched {
+							return fmt.Errorf("root sample must be '%s', '%s' or '%s', got '%s' at line %d", SampleAll, SampleMatched, SampleUnmatched, attr.Value, elementLine)
+						}
+						ruleset.SampleMode = attr.Value
+					case "plugin_cache":
+						v, err := strconv.Atoi(attr.Value)
+						if err != nil || v < 0 || v > MaxPluginCacheSize {
+							return fmt.Errorf("root plugin_cache must be a number of cached results between 0 and %d, got '%s' at line %d", MaxPluginCacheSize, attr.Value, elementLine)
+						}
+						ruleset.PluginCacheSize = v
+					case "first_match":
+						v, err := strconv.ParseBool(attr.Value)
+						if err != nil {
+							return fmt.Errorf("root first_match must be 'true' or 'false', got '%s' at line %d", attr.Value, elementLine)
+						}
+						ruleset.FirstMatch = v
+					case "batch_size":
+						v, err := strconv.Atoi(attr.Value)
+						if err != nil || v < 0 || v > MaxBatchSize {
+							return fmt.Errorf("root batch_size must be a number of events between 0 and %d, got '%s' at line %d", MaxBatchSize, attr.Value, elementLine)
+						}
+						ruleset.BatchSize = v
 					}
 				}
+				if ruleset.FirstMatch && !ruleset.IsDetection {
+					return fmt.Errorf("root first_match only applies to DETECTION rulesets, EXCLUDE rulesets already stop at the first match at line %d", elementLine)
+				}
+				if ruleset.Quiet && ruleset.SampleTrace {
+					return fmt.Errorf("root quiet disables sampling and cannot be combined with sample_trace at line %d", elementLine)
+				}
+				if ruleset.Quiet && ruleset.SampleMode != "" && ruleset.SampleMode != SampleAll {
+					return fmt.Errorf("root quiet disables sampling and cannot be combined with sample at line %d", elementLine)
+				}
 
 			case "rule":
 				// Start a new rule
@@ -107,16 +155,28 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 					switch attr.Name.Local {
 					case "id":
 						if strings.TrimSpace(attr.Value) == "" {
-							return nil, fmt.Errorf("rule id cannot be empty at line %d", elementLine)
+							return fmt.Errorf("rule id cannot be empty at line %d", elementLine)
 						}
 						currentRule.ID = attr.Value
 					case "name":
 						currentRule.Name = attr.Value
+					case "scope":
+						scope := strings.TrimSpace(attr.Value)
+						if err := validateRuleScope(scope); err != nil {
+							return fmt.Errorf("%v at line %d", err, elementLine)
+						}
+						currentRule.Scope = scope
+					case "group":
+						group := strings.TrimSpace(attr.Value)
+						if err := validateRuleGroup(group); err != nil {
+							return fmt.Errorf("%v at line %d", err, elementLine)
+						}
+						currentRule.Group = group
 					}
 				}
 
 				if currentRule.ID == "" {
-					return nil, fmt.Errorf("rule id is required at line %d", elementLine)
+					return fmt.Errorf("rule id is required at line %d", elementLine)
 				}
 
 			case "checklist":
@@ -131,11 +191,11 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 						if attr.Name.Local == "condition" {
 							condition := strings.TrimSpace(attr.Value)
 							if condition == "" {
-								return nil, fmt.Errorf("checklist condition cannot be empty at line %d", elementLine)
+								return fmt.Errorf("checklist condition cannot be empty at line %d", elementLine)
 							}
 							// Validate condition syntax
 							if _, _, ok := ConditionRegex.Find(condition); !ok {
-								return nil, fmt.Errorf("checklist condition is not a valid expression: %s at line %d", condition, elementLine)
+								return fmt.Errorf("checklist condition is not a valid expression: %s at line %d", condition, elementLine)
 							}
 							currentChecklist.Condition = condition
 							currentChecklist.ConditionFlag = true
@@ -149,7 +209,7 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 				if currentRule != nil {
 					checkNode, err := parseCheckNode(element, decoder, elementLine)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					if inChecklist && currentChecklist != nil {
@@ -170,7 +230,7 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 				if currentRule != nil {
 					threshold, err := parseThreshold(element, decoder, elementLine)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					if inChecklist && currentChecklist != nil {
@@ -190,7 +250,7 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 				if currentRule != nil {
 					iterator, err := parseIterator(element, decoder, elementLine)
 					if err != nil {
-						return nil, err
+						return err
 					}
 					operatorIDCounter++
 					currentRule.IteratorMap[operatorIDCounter] = iterator
@@ -204,7 +264,7 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 				if currentRule != nil {
 					appendOp, err := parseAppend(element, decoder, elementLine)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					operatorIDCounter++
@@ -219,7 +279,7 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 				if currentRule != nil {
 					plugin, err := parsePlugin(element, decoder, elementLine)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					operatorIDCounter++
@@ -234,7 +294,7 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 				if currentRule != nil {
 					delFields, err := parseDel(element, decoder, elementLine)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					operatorIDCounter++
@@ -245,22 +305,83 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 					})
 				}
 
+			case "aggregate":
+				if currentRule != nil {
+					if ruleset.Type == "EXCLUDE" {
+						return fmt.Errorf("aggregate is only supported in DETECTION rulesets at line %d", elementLine)
+					}
+					if currentRule.Aggregate != nil {
+						return fmt.Errorf("rule '%s' has more than one aggregate at line %d", currentRule.ID, elementLine)
+					}
+					agg, err := parseAggregate(element, elementLine)
+					if err != nil {
+						return err
+					}
+					currentRule.Aggregate = agg
+				}
+
+			case "cooldown":
+				if currentRule != nil {
+					if ruleset.Type == "EXCLUDE" {
+						return fmt.Errorf("cooldown is only supported in DETECTION rulesets at line %d", elementLine)
+					}
+					if currentRule.Cooldown != nil {
+						return fmt.Errorf("rule '%s' has more than one cooldown at line %d", currentRule.ID, elementLine)
+					}
+					cd, err := parseCooldown(element, elementLine)
+					if err != nil {
+						return err
+					}
+					currentRule.Cooldown = cd
+				}
+
+			case "requires":
+				if currentRule != nil {
+					if currentRule.Requires != nil {
+						return fmt.Errorf("rule '%s' has more than one requires at line %d", currentRule.ID, elementLine)
+					}
+					if inChecklist {
+						return fmt.Errorf("requires applies to the whole rule and cannot be inside a checklist in rule '%s' at line %d", currentRule.ID, elementLine)
+					}
+					fields, err := parseRequires(element, decoder, elementLine)
+					if err != nil {
+						return err
+					}
+					currentRule.Requires = fields
+					currentRule.RequiresList = make([][]string, len(fields))
+					for i, field := range fields {
+						currentRule.RequiresList[i] = common.StringToList(field)
+					}
+				} else {
+					return fmt.Errorf("requires is only allowed inside a rule at line %d", elementLine)
+				}
+
+			case "test":
+				if currentRule != nil {
+					return fmt.Errorf("test is only allowed at root level, found in rule '%s' at line %d", currentRule.ID, elementLine)
+				}
+				test, err := parseInlineTest(element, decoder, elementLine, len(ruleset.Tests))
+				if err != nil {
+					return err
+				}
+				ruleset.Tests = append(ruleset.Tests, test)
+
 			default:
 				// Handle unsupported elements
 				if currentRule != nil {
 					// Inside a rule, check for common mistakes
 					if element.Name.Local == "node" {
-						return nil, fmt.Errorf("unsupported element '<%s>' in rule '%s' at line %d. The 'node' tag has been deprecated, please use 'check' instead", element.Name.Local, currentRule.ID, elementLine)
+						return fmt.Errorf("unsupported element '<%s>' in rule '%s' at line %d. The 'node' tag has been deprecated, please use 'check' instead", element.Name.Local, currentRule.ID, elementLine)
 					} else if element.Name.Local == "filter" {
-						return nil, fmt.Errorf("unsupported element '<%s>' in rule '%s' at line %d. The 'filter' tag has been removed in the new syntax", element.Name.Local, currentRule.ID, elementLine)
+						return fmt.Errorf("unsupported element '<%s>' in rule '%s' at line %d. The 'filter' tag has been removed in the new syntax, use a '<check type=\"...\" field=\"...\">' as the first node of the rule instead: checks run in order and stop at the first mismatch", element.Name.Local, currentRule.ID, elementLine)
 					} else if inChecklist {
-						return nil, fmt.Errorf("unsupported element '<%s>' inside checklist in rule '%s' at line %d", element.Name.Local, currentRule.ID, elementLine)
+						return fmt.Errorf("unsupported element '<%s>' inside checklist in rule '%s' at line %d", element.Name.Local, currentRule.ID, elementLine)
 					} else {
-						return nil, fmt.Errorf("unsupported element '<%s>' in rule '%s' at line %d", element.Name.Local, currentRule.ID, elementLine)
+						return fmt.Errorf("unsupported element '<%s>' in rule '%s' at line %d", element.Name.Local, currentRule.ID, elementLine)
 					}
 				} else {
 					// Outside of rules, only certain elements are allowed at root level
-					return nil, fmt.Errorf("unsupported element '<%s>' at root level at line %d", element.Name.Local, elementLine)
+					return fmt.Errorf("unsupported element '<%s>' at root level at line %d", element.Name.Local, elementLine)
 				}
 			}
 
@@ -286,6 +407,34 @@ func ParseRuleset(rawRuleset []byte) (*Ruleset, error) {
 				}
 			}
 		}
+		return nil
+	}
+
+	for {
+		// Track current line before getting token
+		currentLine = decoder.line
+
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing XML at line %d: %v", currentLine, err)
+		}
+
+		if err := handleToken(token, currentLine); err != nil {
+			if ruleset.OnRuleError != OnRuleErrorSkip || currentRule == nil || currentRule.ID == "" {
+				return nil, err
+			}
+			// Drop the rule and continue after its end tag, RulesetBuild reports it with the build errors
+			ruleset.RuleErrors = append(ruleset.RuleErrors, RuleBuildError{RuleID: currentRule.ID, Error: err.Error()})
+			if err := skipToRuleEnd(decoder); err != nil {
+				return nil, fmt.Errorf("error parsing XML at line %d: %v", decoder.line, err)
+			}
+			currentRule = nil
+			currentChecklist = nil
+			inChecklist = false
+		}
 	}
 
 	ruleset.RulesCount = len(ruleset.Rules)
@@ -477,6 +626,29 @@ func parseCheckNode(element xml.StartElement, decoder *XMLDecoder, elementLine i
 			checkNode.Logic = logic
 		case "delimiter":
 			checkNode.Delimiter = attr.Value
+		case "strict":
+			switch strings.ToLower(strings.TrimSpace(attr.Value)) {
+			case "true":
+				checkNode.Strict = true
+			case "false", "":
+				checkNode.Strict = false
+			default:
+				return checkNode, fmt.Errorf("check strict must be 'true' or 'false', got '%s' at line %d", attr.Value, elementLine)
+			}
+		case "on_error":
+			onError := strings.TrimSpace(attr.Value)
+			if onError != "" && !isValidOnError(onError) {
+				return checkNode, fmt.Errorf("check on_error must be 'match', 'nomatch' or 'error', got '%s' at line %d", attr.Value, elementLine)
+			}
+			checkNode.OnError = onError
+		case "as":
+			as := strings.TrimSpace(attr.Value)
+			if as != "" && as != CheckAsTime {
+				return checkNode, fmt.Errorf("check as must be 'time', got '%s' at line %d", attr.Value, elementLine)
+			}
+			checkNode.As = as
+		case "match":
+			checkNode.Match = strings.TrimSpace(attr.Value)
 		}
 	}
 
@@ -504,6 +676,17 @@ func parseCheckNode(element xml.StartElement, decoder *XMLDecoder, elementLine i
 		case xml.EndElement:
 			if t.Name.Local == "check" {
 				// Additional validation
+				if err := checkNodeFieldRefError(&checkNode); err != nil {
+					return checkNode, fmt.Errorf("invalid check value at line %d: %v", elementLine, err)
+				}
+				if err := checkMatchError(&checkNode); err != nil {
+					return checkNode, fmt.Errorf("invalid check at line %d: %v", elementLine, err)
+				}
+				if checkNode.As == CheckAsTime {
+					if err := timeCheckValueError(&checkNode); err != nil {
+						return checkNode, fmt.Errorf("invalid check at line %d: %v", elementLine, err)
+					}
+				}
 				if checkNode.Type == "REGEX" && checkNode.Value != "" {
 					// Validate regex pattern
 					if _, err := regexp.Compile(checkNode.Value); err != nil {
@@ -519,8 +702,8 @@ func parseCheckNode(element xml.StartElement, decoder *XMLDecoder, elementLine i
 					}
 
 					// Check if plugin exists
-					if _, ok := plugin.Plugins[pluginName]; !ok {
-						if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+					if _, ok := plugin.GetPlugin(pluginName); !ok {
+						if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 							return checkNode, fmt.Errorf("cannot reference temporary plugin '%s' at line %d, please save it first", pluginName, elementLine)
 						}
 						return checkNode, fmt.Errorf("plugin not found: %s at line %d", pluginName, elementLine)
@@ -528,7 +711,7 @@ func parseCheckNode(element xml.StartElement, decoder *XMLDecoder, elementLine i
 
 					// Store parsed plugin info with negation flag
 					// Use the original plugin instance to ensure statistics are recorded correctly
-					checkNode.Plugin = plugin.Plugins[pluginName]
+					checkNode.Plugin, _ = plugin.GetPlugin(pluginName)
 					// Store negation flag separately since we can't modify the original plugin
 					checkNode.IsNegated = isNegated
 					checkNode.PluginArgs = args
@@ -591,6 +774,16 @@ func parseThreshold(element xml.StartElement, decoder *XMLDecoder, elementLine i
 				return threshold, fmt.Errorf("threshold local_cache must be 'true' or 'false', got '%s' at line %d", localCache, elementLine)
 			}
 			threshold.LocalCache = localCache == "true"
+		case "classify_values_field":
+			threshold.ClassifyValuesField = strings.TrimSpace(attr.Value)
+		case "classify_count_field":
+			threshold.ClassifyCountField = strings.TrimSpace(attr.Value)
+		case "fire_count_field":
+			field := strings.TrimSpace(attr.Value)
+			if err := fieldReferenceError(field); field == "" || err != nil {
+				return threshold, fmt.Errorf("threshold fire_count_field must be a field name, got '%s' at line %d", attr.Value, elementLine)
+			}
+			threshold.FireCountField = field
 		}
 	}
 
@@ -632,12 +825,90 @@ func parseThreshold(element xml.StartElement, decoder *XMLDecoder, elementLine i
 					return threshold, fmt.Errorf("threshold count_field cannot be empty when count_type is '%s' at line %d", threshold.CountType, elementLine)
 				}
 
+				if threshold.CountType != "CLASSIFY" && (threshold.ClassifyValuesField != "" || threshold.ClassifyCountField != "") {
+					return threshold, fmt.Errorf("threshold classify_values_field and classify_count_field require count_type 'CLASSIFY' at line %d", elementLine)
+				}
+
 				return threshold, nil
 			}
 		}
 	}
 }
 
+func parseAggregate(element xml.StartElement, elementLine int) (*Aggregate, error) {
+	agg := &Aggregate{Samples: defaultAggregateSamples}
+
+	for _, attr := range element.Attr {
+		switch attr.Name.Local {
+		case "group_by":
+			agg.GroupBy = strings.TrimSpace(attr.Value)
+			for _, field := range strings.Split(agg.GroupBy, ",") {
+				field = strings.TrimSpace(field)
+				if field != "" {
+					agg.GroupByFields = append(agg.GroupByFields, field)
+					agg.GroupByList = append(agg.GroupByList, common.StringToList(field))
+				}
+			}
+		case "window":
+			window := strings.TrimSpace(attr.Value)
+			windowInt, err := common.ParseDurationToSecondsInt(window)
+			if err != nil || windowInt <= 0 {
+				return nil, fmt.Errorf("aggregate window must be a positive duration like '30s' or '5m', got '%s' at line %d", attr.Value, elementLine)
+			}
+			agg.Window = window
+			agg.WindowInt = windowInt
+		case "samples":
+			samples, err := strconv.Atoi(strings.TrimSpace(attr.Value))
+			if err != nil || samples < 0 || samples > maxAggregateSamples {
+				return nil, fmt.Errorf("aggregate samples must be an integer between 0 and %d, got '%s' at line %d", maxAggregateSamples, attr.Value, elementLine)
+			}
+			agg.Samples = samples
+		}
+	}
+
+	if agg.Window == "" {
+		return nil, fmt.Errorf("aggregate window is required at line %d", elementLine)
+	}
+	return agg, nil
+}
+
+func parseCooldown(element xml.StartElement, elementLine int) (*Cooldown, error) {
+	cd := &Cooldown{}
+
+	for _, attr := range element.Attr {
+		switch attr.Name.Local {
+		case "group_by":
+			cd.GroupBy = strings.TrimSpace(attr.Value)
+			for _, field := range strings.Split(cd.GroupBy, ",") {
+				field = strings.TrimSpace(field)
+				if field != "" {
+					cd.GroupByFields = append(cd.GroupByFields, field)
+					cd.GroupByList = append(cd.GroupByList, common.StringToList(field))
+				}
+			}
+		case "duration":
+			duration := strings.TrimSpace(attr.Value)
+			durationInt, err := common.ParseDurationToSecondsInt(duration)
+			if err != nil || durationInt <= 0 {
+				return nil, fmt.Errorf("cooldown duration must be a positive duration like '30s' or '10m', got '%s' at line %d", attr.Value, elementLine)
+			}
+			cd.Duration = duration
+			cd.DurationInt = durationInt
+		case "local_cache":
+			localCache, err := strconv.ParseBool(strings.TrimSpace(attr.Value))
+			if err != nil {
+				return nil, fmt.Errorf("cooldown local_cache must be true or false, got '%s' at line %d", attr.Value, elementLine)
+			}
+			cd.LocalCache = localCache
+		}
+	}
+
+	if cd.Duration == "" {
+		return nil, fmt.Errorf("cooldown duration is required at line %d", elementLine)
+	}
+	return cd, nil
+}
+
 func parseAppend(element xml.StartElement, decoder *XMLDecoder, elementLine int) (Append, error) {
 	var appendElem Append
 
@@ -646,10 +917,12 @@ func parseAppend(element xml.StartElement, decoder *XMLDecoder, elementLine int)
 		switch attr.Name.Local {
 		case "type":
 			appendType := strings.TrimSpace(attr.Value)
-			if appendType != "" && appendType != "PLUGIN" {
-				return appendElem, fmt.Errorf("append type must be empty or 'PLUGIN', got '%s' at line %d", appendType, elementLine)
+			if appendType != "" && appendType != "PLUGIN" && appendType != AppendMerge && appendType != AppendDecode {
+				return appendElem, fmt.Errorf("append type must be empty, 'PLUGIN', 'MERGE' or 'DECODE', got '%s' at line %d", appendType, elementLine)
 			}
 			appendElem.Type = appendType
+		case "encoding":
+			appendElem.Encoding = strings.ToLower(strings.TrimSpace(attr.Value))
 		case "field":
 			field := strings.TrimSpace(attr.Value)
 			if field == "" {
@@ -672,6 +945,9 @@ func parseAppend(element xml.StartElement, decoder *XMLDecoder, elementLine int)
 			if appendElem.Type == "PLUGIN" && value == "" {
 				return appendElem, fmt.Errorf("append plugin value cannot be empty at line %d", elementLine)
 			}
+			if appendElem.Type == AppendMerge && value == "" {
+				return appendElem, fmt.Errorf("append MERGE value cannot be empty at line %d", elementLine)
+			}
 			appendElem.Value = value
 		case xml.EndElement:
 			if t.Name.Local == "append" {
@@ -680,7 +956,24 @@ func parseAppend(element xml.StartElement, decoder *XMLDecoder, elementLine int)
 					return appendElem, fmt.Errorf("append field is required at line %d", elementLine)
 				}
 
-				if appendElem.Type == "PLUGIN" && appendElem.Value != "" {
+				if appendElem.Type == AppendDecode {
+					if err := verifyDecodeAppend(&appendElem); err != nil {
+						return appendElem, fmt.Errorf("%v at line %d", err, elementLine)
+					}
+				} else if appendElem.Encoding != "" {
+					return appendElem, fmt.Errorf("append encoding only applies to type 'DECODE' at line %d", elementLine)
+				}
+
+				if appendElem.Type == AppendMerge && !appendElem.usesPlugin() {
+					// Static objects are parsed once instead of for every event
+					mergeValue, err := parseMergeValue(appendElem.Value)
+					if err != nil {
+						return appendElem, fmt.Errorf("%v at line %d", err, elementLine)
+					}
+					appendElem.MergeValue = mergeValue
+				}
+
+				if appendElem.usesPlugin() && appendElem.Value != "" {
 					// Validate plugin call syntax
 					pluginName, args, err := ParseFunctionCall(appendElem.Value)
 					if err != nil {
@@ -688,15 +981,15 @@ func parseAppend(element xml.StartElement, decoder *XMLDecoder, elementLine int)
 					}
 
 					// Check if plugin exists
-					if _, ok := plugin.Plugins[pluginName]; !ok {
-						if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+					if _, ok := plugin.GetPlugin(pluginName); !ok {
+						if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 							return appendElem, fmt.Errorf("cannot reference temporary plugin '%s' at line %d, please save it first", pluginName, elementLine)
 						}
 						return appendElem, fmt.Errorf("plugin not found: %s at line %d", pluginName, elementLine)
 					}
 
 					// Store parsed plugin info
-					appendElem.Plugin = plugin.Plugins[pluginName]
+					appendElem.Plugin, _ = plugin.GetPlugin(pluginName)
 					appendElem.PluginArgs = args
 				}
 
@@ -732,15 +1025,15 @@ func parsePlugin(element xml.StartElement, decoder *XMLDecoder, elementLine int)
 				}
 
 				// Check if plugin exists
-				if _, ok := plugin.Plugins[pluginName]; !ok {
-					if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+				if _, ok := plugin.GetPlugin(pluginName); !ok {
+					if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 						return pluginElem, fmt.Errorf("cannot reference temporary plugin '%s' at line %d, please save it first", pluginName, elementLine)
 					}
 					return pluginElem, fmt.Errorf("plugin not found: %s at line %d", pluginName, elementLine)
 				}
 
 				// Store parsed plugin info
-				pluginElem.Plugin = plugin.Plugins[pluginName]
+				pluginElem.Plugin, _ = plugin.GetPlugin(pluginName)
 				pluginElem.PluginArgs = args
 
 				return pluginElem, nil
@@ -99,6 +99,11 @@ func getMaxPoolSize() int {
 	return maxSize
 }
 
+// PoolSizes returns the lower and upper bound of the per-ruleset worker pool on this node
+func PoolSizes() (min, max int) {
+	return getMinPoolSize(), getMaxPoolSize()
+}
+
 var ConditionRegex = regexp.MustCompile("^([a-zA-Z0-9_-]+|\\(|\\)|\\s|not)+$")
 
 type OperatorType int
@@ -121,6 +126,14 @@ type EngineOperator struct {
 type Rule struct {
 	ID   string `xml:"id,attr"`
 	Name string `xml:"name,attr"`
+	// Scope is the dotted path of the subtree the rule's field references resolve below (rule attribute scope)
+	Scope string `xml:"scope,attr"`
+	// Group is an optional category used to organize rules and aggregate their hits (rule attribute group)
+	Group string `xml:"group,attr"`
+	// Requires lists the fields an event must hold for the rule to run (<requires> element); events
+	// missing one skip the rule, which then does not match
+	Requires     []string
+	RequiresList [][]string
 
 	Queue *[]EngineOperator
 
@@ -131,6 +144,9 @@ type Rule struct {
 	AppendsMap   map[int]Append
 	PluginMap    map[int]Plugin
 	DelMap       map[int][][]string
+
+	Aggregate *Aggregate // Optional, collapses matches into one alert per group and window
+	Cooldown  *Cooldown  // Optional, silences the rule for a period after it fires
 }
 
 type Ruleset struct {
@@ -149,14 +165,41 @@ type Ruleset struct {
 	Rules       []Rule
 	RulesCount  int
 
+	// SampleTrace attaches the per-rule check node results to sampled events (root attribute sample_trace)
+	SampleTrace bool
+	// Quiet skips sampling and per-rule hit counting for throughput (root attribute quiet)
+	Quiet bool
+	// SampleMode is the root attribute sample: which events the sampler keeps, empty means SampleAll
+	SampleMode string
+	// EngineVersion is the root attribute engine_version, 0 when the ruleset follows the current engine
+	EngineVersion int
+	// OnRuleError is the root attribute on_rule_error, empty means OnRuleErrorFail
+	OnRuleError string
+	// PluginCacheSize is the root attribute plugin_cache: how many plugin results the ruleset caches
+	// by plugin and arguments, 0 disables the cache
+	PluginCacheSize int
+	// FirstMatch is the root attribute first_match: a detection ruleset stops at the first matching
+	// rule, so rules are evaluated in priority order and each event yields at most one result
+	FirstMatch bool
+	// BatchSize is the root attribute batch_size: up to this many queued events are checked by one
+	// pool task, 0 and 1 check every event on its own
+	BatchSize int
+	// RuleErrors lists the rules skipped under on_rule_error="skip"
+	RuleErrors []RuleBuildError
+	// Tests are the <test> cases validation runs against the rules
+	Tests []InlineTest
+
 	UpStream   map[string]*chan map[string]interface{}
 	DownStream map[string]*chan map[string]interface{}
 
+	// statusMachine serializes the writes of Status and keeps their history
+	statusMachine common.StatusMachine
+
 	stopChan chan struct{} // Control channel for Start/Stop
 	antsPool *ants.Pool    // Ants thread pool
 
 	Cache            *ristretto.Cache[string, int]
-	CacheForClassify *ristretto.Cache[string, map[string]bool]
+	CacheForClassify *ristretto.Cache[string, map[string]string]
 
 	// Regex result cache for this ruleset instance
 	RegexResultCache *RegexResultCache
@@ -167,12 +210,37 @@ type Ruleset struct {
 	RawConfig string
 	sampler   *common.Sampler
 
+	// aggregator holds the open windows of rules with <aggregate>, nil in test mode
+	aggregator *alertAggregator
+
+	// kafkaTxn is set by the project when it forwards Kafka to Kafka transactionally
+	kafkaTxn *common.KafkaTxn
+	// budget is the soft limit shared by the rulesets of the project, nil when unlimited
+	budget *ProjectBudget
+	// noMatchDownStream receives the events no rule matched when the project sets emit_no_match
+	noMatchDownStream *chan map[string]interface{}
+	// unroutedEvents counts the unmatched events of a detection ruleset dropped without a
+	// no-match downstream
+	unroutedEvents uint64
+
 	// Performance optimization: pre-compute test mode flag
 	isTestMode bool // true if ProjectNodeSequence starts with "TEST."
 
+	// stateNamespace prefixes the threshold and cooldown keys, empty for deployed instances
+	stateNamespace string
+
+	// decodeFailures counts the payloads DECODE appends could not decode
+	decodeFailures uint64
+
+	// plugin results cached under plugin_cache, created on first use
+	pluginResults   *pluginResultCache
+	pluginCacheOnce sync.Once
+
 	// metrics - only total count is needed now
 	processTotal      uint64         // cumulative message processing total
 	lastReportedTotal uint64         // For calculating increments in 10-second intervals
+	ruleHits          []uint64       // matches per rule, indexed like Rules
+	ruleHitsReported  []uint64       // baseline of the last rule hit collection
 	wg                sync.WaitGroup // WaitGroup for goroutine management
 
 	// OwnerProjects field removed - project usage is now calculated dynamically
@@ -197,6 +265,17 @@ type Iterator struct {
 	Checklists     []Checklist  `xml:"checklist"`
 }
 
+// on_error modes for PLUGIN and REGEX checks
+const (
+	OnErrorMatch   = "match"
+	OnErrorNoMatch = "nomatch"
+	OnErrorError   = "error"
+)
+
+func isValidOnError(v string) bool {
+	return v == OnErrorMatch || v == OnErrorNoMatch || v == OnErrorError
+}
+
 // CheckNodes represents a single check operation in a checklist.
 type CheckNodes struct {
 	ID        string                              `xml:"id,attr"`
@@ -206,6 +285,16 @@ type CheckNodes struct {
 	FieldList []string                            // parsed field path
 	Logic     string                              `xml:"logic,attr"`
 	Delimiter string                              `xml:"delimiter,attr"`
+	// Strict only applies to ISNULL/NOTNULL: when true, only an absent key counts as null
+	// and a present-but-empty value counts as not null
+	Strict bool `xml:"strict,attr"`
+	// OnError decides the result when a PLUGIN or REGEX evaluation fails: match, nomatch (default)
+	// or error, which drops the event from the ruleset and stores it in the dead letter queue
+	OnError string `xml:"on_error,attr"`
+	// As="time" makes MT/LT compare the field and value as timestamps, relative values like now-1h included
+	As string `xml:"as,attr"`
+	// Match="any|all" evaluates the check on each element of an array field instead of the whole value
+	Match string `xml:"match,attr"`
 
 	DelimiterFieldList []string
 	Value              string `xml:",chardata"`
@@ -214,6 +303,9 @@ type CheckNodes struct {
 	Plugin     *plugin.Plugin
 	PluginArgs []*PluginArg
 	IsNegated  bool // Whether the plugin result should be negated (for ! prefix)
+	// Ref is the positional reference (n1, n2...) of a node with an id in a checklist condition,
+	// empty when the id already is the positional reference
+	Ref string
 }
 
 type PluginArg struct {
@@ -229,28 +321,41 @@ type PluginArg struct {
 // Threshold defines aggregation and counting logic for a rule.
 // It supports grouping by fields, time-based ranges, and different counting methods.
 type Threshold struct {
-	ID             string              `xml:"id,attr"`       // ID for referencing in checklist conditions
-	group_by       string              `xml:"group_by,attr"` // Field to group by
-	GroupByList    map[string][]string // Parsed group by fields
-	Range          string              `xml:"range,attr"` // Time range for aggregation
-	RangeInt       int                 // Parsed range in seconds
-	LocalCache     bool                `xml:"local_cache,attr"` // Whether to use local cache
-	CountType      string              `xml:"count_type,attr"`  // Type of counting (SUM/CLASSIFY)
-	CountField     string              `xml:"count_field,attr"` // Field to count
-	CountFieldList []string            // Parsed count field path
-	Value          int                 `xml:",chardata"` // Threshold value
-	GroupByID      string              // Unique identifier for grouping
+	ID                  string              `xml:"id,attr"`       // ID for referencing in checklist conditions
+	group_by            string              `xml:"group_by,attr"` // Field to group by
+	GroupByList         map[string][]string // Parsed group by fields
+	Range               string              `xml:"range,attr"` // Time range for aggregation
+	RangeInt            int                 // Parsed range in seconds
+	LocalCache          bool                `xml:"local_cache,attr"` // Whether to use local cache
+	CountType           string              `xml:"count_type,attr"`  // Type of counting (SUM/CLASSIFY)
+	CountField          string              `xml:"count_field,attr"` // Field to count
+	CountFieldList      []string            // Parsed count field path
+	ClassifyValuesField string              `xml:"classify_values_field,attr"` // CLASSIFY: field receiving the distinct values at fire time
+	ClassifyCountField  string              `xml:"classify_count_field,attr"`  // CLASSIFY: field receiving the distinct count at fire time
+	FireCountField      string              `xml:"fire_count_field,attr"`      // Field receiving the count, sum or distinct count at fire time
+	Value               int                 `xml:",chardata"`                  // Threshold value
+	GroupByID           string              // Unique identifier for grouping
+}
+
+// recordsOnFire reports whether a firing threshold writes to the event: the classify_values_field,
+// classify_count_field or fire_count_field
+func (t *Threshold) recordsOnFire() bool {
+	return t.ClassifyValuesField != "" || t.ClassifyCountField != "" || t.FireCountField != ""
 }
 
 // Append defines additional fields to append after rule matching.
 // It supports both static values and plugin-based dynamic values.
 type Append struct {
-	Type      string `xml:"type,attr"`  // Type of append (PLUGIN)
-	FieldName string `xml:"field,attr"` // Name of field to append
-	Value     string `xml:",chardata"`  // Value to append
+	Type      string `xml:"type,attr"`     // Type of append (PLUGIN, MERGE, DECODE)
+	FieldName string `xml:"field,attr"`    // Name of field to append
+	Value     string `xml:",chardata"`     // Value to append
+	Encoding  string `xml:"encoding,attr"` // Encoding of the source field of a DECODE append: base64 or hex
 
-	Plugin     *plugin.Plugin // Plugin instance if type is PLUGIN
+	Plugin     *plugin.Plugin // Plugin instance if the value is a plugin call
 	PluginArgs []*PluginArg   // Arguments for plugin execution
+
+	MergeValue map[string]interface{} // Parsed JSON object of a MERGE append without plugin
+	FieldList  []string               // Parsed path a MERGE append merges into, empty for the event itself
 }
 
 // Plugin represents a plugin configuration with its execution parameters
@@ -309,8 +414,19 @@ func ValidateWithDetails(path string, raw string) (*ValidationResult, error) {
 		return result, nil
 	}
 
+	// Rules the parser dropped under on_rule_error="skip"
+	for _, e := range ruleset.RuleErrors {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    extractLineFromXMLError(e.Error),
+			Message: fmt.Sprintf("Rule '%s' will be skipped", e.RuleID),
+			Detail:  e.Error,
+		})
+	}
+
 	// Perform detailed validation
 	validateRulesetStructure(ruleset, string(rawRuleset), result)
+	lintRuleset(ruleset, string(rawRuleset), result)
+	validateInlineTests(ruleset, rawRuleset, result)
 
 	return result, nil
 }
@@ -479,6 +595,45 @@ func findThresholdElementLine(xmlContent, ruleID string, ruleIndex int) int {
 	return ruleStartLine
 }
 
+const (
+	// DefaultRulesetWarnRules is the rule count above which validation warns
+	DefaultRulesetWarnRules = 1000
+	// DefaultRulesetMaxRules is the rule count above which a ruleset is rejected
+	DefaultRulesetMaxRules = 10000
+)
+
+// rulesetRuleLimits returns the configured warning and hard rule limits
+func rulesetRuleLimits() (warn int, max int) {
+	warn, max = DefaultRulesetWarnRules, DefaultRulesetMaxRules
+	if n := common.ReadConfig(func(c *common.HubConfig) int { return c.RulesetWarnRules }); n > 0 {
+		warn = n
+	}
+	if n := common.ReadConfig(func(c *common.HubConfig) int { return c.RulesetMaxRules }); n > 0 {
+		max = n
+	}
+	return warn, max
+}
+
+// validateRuleCount warns on large rulesets and rejects those above the hard cap
+func validateRuleCount(ruleset *Ruleset, xmlContent string, result *ValidationResult) {
+	warn, max := rulesetRuleLimits()
+	count := len(ruleset.Rules)
+	if count > max {
+		result.IsValid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Line:    getLineNumber(xmlContent, "<root", 0),
+			Message: fmt.Sprintf("Ruleset has %d rules, exceeding the maximum of %d", count, max),
+			Detail:  "Split the ruleset or raise ruleset_max_rules in the hub config",
+		})
+	} else if count > warn {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    getLineNumber(xmlContent, "<root", 0),
+			Message: fmt.Sprintf("Ruleset has %d rules, above the recommended limit of %d", count, warn),
+			Detail:  "Large rulesets slow down build and evaluation, consider splitting it",
+		})
+	}
+}
+
 // validateRulesetStructure performs detailed validation of ruleset structure
 func validateRulesetStructure(ruleset *Ruleset, xmlContent string, result *ValidationResult) {
 	// Validate root element type
@@ -490,6 +645,8 @@ func validateRulesetStructure(ruleset *Ruleset, xmlContent string, result *Valid
 		})
 	}
 
+	validateRuleCount(ruleset, xmlContent, result)
+
 	// Check for duplicate rule IDs
 	ruleIDMap := make(map[string]int)
 	for i, rule := range ruleset.Rules {
@@ -522,7 +679,11 @@ func validateRulesetStructure(ruleset *Ruleset, xmlContent string, result *Valid
 
 	// Validate each rule
 	for ruleIndex, rule := range ruleset.Rules {
-		validateRule(&rule, xmlContent, ruleIndex, result)
+		if ruleset.OnRuleError == OnRuleErrorSkip {
+			validateSkippableRule(&rule, xmlContent, ruleIndex, result)
+		} else {
+			validateRule(&rule, xmlContent, ruleIndex, result)
+		}
 	}
 }
 
@@ -691,6 +852,11 @@ func validateStandaloneCheck(checkNode *CheckNodes, xmlContent, ruleID string, r
 		}
 	}
 
+	validateNullStrict(checkNode, checkLine, ruleID, result)
+	validateOnError(checkNode, checkLine, ruleID, result)
+	validateCheckMatch(checkNode, checkLine, ruleID, result)
+	validateFieldRefs(checkNode, checkLine, ruleID, result)
+
 	// Validate logic and delimiter combination
 	if checkNode.Logic != "" && checkNode.Delimiter == "" {
 		result.IsValid = false
@@ -710,6 +876,94 @@ func validateStandaloneCheck(checkNode *CheckNodes, xmlContent, ruleID string, r
 	}
 }
 
+// validateNullStrict warns when the strict attribute is used on a check type it does not affect
+func validateNullStrict(checkNode *CheckNodes, line int, ruleID string, result *ValidationResult) {
+	if checkNode.Strict && checkNode.Type != "ISNULL" && checkNode.Type != "NOTNULL" {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    line,
+			Message: "strict attribute only applies to ISNULL and NOTNULL checks",
+			Detail:  fmt.Sprintf("Rule ID: %s, strict will be ignored for type '%s'", ruleID, checkNode.Type),
+		})
+	}
+}
+
+// validateOnError checks the on_error value and warns when it is set on a check type that cannot fail
+func validateOnError(checkNode *CheckNodes, line int, ruleID string, result *ValidationResult) {
+	if checkNode.OnError == "" {
+		return
+	}
+	if !isValidOnError(checkNode.OnError) {
+		result.IsValid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Line:    line,
+			Message: "on_error must be one of: match, nomatch, error",
+			Detail:  fmt.Sprintf("Rule ID: %s, Current value: '%s'", ruleID, checkNode.OnError),
+		})
+		return
+	}
+	if checkNode.Type != "PLUGIN" && checkNode.Type != "REGEX" {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    line,
+			Message: "on_error attribute only applies to PLUGIN and REGEX checks",
+			Detail:  fmt.Sprintf("Rule ID: %s, on_error will be ignored for type '%s'", ruleID, checkNode.Type),
+		})
+	}
+}
+
+// fieldReferenceError reports a malformed _$field reference, nil for values that are not references
+func fieldReferenceError(value string) error {
+	if !hasFromRawPrefix(value) {
+		return nil
+	}
+	path := value[FromRawSymbolLen:]
+	switch {
+	case path == "":
+		return fmt.Errorf("'%s' must be followed by a field name", FromRawSymbol)
+	case value == PluginArgFromRawSymbol:
+		return fmt.Errorf("'%s' refers to the whole event and can only be used as a plugin argument, not as a field", PluginArgFromRawSymbol)
+	case strings.ContainsAny(path, " \t\r\n"):
+		return fmt.Errorf("field reference '%s' must not contain whitespace", value)
+	case strings.HasSuffix(path, ".") && !strings.HasSuffix(path, `\.`):
+		return fmt.Errorf("field reference '%s' has an empty path segment", value)
+	}
+	for _, part := range common.StringToList(path) {
+		if part == "" {
+			return fmt.Errorf("field reference '%s' has an empty path segment", value)
+		}
+	}
+	return nil
+}
+
+// checkNodeFieldRefError validates the _$field references a check compares against, including each
+// delimited value when logic is set
+func checkNodeFieldRefError(checkNode *CheckNodes) error {
+	if checkNode.Type == "PLUGIN" {
+		return nil
+	}
+	values := []string{checkNode.Value}
+	if checkNode.Logic != "" && checkNode.Delimiter != "" {
+		values = strings.Split(strings.TrimSpace(checkNode.Value), checkNode.Delimiter)
+	}
+	for _, v := range values {
+		if err := fieldReferenceError(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFieldRefs reports malformed _$field references in a check value
+func validateFieldRefs(checkNode *CheckNodes, line int, ruleID string, result *ValidationResult) {
+	if err := checkNodeFieldRefError(checkNode); err != nil {
+		result.IsValid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Line:    line,
+			Message: "Invalid field reference in check value",
+			Detail:  fmt.Sprintf("Rule ID: %s, %s", ruleID, err.Error()),
+		})
+	}
+}
+
 // validateChecklist validates checklist elements
 func validateChecklist(checklist *Checklist, xmlContent, ruleID string, ruleIndex int, result *ValidationResult) {
 	if len(checklist.CheckNodes) == 0 && len(checklist.ThresholdNodes) == 0 {
@@ -775,24 +1029,28 @@ func validateChecklist(checklist *Checklist, xmlContent, ruleID string, ruleInde
 			})
 		}
 
-		// Check node ID if condition is present
+		// Check node ID if condition is present, nodes without one are referenced by position
 		if hasCondition {
-			if node.ID == "" || strings.TrimSpace(node.ID) == "" {
+			nodeID := strings.TrimSpace(node.ID)
+			if nodeID == "" {
+				nodeID = ConditionRef(nodeIndex)
+			}
+			if err := checkConditionNodeID(nodeID, nodeIndex); err != nil {
 				result.IsValid = false
 				result.Errors = append(result.Errors, ValidationError{
 					Line:    nodeLine,
-					Message: "Check node id cannot be empty when condition is used",
-					Detail:  fmt.Sprintf("Rule ID: %s", ruleID),
+					Message: "Check node id is reserved for a positional reference",
+					Detail:  fmt.Sprintf("Rule ID: %s, %s", ruleID, err.Error()),
 				})
-			} else if prevIndex, exists := nodeIDMap[node.ID]; exists {
+			} else if prevIndex, exists := nodeIDMap[nodeID]; exists {
 				result.IsValid = false
 				result.Errors = append(result.Errors, ValidationError{
 					Line:    nodeLine,
-					Message: fmt.Sprintf("Duplicate node ID: %s", node.ID),
+					Message: fmt.Sprintf("Duplicate node ID: %s", nodeID),
 					Detail:  fmt.Sprintf("Rule ID: %s, first occurrence at node index %d", ruleID, prevIndex),
 				})
 			} else {
-				nodeIDMap[node.ID] = nodeIndex
+				nodeIDMap[nodeID] = nodeIndex
 			}
 		}
 
@@ -818,6 +1076,11 @@ func validateChecklist(checklist *Checklist, xmlContent, ruleID string, ruleInde
 			}
 		}
 
+		validateNullStrict(&node, nodeLine, ruleID, result)
+		validateOnError(&node, nodeLine, ruleID, result)
+		validateCheckMatch(&node, nodeLine, ruleID, result)
+		validateFieldRefs(&node, nodeLine, ruleID, result)
+
 		// Validate logic and delimiter consistency
 		if node.Logic != "" && node.Delimiter == "" {
 			result.IsValid = false
@@ -929,6 +1192,20 @@ func validateChecklist(checklist *Checklist, xmlContent, ruleID string, ruleInde
 				Message: "Invalid condition expression",
 				Detail:  fmt.Sprintf("Rule ID: %s, Condition: %s", ruleID, checklist.Condition),
 			})
+		} else {
+			ids := make(map[string]bool, len(nodeIDMap))
+			for id := range nodeIDMap {
+				ids[id] = true
+			}
+			ast := GetAST(strings.TrimSpace(checklist.Condition))
+			if err := checkConditionRefs(ast, len(checklist.CheckNodes), ids); err != nil {
+				result.IsValid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Line:    findElementInRule(xmlContent, ruleID, "<checklist", ruleIndex, 0),
+					Message: "Condition references a check node that does not exist",
+					Detail:  fmt.Sprintf("Rule ID: %s, %s", ruleID, err.Error()),
+				})
+			}
 		}
 	}
 }
@@ -1181,7 +1458,31 @@ func validateAppend(appendElem *Append, xmlContent, ruleID string, ruleIndex, ap
 		})
 	}
 
-	if appendElem.Type == "PLUGIN" {
+	if appendElem.Type == AppendDecode {
+		if err := verifyDecodeAppend(appendElem); err != nil {
+			result.IsValid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Line:    appendLine,
+				Message: "Invalid append DECODE",
+				Detail:  fmt.Sprintf("Rule ID: %s, Error: %s", ruleID, err.Error()),
+			})
+		}
+		return
+	}
+
+	if appendElem.Type == AppendMerge && !appendElem.usesPlugin() {
+		if _, err := parseMergeValue(appendElem.Value); err != nil {
+			result.IsValid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Line:    appendLine,
+				Message: "Append MERGE value must be a JSON object or a plugin call",
+				Detail:  fmt.Sprintf("Rule ID: %s, Error: %s", ruleID, err.Error()),
+			})
+		}
+		return
+	}
+
+	if appendElem.usesPlugin() {
 		value := strings.TrimSpace(appendElem.Value)
 		if value == "" {
 			result.IsValid = false
@@ -1206,11 +1507,11 @@ func validateAppend(appendElem *Append, xmlContent, ruleID string, ruleIndex, ap
 
 			// Check if plugin exists
 			var pluginInstance *plugin.Plugin
-			if p, ok := plugin.Plugins[pluginName]; ok {
+			if p, ok := plugin.GetPlugin(pluginName); ok {
 				pluginInstance = p
 			} else {
 				// Check if it's a temporary component
-				if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+				if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 					result.IsValid = false
 					result.Errors = append(result.Errors, ValidationError{
 						Line:    appendLine,
@@ -1233,7 +1534,16 @@ func validateAppend(appendElem *Append, xmlContent, ruleID string, ruleIndex, ap
 			validatePluginParameters(pluginInstance, args, value, appendLine, ruleID, result)
 
 			// Add info about supported plugin types for user awareness
-			if pluginInstance.ReturnType == "bool" {
+			if appendElem.Type == AppendMerge {
+				if pluginInstance.ReturnType == "bool" {
+					result.IsValid = false
+					result.Errors = append(result.Errors, ValidationError{
+						Line:    appendLine,
+						Message: fmt.Sprintf("Append MERGE plugin '%s' returns bool, it must return a map", pluginName),
+						Detail:  fmt.Sprintf("Rule ID: %s", ruleID),
+					})
+				}
+			} else if pluginInstance.ReturnType == "bool" {
 				result.Warnings = append(result.Warnings, ValidationWarning{
 					Line:    appendLine,
 					Message: fmt.Sprintf("Plugin '%s' returns bool type", pluginName),
@@ -1278,11 +1588,11 @@ func validatePlugin(pluginElem *Plugin, xmlContent, ruleID string, ruleIndex, pl
 
 		// Check if plugin exists (using fully qualified names to avoid conflict with parameter name)
 		var pluginInstance *plugin.Plugin
-		if p, ok := plugin.Plugins[pluginName]; ok {
+		if p, ok := plugin.GetPlugin(pluginName); ok {
 			pluginInstance = p
 		} else {
 			// Check if it's a temporary component
-			if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+			if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 				result.IsValid = false
 				result.Errors = append(result.Errors, ValidationError{
 					Line:    pluginLine,
@@ -1337,11 +1647,11 @@ func validateCheckNodePluginCall(pluginCall string, line int, ruleID string, res
 
 	// Check if plugin exists
 	var pluginInstance *plugin.Plugin
-	if p, ok := plugin.Plugins[pluginName]; ok {
+	if p, ok := plugin.GetPlugin(pluginName); ok {
 		pluginInstance = p
 	} else {
 		// Check if it's a temporary component
-		if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+		if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 			result.IsValid = false
 			result.Errors = append(result.Errors, ValidationError{
 				Line:    line,
@@ -1391,11 +1701,11 @@ func validatePluginCall(pluginCall string, line int, ruleID string, result *Vali
 
 	// Check if plugin exists
 	var pluginInstance *plugin.Plugin
-	if p, ok := plugin.Plugins[pluginName]; ok {
+	if p, ok := plugin.GetPlugin(pluginName); ok {
 		pluginInstance = p
 	} else {
 		// Check if it's a temporary component
-		if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+		if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 			result.IsValid = false
 			result.Errors = append(result.Errors, ValidationError{
 				Line:    line,
@@ -1662,7 +1972,7 @@ func Verify(path string, raw string) error {
 		return fmt.Errorf("failed to read ruleset configuration: %w", err)
 	}
 
-	valiRes, err := ValidateWithDetails("", string(raw))
+	valiRes, err := ValidateWithDetails("", string(rawRuleset))
 	if err != nil {
 		return fmt.Errorf("failed to validate resource: %w", err)
 	}
@@ -1742,8 +2052,8 @@ func NewRuleset(path string, raw string, id string) (*Ruleset, error) {
 
 	ruleset.RulesetID = id
 
-	// Only create sampler on leader node for performance
-	if common.IsLeader {
+	// Only create sampler on leader node for performance, quiet rulesets are never sampled
+	if common.IsLeader && !ruleset.Quiet {
 		ruleset.sampler = common.GetSampler("ruleset." + id)
 	}
 
@@ -1753,15 +2063,29 @@ func NewRuleset(path string, raw string, id string) (*Ruleset, error) {
 	return ruleset, nil
 }
 
-// SetStatus sets the ruleset status and error information
-func (r *Ruleset) SetStatus(status common.Status, err error) {
+// SetStatus moves the ruleset to a new status, recording the error as the reason of the transition.
+// Invalid transitions are rejected and leave the status unchanged.
+func (r *Ruleset) SetStatus(status common.Status, err error) error {
+	reason := ""
+	if err != nil {
+		reason = err.Error()
+	}
+	t, terr := r.statusMachine.Transition(&r.Status, status, reason)
+	if terr != nil {
+		logger.Warn("Rejected ruleset status transition", "ruleset", r.RulesetID, "status", status, "error", terr)
+		return terr
+	}
 	if err != nil {
 		r.Err = err
 		logger.Error("Ruleset status changed with error", "ruleset", r.RulesetID, "status", status, "error", err)
 	}
-	r.Status = status
-	t := time.Now()
-	r.StatusChangedAt = &t
+	r.StatusChangedAt = &t.At
+	return nil
+}
+
+// StatusHistory returns the recent status transitions of the ruleset, oldest first
+func (r *Ruleset) StatusHistory() []common.StatusTransition {
+	return r.statusMachine.History()
 }
 
 // cleanup performs cleanup when normal stop fails or panic occurs
@@ -1784,15 +2108,7 @@ func (r *Ruleset) cleanup() {
 	}
 
 	// Close caches
-	if r.Cache != nil {
-		r.Cache.Close()
-		r.Cache = nil
-	}
-
-	if r.CacheForClassify != nil {
-		r.CacheForClassify.Close()
-		r.CacheForClassify = nil
-	}
+	r.CloseCaches()
 
 	// Clear regex result cache
 	if r.RegexResultCache != nil {
@@ -1800,6 +2116,9 @@ func (r *Ruleset) cleanup() {
 		r.RegexResultCache = nil
 	}
 
+	// Open aggregation windows were flushed by Stop
+	r.aggregator = nil
+
 	// Reset atomic counter
 	atomic.StoreUint64(&r.processTotal, 0)
 	atomic.StoreUint64(&r.lastReportedTotal, 0)
@@ -1821,7 +2140,11 @@ func NewFromExisting(existing *Ruleset, newProjectNodeSequence string) (*Ruleset
 	if err != nil {
 		return nil, fmt.Errorf("ruleset verify error for existing config: %s %w", existing.RulesetID, err)
 	}
+	return copyRuleset(existing, newProjectNodeSequence)
+}
 
+// copyRuleset creates the new instance of NewFromExisting from a configuration already verified
+func copyRuleset(existing *Ruleset, newProjectNodeSequence string) (*Ruleset, error) {
 	// Create a new Ruleset instance with the same configuration but different ProjectNodeSequence
 	newRuleset := &Ruleset{
 		Path:                existing.Path,
@@ -1832,8 +2155,18 @@ func NewFromExisting(existing *Ruleset, newProjectNodeSequence string) (*Ruleset
 		ProjectNodeSequence: newProjectNodeSequence, // Set the new sequence
 		Type:                existing.Type,
 		IsDetection:         existing.IsDetection,
-		Rules:               existing.Rules,       // Share the same rules
-		RulesCount:          existing.RulesCount,  // Copy the rules count
+		SampleTrace:         existing.SampleTrace,
+		Quiet:               existing.Quiet,
+		SampleMode:          existing.SampleMode,
+		FirstMatch:          existing.FirstMatch,
+		EngineVersion:       existing.EngineVersion,
+		OnRuleError:         existing.OnRuleError,
+		PluginCacheSize:     existing.PluginCacheSize,
+		BatchSize:           existing.BatchSize,
+		RuleErrors:          existing.RuleErrors,
+		Rules:               existing.Rules,      // Share the same rules
+		RulesCount:          existing.RulesCount, // Copy the rules count
+		Tests:               existing.Tests,
 		Status:              common.StatusStopped, // Initialize status to stopped
 		UpStream:            make(map[string]*chan map[string]interface{}),
 		DownStream:          make(map[string]*chan map[string]interface{}),
@@ -1850,8 +2183,8 @@ func NewFromExisting(existing *Ruleset, newProjectNodeSequence string) (*Ruleset
 		// RulesByFilter field has been removed in the new flexible syntax design
 	}
 
-	// Only create sampler on leader node for performance
-	if common.IsLeader {
+	// Only create sampler on leader node for performance, quiet rulesets are never sampled
+	if common.IsLeader && !newRuleset.Quiet {
 		newRuleset.sampler = common.GetSampler("ruleset." + existing.RulesetID)
 	}
 
@@ -1860,6 +2193,9 @@ func NewFromExisting(existing *Ruleset, newProjectNodeSequence string) (*Ruleset
 	var needsClassifyCache bool
 
 	for _, rule := range newRuleset.Rules {
+		if rule.Cooldown != nil && rule.Cooldown.LocalCache {
+			needsCache = true
+		}
 		if len(rule.ThresholdMap) > 0 {
 			needsCache = true
 			// Check if any threshold uses CLASSIFY mode
@@ -1890,7 +2226,7 @@ func NewFromExisting(existing *Ruleset, newProjectNodeSequence string) (*Ruleset
 
 	if needsClassifyCache {
 		var err error
-		newRuleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]bool]{
+		newRuleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]string]{
 			NumCounters: 10_000_000,       // number of keys to track frequency of.
 			MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
 			BufferItems: 256,              // number of keys per Get buffer.
@@ -1912,6 +2248,34 @@ func (r *Ruleset) SetTestMode() {
 	r.sampler = nil // Disable sampling for test instances
 }
 
+// SetStateNamespace keeps the threshold counters and cooldowns of a copy apart from those of the
+// deployed instance, in Redis and in the local caches
+func (r *Ruleset) SetStateNamespace(ns string) {
+	r.stateNamespace = ns
+}
+
+// CloseCaches closes the local threshold and cooldown caches of an instance that is no longer used
+func (r *Ruleset) CloseCaches() {
+	if r.Cache != nil {
+		r.Cache.Close()
+		r.Cache = nil
+	}
+	if r.CacheForClassify != nil {
+		r.CacheForClassify.Close()
+		r.CacheForClassify = nil
+	}
+}
+
+// SetKafkaTxn makes the ruleset track its results for a transactional project (nil disables it)
+func (r *Ruleset) SetKafkaTxn(txn *common.KafkaTxn) {
+	r.kafkaTxn = txn
+}
+
+// SetProjectBudget shares the soft limits of the project with the ruleset for the next start
+func (r *Ruleset) SetProjectBudget(budget *ProjectBudget) {
+	r.budget = budget
+}
+
 // ParseFunctionCall parses a function call of the form "functionName(arg1, arg2, ...)"
 func ParseFunctionCall(input string) (string, []*PluginArg, error) {
 	input = strings.TrimSpace(input)
@@ -2095,7 +2459,6 @@ func parseValue(s string) (*PluginArg, error) {
 
 // RulesetBuild parses and validates a Ruleset with new flexible rule syntax, initializing all field paths and check functions.
 func RulesetBuild(ruleset *Ruleset) error {
-	var err error
 	//for init local cache, local cache only work for threshold check
 	var createLocalCache = false
 	var createLocalCacheForClassify = false
@@ -2108,6 +2471,8 @@ func RulesetBuild(ruleset *Ruleset) error {
 		return errors.New("resource type only support exclude or detection")
 	}
 
+	// Rules the parser already dropped under on_rule_error="skip" come first
+	ruleErrors := ruleset.RuleErrors
 	for i := range ruleset.Rules {
 		rule := &ruleset.Rules[i]
 
@@ -2122,212 +2487,359 @@ func RulesetBuild(ruleset *Ruleset) error {
 			}
 		}
 
-		// Process checklists in ChecklistMap
-		for id, checklist := range rule.ChecklistMap {
-			// Validate that checklist has at least one check node or threshold node
-			if len(checklist.CheckNodes) == 0 && len(checklist.ThresholdNodes) == 0 {
-				return errors.New("checklist must have at least one check node or threshold node: " + rule.ID)
+		if err := buildRule(ruleset, rule, &createLocalCache, &createLocalCacheForClassify); err != nil {
+			if ruleset.OnRuleError != OnRuleErrorSkip {
+				return err
 			}
+			ruleErrors = append(ruleErrors, RuleBuildError{RuleID: rule.ID, Error: err.Error()})
+		}
+	}
+	ruleset.skipFailedRules(ruleErrors)
 
-			if strings.TrimSpace(checklist.Condition) != "" {
-				if _, _, ok := ConditionRegex.Find(strings.TrimSpace(checklist.Condition)); ok {
-					checklist.ConditionAST = GetAST(strings.TrimSpace(checklist.Condition))
-					checklist.ConditionMap = make(map[string]bool, len(checklist.CheckNodes)+len(checklist.ThresholdNodes))
-					checklist.ConditionFlag = true
-				} else {
-					return errors.New("checklist condition is not a valid expression")
-				}
+	// Initialize regex result cache
+	if ruleset.RegexResultCache == nil {
+		ruleset.RegexResultCache = NewRegexResultCache(1000) // Default capacity: 1000 entries
+	}
+
+	return nil
+}
+
+// buildRule initializes the field paths, check functions, plugins and caches of one rule
+func buildRule(ruleset *Ruleset, rule *Rule, createLocalCache, createLocalCacheForClassify *bool) error {
+	var err error
+
+	if cd := rule.Cooldown; cd != nil {
+		cd.GroupByID = ruleset.RulesetID + rule.ID
+		if cd.LocalCache && !*createLocalCache {
+			ruleset.Cache, err = ristretto.NewCache(&ristretto.Config[string, int]{
+				NumCounters: 10_000_000,       // number of keys to track frequency of.
+				MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
+				BufferItems: 32,               // number of keys per Get buffer.
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create local cache: %w", err)
 			}
+			*createLocalCache = true
+		}
+	}
 
-			// Process check nodes in this checklist
-			for j := range checklist.CheckNodes {
-				node := &checklist.CheckNodes[j]
-				err := processCheckNode(node, &checklist, rule.ID)
-				if err != nil {
+	// Process checklists in ChecklistMap
+	for id, checklist := range rule.ChecklistMap {
+		// Validate that checklist has at least one check node or threshold node
+		if len(checklist.CheckNodes) == 0 && len(checklist.ThresholdNodes) == 0 {
+			return errors.New("checklist must have at least one check node or threshold node: " + rule.ID)
+		}
+
+		if strings.TrimSpace(checklist.Condition) != "" {
+			if _, _, ok := ConditionRegex.Find(strings.TrimSpace(checklist.Condition)); ok {
+				checklist.ConditionAST = GetAST(strings.TrimSpace(checklist.Condition))
+				checklist.ConditionMap = make(map[string]bool, len(checklist.CheckNodes)+len(checklist.ThresholdNodes))
+				checklist.ConditionFlag = true
+				if err := bindConditionRefs(&checklist, rule.ID); err != nil {
 					return err
 				}
+			} else {
+				return errors.New("checklist condition is not a valid expression")
 			}
+		}
 
-			// Process threshold nodes in this checklist
-			for j := range checklist.ThresholdNodes {
-				threshold := &checklist.ThresholdNodes[j]
+		// Process check nodes in this checklist
+		for j := range checklist.CheckNodes {
+			node := &checklist.CheckNodes[j]
+			err := processCheckNode(node, &checklist, rule.ID)
+			if err != nil {
+				return err
+			}
+		}
 
-				// Parse threshold group by fields
-				if threshold.group_by != "" {
-					threshold.GroupByList = make(map[string][]string)
-					groupByFields := strings.Split(threshold.group_by, ",")
-					for _, field := range groupByFields {
-						field = strings.TrimSpace(field)
-						if field != "" {
-							threshold.GroupByList[field] = common.StringToList(field)
-						}
+		// Process threshold nodes in this checklist
+		for j := range checklist.ThresholdNodes {
+			threshold := &checklist.ThresholdNodes[j]
+
+			// Parse threshold group by fields
+			if threshold.group_by != "" {
+				threshold.GroupByList = make(map[string][]string)
+				groupByFields := strings.Split(threshold.group_by, ",")
+				for _, field := range groupByFields {
+					field = strings.TrimSpace(field)
+					if field != "" {
+						threshold.GroupByList[field] = common.StringToList(field)
 					}
 				}
+			}
 
-				// Parse threshold range
-				if threshold.Range != "" {
-					rangeInt, err := common.ParseDurationToSecondsInt(threshold.Range)
-					if err != nil {
-						return errors.New("threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
-					}
-					threshold.RangeInt = rangeInt
+			// Parse threshold range
+			if threshold.Range != "" {
+				rangeInt, err := common.ParseDurationToSecondsInt(threshold.Range)
+				if err != nil {
+					return errors.New("threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
 				}
+				threshold.RangeInt = rangeInt
+			}
 
-				// Set threshold group ID - use same format as standalone threshold for consistency
-				threshold.GroupByID = ruleset.RulesetID + rule.ID
+			// Set threshold group ID - use same format as standalone threshold for consistency
+			threshold.GroupByID = ruleset.RulesetID + rule.ID
 
-				// Initialize cache if needed for checklist thresholds
-				if threshold.LocalCache && !createLocalCache {
-					ruleset.Cache, err = ristretto.NewCache(&ristretto.Config[string, int]{
-						NumCounters: 10_000_000,       // number of keys to track frequency of.
-						MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
-						BufferItems: 32,               // number of keys per Get buffer.
-					})
+			// Initialize cache if needed for checklist thresholds
+			if threshold.LocalCache && !*createLocalCache {
+				ruleset.Cache, err = ristretto.NewCache(&ristretto.Config[string, int]{
+					NumCounters: 10_000_000,       // number of keys to track frequency of.
+					MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
+					BufferItems: 32,               // number of keys per Get buffer.
+				})
 
-					if err != nil {
-						return fmt.Errorf("failed to create local cache: %w", err)
-					}
-					createLocalCache = true
+				if err != nil {
+					return fmt.Errorf("failed to create local cache: %w", err)
 				}
+				*createLocalCache = true
+			}
 
-				if threshold.CountType == "CLASSIFY" && !createLocalCacheForClassify {
-					ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]bool]{
-						NumCounters: 10_000_000,       // number of keys to track frequency of.
-						MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
-						BufferItems: 32,               // number of keys per Get buffer.
-					})
+			if threshold.CountType == "CLASSIFY" && !*createLocalCacheForClassify {
+				ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]string]{
+					NumCounters: 10_000_000,       // number of keys to track frequency of.
+					MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
+					BufferItems: 32,               // number of keys per Get buffer.
+				})
 
-					if err != nil {
-						return fmt.Errorf("failed to create local cache: %w", err)
-					}
-					createLocalCacheForClassify = true
+				if err != nil {
+					return fmt.Errorf("failed to create local cache: %w", err)
 				}
+				*createLocalCacheForClassify = true
+			}
 
-				// Parse count field for SUM and CLASSIFY types
-				if threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY" {
-					if threshold.CountField != "" {
-						threshold.CountFieldList = common.StringToList(strings.TrimSpace(threshold.CountField))
-					}
+			// Parse count field for SUM and CLASSIFY types
+			if threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY" {
+				if threshold.CountField != "" {
+					threshold.CountFieldList = common.StringToList(strings.TrimSpace(threshold.CountField))
 				}
 			}
+		}
+
+		// Sort check nodes for optimization
+		checklist.CheckNodes = sortCheckNodes(checklist.CheckNodes)
+		// Update the checklist in the map
+		rule.ChecklistMap[id] = checklist
+	}
 
-			// Sort check nodes for optimization
-			checklist.CheckNodes = sortCheckNodes(checklist.CheckNodes)
-			// Update the checklist in the map
-			rule.ChecklistMap[id] = checklist
+	// Process standalone check nodes in CheckMap
+	for id, checkNode := range rule.CheckMap {
+		err := processCheckNode(&checkNode, nil, rule.ID)
+		if err != nil {
+			return err
 		}
+		// Update the check node in the map
+		rule.CheckMap[id] = checkNode
+	}
 
-		// Process standalone check nodes in CheckMap
-		for id, checkNode := range rule.CheckMap {
-			err := processCheckNode(&checkNode, nil, rule.ID)
-			if err != nil {
-				return err
-			}
-			// Update the check node in the map
-			rule.CheckMap[id] = checkNode
+	// Process appends in AppendsMap
+	for id, appendNode := range rule.AppendsMap {
+		appendType := strings.TrimSpace(appendNode.Type)
+		appendValue := strings.TrimSpace(appendNode.Value)
+
+		if appendType != "" && appendType != "PLUGIN" && appendType != AppendMerge && appendType != AppendDecode {
+			return errors.New("append type must be empty, 'PLUGIN', 'MERGE' or 'DECODE': " + rule.ID)
 		}
 
-		// Process appends in AppendsMap
-		for id, appendNode := range rule.AppendsMap {
-			appendType := strings.TrimSpace(appendNode.Type)
-			appendValue := strings.TrimSpace(appendNode.Value)
+		if appendNode.FieldName == "" {
+			return errors.New("append field name cannot be empty: " + rule.ID)
+		}
 
-			if appendType != "" && appendType != "PLUGIN" {
-				return errors.New("append type must be empty or 'PLUGIN': " + rule.ID)
+		if appendType == AppendDecode {
+			if err := verifyDecodeAppend(&appendNode); err != nil {
+				return fmt.Errorf("%w: %s", err, rule.ID)
 			}
+		}
 
-			if appendNode.FieldName == "" {
-				return errors.New("append field name cannot be empty: " + rule.ID)
+		if appendType == AppendMerge {
+			appendNode.FieldList = nil
+			if appendNode.FieldName != PluginArgFromRawSymbol {
+				appendNode.FieldList = common.StringToList(strings.TrimSpace(appendNode.FieldName))
 			}
-
-			if appendNode.Type == "PLUGIN" {
-				pluginName, args, err := ParseFunctionCall(appendValue)
+			if !appendNode.usesPlugin() {
+				mergeValue, err := parseMergeValue(appendValue)
 				if err != nil {
-					return err
+					return fmt.Errorf("%w: %s", err, rule.ID)
 				}
-
-				if p, ok := plugin.Plugins[pluginName]; ok {
-					appendNode.Plugin = p
-				} else {
-					// Check if it's a temporary component, temporary components should not be referenced
-					if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
-						return errors.New("cannot reference temporary plugin '" + pluginName + "', please save it first")
-					}
-					return errors.New("not found this plugin: " + pluginName)
-				}
-
-				appendNode.PluginArgs = args
+				appendNode.MergeValue = mergeValue
 			}
-			// Update the append node in the map
-			rule.AppendsMap[id] = appendNode
 		}
 
-		// Process plugins in PluginMap
-		for id, pluginNode := range rule.PluginMap {
-			value := strings.TrimSpace(pluginNode.Value)
-
-			if value == "" {
-				return errors.New("plugin value cannot be empty: " + rule.ID)
-			}
-
-			pluginName, args, err := ParseFunctionCall(value)
+		if appendNode.usesPlugin() {
+			pluginName, args, err := ParseFunctionCall(appendValue)
 			if err != nil {
 				return err
 			}
 
-			if p, ok := plugin.Plugins[pluginName]; ok {
-				pluginNode.Plugin = p
+			if p, ok := plugin.GetPlugin(pluginName); ok {
+				appendNode.Plugin = p
 			} else {
 				// Check if it's a temporary component, temporary components should not be referenced
-				if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+				if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 					return errors.New("cannot reference temporary plugin '" + pluginName + "', please save it first")
 				}
 				return errors.New("not found this plugin: " + pluginName)
 			}
 
-			pluginNode.PluginArgs = args
-			// Update the plugin node in the map
-			rule.PluginMap[id] = pluginNode
+			appendNode.PluginArgs = args
+			if appendType == AppendMerge && appendNode.Plugin.ReturnType == "bool" {
+				return errors.New("append MERGE plugin must return a map, '" + pluginName + "' returns bool: " + rule.ID)
+			}
+		}
+		// Update the append node in the map
+		rule.AppendsMap[id] = appendNode
+	}
+
+	// Process plugins in PluginMap
+	for id, pluginNode := range rule.PluginMap {
+		value := strings.TrimSpace(pluginNode.Value)
+
+		if value == "" {
+			return errors.New("plugin value cannot be empty: " + rule.ID)
 		}
 
-		// Process thresholds in ThresholdMap
-		for id, threshold := range rule.ThresholdMap {
-			if threshold.group_by == "" && threshold.Range == "" && threshold.Value == 0 {
-				// No threshold configured, skip
-				continue
+		pluginName, args, err := ParseFunctionCall(value)
+		if err != nil {
+			return err
+		}
+
+		if p, ok := plugin.GetPlugin(pluginName); ok {
+			pluginNode.Plugin = p
+		} else {
+			// Check if it's a temporary component, temporary components should not be referenced
+			if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
+				return errors.New("cannot reference temporary plugin '" + pluginName + "', please save it first")
 			}
+			return errors.New("not found this plugin: " + pluginName)
+		}
+
+		pluginNode.PluginArgs = args
+		// Update the plugin node in the map
+		rule.PluginMap[id] = pluginNode
+	}
+
+	// Process thresholds in ThresholdMap
+	for id, threshold := range rule.ThresholdMap {
+		if threshold.group_by == "" && threshold.Range == "" && threshold.Value == 0 {
+			// No threshold configured, skip
+			continue
+		}
 
-			if threshold.group_by == "" {
-				return errors.New("threshold group_by cannot be empty: " + rule.ID)
+		if threshold.group_by == "" {
+			return errors.New("threshold group_by cannot be empty: " + rule.ID)
+		}
+		if threshold.Range == "" {
+			return errors.New("threshold range cannot be empty: " + rule.ID)
+		}
+		if threshold.Value <= 0 {
+			return errors.New("threshold value must be a positive integer (greater than 0): " + rule.ID)
+		}
+
+		if !(threshold.CountType == "" || threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY") {
+			return errors.New("threshold count_type must be empty (default count mode), 'SUM', or 'CLASSIFY': " + rule.ID)
+		}
+
+		if threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY" {
+			if threshold.CountField == "" {
+				return errors.New("threshold count_field cannot be empty when count_type is 'SUM' or 'CLASSIFY': " + rule.ID)
+			} else {
+				// Parse threshold count field path
+				threshold.CountFieldList = common.StringToList(strings.TrimSpace(threshold.CountField))
 			}
-			if threshold.Range == "" {
-				return errors.New("threshold range cannot be empty: " + rule.ID)
+		}
+
+		threshold.RangeInt, err = common.ParseDurationToSecondsInt(threshold.Range)
+		if err != nil {
+			return errors.New("threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
+		}
+
+		threshold.GroupByID = ruleset.RulesetID + rule.ID
+
+		if !*createLocalCache {
+			ruleset.Cache, err = ristretto.NewCache(&ristretto.Config[string, int]{
+				NumCounters: 10_000_000,       // number of keys to track frequency of.
+				MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
+				BufferItems: 32,               // number of keys per Get buffer.
+			})
+
+			if err != nil {
+				return fmt.Errorf("failed to create local cache: %w", err)
 			}
-			if threshold.Value <= 0 {
-				return errors.New("threshold value must be a positive integer (greater than 0): " + rule.ID)
+			*createLocalCache = true
+		}
+
+		if threshold.CountType == "CLASSIFY" {
+			if !*createLocalCacheForClassify {
+				ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]string]{
+					NumCounters: 10_000_000,       // number of keys to track frequency of.
+					MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
+					BufferItems: 32,               // number of keys per Get buffer.
+				})
+
+				if err != nil {
+					return fmt.Errorf("failed to create local cache: %w", err)
+				}
+				*createLocalCacheForClassify = true
 			}
+		}
+
+		// Parse threshold group by fields
+		thresholdGroupBYList := strings.Split(strings.TrimSpace(threshold.group_by), ",")
+		threshold.GroupByList = make(map[string][]string, len(thresholdGroupBYList))
+		for i := range thresholdGroupBYList {
+			tmpList := common.StringToList(thresholdGroupBYList[i])
+			threshold.GroupByList[thresholdGroupBYList[i]] = make([]string, len(tmpList))
+			threshold.GroupByList[thresholdGroupBYList[i]] = tmpList
+		}
+		// Update the threshold in the map
+		rule.ThresholdMap[id] = threshold
+	}
 
-			if !(threshold.CountType == "" || threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY") {
-				return errors.New("threshold count_type must be empty (default count mode), 'SUM', or 'CLASSIFY': " + rule.ID)
+	// Process iterators in IteratorMap
+	for id, iterator := range rule.IteratorMap {
+		// Parse iterator field path
+		if iterator.Field != "" {
+			iterator.FieldList = common.StringToList(strings.TrimSpace(iterator.Field))
+		}
+
+		// Process check nodes within iterator
+		for j := range iterator.CheckNodes {
+			node := &iterator.CheckNodes[j]
+			err := processCheckNode(node, nil, rule.ID)
+			if err != nil {
+				return err
 			}
+		}
 
-			if threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY" {
-				if threshold.CountField == "" {
-					return errors.New("threshold count_field cannot be empty when count_type is 'SUM' or 'CLASSIFY': " + rule.ID)
-				} else {
-					// Parse threshold count field path
-					threshold.CountFieldList = common.StringToList(strings.TrimSpace(threshold.CountField))
+		// Process threshold nodes within iterator
+		for j := range iterator.ThresholdNodes {
+			threshold := &iterator.ThresholdNodes[j]
+
+			// Parse threshold group by fields
+			if threshold.group_by != "" {
+				threshold.GroupByList = make(map[string][]string)
+				groupByFields := strings.Split(threshold.group_by, ",")
+				for _, field := range groupByFields {
+					field = strings.TrimSpace(field)
+					if field != "" {
+						threshold.GroupByList[field] = common.StringToList(field)
+					}
 				}
 			}
 
-			threshold.RangeInt, err = common.ParseDurationToSecondsInt(threshold.Range)
-			if err != nil {
-				return errors.New("threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
+			// Parse threshold range
+			if threshold.Range != "" {
+				rangeInt, err := common.ParseDurationToSecondsInt(threshold.Range)
+				if err != nil {
+					return errors.New("iterator threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
+				}
+				threshold.RangeInt = rangeInt
 			}
 
+			// Set threshold group ID for iterator thresholds
 			threshold.GroupByID = ruleset.RulesetID + rule.ID
 
-			if !createLocalCache {
+			// Initialize cache if needed for iterator thresholds
+			if threshold.LocalCache && !*createLocalCache {
 				ruleset.Cache, err = ristretto.NewCache(&ristretto.Config[string, int]{
 					NumCounters: 10_000_000,       // number of keys to track frequency of.
 					MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
@@ -2335,59 +2847,60 @@ func RulesetBuild(ruleset *Ruleset) error {
 				})
 
 				if err != nil {
-					return fmt.Errorf("failed to create local cache: %w", err)
+					return fmt.Errorf("failed to create local cache for iterator: %w", err)
 				}
-				createLocalCache = true
+				*createLocalCache = true
 			}
 
-			if threshold.CountType == "CLASSIFY" {
-				if !createLocalCacheForClassify {
-					ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]bool]{
-						NumCounters: 10_000_000,       // number of keys to track frequency of.
-						MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
-						BufferItems: 32,               // number of keys per Get buffer.
-					})
+			if threshold.CountType == "CLASSIFY" && !*createLocalCacheForClassify {
+				ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]string]{
+					NumCounters: 10_000_000,       // number of keys to track frequency of.
+					MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
+					BufferItems: 32,               // number of keys per Get buffer.
+				})
 
-					if err != nil {
-						return fmt.Errorf("failed to create local cache: %w", err)
-					}
-					createLocalCacheForClassify = true
+				if err != nil {
+					return fmt.Errorf("failed to create local cache for iterator classify: %w", err)
 				}
+				*createLocalCacheForClassify = true
 			}
 
-			// Parse threshold group by fields
-			thresholdGroupBYList := strings.Split(strings.TrimSpace(threshold.group_by), ",")
-			threshold.GroupByList = make(map[string][]string, len(thresholdGroupBYList))
-			for i := range thresholdGroupBYList {
-				tmpList := common.StringToList(thresholdGroupBYList[i])
-				threshold.GroupByList[thresholdGroupBYList[i]] = make([]string, len(tmpList))
-				threshold.GroupByList[thresholdGroupBYList[i]] = tmpList
+			// Parse count field for SUM and CLASSIFY types
+			if threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY" {
+				if threshold.CountField != "" {
+					threshold.CountFieldList = common.StringToList(strings.TrimSpace(threshold.CountField))
+				}
 			}
-			// Update the threshold in the map
-			rule.ThresholdMap[id] = threshold
 		}
 
-		// Process iterators in IteratorMap
-		for id, iterator := range rule.IteratorMap {
-			// Parse iterator field path
-			if iterator.Field != "" {
-				iterator.FieldList = common.StringToList(strings.TrimSpace(iterator.Field))
-			}
+		// Update the iterator in the map
+		rule.IteratorMap[id] = iterator
+	}
 
-			// Process check nodes within iterator
-			for j := range iterator.CheckNodes {
-				node := &iterator.CheckNodes[j]
-				err := processCheckNode(node, nil, rule.ID)
-				if err != nil {
+	// Process checklists within iterator
+	for id, iterator := range rule.IteratorMap {
+		for j := range iterator.Checklists {
+			cl := &iterator.Checklists[j]
+			if strings.TrimSpace(cl.Condition) != "" {
+				if _, _, ok := ConditionRegex.Find(strings.TrimSpace(cl.Condition)); ok {
+					cl.ConditionAST = GetAST(strings.TrimSpace(cl.Condition))
+					cl.ConditionMap = make(map[string]bool, len(cl.CheckNodes)+len(cl.ThresholdNodes))
+					cl.ConditionFlag = true
+					if err := bindConditionRefs(cl, rule.ID); err != nil {
+						return err
+					}
+				} else {
+					return errors.New("checklist condition is not a valid expression")
+				}
+			}
+			for k := range cl.CheckNodes {
+				node := &cl.CheckNodes[k]
+				if err := processCheckNode(node, cl, rule.ID); err != nil {
 					return err
 				}
 			}
-
-			// Process threshold nodes within iterator
-			for j := range iterator.ThresholdNodes {
-				threshold := &iterator.ThresholdNodes[j]
-
-				// Parse threshold group by fields
+			for k := range cl.ThresholdNodes {
+				threshold := &cl.ThresholdNodes[k]
 				if threshold.group_by != "" {
 					threshold.GroupByList = make(map[string][]string)
 					groupByFields := strings.Split(threshold.group_by, ",")
@@ -2398,141 +2911,53 @@ func RulesetBuild(ruleset *Ruleset) error {
 						}
 					}
 				}
-
-				// Parse threshold range
 				if threshold.Range != "" {
 					rangeInt, err := common.ParseDurationToSecondsInt(threshold.Range)
 					if err != nil {
-						return errors.New("iterator threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
+						return errors.New("iterator checklist threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
 					}
 					threshold.RangeInt = rangeInt
 				}
-
-				// Set threshold group ID for iterator thresholds
 				threshold.GroupByID = ruleset.RulesetID + rule.ID
 
-				// Initialize cache if needed for iterator thresholds
-				if threshold.LocalCache && !createLocalCache {
+				if threshold.LocalCache && !*createLocalCache {
+					var err error
 					ruleset.Cache, err = ristretto.NewCache(&ristretto.Config[string, int]{
-						NumCounters: 10_000_000,       // number of keys to track frequency of.
-						MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
-						BufferItems: 32,               // number of keys per Get buffer.
+						NumCounters: 10_000_000,
+						MaxCost:     1024 * 1024 * 64,
+						BufferItems: 32,
 					})
-
 					if err != nil {
-						return fmt.Errorf("failed to create local cache for iterator: %w", err)
+						return fmt.Errorf("failed to create local cache for iterator checklist: %w", err)
 					}
-					createLocalCache = true
+					*createLocalCache = true
 				}
-
-				if threshold.CountType == "CLASSIFY" && !createLocalCacheForClassify {
-					ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]bool]{
-						NumCounters: 10_000_000,       // number of keys to track frequency of.
-						MaxCost:     1024 * 1024 * 64, // maximum cost of cache.
-						BufferItems: 32,               // number of keys per Get buffer.
+				if threshold.CountType == "CLASSIFY" && !*createLocalCacheForClassify {
+					var err error
+					ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]string]{
+						NumCounters: 10_000_000,
+						MaxCost:     1024 * 1024 * 64,
+						BufferItems: 32,
 					})
-
 					if err != nil {
-						return fmt.Errorf("failed to create local cache for iterator classify: %w", err)
+						return fmt.Errorf("failed to create local cache for iterator checklist classify: %w", err)
 					}
-					createLocalCacheForClassify = true
+					*createLocalCacheForClassify = true
 				}
-
-				// Parse count field for SUM and CLASSIFY types
 				if threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY" {
 					if threshold.CountField != "" {
 						threshold.CountFieldList = common.StringToList(strings.TrimSpace(threshold.CountField))
 					}
 				}
 			}
-
-			// Update the iterator in the map
-			rule.IteratorMap[id] = iterator
-		}
-
-		// Process checklists within iterator
-		for id, iterator := range rule.IteratorMap {
-			for j := range iterator.Checklists {
-				cl := &iterator.Checklists[j]
-				if strings.TrimSpace(cl.Condition) != "" {
-					if _, _, ok := ConditionRegex.Find(strings.TrimSpace(cl.Condition)); ok {
-						cl.ConditionAST = GetAST(strings.TrimSpace(cl.Condition))
-						cl.ConditionMap = make(map[string]bool, len(cl.CheckNodes)+len(cl.ThresholdNodes))
-						cl.ConditionFlag = true
-					} else {
-						return errors.New("checklist condition is not a valid expression")
-					}
-				}
-				for k := range cl.CheckNodes {
-					node := &cl.CheckNodes[k]
-					if err := processCheckNode(node, cl, rule.ID); err != nil {
-						return err
-					}
-				}
-				for k := range cl.ThresholdNodes {
-					threshold := &cl.ThresholdNodes[k]
-					if threshold.group_by != "" {
-						threshold.GroupByList = make(map[string][]string)
-						groupByFields := strings.Split(threshold.group_by, ",")
-						for _, field := range groupByFields {
-							field = strings.TrimSpace(field)
-							if field != "" {
-								threshold.GroupByList[field] = common.StringToList(field)
-							}
-						}
-					}
-					if threshold.Range != "" {
-						rangeInt, err := common.ParseDurationToSecondsInt(threshold.Range)
-						if err != nil {
-							return errors.New("iterator checklist threshold parse range err: " + err.Error() + ", rule id: " + rule.ID)
-						}
-						threshold.RangeInt = rangeInt
-					}
-					threshold.GroupByID = ruleset.RulesetID + rule.ID
-
-					if threshold.LocalCache && !createLocalCache {
-						var err error
-						ruleset.Cache, err = ristretto.NewCache(&ristretto.Config[string, int]{
-							NumCounters: 10_000_000,
-							MaxCost:     1024 * 1024 * 64,
-							BufferItems: 32,
-						})
-						if err != nil {
-							return fmt.Errorf("failed to create local cache for iterator checklist: %w", err)
-						}
-						createLocalCache = true
-					}
-					if threshold.CountType == "CLASSIFY" && !createLocalCacheForClassify {
-						var err error
-						ruleset.CacheForClassify, err = ristretto.NewCache(&ristretto.Config[string, map[string]bool]{
-							NumCounters: 10_000_000,
-							MaxCost:     1024 * 1024 * 64,
-							BufferItems: 32,
-						})
-						if err != nil {
-							return fmt.Errorf("failed to create local cache for iterator checklist classify: %w", err)
-						}
-						createLocalCacheForClassify = true
-					}
-					if threshold.CountType == "SUM" || threshold.CountType == "CLASSIFY" {
-						if threshold.CountField != "" {
-							threshold.CountFieldList = common.StringToList(strings.TrimSpace(threshold.CountField))
-						}
-					}
-				}
-			}
-			// Update iterator back (in case of pointer changes)
-			rule.IteratorMap[id] = iterator
 		}
-
-		// Process del operations in DelMap (no additional processing needed as DelMap already contains parsed field paths)
+		// Update iterator back (in case of pointer changes)
+		rule.IteratorMap[id] = iterator
 	}
 
-	// Initialize regex result cache
-	if ruleset.RegexResultCache == nil {
-		ruleset.RegexResultCache = NewRegexResultCache(1000) // Default capacity: 1000 entries
-	}
+	// Process del operations in DelMap (no additional processing needed as DelMap already contains parsed field paths)
 
+	rule.applyScope()
 	return nil
 }
 
@@ -2540,21 +2965,6 @@ func RulesetBuild(ruleset *Ruleset) error {
 func processCheckNode(node *CheckNodes, checklist *Checklist, ruleID string) error {
 	node.FieldList = common.StringToList(strings.TrimSpace(node.Field))
 
-	if checklist != nil && checklist.ConditionFlag {
-		id := strings.TrimSpace(node.ID)
-		node.ID = id
-
-		if id == "" {
-			return errors.New("check node id cannot be empty: " + ruleID)
-		}
-
-		if _, ok := checklist.ConditionMap[id]; ok {
-			return errors.New("check node id cannot be repeated: " + ruleID)
-		} else {
-			checklist.ConditionMap[id] = false
-		}
-	}
-
 	switch strings.TrimSpace(node.Type) {
 	case "PLUGIN":
 		pluginName, args, isNegated, err := ParseCheckNodePluginCall(node.Value)
@@ -2562,14 +2972,14 @@ func processCheckNode(node *CheckNodes, checklist *Checklist, ruleID string) err
 			return err
 		}
 
-		if p, ok := plugin.Plugins[pluginName]; ok {
+		if p, ok := plugin.GetPlugin(pluginName); ok {
 			// Use the original plugin instance to ensure statistics are recorded correctly
 			node.Plugin = p
 			// Store negation flag separately since we can't modify the original plugin
 			node.IsNegated = isNegated
 		} else {
 			// Check if it's a temporary component, temporary components should not be referenced
-			if _, tempExists := plugin.PluginsNew[pluginName]; tempExists {
+			if _, tempExists := plugin.GetPluginNew(pluginName); tempExists {
 				return errors.New("cannot reference temporary plugin '" + pluginName + "', please save it first (rule id: " + ruleID + ")")
 			}
 			return errors.New("not found this plugin: " + pluginName + " rule id: " + ruleID)
@@ -2603,8 +3013,14 @@ func processCheckNode(node *CheckNodes, checklist *Checklist, ruleID string) err
 		node.CheckFunc = NCS_NI
 	case "MT":
 		node.CheckFunc = MT
+		if node.As == CheckAsTime {
+			node.CheckFunc = MT_TIME
+		}
 	case "LT":
 		node.CheckFunc = LT
+		if node.As == CheckAsTime {
+			node.CheckFunc = LT_TIME
+		}
 	case "REGEX":
 		// REGEX handled below
 	case "ISNULL":
@@ -2,8 +2,8 @@ package rules_engine
 
 import (
 	"AgentSmith-HUB/common"
-	"AgentSmith-HUB/logger"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -11,33 +11,35 @@ import (
 	regexp "github.com/BurntSushi/rure-go"
 )
 
-// RedisFRQSum performs frequency sum aggregation using Redis
+// redisThresholdSum is the cluster-wide counter of non-local thresholds, replaced in tests
+var redisThresholdSum = common.RedisThresholdSum
+
+// redisThresholdClassify is the cluster-wide set of non-local CLASSIFY thresholds, replaced in tests
+var redisThresholdClassify = common.RedisThresholdClassify
+
+// RedisFRQSum performs frequency sum aggregation using Redis, so every node running the ruleset adds
+// to the same counter
 // groupByKey: Redis key for grouping
 // sumData: Value to add to the sum
 // rangeInt: Time range in seconds
 // threshold: Threshold value to trigger
-// Returns: true if threshold is exceeded, false otherwise
-func RedisFRQSum(groupByKey string, sumData int, rangeInt int, threshold int) (bool, error) {
-	var res = false
-	redisSetNXRes, err := common.RedisSetNX(groupByKey, sumData, rangeInt)
+// Returns: true if threshold is exceeded, with the count or sum that exceeded it
+func RedisFRQSum(groupByKey string, sumData int, rangeInt int, threshold int) (bool, int, error) {
+	res, count, err := redisThresholdSum(groupByKey, int64(sumData), rangeInt, threshold)
 	if err != nil {
-		return false, fmt.Errorf("failed to set Redis key %s: %w", groupByKey, err)
+		return false, 0, fmt.Errorf("failed to add to Redis key %s: %w", groupByKey, err)
 	}
+	return res, int(count), nil
+}
 
-	if !redisSetNXRes {
-		groupByValue, err := common.RedisIncrby(groupByKey, int64(sumData))
-		if err != nil {
-			return false, fmt.Errorf("failed to increment Redis key %s: %w", groupByKey, err)
-		} else {
-			if groupByValue > int64(threshold) {
-				res = true
-				if err := common.RedisDel(groupByKey); err != nil {
-					logger.Error("failed to delete Redis key %s: %v", groupByKey, err)
-				}
-			}
-		}
+// sortedGroupByFields returns the group_by fields of a threshold in sorted order
+func sortedGroupByFields(groupBy map[string][]string) []string {
+	fields := make([]string, 0, len(groupBy))
+	for field := range groupBy {
+		fields = append(fields, field)
 	}
-	return res, nil
+	sort.Strings(fields)
+	return fields
 }
 
 // LocalCacheFRQSum performs frequency sum aggregation using local cache
@@ -45,8 +47,8 @@ func RedisFRQSum(groupByKey string, sumData int, rangeInt int, threshold int) (b
 // sumData: Value to add to the sum
 // rangeInt: Time range in seconds
 // threshold: Threshold value to trigger
-// Returns: true if threshold is exceeded, false otherwise
-func (r *Ruleset) LocalCacheFRQSum(groupByKey string, sumData int, rangeInt int, threshold int) (bool, error) {
+// Returns: true if threshold is exceeded, with the count or sum that exceeded it
+func (r *Ruleset) LocalCacheFRQSum(groupByKey string, sumData int, rangeInt int, threshold int) (bool, int, error) {
 	// Acquire write lock to protect cache operations
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -54,7 +56,7 @@ func (r *Ruleset) LocalCacheFRQSum(groupByKey string, sumData int, rangeInt int,
 	if v, ok := r.Cache.Get(groupByKey); ok {
 		if v+sumData > threshold {
 			r.Cache.Del(groupByKey)
-			return true, nil
+			return true, v + sumData, nil
 		} else {
 			if tmpTtl, exist := r.Cache.GetTTL(groupByKey); exist {
 				success := r.Cache.SetWithTTL(groupByKey, v+sumData, 1, tmpTtl)
@@ -69,7 +71,7 @@ func (r *Ruleset) LocalCacheFRQSum(groupByKey string, sumData int, rangeInt int,
 					r.Cache.Wait()
 				}
 			}
-			return false, nil
+			return false, v + sumData, nil
 		}
 	} else {
 		// Use cost=1 instead of 0, as ristretto may have special handling for cost=0
@@ -79,78 +81,68 @@ func (r *Ruleset) LocalCacheFRQSum(groupByKey string, sumData int, rangeInt int,
 			// Wait for the cache to be ready (ristretto is async)
 			r.Cache.Wait()
 		}
-		return false, nil
+		return false, sumData, nil
 	}
 }
 
-// RedisFRQClassify performs frequency classification using Redis
-// tmpKey: Temporary key for tracking
-// groupByKey: Base key for grouping
+// RedisFRQClassify performs frequency classification using a Redis set of the distinct values, so
+// every node running the ruleset adds to the same set
+// groupByKey: Redis key of the set for grouping
+// value: Classified value to add
 // rangeInt: Time range in seconds
 // threshold: Threshold value to trigger
-// Returns: true if threshold is exceeded, false otherwise
-func RedisFRQClassify(tmpKey string, groupByKey string, rangeInt int, threshold int) (bool, error) {
-	var res = false
-	_, err := common.RedisSet(tmpKey, 1, rangeInt)
+// Returns: true if threshold is exceeded, with the distinct values that exceeded it
+func RedisFRQClassify(groupByKey string, value string, rangeInt int, threshold int) (bool, []string, error) {
+	res, values, err := redisThresholdClassify(groupByKey, value, rangeInt, threshold)
 	if err != nil {
-		return false, fmt.Errorf("failed to set Redis key %s: %w", tmpKey, err)
-	}
-
-	tmpRes, err := common.RedisKeys(groupByKey + "*")
-	if err != nil {
-		return false, fmt.Errorf("failed to get Redis keys matching %s*: %w", groupByKey, err)
-	}
-
-	if len(tmpRes) > threshold {
-		res = true
-		for i := range tmpRes {
-			if err := common.RedisDel(tmpRes[i]); err != nil {
-				logger.Error("failed to delete Redis key %s: %v", tmpRes[i], err)
-			}
-		}
+		return false, nil, fmt.Errorf("failed to add to Redis set %s: %w", groupByKey, err)
 	}
-	return res, nil
+	sort.Strings(values)
+	return res, values, nil
 }
 
-func (r *Ruleset) LocalCacheFRQClassify(tmpKey string, groupByKey string, rangeInt int, threshold int) (bool, error) {
+func (r *Ruleset) LocalCacheFRQClassify(tmpKey string, groupByKey string, value string, rangeInt int, threshold int) (bool, []string, error) {
 	// Acquire write lock to protect cache operations
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if keys, ok := r.CacheForClassify.Get(groupByKey); ok {
 		// Create a copy of the map to avoid modifying the cached map directly
-		keysCopy := make(map[string]bool)
+		keysCopy := make(map[string]string)
 		for k, v := range keys {
 			keysCopy[k] = v
 		}
 
-		count := len(keysCopy) + 1
 		for key := range keysCopy {
 			if _, okk := r.Cache.Get(key); !okk {
-				count = count - 1
 				delete(keysCopy, key)
 			}
 		}
+		// Count distinct values like the Redis variant, a repeated value does not add to the count
+		keysCopy[tmpKey] = value
 
-		if count > threshold {
-			for key := range keysCopy {
+		if len(keysCopy) > threshold {
+			values := make([]string, 0, len(keysCopy))
+			for key, v := range keysCopy {
+				values = append(values, v)
 				r.Cache.Del(key)
 			}
 			r.CacheForClassify.Del(groupByKey)
-			return true, nil
+			sort.Strings(values)
+			return true, values, nil
 		} else {
-			keysCopy[tmpKey] = true
 			r.CacheForClassify.SetWithTTL(groupByKey, keysCopy, 1, time.Duration(rangeInt*2)*time.Second)
+			r.CacheForClassify.Wait()
 			success := r.Cache.SetWithTTL(tmpKey, 1, 1, time.Duration(rangeInt)*time.Second)
 			if success {
 				// Wait for the cache to be ready (ristretto is async)
 				r.Cache.Wait()
 			}
-			return false, nil
+			return false, nil, nil
 		}
 	} else {
-		keys := map[string]bool{
-			tmpKey: true,
+		keys := map[string]string{
+			tmpKey: value,
 		}
 		success := r.Cache.SetWithTTL(tmpKey, 1, 1, time.Duration(rangeInt)*time.Second)
 		if success {
@@ -158,7 +150,8 @@ func (r *Ruleset) LocalCacheFRQClassify(tmpKey string, groupByKey string, rangeI
 			r.Cache.Wait()
 		}
 		r.CacheForClassify.SetWithTTL(groupByKey, keys, 1, time.Duration(rangeInt*2)*time.Second)
-		return false, nil
+		r.CacheForClassify.Wait()
+		return false, nil, nil
 	}
 }
 
@@ -215,6 +208,12 @@ func GetRuleValueFromRawFromCache(cache map[string]common.CheckCoreCache, checkK
 	}
 }
 
+// GetRuleValueFromRawWithExist resolves a _$ field reference and reports whether the field exists
+func GetRuleValueFromRawWithExist(cache map[string]common.CheckCoreCache, checkKey string, data map[string]interface{}) (string, bool) {
+	res := GetRuleValueFromRawFromCache(cache, checkKey, data)
+	return res, cache[checkKey].Exist
+}
+
 func GetCheckDataFromCache(cache map[string]common.CheckCoreCache, checkKey string, data map[string]interface{}, checkKeyList []string) (res string, exist bool) {
 	tmpRes, ok := cache[checkKey]
 	if ok {
@@ -384,12 +383,13 @@ func NCS_NI(data string, ruleData string) (res bool, hitData string) {
 }
 
 func MT(data string, ruleData string) (res bool, hitData string) {
-	ori_int, err := strconv.ParseFloat(data, 64)
-	if err != nil {
-		return false, ""
-	}
-	check_int, err := strconv.ParseFloat(ruleData, 64)
-	if err != nil {
+	ori_int, err1 := strconv.ParseFloat(data, 64)
+	check_int, err2 := strconv.ParseFloat(ruleData, 64)
+	if err1 != nil || err2 != nil {
+		// Not both numeric: compare chronologically when both operands are timestamps
+		if cmp, ok := compareTimes(data, ruleData, false); ok && cmp > 0 {
+			return true, ruleData
+		}
 		return false, ""
 	}
 
@@ -401,12 +401,13 @@ func MT(data string, ruleData string) (res bool, hitData string) {
 }
 
 func LT(data string, ruleData string) (res bool, hitData string) {
-	ori_int, err := strconv.ParseFloat(data, 64)
-	if err != nil {
-		return false, ""
-	}
-	check_int, err := strconv.ParseFloat(ruleData, 64)
-	if err != nil {
+	ori_int, err1 := strconv.ParseFloat(data, 64)
+	check_int, err2 := strconv.ParseFloat(ruleData, 64)
+	if err1 != nil || err2 != nil {
+		// Not both numeric: compare chronologically when both operands are timestamps
+		if cmp, ok := compareTimes(data, ruleData, false); ok && cmp < 0 {
+			return true, ruleData
+		}
 		return false, ""
 	}
 
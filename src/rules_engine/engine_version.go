@@ -0,0 +1,121 @@
+package rules_engine
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Engine versions a ruleset can pin with the root attribute engine_version. A ruleset without the
+// attribute always gets the current engine; a pinned ruleset keeps the semantics of its version and
+// is rejected when it uses constructs introduced later.
+const (
+	// EngineVersion1 is the original DSL
+	EngineVersion1 = 1
+	// EngineVersion2 adds check attributes strict, on_error, as and match, root attributes sample_trace,
+	// sample, on_rule_error, quiet, first_match, batch_size and plugin_cache, the rule attributes scope
+	// and group, the aggregate, cooldown, requires and test elements, the append types MERGE and DECODE,
+	// threshold attributes classify_values_field, classify_count_field and fire_count_field, and no-match
+	// semantics for _$ references to missing fields (v1 compares them against "")
+	EngineVersion2 = 2
+
+	CurrentEngineVersion = EngineVersion2
+)
+
+// engineFeatureVersions lists the engine version each construct was introduced in. Constructs not
+// listed exist since EngineVersion1. Keys are "root@attr", "check@attr", "check:TYPE", "append:TYPE" or the
+// element name.
+var engineFeatureVersions = map[string]int{
+	"root@sample_trace":  EngineVersion2,
+	"root@on_rule_error": EngineVersion2,
+	"root@quiet":         EngineVersion2,
+	"root@sample":        EngineVersion2,
+	"root@first_match":   EngineVersion2,
+	"root@batch_size":    EngineVersion2,
+	"root@plugin_cache":  EngineVersion2,
+	"check@strict":       EngineVersion2,
+	"check@on_error":     EngineVersion2,
+	"check@as":           EngineVersion2,
+	"check@match":        EngineVersion2,
+	"rule@scope":         EngineVersion2,
+	"rule@group":         EngineVersion2,
+	"aggregate":          EngineVersion2,
+	"cooldown":           EngineVersion2,
+	"requires":           EngineVersion2,
+	"test":               EngineVersion2,
+	"append:MERGE":       EngineVersion2,
+	"append:DECODE":      EngineVersion2,
+
+	"threshold@classify_values_field": EngineVersion2,
+	"threshold@classify_count_field":  EngineVersion2,
+	"threshold@fire_count_field":      EngineVersion2,
+}
+
+// parseEngineVersion validates the root attribute engine_version
+func parseEngineVersion(value string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || v < EngineVersion1 || v > CurrentEngineVersion {
+		return 0, fmt.Errorf("root engine_version must be between %d and %d, got '%s'", EngineVersion1, CurrentEngineVersion, value)
+	}
+	return v, nil
+}
+
+// effectiveEngineVersion returns the declared version, or the current one when none was declared
+func effectiveEngineVersion(declared int) int {
+	if declared == 0 {
+		return CurrentEngineVersion
+	}
+	return declared
+}
+
+// requireEngineVersion rejects a construct that is newer than the declared engine version
+func requireEngineVersion(declared int, feature string) error {
+	need, ok := engineFeatureVersions[feature]
+	if !ok || effectiveEngineVersion(declared) >= need {
+		return nil
+	}
+	return fmt.Errorf("%s requires engine_version >= %d, but the ruleset declares engine_version=\"%d\"",
+		describeEngineFeature(feature), need, declared)
+}
+
+func describeEngineFeature(feature string) string {
+	if elem, attr, ok := strings.Cut(feature, "@"); ok {
+		return fmt.Sprintf("%s attribute '%s'", elem, attr)
+	}
+	if elem, typ, ok := strings.Cut(feature, ":"); ok {
+		return fmt.Sprintf("%s type '%s'", elem, typ)
+	}
+	return fmt.Sprintf("element <%s>", feature)
+}
+
+// checkElementVersion rejects an element, attribute, check type or append type newer than the engine version
+// declared on root. The root element itself sets the version before its other attributes are checked.
+func (d *XMLDecoder) checkElementVersion(element xml.StartElement) error {
+	name := element.Name.Local
+	if name == "root" {
+		for _, attr := range element.Attr {
+			if attr.Name.Local == "engine_version" {
+				v, err := parseEngineVersion(attr.Value)
+				if err != nil {
+					return err
+				}
+				d.engineVersion = v
+			}
+		}
+	}
+	if err := requireEngineVersion(d.engineVersion, name); err != nil {
+		return err
+	}
+	for _, attr := range element.Attr {
+		if err := requireEngineVersion(d.engineVersion, name+"@"+attr.Name.Local); err != nil {
+			return err
+		}
+		if (name == "check" || name == "append") && attr.Name.Local == "type" {
+			if err := requireEngineVersion(d.engineVersion, name+":"+strings.TrimSpace(attr.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
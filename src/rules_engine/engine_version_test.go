@@ -0,0 +1,129 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEngineVersion_RejectsNewerConstructs(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		wantErr string
+	}{
+		{
+			"on_error under v1",
+			`<root type="DETECTION" name="v1" engine_version="1">
+  <rule id="r1" name="r1">
+    <check type="PLUGIN" on_error="nomatch">isPrivateIP(_$ip)</check>
+  </rule>
+</root>`,
+			`check attribute 'on_error' requires engine_version >= 2, but the ruleset declares engine_version="1"`,
+		},
+		{
+			"strict inside iterator under v1",
+			`<root type="DETECTION" name="v1" engine_version="1">
+  <rule id="r1" name="r1">
+    <iterator type="ANY" field="items" variable="item">
+      <check type="ISNULL" field="item.name" strict="true"></check>
+    </iterator>
+  </rule>
+</root>`,
+			`check attribute 'strict' requires engine_version >= 2`,
+		},
+		{
+			"sample_trace before engine_version",
+			`<root type="DETECTION" name="v1" sample_trace="true" engine_version="1">
+  <rule id="r1" name="r1"><check type="EQU" field="a">b</check></rule>
+</root>`,
+			`root attribute 'sample_trace' requires engine_version >= 2`,
+		},
+		{
+			"first_match under v1",
+			`<root type="DETECTION" name="v1" engine_version="1" first_match="true">
+  <rule id="r1" name="r1"><check type="EQU" field="a">b</check></rule>
+</root>`,
+			`root attribute 'first_match' requires engine_version >= 2`,
+		},
+		{
+			"batch_size under v1",
+			`<root type="DETECTION" name="v1" engine_version="1" batch_size="10">
+  <rule id="r1" name="r1"><check type="EQU" field="a">b</check></rule>
+</root>`,
+			`root attribute 'batch_size' requires engine_version >= 2`,
+		},
+		{
+			"plugin_cache under v1",
+			`<root type="DETECTION" name="v1" engine_version="1" plugin_cache="100">
+  <rule id="r1" name="r1"><check type="EQU" field="a">b</check></rule>
+</root>`,
+			`root attribute 'plugin_cache' requires engine_version >= 2`,
+		},
+		{
+			"MERGE append under v1",
+			`<root type="DETECTION" name="v1" engine_version="1">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="a">b</check>
+    <append type="MERGE">{"tag": "x"}</append>
+  </rule>
+</root>`,
+			`append type 'MERGE' requires engine_version >= 2`,
+		},
+		{
+			"DECODE append under v1",
+			`<root type="DETECTION" name="v1" engine_version="1">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="a">b</check>
+    <append type="DECODE" encoding="base64" field="decoded">_$payload</append>
+  </rule>
+</root>`,
+			`append type 'DECODE' requires engine_version >= 2`,
+		},
+		{
+			"unknown version",
+			`<root type="DETECTION" name="v9" engine_version="9">
+  <rule id="r1" name="r1"><check type="EQU" field="a">b</check></rule>
+</root>`,
+			`root engine_version must be between 1 and 2, got '9'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRuleset([]byte(tt.xml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// The same constructs are accepted when the version allows them or none is declared
+	for _, version := range []string{` engine_version="2"`, ``} {
+		xml := `<root type="DETECTION" name="v2"` + version + `><rule id="r1" name="r1"><check type="ISNULL" field="a" strict="true"></check></rule></root>`
+		if _, err := ParseRuleset([]byte(xml)); err != nil {
+			t.Fatalf("expected strict to be accepted with%s, got %v", version, err)
+		}
+	}
+}
+
+func TestEngineVersion_V1MissingReferenceComparesEmpty(t *testing.T) {
+	v1 := buildRulesetFromXML(t, `
+<root type="DETECTION" name="v1" engine_version="1">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="owner">_$user</check>
+  </rule>
+</root>`)
+	v2 := buildRulesetFromXML(t, `
+<root type="DETECTION" name="v2" engine_version="2">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="owner">_$user</check>
+  </rule>
+</root>`)
+
+	data := map[string]interface{}{"owner": ""}
+	if len(v1.EngineCheck(data)) != 1 {
+		t.Fatal("v1: expected a missing reference to compare as empty string")
+	}
+	if len(v2.EngineCheck(data)) != 0 {
+		t.Fatal("v2: expected a missing reference to never match")
+	}
+}
@@ -0,0 +1,86 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+)
+
+// traceCacheKey holds the event's *EvalTrace in the per-event rule cache while a sampled event is
+// evaluated. Like onErrorRouteCacheKey it starts with a NUL byte so it cannot collide with fields.
+const traceCacheKey = "\x00eval_trace"
+
+// maxTraceNodes caps the recorded check nodes per event; iterators can evaluate a node many times
+const maxTraceNodes = 256
+
+// EvalTrace is the evaluation result of every rule for one sampled event
+type EvalTrace struct {
+	Rules     []RuleTrace `json:"rules"`
+	Truncated bool        `json:"truncated,omitempty"` // true when maxTraceNodes was reached
+	nodes     int
+}
+
+// RuleTrace records whether a rule matched and the outcome of each check node it evaluated
+type RuleTrace struct {
+	ID      string `json:"id"`
+	Matched bool   `json:"matched"`
+	// MissingField is the required field whose absence skipped the rule
+	MissingField string      `json:"missing_field,omitempty"`
+	Nodes        []NodeTrace `json:"nodes,omitempty"`
+}
+
+// NodeTrace is the outcome of one check node evaluation
+type NodeTrace struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Field   string `json:"field,omitempty"`
+	Matched bool   `json:"matched"`
+}
+
+// recordNodeTrace appends the check node result to the current rule of the event trace, if any
+func recordNodeTrace(ruleCache map[string]common.CheckCoreCache, checkNode *CheckNodes, matched bool) {
+	cached, ok := ruleCache[traceCacheKey]
+	if !ok {
+		return
+	}
+	trace, ok := cached.TypedData.(*EvalTrace)
+	if !ok || len(trace.Rules) == 0 {
+		return
+	}
+	if trace.nodes >= maxTraceNodes {
+		trace.Truncated = true
+		return
+	}
+	trace.nodes++
+
+	rule := &trace.Rules[len(trace.Rules)-1]
+	rule.Nodes = append(rule.Nodes, NodeTrace{
+		ID:      checkNode.ID,
+		Type:    checkNode.Type,
+		Field:   checkNode.Field,
+		Matched: matched,
+	})
+}
+
+// tracedCheck evaluates a sampled event with tracing. It returns the rule results, a copy of the
+// event as it was before evaluation and the trace.
+func (r *Ruleset) tracedCheck(data map[string]interface{}) ([]map[string]interface{}, map[string]interface{}, *EvalTrace) {
+	// Rules that do not modify data still add the hit rule id to the event, so sample a copy
+	sample := common.MapDeepCopy(data)
+	trace := &EvalTrace{Rules: make([]RuleTrace, 0, len(r.Rules))}
+	results := r.engineCheck(data, trace)
+	return results, sample, trace
+}
+
+// MatchedRules evaluates an event and returns the IDs of the rules that matched it in evaluation
+// order: every rule that hit for DETECTION, the rule that filtered the event for EXCLUDE. The event
+// is not modified.
+func (r *Ruleset) MatchedRules(data map[string]interface{}) []string {
+	trace := &EvalTrace{Rules: make([]RuleTrace, 0, len(r.Rules))}
+	r.engineCheck(common.MapDeepCopy(data), trace)
+	matched := make([]string, 0, 1)
+	for _, rule := range trace.Rules {
+		if rule.Matched {
+			matched = append(matched, rule.ID)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,75 @@
+package rules_engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"AgentSmith-HUB/common"
+)
+
+const traceRulesetXML = `
+<root type="DETECTION" name="trace" sample_trace="true">
+  <rule id="r1" name="r1">
+    <check id="c1" type="EQU" field="action">login</check>
+    <check id="c2" type="INCL" field="user">admin</check>
+  </rule>
+  <rule id="r2" name="r2">
+    <check type="EQU" field="action">logout</check>
+  </rule>
+</root>`
+
+func TestTracedCheck_RecordsNodeResults(t *testing.T) {
+	rs := buildRulesetFromXML(t, traceRulesetXML)
+	if !rs.SampleTrace {
+		t.Fatal("expected sample_trace to be parsed")
+	}
+
+	data := map[string]interface{}{"action": "login", "user": "guest"}
+	results, sample, trace := rs.tracedCheck(data)
+	if len(results) != 0 {
+		t.Fatalf("expected no hits, got %d", len(results))
+	}
+	if _, ok := sample[HitRuleIdFieldName]; ok {
+		t.Fatal("sampled event must be captured before evaluation")
+	}
+
+	if len(trace.Rules) != 2 {
+		t.Fatalf("expected 2 rule traces, got %d", len(trace.Rules))
+	}
+	r1 := trace.Rules[0]
+	if r1.ID != "r1" || r1.Matched || len(r1.Nodes) != 2 {
+		t.Fatalf("unexpected r1 trace: %+v", r1)
+	}
+	if !r1.Nodes[0].Matched || r1.Nodes[0].ID != "c1" || r1.Nodes[1].Matched || r1.Nodes[1].Field != "user" {
+		t.Fatalf("unexpected r1 node trace: %+v", r1.Nodes)
+	}
+	if r2 := trace.Rules[1]; r2.Matched || len(r2.Nodes) != 1 || r2.Nodes[0].Matched {
+		t.Fatalf("unexpected r2 trace: %+v", r2)
+	}
+}
+
+func TestTracedCheck_TraceStoredInSample(t *testing.T) {
+	rs := buildRulesetFromXML(t, traceRulesetXML)
+	_, sample, trace := rs.tracedCheck(map[string]interface{}{"action": "logout"})
+
+	out, err := json.Marshal(common.SampleData{Data: sample, Trace: trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"trace":{"rules":[{"id":"r1","matched":false,"nodes":[{"id":"c1","type":"EQU","field":"action","matched":false}]},{"id":"r2","matched":true`) {
+		t.Fatalf("sample does not carry the node trace: %s", out)
+	}
+
+	// Without a trace the field is omitted to keep regular samples small
+	out, _ = json.Marshal(common.SampleData{Data: sample})
+	if strings.Contains(string(out), "trace") {
+		t.Fatalf("untraced sample should not contain a trace: %s", out)
+	}
+}
+
+func TestSampleTrace_InvalidValue(t *testing.T) {
+	if _, err := ParseRuleset([]byte(`<root type="DETECTION" sample_trace="yes-please"></root>`)); err == nil {
+		t.Fatal("expected invalid sample_trace value to be rejected")
+	}
+}
@@ -0,0 +1,88 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestINCL_FieldReferencedValue(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION" name="field_ref">
+  <rule id="r1" name="r1">
+    <check type="INCL" field="url">_$hostname</check>
+  </rule>
+</root>`)
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want bool
+	}{
+		{"contains referenced value", map[string]interface{}{"url": "https://web01.corp/login", "hostname": "web01"}, true},
+		{"does not contain referenced value", map[string]interface{}{"url": "https://web02.corp/login", "hostname": "web01"}, false},
+		{"missing referenced field", map[string]interface{}{"url": "https://web01.corp/login"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(rs.EngineCheck(tt.data)) > 0; got != tt.want {
+				t.Fatalf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestINCL_FieldReferencedDelimitedValues(t *testing.T) {
+	orRS := buildRulesetFromXML(t, `
+<root type="DETECTION" name="field_ref_or">
+  <rule id="r1" name="r1">
+    <check type="INCL" field="cmd" logic="OR" delimiter="|">_$user.name|_$missing</check>
+  </rule>
+</root>`)
+	andRS := buildRulesetFromXML(t, `
+<root type="DETECTION" name="field_ref_and">
+  <rule id="r1" name="r1">
+    <check type="INCL" field="cmd" logic="AND" delimiter="|">_$user.name|sudo</check>
+  </rule>
+</root>`)
+
+	data := map[string]interface{}{"cmd": "sudo -u alice id", "user": map[string]interface{}{"name": "alice"}}
+	if len(orRS.EngineCheck(data)) != 1 {
+		t.Fatal("OR: expected match on the present reference, missing one is skipped")
+	}
+	if len(andRS.EngineCheck(data)) != 1 {
+		t.Fatal("AND: expected match when every value is contained")
+	}
+
+	noUser := map[string]interface{}{"cmd": "sudo -u alice id"}
+	if len(orRS.EngineCheck(noUser)) != 0 {
+		t.Fatal("OR: expected no match when every reference is missing")
+	}
+	if len(andRS.EngineCheck(noUser)) != 0 {
+		t.Fatal("AND: expected no match when a reference is missing")
+	}
+}
+
+func TestFieldReference_InvalidSyntax(t *testing.T) {
+	for _, value := range []string{"_$", "_$a..b", "_$a.", "_$.a", "_$ORIDATA", "_$host name"} {
+		t.Run(value, func(t *testing.T) {
+			xml := `<root type="DETECTION" name="bad"><rule id="r1" name="r1"><check type="INCL" field="url">` + value + `</check></rule></root>`
+			if _, err := ParseRuleset([]byte(xml)); err == nil {
+				t.Fatalf("expected %q to be rejected when parsing", value)
+			}
+
+			result, err := ValidateWithDetails("", xml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, e := range result.Errors {
+				if strings.Contains(e.Message+e.Detail, "field") {
+					found = true
+				}
+			}
+			if result.IsValid || !found {
+				t.Fatalf("expected a field reference validation error for %q, got %+v", value, result.Errors)
+			}
+		})
+	}
+}
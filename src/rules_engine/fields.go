@@ -0,0 +1,214 @@
+package rules_engine
+
+import (
+	"sort"
+	"strings"
+)
+
+// RulesetFields is the data contract of a ruleset: the event fields its rules read, write and delete.
+// Fields read inside an <iterator> are reported below the iterated field as "field.*.child".
+// Requires lists the reads declared with <requires>, without which rules are skipped.
+type RulesetFields struct {
+	Reads    []string `json:"reads"`
+	Writes   []string `json:"writes"`
+	Deletes  []string `json:"deletes"`
+	Requires []string `json:"requires,omitempty"`
+}
+
+type fieldSet map[string]struct{}
+
+func (s fieldSet) add(path []string) {
+	if len(path) > 0 {
+		s[strings.Join(path, ".")] = struct{}{}
+	}
+}
+
+// addRef adds the field of a _$ reference, ignoring literal values and _$ORIDATA
+func (s fieldSet) addRef(value string) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, FromRawSymbol) && value != PluginArgFromRawSymbol {
+		s.add(fieldPath(value[FromRawSymbolLen:]))
+	}
+}
+
+func (s fieldSet) addPluginArgs(args []*PluginArg) {
+	for _, arg := range args {
+		if arg == nil || arg.Type != 1 {
+			continue
+		}
+		if field, ok := arg.Value.(string); ok {
+			s.add(fieldPath(field))
+		}
+	}
+}
+
+func (s fieldSet) sorted() []string {
+	res := make([]string, 0, len(s))
+	for f := range s {
+		res = append(res, f)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// fieldPath splits a dotted field name like the parser does, keeping escaped dots in the segment
+func fieldPath(field string) []string {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return nil
+	}
+	var res []string
+	var sb strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+1 < len(field) && field[i+1] == '.' {
+			sb.WriteString(`\.`)
+			i++
+		} else if field[i] == '.' {
+			res = append(res, sb.String())
+			sb.Reset()
+		} else {
+			sb.WriteByte(field[i])
+		}
+	}
+	return append(res, sb.String())
+}
+
+// Fields returns the fields the built rules of the ruleset read, write and delete
+func (r *Ruleset) Fields() RulesetFields {
+	reads, writes, deletes, requires := fieldSet{}, fieldSet{}, fieldSet{}, fieldSet{}
+	for i := range r.Rules {
+		r.Rules[i].collectFields(reads, writes, deletes)
+		r.Rules[i].collectRequires(requires)
+	}
+	return newRulesetFields(reads, writes, deletes, requires)
+}
+
+// Fields returns the fields a single built rule reads, writes and deletes
+func (rule *Rule) Fields() RulesetFields {
+	reads, writes, deletes, requires := fieldSet{}, fieldSet{}, fieldSet{}, fieldSet{}
+	rule.collectFields(reads, writes, deletes)
+	rule.collectRequires(requires)
+	return newRulesetFields(reads, writes, deletes, requires)
+}
+
+func newRulesetFields(reads, writes, deletes, requires fieldSet) RulesetFields {
+	fields := RulesetFields{Reads: reads.sorted(), Writes: writes.sorted(), Deletes: deletes.sorted()}
+	if len(requires) > 0 {
+		fields.Requires = requires.sorted()
+	}
+	return fields
+}
+
+func (rule *Rule) collectRequires(requires fieldSet) {
+	for _, field := range rule.Requires {
+		requires.add(fieldPath(field))
+	}
+}
+
+func (rule *Rule) collectFields(reads, writes, deletes fieldSet) {
+	for _, field := range rule.Requires {
+		reads.add(fieldPath(field))
+	}
+	for _, checklist := range rule.ChecklistMap {
+		collectChecklistReads(reads, writes, &checklist)
+	}
+	for _, node := range rule.CheckMap {
+		collectCheckReads(reads, &node)
+	}
+	for _, threshold := range rule.ThresholdMap {
+		collectThresholdFields(reads, writes, &threshold)
+	}
+	for _, iterator := range rule.IteratorMap {
+		collectIteratorReads(reads, writes, &iterator)
+	}
+	for _, a := range rule.AppendsMap {
+		if a.FieldName != PluginArgFromRawSymbol {
+			writes.add(fieldPath(a.FieldName))
+		}
+		if a.usesPlugin() {
+			reads.addPluginArgs(a.PluginArgs)
+		} else if a.Type != AppendMerge {
+			reads.addRef(a.Value)
+		}
+	}
+	for _, p := range rule.PluginMap {
+		reads.addPluginArgs(p.PluginArgs)
+	}
+	for _, fields := range rule.DelMap {
+		for _, path := range fields {
+			deletes.add(path)
+		}
+	}
+	if rule.Aggregate != nil {
+		for _, field := range rule.Aggregate.GroupByFields {
+			reads.add(fieldPath(field))
+		}
+	}
+	if rule.Cooldown != nil {
+		for _, field := range rule.Cooldown.GroupByFields {
+			reads.add(fieldPath(field))
+		}
+	}
+}
+
+func collectCheckReads(reads fieldSet, node *CheckNodes) {
+	if node.Type == "PLUGIN" {
+		reads.addPluginArgs(node.PluginArgs)
+		return
+	}
+	reads.add(fieldPath(node.Field))
+	reads.addRef(node.Value)
+}
+
+func collectThresholdFields(reads, writes fieldSet, threshold *Threshold) {
+	for field := range threshold.GroupByList {
+		reads.add(fieldPath(field))
+	}
+	reads.add(fieldPath(threshold.CountField))
+	if threshold.ClassifyValuesField != "" {
+		writes.add(fieldPath(threshold.ClassifyValuesField))
+	}
+	if threshold.ClassifyCountField != "" {
+		writes.add(fieldPath(threshold.ClassifyCountField))
+	}
+	if threshold.FireCountField != "" {
+		writes.add(fieldPath(threshold.FireCountField))
+	}
+}
+
+func collectChecklistReads(reads, writes fieldSet, checklist *Checklist) {
+	for i := range checklist.CheckNodes {
+		collectCheckReads(reads, &checklist.CheckNodes[i])
+	}
+	for i := range checklist.ThresholdNodes {
+		collectThresholdFields(reads, writes, &checklist.ThresholdNodes[i])
+	}
+}
+
+// collectIteratorReads reports the iterated field, and the fields its nodes read relative to
+// the iterator variable below it
+func collectIteratorReads(reads, writes fieldSet, iterator *Iterator) {
+	base := fieldPath(iterator.Field)
+	reads.add(base)
+
+	inner, innerWrites := fieldSet{}, fieldSet{}
+	for i := range iterator.CheckNodes {
+		collectCheckReads(inner, &iterator.CheckNodes[i])
+	}
+	for i := range iterator.ThresholdNodes {
+		collectThresholdFields(inner, innerWrites, &iterator.ThresholdNodes[i])
+	}
+	for i := range iterator.Checklists {
+		collectChecklistReads(inner, innerWrites, &iterator.Checklists[i])
+	}
+	for f := range innerWrites {
+		writes[f] = struct{}{}
+	}
+	for f := range inner {
+		path := fieldPath(f)
+		if path[0] == iterator.Variable {
+			path = append(append(append([]string{}, base...), "*"), path[1:]...)
+		}
+		reads.add(path)
+	}
+}
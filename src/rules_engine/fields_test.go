@@ -0,0 +1,35 @@
+package rules_engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFields_ReadWriteDelete(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION">
+  <rule id="r1">
+    <checklist condition="a and b">
+      <check id="a" type="EQU" field="event.action">login</check>
+      <check id="b" type="NEQ" field="user">_$expected_user</check>
+    </checklist>
+    <threshold group_by="src_ip" range="5m" count_type="CLASSIFY" count_field="dst_port">10</threshold>
+    <iterator type="ANY" field="procs" variable="p">
+      <check type="EQU" field="p.name">bash</check>
+    </iterator>
+    <append field="alert.source">_$src_ip</append>
+    <append type="PLUGIN" field="alert.time">now()</append>
+    <del>request.headers.authorization,password</del>
+  </rule>
+</root>`)
+
+	got := rs.Fields()
+	want := RulesetFields{
+		Reads:   []string{"dst_port", "event.action", "expected_user", "procs", "procs.*.name", "src_ip", "user"},
+		Writes:  []string{"alert.source", "alert.time"},
+		Deletes: []string{"password", "request.headers.authorization"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected fields:\n got  %+v\n want %+v", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package rules_engine
+
+import (
+	"fmt"
+	"testing"
+)
+
+func firstMatchRuleset(t *testing.T, attr string) *Ruleset {
+	t.Helper()
+	return buildRulesetFromXML(t, fmt.Sprintf(`
+<root type="DETECTION" name="classify"%s>
+  <rule id="critical" name="critical">
+    <check type="EQU" field="severity">9</check>
+    <append field="class">critical</append>
+  </rule>
+  <rule id="admin" name="admin">
+    <check type="EQU" field="user">admin</check>
+    <append field="class">privileged</append>
+  </rule>
+  <rule id="any" name="any">
+    <check type="NOTNULL" field="user" />
+    <append field="class">other</append>
+  </rule>
+</root>`, attr))
+}
+
+func TestFirstMatch_OnlyFirstMatchingRule(t *testing.T) {
+	rs := firstMatchRuleset(t, ` first_match="true"`)
+
+	cases := []struct {
+		event map[string]interface{}
+		class string
+	}{
+		{map[string]interface{}{"user": "admin", "severity": "9"}, "critical"},
+		{map[string]interface{}{"user": "admin", "severity": "1"}, "privileged"},
+		{map[string]interface{}{"user": "bob"}, "other"},
+	}
+	for _, tc := range cases {
+		results := rs.EngineCheck(tc.event)
+		if len(results) != 1 || results[0]["class"] != tc.class {
+			t.Fatalf("expected one %s result for %v, got %v", tc.class, tc.event, results)
+		}
+	}
+
+	// Without first_match every matching rule produces a result
+	if results := firstMatchRuleset(t, "").EngineCheck(map[string]interface{}{"user": "admin", "severity": "9"}); len(results) != 3 {
+		t.Fatalf("expected 3 results without first_match, got %d", len(results))
+	}
+}
+
+func TestFirstMatch_Invalid(t *testing.T) {
+	for _, root := range []string{`<root type="DETECTION" first_match="yes">`, `<root type="EXCLUDE" first_match="true">`} {
+		if _, err := ParseRuleset([]byte(root + `<rule id="r1"><check type="NOTNULL" field="f" /></rule></root>`)); err == nil {
+			t.Fatalf("expected %s rejected", root)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package rules_engine
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// InlineTest is a test case embedded in a ruleset with a root level <test> element. Validation
+// evaluates Input and fails unless exactly the rules in Expect match it.
+//
+//	<test name="failed login fires">
+//	    <input>{"action": "login_failed"}</input>
+//	    <expect>brute_force</expect>
+//	</test>
+type InlineTest struct {
+	Name   string
+	Input  map[string]interface{}
+	Expect []string // IDs of the rules expected to match, empty when no rule should
+	Line   int
+}
+
+// parseInlineTest reads a <test> element with its <input> event and <expect> rule IDs
+func parseInlineTest(element xml.StartElement, decoder *XMLDecoder, elementLine int, index int) (InlineTest, error) {
+	test := InlineTest{Name: fmt.Sprintf("#%d", index+1), Line: elementLine}
+	for _, attr := range element.Attr {
+		if attr.Name.Local == "name" && strings.TrimSpace(attr.Value) != "" {
+			test.Name = strings.TrimSpace(attr.Value)
+		}
+	}
+
+	var child string
+	var text strings.Builder
+	hasInput := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return test, fmt.Errorf("error parsing test '%s' at line %d: %v", test.Name, elementLine, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if child != "" || (t.Name.Local != "input" && t.Name.Local != "expect") {
+				return test, fmt.Errorf("unsupported element '<%s>' in test '%s' at line %d, only <input> and <expect> are allowed", t.Name.Local, test.Name, elementLine)
+			}
+			child = t.Name.Local
+			text.Reset()
+		case xml.CharData:
+			if child != "" {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "input":
+				if err := sonic.UnmarshalString(strings.TrimSpace(text.String()), &test.Input); err != nil || test.Input == nil {
+					return test, fmt.Errorf("test '%s' input must be a JSON object at line %d", test.Name, elementLine)
+				}
+				hasInput = true
+			case "expect":
+				for _, id := range strings.Split(text.String(), ",") {
+					if id = strings.TrimSpace(id); id != "" {
+						test.Expect = append(test.Expect, id)
+					}
+				}
+			case "test":
+				if !hasInput {
+					return test, fmt.Errorf("test '%s' requires an <input> event at line %d", test.Name, elementLine)
+				}
+				return test, nil
+			}
+			child = ""
+		}
+	}
+}
+
+// validateInlineTests runs the embedded tests of a ruleset that passed validation so far, reporting
+// each test whose matched rules differ from its expectation
+func validateInlineTests(ruleset *Ruleset, rawRuleset []byte, result *ValidationResult) {
+	if len(ruleset.Tests) == 0 || !result.IsValid {
+		return
+	}
+	// Build a separate instance, RulesetBuild prepares the ruleset in place
+	r, err := ParseRuleset(rawRuleset)
+	if err != nil {
+		return
+	}
+	if err := RulesetBuild(r); err != nil {
+		// Reported by Verify with the build errors
+		return
+	}
+	defer r.CloseCaches()
+
+	// Run the tests on a test instance with its own state, so thresholds, cooldowns and dead letters
+	// never touch those of the deployed ruleset. NewFromExisting would validate the ruleset again.
+	seq := fmt.Sprintf("TEST.inline.%d", time.Now().UnixNano())
+	r, err = copyRuleset(r, seq)
+	if err != nil {
+		return
+	}
+	defer r.CloseCaches()
+	r.SetTestMode()
+	r.SetStateNamespace(seq + ":")
+
+	ruleIDs := make(map[string]struct{}, len(r.Rules))
+	for i := range r.Rules {
+		ruleIDs[r.Rules[i].ID] = struct{}{}
+	}
+	for _, test := range r.Tests {
+		unknown := false
+		for _, id := range test.Expect {
+			if _, ok := ruleIDs[id]; !ok {
+				unknown = true
+				result.IsValid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Line:    test.Line,
+					Message: fmt.Sprintf("Test '%s' expects unknown rule '%s'", test.Name, id),
+				})
+			}
+		}
+		if unknown {
+			continue
+		}
+
+		expected := append([]string(nil), test.Expect...)
+		matched := r.MatchedRules(test.Input)
+		sort.Strings(expected)
+		sort.Strings(matched)
+		if strings.Join(expected, ",") != strings.Join(matched, ",") {
+			result.IsValid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Line:    test.Line,
+				Message: fmt.Sprintf("Test '%s' failed: expected rules [%s], matched [%s]", test.Name, strings.Join(expected, ", "), strings.Join(matched, ", ")),
+			})
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+const inlineTestRules = `
+  <rule id="brute_force">
+    <check type="EQU" field="action">login_failed</check>
+  </rule>
+  <rule id="admin_login">
+    <check type="EQU" field="user">admin</check>
+  </rule>`
+
+func TestInlineTests_Pass(t *testing.T) {
+	xml := `<root type="DETECTION" name="self-tested">` + inlineTestRules + `
+  <test name="failed admin login">
+    <input>{"action": "login_failed", "user": "admin"}</input>
+    <expect>admin_login, brute_force</expect>
+  </test>
+  <test name="benign">
+    <input>{"action": "logout", "user": "bob"}</input>
+    <expect/>
+  </test>
+</root>`
+	res, err := ValidateWithDetails("", xml)
+	if err != nil || !res.IsValid {
+		t.Fatalf("expected the embedded tests to pass, got %+v (%v)", res, err)
+	}
+	if err := Verify("", xml); err != nil {
+		t.Fatalf("expected verify to accept the ruleset: %v", err)
+	}
+}
+
+func TestInlineTests_FailureBlocksVerify(t *testing.T) {
+	xml := `<root type="DETECTION" name="self-tested">` + inlineTestRules + `
+  <test name="regression">
+    <input>{"action": "login_failed", "user": "bob"}</input>
+    <expect>admin_login</expect>
+  </test>
+</root>`
+	res, err := ValidateWithDetails("", xml)
+	if err != nil || res.IsValid || len(res.Errors) != 1 {
+		t.Fatalf("expected one failed test, got %+v (%v)", res, err)
+	}
+	if res.Errors[0].Line != 8 || !strings.Contains(res.Errors[0].Message, "expected rules [admin_login], matched [brute_force]") {
+		t.Fatalf("unexpected error %+v", res.Errors[0])
+	}
+	if err := Verify("", xml); err == nil || !strings.Contains(err.Error(), "Test 'regression' failed") {
+		t.Fatalf("expected verify to reject the ruleset, got %v", err)
+	}
+}
+
+func TestInlineTests_Parse(t *testing.T) {
+	for body, want := range map[string]string{
+		`<test><expect>brute_force</expect></test>`:           "requires an <input>",
+		`<test><input>[1]</input></test>`:                     "must be a JSON object",
+		`<test><input>{}</input><expect>nope</expect></test>`: "unknown rule 'nope'",
+		`<test><input>{}</input><output>x</output></test>`:    "only <input> and <expect>",
+	} {
+		xml := `<root type="DETECTION">` + inlineTestRules + body + `</root>`
+		res, err := ValidateWithDetails("", xml)
+		if err != nil || res.IsValid {
+			t.Fatalf("expected %s to be rejected", body)
+		}
+		if got := res.Errors[0].Message + " " + res.Errors[0].Detail; !strings.Contains(got, want) {
+			t.Fatalf("expected %q for %s, got %q", want, body, got)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"fmt"
+	"sort"
+	"strings"
+
+	regexp "github.com/BurntSushi/rure-go"
+)
+
+// lintMaxThresholdRange is the threshold range above which counters are considered long-lived; every
+// group key is held in Redis or the local cache for the whole range
+const lintMaxThresholdRange = 24 * 60 * 60
+
+// lintRuleset adds style and quality warnings for a ruleset that parsed successfully. Lint findings
+// never make a ruleset invalid.
+func lintRuleset(ruleset *Ruleset, xmlContent string, result *ValidationResult) {
+	for ruleIndex := range ruleset.Rules {
+		lintRule(&ruleset.Rules[ruleIndex], xmlContent, ruleIndex, result)
+	}
+}
+
+func lintRule(rule *Rule, xmlContent string, ruleIndex int, result *ValidationResult) {
+	ruleID := rule.ID
+	lineOf := func(pattern string) int {
+		return findElementInRule(xmlContent, ruleID, pattern, ruleIndex, 0)
+	}
+
+	if len(rule.CheckMap) == 0 && len(rule.ChecklistMap) == 0 && len(rule.IteratorMap) == 0 {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    lineOf("<rule"),
+			Message: "Rule has no check, checklist or iterator and is evaluated for every event",
+			Detail:  fmt.Sprintf("Rule ID: %s, add a check to narrow the events the rule applies to", ruleID),
+		})
+	}
+
+	var checks []*CheckNodes
+	var thresholds []*Threshold
+	for _, k := range sortedKeys(rule.CheckMap) {
+		node := rule.CheckMap[k]
+		checks = append(checks, &node)
+	}
+	for _, k := range sortedKeys(rule.ChecklistMap) {
+		checklist := rule.ChecklistMap[k]
+		checks, thresholds = appendChecklistNodes(&checklist, checks, thresholds)
+	}
+	for _, k := range sortedKeys(rule.IteratorMap) {
+		iterator := rule.IteratorMap[k]
+		for i := range iterator.CheckNodes {
+			checks = append(checks, &iterator.CheckNodes[i])
+		}
+		for i := range iterator.ThresholdNodes {
+			thresholds = append(thresholds, &iterator.ThresholdNodes[i])
+		}
+		for i := range iterator.Checklists {
+			checks, thresholds = appendChecklistNodes(&iterator.Checklists[i], checks, thresholds)
+		}
+	}
+	for _, k := range sortedKeys(rule.ThresholdMap) {
+		threshold := rule.ThresholdMap[k]
+		thresholds = append(thresholds, &threshold)
+	}
+
+	for _, node := range checks {
+		switch node.Type {
+		case "REGEX":
+			lintRegex(strings.TrimSpace(node.Value), lineOf(node.Value), ruleID, result)
+		case "PLUGIN":
+			lintPluginCall(node.Value, lineOf(node.Value), ruleID, result)
+		}
+	}
+
+	for _, threshold := range thresholds {
+		rangeSec, err := common.ParseDurationToSecondsInt(threshold.Range)
+		if err == nil && rangeSec > lintMaxThresholdRange {
+			result.Warnings = append(result.Warnings, ValidationWarning{
+				Line:    lineOf(fmt.Sprintf(`range="%s"`, threshold.Range)),
+				Message: "Threshold range is longer than 24h",
+				Detail:  fmt.Sprintf("Rule ID: %s, range '%s' keeps a counter per group for the whole range", ruleID, threshold.Range),
+			})
+		}
+	}
+
+	for _, k := range sortedKeys(rule.AppendsMap) {
+		if appendElem := rule.AppendsMap[k]; appendElem.usesPlugin() {
+			lintPluginCall(appendElem.Value, lineOf(appendElem.Value), ruleID, result)
+		}
+	}
+	for _, k := range sortedKeys(rule.PluginMap) {
+		p := rule.PluginMap[k]
+		lintPluginCall(p.Value, lineOf(strings.TrimSpace(p.Value)), ruleID, result)
+	}
+}
+
+func appendChecklistNodes(checklist *Checklist, checks []*CheckNodes, thresholds []*Threshold) ([]*CheckNodes, []*Threshold) {
+	for i := range checklist.CheckNodes {
+		checks = append(checks, &checklist.CheckNodes[i])
+	}
+	for i := range checklist.ThresholdNodes {
+		thresholds = append(thresholds, &checklist.ThresholdNodes[i])
+	}
+	return checks, thresholds
+}
+
+// lintRegex flags patterns that match every value and redundant leading or trailing .* in
+// unanchored patterns, which only slow matching down
+func lintRegex(pattern string, line int, ruleID string, result *ValidationResult) {
+	re, err := regexp.Compile(pattern)
+	if err != nil || pattern == "" {
+		return // reported by validation
+	}
+	if re.IsMatch("") && re.IsMatch("\x00lint probe") {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    line,
+			Message: "Regex matches every value",
+			Detail:  fmt.Sprintf("Rule ID: %s, pattern '%s' does not filter anything", ruleID, pattern),
+		})
+		return
+	}
+	if strings.HasPrefix(pattern, ".*") || (strings.HasSuffix(pattern, ".*") && !strings.HasSuffix(pattern, `\.*`)) {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    line,
+			Message: "Leading or trailing .* in a regex is redundant",
+			Detail:  fmt.Sprintf("Rule ID: %s, regex matching is unanchored, '%s' can drop the .*", ruleID, pattern),
+		})
+	}
+}
+
+// lintPluginCall flags literal empty string arguments, which usually are placeholders left behind
+func lintPluginCall(call string, line int, ruleID string, result *ValidationResult) {
+	_, args, _, err := ParseCheckNodePluginCall(call)
+	if err != nil {
+		return // reported by validation
+	}
+	for i, arg := range args {
+		if s, ok := arg.Value.(string); ok && arg.Type == 0 && s == "" {
+			result.Warnings = append(result.Warnings, ValidationWarning{
+				Line:    line,
+				Message: "Plugin call has a literal empty string argument",
+				Detail:  fmt.Sprintf("Rule ID: %s, argument %d of '%s' is \"\"", ruleID, i+1, strings.TrimSpace(call)),
+			})
+		}
+	}
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
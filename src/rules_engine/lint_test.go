@@ -0,0 +1,123 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+
+	"AgentSmith-HUB/plugin"
+)
+
+func lintWarnings(t *testing.T, xml string) []ValidationWarning {
+	t.Helper()
+	result, err := ValidateWithDetails("", xml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsValid {
+		t.Fatalf("lint findings must not invalidate the ruleset: %+v", result.Errors)
+	}
+	return result.Warnings
+}
+
+func findWarning(warnings []ValidationWarning, message string) *ValidationWarning {
+	for i := range warnings {
+		if strings.Contains(warnings[i].Message, message) {
+			return &warnings[i]
+		}
+	}
+	return nil
+}
+
+func TestLint_Warnings(t *testing.T) {
+	if _, ok := plugin.GetPlugin(failingPluginName); !ok {
+		if err := plugin.NewPlugin("", failingPluginCode, failingPluginName, plugin.YAEGI_PLUGIN); err != nil {
+			t.Fatalf("failed to load plugin: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		xml     string
+		message string
+		line    int
+	}{
+		{
+			name: "regex matching everything",
+			xml: `<root type="DETECTION" name="lint">
+  <rule id="r1" name="r1">
+    <check type="REGEX" field="cmd">.*</check>
+  </rule>
+</root>`,
+			message: "Regex matches every value",
+			line:    3,
+		},
+		{
+			name: "redundant leading .* in checklist node",
+			xml: `<root type="DETECTION" name="lint">
+  <rule id="r1" name="r1">
+    <checklist condition="a">
+      <check id="a" type="REGEX" field="cmd">.*powershell</check>
+    </checklist>
+  </rule>
+</root>`,
+			message: "Leading or trailing .*",
+			line:    4,
+		},
+		{
+			name: "rule without filter",
+			xml: `<root type="DETECTION" name="lint">
+  <rule id="r1" name="r1">
+    <append field="seen">yes</append>
+  </rule>
+</root>`,
+			message: "evaluated for every event",
+			line:    2,
+		},
+		{
+			name: "long threshold range",
+			xml: `<root type="DETECTION" name="lint">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="action">login</check>
+    <threshold group_by="user" range="30d">5</threshold>
+  </rule>
+</root>`,
+			message: "Threshold range is longer than 24h",
+			line:    4,
+		},
+		{
+			name: "plugin call with empty string",
+			xml: `<root type="DETECTION" name="lint">
+  <rule id="r1" name="r1">
+    <check type="PLUGIN">onErrorFailingPlugin(user, "")</check>
+  </rule>
+</root>`,
+			message: "literal empty string argument",
+			line:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := lintWarnings(t, tt.xml)
+			w := findWarning(warnings, tt.message)
+			if w == nil {
+				t.Fatalf("expected warning %q, got %+v", tt.message, warnings)
+			}
+			if w.Line != tt.line {
+				t.Fatalf("expected warning on line %d, got %d", tt.line, w.Line)
+			}
+		})
+	}
+}
+
+func TestLint_CleanRuleset(t *testing.T) {
+	warnings := lintWarnings(t, `<root type="DETECTION" name="lint">
+  <rule id="r1" name="r1">
+    <check type="REGEX" field="cmd">powershell\s+-enc</check>
+    <threshold group_by="user" range="5m">5</threshold>
+  </rule>
+</root>`)
+	if len(warnings) != 0 {
+		t.Fatalf("expected no lint warnings, got %+v", warnings)
+	}
+}
@@ -0,0 +1,38 @@
+package rules_engine
+
+import "sync/atomic"
+
+// NoMatchFieldName flags an event a detection ruleset evaluated without any rule matching, its value
+// is the ID of the ruleset
+const NoMatchFieldName = "_hub_no_match"
+
+// SetNoMatchDownStream routes the events matching no rule of a detection ruleset to ch for auditing,
+// nil stops the routing. Like the other downstreams it takes effect on the next start.
+func (r *Ruleset) SetNoMatchDownStream(ch *chan map[string]interface{}) {
+	r.noMatchDownStream = ch
+}
+
+// GetUnroutedEvents returns how many events the detection ruleset dropped because no rule matched
+// and no downstream takes the unmatched events
+func (r *Ruleset) GetUnroutedEvents() uint64 {
+	return atomic.LoadUint64(&r.unroutedEvents)
+}
+
+// noMatchEvent returns the flagged event to route when a detection ruleset produced no result, nil
+// when the event matched or no-match routing is off
+func (r *Ruleset) noMatchEvent(data map[string]interface{}, results []map[string]interface{}) map[string]interface{} {
+	if !r.IsDetection || len(results) > 0 {
+		return nil
+	}
+	if r.noMatchDownStream == nil {
+		atomic.AddUint64(&r.unroutedEvents, 1)
+		return nil
+	}
+	// The event may be shared with other downstreams of the input, so the flag goes on a copy
+	event := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		event[k] = v
+	}
+	event[NoMatchFieldName] = r.RulesetID
+	return event
+}
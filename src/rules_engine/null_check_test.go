@@ -0,0 +1,63 @@
+package rules_engine
+
+import (
+	"fmt"
+	"testing"
+)
+
+func nullCheckRuleset(t *testing.T, checkType string, strict string) *Ruleset {
+	t.Helper()
+	strictAttr := ""
+	if strict != "" {
+		strictAttr = fmt.Sprintf(` strict="%s"`, strict)
+	}
+	xml := fmt.Sprintf(`
+<root type="DETECTION" name="null-check">
+  <rule id="r1" name="r1">
+    <check type="%s" field="f"%s />
+  </rule>
+</root>`, checkType, strictAttr)
+	return buildRulesetFromXML(t, xml)
+}
+
+func TestNullChecks_StrictAndNonStrict(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   map[string]interface{}
+		strict string
+		isNull bool
+	}{
+		{"absent/non-strict", map[string]interface{}{}, "", true},
+		{"empty/non-strict", map[string]interface{}{"f": ""}, "", true},
+		{"whitespace/non-strict", map[string]interface{}{"f": "  "}, "false", true},
+		{"value/non-strict", map[string]interface{}{"f": "x"}, "", false},
+		{"absent/strict", map[string]interface{}{}, "true", true},
+		{"empty/strict", map[string]interface{}{"f": ""}, "true", false},
+		{"value/strict", map[string]interface{}{"f": "x"}, "true", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			isNull := len(nullCheckRuleset(t, "ISNULL", tc.strict).EngineCheck(tc.data)) == 1
+			if isNull != tc.isNull {
+				t.Fatalf("ISNULL: expected %v, got %v", tc.isNull, isNull)
+			}
+			notNull := len(nullCheckRuleset(t, "NOTNULL", tc.strict).EngineCheck(tc.data)) == 1
+			if notNull != !tc.isNull {
+				t.Fatalf("NOTNULL: expected %v, got %v", !tc.isNull, notNull)
+			}
+		})
+	}
+}
+
+func TestNullChecks_InvalidStrictValue(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="null-check">
+  <rule id="r1" name="r1">
+    <check type="ISNULL" field="f" strict="yes" />
+  </rule>
+</root>`
+	if _, err := ParseRuleset([]byte(xml)); err == nil {
+		t.Fatalf("expected ParseRuleset to reject invalid strict value")
+	}
+}
@@ -0,0 +1,133 @@
+package rules_engine
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/plugin"
+
+	"github.com/bytedance/sonic"
+)
+
+const failingPluginName = "onErrorFailingPlugin"
+
+const failingPluginCode = `package plugin
+
+import "errors"
+
+func Eval(args ...interface{}) (bool, error) {
+	return false, errors.New("lookup failed")
+}
+`
+
+func onErrorRuleset(t *testing.T, rulesetType, onError string) *Ruleset {
+	t.Helper()
+	if _, ok := plugin.GetPlugin(failingPluginName); !ok {
+		if err := plugin.NewPlugin("", failingPluginCode, failingPluginName, plugin.YAEGI_PLUGIN); err != nil {
+			t.Fatalf("failed to load plugin: %v", err)
+		}
+	}
+	attr := ""
+	if onError != "" {
+		attr = fmt.Sprintf(` on_error="%s"`, onError)
+	}
+	xml := fmt.Sprintf(`
+<root type="%s" name="on-error">
+  <rule id="r1" name="r1">
+    <check type="PLUGIN"%s>%s(f)</check>
+  </rule>
+  <rule id="r2" name="r2">
+    <check type="NOTNULL" field="f" />
+  </rule>
+</root>`, rulesetType, attr, failingPluginName)
+	// Keep on_error="error" from reaching Redis, tests replacing the push do so after this
+	deadLetterPush = func(string, interface{}, int64) error { return nil }
+	t.Cleanup(func() { deadLetterPush = common.RedisLPush })
+	return buildRulesetFromXML(t, xml)
+}
+
+func TestOnError_Detection(t *testing.T) {
+	cases := []struct {
+		onError string
+		hits    []string
+	}{
+		{"", []string{"TEST.RS.r2"}},
+		{"nomatch", []string{"TEST.RS.r2"}},
+		{"match", []string{"TEST.RS.r1", "TEST.RS.r2"}},
+		// The event is routed away, so r2 does not get to emit it either
+		{"error", nil},
+	}
+	for _, tc := range cases {
+		t.Run("on_error="+tc.onError, func(t *testing.T) {
+			results := onErrorRuleset(t, "DETECTION", tc.onError).EngineCheck(map[string]interface{}{"f": "x"})
+			if len(results) != len(tc.hits) {
+				t.Fatalf("expected %d results, got %d", len(tc.hits), len(results))
+			}
+			if len(results) > 0 {
+				// Rules that do not modify data share the event, so the last result carries every hit
+				if hits := results[len(results)-1][HitRuleIdFieldName]; hits != strings.Join(tc.hits, ",") {
+					t.Fatalf("expected hits %v, got %v", tc.hits, hits)
+				}
+			}
+		})
+	}
+}
+
+func TestOnError_Exclude(t *testing.T) {
+	data := map[string]interface{}{}
+	// With nomatch the event passes, with match it is filtered, with error it is routed away
+	if res := onErrorRuleset(t, "EXCLUDE", "nomatch").EngineCheck(data); len(res) != 1 {
+		t.Fatalf("nomatch: expected event to pass, got %d results", len(res))
+	}
+	if res := onErrorRuleset(t, "EXCLUDE", "match").EngineCheck(data); len(res) != 0 {
+		t.Fatalf("match: expected event to be filtered, got %d results", len(res))
+	}
+	if res := onErrorRuleset(t, "EXCLUDE", "error").EngineCheck(data); len(res) != 0 {
+		t.Fatalf("error: expected event to be routed away, got %d results", len(res))
+	}
+}
+
+func TestOnError_InvalidValue(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="on-error">
+  <rule id="r1" name="r1">
+    <check type="REGEX" field="f" on_error="ignore">^a</check>
+  </rule>
+</root>`
+	if _, err := ParseRuleset([]byte(xml)); err == nil {
+		t.Fatal("expected ParseRuleset to reject invalid on_error value")
+	}
+
+	result, err := ValidateWithDetails("", xml)
+	if err != nil {
+		t.Fatalf("ValidateWithDetails error: %v", err)
+	}
+	if result.IsValid {
+		t.Fatal("expected ValidateWithDetails to report invalid on_error value")
+	}
+}
+
+func TestOnError_ErrorDeadLettersEvent(t *testing.T) {
+	rs := onErrorRuleset(t, "DETECTION", "error")
+	var key, stored string
+	deadLetterPush = func(k string, value interface{}, maxLen int64) error {
+		key, stored = k, value.(string)
+		return nil
+	}
+	if res := rs.EngineCheck(map[string]interface{}{"f": "x"}); len(res) != 0 {
+		t.Fatalf("expected the event to be routed away, got %d results", len(res))
+	}
+
+	var letter DeadLetter
+	if err := sonic.Unmarshal([]byte(stored), &letter); err != nil {
+		t.Fatalf("unexpected entry %q: %v", stored, err)
+	}
+	if key != DeadLetterKey(rs.RulesetID) || letter.Rule != "r1" || letter.Reason != DeadLetterCheckFailed || letter.Event["f"] != "x" {
+		t.Fatalf("unexpected dead letter %s %+v", key, letter)
+	}
+	if !strings.Contains(letter.Error, "lookup failed") {
+		t.Fatalf("expected the plugin error in the dead letter, got %q", letter.Error)
+	}
+}
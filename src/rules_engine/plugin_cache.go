@@ -0,0 +1,175 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"AgentSmith-HUB/plugin"
+	"container/list"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MaxPluginCacheSize caps the root attribute plugin_cache
+const MaxPluginCacheSize = 100000
+
+// pluginResult is a cached plugin call: the boolean of a check plugin or the value of an other plugin
+type pluginResult struct {
+	check bool
+	value interface{}
+	ok    bool
+}
+
+type pluginCacheEntry struct {
+	key    string
+	result pluginResult
+}
+
+// pluginResultCache is the LRU of plugin results of a ruleset (root attribute plugin_cache), keyed by the
+// plugin and its resolved arguments. Plugins of check nodes and appends must then be pure functions
+// of their arguments; <plugin> operations run for their side effects and are never cached.
+type pluginResultCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // Most recently used first
+	hits    uint64
+	misses  uint64
+}
+
+func newPluginResultCache(size int) *pluginResultCache {
+	return &pluginResultCache{
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+func (c *pluginResultCache) get(key string) (pluginResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return pluginResult{}, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*pluginCacheEntry).result, true
+}
+
+func (c *pluginResultCache) put(key string, result pluginResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*pluginCacheEntry).result = result
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&pluginCacheEntry{key: key, result: result})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*pluginCacheEntry).key)
+	}
+}
+
+// pluginCacheKey builds the cache key of a plugin call, false when an argument is not a scalar (e.g.
+// the whole event or an object field) and the call cannot be cached
+func pluginCacheKey(p *plugin.Plugin, args []interface{}) (string, bool) {
+	var b strings.Builder
+	b.WriteString(p.Name)
+	for _, arg := range args {
+		b.WriteByte(0)
+		switch v := arg.(type) {
+		case nil:
+			b.WriteByte('n')
+		case string:
+			b.WriteByte('s')
+			b.WriteString(v)
+		case bool:
+			b.WriteByte('b')
+			b.WriteString(strconv.FormatBool(v))
+		case int:
+			b.WriteByte('i')
+			b.WriteString(strconv.Itoa(v))
+		case int64:
+			b.WriteByte('i')
+			b.WriteString(strconv.FormatInt(v, 10))
+		case float64:
+			b.WriteByte('f')
+			b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// pluginCache returns the plugin result cache of the ruleset, nil unless plugin_cache is set
+func (r *Ruleset) pluginCache() *pluginResultCache {
+	if r.PluginCacheSize <= 0 {
+		return nil
+	}
+	r.pluginCacheOnce.Do(func() {
+		r.pluginResults = newPluginResultCache(r.PluginCacheSize)
+	})
+	return r.pluginResults
+}
+
+// evalPluginCheck calls a check plugin, or returns its cached result for the same arguments.
+// Failed calls are not cached.
+func (r *Ruleset) evalPluginCheck(p *plugin.Plugin, args []interface{}) (bool, error) {
+	c := r.pluginCache()
+	if c == nil {
+		return p.FuncEvalCheckNode(args...)
+	}
+	key, ok := pluginCacheKey(p, args)
+	if !ok {
+		return p.FuncEvalCheckNode(args...)
+	}
+	if res, hit := c.get(key); hit {
+		return res.check, nil
+	}
+	result, err := p.FuncEvalCheckNode(args...)
+	if err == nil {
+		c.put(key, pluginResult{check: result})
+	}
+	return result, err
+}
+
+// evalPluginOther calls an other plugin, or returns a copy of its cached result for the same
+// arguments. Failed calls are not cached.
+func (r *Ruleset) evalPluginOther(p *plugin.Plugin, args []interface{}) (interface{}, bool, error) {
+	c := r.pluginCache()
+	if c == nil {
+		return p.FuncEvalOther(args...)
+	}
+	key, cacheable := pluginCacheKey(p, args)
+	if !cacheable {
+		return p.FuncEvalOther(args...)
+	}
+	if res, hit := c.get(key); hit {
+		return common.MapDeepCopyAction(res.value), res.ok, nil
+	}
+	value, ok, err := p.FuncEvalOther(args...)
+	if err == nil {
+		c.put(key, pluginResult{value: common.MapDeepCopyAction(value), ok: ok})
+	}
+	return value, ok, err
+}
+
+// GetPluginCacheStats returns the size and hit counts of the plugin cache, nil unless plugin_cache is set
+func (r *Ruleset) GetPluginCacheStats() map[string]interface{} {
+	c := r.pluginCache()
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"size":    c.size,
+		"entries": c.order.Len(),
+		"hits":    c.hits,
+		"misses":  c.misses,
+	}
+}
@@ -0,0 +1,121 @@
+package rules_engine
+
+import (
+	"fmt"
+	"testing"
+
+	"AgentSmith-HUB/plugin"
+)
+
+const (
+	cacheCheckPluginName  = "pluginCacheIsAdmin"
+	cacheAppendPluginName = "pluginCacheOwner"
+)
+
+const cacheCheckPluginCode = `package plugin
+
+func Eval(args ...interface{}) (bool, error) {
+	return args[0] == "admin" || args[0] == "root", nil
+}
+`
+
+const cacheAppendPluginCode = `package plugin
+
+func Eval(args ...interface{}) (interface{}, bool, error) {
+	return "owner-of-" + args[0].(string), true, nil
+}
+`
+
+func loadCachePlugins(tb testing.TB) (*plugin.Plugin, *plugin.Plugin) {
+	tb.Helper()
+	for name, code := range map[string]string{cacheCheckPluginName: cacheCheckPluginCode, cacheAppendPluginName: cacheAppendPluginCode} {
+		if _, ok := plugin.GetPlugin(name); !ok {
+			if err := plugin.NewPlugin("", code, name, plugin.YAEGI_PLUGIN); err != nil {
+				tb.Fatalf("failed to load plugin %s: %v", name, err)
+			}
+		}
+	}
+	check, _ := plugin.GetPlugin(cacheCheckPluginName)
+	appendPlugin, _ := plugin.GetPlugin(cacheAppendPluginName)
+	check.GetSuccessIncrementAndUpdate()
+	appendPlugin.GetSuccessIncrementAndUpdate()
+	return check, appendPlugin
+}
+
+func pluginCacheRulesetXML(attr string) string {
+	return fmt.Sprintf(`
+<root type="DETECTION" name="plugin-cache"%s>
+  <rule id="r1" name="r1">
+    <check type="PLUGIN">%s(user)</check>
+    <append type="PLUGIN" field="owner">%s(host)</append>
+  </rule>
+</root>`, attr, cacheCheckPluginName, cacheAppendPluginName)
+}
+
+func TestPluginCache_RepeatedEventsCallPluginsOnce(t *testing.T) {
+	check, appendPlugin := loadCachePlugins(t)
+	rs := buildRulesetFromXML(t, pluginCacheRulesetXML(` plugin_cache="10"`))
+
+	for i := 0; i < 5; i++ {
+		results := rs.EngineCheck(map[string]interface{}{"user": "admin", "host": "web-1"})
+		if len(results) != 1 || results[0]["owner"] != "owner-of-web-1" {
+			t.Fatalf("unexpected results %v", results)
+		}
+	}
+	if calls := check.GetSuccessIncrementAndUpdate(); calls != 1 {
+		t.Fatalf("expected the check plugin to be called once, got %d", calls)
+	}
+	if calls := appendPlugin.GetSuccessIncrementAndUpdate(); calls != 1 {
+		t.Fatalf("expected the append plugin to be called once, got %d", calls)
+	}
+
+	// Different field values must not be served from the cache
+	if results := rs.EngineCheck(map[string]interface{}{"user": "guest", "host": "web-1"}); len(results) != 0 {
+		t.Fatalf("expected no hit for guest, got %v", results)
+	}
+	results := rs.EngineCheck(map[string]interface{}{"user": "root", "host": "web-2"})
+	if len(results) != 1 || results[0]["owner"] != "owner-of-web-2" {
+		t.Fatalf("unexpected results %v", results)
+	}
+
+	stats := rs.GetPluginCacheStats()
+	if stats["hits"] != uint64(8) || stats["misses"] != uint64(5) {
+		t.Fatalf("unexpected cache stats %v", stats)
+	}
+}
+
+func TestPluginCache_Invalid(t *testing.T) {
+	for _, v := range []string{"-1", "abc", "100001"} {
+		if _, err := ParseRuleset([]byte(pluginCacheRulesetXML(fmt.Sprintf(` plugin_cache="%s"`, v)))); err == nil {
+			t.Fatalf("expected plugin_cache=%q to be rejected", v)
+		}
+	}
+}
+
+func benchmarkPluginCache(b *testing.B, attr string) {
+	check, _ := loadCachePlugins(b)
+	rs, err := ParseRuleset([]byte(pluginCacheRulesetXML(attr)))
+	if err != nil {
+		b.Fatalf("ParseRuleset error: %v", err)
+	}
+	rs.RulesetID = "BENCH.RS"
+	if err := RulesetBuild(rs); err != nil {
+		b.Fatalf("RulesetBuild error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs.EngineCheck(map[string]interface{}{"user": "admin", "host": "web-1"})
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(check.GetSuccessIncrementAndUpdate())/float64(b.N), "plugin-calls/op")
+}
+
+func BenchmarkPluginCache_Disabled(b *testing.B) {
+	benchmarkPluginCache(b, "")
+}
+
+func BenchmarkPluginCache_Enabled(b *testing.B) {
+	benchmarkPluginCache(b, ` plugin_cache="1000"`)
+}
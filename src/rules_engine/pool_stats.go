@@ -0,0 +1,34 @@
+package rules_engine
+
+// PoolStats is the utilization of the worker pool of a running ruleset
+type PoolStats struct {
+	Running  int `json:"running"`  // Live workers: busy ones, and idle ones until they expire after a second
+	Free     int `json:"free"`     // Capacity - Running, workers the pool can still start
+	Capacity int `json:"capacity"` // Current size, tuned between MinSize and MaxSize by the upstream backlog
+	MinSize  int `json:"min_size"`
+	MaxSize  int `json:"max_size"`
+	// Waiting counts the submissions blocked on a full pool, Queued the events buffered upstream
+	Waiting     int     `json:"waiting"`
+	Queued      int     `json:"queued"`
+	Utilization float64 `json:"utilization"` // Running / Capacity, near 1 with Waiting above 0 means an undersized pool
+}
+
+// PoolStats reads the live state of the worker pool; all counts are 0 while the ruleset is stopped
+func (r *Ruleset) PoolStats() PoolStats {
+	stats := PoolStats{MinSize: getMinPoolSize(), MaxSize: getMaxPoolSize()}
+	for _, upCh := range r.UpStream {
+		stats.Queued += len(*upCh)
+	}
+	pool := r.antsPool
+	if pool == nil {
+		return stats
+	}
+	stats.Running = pool.Running()
+	stats.Free = pool.Free()
+	stats.Capacity = pool.Cap()
+	stats.Waiting = pool.Waiting()
+	if stats.Capacity > 0 {
+		stats.Utilization = float64(stats.Running) / float64(stats.Capacity)
+	}
+	return stats
+}
@@ -0,0 +1,60 @@
+package rules_engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+func TestPoolStatsReflectPoolUnderLoad(t *testing.T) {
+	r := &Ruleset{}
+	if stats := r.PoolStats(); stats.Capacity != 0 || stats.Running != 0 || stats.MaxSize < stats.MinSize {
+		t.Fatalf("unexpected stats of a stopped ruleset %+v", stats)
+	}
+
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Release()
+	r.antsPool = pool
+	up := make(chan map[string]interface{}, 10)
+	for i := 0; i < 3; i++ {
+		up <- map[string]interface{}{"n": i}
+	}
+	r.UpStream = map[string]*chan map[string]interface{}{"INPUT.in": &up}
+
+	block := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		if err := pool.Submit(func() { <-block }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A third task waits for a free worker
+	go pool.Submit(func() { <-block })
+
+	var stats PoolStats
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats = r.PoolStats()
+		if stats.Running == 2 && stats.Waiting == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pool never reached the expected load, got %+v", stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats.Capacity != 2 || stats.Free != 0 || stats.Queued != 3 || stats.Utilization != 1 {
+		t.Fatalf("unexpected stats under load %+v", stats)
+	}
+
+	close(block)
+	for r.PoolStats().Waiting != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the waiting task never ran, got %+v", r.PoolStats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
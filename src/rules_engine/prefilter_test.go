@@ -0,0 +1,47 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegexCheckGatesRuleEvaluation(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="prefilter">
+  <rule id="r1" name="r1">
+    <check type="REGEX" field="path">^/admin/</check>
+    <checklist condition="n1 or n2">
+      <check type="INCL" field="user">root</check>
+      <check type="EQU" field="method">DELETE</check>
+    </checklist>
+  </rule>
+</root>`
+	rs := buildRulesetFromXML(t, xml)
+	rs.SampleTrace = true
+
+	results, _, trace := rs.tracedCheck(map[string]interface{}{"path": "/public/index", "user": "root"})
+	if len(results) != 0 {
+		t.Fatalf("expected no hit, got %v", results)
+	}
+	if nodes := trace.Rules[0].Nodes; len(nodes) != 1 || nodes[0].Type != "REGEX" || nodes[0].Matched {
+		t.Fatalf("expected evaluation to stop at the REGEX check, got %+v", nodes)
+	}
+
+	results, _, trace = rs.tracedCheck(map[string]interface{}{"path": "/admin/users", "user": "root"})
+	if len(results) != 1 || len(trace.Rules[0].Nodes) < 2 {
+		t.Fatalf("expected the checklist evaluated after the REGEX check, got %v %+v", results, trace.Rules[0].Nodes)
+	}
+}
+
+func TestFilterElementPointsToChecks(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="prefilter">
+  <rule id="r1" name="r1">
+    <filter field="path">/admin/</filter>
+    <check type="NOTNULL" field="user" />
+  </rule>
+</root>`
+	if _, err := ParseRuleset([]byte(xml)); err == nil || !strings.Contains(err.Error(), "<check type=") {
+		t.Fatalf("expected the error to point to check nodes, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package rules_engine
+
+import (
+	"sync/atomic"
+)
+
+// ProjectBudget is the soft share of engine tasks and event memory one project may hold, checked
+// before the global task guard. Events arriving while the project is over budget are shed (dropped
+// and counted) so a flooded project cannot take every global slot and stall the others.
+type ProjectBudget struct {
+	maxTasks int64 // 0 means unlimited
+	maxBytes int64 // 0 means unlimited
+
+	inFlight   int64
+	bytes      int64
+	peakTasks  int64
+	shedTasks  uint64
+	shedMemory uint64
+}
+
+// NewProjectBudget returns the budget shared by the rulesets of a project, nil when no limit is set
+func NewProjectBudget(maxTasks int, maxMemoryMB int) *ProjectBudget {
+	if maxTasks <= 0 && maxMemoryMB <= 0 {
+		return nil
+	}
+	return &ProjectBudget{maxTasks: int64(max(maxTasks, 0)), maxBytes: int64(max(maxMemoryMB, 0)) << 20}
+}
+
+// tryAcquire reserves a task and the estimated size of the event, ok is false when the event is shed.
+// The returned size must be passed back to release.
+func (b *ProjectBudget) tryAcquire(data map[string]interface{}) (size int64, ok bool) {
+	if b == nil {
+		return 0, true
+	}
+	n := atomic.AddInt64(&b.inFlight, 1)
+	if b.maxTasks > 0 && n > b.maxTasks {
+		atomic.AddInt64(&b.inFlight, -1)
+		atomic.AddUint64(&b.shedTasks, 1)
+		return 0, false
+	}
+	if b.maxBytes > 0 {
+		size = estimateEventSize(data)
+		if atomic.AddInt64(&b.bytes, size) > b.maxBytes {
+			atomic.AddInt64(&b.bytes, -size)
+			atomic.AddInt64(&b.inFlight, -1)
+			atomic.AddUint64(&b.shedMemory, 1)
+			return 0, false
+		}
+	}
+	for {
+		peak := atomic.LoadInt64(&b.peakTasks)
+		if n <= peak || atomic.CompareAndSwapInt64(&b.peakTasks, peak, n) {
+			break
+		}
+	}
+	return size, true
+}
+
+func (b *ProjectBudget) release(size int64) {
+	if b == nil {
+		return
+	}
+	if size > 0 {
+		atomic.AddInt64(&b.bytes, -size)
+	}
+	atomic.AddInt64(&b.inFlight, -1)
+}
+
+// ProjectBudgetStats is the current usage of a project against its soft limits
+type ProjectBudgetStats struct {
+	MaxTasks       int64  `json:"max_tasks,omitempty"`
+	MaxMemoryBytes int64  `json:"max_memory_bytes,omitempty"`
+	InFlight       int64  `json:"in_flight"`
+	PeakInFlight   int64  `json:"peak_in_flight"`
+	MemoryBytes    int64  `json:"memory_bytes"` // estimated size of the events in flight
+	ShedOverTasks  uint64 `json:"shed_over_tasks"`
+	ShedOverMemory uint64 `json:"shed_over_memory"`
+}
+
+// Stats returns the usage of the budget, the zero value for a nil budget
+func (b *ProjectBudget) Stats() ProjectBudgetStats {
+	if b == nil {
+		return ProjectBudgetStats{}
+	}
+	return ProjectBudgetStats{
+		MaxTasks:       b.maxTasks,
+		MaxMemoryBytes: b.maxBytes,
+		InFlight:       atomic.LoadInt64(&b.inFlight),
+		PeakInFlight:   atomic.LoadInt64(&b.peakTasks),
+		MemoryBytes:    atomic.LoadInt64(&b.bytes),
+		ShedOverTasks:  atomic.LoadUint64(&b.shedTasks),
+		ShedOverMemory: atomic.LoadUint64(&b.shedMemory),
+	}
+}
+
+// estimateEventSize approximates the memory held by an event: string and key bytes plus a fixed
+// overhead per value. It is only meant to compare events against a budget, not to be exact.
+func estimateEventSize(v interface{}) int64 {
+	const overhead = 16
+	switch val := v.(type) {
+	case map[string]interface{}:
+		size := int64(overhead)
+		for k, child := range val {
+			size += int64(len(k)) + estimateEventSize(child)
+		}
+		return size
+	case []interface{}:
+		size := int64(overhead)
+		for _, child := range val {
+			size += estimateEventSize(child)
+		}
+		return size
+	case string:
+		return overhead + int64(len(val))
+	case []byte:
+		return overhead + int64(len(val))
+	default:
+		return overhead
+	}
+}
@@ -0,0 +1,57 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"testing"
+)
+
+const quietBenchRules = `
+  <rule id="r1">
+    <check type="EQU" field="action">login</check>
+  </rule>
+  <rule id="r2">
+    <check type="INCL" field="user">adm</check>
+  </rule>
+  <rule id="r3">
+    <checklist condition="a or b">
+      <check id="a" type="START" field="path">/etc</check>
+      <check id="b" type="END" field="path">.sh</check>
+    </checklist>
+  </rule>
+</root>`
+
+// newBenchRuleset builds a ruleset that runs like a deployed one: counted, sampled and hit-tracked
+func newBenchRuleset(b *testing.B, root string) *Ruleset {
+	b.Helper()
+	rs, err := ParseRuleset([]byte(root + quietBenchRules))
+	if err != nil {
+		b.Fatalf("ParseRuleset error: %v", err)
+	}
+	rs.RulesetID = "BENCH.RS"
+	if err := RulesetBuild(rs); err != nil {
+		b.Fatalf("RulesetBuild error: %v", err)
+	}
+	rs.ProjectNodeSequence = "ruleset.bench.rs"
+	if !rs.Quiet {
+		rs.sampler = common.GetSampler("ruleset.bench.rs")
+	}
+	rs.resetRuleHits()
+	return rs
+}
+
+func benchmarkCheckAndSample(b *testing.B, rs *Ruleset) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Matches annotate the event, so each iteration needs its own
+		rs.checkAndSample(map[string]interface{}{"action": "login", "user": "admin", "path": "/etc/passwd"})
+	}
+}
+
+func BenchmarkCheckAndSample_Default(b *testing.B) {
+	benchmarkCheckAndSample(b, newBenchRuleset(b, `<root type="DETECTION" sample_trace="true">`))
+}
+
+func BenchmarkCheckAndSample_Quiet(b *testing.B) {
+	benchmarkCheckAndSample(b, newBenchRuleset(b, `<root type="DETECTION" quiet="true">`))
+}
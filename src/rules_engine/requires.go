@@ -0,0 +1,70 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// parseRequires parses the <requires> element of a rule: a comma separated list of the fields an
+// event must hold for the rule to be evaluated
+func parseRequires(element xml.StartElement, decoder *XMLDecoder, elementLine int) ([]string, error) {
+	var content strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			content.Write(t)
+		case xml.StartElement:
+			return nil, fmt.Errorf("requires cannot contain elements, found '<%s>' at line %d", t.Name.Local, elementLine)
+		case xml.EndElement:
+			if t.Name.Local == element.Name.Local {
+				return validateRequires(content.String(), elementLine)
+			}
+		}
+	}
+}
+
+func validateRequires(content string, elementLine int) ([]string, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("requires must list at least one field at line %d", elementLine)
+	}
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(content, ",") {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == "":
+			return nil, fmt.Errorf("requires has an empty field in '%s' at line %d", strings.TrimSpace(content), elementLine)
+		case hasFromRawPrefix(field):
+			return nil, fmt.Errorf("requires lists field paths, not references: '%s' must not start with '%s' at line %d", field, FromRawSymbol, elementLine)
+		case strings.ContainsAny(field, " \t\r\n"):
+			return nil, fmt.Errorf("requires field cannot contain whitespace, got '%s' at line %d", field, elementLine)
+		case seen[field]:
+			return nil, fmt.Errorf("requires lists field '%s' more than once at line %d", field, elementLine)
+		}
+		for _, segment := range common.StringToList(field) {
+			if segment == "" {
+				return nil, fmt.Errorf("requires field has an empty path segment, got '%s' at line %d", field, elementLine)
+			}
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// missingRequiredField returns the first required field the event does not hold, "" when the rule
+// can be evaluated
+func (rule *Rule) missingRequiredField(data map[string]interface{}) string {
+	for i, path := range rule.RequiresList {
+		if _, ok := common.GetCheckDataWithType(data, path); !ok {
+			return rule.Requires[i]
+		}
+	}
+	return ""
+}
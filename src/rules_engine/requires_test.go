@@ -0,0 +1,74 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequires_SkipsRuleWhenFieldMissing(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION">
+  <rule id="r_unknown_dst">
+    <requires>src_ip, dst_ip</requires>
+    <check type="ISNULL" field="dst_port"></check>
+  </rule>
+  <rule id="r_any">
+    <check type="NOTNULL" field="src_ip"></check>
+  </rule>
+</root>`)
+
+	// Without dst_ip the ISNULL check would match, the requirement skips the rule instead
+	results := rs.EngineCheck(map[string]interface{}{"src_ip": "10.0.0.1"})
+	if len(results) != 1 || results[0][HitRuleIdFieldName] != "TEST.RS.r_any" {
+		t.Fatalf("expected only the rule without requirement to match, got %v", results)
+	}
+
+	results = rs.EngineCheck(map[string]interface{}{"src_ip": "10.0.0.1", "dst_ip": "10.0.0.2"})
+	if len(results) != 2 {
+		t.Fatalf("expected both rules to match once the required fields exist, got %d results", len(results))
+	}
+
+	_, _, trace := rs.tracedCheck(map[string]interface{}{"src_ip": "10.0.0.1"})
+	if trace.Rules[0].MissingField != "dst_ip" || trace.Rules[0].Matched {
+		t.Fatalf("expected the trace to report the skipped rule, got %+v", trace.Rules[0])
+	}
+
+	if got := rs.Fields().Requires; len(got) != 2 || got[0] != "dst_ip" || got[1] != "src_ip" {
+		t.Fatalf("unexpected required fields %v", got)
+	}
+}
+
+func TestRequires_SkippedExcludeRulePassesEvent(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="EXCLUDE">
+  <rule id="r_internal" scope="net">
+    <requires>dst_ip</requires>
+    <check type="START" field="dst_ip">10.</check>
+  </rule>
+</root>`)
+
+	if got := rs.EngineCheck(map[string]interface{}{"net": map[string]interface{}{"src_ip": "10.0.0.1"}}); len(got) != 1 {
+		t.Fatalf("an event skipping every exclude rule must pass, got %d results", len(got))
+	}
+	if got := rs.EngineCheck(map[string]interface{}{"net": map[string]interface{}{"dst_ip": "10.0.0.1"}}); len(got) != 0 {
+		t.Fatalf("the scoped requirement must be met within the scope, got %d results", len(got))
+	}
+}
+
+func TestRequires_Validation(t *testing.T) {
+	cases := map[string]string{
+		`<rule id="r"><requires></requires><check type="NOTNULL" field="a"></check></rule>`:                         "must list at least one field",
+		`<rule id="r"><requires>a,,b</requires><check type="NOTNULL" field="a"></check></rule>`:                     "empty field",
+		`<rule id="r"><requires>_$a</requires><check type="NOTNULL" field="a"></check></rule>`:                      "must not start with",
+		`<rule id="r"><requires>a,a</requires><check type="NOTNULL" field="a"></check></rule>`:                      "more than once",
+		`<rule id="r"><requires>a..b</requires><check type="NOTNULL" field="a"></check></rule>`:                     "empty path segment",
+		`<rule id="r"><requires>a</requires><requires>b</requires><check type="NOTNULL" field="a"></check></rule>`:  "more than one requires",
+		`<rule id="r"><checklist><requires>a</requires><check type="NOTNULL" field="a"></check></checklist></rule>`: "cannot be inside a checklist",
+	}
+	for rule, want := range cases {
+		_, err := ParseRuleset([]byte(`<root type="DETECTION">` + rule + `</root>`))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q for %s, got %v", want, rule, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package rules_engine
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Values of the root attribute on_rule_error
+const (
+	// OnRuleErrorFail rejects the whole ruleset when a rule fails to build (default)
+	OnRuleErrorFail = "fail"
+	// OnRuleErrorSkip drops the rules that fail to build and runs the others
+	OnRuleErrorSkip = "skip"
+)
+
+// RuleBuildError records a rule that was skipped because it failed to build
+type RuleBuildError struct {
+	RuleID string `json:"rule_id"`
+	Error  string `json:"error"`
+}
+
+// skipFailedRules removes the rules listed in ruleErrors and records why they were skipped
+func (r *Ruleset) skipFailedRules(ruleErrors []RuleBuildError) {
+	r.RuleErrors = ruleErrors
+	if len(ruleErrors) == 0 {
+		return
+	}
+
+	failed := make(map[string]bool, len(ruleErrors))
+	for _, e := range ruleErrors {
+		failed[e.RuleID] = true
+	}
+	kept := make([]Rule, 0, len(r.Rules))
+	for i := range r.Rules {
+		if !failed[r.Rules[i].ID] {
+			kept = append(kept, r.Rules[i])
+		}
+	}
+	r.Rules = kept
+}
+
+// skipToRuleEnd consumes tokens up to and including the end tag of the current rule
+func skipToRuleEnd(decoder *XMLDecoder) error {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if end, ok := token.(xml.EndElement); ok && end.Name.Local == "rule" {
+			return nil
+		}
+	}
+}
+
+// ruleErrorsSummary describes the skipped rules, nil when all rules were built
+func (r *Ruleset) ruleErrorsSummary() error {
+	if len(r.RuleErrors) == 0 {
+		return nil
+	}
+	parts := make([]string, 0, len(r.RuleErrors))
+	for _, e := range r.RuleErrors {
+		parts = append(parts, fmt.Sprintf("%s: %s", e.RuleID, e.Error))
+	}
+	return fmt.Errorf("%d rule(s) skipped because they failed to build: %s", len(r.RuleErrors), strings.Join(parts, "; "))
+}
+
+// validateSkippableRule reports the errors of a rule as warnings, since under on_rule_error="skip"
+// they drop the rule instead of rejecting the ruleset
+func validateSkippableRule(rule *Rule, xmlContent string, ruleIndex int, result *ValidationResult) {
+	ruleResult := &ValidationResult{IsValid: true}
+	validateRule(rule, xmlContent, ruleIndex, ruleResult)
+	result.Warnings = append(result.Warnings, ruleResult.Warnings...)
+	for _, e := range ruleResult.Errors {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Line:    e.Line,
+			Message: fmt.Sprintf("Rule '%s' will be skipped: %s", rule.ID, e.Message),
+			Detail:  e.Detail,
+		})
+	}
+}
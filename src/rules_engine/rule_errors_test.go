@@ -0,0 +1,40 @@
+package rules_engine
+
+import "testing"
+
+func TestOnRuleErrorSkip_IsolatesFailingRule(t *testing.T) {
+	xml := `
+<root type="DETECTION" name="skip" on_rule_error="skip">
+  <rule id="good1">
+    <check type="EQU" field="action">login</check>
+  </rule>
+  <rule id="bad">
+    <check type="PLUGIN">noSuchPlugin(action)</check>
+  </rule>
+  <rule id="good2">
+    <check type="INCL" field="user">admin</check>
+  </rule>
+</root>`
+	rs := buildRulesetFromXML(t, xml)
+
+	if len(rs.RuleErrors) != 1 || rs.RuleErrors[0].RuleID != "bad" || rs.RuleErrors[0].Error == "" {
+		t.Fatalf("expected rule 'bad' to be reported, got %+v", rs.RuleErrors)
+	}
+	if len(rs.Rules) != 2 {
+		t.Fatalf("expected the 2 good rules to remain, got %d", len(rs.Rules))
+	}
+	res := rs.EngineCheck(map[string]interface{}{"action": "login", "user": "admin"})
+	if len(res) != 2 {
+		t.Fatalf("expected both good rules to match, got %d", len(res))
+	}
+
+	result, err := ValidateWithDetails("", xml)
+	if err != nil || !result.IsValid || len(result.Warnings) == 0 {
+		t.Fatalf("expected the failing rule as a warning, got %+v %v", result, err)
+	}
+
+	// Without the mode the whole ruleset is rejected
+	if _, err := ParseRuleset([]byte(`<root type="DETECTION"><rule id="bad"><check type="PLUGIN">noSuchPlugin(action)</check></rule></root>`)); err == nil {
+		t.Fatalf("expected an error without on_rule_error=\"skip\"")
+	}
+}
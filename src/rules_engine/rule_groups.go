@@ -0,0 +1,49 @@
+package rules_engine
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// validateRuleGroup checks the optional rule attribute group, a category such as an ATT&CK tactic
+func validateRuleGroup(group string) error {
+	if group == "" {
+		return fmt.Errorf("rule group cannot be empty")
+	}
+	if strings.ContainsAny(group, " \t\r\n") {
+		return fmt.Errorf("rule group cannot contain whitespace, got '%s'", group)
+	}
+	return nil
+}
+
+// RuleGroups returns the IDs of the rules in each group in definition order. Rules without a group
+// are left out.
+func (r *Ruleset) RuleGroups() map[string][]string {
+	groups := make(map[string][]string)
+	for i := range r.Rules {
+		if g := r.Rules[i].Group; g != "" {
+			groups[g] = append(groups[g], r.Rules[i].ID)
+		}
+	}
+	return groups
+}
+
+// GetGroupHits returns the hits per rule group since the ruleset started, summing the hits of the
+// rules in each group. Groups whose rules have not fired report 0.
+func (r *Ruleset) GetGroupHits() map[string]uint64 {
+	hits := r.ruleHits
+	groups := make(map[string]uint64)
+	for i := range r.Rules {
+		g := r.Rules[i].Group
+		if g == "" {
+			continue
+		}
+		var n uint64
+		if i < len(hits) {
+			n = atomic.LoadUint64(&hits[i])
+		}
+		groups[g] += n
+	}
+	return groups
+}
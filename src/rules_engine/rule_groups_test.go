@@ -0,0 +1,59 @@
+package rules_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleGroups_HitsAggregateByGroup(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION" name="groups">
+  <rule id="brute_force" group="credential_access">
+    <check type="EQU" field="action">login_failed</check>
+  </rule>
+  <rule id="dump_lsass" group="credential_access">
+    <check type="INCL" field="cmd">lsass</check>
+  </rule>
+  <rule id="new_service" group="persistence">
+    <check type="EQU" field="action">service_created</check>
+  </rule>
+  <rule id="ungrouped">
+    <check type="EQU" field="action">other</check>
+  </rule>
+</root>`)
+	rs.resetRuleHits()
+
+	events := []map[string]interface{}{
+		{"action": "login_failed"},
+		{"action": "login_failed", "cmd": "procdump lsass.exe"},
+		{"action": "x", "cmd": "lsass"},
+		{"action": "other"},
+	}
+	for _, event := range events {
+		rs.EngineCheck(event)
+	}
+
+	hits := rs.GetGroupHits()
+	if hits["credential_access"] != 4 {
+		t.Fatalf("expected 4 credential_access hits over both rules, got %v", hits)
+	}
+	if n, ok := hits["persistence"]; !ok || n != 0 {
+		t.Fatalf("expected persistence to be reported with 0 hits, got %v", hits)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("expected ungrouped rules to be left out, got %v", hits)
+	}
+	if ids := rs.RuleGroups()["credential_access"]; strings.Join(ids, ",") != "brute_force,dump_lsass" {
+		t.Fatalf("expected the credential_access rules in definition order, got %v", ids)
+	}
+}
+
+func TestRuleGroups_Verify(t *testing.T) {
+	xml := `<root type="DETECTION"><rule id="r1" group="credential access"><check type="EQU" field="a">x</check></rule></root>`
+	if _, err := ParseRuleset([]byte(xml)); err == nil || !strings.Contains(err.Error(), "whitespace") {
+		t.Fatalf("expected a group with whitespace to be rejected, got %v", err)
+	}
+	if _, err := ParseRuleset([]byte(`<root type="DETECTION"><rule id="r1"><check type="EQU" field="a">x</check></rule></root>`)); err != nil {
+		t.Fatalf("expected group to be optional: %v", err)
+	}
+}
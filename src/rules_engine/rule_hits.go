@@ -0,0 +1,48 @@
+package rules_engine
+
+import "sync/atomic"
+
+// resetRuleHits allocates one hit counter per rule, called when the ruleset starts
+func (r *Ruleset) resetRuleHits() {
+	r.ruleHits = make([]uint64, len(r.Rules))
+	r.ruleHitsReported = make([]uint64, len(r.Rules))
+}
+
+// countRuleHit records a match of the rule at ruleIndex
+func (r *Ruleset) countRuleHit(ruleIndex int) {
+	if ruleIndex < len(r.ruleHits) {
+		atomic.AddUint64(&r.ruleHits[ruleIndex], 1)
+	}
+}
+
+// GetRuleHitIncrementsAndUpdate returns the hits per rule ID since the last call and moves the
+// baseline, like GetIncrementAndUpdate does for the processed total. Rules without new hits are
+// left out.
+func (r *Ruleset) GetRuleHitIncrementsAndUpdate() map[string]uint64 {
+	hits, reported := r.ruleHits, r.ruleHitsReported
+	if len(hits) == 0 || len(hits) != len(r.Rules) {
+		return nil
+	}
+	var increments map[string]uint64
+	for i := range hits {
+		current := atomic.LoadUint64(&hits[i])
+		last := atomic.LoadUint64(&reported[i])
+		if current == last || !atomic.CompareAndSwapUint64(&reported[i], last, current) {
+			continue
+		}
+		if increments == nil {
+			increments = make(map[string]uint64)
+		}
+		increments[r.Rules[i].ID] = current - last
+	}
+	return increments
+}
+
+// RuleIDs returns the IDs of all rules in the ruleset in definition order
+func (r *Ruleset) RuleIDs() []string {
+	ids := make([]string, 0, len(r.Rules))
+	for i := range r.Rules {
+		ids = append(ids, r.Rules[i].ID)
+	}
+	return ids
+}
@@ -0,0 +1,74 @@
+package rules_engine
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"AgentSmith-HUB/common"
+)
+
+func rulesetWithRules(n int) string {
+	var sb strings.Builder
+	sb.WriteString(`<root type="DETECTION" name="limits">` + "\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `  <rule id="r%d" name="r%d"><check type="EQU" field="f">x</check></rule>`+"\n", i, i)
+	}
+	sb.WriteString(`</root>`)
+	return sb.String()
+}
+
+func withRuleLimits(t *testing.T, warn, max int) {
+	t.Helper()
+	prev := common.Config
+	common.Config = &common.HubConfig{RulesetWarnRules: warn, RulesetMaxRules: max}
+	t.Cleanup(func() { common.Config = prev })
+}
+
+func TestValidateRuleCount_Thresholds(t *testing.T) {
+	withRuleLimits(t, 3, 5)
+
+	cases := []struct {
+		rules   int
+		valid   bool
+		warning bool
+	}{
+		{3, true, false},
+		{4, true, true},
+		{5, true, true},
+		{6, false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%d_rules", tc.rules), func(t *testing.T) {
+			res, err := ValidateWithDetails("", rulesetWithRules(tc.rules))
+			if err != nil {
+				t.Fatalf("ValidateWithDetails error: %v", err)
+			}
+			if res.IsValid != tc.valid {
+				t.Fatalf("IsValid = %v, want %v (errors: %+v)", res.IsValid, tc.valid, res.Errors)
+			}
+
+			hasWarning := false
+			for _, w := range res.Warnings {
+				if strings.Contains(w.Message, fmt.Sprintf("has %d rules", tc.rules)) {
+					hasWarning = true
+				}
+			}
+			if hasWarning != tc.warning {
+				t.Fatalf("rule count warning = %v, want %v (warnings: %+v)", hasWarning, tc.warning, res.Warnings)
+			}
+
+			if !tc.valid && !strings.Contains(res.Errors[0].Message, "exceeding the maximum of 5") {
+				t.Fatalf("unexpected error message: %s", res.Errors[0].Message)
+			}
+		})
+	}
+}
+
+func TestVerify_RejectsAboveRuleCap(t *testing.T) {
+	withRuleLimits(t, 1, 2)
+	if err := Verify("", rulesetWithRules(3)); err == nil {
+		t.Fatal("expected Verify to reject a ruleset above the hard cap")
+	}
+}
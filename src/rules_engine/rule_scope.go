@@ -0,0 +1,151 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"fmt"
+	"strings"
+)
+
+// validateRuleScope checks the rule attribute scope, a dotted path to the subtree the rule reads
+func validateRuleScope(scope string) error {
+	if scope == "" {
+		return fmt.Errorf("rule scope cannot be empty")
+	}
+	if hasFromRawPrefix(scope) {
+		return fmt.Errorf("rule scope is a field path and must not start with '%s', got '%s'", FromRawSymbol, scope)
+	}
+	if strings.ContainsAny(scope, " \t\r\n") {
+		return fmt.Errorf("rule scope cannot contain whitespace, got '%s'", scope)
+	}
+	for _, segment := range common.StringToList(scope) {
+		if segment == "" {
+			return fmt.Errorf("rule scope has an empty path segment, got '%s'", scope)
+		}
+	}
+	return nil
+}
+
+// scopedField prefixes a field path with the rule scope
+func scopedField(scope, field string) string {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return field
+	}
+	return scope + "." + field
+}
+
+// scopedValue prefixes the field of a _$ reference with the rule scope, other values are kept
+func scopedValue(scope, value string) string {
+	trimmed := strings.TrimSpace(value)
+	if !hasFromRawPrefix(trimmed) || trimmed == PluginArgFromRawSymbol {
+		return value
+	}
+	return FromRawSymbol + scopedField(scope, trimmed[FromRawSymbolLen:])
+}
+
+func scopePluginArgs(scope string, args []*PluginArg) {
+	for _, arg := range args {
+		if arg == nil || arg.Type != 1 {
+			continue
+		}
+		if field, ok := arg.Value.(string); ok {
+			arg.Value = scopedField(scope, field)
+		}
+	}
+}
+
+func scopeCheckNode(scope string, node *CheckNodes) {
+	if node.Field != "" {
+		node.Field = scopedField(scope, node.Field)
+		node.FieldList = common.StringToList(node.Field)
+	}
+	node.Value = scopedValue(scope, node.Value)
+	for i, v := range node.DelimiterFieldList {
+		node.DelimiterFieldList[i] = scopedValue(scope, v)
+	}
+	scopePluginArgs(scope, node.PluginArgs)
+}
+
+func scopeThreshold(scope string, threshold *Threshold) {
+	if len(threshold.GroupByList) > 0 {
+		groupBy := make(map[string][]string, len(threshold.GroupByList))
+		for field := range threshold.GroupByList {
+			f := scopedField(scope, field)
+			groupBy[f] = common.StringToList(f)
+		}
+		threshold.GroupByList = groupBy
+	}
+	if threshold.CountField != "" {
+		threshold.CountField = scopedField(scope, threshold.CountField)
+		threshold.CountFieldList = common.StringToList(threshold.CountField)
+	}
+}
+
+// applyScope rewrites the field references of a built rule so they resolve below rule.Scope:
+// check fields and _$ values, threshold and aggregate fields, plugin arguments, iterated, required and
+// deleted fields. Append target fields stay relative to the event root, and nodes inside an
+// <iterator> keep resolving against the iterator variable.
+func (rule *Rule) applyScope() {
+	scope := rule.Scope
+	if scope == "" {
+		return
+	}
+	for id, checklist := range rule.ChecklistMap {
+		for i := range checklist.CheckNodes {
+			scopeCheckNode(scope, &checklist.CheckNodes[i])
+		}
+		for i := range checklist.ThresholdNodes {
+			scopeThreshold(scope, &checklist.ThresholdNodes[i])
+		}
+		rule.ChecklistMap[id] = checklist
+	}
+	for id, node := range rule.CheckMap {
+		scopeCheckNode(scope, &node)
+		rule.CheckMap[id] = node
+	}
+	for id, threshold := range rule.ThresholdMap {
+		scopeThreshold(scope, &threshold)
+		rule.ThresholdMap[id] = threshold
+	}
+	for id, iterator := range rule.IteratorMap {
+		iterator.Field = scopedField(scope, iterator.Field)
+		iterator.FieldList = common.StringToList(iterator.Field)
+		rule.IteratorMap[id] = iterator
+	}
+	for id, a := range rule.AppendsMap {
+		if a.usesPlugin() {
+			scopePluginArgs(scope, a.PluginArgs)
+		} else if a.Type != AppendMerge {
+			a.Value = scopedValue(scope, a.Value)
+		}
+		rule.AppendsMap[id] = a
+	}
+	for _, p := range rule.PluginMap {
+		scopePluginArgs(scope, p.PluginArgs)
+	}
+	scopePath := common.StringToList(scope)
+	for id, fields := range rule.DelMap {
+		for i, path := range fields {
+			fields[i] = append(append([]string{}, scopePath...), path...)
+		}
+		rule.DelMap[id] = fields
+	}
+	if agg := rule.Aggregate; agg != nil {
+		for i, field := range agg.GroupByFields {
+			agg.GroupByFields[i] = scopedField(scope, field)
+			if i < len(agg.GroupByList) {
+				agg.GroupByList[i] = common.StringToList(agg.GroupByFields[i])
+			}
+		}
+	}
+	for i, field := range rule.Requires {
+		rule.Requires[i] = scopedField(scope, field)
+		rule.RequiresList[i] = common.StringToList(rule.Requires[i])
+	}
+	if cd := rule.Cooldown; cd != nil {
+		for i, field := range cd.GroupByFields {
+			cd.GroupByFields[i] = scopedField(scope, field)
+			cd.GroupByList[i] = common.StringToList(cd.GroupByFields[i])
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package rules_engine
+
+import (
+	"testing"
+)
+
+func TestRuleScope_MatchesNestedFieldWithoutFullPath(t *testing.T) {
+	rs := buildRulesetFromXML(t, `
+<root type="DETECTION">
+  <rule id="r1" scope="event.details">
+    <checklist condition="a and b">
+      <check id="a" type="EQU" field="action">login</check>
+      <check id="b" type="NEQ" field="user">_$expected_user</check>
+    </checklist>
+    <append field="matched_user">_$user</append>
+    <del>password</del>
+  </rule>
+</root>`)
+
+	event := map[string]interface{}{
+		"action": "logout", // root fields are ignored by the scoped rule
+		"event": map[string]interface{}{
+			"details": map[string]interface{}{
+				"action":        "login",
+				"user":          "root",
+				"expected_user": "alice",
+				"password":      "secret",
+			},
+		},
+	}
+	results := rs.EngineCheck(event)
+	if len(results) != 1 {
+		t.Fatalf("expected the scoped rule to match, got %d results", len(results))
+	}
+	res := results[0]
+	if res["matched_user"] != "root" {
+		t.Fatalf("append must read within the scope and write at the root, got %v", res["matched_user"])
+	}
+	details := res["event"].(map[string]interface{})["details"].(map[string]interface{})
+	if _, ok := details["password"]; ok {
+		t.Fatal("del must resolve within the scope")
+	}
+
+	miss := map[string]interface{}{
+		"action": "login",
+		"user":   "root",
+		"event":  map[string]interface{}{"details": map[string]interface{}{"action": "logout"}},
+	}
+	if len(rs.EngineCheck(miss)) != 0 {
+		t.Fatal("the scoped rule must not match on root fields")
+	}
+
+	if got := rs.Fields().Reads; len(got) != 3 || got[0] != "event.details.action" {
+		t.Fatalf("fields must report the resolved paths, got %v", got)
+	}
+}
+
+func TestRuleScope_InvalidPath(t *testing.T) {
+	for _, scope := range []string{"", "event..details", "_$event", "event details", ".event"} {
+		xml := `<root type="DETECTION"><rule id="r1" scope="` + scope + `"><check type="EQU" field="a">b</check></rule></root>`
+		if _, err := ParseRuleset([]byte(xml)); err == nil {
+			t.Fatalf("scope %q must be rejected", scope)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package rules_engine
+
+import "AgentSmith-HUB/common"
+
+// Values of the root attribute sample, selecting the events the ruleset sampler keeps
+const (
+	// SampleAll samples events regardless of the outcome (default)
+	SampleAll = "all"
+	// SampleMatched only samples events on which a rule matched: a hit for DETECTION, a filtered
+	// event for EXCLUDE
+	SampleMatched = "matched"
+	// SampleUnmatched only samples events no rule matched
+	SampleUnmatched = "unmatched"
+)
+
+// storeOutcomeSample stores an event claimed by the outcome sampling, replaced in tests since
+// samples are persisted to Redis
+var storeOutcomeSample = func(s *common.Sampler, data map[string]interface{}, trace *EvalTrace, projectNodeSequence string) {
+	if trace != nil {
+		s.StoreClaimed(data, trace, projectNodeSequence)
+		return
+	}
+	s.StoreClaimed(data, nil, projectNodeSequence)
+}
+
+// sampleByOutcome reports whether the sampler keeps events depending on their outcome
+func (r *Ruleset) sampleByOutcome() bool {
+	return r.SampleMode == SampleMatched || r.SampleMode == SampleUnmatched
+}
+
+// wantsOutcome reports whether an event with these results belongs in the samples
+func (r *Ruleset) wantsOutcome(results []map[string]interface{}) bool {
+	matched := len(results) > 0
+	if !r.IsDetection {
+		matched = len(results) == 0
+	}
+	return matched == (r.SampleMode == SampleMatched)
+}
+
+// checkAndSampleOutcome runs the rules and samples the event only if its outcome is the one the
+// sample mode keeps. The sampling slot is taken after the check, so events with another outcome
+// leave it open for the next event instead of using it up.
+func (r *Ruleset) checkAndSampleOutcome(data map[string]interface{}) []map[string]interface{} {
+	if !r.sampler.Pending(r.ProjectNodeSequence) {
+		return r.EngineCheck(data)
+	}
+
+	// Rules that do not modify data only add the hit rule id and name to the event, restored on the sample
+	prevHit, hadHit := data[HitRuleIdFieldName]
+	prevName, hadName := data[HitRuleNameFieldName]
+	var trace *EvalTrace
+	if r.SampleTrace {
+		trace = &EvalTrace{Rules: make([]RuleTrace, 0, len(r.Rules))}
+	}
+	results := r.engineCheck(data, trace)
+	if !r.wantsOutcome(results) || !r.sampler.Claim(r.ProjectNodeSequence) {
+		return results
+	}
+
+	sample := common.MapDeepCopy(data)
+	if hadHit {
+		sample[HitRuleIdFieldName] = prevHit
+	} else {
+		delete(sample, HitRuleIdFieldName)
+	}
+	if hadName {
+		sample[HitRuleNameFieldName] = prevName
+	} else {
+		delete(sample, HitRuleNameFieldName)
+	}
+	storeOutcomeSample(r.sampler, sample, trace, r.ProjectNodeSequence)
+	return results
+}
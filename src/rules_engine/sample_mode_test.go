@@ -0,0 +1,84 @@
+package rules_engine
+
+import (
+	"fmt"
+	"testing"
+
+	"AgentSmith-HUB/common"
+)
+
+const sampleModeRulesetXML = `
+<root type="DETECTION" name="sample_mode" sample="%s">
+  <rule id="r1" name="r1">
+    <check type="EQU" field="action">login</check>
+  </rule>
+</root>`
+
+// captureOutcomeSamples makes the ruleset sample into a slice instead of Redis
+func captureOutcomeSamples(t *testing.T, rs *Ruleset) *[]map[string]interface{} {
+	t.Helper()
+	orig := storeOutcomeSample
+	t.Cleanup(func() { storeOutcomeSample = orig })
+	stored := &[]map[string]interface{}{}
+	storeOutcomeSample = func(_ *common.Sampler, data map[string]interface{}, _ *EvalTrace, _ string) {
+		*stored = append(*stored, data)
+	}
+
+	sampler := common.NewSampler("test_" + rs.SampleMode)
+	t.Cleanup(sampler.Close)
+	rs.sampler = sampler
+	rs.ProjectNodeSequence = "INPUT.in.RULESET.sample_mode"
+	return stored
+}
+
+func TestSampleMatchedOnlyStoresFiredEvents(t *testing.T) {
+	rs := buildRulesetFromXML(t, fmt.Sprintf(sampleModeRulesetXML, SampleMatched))
+	if rs.SampleMode != SampleMatched {
+		t.Fatalf("expected sample mode to be parsed, got %q", rs.SampleMode)
+	}
+	stored := captureOutcomeSamples(t, rs)
+
+	// The slot is open for the first event, but unmatched events must leave it open
+	for i := 0; i < 3; i++ {
+		if res := rs.checkAndSample(map[string]interface{}{"action": "logout", "n": i}); len(res) != 0 {
+			t.Fatalf("unexpected hit: %+v", res)
+		}
+	}
+	if len(*stored) != 0 || !rs.sampler.Pending(rs.ProjectNodeSequence) {
+		t.Fatalf("unmatched events must not be sampled nor use up the slot: %+v", *stored)
+	}
+	if res := rs.checkAndSample(map[string]interface{}{"action": "login", "user": "alice"}); len(res) != 1 {
+		t.Fatalf("expected one hit, got %+v", res)
+	}
+	// The slot stays closed until the sampling controller reopens it
+	rs.checkAndSample(map[string]interface{}{"action": "login", "user": "bob"})
+
+	if len(*stored) != 1 {
+		t.Fatalf("expected exactly one sample, got %+v", *stored)
+	}
+	sample := (*stored)[0]
+	if sample["user"] != "alice" {
+		t.Fatalf("expected the matched event to be sampled, got %+v", sample)
+	}
+	if _, ok := sample[HitRuleIdFieldName]; ok {
+		t.Fatalf("the sample must be the event as it entered the ruleset: %+v", sample)
+	}
+}
+
+func TestSampleUnmatchedOnly(t *testing.T) {
+	rs := buildRulesetFromXML(t, fmt.Sprintf(sampleModeRulesetXML, SampleUnmatched))
+	stored := captureOutcomeSamples(t, rs)
+
+	rs.checkAndSample(map[string]interface{}{"action": "login"})
+	rs.checkAndSample(map[string]interface{}{"action": "logout"})
+	if len(*stored) != 1 || (*stored)[0]["action"] != "logout" {
+		t.Fatalf("expected only the unmatched event to be sampled, got %+v", *stored)
+	}
+
+	if _, err := ParseRuleset([]byte(`<root type="DETECTION" sample="hits"></root>`)); err == nil {
+		t.Fatal("expected an invalid sample value to be rejected")
+	}
+	if _, err := ParseRuleset([]byte(`<root type="DETECTION" quiet="true" sample="matched"></root>`)); err == nil {
+		t.Fatal("expected quiet to conflict with a filtered sample mode")
+	}
+}
@@ -122,17 +122,23 @@ func (r *Ruleset) simdExecuteORLogic(checkNode *CheckNodes, data map[string]inte
 
 	simdOps := GetSIMDOperations()
 
-	// Prepare patterns for batch processing
+	// Prepare patterns for batch processing; references to missing fields cannot match
 	patterns := make([]string, 0, len(checkNode.DelimiterFieldList))
 	for _, v := range checkNode.DelimiterFieldList {
 		var checkNodeValue string
 		if hasFromRawPrefix(v) {
-			checkNodeValue = GetRuleValueFromRawFromCache(ruleCache, v, data)
+			var exist bool
+			if checkNodeValue, exist = GetRuleValueFromRawWithExist(ruleCache, v, data); !exist {
+				continue
+			}
 		} else {
 			checkNodeValue = v
 		}
 		patterns = append(patterns, checkNodeValue)
 	}
+	if len(patterns) == 0 {
+		return false
+	}
 
 	// Choose the appropriate SIMD operation based on check type
 	var operation CompareOperation
@@ -180,12 +186,15 @@ func (r *Ruleset) simdExecuteANDLogic(checkNode *CheckNodes, data map[string]int
 
 	simdOps := GetSIMDOperations()
 
-	// Prepare patterns for batch processing
+	// Prepare patterns for batch processing; a reference to a missing field fails the AND
 	patterns := make([]string, 0, len(checkNode.DelimiterFieldList))
 	for _, v := range checkNode.DelimiterFieldList {
 		var checkNodeValue string
 		if hasFromRawPrefix(v) {
-			checkNodeValue = GetRuleValueFromRawFromCache(ruleCache, v, data)
+			var exist bool
+			if checkNodeValue, exist = GetRuleValueFromRawWithExist(ruleCache, v, data); !exist {
+				return false
+			}
 		} else {
 			checkNodeValue = v
 		}
@@ -0,0 +1,104 @@
+package rules_engine
+
+import (
+	"AgentSmith-HUB/common"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// taskGuard is a counting semaphore bounding the engine tasks in flight across all rulesets.
+// Every ruleset pool resizes on its own, so without a shared cap many busy projects can together
+// spawn far more goroutines than the node can hold.
+type taskGuard struct {
+	slots    chan struct{}
+	inFlight int64
+	peak     int64
+	waits    uint64 // acquisitions that found the guard saturated
+}
+
+func newTaskGuard(limit int) *taskGuard {
+	return &taskGuard{slots: make(chan struct{}, limit)}
+}
+
+// acquire takes a slot, blocking while the guard is saturated. This holds back the upstream reader
+// so the upstream channel fills and the input slows down, instead of tasks piling up in memory.
+func (g *taskGuard) acquire() {
+	select {
+	case g.slots <- struct{}{}:
+	default:
+		atomic.AddUint64(&g.waits, 1)
+		g.slots <- struct{}{}
+	}
+	n := atomic.AddInt64(&g.inFlight, 1)
+	for {
+		peak := atomic.LoadInt64(&g.peak)
+		if n <= peak || atomic.CompareAndSwapInt64(&g.peak, peak, n) {
+			break
+		}
+	}
+}
+
+func (g *taskGuard) release() {
+	atomic.AddInt64(&g.inFlight, -1)
+	<-g.slots
+}
+
+// evaluateThenDeliver runs evaluate on the slot taken by acquire and releases it before deliver.
+// Delivery blocks on a full downstream; in ruleset chains a ruleset waiting for its downstream
+// ruleset must not hold the slots that ruleset needs to drain its channel.
+func (g *taskGuard) evaluateThenDeliver(evaluate, deliver func()) {
+	func() {
+		defer g.release()
+		evaluate()
+	}()
+	deliver()
+}
+
+// EngineTaskStats is the state of the global engine task guard, reported with node metrics
+type EngineTaskStats struct {
+	Limit    int    `json:"limit"`
+	InFlight int64  `json:"in_flight"`
+	Peak     int64  `json:"peak"`
+	Waits    uint64 `json:"waits"` // times a ruleset had to wait for a free slot
+}
+
+func (g *taskGuard) stats() EngineTaskStats {
+	return EngineTaskStats{
+		Limit:    cap(g.slots),
+		InFlight: atomic.LoadInt64(&g.inFlight),
+		Peak:     atomic.LoadInt64(&g.peak),
+		Waits:    atomic.LoadUint64(&g.waits),
+	}
+}
+
+var (
+	engineTasks     *taskGuard
+	engineTasksOnce sync.Once
+)
+
+// getEngineTaskLimit returns the configured max_engine_tasks, or a default sized to keep every
+// ruleset pool on a few busy projects at its maximum
+func getEngineTaskLimit() int {
+	if common.Config != nil && common.Config.MaxEngineTasks > 0 {
+		return common.Config.MaxEngineTasks
+	}
+	limit := runtime.NumCPU() * 32
+	if limit < 256 {
+		limit = 256
+	}
+	return limit
+}
+
+// globalTaskGuard returns the process wide guard, created from the hub config on first use
+func globalTaskGuard() *taskGuard {
+	engineTasksOnce.Do(func() {
+		engineTasks = newTaskGuard(getEngineTaskLimit())
+	})
+	return engineTasks
+}
+
+// GetEngineTaskStats returns the limit and saturation of the global engine task guard
+func GetEngineTaskStats() EngineTaskStats {
+	return globalTaskGuard().stats()
+}
@@ -0,0 +1,188 @@
+package rules_engine
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTaskGuard_BoundsConcurrentTasks(t *testing.T) {
+	g := newTaskGuard(3)
+	var running, maxRunning int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.acquire()
+			defer g.release()
+			n := atomic.AddInt64(&running, 1)
+			for {
+				m := atomic.LoadInt64(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&running, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning > 3 {
+		t.Fatalf("expected at most 3 concurrent tasks, got %d", maxRunning)
+	}
+	stats := g.stats()
+	if stats.Limit != 3 || stats.InFlight != 0 || stats.Peak > 3 || stats.Waits == 0 {
+		t.Fatalf("unexpected guard stats: %+v", stats)
+	}
+}
+
+func TestTaskGuard_SharedAcrossRulesets(t *testing.T) {
+	globalTaskGuard()
+	saved := engineTasks
+	engineTasks = newTaskGuard(2)
+	defer func() { engineTasks = saved }()
+
+	// Saturate the guard before any event arrives
+	engineTasks.acquire()
+	engineTasks.acquire()
+
+	var downs []chan map[string]interface{}
+	for i := 0; i < 2; i++ {
+		rs := buildRulesetFromXML(t, `<root type="DETECTION" name="guard"><rule id="r1" name="r1"><check type="EQU" field="a">1</check></rule></root>`)
+		up := make(chan map[string]interface{}, 10)
+		down := make(chan map[string]interface{}, 10)
+		rs.UpStream = map[string]*chan map[string]interface{}{"in": &up}
+		rs.DownStream = map[string]*chan map[string]interface{}{"out": &down}
+		if err := rs.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer rs.Stop()
+		for j := 0; j < 3; j++ {
+			up <- map[string]interface{}{"a": "1"}
+		}
+		downs = append(downs, down)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	for _, down := range downs {
+		if len(down) != 0 {
+			t.Fatalf("a ruleset ran a task while the global guard was saturated")
+		}
+	}
+
+	engineTasks.release()
+	engineTasks.release()
+
+	received := 0
+	deadline := time.After(5 * time.Second)
+	for received < 6 {
+		select {
+		case <-downs[0]:
+			received++
+		case <-downs[1]:
+			received++
+		case <-deadline:
+			t.Fatalf("expected all 6 events after releasing the guard, got %d", received)
+		}
+	}
+	if stats := engineTasks.stats(); stats.Peak > 2 {
+		t.Fatalf("global cap exceeded across rulesets: %+v", stats)
+	}
+}
+
+func TestProjectBudget_ShedsOnlyTheProjectOverBudget(t *testing.T) {
+	const xml = `<root type="DETECTION" name="budget"><rule id="r1" name="r1"><check type="EQU" field="a">1</check></rule></root>`
+	start := func(budget *ProjectBudget) (chan map[string]interface{}, chan map[string]interface{}) {
+		rs := buildRulesetFromXML(t, xml)
+		up := make(chan map[string]interface{}, 10)
+		down := make(chan map[string]interface{}, 10)
+		rs.UpStream = map[string]*chan map[string]interface{}{"in": &up}
+		rs.DownStream = map[string]*chan map[string]interface{}{"out": &down}
+		rs.SetProjectBudget(budget)
+		if err := rs.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { rs.Stop() })
+		return up, down
+	}
+
+	// The busy project already holds its only task, the other is well within its budget
+	busy := NewProjectBudget(1, 0)
+	if _, ok := busy.tryAcquire(nil); !ok {
+		t.Fatal("expected the first task to fit the budget")
+	}
+	other := NewProjectBudget(100, 0)
+	busyUp, busyDown := start(busy)
+	otherUp, otherDown := start(other)
+
+	for i := 0; i < 3; i++ {
+		busyUp <- map[string]interface{}{"a": "1"}
+		otherUp <- map[string]interface{}{"a": "1"}
+	}
+
+	deadline := time.After(5 * time.Second)
+	for received := 0; received < 3; received++ {
+		select {
+		case <-otherDown:
+		case <-deadline:
+			t.Fatalf("the project within budget was held back, got %d of 3 events", received)
+		}
+	}
+	for busy.Stats().ShedOverTasks < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected 3 shed events, got %+v", busy.Stats())
+		default:
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	if len(busyDown) != 0 {
+		t.Fatalf("the project over budget forwarded %d events", len(busyDown))
+	}
+	if stats := other.Stats(); stats.ShedOverTasks != 0 || stats.ShedOverMemory != 0 {
+		t.Fatalf("the project within budget shed events: %+v", stats)
+	}
+
+	mem := NewProjectBudget(0, 1)
+	big := map[string]interface{}{"payload": string(make([]byte, 2<<20))}
+	if _, ok := mem.tryAcquire(big); ok || mem.Stats().ShedOverMemory != 1 {
+		t.Fatalf("expected an event above max_memory_mb to be shed: %+v", mem.Stats())
+	}
+}
+
+func TestTaskGuard_ChainedRulesetsDoNotDeadlock(t *testing.T) {
+	globalTaskGuard()
+	saved := engineTasks
+	engineTasks = newTaskGuard(1)
+	defer func() { engineTasks = saved }()
+
+	// input -> A -> B -> output, with room for a single event between the rulesets
+	const xml = `<root type="DETECTION" name="chain"><rule id="r1" name="r1"><check type="EQU" field="a">1</check></rule></root>`
+	up := make(chan map[string]interface{}, 200)
+	mid := make(chan map[string]interface{}, 1)
+	down := make(chan map[string]interface{}, 200)
+	for _, stage := range []struct{ in, out *chan map[string]interface{} }{{&up, &mid}, {&mid, &down}} {
+		rs := buildRulesetFromXML(t, xml)
+		rs.UpStream = map[string]*chan map[string]interface{}{"in": stage.in}
+		rs.DownStream = map[string]*chan map[string]interface{}{"out": stage.out}
+		if err := rs.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer rs.Stop()
+	}
+
+	for i := 0; i < 200; i++ {
+		up <- map[string]interface{}{"a": "1"}
+	}
+	deadline := time.After(5 * time.Second)
+	for received := 0; received < 200; received++ {
+		select {
+		case <-down:
+		case <-deadline:
+			t.Fatalf("the chain stalled after %d of 200 events", received)
+		}
+	}
+}
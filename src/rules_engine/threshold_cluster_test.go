@@ -0,0 +1,169 @@
+package rules_engine
+
+import (
+	"sync"
+	"testing"
+)
+
+// sharedCounters stands in for the Redis counters of a cluster, with the semantics of thresholdSumScript
+type sharedCounters struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	ttls   map[string]int
+}
+
+func (c *sharedCounters) sum(key string, value int64, expiration int, threshold int) (bool, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count, ok := c.counts[key]
+	if !ok {
+		c.counts[key] = value
+		c.ttls[key] = expiration
+		return false, value, nil
+	}
+	count += value
+	if count > int64(threshold) {
+		delete(c.counts, key)
+		delete(c.ttls, key)
+		return true, count, nil
+	}
+	c.counts[key] = count
+	return false, count, nil
+}
+
+func TestRedisThreshold_CountsAcrossNodes(t *testing.T) {
+	counters := &sharedCounters{counts: map[string]int64{}, ttls: map[string]int{}}
+	orig := redisThresholdSum
+	redisThresholdSum = counters.sum
+	t.Cleanup(func() { redisThresholdSum = orig })
+
+	const xml = `
+<root type="DETECTION" name="cluster-threshold">
+  <rule id="r1" name="brute force">
+    <check type="EQU" field="action">login_failed</check>
+    <threshold group_by="user,host" range="10m" local_cache="false">5</threshold>
+  </rule>
+</root>`
+	// Two hub nodes running the same ruleset
+	nodes := []*Ruleset{buildRulesetFromXML(t, xml), buildRulesetFromXML(t, xml)}
+
+	fired := 0
+	for i := 0; i < 6; i++ {
+		event := map[string]interface{}{"action": "login_failed", "user": "admin", "host": "db1"}
+		if res := nodes[i%2].EngineCheck(event); len(res) > 0 {
+			if i != 5 {
+				t.Fatalf("threshold fired at event %d, before the combined count exceeded 5", i+1)
+			}
+			fired++
+		}
+	}
+	if fired != 1 {
+		t.Fatalf("expected the sixth event of the cluster to fire once, fired %d times", fired)
+	}
+	if len(counters.counts) != 0 {
+		t.Fatalf("expected the fired counter to be reset, left %v", counters.counts)
+	}
+
+	nodes[0].EngineCheck(map[string]interface{}{"action": "login_failed", "user": "admin", "host": "db1"})
+	for key, ttl := range counters.ttls {
+		if ttl != 600 {
+			t.Fatalf("expected counter %s to expire with the 10m range, got %ds", key, ttl)
+		}
+	}
+}
+
+func TestRedisThreshold_NamespacedCopyKeepsOwnCounters(t *testing.T) {
+	counters := &sharedCounters{counts: map[string]int64{}, ttls: map[string]int{}}
+	orig := redisThresholdSum
+	redisThresholdSum = counters.sum
+	t.Cleanup(func() { redisThresholdSum = orig })
+
+	const xml = `
+<root type="DETECTION" name="replayed-threshold">
+  <rule id="r1" name="brute force">
+    <check type="EQU" field="action">login_failed</check>
+    <threshold group_by="user" range="10m" local_cache="false">2</threshold>
+  </rule>
+</root>`
+	deployed := buildRulesetFromXML(t, xml)
+	replay := buildRulesetFromXML(t, xml)
+	replay.SetStateNamespace("replay_1:")
+
+	event := map[string]interface{}{"action": "login_failed", "user": "admin"}
+	deployed.EngineCheck(event)
+	deployed.EngineCheck(event)
+	for i := 0; i < 2; i++ {
+		if res := replay.EngineCheck(event); len(res) > 0 {
+			t.Fatalf("the copy fired at event %d on the counter of the deployed ruleset", i+1)
+		}
+	}
+	if len(counters.counts) != 2 {
+		t.Fatalf("expected separate counters for the deployed ruleset and the copy, got %v", counters.counts)
+	}
+	if res := deployed.EngineCheck(event); len(res) == 0 {
+		t.Fatal("the deployed ruleset must fire on its own third event")
+	}
+}
+
+// sharedSets stands in for the Redis sets of a cluster, with the semantics of thresholdClassifyScript
+type sharedSets struct {
+	mu   sync.Mutex
+	sets map[string]map[string]struct{}
+}
+
+func (c *sharedSets) classify(key string, value string, expiration int, threshold int) (bool, []string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set, ok := c.sets[key]
+	if !ok {
+		set = make(map[string]struct{})
+		c.sets[key] = set
+	}
+	set[value] = struct{}{}
+	if len(set) <= threshold {
+		return false, nil, nil
+	}
+	delete(c.sets, key)
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	return true, values, nil
+}
+
+func TestRedisClassify_CountsDistinctValuesAcrossNodes(t *testing.T) {
+	sets := &sharedSets{sets: map[string]map[string]struct{}{}}
+	orig := redisThresholdClassify
+	redisThresholdClassify = sets.classify
+	t.Cleanup(func() { redisThresholdClassify = orig })
+
+	const xml = `
+<root type="DETECTION" name="cluster-classify">
+  <rule id="r1" name="port scan">
+    <threshold group_by="src_ip" range="5m" count_type="CLASSIFY" count_field="dst_port" local_cache="false" classify_values_field="ports">2</threshold>
+  </rule>
+</root>`
+	nodes := []*Ruleset{buildRulesetFromXML(t, xml), buildRulesetFromXML(t, xml)}
+
+	// A repeated port does not count, the third distinct port seen across the nodes fires
+	ports := []string{"22", "22", "80", "443"}
+	for i, port := range ports {
+		res := nodes[i%2].EngineCheck(map[string]interface{}{"src_ip": "10.0.0.1", "dst_port": port})
+		if i < len(ports)-1 {
+			if len(res) > 0 {
+				t.Fatalf("threshold fired at event %d, before a third distinct port", i+1)
+			}
+			continue
+		}
+		if len(res) != 1 {
+			t.Fatalf("expected the third distinct port to fire, got %d results", len(res))
+		}
+		got, _ := res[0]["ports"].([]string)
+		if len(got) != 3 || got[0] != "22" || got[1] != "443" || got[2] != "80" {
+			t.Fatalf("expected the sorted distinct ports, got %v", res[0]["ports"])
+		}
+	}
+	if len(sets.sets) != 0 {
+		t.Fatalf("expected the fired set to be deleted, left %v", sets.sets)
+	}
+}
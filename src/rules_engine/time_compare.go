@@ -0,0 +1,130 @@
+package rules_engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckAsTime is the check attribute value as="time", comparing MT/LT operands chronologically
+const CheckAsTime = "time"
+
+// timeLayouts are the absolute timestamp formats MT/LT understand, tried in order
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02",
+}
+
+// parseTimeOperand parses an absolute timestamp or a relative one like now, now-1h or now+2d.
+// With epoch set, plain numbers are read as Unix seconds, or milliseconds above 1e12.
+func parseTimeOperand(s string, now time.Time, epoch bool) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	if strings.HasPrefix(s, "now") {
+		rest := strings.TrimSpace(s[len("now"):])
+		if rest == "" {
+			return now, true
+		}
+		if rest[0] != '-' && rest[0] != '+' {
+			return time.Time{}, false
+		}
+		d, err := parseRelativeDuration(strings.TrimSpace(rest[1:]))
+		if err != nil {
+			return time.Time{}, false
+		}
+		if rest[0] == '-' {
+			d = -d
+		}
+		return now.Add(d), true
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	if epoch {
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			if n > 1e12 {
+				return time.UnixMilli(int64(n)), true
+			}
+			sec := int64(n)
+			return time.Unix(sec, int64((n-float64(sec))*1e9)), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseRelativeDuration accepts Go durations (90s, 1h30m) plus whole days (7d)
+func parseRelativeDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid day count '%s'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+	return d, nil
+}
+
+// compareTimes orders two operands chronologically, ok is false when either is not a time
+func compareTimes(data, ruleData string, epoch bool) (cmp int, ok bool) {
+	now := time.Now()
+	ruleTime, ok := parseTimeOperand(ruleData, now, epoch)
+	if !ok {
+		return 0, false
+	}
+	dataTime, ok := parseTimeOperand(data, now, epoch)
+	if !ok {
+		return 0, false
+	}
+	return dataTime.Compare(ruleTime), true
+}
+
+// MT_TIME is MT with as="time": the field is later than the value
+func MT_TIME(data string, ruleData string) (res bool, hitData string) {
+	if cmp, ok := compareTimes(data, ruleData, true); ok && cmp > 0 {
+		return true, ruleData
+	}
+	return false, ""
+}
+
+// LT_TIME is LT with as="time": the field is earlier than the value
+func LT_TIME(data string, ruleData string) (res bool, hitData string) {
+	if cmp, ok := compareTimes(data, ruleData, true); ok && cmp < 0 {
+		return true, ruleData
+	}
+	return false, ""
+}
+
+// timeCheckValueError validates the static value(s) of an as="time" check, _$ references are
+// resolved at evaluation time
+func timeCheckValueError(node *CheckNodes) error {
+	if node.Type != "MT" && node.Type != "LT" {
+		return fmt.Errorf("as=\"time\" only applies to MT and LT checks, got type '%s'", node.Type)
+	}
+	values := []string{node.Value}
+	if node.Delimiter != "" && strings.Contains(node.Value, node.Delimiter) {
+		values = strings.Split(node.Value, node.Delimiter)
+	}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if hasFromRawPrefix(v) {
+			continue
+		}
+		if _, ok := parseTimeOperand(v, time.Now(), true); !ok {
+			return fmt.Errorf("invalid time value '%s', expected RFC3339 (2024-01-15T08:00:00Z), a date (2024-01-15), epoch seconds/milliseconds or now[+-]duration like now-1h or now-7d", v)
+		}
+	}
+	return nil
+}
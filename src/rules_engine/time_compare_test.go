@@ -0,0 +1,94 @@
+package rules_engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func timeCheckRuleset(t *testing.T, checkType, as, value string) *Ruleset {
+	t.Helper()
+	asAttr := ""
+	if as != "" {
+		asAttr = fmt.Sprintf(` as="%s"`, as)
+	}
+	xml := fmt.Sprintf(`
+<root type="DETECTION" name="time-check">
+  <rule id="r1" name="r1">
+    <check type="%s" field="ts"%s>%s</check>
+  </rule>
+</root>`, checkType, asAttr, value)
+	return buildRulesetFromXML(t, xml)
+}
+
+func TestTimeChecks_RFC3339(t *testing.T) {
+	cases := []struct {
+		name      string
+		checkType string
+		as        string
+		ts        string
+		value     string
+		match     bool
+	}{
+		{"MT/later", "MT", "", "2024-01-15T10:00:00Z", "2024-01-15T08:00:00Z", true},
+		{"MT/earlier", "MT", "", "2024-01-15T06:00:00Z", "2024-01-15T08:00:00Z", false},
+		{"LT/earlier", "LT", "", "2024-01-15T06:00:00Z", "2024-01-15T08:00:00Z", true},
+		{"LT/offset", "LT", "", "2024-01-15T09:00:00+02:00", "2024-01-15T08:00:00Z", true}, // 07:00Z
+		{"MT/as-time", "MT", "time", "2024-01-15T10:00:00Z", "2024-01-15", true},
+		{"LT/not-a-time", "LT", "", "yesterday", "2024-01-15T08:00:00Z", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := timeCheckRuleset(t, tc.checkType, tc.as, tc.value)
+			got := len(rs.EngineCheck(map[string]interface{}{"ts": tc.ts})) == 1
+			if got != tc.match {
+				t.Fatalf("expected match=%v, got %v", tc.match, got)
+			}
+		})
+	}
+}
+
+func TestTimeChecks_RelativeDuration(t *testing.T) {
+	older := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+
+	olderThanHour := timeCheckRuleset(t, "LT", "", "now-1h")
+	if len(olderThanHour.EngineCheck(map[string]interface{}{"ts": older})) != 1 {
+		t.Fatal("event from 2h ago must be older than now-1h")
+	}
+	if len(olderThanHour.EngineCheck(map[string]interface{}{"ts": recent})) != 0 {
+		t.Fatal("event from 10m ago must not be older than now-1h")
+	}
+
+	// as="time" also reads epoch seconds and milliseconds
+	newerThanDay := timeCheckRuleset(t, "MT", "time", "now-1d")
+	epochMs := fmt.Sprint(time.Now().Add(-time.Hour).UnixMilli())
+	if len(newerThanDay.EngineCheck(map[string]interface{}{"ts": epochMs})) != 1 {
+		t.Fatal("epoch milliseconds from 1h ago must be newer than now-1d")
+	}
+	epochSec := fmt.Sprint(time.Now().Add(-48 * time.Hour).Unix())
+	if len(newerThanDay.EngineCheck(map[string]interface{}{"ts": epochSec})) != 0 {
+		t.Fatal("epoch seconds from 48h ago must not be newer than now-1d")
+	}
+
+	// Numbers without as="time" keep comparing numerically
+	numeric := timeCheckRuleset(t, "MT", "", "100")
+	if len(numeric.EngineCheck(map[string]interface{}{"ts": "150"})) != 1 {
+		t.Fatal("numeric MT must still work")
+	}
+}
+
+func TestTimeChecks_InvalidFormat(t *testing.T) {
+	cases := map[string]string{
+		"bad value": `<check type="LT" field="ts" as="time">last tuesday</check>`,
+		"bad as":    `<check type="LT" field="ts" as="date">now-1h</check>`,
+		"bad type":  `<check type="EQU" field="ts" as="time">now-1h</check>`,
+		"bad unit":  `<check type="LT" field="ts" as="time">now-1w</check>`,
+	}
+	for name, check := range cases {
+		xml := `<root type="DETECTION"><rule id="r1">` + check + `</rule></root>`
+		if _, err := ParseRuleset([]byte(xml)); err == nil {
+			t.Fatalf("%s: expected a parse error", name)
+		}
+	}
+}